@@ -7,15 +7,18 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/baldybuilds/creatorsync/internal/config"
 	"github.com/baldybuilds/creatorsync/internal/server"
+	"github.com/baldybuilds/creatorsync/internal/tracing"
 
 	_ "github.com/joho/godotenv/autoload"
 )
 
-func gracefulShutdown(fiberServer *server.FiberServer, done chan bool) {
+func gracefulShutdown(fiberServer *server.FiberServer, shutdownTracing func(context.Context) error, done chan bool) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -23,10 +26,31 @@ func gracefulShutdown(fiberServer *server.FiberServer, done chan bool) {
 
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// In-flight requests and collections drain side by side. Collections
+	// still running after SHUTDOWN_DRAIN_TIMEOUT resume after the restart.
+	ctx, cancel := context.WithTimeout(context.Background(), config.Shutdown().DrainTimeout)
 	defer cancel()
-	if err := fiberServer.ShutdownWithContext(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := fiberServer.ShutdownWithContext(ctx); err != nil {
+			log.Printf("Server forced to shutdown with error: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		fiberServer.DrainCollections(ctx)
+	}()
+	wg.Wait()
+
+	fiberServer.StopBackgroundServices()
+
+	// Flush the spans of the last requests, even when draining took the whole timeout
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
 	}
 
 	log.Println("Server exiting")
@@ -34,12 +58,18 @@ func gracefulShutdown(fiberServer *server.FiberServer, done chan bool) {
 }
 
 func main() {
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	server, err := server.New()
 	if err != nil {
 		log.Fatalf("Failed to initialize server: %v", err)
 	}
 
 	server.RegisterFiberRoutes()
+	server.StartBackgroundServices(context.Background())
 
 	done := make(chan bool, 1)
 
@@ -51,7 +81,7 @@ func main() {
 		}
 	}()
 
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, shutdownTracing, done)
 
 	<-done
 	log.Println("Graceful shutdown complete.")
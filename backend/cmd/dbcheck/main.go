@@ -0,0 +1,65 @@
+// Command dbcheck verifies the database after a backup restore or a
+// migration, e.g. nightly against a restored backup. It counts the rows of
+// every table against the optional -expect file, finds rows whose user no
+// longer exists and decrypts every stored OAuth token with the configured keys.
+//
+// The report is printed as JSON on stdout. The command exits with status 1
+// when it finds problems and 2 when the database could not be checked.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/baldybuilds/creatorsync/internal/database"
+	"github.com/baldybuilds/creatorsync/internal/dbcheck"
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	expectPath := flag.String("expect", "", `JSON file of row count bounds per table, e.g. {"users": {"min": 1}}`)
+	skipTokens := flag.Bool("skip-tokens", false, "don't decrypt stored tokens, e.g. where the encryption keys aren't available")
+	flag.Parse()
+
+	var expectations dbcheck.Expectations
+	if *expectPath != "" {
+		var err error
+		if expectations, err = dbcheck.LoadExpectations(*expectPath); err != nil {
+			log.Printf("%v", err)
+			os.Exit(2)
+		}
+	}
+
+	var keyring *tokencrypt.Keyring
+	if !*skipTokens {
+		var err error
+		if keyring, err = tokencrypt.FromEnv(); err != nil {
+			log.Printf("Failed to load token encryption keys (use -skip-tokens to check without them): %v", err)
+			os.Exit(2)
+		}
+	}
+
+	db := database.New()
+	defer db.Close()
+
+	report, err := dbcheck.NewChecker(db.GetDB(), expectations, keyring).Run(context.Background())
+	if err != nil {
+		log.Printf("Database check failed: %v", err)
+		os.Exit(2)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("Failed to write report: %v", err)
+		os.Exit(2)
+	}
+	if !report.OK {
+		log.Printf("Found %d problems", len(report.Problems))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+// Command debug diagnoses a deployment. With -check it verifies the
+// configuration, the database connection and schema, the Clerk secret key and
+// the Twitch app credentials, e.g. as a container health gate before starting
+// the API:
+//
+//	debug -check && api
+//
+// The report is printed as JSON on stdout. The command exits with status 1
+// when a check fails and 2 on invalid usage.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/selfcheck"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	check := flag.Bool("check", false, "verify config, database, schema, Clerk and Twitch, and exit non-zero if any fails")
+	timeout := flag.Duration("timeout", 15*time.Second, "timeout of each check")
+	flag.Parse()
+
+	if !*check {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	checks, closeChecks := selfcheck.Default()
+	report := selfcheck.Run(context.Background(), checks, *timeout)
+	closeChecks()
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("Failed to write report: %v", err)
+		os.Exit(1)
+	}
+	if !report.OK {
+		for _, result := range report.Checks {
+			if result.Status != selfcheck.StatusOK {
+				log.Printf("Check %s %s: %s", result.Name, result.Status, result.Error)
+			}
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,113 @@
+// Command loadtest replays dashboard traffic against an environment and fails
+// when latency or errors exceed the performance budgets, e.g. before a release
+// against staging. Workers sign in as -users synthetic users in turn and load
+// /overview, /enhanced and /charts like the dashboard does.
+//
+// Tokens are signed with -key, so the target must verify sessions against its
+// public key: run with -print-jwks and serve the output at the target's
+// CLERK_JWKS_URL. Use -user-ids to load seeded accounts with realistic data.
+//
+// The report is printed as JSON on stdout. The command exits with status 1
+// when a budget is exceeded and 2 when the test could not run.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/loadtest"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	target := flag.String("target", "", "base URL of the API, e.g. https://staging.creatorsync.app")
+	keyPath := flag.String("key", "", "PEM file of the RSA key tokens are signed with")
+	keyID := flag.String("kid", "loadtest", "key ID of the signing key in the JWKS")
+	printJWKS := flag.Bool("print-jwks", false, "print the JWKS of the signing key and exit")
+	users := flag.Int("users", 50, "number of synthetic users")
+	userPrefix := flag.String("user-prefix", "user_loadtest_", "prefix of the synthetic user IDs")
+	userIDs := flag.String("user-ids", "", "comma separated user IDs to sign in as instead of synthetic users")
+	concurrency := flag.Int("concurrency", 10, "number of concurrent workers")
+	duration := flag.Duration("duration", time.Minute, "how long to send traffic")
+	think := flag.Duration("think", 0, "pause between a user's requests")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of each request")
+	p95 := flag.Duration("p95", 500*time.Millisecond, "p95 latency budget of each endpoint")
+	p99 := flag.Duration("p99", 1500*time.Millisecond, "p99 latency budget of each endpoint")
+	maxErrorRate := flag.Float64("max-error-rate", 0.01, "share of requests of each endpoint that may fail")
+	flag.Parse()
+
+	if *keyPath == "" {
+		log.Printf("-key is required")
+		os.Exit(2)
+	}
+	signer, err := loadtest.LoadSigner(*keyPath, *keyID)
+	if err != nil {
+		log.Printf("%v", err)
+		os.Exit(2)
+	}
+
+	if *printJWKS {
+		jwks, err := signer.JWKS()
+		if err != nil {
+			log.Printf("Failed to encode JWKS: %v", err)
+			os.Exit(2)
+		}
+		fmt.Println(string(jwks))
+		return
+	}
+	if *target == "" {
+		log.Printf("-target is required")
+		os.Exit(2)
+	}
+
+	cfg := loadtest.Config{
+		Target:      *target,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		ThinkTime:   *think,
+		Budget:      loadtest.Budget{P95: *p95, P99: *p99, MaxErrorRate: *maxErrorRate},
+		Client:      &http.Client{Timeout: *timeout},
+	}
+	if *userIDs != "" {
+		for _, id := range strings.Split(*userIDs, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				cfg.Users = append(cfg.Users, id)
+			}
+		}
+	} else {
+		for i := 1; i <= *users; i++ {
+			cfg.Users = append(cfg.Users, fmt.Sprintf("%s%d", *userPrefix, i))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Sending dashboard traffic to %s for %s as %d users with %d workers", cfg.Target, cfg.Duration, len(cfg.Users), cfg.Concurrency)
+	report, err := loadtest.Run(ctx, cfg, signer)
+	if err != nil {
+		log.Printf("Load test failed: %v", err)
+		os.Exit(2)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("Failed to write report: %v", err)
+		os.Exit(2)
+	}
+	if !report.OK {
+		for _, violation := range report.Violations {
+			log.Printf("Budget exceeded: %s", violation)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,44 @@
+// Command reconcile finds Twitch data left behind by disconnects and account
+// switches whose cleanup never finished: analytics, EventSub subscriptions and
+// collection retries of users who no longer have a Twitch account. The
+// analytics are archived the way a disconnect would archive them (without a
+// Twitch account to restore them to, so they are purged after
+// DISCONNECT_RESTORE_WINDOW) and cleanup jobs left running are marked failed.
+//
+// Run it with -dry-run first to list the affected users.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/database"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only list users with orphaned Twitch data")
+	flag.Parse()
+
+	db := database.New()
+	defer db.Close()
+
+	repo := analytics.NewRepository(db.GetDB())
+	result, err := analytics.ReconcileTwitchData(context.Background(), repo, *dryRun)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if *dryRun {
+		log.Printf("%d users have orphaned Twitch data: %v", len(result.Orphaned), result.Orphaned)
+		return
+	}
+
+	log.Printf("Cleaned up %d of %d users with orphaned Twitch data (%d rows archived, %d failed), %d interrupted cleanup jobs marked failed",
+		result.Cleaned, len(result.Orphaned), result.ArchivedRows, result.Failed, result.Interrupted)
+	if result.Failed > 0 {
+		log.Fatalf("Some users could not be cleaned up; run the command again")
+	}
+}
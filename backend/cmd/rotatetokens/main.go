@@ -0,0 +1,59 @@
+// Command rotatetokens re-encrypts the stored OAuth tokens of every provider
+// (Twitch, Patreon, ...; see analytics.TokenTables) with the current encryption
+// key (TWITCH_TOKEN_ENCRYPTION_KEY_ID).
+//
+// To rotate: add the new key to TWITCH_TOKEN_ENCRYPTION_KEYS, point
+// TWITCH_TOKEN_ENCRYPTION_KEY_ID at it and deploy, run this command, then
+// remove the old key once it reports no failures.
+//
+// The same steps move tokens onto KMS envelope encryption: set
+// TOKEN_ENCRYPTION_PROVIDER and TOKEN_KMS_KEY instead of a new local key.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/database"
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count tokens that are not on the current key")
+	flag.Parse()
+
+	keyring, err := tokencrypt.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load token encryption keys: %v", err)
+	}
+
+	db := database.New()
+	defer db.Close()
+
+	repo := analytics.NewRepository(db.GetDB())
+	result, err := analytics.ReencryptTokens(context.Background(), repo, keyring, *dryRun)
+	if err != nil {
+		log.Fatalf("Token re-encryption failed: %v", err)
+	}
+
+	if *dryRun {
+		for _, table := range result.Tables {
+			log.Printf("%s: %d tokens are not encrypted with key %q", table.Table, table.Checked, result.CurrentKeyID)
+		}
+		log.Printf("%d tokens in total are not encrypted with key %q", result.Checked, result.CurrentKeyID)
+		return
+	}
+
+	for _, table := range result.Tables {
+		log.Printf("%s: re-encrypted %d of %d tokens (%d changed during the run, %d failed)",
+			table.Table, table.Reencrypted, table.Checked, table.Skipped, table.Failed)
+	}
+	log.Printf("Re-encrypted %d of %d tokens with key %q (%d changed during the run, %d failed)",
+		result.Reencrypted, result.Checked, result.CurrentKeyID, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		log.Fatalf("Some tokens could not be decrypted; keep their keys configured")
+	}
+}
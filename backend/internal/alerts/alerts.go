@@ -0,0 +1,399 @@
+// Package alerts evaluates user-defined alert rules and simple anomaly
+// detection against collected analytics after each collection, storing in-app
+// alerts and optionally pushing them to a webhook or email.
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+)
+
+// Rule types
+const (
+	RuleFollowerDrop = "follower_drop"
+	RuleViewsSpike   = "views_spike"
+	RuleStreamMissed = "stream_missed"
+	RuleAnomaly      = "anomaly"
+)
+
+// AlertCollectionChanges is the type of the alerts summarising what a
+// collection changed. They don't come from a rule.
+const AlertCollectionChanges = "collection_changes"
+
+// Metrics anomaly rules can watch
+const (
+	MetricFollowersGained = "followers_gained"
+	MetricViewsGained     = "views_gained"
+	MetricSubscribers     = "subscribers"
+)
+
+const (
+	// minAnomalyPoints is the fewest prior days needed before anomalies are reported
+	minAnomalyPoints = 7
+	// maxWindowDays bounds how much history a rule can look at
+	maxWindowDays = 90
+)
+
+// Rule is a user-defined alert condition. What Threshold means depends on the
+// type: a percentage for follower_drop and views_spike, a number of days for
+// stream_missed and a z-score for anomaly.
+type Rule struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      string    `json:"-" db:"user_id"`
+	Type        string    `json:"type" db:"type"`
+	Metric      string    `json:"metric" db:"metric"`
+	Threshold   float64   `json:"threshold" db:"threshold"`
+	WindowDays  int       `json:"window_days" db:"window_days"`
+	Enabled     bool      `json:"enabled" db:"enabled"`
+	WebhookURL  string    `json:"webhook_url" db:"webhook_url"`
+	NotifyEmail bool      `json:"notify_email" db:"notify_email"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Alert is a triggered rule shown in the app
+type Alert struct {
+	ID      int    `json:"id" db:"id"`
+	UserID  string `json:"-" db:"user_id"`
+	RuleID  *int   `json:"rule_id" db:"rule_id"`
+	Type    string `json:"type" db:"type"`
+	Metric  string `json:"metric" db:"metric"`
+	Message string `json:"message" db:"message"`
+	// Value is what was measured and Expected the baseline it was compared with
+	Value     float64    `json:"value" db:"value"`
+	Expected  float64    `json:"expected" db:"expected"`
+	DedupeKey string     `json:"-" db:"dedupe_key"`
+	ReadAt    *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Normalize fills in default thresholds and windows and validates the rule
+func (r *Rule) Normalize() error {
+	r.WebhookURL = strings.TrimSpace(r.WebhookURL)
+
+	var threshold float64
+	var window int
+	switch r.Type {
+	case RuleFollowerDrop:
+		threshold, window = 5, 1
+	case RuleViewsSpike:
+		threshold, window = 100, 7
+	case RuleStreamMissed:
+		threshold, window = 7, 0
+	case RuleAnomaly:
+		threshold, window = 3, 14
+		if r.Metric == "" {
+			r.Metric = MetricFollowersGained
+		}
+		if r.Metric != MetricFollowersGained && r.Metric != MetricViewsGained && r.Metric != MetricSubscribers {
+			return fmt.Errorf("unknown metric %q (use %s, %s or %s)", r.Metric, MetricFollowersGained, MetricViewsGained, MetricSubscribers)
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q (use %s, %s, %s or %s)", r.Type, RuleFollowerDrop, RuleViewsSpike, RuleStreamMissed, RuleAnomaly)
+	}
+	if r.Type != RuleAnomaly {
+		r.Metric = ""
+	}
+
+	if r.Threshold == 0 {
+		r.Threshold = threshold
+	}
+	if r.Threshold < 0 || math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0) {
+		return fmt.Errorf("threshold must be positive")
+	}
+
+	// stream_missed counts days since the last stream and has no window
+	if r.Type == RuleStreamMissed {
+		r.WindowDays = 0
+	} else {
+		if r.WindowDays == 0 {
+			r.WindowDays = window
+		}
+		if r.WindowDays < 1 || r.WindowDays > maxWindowDays {
+			return fmt.Errorf("window_days must be between 1 and %d", maxWindowDays)
+		}
+	}
+
+	if r.WebhookURL != "" {
+		u, err := url.Parse(r.WebhookURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || len(r.WebhookURL) > 2048 {
+			return fmt.Errorf("webhook_url must be an https URL")
+		}
+	}
+
+	return nil
+}
+
+// Inputs is the data rules are evaluated against
+type Inputs struct {
+	// History is daily channel analytics, oldest first
+	History []analytics.ChannelAnalytics
+	// LastStreamAt is when the channel last went live, nil if unknown
+	LastStreamAt *time.Time
+	Now          time.Time
+	// Locale is the language alert messages are written in
+	Locale string
+}
+
+// Evaluate returns the alert a rule raises for the inputs, or nil
+func Evaluate(rule Rule, in Inputs) *Alert {
+	var alert *Alert
+	switch rule.Type {
+	case RuleFollowerDrop:
+		alert = evaluateFollowerDrop(rule, in.History, in.Locale)
+	case RuleViewsSpike:
+		alert = evaluateViewsSpike(rule, in.History, in.Locale)
+	case RuleStreamMissed:
+		alert = evaluateStreamMissed(rule, in.LastStreamAt, in.Now, in.Locale)
+	case RuleAnomaly:
+		alert = evaluateAnomaly(rule, in.History, in.Locale)
+	}
+	if alert == nil {
+		return nil
+	}
+
+	ruleID := rule.ID
+	alert.UserID = rule.UserID
+	alert.RuleID = &ruleID
+	alert.Type = rule.Type
+	alert.Metric = rule.Metric
+	return alert
+}
+
+// evaluateFollowerDrop compares the latest follower count with the count
+// window days earlier
+func evaluateFollowerDrop(rule Rule, history []analytics.ChannelAnalytics, locale string) *Alert {
+	if len(history) < 2 {
+		return nil
+	}
+	latest := history[len(history)-1]
+
+	cutoff := latest.Date.AddDate(0, 0, -rule.WindowDays)
+	var base *analytics.ChannelAnalytics
+	for i := len(history) - 2; i >= 0; i-- {
+		if !history[i].Date.After(cutoff) {
+			base = &history[i]
+			break
+		}
+	}
+	if base == nil || base.FollowersCount <= 0 {
+		return nil
+	}
+
+	drop := float64(base.FollowersCount-latest.FollowersCount) / float64(base.FollowersCount) * 100
+	if drop < rule.Threshold {
+		return nil
+	}
+
+	return &Alert{
+		Message: i18n.T(locale, "Followers dropped %.1f%% in %s (%d to %d)",
+			drop, pluralDays(locale, rule.WindowDays), base.FollowersCount, latest.FollowersCount),
+		Value:     float64(latest.FollowersCount),
+		Expected:  float64(base.FollowersCount),
+		DedupeKey: dedupeKey(rule, latest.Date),
+	}
+}
+
+// evaluateViewsSpike compares the latest daily view gain with the average
+// gain over the window before it
+func evaluateViewsSpike(rule Rule, history []analytics.ChannelAnalytics, locale string) *Alert {
+	gains := dailyGains(collectedSnapshots(history), func(a analytics.ChannelAnalytics) int { return a.TotalViews })
+	if len(gains) < 2 {
+		return nil
+	}
+
+	latest := gains[len(gains)-1]
+	mean, _ := meanStdDev(trailing(gains[:len(gains)-1], rule.WindowDays))
+	if mean <= 0 {
+		return nil
+	}
+
+	increase := (latest - mean) / mean * 100
+	if increase < rule.Threshold {
+		return nil
+	}
+
+	return &Alert{
+		Message: i18n.T(locale, "Views spiked %.0f%% above the %d-day average (%.0f vs %.0f per day)",
+			increase, rule.WindowDays, latest, mean),
+		Value:     latest,
+		Expected:  mean,
+		DedupeKey: dedupeKey(rule, history[len(history)-1].Date),
+	}
+}
+
+// evaluateStreamMissed fires once per gap when the channel hasn't been live
+// for threshold days
+func evaluateStreamMissed(rule Rule, lastStreamAt *time.Time, now time.Time, locale string) *Alert {
+	if lastStreamAt == nil {
+		return nil
+	}
+
+	days := now.Sub(*lastStreamAt).Hours() / 24
+	if days < rule.Threshold {
+		return nil
+	}
+
+	return &Alert{
+		Message:   i18n.T(locale, "No stream for %.0f days (last live %s)", math.Floor(days), lastStreamAt.Format(i18n.T(locale, "Jan 2"))),
+		Value:     math.Floor(days),
+		Expected:  rule.Threshold,
+		DedupeKey: dedupeKey(rule, *lastStreamAt),
+	}
+}
+
+// evaluateAnomaly flags the latest value of the metric when it is more than
+// threshold standard deviations from the window before it
+func evaluateAnomaly(rule Rule, history []analytics.ChannelAnalytics, locale string) *Alert {
+	var series []float64
+	var label string
+	switch rule.Metric {
+	case MetricFollowersGained:
+		series = dailyGains(history, func(a analytics.ChannelAnalytics) int { return a.FollowersCount })
+		label = "Followers gained"
+	case MetricViewsGained:
+		series = dailyGains(collectedSnapshots(history), func(a analytics.ChannelAnalytics) int { return a.TotalViews })
+		label = "Views gained"
+	case MetricSubscribers:
+		for _, day := range collectedSnapshots(history) {
+			series = append(series, float64(day.SubscriberCount))
+		}
+		label = "Subscribers"
+	}
+	if len(series) < minAnomalyPoints+1 {
+		return nil
+	}
+
+	latest := series[len(series)-1]
+	prior := trailing(series[:len(series)-1], rule.WindowDays)
+	if len(prior) < minAnomalyPoints {
+		return nil
+	}
+
+	mean, stdDev := meanStdDev(prior)
+	if stdDev == 0 {
+		return nil
+	}
+	z := (latest - mean) / stdDev
+	if math.Abs(z) < rule.Threshold {
+		return nil
+	}
+
+	// Separate sentences so translations can agree with the direction
+	format := "%s was unusually high: %.0f vs a typical %.0f (%.1f standard deviations)"
+	if z < 0 {
+		format = "%s was unusually low: %.0f vs a typical %.0f (%.1f standard deviations)"
+	}
+	return &Alert{
+		Message:   i18n.T(locale, format, i18n.T(locale, label), latest, mean, math.Abs(z)),
+		Value:     latest,
+		Expected:  mean,
+		DedupeKey: dedupeKey(rule, history[len(history)-1].Date),
+	}
+}
+
+// ChangesAlert summarises what a collection changed as an in-app alert, or
+// returns nil when nothing the user would notice changed
+func ChangesAlert(userID string, changes *analytics.ChangeSummary, locale string) *Alert {
+	var parts []string
+	switch {
+	case changes.NewVideoCount == 1:
+		parts = append(parts, i18n.T(locale, "1 new video"))
+	case changes.NewVideoCount > 1:
+		parts = append(parts, i18n.T(locale, "%d new videos", changes.NewVideoCount))
+	}
+	switch {
+	case changes.FollowersChange == 1 || changes.FollowersChange == -1:
+		parts = append(parts, i18n.T(locale, "%+d follower", changes.FollowersChange))
+	case changes.FollowersChange != 0:
+		parts = append(parts, i18n.T(locale, "%+d followers", changes.FollowersChange))
+	}
+	switch {
+	case changes.SubscribersChange == 1 || changes.SubscribersChange == -1:
+		parts = append(parts, i18n.T(locale, "%+d subscriber", changes.SubscribersChange))
+	case changes.SubscribersChange != 0:
+		parts = append(parts, i18n.T(locale, "%+d subscribers", changes.SubscribersChange))
+	}
+	switch {
+	case changes.VideosGainingViews == 1:
+		parts = append(parts, i18n.T(locale, "1 video gained views"))
+	case changes.VideosGainingViews > 1:
+		parts = append(parts, i18n.T(locale, "%d videos gained views", changes.VideosGainingViews))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+
+	return &Alert{
+		UserID:    userID,
+		Type:      AlertCollectionChanges,
+		Message:   i18n.T(locale, "Since the last collection: %s", strings.Join(parts, ", ")),
+		Value:     float64(changes.FollowersChange),
+		DedupeKey: fmt.Sprintf("%s:%d", AlertCollectionChanges, changes.JobID),
+	}
+}
+
+// dailyGains turns a running total into day-over-day changes
+func dailyGains(history []analytics.ChannelAnalytics, value func(analytics.ChannelAnalytics) int) []float64 {
+	if len(history) < 2 {
+		return nil
+	}
+	gains := make([]float64, 0, len(history)-1)
+	for i := 1; i < len(history); i++ {
+		gains = append(gains, float64(value(history[i])-value(history[i-1])))
+	}
+	return gains
+}
+
+// collectedSnapshots leaves out the backfilled and imported snapshots, whose
+// subscriber and view counts are unknown
+func collectedSnapshots(history []analytics.ChannelAnalytics) []analytics.ChannelAnalytics {
+	var collected []analytics.ChannelAnalytics
+	for _, day := range history {
+		if !day.Synthetic() {
+			collected = append(collected, day)
+		}
+	}
+	return collected
+}
+
+// trailing returns the last n values
+func trailing(values []float64, n int) []float64 {
+	if len(values) > n {
+		return values[len(values)-n:]
+	}
+	return values
+}
+
+func meanStdDev(values []float64) (mean, stdDev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	for _, v := range values {
+		stdDev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stdDev / float64(len(values)))
+}
+
+// dedupeKey makes a rule fire at most once for the same day of data
+func dedupeKey(rule Rule, date time.Time) string {
+	return fmt.Sprintf("%s:%d:%s", rule.Type, rule.ID, date.Format("2006-01-02"))
+}
+
+func pluralDays(locale string, n int) string {
+	if n == 1 {
+		return i18n.T(locale, "1 day")
+	}
+	return i18n.T(locale, "%d days", n)
+}
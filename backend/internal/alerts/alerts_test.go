@@ -0,0 +1,120 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+func history(start time.Time, followers, views []int) []analytics.ChannelAnalytics {
+	rows := make([]analytics.ChannelAnalytics, len(followers))
+	for i := range followers {
+		rows[i] = analytics.ChannelAnalytics{
+			Date:           start.AddDate(0, 0, i),
+			FollowersCount: followers[i],
+			TotalViews:     views[i],
+		}
+	}
+	return rows
+}
+
+func TestRuleNormalize(t *testing.T) {
+	rule := Rule{Type: RuleAnomaly}
+	require.NoError(t, rule.Normalize())
+	assert.Equal(t, MetricFollowersGained, rule.Metric)
+	assert.Equal(t, 3.0, rule.Threshold)
+	assert.Equal(t, 14, rule.WindowDays)
+
+	rule = Rule{Type: RuleStreamMissed, Metric: MetricSubscribers, WindowDays: 5}
+	require.NoError(t, rule.Normalize())
+	assert.Empty(t, rule.Metric)
+	assert.Zero(t, rule.WindowDays)
+
+	assert.Error(t, (&Rule{Type: "unknown"}).Normalize())
+	assert.Error(t, (&Rule{Type: RuleFollowerDrop, WindowDays: 365}).Normalize())
+	assert.Error(t, (&Rule{Type: RuleFollowerDrop, WebhookURL: "http://example.com/hook"}).Normalize())
+}
+
+func TestEvaluateFollowerDrop(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	rule := Rule{ID: 7, UserID: "user_1", Type: RuleFollowerDrop, Threshold: 5, WindowDays: 1}
+
+	alert := Evaluate(rule, Inputs{History: history(start, []int{1000, 940}, []int{0, 0})})
+	require.NotNil(t, alert)
+	assert.Equal(t, 940.0, alert.Value)
+	assert.Equal(t, 1000.0, alert.Expected)
+	assert.Equal(t, "follower_drop:7:2024-05-02", alert.DedupeKey)
+	assert.Equal(t, 7, *alert.RuleID)
+
+	assert.Nil(t, Evaluate(rule, Inputs{History: history(start, []int{1000, 990}, []int{0, 0})}))
+}
+
+func TestEvaluateViewsSpike(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	rule := Rule{Type: RuleViewsSpike, Threshold: 100, WindowDays: 7}
+
+	// 100 views a day, then 300
+	alert := Evaluate(rule, Inputs{History: history(start, []int{0, 0, 0, 0}, []int{0, 100, 200, 500})})
+	require.NotNil(t, alert)
+	assert.Equal(t, 300.0, alert.Value)
+	assert.Equal(t, 100.0, alert.Expected)
+
+	assert.Nil(t, Evaluate(rule, Inputs{History: history(start, []int{0, 0, 0, 0}, []int{0, 100, 200, 350})}))
+
+	// An imported day has no views and doesn't hide the spike
+	imported := history(start, []int{0, 0, 0, 0, 0}, []int{0, 100, 200, 0, 600})
+	imported[3].Source = analytics.ChannelSourceImport
+	alert = Evaluate(rule, Inputs{History: imported})
+	require.NotNil(t, alert)
+	assert.Equal(t, 400.0, alert.Value)
+	assert.Equal(t, 100.0, alert.Expected)
+}
+
+func TestEvaluateStreamMissed(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	rule := Rule{Type: RuleStreamMissed, Threshold: 7}
+
+	last := now.AddDate(0, 0, -10)
+	alert := Evaluate(rule, Inputs{LastStreamAt: &last, Now: now})
+	require.NotNil(t, alert)
+	assert.Equal(t, 10.0, alert.Value)
+
+	recent := now.AddDate(0, 0, -2)
+	assert.Nil(t, Evaluate(rule, Inputs{LastStreamAt: &recent, Now: now}))
+	assert.Nil(t, Evaluate(rule, Inputs{Now: now}))
+}
+
+func TestEvaluateAnomaly(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	rule := Rule{Type: RuleAnomaly, Metric: MetricFollowersGained, Threshold: 3, WindowDays: 14}
+
+	// Roughly 10 followers a day, then 60
+	followers := []int{0, 10, 19, 30, 40, 49, 60, 70, 79, 90, 150}
+	views := make([]int, len(followers))
+	alert := Evaluate(rule, Inputs{History: history(start, followers, views)})
+	require.NotNil(t, alert)
+	assert.Equal(t, 60.0, alert.Value)
+	assert.Contains(t, alert.Message, "unusually high")
+
+	// Too little history
+	assert.Nil(t, Evaluate(rule, Inputs{History: history(start, followers[:5], views[:5])}))
+}
+
+func TestChangesAlert(t *testing.T) {
+	changes := &analytics.ChangeSummary{JobID: 42, NewVideoCount: 2, FollowersChange: 1, VideosGainingViews: 3}
+	alert := ChangesAlert("user_1", changes, "en")
+	require.NotNil(t, alert)
+	assert.Equal(t, AlertCollectionChanges, alert.Type)
+	assert.Equal(t, "Since the last collection: 2 new videos, +1 follower, 3 videos gained views", alert.Message)
+	assert.Equal(t, "collection_changes:42", alert.DedupeKey)
+
+	alert = ChangesAlert("user_1", &analytics.ChangeSummary{JobID: 43, SubscribersChange: -2}, "de")
+	require.NotNil(t, alert)
+	assert.Equal(t, "Seit der letzten Datenerfassung: -2 Abonnenten", alert.Message)
+
+	assert.Nil(t, ChangesAlert("user_1", &analytics.ChangeSummary{ViewsChange: 100}, "en"))
+}
@@ -0,0 +1,284 @@
+package alerts
+
+import (
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRulesPerUser bounds how many alert rules a user can define
+const maxRulesPerUser = 20
+
+type Handlers struct {
+	repo Repository
+}
+
+func NewHandlers(repo Repository) *Handlers {
+	return &Handlers{
+		repo: repo,
+	}
+}
+
+// RegisterRoutes registers alert routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	alerts := router.Group("/alerts")
+	alerts.Get("/", h.ListAlerts)
+	alerts.Post("/:id/read", h.MarkRead)
+
+	alerts.Get("/rules", h.ListRules)
+	alerts.Post("/rules", h.CreateRule)
+	alerts.Put("/rules/:id", h.UpdateRule)
+	alerts.Delete("/rules/:id", h.DeleteRule)
+}
+
+// ListAlerts returns the user's alerts, newest first; ?unread=true hides read ones
+func (h *Handlers) ListAlerts(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	alerts, err := h.repo.ListAlerts(c.UserContext(), user.ID, c.QueryBool("unread", false), limit)
+	if err != nil {
+		log.Printf("Error listing alerts for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list alerts",
+		})
+	}
+
+	unread, err := h.repo.CountUnreadAlerts(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error counting unread alerts for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list alerts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"alerts": alerts,
+		"unread": unread,
+	})
+}
+
+// MarkRead marks one of the user's alerts as read
+func (h *Handlers) MarkRead(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	alertID, err := c.ParamsInt("id")
+	if err != nil || alertID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid alert ID",
+		})
+	}
+
+	found, err := h.repo.MarkAlertRead(c.UserContext(), user.ID, alertID)
+	if err != nil {
+		log.Printf("Error marking alert %d read for user %s: %v", alertID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update alert",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Alert not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Alert marked as read",
+		"id":      alertID,
+	})
+}
+
+// ListRules returns the user's alert rules
+func (h *Handlers) ListRules(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	rules, err := h.repo.ListRules(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing alert rules for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list alert rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+// ruleRequest is the body for creating or updating a rule. Zero threshold and
+// window_days use the type's defaults.
+type ruleRequest struct {
+	Type        string  `json:"type"`
+	Metric      string  `json:"metric"`
+	Threshold   float64 `json:"threshold"`
+	WindowDays  int     `json:"window_days"`
+	Enabled     *bool   `json:"enabled"`
+	WebhookURL  string  `json:"webhook_url"`
+	NotifyEmail bool    `json:"notify_email"`
+}
+
+func (req ruleRequest) rule(userID string) (*Rule, error) {
+	rule := &Rule{
+		UserID:      userID,
+		Type:        req.Type,
+		Metric:      req.Metric,
+		Threshold:   req.Threshold,
+		WindowDays:  req.WindowDays,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		WebhookURL:  req.WebhookURL,
+		NotifyEmail: req.NotifyEmail,
+	}
+	return rule, rule.Normalize()
+}
+
+// CreateRule adds an alert rule
+func (h *Handlers) CreateRule(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req ruleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := req.rule(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	existing, err := h.repo.ListRules(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing alert rules for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create alert rule",
+		})
+	}
+	if len(existing) >= maxRulesPerUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Alert rule limit reached, delete an existing rule first",
+		})
+	}
+
+	if err := h.repo.CreateRule(c.UserContext(), rule); err != nil {
+		log.Printf("Error creating alert rule for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create alert rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"rule": rule,
+	})
+}
+
+// UpdateRule replaces one of the user's alert rules
+func (h *Handlers) UpdateRule(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	ruleID, err := c.ParamsInt("id")
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	var req ruleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := req.rule(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	rule.ID = ruleID
+
+	found, err := h.repo.UpdateRule(c.UserContext(), rule)
+	if err != nil {
+		log.Printf("Error updating alert rule %d for user %s: %v", ruleID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update alert rule",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Alert rule not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"rule": rule,
+	})
+}
+
+// DeleteRule deletes one of the user's alert rules; past alerts are kept
+func (h *Handlers) DeleteRule(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	ruleID, err := c.ParamsInt("id")
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	found, err := h.repo.DeleteRule(c.UserContext(), user.ID, ruleID)
+	if err != nil {
+		log.Printf("Error deleting alert rule %d for user %s: %v", ruleID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete alert rule",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Alert rule not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Alert rule deleted",
+		"id":      ruleID,
+	})
+}
@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/email"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+)
+
+// Notifier pushes alerts to webhooks and email
+type Notifier struct {
+	httpClient *http.Client
+	email      *email.ResendClient
+	emailFrom  string
+}
+
+// NewNotifier creates a notifier. Email is skipped when Resend isn't configured.
+func NewNotifier(cfg config.AlertsConfig, emailClient *email.ResendClient) *Notifier {
+	return &Notifier{
+		httpClient: &http.Client{Timeout: cfg.WebhookTimeout},
+		email:      emailClient,
+		emailFrom:  cfg.EmailFrom,
+	}
+}
+
+// webhookPayload carries the alert plus "text"/"content" so Slack and Discord
+// incoming webhooks can display it as-is
+type webhookPayload struct {
+	Event   string `json:"event"`
+	Alert   *Alert `json:"alert"`
+	Text    string `json:"text"`
+	Content string `json:"content"`
+}
+
+// SendWebhook posts the alert as JSON to the URL
+func (n *Notifier) SendWebhook(ctx context.Context, webhookURL string, alert *Alert) error {
+	body, err := json.Marshal(webhookPayload{
+		Event:   "alert.triggered",
+		Alert:   alert,
+		Text:    alert.Message,
+		Content: alert.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CreatorSync-Alerts/1.0")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SendEmail emails the alert to the address, in the locale
+func (n *Notifier) SendEmail(to, locale string, alert *Alert) error {
+	if n.email == nil {
+		return fmt.Errorf("email is not configured")
+	}
+
+	return n.email.Send(email.EmailRequest{
+		From:    n.emailFrom,
+		To:      []string{to},
+		Subject: i18n.T(locale, "CreatorSync alert: %s", alert.Message),
+		HTML: fmt.Sprintf(`
+			<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+				<h1 style="color: #6366f1;">%s</h1>
+				<p>%s</p>
+				<p style="color: #6b7280;">%s</p>
+			</div>
+		`, html.EscapeString(i18n.T(locale, "CreatorSync alert")), html.EscapeString(alert.Message),
+			html.EscapeString(i18n.T(locale, "You can change or turn off this alert in your CreatorSync settings."))),
+	})
+}
@@ -0,0 +1,139 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListRules(ctx context.Context, userID string) ([]Rule, error)
+	GetEnabledRules(ctx context.Context, userID string) ([]Rule, error)
+	CreateRule(ctx context.Context, rule *Rule) error
+	UpdateRule(ctx context.Context, rule *Rule) (bool, error)
+	DeleteRule(ctx context.Context, userID string, ruleID int) (bool, error)
+
+	CreateAlert(ctx context.Context, alert *Alert) (bool, error)
+	ListAlerts(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Alert, error)
+	CountUnreadAlerts(ctx context.Context, userID string) (int, error)
+	MarkAlertRead(ctx context.Context, userID string, alertID int) (bool, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const ruleColumns = `id, user_id, type, metric, threshold, window_days, enabled, webhook_url, notify_email, created_at, updated_at`
+
+func (r *repository) ListRules(ctx context.Context, userID string) ([]Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM alert_rules WHERE user_id = $1 ORDER BY created_at`
+
+	rules := []Rule{}
+	err := r.db.SelectContext(ctx, &rules, query, userID)
+	return rules, err
+}
+
+func (r *repository) GetEnabledRules(ctx context.Context, userID string) ([]Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM alert_rules WHERE user_id = $1 AND enabled ORDER BY id`
+
+	rules := []Rule{}
+	err := r.db.SelectContext(ctx, &rules, query, userID)
+	return rules, err
+}
+
+func (r *repository) CreateRule(ctx context.Context, rule *Rule) error {
+	query := `
+		INSERT INTO alert_rules (user_id, type, metric, threshold, window_days, enabled, webhook_url, notify_email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, rule.UserID, rule.Type, rule.Metric, rule.Threshold,
+		rule.WindowDays, rule.Enabled, rule.WebhookURL, rule.NotifyEmail).
+		Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+// UpdateRule saves a rule, returning false if the user has no such rule
+func (r *repository) UpdateRule(ctx context.Context, rule *Rule) (bool, error) {
+	query := `
+		UPDATE alert_rules
+		SET type = $3, metric = $4, threshold = $5, window_days = $6, enabled = $7,
+			webhook_url = $8, notify_email = $9, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING created_at, updated_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, rule.ID, rule.UserID, rule.Type, rule.Metric, rule.Threshold,
+		rule.WindowDays, rule.Enabled, rule.WebhookURL, rule.NotifyEmail).
+		Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *repository) DeleteRule(ctx context.Context, userID string, ruleID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CreateAlert stores an alert, returning false if the same alert was already
+// raised (same dedupe key) so it isn't delivered twice
+func (r *repository) CreateAlert(ctx context.Context, alert *Alert) (bool, error) {
+	query := `
+		INSERT INTO alerts (user_id, rule_id, type, metric, message, value, expected, dedupe_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, alert.UserID, alert.RuleID, alert.Type, alert.Metric,
+		alert.Message, alert.Value, alert.Expected, alert.DedupeKey).
+		Scan(&alert.ID, &alert.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *repository) ListAlerts(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Alert, error) {
+	query := `
+		SELECT id, user_id, rule_id, type, metric, message, value, expected, dedupe_key, read_at, created_at
+		FROM alerts
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	alerts := []Alert{}
+	err := r.db.SelectContext(ctx, &alerts, query, userID, unreadOnly, limit)
+	return alerts, err
+}
+
+func (r *repository) CountUnreadAlerts(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM alerts WHERE user_id = $1 AND read_at IS NULL`, userID)
+	return count, err
+}
+
+func (r *repository) MarkAlertRead(ctx context.Context, userID string, alertID int) (bool, error) {
+	query := `
+		UPDATE alerts
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, alertID, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -0,0 +1,179 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/email"
+)
+
+// recentVideosScanned is how many recent videos are searched for the last VOD
+// when working out when the channel last streamed
+const recentVideosScanned = 50
+
+// Service evaluates rules against stored analytics and delivers new alerts
+type Service struct {
+	repo          Repository
+	analyticsRepo analytics.Repository
+	notifier      *Notifier
+}
+
+func NewService(repo Repository, analyticsRepo analytics.Repository) *Service {
+	emailClient, err := email.NewResendClient()
+	if err != nil {
+		log.Printf("⚠️ Alert emails disabled: %v", err)
+	}
+
+	return &Service{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+		notifier:      NewNotifier(config.Alerts(), emailClient),
+	}
+}
+
+// EvaluateUser runs the user's enabled rules against their latest data. It is
+// hooked into collection, so it only reads what has already been stored.
+func (s *Service) EvaluateUser(ctx context.Context, userID string) error {
+	rules, err := s.repo.GetEnabledRules(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	in, err := s.loadInputs(ctx, userID, rules)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		alert := Evaluate(rule, in)
+		if alert == nil {
+			continue
+		}
+
+		created, err := s.repo.CreateAlert(ctx, alert)
+		if err != nil {
+			log.Printf("Failed to save %s alert for user %s: %v", rule.Type, userID, err)
+			continue
+		}
+		if !created {
+			continue
+		}
+
+		log.Printf("🔔 Alert for user %s: %s", userID, alert.Message)
+		s.deliver(ctx, rule, alert)
+	}
+
+	return nil
+}
+
+// NotifyChanges shows the user what a collection changed as an in-app alert.
+// It has the signature of an analytics.ChangeHook. Unlike rule alerts it isn't
+// pushed to webhooks or email.
+func (s *Service) NotifyChanges(ctx context.Context, userID string, changes *analytics.ChangeSummary) error {
+	locale, err := s.analyticsRepo.GetUserLocale(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	alert := ChangesAlert(userID, changes, locale)
+	if alert == nil {
+		return nil
+	}
+	if _, err := s.repo.CreateAlert(ctx, alert); err != nil {
+		return fmt.Errorf("failed to save change alert: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) loadInputs(ctx context.Context, userID string, rules []Rule) (Inputs, error) {
+	in := Inputs{Now: time.Now().UTC()}
+
+	locale, err := s.analyticsRepo.GetUserLocale(ctx, userID)
+	if err != nil {
+		return in, err
+	}
+	in.Locale = locale
+
+	// One extra day turns the window into window+1 points (window daily changes)
+	days := minAnomalyPoints + 1
+	needsStreams := false
+	for _, rule := range rules {
+		days = max(days, rule.WindowDays+1)
+		needsStreams = needsStreams || rule.Type == RuleStreamMissed
+	}
+
+	history, err := s.analyticsRepo.GetChannelAnalytics(ctx, userID, days)
+	if err != nil {
+		return in, fmt.Errorf("failed to get channel analytics: %w", err)
+	}
+	// Stored newest first
+	for i := len(history) - 1; i >= 0; i-- {
+		in.History = append(in.History, history[i])
+	}
+
+	if needsStreams {
+		in.LastStreamAt, err = s.lastStreamAt(ctx, userID)
+		if err != nil {
+			return in, err
+		}
+	}
+
+	return in, nil
+}
+
+// lastStreamAt is the latest of the last stream session and the last VOD
+func (s *Service) lastStreamAt(ctx context.Context, userID string) (*time.Time, error) {
+	var last *time.Time
+
+	sessions, err := s.analyticsRepo.GetStreamSessions(ctx, userID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream sessions: %w", err)
+	}
+	if len(sessions) > 0 && sessions[0].StartedAt != nil {
+		last = sessions[0].StartedAt
+	}
+
+	videos, err := s.analyticsRepo.GetVideoAnalytics(ctx, userID, recentVideosScanned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get videos: %w", err)
+	}
+	for _, video := range videos {
+		if video.VideoType != "vod" || video.PublishedAt == nil {
+			continue
+		}
+		if last == nil || video.PublishedAt.After(*last) {
+			last = video.PublishedAt
+		}
+		break
+	}
+
+	return last, nil
+}
+
+// deliver pushes a new alert to the rule's webhook and email. Failures are
+// logged only; the alert is already visible in the app.
+func (s *Service) deliver(ctx context.Context, rule Rule, alert *Alert) {
+	if rule.WebhookURL != "" {
+		if err := s.notifier.SendWebhook(ctx, rule.WebhookURL, alert); err != nil {
+			log.Printf("Failed to deliver alert %d to webhook for user %s: %v", alert.ID, alert.UserID, err)
+		}
+	}
+
+	if rule.NotifyEmail {
+		user, err := s.analyticsRepo.GetUserByClerkID(ctx, alert.UserID)
+		if err != nil || user == nil || user.Email == "" {
+			log.Printf("No email address to send alert %d to for user %s (err: %v)", alert.ID, alert.UserID, err)
+			return
+		}
+		if err := s.notifier.SendEmail(user.Email, user.Locale, alert); err != nil {
+			log.Printf("Failed to email alert %d for user %s: %v", alert.ID, alert.UserID, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package analytics
+
+// archivedTable is a table of Twitch analytics that is set aside on disconnect
+type archivedTable struct {
+	name string
+	// owned selects the user's rows, with the user ID as $1
+	owned string
+	// restored is the archived row as put back, "row_data" unless references
+	// to rows that may be gone need clearing
+	restored string
+	// restoredIf filters the rows put back, as r, when set
+	restoredIf string
+}
+
+// archivedTables are in restore order, referenced tables first. Rows are
+// deleted in the reverse order.
+var archivedTables = []archivedTable{
+	{name: "stream_sessions", owned: "user_id = $1"},
+	{
+		name:  "video_analytics",
+		owned: "user_id = $1",
+		// The session may have been collected again under a new id
+		restored: `CASE WHEN EXISTS (SELECT 1 FROM stream_sessions s WHERE s.id = (row_data->>'stream_session_id')::int)
+			THEN row_data ELSE jsonb_set(row_data, '{stream_session_id}', 'null') END`,
+	},
+	{name: "video_daily_stats", owned: "video_id IN (SELECT video_id FROM video_analytics WHERE user_id = $1)"},
+	{name: "video_daily_stats_rollups", owned: "video_id IN (SELECT video_id FROM video_analytics WHERE user_id = $1)"},
+	{name: "channel_analytics", owned: "user_id = $1"},
+	{name: "channel_analytics_rollups", owned: "user_id = $1"},
+	{name: "game_analytics", owned: "user_id = $1"},
+	{name: "revenue_analytics", owned: "user_id = $1"},
+	{name: "channel_point_redemptions", owned: "user_id = $1"},
+	{name: "moderation_actions", owned: "user_id = $1"},
+	{name: "moderation_daily_metrics", owned: "user_id = $1"},
+	{name: "raids", owned: "user_id = $1"},
+	{name: "follow_events", owned: "user_id = $1"},
+	{name: "weekly_insights", owned: "user_id = $1"},
+	{name: "channel_consistency", owned: "user_id = $1"},
+	{name: "highlight_suggestions", owned: "user_id = $1"},
+	{name: "stream_schedule_segments", owned: "user_id = $1"},
+	{name: "creator_goals", owned: "user_id = $1"},
+	{
+		name:  "creator_goal_snapshots",
+		owned: "goal_id IN (SELECT id FROM creator_goals WHERE user_id = $1)",
+		// The goal may have been collected again under a new id, snapshots
+		// follow it by its Twitch goal ID
+		restored: `jsonb_set(row_data, '{goal_id}', COALESCE(to_jsonb((
+			SELECT g.id FROM disconnect_archive_rows ag
+			JOIN creator_goals g ON g.user_id = ag.row_data->>'user_id' AND g.goal_id = ag.row_data->>'goal_id'
+			WHERE ag.archive_id = a.archive_id AND ag.table_name = 'creator_goals'
+			AND (ag.row_data->>'id')::int = (a.row_data->>'goal_id')::int
+		)), 'null'))`,
+		restoredIf: "r.goal_id IS NOT NULL",
+	},
+	{name: "collaboration_tags", owned: "user_id = $1"},
+}
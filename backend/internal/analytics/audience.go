@@ -0,0 +1,45 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// GetAudienceReport breaks the user's videos and clips of the last days down
+// by broadcast language, next to the channel's language setting
+func (s *service) GetAudienceReport(ctx context.Context, userID string, days int) (*AudienceReport, error) {
+	shares, err := s.repo.GetLanguageShares(ctx, userID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+	language, err := s.repo.GetBroadcasterLanguage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AudienceReport{
+		Days:                days,
+		BroadcasterLanguage: language,
+		Languages:           languagePercents(shares),
+	}, nil
+}
+
+// languagePercents fills in each language's share of all views
+func languagePercents(shares []LanguageShare) []LanguageShare {
+	if shares == nil {
+		return []LanguageShare{}
+	}
+
+	total := 0
+	for _, share := range shares {
+		total += share.Views
+	}
+	if total == 0 {
+		return shares
+	}
+	for i := range shares {
+		shares[i].ViewsPercent = math.Round(float64(shares[i].Views)/float64(total)*10000) / 100
+	}
+	return shares
+}
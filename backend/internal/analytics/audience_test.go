@@ -0,0 +1,35 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAudienceReport(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _ := newTestService()
+	repo.On("GetLanguageShares", ctx, "user_1", mock.Anything).Return([]LanguageShare{
+		{Language: "en", Videos: 4, Clips: 10, Views: 750},
+		{Language: "es", Videos: 1, Clips: 2, Views: 250},
+	}, nil)
+	repo.On("GetBroadcasterLanguage", ctx, "user_1").Return("en", nil)
+
+	report, err := svc.GetAudienceReport(ctx, "user_1", 90)
+	require.NoError(t, err)
+	assert.Equal(t, 90, report.Days)
+	assert.Equal(t, "en", report.BroadcasterLanguage)
+	require.Len(t, report.Languages, 2)
+	assert.Equal(t, 75.0, report.Languages[0].ViewsPercent)
+	assert.Equal(t, 25.0, report.Languages[1].ViewsPercent)
+}
+
+func TestLanguagePercentsWithoutViews(t *testing.T) {
+	assert.Equal(t, []LanguageShare{}, languagePercents(nil))
+
+	shares := languagePercents([]LanguageShare{{Language: "unknown", Clips: 1}})
+	assert.Zero(t, shares[0].ViewsPercent)
+}
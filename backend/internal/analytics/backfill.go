@@ -0,0 +1,153 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// maxBackfillFollowers bounds how many followers we page through (100 per request)
+	maxBackfillFollowers = 50000
+	// maxBackfillDays bounds how far back the reconstructed series goes
+	maxBackfillDays = 730
+)
+
+// BackfillFollowerHistory reconstructs a daily follower-count series from the
+// followed_at timestamps of the channel's current followers and stores it in
+// channel_analytics. It only runs once per user; days that already have real
+// snapshots are left untouched.
+//
+// The series is a lower bound: people who followed and later unfollowed are not
+// returned by Twitch, so historical counts may be slightly understated.
+func (dc *dataCollector) BackfillFollowerHistory(ctx context.Context, userID string) error {
+	previous, err := dc.repo.GetLatestAnalyticsJob(ctx, userID, "follower_backfill", "completed")
+	if err != nil {
+		return fmt.Errorf("failed to check previous backfill: %w", err)
+	}
+	if previous != nil {
+		log.Printf("Follower history already backfilled for user %s, skipping", userID)
+		return nil
+	}
+
+	job := &AnalyticsJob{
+		UserID:   userID,
+		JobType:  "follower_backfill",
+		Status:   "running",
+		DataDate: &[]time.Time{time.Now()}[0],
+	}
+
+	if err := dc.repo.CreateAnalyticsJob(ctx, job); err != nil {
+		log.Printf("Failed to create analytics job: %v", err)
+	}
+
+	defer func() {
+		if job.ID > 0 {
+			status := "completed"
+			var errorMsg *string
+			if job.ErrorMessage != "" {
+				status = "failed"
+				errorMsg = &job.ErrorMessage
+			}
+			dc.repo.UpdateAnalyticsJob(ctx, job.ID, status, errorMsg)
+		}
+	}()
+
+	twitchToken, err := dc.tokens.GetValidToken(ctx, userID)
+	if err != nil {
+		job.ErrorMessage = fmt.Sprintf("Failed to get Twitch token: %v", err)
+		return err
+	}
+
+	userInfo, err := dc.twitchClient.GetUserInfo(ctx, twitchToken)
+	if err != nil {
+		job.ErrorMessage = fmt.Sprintf("Failed to get user info: %v", err)
+		return err
+	}
+
+	var followedAt []time.Time
+	total := 0
+	cursor := ""
+	for len(followedAt) < maxBackfillFollowers {
+		page, err := dc.twitchClient.GetChannelFollowers(ctx, twitchToken, userInfo.ID, 100, cursor)
+		if err != nil {
+			job.ErrorMessage = fmt.Sprintf("Failed to get followers: %v", err)
+			return err
+		}
+
+		total = page.Total
+		for _, follower := range page.Data {
+			followedAt = append(followedAt, follower.FollowedAt)
+		}
+
+		cursor = page.Pagination.Cursor
+		if cursor == "" || len(page.Data) == 0 {
+			break
+		}
+	}
+
+	log.Printf("Fetched %d of %d followers for backfill of user %s", len(followedAt), total, userID)
+
+	rows := buildFollowerHistory(userID, followedAt, total, time.Now().UTC(), maxBackfillDays)
+	if len(rows) == 0 {
+		log.Printf("No follower history to backfill for user %s", userID)
+		return nil
+	}
+
+	inserted, err := dc.repo.BackfillChannelAnalytics(ctx, rows)
+	if err != nil {
+		job.ErrorMessage = fmt.Sprintf("Failed to save follower history: %v", err)
+		return err
+	}
+
+	log.Printf("Backfilled %d days of follower history for user %s", inserted, userID)
+	return nil
+}
+
+// buildFollowerHistory turns follow timestamps into one row per day, from the
+// first follow (capped at maxDays ago) up to yesterday. Today is left to the
+// regular daily snapshot. Only followers are known, so rows are marked as
+// backfilled. When only part of the follower list was fetched, the
+// unfetched (oldest) followers are counted as already present at the start.
+func buildFollowerHistory(userID string, followedAt []time.Time, total int, now time.Time, maxDays int) []ChannelAnalytics {
+	if len(followedAt) == 0 {
+		return nil
+	}
+
+	days := make([]time.Time, len(followedAt))
+	for i, t := range followedAt {
+		days[i] = t.UTC().Truncate(24 * time.Hour)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	start := days[0]
+	if earliest := today.AddDate(0, 0, -maxDays); start.Before(earliest) {
+		start = earliest
+	}
+
+	baseline := 0
+	if total > len(followedAt) {
+		baseline = total - len(followedAt)
+	}
+
+	var rows []ChannelAnalytics
+	idx := 0
+	count := baseline
+	for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+		for idx < len(days) && !days[idx].After(day) {
+			count++
+			idx++
+		}
+		rows = append(rows, ChannelAnalytics{
+			UserID:         userID,
+			Date:           day,
+			FollowersCount: count,
+			Source:         ChannelSourceBackfill,
+		})
+	}
+
+	return rows
+}
@@ -0,0 +1,105 @@
+package analytics
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+func TestBuildFollowerHistory(t *testing.T) {
+	now := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+	followedAt := []time.Time{
+		time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 7, 23, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 7, 1, 0, 0, 0, time.UTC),
+	}
+
+	rows := buildFollowerHistory("user_1", followedAt, 5, now, 365)
+
+	// June 7, 8 and 9 - today is left to the daily snapshot
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+
+	// Two followers were not fetched, so they count from the start
+	expected := []int{4, 4, 5}
+	for i, row := range rows {
+		if row.FollowersCount != expected[i] {
+			t.Errorf("day %s: expected %d followers, got %d", row.Date.Format("2006-01-02"), expected[i], row.FollowersCount)
+		}
+		if row.Source != ChannelSourceBackfill {
+			t.Errorf("day %s: expected a backfilled row, got source %q", row.Date.Format("2006-01-02"), row.Source)
+		}
+	}
+}
+
+func TestBuildFollowerHistoryCapsDays(t *testing.T) {
+	now := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	followedAt := []time.Time{
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+	}
+
+	rows := buildFollowerHistory("user_1", followedAt, 2, now, 5)
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %d", len(rows))
+	}
+	if rows[0].FollowersCount != 1 {
+		t.Errorf("expected old follower to be counted on the first day, got %d", rows[0].FollowersCount)
+	}
+	if rows[len(rows)-1].FollowersCount != 2 {
+		t.Errorf("expected 2 followers on the last day, got %d", rows[len(rows)-1].FollowersCount)
+	}
+}
+
+func TestBackfillFollowerHistoryFromRecordedFollowers(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+
+	recorder, err := twitch.NewRecorder(filepath.Join("testdata", "backfill_followers.json"), twitch.ModeReplay)
+	require.NoError(t, err)
+	client, err := twitch.NewClient("", "")
+	require.NoError(t, err)
+	client.SetTransport(recorder)
+
+	accessToken, keyID, err := encryptToken(ctx, "fixture-token")
+	require.NoError(t, err)
+
+	repo := &mockRepository{}
+	repo.On("GetLatestAnalyticsJob", ctx, "user_1", "follower_backfill", "completed").Return(nil, nil)
+	repo.On("CreateAnalyticsJob", ctx, mock.Anything).Return(nil)
+	repo.On("GetTwitchToken", ctx, "user_1").Return(&TwitchToken{
+		UserID:          "user_1",
+		TwitchUserID:    "141981764",
+		AccessToken:     accessToken,
+		EncryptionKeyID: keyID,
+	}, nil)
+
+	var rows []ChannelAnalytics
+	repo.On("BackfillChannelAnalytics", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		rows = args.Get(1).([]ChannelAnalytics)
+	}).Return(0, nil)
+
+	collector := NewDataCollector(repo, client)
+	require.NoError(t, collector.BackfillFollowerHistory(ctx, "user_1"))
+	repo.AssertExpectations(t)
+
+	// Both recorded pages were read: 3 of 5 followers, so 2 count from the start
+	require.NotEmpty(t, rows)
+	secondFollowDay := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	for _, row := range rows {
+		assert.Equal(t, "user_1", row.UserID)
+		if row.Date.Before(secondFollowDay) {
+			assert.Equal(t, 4, row.FollowersCount, row.Date.Format("2006-01-02"))
+		} else {
+			assert.Equal(t, 5, row.FollowersCount, row.Date.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// maxChangedVideos bounds how many new videos a change summary lists
+	maxChangedVideos = 10
+	// maxChangeSummaries bounds how many summaries /changes returns
+	maxChangeSummaries = 100
+)
+
+// ChangeSummary is what a collection changed for a user, compared with what
+// was stored before it ran. Video changes are only tracked by user
+// collections; the daily collection only saves channel data.
+type ChangeSummary struct {
+	JobID       int       `json:"job_id"`
+	JobType     string    `json:"job_type"`
+	CollectedAt time.Time `json:"collected_at"`
+	// FirstCollection is set when nothing was stored before, so everything
+	// would be new. The changes are left at zero then.
+	FirstCollection   bool `json:"first_collection"`
+	FollowersChange   int  `json:"followers_change"`
+	SubscribersChange int  `json:"subscribers_change"`
+	ViewsChange       int  `json:"views_change"`
+	// NewVideos lists the first maxChangedVideos of NewVideoCount, most viewed first
+	NewVideos          []ChangedVideo `json:"new_videos"`
+	NewVideoCount      int            `json:"new_video_count"`
+	VideosGainingViews int            `json:"videos_gaining_views"`
+	VideoViewsGained   int            `json:"video_views_gained"`
+}
+
+// ChangedVideo is a video or clip a collection saved for the first time
+type ChangedVideo struct {
+	VideoID   string `json:"video_id"`
+	Title     string `json:"title"`
+	VideoType string `json:"video_type"`
+	ViewCount int    `json:"view_count"`
+}
+
+// Empty reports whether the collection changed nothing worth telling the user
+func (c *ChangeSummary) Empty() bool {
+	return c.FollowersChange == 0 && c.SubscribersChange == 0 && c.NewVideoCount == 0 && c.VideosGainingViews == 0
+}
+
+// ChangeHook runs after a collection that changed something, e.g. to notify
+// the user about it
+type ChangeHook func(ctx context.Context, userID string, changes *ChangeSummary) error
+
+// collectionSnapshot is the user's stored data a collection is compared with
+type collectionSnapshot struct {
+	channel *ChannelAnalytics
+	// videos is nil when the collection doesn't save videos
+	videos map[string]VideoViewCount
+}
+
+// snapshot loads the user's latest channel analytics and, with videos, the
+// view counts of their videos
+func (dc *dataCollector) snapshot(ctx context.Context, userID string, videos bool) (*collectionSnapshot, error) {
+	channel, err := dc.repo.GetLatestChannelAnalytics(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest channel analytics: %w", err)
+	}
+
+	snap := &collectionSnapshot{channel: channel}
+	if !videos {
+		return snap, nil
+	}
+
+	counts, err := dc.repo.GetVideoViewCounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video view counts: %w", err)
+	}
+	snap.videos = make(map[string]VideoViewCount, len(counts))
+	for _, count := range counts {
+		snap.videos[count.VideoID] = count
+	}
+	return snap, nil
+}
+
+// compareSnapshots summarises what changed between the data stored before and
+// after a collection
+func compareSnapshots(before, after *collectionSnapshot) *ChangeSummary {
+	changes := &ChangeSummary{NewVideos: []ChangedVideo{}}
+	if before.channel == nil {
+		changes.FirstCollection = true
+		return changes
+	}
+
+	if after.channel != nil {
+		changes.FollowersChange = after.channel.FollowersCount - before.channel.FollowersCount
+		// A backfilled or imported day has no subscriber and view counts to compare with
+		if !before.channel.Synthetic() {
+			changes.SubscribersChange = after.channel.SubscriberCount - before.channel.SubscriberCount
+			changes.ViewsChange = after.channel.TotalViews - before.channel.TotalViews
+		}
+	}
+
+	if before.videos == nil || after.videos == nil {
+		return changes
+	}
+	for id, video := range after.videos {
+		previous, ok := before.videos[id]
+		if !ok {
+			changes.NewVideoCount++
+			changes.NewVideos = append(changes.NewVideos, ChangedVideo(video))
+			continue
+		}
+		if gained := video.ViewCount - previous.ViewCount; gained > 0 {
+			changes.VideosGainingViews++
+			changes.VideoViewsGained += gained
+		}
+	}
+
+	sort.Slice(changes.NewVideos, func(i, j int) bool {
+		if changes.NewVideos[i].ViewCount != changes.NewVideos[j].ViewCount {
+			return changes.NewVideos[i].ViewCount > changes.NewVideos[j].ViewCount
+		}
+		return changes.NewVideos[i].VideoID < changes.NewVideos[j].VideoID
+	})
+	if len(changes.NewVideos) > maxChangedVideos {
+		changes.NewVideos = changes.NewVideos[:maxChangedVideos]
+	}
+	return changes
+}
+
+// recordChanges compares the user's data with the snapshot taken before the
+// job, saves the summary on the job and runs the change hooks. Failures are
+// logged only; the collection itself is done.
+func (dc *dataCollector) recordChanges(ctx context.Context, job *AnalyticsJob, before *collectionSnapshot) {
+	if job.ID == 0 || before == nil {
+		return
+	}
+	// Like the job's report, the summary is saved even when the collection was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	after, err := dc.snapshot(ctx, job.UserID, before.videos != nil)
+	if err != nil {
+		log.Printf("Failed to compare collection job %d with the data before it: %v", job.ID, err)
+		return
+	}
+
+	changes := compareSnapshots(before, after)
+	changes.JobID = job.ID
+	changes.JobType = job.JobType
+	changes.CollectedAt = time.Now().UTC()
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		log.Printf("Failed to encode change summary for job %d: %v", job.ID, err)
+		return
+	}
+	if err := dc.repo.SaveAnalyticsJobChanges(ctx, job.ID, data); err != nil {
+		log.Printf("Failed to save change summary for job %d: %v", job.ID, err)
+		return
+	}
+
+	if changes.Empty() {
+		return
+	}
+	for _, hook := range dc.changeHooks {
+		if err := hook(ctx, job.UserID, changes); err != nil {
+			log.Printf("Change hook failed for user %s: %v", job.UserID, err)
+		}
+	}
+}
+
+// GetChangeSummaries returns what the user's collections of the last days
+// changed, newest first
+func (s *service) GetChangeSummaries(ctx context.Context, userID string, days, limit int) ([]ChangeSummary, error) {
+	if limit <= 0 || limit > maxChangeSummaries {
+		limit = maxChangeSummaries
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	changes, err := s.repo.GetChangeSummaries(ctx, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change summaries: %w", err)
+	}
+	if changes == nil {
+		changes = []ChangeSummary{}
+	}
+	return changes, nil
+}
@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func videoCounts(counts ...VideoViewCount) map[string]VideoViewCount {
+	videos := make(map[string]VideoViewCount, len(counts))
+	for _, count := range counts {
+		videos[count.VideoID] = count
+	}
+	return videos
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	before := &collectionSnapshot{
+		channel: &ChannelAnalytics{FollowersCount: 100, SubscriberCount: 10, TotalViews: 1000},
+		videos: videoCounts(
+			VideoViewCount{VideoID: "v1", ViewCount: 50},
+			VideoViewCount{VideoID: "v2", ViewCount: 20},
+		),
+	}
+	after := &collectionSnapshot{
+		channel: &ChannelAnalytics{FollowersCount: 112, SubscriberCount: 9, TotalViews: 1300},
+		videos: videoCounts(
+			VideoViewCount{VideoID: "v1", ViewCount: 80},
+			VideoViewCount{VideoID: "v2", ViewCount: 20},
+			VideoViewCount{VideoID: "v3", Title: "New stream", VideoType: "archive", ViewCount: 5},
+			VideoViewCount{VideoID: "v4", Title: "New clip", VideoType: "clip", ViewCount: 40},
+		),
+	}
+
+	changes := compareSnapshots(before, after)
+	assert.False(t, changes.FirstCollection)
+	assert.Equal(t, 12, changes.FollowersChange)
+	assert.Equal(t, -1, changes.SubscribersChange)
+	assert.Equal(t, 300, changes.ViewsChange)
+	assert.Equal(t, 2, changes.NewVideoCount)
+	assert.Equal(t, []ChangedVideo{
+		{VideoID: "v4", Title: "New clip", VideoType: "clip", ViewCount: 40},
+		{VideoID: "v3", Title: "New stream", VideoType: "archive", ViewCount: 5},
+	}, changes.NewVideos)
+	assert.Equal(t, 1, changes.VideosGainingViews)
+	assert.Equal(t, 30, changes.VideoViewsGained)
+	assert.False(t, changes.Empty())
+}
+
+func TestCompareSnapshotsFirstCollection(t *testing.T) {
+	after := &collectionSnapshot{
+		channel: &ChannelAnalytics{FollowersCount: 100},
+		videos:  videoCounts(VideoViewCount{VideoID: "v1", ViewCount: 50}),
+	}
+
+	changes := compareSnapshots(&collectionSnapshot{videos: videoCounts()}, after)
+	assert.True(t, changes.FirstCollection)
+	assert.Zero(t, changes.FollowersChange)
+	assert.Zero(t, changes.NewVideoCount)
+	assert.True(t, changes.Empty())
+}
+
+func TestCompareSnapshotsAfterBackfilledDay(t *testing.T) {
+	before := &collectionSnapshot{channel: &ChannelAnalytics{FollowersCount: 90, Source: ChannelSourceBackfill}}
+	after := &collectionSnapshot{channel: &ChannelAnalytics{FollowersCount: 100, SubscriberCount: 12, TotalViews: 5000}}
+
+	changes := compareSnapshots(before, after)
+	assert.False(t, changes.FirstCollection)
+	assert.Equal(t, 10, changes.FollowersChange)
+	assert.Zero(t, changes.SubscribersChange)
+	assert.Zero(t, changes.ViewsChange)
+}
+
+func TestCompareSnapshotsWithoutVideos(t *testing.T) {
+	channel := &ChannelAnalytics{FollowersCount: 100}
+	changes := compareSnapshots(&collectionSnapshot{channel: channel}, &collectionSnapshot{channel: channel})
+	assert.True(t, changes.Empty())
+	assert.Empty(t, changes.NewVideos)
+}
+
+func TestCompareSnapshotsCapsNewVideos(t *testing.T) {
+	before := &collectionSnapshot{channel: &ChannelAnalytics{}, videos: videoCounts()}
+	after := &collectionSnapshot{channel: &ChannelAnalytics{}, videos: videoCounts()}
+	for i := 0; i < maxChangedVideos+5; i++ {
+		id := fmt.Sprintf("v%02d", i)
+		after.videos[id] = VideoViewCount{VideoID: id, ViewCount: i}
+	}
+
+	changes := compareSnapshots(before, after)
+	assert.Equal(t, maxChangedVideos+5, changes.NewVideoCount)
+	require.Len(t, changes.NewVideos, maxChangedVideos)
+	assert.Equal(t, "v14", changes.NewVideos[0].VideoID)
+}
+
+func TestRecordChanges(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{}
+	repo.On("GetLatestChannelAnalytics", mock.Anything, "user_1").Return(&ChannelAnalytics{FollowersCount: 105}, nil)
+	repo.On("SaveAnalyticsJobChanges", mock.Anything, 7, mock.MatchedBy(func(data []byte) bool {
+		var changes ChangeSummary
+		return json.Unmarshal(data, &changes) == nil && changes.JobID == 7 && changes.FollowersChange == 5
+	})).Return(nil)
+
+	dc := &dataCollector{repo: repo}
+	var notified *ChangeSummary
+	dc.AddChangeHook(func(ctx context.Context, userID string, changes *ChangeSummary) error {
+		notified = changes
+		return nil
+	})
+
+	job := &AnalyticsJob{ID: 7, UserID: "user_1", JobType: "daily_channel"}
+	dc.recordChanges(ctx, job, &collectionSnapshot{channel: &ChannelAnalytics{FollowersCount: 100}})
+	require.NotNil(t, notified)
+	assert.Equal(t, 5, notified.FollowersChange)
+	repo.AssertExpectations(t)
+}
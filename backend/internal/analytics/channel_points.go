@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// SaveRedemptionEvent stores a redemption delivered by EventSub. Events for
+// broadcasters we don't know are dropped.
+func SaveRedemptionEvent(ctx context.Context, repo Repository, event *twitch.RedemptionEvent) error {
+	userID, err := repo.GetUserIDByTwitchID(ctx, event.BroadcasterUserID)
+	if err != nil {
+		return fmt.Errorf("failed to look up broadcaster: %w", err)
+	}
+	if userID == "" {
+		log.Printf("Ignoring redemption for unknown broadcaster %s", event.BroadcasterUserID)
+		return nil
+	}
+
+	return repo.SaveChannelPointRedemption(ctx, &ChannelPointRedemption{
+		UserID:       userID,
+		RedemptionID: event.ID,
+		RewardID:     event.Reward.ID,
+		RewardTitle:  event.Reward.Title,
+		RewardCost:   event.Reward.Cost,
+		Status:       event.Status,
+		RedeemedAt:   event.RedeemedAt,
+	})
+}
+
+// GetChannelPointsAnalytics summarizes redemptions over the last days
+func (s *service) GetChannelPointsAnalytics(ctx context.Context, userID string, days int) (*ChannelPointsAnalytics, error) {
+	since := time.Now().AddDate(0, 0, -days)
+
+	sub, err := s.repo.GetEventSubSubscription(ctx, userID, twitch.EventSubRedemptionAdd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EventSub subscription: %w", err)
+	}
+
+	rewards, err := s.repo.GetTopRewards(ctx, userID, since, 10)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top rewards: %w", err)
+	}
+
+	trend, err := s.repo.GetRedemptionTrend(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redemption trend: %w", err)
+	}
+
+	result := &ChannelPointsAnalytics{
+		Days:       days,
+		Tracking:   sub != nil && sub.Status == "enabled",
+		TopRewards: rewards,
+		Trend:      trend,
+	}
+	for _, point := range trend {
+		result.TotalRedemptions += point.Redemptions
+		result.PointsSpent += point.PointsSpent
+	}
+	if result.TopRewards == nil {
+		result.TopRewards = []RewardStats{}
+	}
+	if result.Trend == nil {
+		result.Trend = []RedemptionTrendPoint{}
+	}
+
+	return result, nil
+}
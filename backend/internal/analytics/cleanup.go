@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Reasons a Twitch account is cleaned up
+const (
+	CleanupDisconnect    = "disconnect"
+	CleanupAccountSwitch = "account_switch"
+	CleanupReconcile     = "reconcile"
+)
+
+// TwitchCleanupSteps is the progress total of a Twitch cleanup job: one step
+// per archived table and one for removing the connection
+var TwitchCleanupSteps = len(archivedTables) + 1
+
+// interruptedCleanupAge is how long a cleanup job may run before
+// reconciliation treats it as interrupted
+const interruptedCleanupAge = time.Hour
+
+// TwitchCleanupReport is saved on a "twitch_cleanup" job
+type TwitchCleanupReport struct {
+	Reason       string     `json:"reason"`
+	TwitchUserID string     `json:"twitch_user_id"`
+	ArchiveID    int64      `json:"archive_id,omitempty"`
+	ArchivedRows int        `json:"archived_rows"`
+	ExpiresAt    *time.Time `json:"restorable_until,omitempty"`
+}
+
+// CleanupTwitchAccount removes the user's Twitch connection and archives the
+// analytics of twitchUserID, restorable for DISCONNECT_RESTORE_WINDOW. It runs
+// as a "twitch_cleanup" job whose progress is updated as tables are archived.
+// Everything happens in one transaction, so a failure leaves the data as it
+// was. The returned job carries the final status.
+func CleanupTwitchAccount(ctx context.Context, repo Repository, userID, twitchUserID, reason string) (*AnalyticsJob, *DisconnectArchive, error) {
+	job := &AnalyticsJob{
+		UserID:        userID,
+		JobType:       "twitch_cleanup",
+		Status:        "running",
+		ProgressTotal: TwitchCleanupSteps,
+	}
+	if err := repo.CreateAnalyticsJob(ctx, job); err != nil {
+		return nil, nil, fmt.Errorf("failed to create cleanup job: %w", err)
+	}
+
+	report := TwitchCleanupReport{Reason: reason, TwitchUserID: twitchUserID}
+	archive, err := repo.CleanupTwitchAccount(ctx, job.ID, userID, twitchUserID, time.Now().Add(config.Disconnect().RestoreWindow))
+	job.Status = "completed"
+	var errorMsg *string
+	if err != nil {
+		job.Status = "failed"
+		job.ErrorMessage = err.Error()
+		errorMsg = &job.ErrorMessage
+	} else {
+		job.ProgressCompleted = TwitchCleanupSteps
+		report.ArchiveID = archive.ID
+		report.ArchivedRows = archive.RowCount
+		report.ExpiresAt = &archive.ExpiresAt
+	}
+
+	reportJSON, _ := json.Marshal(report)
+	raw := json.RawMessage(reportJSON)
+	job.Report = &raw
+	// The cleanup is done (or rolled back) even if the request was cancelled meanwhile
+	if completeErr := repo.CompleteAnalyticsJob(context.WithoutCancel(ctx), job.ID, job.Status, errorMsg, reportJSON); completeErr != nil {
+		log.Printf("Failed to complete cleanup job %d: %v", job.ID, completeErr)
+	}
+
+	if err != nil {
+		return job, nil, err
+	}
+	return job, archive, nil
+}
+
+// ReconcileResult summarizes a reconciliation of Twitch data
+type ReconcileResult struct {
+	// Interrupted is how many cleanup jobs were left running and marked failed
+	Interrupted int64 `json:"interrupted"`
+	// Orphaned are the users with analytics but no Twitch account
+	Orphaned     []string `json:"orphaned"`
+	Cleaned      int      `json:"cleaned"`
+	ArchivedRows int      `json:"archived_rows"`
+	Failed       int      `json:"failed"`
+}
+
+// ReconcileTwitchData finds data left behind by cleanups that never finished:
+// users without a Twitch account who still have analytics, EventSub
+// subscriptions or collection retries. Their data is archived the way a
+// disconnect would, and cleanup jobs left running are marked failed. With
+// dryRun it only reports the orphans.
+func ReconcileTwitchData(ctx context.Context, repo Repository, dryRun bool) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+
+	orphaned, err := repo.ListOrphanedTwitchDataUsers(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Orphaned = orphaned
+	if dryRun {
+		return result, nil
+	}
+
+	result.Interrupted, err = repo.FailInterruptedAnalyticsJobs(ctx, "twitch_cleanup", time.Now().Add(-interruptedCleanupAge))
+	if err != nil {
+		return result, err
+	}
+
+	for _, userID := range orphaned {
+		_, archive, err := CleanupTwitchAccount(ctx, repo, userID, "", CleanupReconcile)
+		if err != nil {
+			log.Printf("Failed to clean up orphaned Twitch data of user %s: %v", userID, err)
+			result.Failed++
+			continue
+		}
+		result.Cleaned++
+		result.ArchivedRows += archive.RowCount
+	}
+	return result, nil
+}
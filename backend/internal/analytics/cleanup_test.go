@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectCleanupJob(ctx context.Context, repo *mockRepository, jobID int) {
+	repo.On("CreateAnalyticsJob", ctx, mock.MatchedBy(func(job *AnalyticsJob) bool {
+		return job.JobType == "twitch_cleanup" && job.Status == "running" && job.ProgressTotal == TwitchCleanupSteps
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*AnalyticsJob).ID = jobID
+	}).Return(nil)
+}
+
+func TestCleanupTwitchAccountCompletesJob(t *testing.T) {
+	repo := &mockRepository{}
+	ctx := context.Background()
+	expectCleanupJob(ctx, repo, 7)
+
+	archive := &DisconnectArchive{ID: 3, RowCount: 12, ExpiresAt: time.Now().Add(time.Hour)}
+	repo.On("CleanupTwitchAccount", ctx, 7, "user_1", "tw_1", mock.AnythingOfType("time.Time")).Return(archive, nil)
+
+	var report TwitchCleanupReport
+	repo.On("CompleteAnalyticsJob", mock.Anything, 7, "completed", (*string)(nil), mock.Anything).Run(func(args mock.Arguments) {
+		require.NoError(t, json.Unmarshal(args.Get(4).([]byte), &report))
+	}).Return(nil)
+
+	job, got, err := CleanupTwitchAccount(ctx, repo, "user_1", "tw_1", CleanupDisconnect)
+	require.NoError(t, err)
+	assert.Equal(t, archive, got)
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, TwitchCleanupSteps, job.ProgressCompleted)
+	assert.Equal(t, TwitchCleanupReport{
+		Reason:       CleanupDisconnect,
+		TwitchUserID: "tw_1",
+		ArchiveID:    3,
+		ArchivedRows: 12,
+		ExpiresAt:    report.ExpiresAt,
+	}, report)
+	repo.AssertExpectations(t)
+}
+
+func TestCleanupTwitchAccountRecordsFailure(t *testing.T) {
+	repo := &mockRepository{}
+	ctx := context.Background()
+	expectCleanupJob(ctx, repo, 8)
+
+	repo.On("CleanupTwitchAccount", ctx, 8, "user_1", "tw_1", mock.AnythingOfType("time.Time")).Return(nil, errors.New("failed to archive raids: boom"))
+	repo.On("CompleteAnalyticsJob", mock.Anything, 8, "failed", mock.MatchedBy(func(msg *string) bool {
+		return msg != nil && *msg == "failed to archive raids: boom"
+	}), mock.Anything).Return(nil)
+
+	job, archive, err := CleanupTwitchAccount(ctx, repo, "user_1", "tw_1", CleanupAccountSwitch)
+	require.Error(t, err)
+	assert.Nil(t, archive)
+	assert.Equal(t, "failed", job.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestReconcileTwitchData(t *testing.T) {
+	repo := &mockRepository{}
+	ctx := context.Background()
+
+	repo.On("ListOrphanedTwitchDataUsers", ctx).Return([]string{"user_1", "user_2"}, nil)
+
+	result, err := ReconcileTwitchData(ctx, repo, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_1", "user_2"}, result.Orphaned)
+	assert.Zero(t, result.Cleaned)
+
+	repo.On("FailInterruptedAnalyticsJobs", ctx, "twitch_cleanup", mock.AnythingOfType("time.Time")).Return(int64(1), nil)
+	expectCleanupJob(ctx, repo, 9)
+	repo.On("CleanupTwitchAccount", ctx, 9, "user_1", "", mock.AnythingOfType("time.Time")).Return(&DisconnectArchive{ID: 1, RowCount: 4}, nil)
+	repo.On("CleanupTwitchAccount", ctx, 9, "user_2", "", mock.AnythingOfType("time.Time")).Return(nil, errors.New("boom"))
+	repo.On("CompleteAnalyticsJob", mock.Anything, 9, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	result, err = ReconcileTwitchData(ctx, repo, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Interrupted)
+	assert.Equal(t, 1, result.Cleaned)
+	assert.Equal(t, 4, result.ArchivedRows)
+	assert.Equal(t, 1, result.Failed)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/baldybuilds/creatorsync/internal/media"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// ClipDownloader is the part of the Twitch client resolving clip MP4 URLs
+type ClipDownloader interface {
+	GetClipDownload(ctx context.Context, userAccessToken, editorID, broadcasterID, clipID string) (*twitch.ClipDownload, error)
+}
+
+// ClipDownloads resolves the MP4 URLs of a user's own clips with their Twitch
+// token
+type ClipDownloads struct {
+	repo       Repository
+	tokens     *TwitchTokenHelper
+	downloader ClipDownloader
+}
+
+func NewClipDownloads(repo Repository, tokens *TwitchTokenHelper, downloader ClipDownloader) *ClipDownloads {
+	return &ClipDownloads{
+		repo:       repo,
+		tokens:     tokens,
+		downloader: downloader,
+	}
+}
+
+// GetClipDownload returns the download URLs of one of the user's clips. It
+// wraps media.ErrClipUnavailable when the user has to reconnect Twitch first.
+func (d *ClipDownloads) GetClipDownload(ctx context.Context, userID, clipID string) (*twitch.ClipDownload, error) {
+	user, err := d.repo.GetUserByClerkID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil || user.TwitchUserID == "" {
+		return nil, fmt.Errorf("%w: twitch is not connected", media.ErrClipUnavailable)
+	}
+
+	token, err := d.tokens.GetValidToken(ctx, userID)
+	if errors.Is(err, ErrTwitchAuthRequired) {
+		return nil, fmt.Errorf("%w: %w", media.ErrClipUnavailable, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Twitch token: %w", err)
+	}
+	return d.downloader.GetClipDownload(ctx, token, user.TwitchUserID, user.TwitchUserID, clipID)
+}
@@ -0,0 +1,227 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxCollaborators bounds how many creators one stream or video can be tagged with
+const maxCollaborators = 10
+
+// twitchLoginPattern matches Twitch logins once lowercased
+var twitchLoginPattern = regexp.MustCompile(`^[a-z0-9_]{3,25}$`)
+
+// collabMarkers introduce the creators a title features, e.g. "ft. Sykkuno"
+// or "w/ @Valkyrae". "with" only counts when followed by an @mention.
+var collabMarkers = map[string]bool{
+	"ft": true, "ft.": true, "feat": true, "feat.": true, "featuring": true, "w/": true,
+}
+
+// normalizeCollaborators lowercases logins, drops "@" and duplicates and
+// rejects anything that can't be a Twitch login
+func normalizeCollaborators(logins []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, login := range logins {
+		login = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(login), "@"))
+		if !twitchLoginPattern.MatchString(login) {
+			return nil, fmt.Errorf("%q is not a Twitch login", login)
+		}
+		if seen[login] {
+			continue
+		}
+		seen[login] = true
+		normalized = append(normalized, login)
+	}
+	if len(normalized) > maxCollaborators {
+		return nil, fmt.Errorf("at most %d collaborators can be tagged", maxCollaborators)
+	}
+	return normalized, nil
+}
+
+// detectCollaborators reads the creators a title says it features: the names
+// after "ft."/"feat."/"w/", joined by commas, "&", "+" or "and", and @mentions
+// after "with". "Duos w/ @Valkyrae & Sykkuno | !discord" gives valkyrae and sykkuno.
+func detectCollaborators(title string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	add := func(login string) {
+		if !seen[login] && len(found) < maxCollaborators {
+			seen[login] = true
+			found = append(found, login)
+		}
+	}
+
+	// Split markers glued to the name, e.g. "w/shroud"
+	var tokens []string
+	for _, field := range strings.Fields(strings.ToLower(title)) {
+		for _, marker := range []string{"w/", "ft.", "feat."} {
+			if strings.HasPrefix(field, marker) && len(field) > len(marker) {
+				tokens = append(tokens, marker)
+				field = field[len(marker):]
+				break
+			}
+		}
+		tokens = append(tokens, field)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		mentionsOnly := tokens[i] == "with"
+		if !collabMarkers[tokens[i]] && !mentionsOnly {
+			continue
+		}
+
+		// Names follow the marker until something that isn't a name or a joiner
+		j := i + 1
+		for ; j < len(tokens); j++ {
+			token := tokens[j]
+			if isCollabJoiner(token) {
+				continue
+			}
+			name := strings.Trim(token, "()[],.!?:;\"'")
+			if mentionsOnly && !strings.HasPrefix(name, "@") {
+				break
+			}
+			name = strings.TrimPrefix(name, "@")
+			if strings.HasPrefix(token, "!") || strings.HasPrefix(token, "#") || !twitchLoginPattern.MatchString(name) {
+				break
+			}
+			add(name)
+
+			// Another name only follows a comma or a joiner
+			if !strings.HasSuffix(token, ",") && (j+1 >= len(tokens) || !isCollabJoiner(tokens[j+1])) {
+				j++
+				break
+			}
+		}
+		// The token that ended the names may start another list
+		i = j - 1
+	}
+	return found
+}
+
+func isCollabJoiner(token string) bool {
+	return token == "&" || token == "+" || token == "and" || token == ","
+}
+
+// GetCollaborationReport compares the content of the last days made with other
+// creators to solo content. Tagged collaborators take precedence over the ones
+// detected from titles.
+func (s *service) GetCollaborationReport(ctx context.Context, userID string, days int) (*CollaborationReport, error) {
+	content, err := s.repo.GetCollabContent(ctx, userID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildCollaborationReport(content)
+	report.Days = days
+	return report, nil
+}
+
+// SetCollaborators tags a stream or video, an empty list marking it solo
+func (s *service) SetCollaborators(ctx context.Context, userID, contentType, contentID string, logins []string) (bool, error) {
+	return s.repo.SetCollaborators(ctx, userID, contentType, contentID, logins)
+}
+
+// DeleteCollaborators goes back to detecting the collaborators from the title
+func (s *service) DeleteCollaborators(ctx context.Context, userID, contentType, contentID string) (bool, error) {
+	return s.repo.DeleteCollaborators(ctx, userID, contentType, contentID)
+}
+
+// collabTotals sums metrics before they are averaged into CollabStats
+type collabTotals struct {
+	streams, viewers, peak, followers int
+	videos, views                     int
+}
+
+func (t *collabTotals) add(item CollabContent) {
+	if item.ContentType == "stream" {
+		t.streams++
+		t.viewers += item.AverageViewers
+		t.peak += item.PeakViewers
+		t.followers += item.FollowersGained
+	} else {
+		t.videos++
+		t.views += item.ViewCount
+	}
+}
+
+func (t collabTotals) stats() CollabStats {
+	stats := CollabStats{Streams: t.streams, Videos: t.videos}
+	if t.streams > 0 {
+		stats.AvgViewers = float64(t.viewers) / float64(t.streams)
+		stats.AvgPeakViewers = float64(t.peak) / float64(t.streams)
+		stats.AvgFollowersGained = float64(t.followers) / float64(t.streams)
+	}
+	if t.videos > 0 {
+		stats.AvgVideoViews = float64(t.views) / float64(t.videos)
+	}
+	return stats
+}
+
+func buildCollaborationReport(content []CollabContent) *CollaborationReport {
+	report := &CollaborationReport{
+		Collaborators: []CollaboratorStats{},
+		Content:       []CollabContent{},
+	}
+
+	var solo, collab collabTotals
+	byLogin := make(map[string]*collabTotals)
+	for _, item := range content {
+		if item.Source == "" {
+			item.Collaborators = detectCollaborators(item.Title)
+			if len(item.Collaborators) > 0 {
+				item.Source = "detected"
+			}
+		}
+		if len(item.Collaborators) == 0 {
+			solo.add(item)
+			continue
+		}
+
+		collab.add(item)
+		for _, login := range item.Collaborators {
+			if byLogin[login] == nil {
+				byLogin[login] = &collabTotals{}
+			}
+			byLogin[login].add(item)
+		}
+		report.Content = append(report.Content, item)
+	}
+
+	report.Solo = solo.stats()
+	report.Collab = collab.stats()
+	report.ViewerLiftPercent = liftPercent(report.Collab.AvgViewers, report.Solo.AvgViewers, collab.streams, solo.streams)
+	report.VideoViewLiftPercent = liftPercent(report.Collab.AvgVideoViews, report.Solo.AvgVideoViews, collab.videos, solo.videos)
+
+	for login, totals := range byLogin {
+		stats := totals.stats()
+		report.Collaborators = append(report.Collaborators, CollaboratorStats{
+			Login:             login,
+			CollabStats:       stats,
+			ViewerLiftPercent: liftPercent(stats.AvgViewers, report.Solo.AvgViewers, totals.streams, solo.streams),
+		})
+	}
+	sort.Slice(report.Collaborators, func(i, j int) bool {
+		a, b := report.Collaborators[i], report.Collaborators[j]
+		if a.Streams+a.Videos != b.Streams+b.Videos {
+			return a.Streams+a.Videos > b.Streams+b.Videos
+		}
+		return a.Login < b.Login
+	})
+	return report
+}
+
+// liftPercent is how much higher value is than baseline, nil when either side
+// has no content or the baseline is zero
+func liftPercent(value, baseline float64, count, baselineCount int) *float64 {
+	if count == 0 || baselineCount == 0 || baseline == 0 {
+		return nil
+	}
+	lift := (value/baseline - 1) * 100
+	return &lift
+}
@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCollaborators(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"Duos w/ @Valkyrae & Sykkuno | !discord", []string{"valkyrae", "sykkuno"}},
+		{"Among Us ft. Pokimane, Corpse and Toast", []string{"pokimane", "corpse", "toast"}},
+		{"Ranked w/shroud playing Valorant", []string{"shroud"}},
+		{"feat. xQc (#ad)", []string{"xqc"}},
+		{"Chill stream with @Tenz and chat", []string{"tenz"}},
+		{"Playing with chat tonight", nil},
+		{"Speedrun w/o glitches", nil},
+		{"Elden Ring part 3 !merch", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, detectCollaborators(tt.title), tt.title)
+	}
+}
+
+func TestNormalizeCollaborators(t *testing.T) {
+	logins, err := normalizeCollaborators([]string{"@Shroud", " shroud ", "tenz"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shroud", "tenz"}, logins)
+
+	logins, err = normalizeCollaborators(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, logins)
+
+	_, err = normalizeCollaborators([]string{"not a login"})
+	assert.Error(t, err)
+}
+
+func TestBuildCollaborationReport(t *testing.T) {
+	content := []CollabContent{
+		{ContentType: "stream", ContentID: "s1", Title: "Solo ranked", AverageViewers: 100, FollowersGained: 10},
+		{ContentType: "stream", ContentID: "s2", Title: "Duos w/ @Valkyrae", AverageViewers: 150, FollowersGained: 30},
+		{ContentType: "stream", ContentID: "s3", Title: "Lethal Company ft. valkyrae & sykkuno", AverageViewers: 250, FollowersGained: 50},
+		// Tagged solo overrides the "ft." in the title
+		{ContentType: "stream", ContentID: "s4", Title: "Reacting ft. your clips", AverageViewers: 100, Source: "tagged", Collaborators: []string{}},
+		{ContentType: "video", ContentID: "v1", Title: "Best moments", ViewCount: 1000, Source: "tagged", Collaborators: []string{"tenz"}},
+		{ContentType: "video", ContentID: "v2", Title: "Patch notes", ViewCount: 500},
+	}
+
+	report := buildCollaborationReport(content)
+
+	assert.Equal(t, 2, report.Solo.Streams)
+	assert.Equal(t, 100.0, report.Solo.AvgViewers)
+	assert.Equal(t, 2, report.Collab.Streams)
+	assert.Equal(t, 200.0, report.Collab.AvgViewers)
+	assert.Equal(t, 40.0, report.Collab.AvgFollowersGained)
+	require.NotNil(t, report.ViewerLiftPercent)
+	assert.Equal(t, 100.0, *report.ViewerLiftPercent)
+	require.NotNil(t, report.VideoViewLiftPercent)
+	assert.Equal(t, 100.0, *report.VideoViewLiftPercent)
+
+	require.Len(t, report.Collaborators, 3)
+	assert.Equal(t, "valkyrae", report.Collaborators[0].Login)
+	assert.Equal(t, 2, report.Collaborators[0].Streams)
+	assert.Equal(t, "sykkuno", report.Collaborators[1].Login)
+	assert.Equal(t, 150.0, *report.Collaborators[1].ViewerLiftPercent)
+	assert.Equal(t, "tenz", report.Collaborators[2].Login)
+	assert.Nil(t, report.Collaborators[2].ViewerLiftPercent)
+
+	require.Len(t, report.Content, 3)
+	assert.Equal(t, "detected", report.Content[0].Source)
+	assert.Equal(t, "tagged", report.Content[2].Source)
+}
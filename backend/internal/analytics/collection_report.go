@@ -0,0 +1,106 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collection statuses, of a whole user collection and of its steps
+const (
+	CollectionCompleted = "completed"
+	CollectionPartial   = "partial"
+	CollectionFailed    = "failed"
+)
+
+// CollectionStep is the outcome of one part of a user's collection
+type CollectionStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Count is how many items the step saved, where it saves any
+	Count      int   `json:"count,omitempty"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// CollectionReport says which parts of a user's collection succeeded. Status
+// is partial when some steps failed and others didn't.
+type CollectionReport struct {
+	Status    string           `json:"status"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Steps     []CollectionStep `json:"steps"`
+
+	mu sync.Mutex
+}
+
+// run times step and records its outcome. It is safe to call from
+// concurrent steps.
+func (r *CollectionReport) run(name string, step func() (int, error)) {
+	started := time.Now()
+	count, err := step()
+
+	result := CollectionStep{
+		Name:       name,
+		Status:     CollectionCompleted,
+		Count:      count,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = CollectionFailed
+		result.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Steps = append(r.Steps, result)
+	if err != nil {
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	switch {
+	case r.Failed == 0:
+		r.Status = CollectionCompleted
+	case r.Succeeded == 0:
+		r.Status = CollectionFailed
+	default:
+		r.Status = CollectionPartial
+	}
+}
+
+// errorSummary lists the failed steps, or is empty when none failed
+func (r *CollectionReport) errorSummary() string {
+	var failed []string
+	for _, step := range r.Steps {
+		if step.Status == CollectionFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", step.Name, step.Error))
+		}
+	}
+	return strings.Join(failed, "; ")
+}
+
+// finishCollectionJob stores the report on the job. It runs even when the
+// collection's context was cancelled.
+func (dc *dataCollector) finishCollectionJob(ctx context.Context, job *AnalyticsJob, report *CollectionReport) {
+	if job.ID == 0 {
+		return
+	}
+
+	var errorMsg *string
+	if summary := report.errorSummary(); summary != "" {
+		errorMsg = &summary
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Failed to encode collection report for job %d: %v", job.ID, err)
+		return
+	}
+	if err := dc.repo.CompleteAnalyticsJob(context.WithoutCancel(ctx), job.ID, report.Status, errorMsg, data); err != nil {
+		log.Printf("Failed to save collection report for job %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionReportStatus(t *testing.T) {
+	report := &CollectionReport{}
+	report.run("channel", func() (int, error) { return 0, nil })
+	assert.Equal(t, CollectionCompleted, report.Status)
+
+	report.run("videos", func() (int, error) { return 12, errors.New("failed to get videos: timeout") })
+	assert.Equal(t, CollectionPartial, report.Status)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 12, report.Steps[1].Count)
+	assert.Equal(t, "videos: failed to get videos: timeout", report.errorSummary())
+
+	failed := &CollectionReport{}
+	failed.run("channel", func() (int, error) { return 0, errors.New("no token") })
+	assert.Equal(t, CollectionFailed, failed.Status)
+}
+
+func TestCollectionReportConcurrentSteps(t *testing.T) {
+	report := &CollectionReport{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.run("step", func() (int, error) { return 1, nil })
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, report.Steps, 20)
+	assert.Equal(t, 20, report.Succeeded)
+}
+
+func TestFinishCollectionJobSavesReport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// The report is saved even when the collection was cancelled
+	cancel()
+
+	repo := &mockRepository{}
+	var saved []byte
+	repo.On("CompleteAnalyticsJob", mock.Anything, 7, CollectionPartial, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		require.NoError(t, args.Get(0).(context.Context).Err())
+		msg := args.Get(3).(*string)
+		require.NotNil(t, msg)
+		assert.Equal(t, "channel: no token", *msg)
+		saved = args.Get(4).([]byte)
+	}).Return(nil)
+
+	report := &CollectionReport{}
+	report.run("channel", func() (int, error) { return 0, errors.New("no token") })
+	report.run("videos", func() (int, error) { return 3, nil })
+
+	dc := &dataCollector{repo: repo}
+	dc.finishCollectionJob(ctx, &AnalyticsJob{ID: 7}, report)
+	repo.AssertExpectations(t)
+
+	var decoded CollectionReport
+	require.NoError(t, json.Unmarshal(saved, &decoded))
+	assert.Equal(t, CollectionPartial, decoded.Status)
+	require.Len(t, decoded.Steps, 2)
+	assert.Equal(t, "videos", decoded.Steps[1].Name)
+	assert.Equal(t, 3, decoded.Steps[1].Count)
+}
@@ -6,31 +6,92 @@ import (
 	"log"
 	"time"
 
-	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/export"
 	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"golang.org/x/sync/errgroup"
 )
 
+// DataCollector is the one contract for collecting a user's Twitch data. The
+// scheduler runs the daily and stream collections and retries them, the
+// service and handlers run the others on demand.
 type DataCollector interface {
+	// CollectDailyChannelData saves today's channel snapshot, then runs the
+	// collection hooks
 	CollectDailyChannelData(ctx context.Context, userID string) error
+	// CollectStreamData samples the user's live stream
 	CollectStreamData(ctx context.Context, userID string) error
-	CollectVideoData(ctx context.Context, userID string) error
-	CollectAllUserData(ctx context.Context, userID string) error
+	// CollectVideoData saves the user's videos and clips, then runs the video hooks
+	CollectVideoData(ctx context.Context, userID string, opts CollectionOptions) error
+	// CollectAllUserData runs every collection and reports which parts succeeded
+	CollectAllUserData(ctx context.Context, userID string, opts CollectionOptions) (*CollectionReport, error)
+	// BackfillFollowerHistory reconstructs daily follower counts once per user
+	BackfillFollowerHistory(ctx context.Context, userID string) error
+	// AddCollectionHook, AddVideoHook and AddChangeHook register hooks before
+	// collection starts
+	AddCollectionHook(hook CollectionHook)
+	AddVideoHook(hook CollectionHook)
+	AddChangeHook(hook ChangeHook)
 }
 
+var _ DataCollector = (*dataCollector)(nil)
+
+// CollectionHook runs after a user's daily channel data has been saved, e.g. to
+// evaluate alert rules against it, or after their videos have been saved
+type CollectionHook func(ctx context.Context, userID string) error
+
 type dataCollector struct {
 	repo         Repository
-	twitchClient *twitch.Client
+	twitchClient TwitchAPI
+	tokens       *TwitchTokenHelper
+	sink         export.Sink
+	hooks        []CollectionHook
+	videoHooks   []CollectionHook
+	changeHooks  []ChangeHook
 }
 
-func NewDataCollector(repo Repository, twitchClient *twitch.Client) DataCollector {
+func NewDataCollector(repo Repository, twitchClient TwitchAPI) DataCollector {
+	sink, err := export.NewSinkFromConfig()
+	if err != nil {
+		log.Printf("⚠️ Analytics snapshot export disabled: %v", err)
+	}
+
 	return &dataCollector{
 		repo:         repo,
 		twitchClient: twitchClient,
+		tokens:       NewTwitchTokenHelper(repo, twitchClient),
+		sink:         sink,
 	}
 }
 
-// CollectDailyChannelData collects channel metrics for a given day
+// AddCollectionHook registers a hook to run after each daily channel collection.
+// Hooks must be added before collection starts.
+func (dc *dataCollector) AddCollectionHook(hook CollectionHook) {
+	dc.hooks = append(dc.hooks, hook)
+}
+
+// AddVideoHook registers a hook to run after each video collection, e.g. to
+// enrich the saved titles. Hooks must be added before collection starts.
+func (dc *dataCollector) AddVideoHook(hook CollectionHook) {
+	dc.videoHooks = append(dc.videoHooks, hook)
+}
+
+// AddChangeHook registers a hook to run after each collection that changed
+// something, with its change summary. Hooks must be added before collection
+// starts.
+func (dc *dataCollector) AddChangeHook(hook ChangeHook) {
+	dc.changeHooks = append(dc.changeHooks, hook)
+}
+
+// CollectDailyChannelData collects channel metrics for a given day and
+// records what changed on its job
 func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID string) error {
+	return dc.collectDailyChannelData(ctx, userID, true)
+}
+
+// collectDailyChannelData collects channel metrics for a given day. Without
+// trackChanges the caller records the changes, as user collections do.
+func (dc *dataCollector) collectDailyChannelData(ctx context.Context, userID string, trackChanges bool) error {
 	job := &AnalyticsJob{
 		UserID:   userID,
 		JobType:  "daily_channel",
@@ -50,12 +111,20 @@ func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID str
 				status = "failed"
 				errorMsg = &job.ErrorMessage
 			}
-			dc.repo.UpdateAnalyticsJob(ctx, job.ID, status, errorMsg)
+			dc.repo.UpdateAnalyticsJob(context.WithoutCancel(ctx), job.ID, status, errorMsg)
 		}
 	}()
 
+	var before *collectionSnapshot
+	if trackChanges {
+		var err error
+		if before, err = dc.snapshot(ctx, userID, false); err != nil {
+			log.Printf("Not recording changes of daily collection for user %s: %v", userID, err)
+		}
+	}
+
 	// Get user's Twitch OAuth token
-	twitchToken, err := clerk.GetOAuthToken(ctx, userID, "oauth_twitch")
+	twitchToken, err := dc.tokens.GetValidToken(ctx, userID)
 	if err != nil {
 		job.ErrorMessage = fmt.Sprintf("Failed to get Twitch token: %v", err)
 		return err
@@ -73,9 +142,14 @@ func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID str
 
 	// Try to get user info first to get total view count
 	log.Printf("Fetching user info for user %s", userID)
-	userInfo, err := dc.twitchClient.GetUserInfo(twitchToken)
+	userInfo, err := dc.twitchClient.GetUserInfo(ctx, twitchToken)
 	if err != nil {
 		log.Printf("Failed to get user info: %v", err)
+		if twitch.IsUnauthorized(err) {
+			// A revoked token fails every other call too; report it so the user gets parked
+			job.ErrorMessage = fmt.Sprintf("Twitch rejected the token: %v", err)
+			return err
+		}
 	} else {
 		log.Printf("Successfully got user info for %s (ID: %s, Login: %s, ViewCount: %d)",
 			userInfo.DisplayName, userInfo.ID, userInfo.Login, userInfo.ViewCount)
@@ -84,16 +158,17 @@ func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID str
 
 	// Try to get channel info
 	log.Printf("Fetching channel info for user %s", userID)
-	_, err = dc.twitchClient.GetChannelInfoWithToken(twitchToken)
+	channel, err := dc.twitchClient.GetChannelInfoWithToken(ctx, twitchToken)
 	if err != nil {
 		log.Printf("Failed to get channel info: %v", err)
 	} else {
 		log.Printf("Successfully got channel info for user %s", userID)
+		analytics.BroadcasterLanguage = channel.BroadcasterLanguage
 	}
 
 	// Try to get follower count
 	log.Printf("Fetching follower count for user %s", userID)
-	followers, err := dc.twitchClient.GetFollowerCount(twitchToken)
+	followers, err := dc.twitchClient.GetFollowerCount(ctx, twitchToken)
 	if err != nil {
 		log.Printf("Failed to get follower count: %v", err)
 	} else {
@@ -103,7 +178,7 @@ func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID str
 
 	// Try to get subscriber count
 	log.Printf("Fetching subscriber count for user %s", userID)
-	subscribers, err := dc.twitchClient.GetSubscriberCount(twitchToken)
+	subscribers, err := dc.twitchClient.GetSubscriberCount(ctx, twitchToken)
 	if err != nil {
 		log.Printf("Failed to get subscriber count (may be normal for non-partners): %v", err)
 	} else {
@@ -111,6 +186,14 @@ func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID str
 		analytics.SubscriberCount = subscribers
 	}
 
+	// Revenue, moderation, schedule and goal snapshots need the broadcaster ID from the user info
+	if userInfo != nil {
+		dc.collectRevenueData(ctx, userID, twitchToken, userInfo.ID)
+		dc.collectModerationData(ctx, userID, twitchToken, userInfo.ID)
+		dc.collectStreamSchedule(ctx, userID, twitchToken, userInfo.ID)
+		dc.collectCreatorGoals(ctx, userID, twitchToken, userInfo.ID)
+	}
+
 	// Save to database (always save what we have, even if some calls failed)
 	log.Printf("Saving channel analytics for user %s", userID)
 	if err := dc.repo.SaveChannelAnalytics(ctx, analytics); err != nil {
@@ -120,11 +203,19 @@ func (dc *dataCollector) CollectDailyChannelData(ctx context.Context, userID str
 
 	log.Printf("Successfully collected and saved channel data for user %s (followers: %d, views: %d, subscribers: %d)",
 		userID, analytics.FollowersCount, analytics.TotalViews, analytics.SubscriberCount)
+
+	for _, hook := range dc.hooks {
+		if err := hook(ctx, userID); err != nil {
+			log.Printf("Collection hook failed for user %s: %v", userID, err)
+		}
+	}
+
+	dc.recordChanges(ctx, job, before)
 	return nil
 }
 
-// CollectVideoData collects video analytics (VODs, clips, highlights)
-func (dc *dataCollector) CollectVideoData(ctx context.Context, userID string) error {
+// CollectVideoData collects video analytics (VODs, highlights, uploads and optionally clips)
+func (dc *dataCollector) CollectVideoData(ctx context.Context, userID string, opts CollectionOptions) error {
 	job := &AnalyticsJob{
 		UserID:  userID,
 		JobType: "video_data",
@@ -143,53 +234,204 @@ func (dc *dataCollector) CollectVideoData(ctx context.Context, userID string) er
 				status = "failed"
 				errorMsg = &job.ErrorMessage
 			}
-			dc.repo.UpdateAnalyticsJob(ctx, job.ID, status, errorMsg)
+			dc.repo.UpdateAnalyticsJob(context.WithoutCancel(ctx), job.ID, status, errorMsg)
 		}
 	}()
 
+	if _, err := dc.collectVideoData(ctx, userID, opts); err != nil {
+		job.ErrorMessage = err.Error()
+		return err
+	}
+	return nil
+}
+
+// collectVideoData saves the user's videos and clips and returns how many were
+// saved. Failing to fetch the videos is an error, but what was fetched is
+// still saved.
+func (dc *dataCollector) collectVideoData(ctx context.Context, userID string, opts CollectionOptions) (int, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return 0, fmt.Errorf("invalid collection options: %w", err)
+	}
+
 	// Get user's Twitch OAuth token
-	twitchToken, err := clerk.GetOAuthToken(ctx, userID, "oauth_twitch")
+	twitchToken, err := dc.tokens.GetValidToken(ctx, userID)
 	if err != nil {
-		job.ErrorMessage = fmt.Sprintf("Failed to get Twitch token: %v", err)
-		return err
+		return 0, fmt.Errorf("failed to get Twitch token: %w", err)
 	}
 
-	// Collect VODs
-	log.Printf("Fetching VODs for user %s", userID)
-	vods, err := dc.twitchClient.GetVideos(twitchToken, "archive", 50)
+	userInfo, err := dc.twitchClient.GetUserInfo(ctx, twitchToken)
 	if err != nil {
-		log.Printf("Failed to get VODs: %v", err)
-	} else {
-		log.Printf("Found %d VODs for user %s", len(vods), userID)
+		return 0, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// Collect videos
+	log.Printf("Fetching up to %d videos (%v) for user %s", opts.MaxVideos, opts.VideoTypes, userID)
+	saved := 0
+	videos, fetchErr := dc.fetchAllVideos(ctx, twitchToken, userInfo.ID, opts)
+	if fetchErr != nil {
+		log.Printf("Failed to get videos: %v", fetchErr)
+		fetchErr = fmt.Errorf("failed to get videos: %w", fetchErr)
+	}
+	if len(videos) > 0 {
+		log.Printf("Found %d videos for user %s", len(videos), userID)
 		videosSaved := 0
-		for _, vod := range vods {
-			// Convert duration string to seconds (simplified)
+		for _, vod := range videos {
+			// Twitch durations use Go's duration format (e.g., "1h23m45s")
 			durationSeconds := 0
-			// TODO: Parse duration string properly (e.g., "1h23m45s" -> seconds)
+			if duration, err := time.ParseDuration(vod.Duration); err == nil {
+				durationSeconds = int(duration.Seconds())
+			}
 
 			video := &VideoAnalytics{
 				UserID:       userID,
 				VideoID:      vod.ID,
 				Title:        vod.Title,
-				VideoType:    "vod",
+				VideoType:    storedVideoType(vod.Type),
 				Duration:     durationSeconds,
 				ViewCount:    vod.ViewCount,
 				ThumbnailURL: vod.ThumbnailURL,
 				PublishedAt:  &vod.PublishedAt,
+				Language:     vod.Language,
+			}
+			for _, segment := range vod.MutedSegments {
+				video.MutedSegments = append(video.MutedSegments, MutedSegment{Offset: segment.Offset, Duration: segment.Duration})
 			}
 
 			if err := dc.repo.SaveVideoAnalytics(ctx, video); err != nil {
-				log.Printf("Failed to save video analytics for VOD %s (%s): %v", vod.ID, vod.Title, err)
+				log.Printf("Failed to save video analytics for video %s (%s): %v", vod.ID, vod.Title, err)
 			} else {
 				videosSaved++
-				log.Printf("Saved video: %s (ID: %s, Views: %d)", vod.Title, vod.ID, vod.ViewCount)
 			}
 		}
-		log.Printf("Successfully saved %d out of %d VODs for user %s", videosSaved, len(vods), userID)
+		log.Printf("Successfully saved %d out of %d videos for user %s", videosSaved, len(videos), userID)
+		saved += videosSaved
+	}
+
+	// Collect clips
+	if opts.IncludeClips {
+		maxClips := config.Collection().MaxClips
+		log.Printf("Fetching up to %d clips for user %s", maxClips, userID)
+		clips, err := dc.fetchClips(ctx, twitchToken, userInfo.ID, maxClips)
+		if err != nil {
+			log.Printf("Failed to get clips: %v", err)
+		}
+
+		clipsSaved := 0
+		for _, clip := range clips {
+			video := &VideoAnalytics{
+				UserID:       userID,
+				VideoID:      clip.ID,
+				Title:        clip.Title,
+				VideoType:    "clip",
+				Duration:     int(clip.Duration),
+				ViewCount:    clip.ViewCount,
+				ThumbnailURL: clip.ThumbnailURL,
+				PublishedAt:  &clip.CreatedAt,
+				Language:     clip.Language,
+			}
+			if clip.VideoID != "" && clip.VodOffset != nil {
+				video.SourceVideoID = clip.VideoID
+				video.VodOffset = clip.VodOffset
+			}
+
+			if err := dc.repo.SaveVideoAnalytics(ctx, video); err != nil {
+				log.Printf("Failed to save video analytics for clip %s (%s): %v", clip.ID, clip.Title, err)
+			} else {
+				clipsSaved++
+			}
+		}
+		log.Printf("Successfully saved %d out of %d clips for user %s", clipsSaved, len(clips), userID)
+		saved += clipsSaved
+	}
+
+	// Attribute VODs to the streams they were recorded from
+	if linked, err := dc.repo.LinkVideosToStreamSessions(ctx, userID); err != nil {
+		log.Printf("Failed to link VODs to stream sessions for user %s: %v", userID, err)
+	} else if linked > 0 {
+		log.Printf("Linked %d VODs to stream sessions for user %s", linked, userID)
+	}
+
+	if err := dc.updateConsistency(ctx, userID); err != nil {
+		log.Printf("Failed to update consistency for user %s: %v", userID, err)
 	}
 
+	if err := dc.updateHighlightSuggestions(ctx, userID); err != nil {
+		log.Printf("Failed to update highlight suggestions for user %s: %v", userID, err)
+	}
+
+	for _, hook := range dc.videoHooks {
+		if err := hook(ctx, userID); err != nil {
+			log.Printf("Video collection hook failed for user %s: %v", userID, err)
+		}
+	}
+
+	if fetchErr != nil {
+		return saved, fetchErr
+	}
 	log.Printf("Successfully completed video data collection for user %s", userID)
-	return nil
+	return saved, nil
+}
+
+// fetchAllVideos pages through the user's videos for each requested type until
+// opts.MaxVideos videos have been collected or Twitch runs out of pages
+func (dc *dataCollector) fetchAllVideos(ctx context.Context, twitchToken, twitchUserID string, opts CollectionOptions) ([]twitch.VideoInfo, error) {
+	var videos []twitch.VideoInfo
+
+	for _, videoType := range opts.twitchVideoTypes() {
+		cursor := ""
+		for len(videos) < opts.MaxVideos {
+			page, next, err := dc.twitchClient.GetUserVideosPage(ctx, twitchToken, twitchUserID, videoType, opts.MaxVideos-len(videos), cursor)
+			if err != nil {
+				return videos, fmt.Errorf("failed to get %s videos: %w", videoType, err)
+			}
+
+			videos = append(videos, page...)
+
+			if next == "" || len(page) == 0 {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	return videos, nil
+}
+
+// fetchClips pages through the broadcaster's clips until maxClips have been collected
+func (dc *dataCollector) fetchClips(ctx context.Context, twitchToken, broadcasterID string, maxClips int) ([]twitch.ClipInfo, error) {
+	var clips []twitch.ClipInfo
+
+	cursor := ""
+	for len(clips) < maxClips {
+		limit := maxClips - len(clips)
+		if limit > 100 {
+			limit = 100
+		}
+
+		page, err := dc.twitchClient.GetClipsPage(ctx, twitchToken, broadcasterID, limit, cursor)
+		if err != nil {
+			return clips, err
+		}
+
+		clips = append(clips, page.Data...)
+
+		if page.Pagination.Cursor == "" || len(page.Data) == 0 {
+			break
+		}
+		cursor = page.Pagination.Cursor
+	}
+
+	return clips, nil
+}
+
+// storedVideoType maps Twitch video types to the values stored in video_analytics;
+// past broadcasts have always been stored as "vod"
+func storedVideoType(twitchType string) string {
+	if twitchType == "archive" || twitchType == "" {
+		return "vod"
+	}
+	return twitchType
 }
 
 // CollectStreamData collects basic stream data (simplified version)
@@ -211,13 +453,13 @@ func (dc *dataCollector) ensureUserExists(ctx context.Context, userID string) er
 	}
 
 	// Get user's Twitch OAuth token to fetch profile info
-	twitchToken, err := clerk.GetOAuthToken(ctx, userID, "oauth_twitch")
+	twitchToken, err := dc.tokens.GetValidToken(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get Twitch token: %w", err)
 	}
 
 	// Fetch user info from Twitch
-	userInfo, err := dc.twitchClient.GetUserInfo(twitchToken)
+	userInfo, err := dc.twitchClient.GetUserInfo(ctx, twitchToken)
 	if err != nil {
 		return fmt.Errorf("failed to get user info from Twitch: %w", err)
 	}
@@ -241,31 +483,79 @@ func (dc *dataCollector) ensureUserExists(ctx context.Context, userID string) er
 	return nil
 }
 
-// CollectAllUserData runs all data collection for a user
-func (dc *dataCollector) CollectAllUserData(ctx context.Context, userID string) error {
+// CollectAllUserData runs all data collection for a user. The channel and
+// video parts run side by side, each within its configured timeout, and one
+// failing doesn't stop the other. The returned report, also saved on the
+// user_collection job, says which parts succeeded. Only failing to set up the
+// user is an error.
+func (dc *dataCollector) CollectAllUserData(ctx context.Context, userID string, opts CollectionOptions) (*CollectionReport, error) {
 	log.Printf("Starting complete data collection for user %s", userID)
 
 	// Ensure user record exists before collecting analytics
 	if err := dc.ensureUserExists(ctx, userID); err != nil {
 		log.Printf("Failed to ensure user exists for %s: %v", userID, err)
-		return err
+		return nil, err
 	}
 
-	// Collect channel data
-	if err := dc.CollectDailyChannelData(ctx, userID); err != nil {
-		log.Printf("Channel data collection failed for user %s: %v", userID, err)
+	job := &AnalyticsJob{
+		UserID:  userID,
+		JobType: "user_collection",
+		Status:  "running",
 	}
-
-	// Collect video data
-	if err := dc.CollectVideoData(ctx, userID); err != nil {
-		log.Printf("Video data collection failed for user %s: %v", userID, err)
+	if err := dc.repo.CreateAnalyticsJob(ctx, job); err != nil {
+		log.Printf("Failed to create analytics job: %v", err)
 	}
 
-	// Collect stream data
-	if err := dc.CollectStreamData(ctx, userID); err != nil {
-		log.Printf("Stream data collection failed for user %s: %v", userID, err)
+	before, err := dc.snapshot(ctx, userID, true)
+	if err != nil {
+		log.Printf("Not recording changes of collection for user %s: %v", userID, err)
 	}
 
-	log.Printf("Completed data collection for user %s", userID)
-	return nil
+	report := &CollectionReport{}
+	defer dc.recordChanges(ctx, job, before)
+	defer dc.finishCollectionJob(ctx, job, report)
+
+	// Reconstruct follower history the first time we collect for this user
+	report.run("follower_backfill", func() (int, error) {
+		return 0, dc.BackfillFollowerHistory(ctx, userID)
+	})
+
+	cfg := config.Collection()
+	var g errgroup.Group
+	g.Go(func() error {
+		report.run("channel", func() (int, error) {
+			channelCtx, cancel := context.WithTimeout(ctx, cfg.ChannelTimeout)
+			defer cancel()
+			return 0, dc.collectDailyChannelData(channelCtx, userID, false)
+		})
+		return nil
+	})
+	g.Go(func() error {
+		report.run("videos", func() (int, error) {
+			videoCtx, cancel := context.WithTimeout(ctx, cfg.VideoTimeout)
+			defer cancel()
+			return dc.collectVideoData(videoCtx, userID, opts)
+		})
+		return nil
+	})
+	g.Wait()
+
+	// Collect stream data
+	report.run("streams", func() (int, error) {
+		return 0, dc.CollectStreamData(ctx, userID)
+	})
+
+	// Start receiving channel points redemptions
+	report.run("eventsub", func() (int, error) {
+		return 0, dc.ensureEventSubscriptions(ctx, userID)
+	})
+
+	// Export a snapshot of everything collected so far
+	report.run("snapshot_export", func() (int, error) {
+		return 0, dc.exportSnapshot(ctx, userID)
+	})
+
+	log.Printf("Completed data collection for user %s: %s (%d succeeded, %d failed)",
+		userID, report.Status, report.Succeeded, report.Failed)
+	return report, nil
 }
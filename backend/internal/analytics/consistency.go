@@ -0,0 +1,143 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// consistencyWindowWeeks is the window for the averages and the longest gap
+	consistencyWindowWeeks = 12
+	// consistencyHistoryWeeks bounds how far back streaks are followed
+	consistencyHistoryWeeks = 104
+	// streamActivityDays is the window of the streams and hours streamed tiles
+	streamActivityDays = 30
+)
+
+// updateConsistency recomputes and stores the user's consistency figures
+func (dc *dataCollector) updateConsistency(ctx context.Context, userID string) error {
+	now := time.Now()
+	streams, uploads, err := dc.repo.GetBroadcastTimes(ctx, userID, startOfWeek(now).AddDate(0, 0, -7*consistencyHistoryWeeks))
+	if err != nil {
+		return err
+	}
+
+	consistency := computeConsistency(streams, uploads, now)
+	consistency.UserID = userID
+
+	consistency.StreamsLast30Days, consistency.HoursStreamedLast30, err = dc.repo.GetStreamActivity(ctx, userID, now.AddDate(0, 0, -streamActivityDays))
+	if err != nil {
+		return err
+	}
+	if err := dc.repo.SaveChannelConsistency(ctx, consistency); err != nil {
+		return fmt.Errorf("failed to save channel consistency: %w", err)
+	}
+
+	log.Printf("Updated consistency for user %s: %d week streak, %.1f streams/week, %d streams in %d days",
+		userID, consistency.CurrentStreakWeeks, consistency.AverageStreamsPerWeek, consistency.StreamsLast30Days, streamActivityDays)
+	return nil
+}
+
+// computeConsistency derives streaks, weekly averages and the longest gap from
+// stream and upload times. Weeks are ISO weeks in UTC. The current week does
+// not break a streak until it is over, and the longest gap includes the time
+// since the last stream.
+func computeConsistency(streams, uploads []time.Time, now time.Time) *ChannelConsistency {
+	streams = sortedTimes(streams)
+	uploads = sortedTimes(uploads)
+	consistency := &ChannelConsistency{}
+	if len(streams) == 0 && len(uploads) == 0 {
+		return consistency
+	}
+
+	// Streaks
+	streamed := make(map[time.Time]bool)
+	for _, at := range streams {
+		streamed[startOfWeek(at)] = true
+	}
+
+	week := startOfWeek(now)
+	if !streamed[week] {
+		week = week.AddDate(0, 0, -7)
+	}
+	for streamed[week] {
+		consistency.CurrentStreakWeeks++
+		week = week.AddDate(0, 0, -7)
+	}
+
+	weeks := make([]time.Time, 0, len(streamed))
+	for w := range streamed {
+		weeks = append(weeks, w)
+	}
+	weeks = sortedTimes(weeks)
+	run := 0
+	for i, w := range weeks {
+		if i > 0 && w.Equal(weeks[i-1].AddDate(0, 0, 7)) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > consistency.LongestStreakWeeks {
+			consistency.LongestStreakWeeks = run
+		}
+	}
+
+	// Weekly averages over the window, or since the first activity for newer channels
+	windowStart := startOfWeek(now).AddDate(0, 0, -7*(consistencyWindowWeeks-1))
+	first := now
+	if len(streams) > 0 {
+		first = streams[0]
+	}
+	if len(uploads) > 0 && uploads[0].Before(first) {
+		first = uploads[0]
+	}
+	if start := startOfWeek(first); start.After(windowStart) {
+		windowStart = start
+	}
+	windowWeeks := int(startOfWeek(now).Sub(windowStart).Hours()/(24*7)) + 1
+
+	consistency.AverageStreamsPerWeek = float64(countSince(streams, windowStart)) / float64(windowWeeks)
+	consistency.AverageUploadsPerWeek = float64(countSince(uploads, windowStart)) / float64(windowWeeks)
+
+	// Longest gap between streams in the window, counting the stream just
+	// before it and the time since the last one
+	if len(streams) > 0 {
+		last := streams[len(streams)-1]
+		consistency.LastStreamAt = &last
+
+		var longest time.Duration
+		for i := 1; i < len(streams); i++ {
+			if streams[i].Before(windowStart) {
+				continue
+			}
+			if gap := streams[i].Sub(streams[i-1]); gap > longest {
+				longest = gap
+			}
+		}
+		if gap := now.Sub(last); gap > longest {
+			longest = gap
+		}
+		consistency.LongestGapDays = int(longest.Hours() / 24)
+	}
+
+	return consistency
+}
+
+func sortedTimes(times []time.Time) []time.Time {
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	return sorted
+}
+
+func countSince(times []time.Time, since time.Time) int {
+	count := 0
+	for _, at := range times {
+		if !at.Before(since) {
+			count++
+		}
+	}
+	return count
+}
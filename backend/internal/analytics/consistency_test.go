@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeConsistency(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 6, 18, 12, 0, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 20, 0, 0, 0, time.UTC) }
+
+	streams := []time.Time{
+		// An older run of three weeks
+		day(2025, 3, 3), day(2025, 3, 10), day(2025, 3, 17),
+		// Three weeks in a row up to last week; none yet this week
+		day(2025, 5, 27), day(2025, 5, 29), day(2025, 6, 2), day(2025, 6, 11),
+		day(2025, 5, 20),
+	}
+	uploads := []time.Time{day(2025, 6, 14)}
+
+	c := computeConsistency(streams, uploads, now)
+	assert.Equal(t, 4, c.CurrentStreakWeeks)
+	assert.Equal(t, 4, c.LongestStreakWeeks)
+	// 5 streams in the 12 weeks since 2025-03-31
+	assert.InDelta(t, 5.0/12.0, c.AverageStreamsPerWeek, 0.0001)
+	assert.InDelta(t, 1.0/12.0, c.AverageUploadsPerWeek, 0.0001)
+	// 2025-03-17 to 2025-05-20 crosses into the window
+	assert.Equal(t, 64, c.LongestGapDays)
+	assert.Equal(t, day(2025, 6, 11), *c.LastStreamAt)
+}
+
+func TestComputeConsistencyNewChannel(t *testing.T) {
+	now := time.Date(2025, 6, 18, 12, 0, 0, 0, time.UTC)
+	streams := []time.Time{
+		time.Date(2025, 6, 10, 20, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 16, 20, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 17, 20, 0, 0, 0, time.UTC),
+	}
+
+	c := computeConsistency(streams, nil, now)
+	assert.Equal(t, 2, c.CurrentStreakWeeks)
+	// Averaged over the two weeks the channel has existed
+	assert.InDelta(t, 1.5, c.AverageStreamsPerWeek, 0.0001)
+	assert.Equal(t, 6, c.LongestGapDays)
+}
+
+func TestComputeConsistencyStreakBroken(t *testing.T) {
+	now := time.Date(2025, 6, 18, 12, 0, 0, 0, time.UTC)
+	streams := []time.Time{time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)}
+
+	c := computeConsistency(streams, nil, now)
+	assert.Equal(t, 0, c.CurrentStreakWeeks)
+	assert.Equal(t, 1, c.LongestStreakWeeks)
+	assert.Equal(t, 16, c.LongestGapDays)
+
+	empty := computeConsistency(nil, nil, now)
+	assert.Equal(t, 0, empty.CurrentStreakWeeks)
+	assert.Nil(t, empty.LastStreamAt)
+}
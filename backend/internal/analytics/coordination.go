@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/database"
+)
+
+// With several API replicas, the one holding schedulerLockName runs the
+// scheduled jobs and the others stand by. Collections of a user, scheduled or
+// manual, hold the user's collection lock, so replicas never collect the same
+// user at once.
+const schedulerLockName = "analytics_scheduler"
+
+func userCollectionLockName(userID string) string {
+	return "analytics_collection:" + userID
+}
+
+// awaitLeadership waits until this replica holds the scheduler lock. The lock
+// is nil when there is nothing to coordinate with. It fails only once ctx ends.
+func (s *scheduler) awaitLeadership(ctx context.Context) (database.Lock, error) {
+	if s.locks == nil {
+		return nil, nil
+	}
+
+	interval := config.Scheduler().LeaderCheckInterval
+	standby := false
+	for {
+		lock, ok, err := s.locks.TryLock(ctx, schedulerLockName)
+		switch {
+		case err != nil:
+			log.Printf("Failed to take the scheduler lock: %v", err)
+		case ok:
+			log.Println("🗓️ This replica now runs the scheduled jobs")
+			return lock, nil
+		case !standby:
+			log.Println("Another replica runs the scheduled jobs, standing by")
+			standby = true
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// holdLeadership returns once ctx ends or the scheduler lock may have been
+// lost, e.g. because the database restarted
+func (s *scheduler) holdLeadership(ctx context.Context, lock database.Lock) {
+	if lock == nil {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(config.Scheduler().LeaderCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := lock.Check(ctx); err != nil {
+				log.Printf("Stopping the scheduled jobs: %v", err)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// withUserLock runs collect while holding the user's collection lock. ok is
+// false, and collect doesn't run, when the user is being collected already,
+// on this replica or another.
+func (s *scheduler) withUserLock(ctx context.Context, userID string, collect func() error) (ok bool, err error) {
+	release, ok, err := s.lockUser(ctx, userID)
+	if !ok {
+		return false, err
+	}
+	defer release()
+	return true, collect()
+}
+
+// lockUser takes the user's collection lock. ok is false when the user is
+// being collected already. Without locks to coordinate with it always
+// succeeds.
+func (s *scheduler) lockUser(ctx context.Context, userID string) (release func(), ok bool, err error) {
+	if s.locks == nil {
+		return func() {}, true, nil
+	}
+
+	lock, ok, err := s.locks.TryLock(ctx, userCollectionLockName(userID))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to take collection lock: %w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	return lock.Release, true, nil
+}
@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/database"
+)
+
+// memoryLocker stands in for the advisory locks shared by replicas
+type memoryLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func newMemoryLocker() *memoryLocker {
+	return &memoryLocker{held: make(map[string]bool)}
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, name string) (database.Lock, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return &memoryLock{locker: l, name: name}, true, nil
+}
+
+func (l *memoryLocker) isHeld(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held[name]
+}
+
+type memoryLock struct {
+	locker *memoryLocker
+	name   string
+}
+
+func (l *memoryLock) Check(ctx context.Context) error { return nil }
+
+func (l *memoryLock) Release() {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	delete(l.locker.held, l.name)
+}
+
+// scheduling reports whether the scheduler's daily snapshot has a next run
+func scheduling(s *scheduler) bool {
+	for _, status := range s.Schedules() {
+		if status.Name == "daily_snapshot" {
+			return status.NextRun != nil
+		}
+	}
+	return false
+}
+
+func TestOnlyOneReplicaRunsScheduledJobs(t *testing.T) {
+	t.Setenv("SCHEDULER_LEADER_CHECK_INTERVAL", "10ms")
+	locks := newMemoryLocker()
+	first := &scheduler{locks: locks}
+	second := &scheduler{locks: locks}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- first.Run(ctx) }()
+	require.Eventually(t, func() bool { return scheduling(first) }, time.Second, 5*time.Millisecond)
+
+	standbyCtx, standbyCancel := context.WithCancel(context.Background())
+	defer standbyCancel()
+	standbyDone := make(chan error)
+	go func() { standbyDone <- second.Run(standbyCtx) }()
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, scheduling(second))
+
+	// The standby takes over once the first replica stops
+	cancel()
+	require.NoError(t, <-done)
+	require.Eventually(t, func() bool { return scheduling(second) }, time.Second, 5*time.Millisecond)
+
+	standbyCancel()
+	require.NoError(t, <-standbyDone)
+	assert.False(t, locks.isHeld(schedulerLockName))
+}
+
+func TestCollectionSkipsUserCollectedElsewhere(t *testing.T) {
+	t.Setenv("SCHEDULER_MAX_JITTER", "0s")
+	s, repo, collector := newTestScheduler()
+	locks := newMemoryLocker()
+	s.locks = locks
+	ctx := context.Background()
+
+	// Another replica is collecting user_1
+	held, ok, err := locks.TryLock(ctx, userCollectionLockName("user_1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	defer held.Release()
+
+	repo.On("ListCollectableUserIDs", ctx).Return([]string{"user_1", "user_2"}, nil)
+	repo.On("CreateAnalyticsJob", ctx, mock.Anything).Return(nil)
+	collector.On("CollectDailyChannelData", ctx, "user_2").Return(nil).Once()
+
+	s.runDailyCollectionForAllUsers(ctx)
+
+	collector.AssertExpectations(t)
+	collector.AssertNotCalled(t, "CollectDailyChannelData", ctx, "user_1")
+	assert.False(t, locks.isHeld(userCollectionLockName("user_2")))
+}
+
+func TestManualCollectionSkipsUserCollectedElsewhere(t *testing.T) {
+	s, _, collector := newTestScheduler()
+	locks := newMemoryLocker()
+	s.locks = locks
+	ctx := context.Background()
+
+	held, ok, err := locks.TryLock(ctx, userCollectionLockName("user_1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.False(t, s.TriggerUserCollection("user_1", DefaultCollectionOptions()))
+	held.Release()
+
+	// Once the other collection ends the user is collected, and the lock is
+	// held until it finishes
+	collector.On("CollectAllUserData", mock.Anything, "user_1", mock.Anything).Return(&CollectionReport{Status: CollectionCompleted}, nil).Once()
+	assert.True(t, s.TriggerUserCollection("user_1", DefaultCollectionOptions()))
+	require.True(t, s.Drain(ctx))
+	collector.AssertExpectations(t)
+	assert.False(t, locks.isHeld(userCollectionLockName("user_1")))
+}
@@ -0,0 +1,422 @@
+package analytics
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxImportRows bounds the days of one import, over 13 years
+	maxImportRows = 5000
+	// maxImportErrors bounds the row errors listed in the report
+	maxImportErrors = 20
+)
+
+// ErrInvalidImport is returned for files that aren't a channel analytics CSV
+var ErrInvalidImport = errors.New("invalid CSV")
+
+// importColumns maps the columns read from Twitch's channel analytics export
+// to the header names they've had
+var importColumns = map[string][]string{
+	"date":             {"date", "day"},
+	"minutes_streamed": {"minutes streamed", "time streamed (minutes)"},
+	"average_viewers":  {"average viewers", "avg viewers", "avg. viewers"},
+	"max_viewers":      {"max viewers", "peak viewers"},
+	"unique_chatters":  {"unique chatters", "chatters"},
+	"follows":          {"follows", "new followers", "followers gained"},
+	"subscriptions":    {"subscriptions", "new subscriptions", "subs"},
+}
+
+// importDateLayouts are the date formats seen in exports, by locale and age
+var importDateLayouts = []string{
+	time.DateOnly,
+	"01/02/2006",
+	"1/2/2006",
+	"Mon Jan 2 2006",
+	"Mon Jan 02 2006",
+	"Jan 2, 2006",
+	time.RFC3339,
+}
+
+// ImportRowError is a row of the file that wasn't imported
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport is returned by POST /api/analytics/import and stored on its
+// csv_import job. Status is partial when some rows were invalid and failed
+// when none were valid.
+type ImportReport struct {
+	JobID  int    `json:"job_id,omitempty"`
+	Status string `json:"status"`
+	Rows   int    `json:"rows"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	// DaysImported and StreamsImported count new channel_analytics and
+	// stream_sessions rows. Existing counts the ones already stored, e.g. by
+	// collection or an earlier import, which are kept as they were.
+	DaysImported    int              `json:"days_imported"`
+	StreamsImported int              `json:"streams_imported"`
+	Existing        int              `json:"existing"`
+	Duplicates      int              `json:"duplicates"`
+	Invalid         int              `json:"invalid"`
+	Errors          []ImportRowError `json:"errors,omitempty"`
+}
+
+func (r *ImportReport) rowError(line int, err error) {
+	r.Invalid++
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportRowError{Line: line, Error: err.Error()})
+	}
+}
+
+// importDay is one day of the channel analytics export
+type importDay struct {
+	Date            time.Time
+	MinutesStreamed int
+	AverageViewers  int
+	MaxViewers      int
+	UniqueChatters  int
+	Follows         int
+	Subscriptions   int
+}
+
+// importFile is a parsed channel analytics export
+type importFile struct {
+	// Days are the valid days in order
+	Days []importDay
+	// HasFollows is whether follower counts can be rebuilt from the file
+	HasFollows bool
+	Report     *ImportReport
+}
+
+// parseChannelCSV reads the channel analytics CSV exported from the Twitch
+// creator dashboard. Invalid rows and repeated days are skipped and counted
+// in the report.
+func parseChannelCSV(r io.Reader, now time.Time) (*importFile, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range importColumns {
+			if _, ok := columns[column]; !ok && slices.Contains(aliases, name) {
+				columns[column] = i
+			}
+		}
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("%w: no Date column", ErrInvalidImport)
+	}
+	if len(columns) == 1 {
+		return nil, fmt.Errorf("%w: no known analytics columns", ErrInvalidImport)
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	report := &ImportReport{}
+	seen := make(map[time.Time]bool)
+	var days []importDay
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+			}
+			report.Rows++
+			report.rowError(parseErr.StartLine, parseErr.Err)
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		report.Rows++
+		if report.Rows > maxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImport, maxImportRows)
+		}
+
+		day, err := parseImportDay(record, columns)
+		if err != nil {
+			report.rowError(line, err)
+			continue
+		}
+		if day.Date.After(today) {
+			report.rowError(line, errors.New("date is in the future"))
+			continue
+		}
+		if seen[day.Date] {
+			report.Duplicates++
+			continue
+		}
+		seen[day.Date] = true
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	if len(days) > 0 {
+		report.From = days[0].Date.Format(time.DateOnly)
+		report.To = days[len(days)-1].Date.Format(time.DateOnly)
+	}
+	_, hasFollows := columns["follows"]
+	return &importFile{Days: days, HasFollows: hasFollows, Report: report}, nil
+}
+
+func parseImportDay(record []string, columns map[string]int) (importDay, error) {
+	field := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var day importDay
+	date, err := parseImportDate(field("date"))
+	if err != nil {
+		return day, err
+	}
+	day.Date = date
+
+	counts := []struct {
+		column string
+		value  *int
+	}{
+		{"minutes_streamed", &day.MinutesStreamed},
+		{"average_viewers", &day.AverageViewers},
+		{"max_viewers", &day.MaxViewers},
+		{"unique_chatters", &day.UniqueChatters},
+		{"follows", &day.Follows},
+		{"subscriptions", &day.Subscriptions},
+	}
+	for _, count := range counts {
+		value, err := parseImportCount(field(count.column))
+		if err != nil {
+			return day, fmt.Errorf("invalid %s: %w", strings.ReplaceAll(count.column, "_", " "), err)
+		}
+		*count.value = value
+	}
+	return day, nil
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("missing date")
+	}
+	for _, layout := range importDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC().Truncate(24 * time.Hour), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseImportCount reads a non-negative number, rounding averages. Empty
+// cells are zero.
+func parseImportCount(value string) (int, error) {
+	value = strings.ReplaceAll(value, ",", "")
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	if number < 0 {
+		return 0, fmt.Errorf("%q is negative", value)
+	}
+	return int(math.Round(number)), nil
+}
+
+// importedChannelRows turns daily follows into follower counts. They are
+// anchored on the earliest stored snapshot, so imported history joins the
+// collected one, or count up from zero when nothing is stored. They are marked
+// as imported, as the export has no subscriber or view counts.
+func importedChannelRows(userID string, days []importDay, anchor *ChannelAnalytics) []ChannelAnalytics {
+	base, anchorCumulative := 0, 0
+	cumulative := make([]int, len(days))
+	for i, day := range days {
+		cumulative[i] = day.Follows
+		if i > 0 {
+			cumulative[i] += cumulative[i-1]
+		}
+		if anchor != nil && !day.Date.After(anchor.Date) {
+			anchorCumulative = cumulative[i]
+		}
+	}
+	if anchor != nil {
+		base = anchor.FollowersCount
+	}
+
+	rows := make([]ChannelAnalytics, 0, len(days))
+	for i, day := range days {
+		rows = append(rows, ChannelAnalytics{
+			UserID:         userID,
+			Date:           day.Date,
+			FollowersCount: max(base+cumulative[i]-anchorCumulative, 0),
+			Source:         ChannelSourceImport,
+		})
+	}
+	return rows
+}
+
+// importedStreamSessions adds a session for each streamed day without a
+// collected one. Their IDs are derived from the day, so importing the same
+// file again doesn't add them twice.
+func importedStreamSessions(userID string, days []importDay, collected []StreamSession) ([]StreamSession, int) {
+	streamed := make(map[time.Time]bool)
+	for _, session := range collected {
+		if session.StartedAt != nil {
+			streamed[session.StartedAt.UTC().Truncate(24*time.Hour)] = true
+		}
+	}
+
+	var sessions []StreamSession
+	existing := 0
+	for _, day := range days {
+		if day.MinutesStreamed == 0 {
+			continue
+		}
+		if streamed[day.Date] {
+			existing++
+			continue
+		}
+		startedAt := day.Date
+		endedAt := startedAt.Add(time.Duration(day.MinutesStreamed) * time.Minute)
+		sessions = append(sessions, StreamSession{
+			UserID:            userID,
+			StreamID:          fmt.Sprintf("import:%s:%s", userID, day.Date.Format(time.DateOnly)),
+			StartedAt:         &startedAt,
+			EndedAt:           &endedAt,
+			DurationMinutes:   day.MinutesStreamed,
+			PeakViewers:       day.MaxViewers,
+			AverageViewers:    day.AverageViewers,
+			TotalChatters:     day.UniqueChatters,
+			FollowersGained:   day.Follows,
+			SubscribersGained: day.Subscriptions,
+		})
+	}
+	return sessions, existing
+}
+
+// ImportChannelCSV imports the channel analytics CSV exported from the Twitch
+// creator dashboard into channel_analytics and stream_sessions, recording the
+// import as a csv_import job. Stored days and streams are never overwritten.
+func (s *service) ImportChannelCSV(ctx context.Context, userID string, r io.Reader) (*ImportReport, error) {
+	file, err := parseChannelCSV(r, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	days, report := file.Days, file.Report
+
+	job := &AnalyticsJob{
+		UserID:        userID,
+		JobType:       "csv_import",
+		Status:        "running",
+		DataDate:      &[]time.Time{time.Now()}[0],
+		ProgressTotal: report.Rows,
+	}
+	if err := s.repo.CreateAnalyticsJob(ctx, job); err != nil {
+		log.Printf("Failed to create analytics job: %v", err)
+	}
+	report.JobID = job.ID
+
+	err = s.importDays(ctx, userID, file)
+	switch {
+	case err != nil || len(days) == 0:
+		report.Status = CollectionFailed
+	case report.Invalid > 0:
+		report.Status = CollectionPartial
+	default:
+		report.Status = CollectionCompleted
+	}
+
+	if job.ID > 0 {
+		var errorMsg *string
+		if err != nil {
+			message := err.Error()
+			errorMsg = &message
+		}
+		if encoded, encodeErr := json.Marshal(report); encodeErr == nil {
+			if err := s.repo.CompleteAnalyticsJob(ctx, job.ID, report.Status, errorMsg, encoded); err != nil {
+				log.Printf("Failed to complete analytics job %d: %v", job.ID, err)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if report.DaysImported > 0 || report.StreamsImported > 0 {
+		s.EvictUser(userID)
+	}
+	log.Printf("Imported %d days and %d streams from CSV for user %s (%d rows, %d invalid)",
+		report.DaysImported, report.StreamsImported, userID, report.Rows, report.Invalid)
+	return report, nil
+}
+
+func (s *service) importDays(ctx context.Context, userID string, file *importFile) error {
+	days, report := file.Days, file.Report
+	if len(days) == 0 {
+		return nil
+	}
+
+	if file.HasFollows {
+		anchor, err := s.repo.GetEarliestChannelAnalytics(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get earliest snapshot: %w", err)
+		}
+		rows := importedChannelRows(userID, days, anchor)
+		inserted, err := s.repo.BackfillChannelAnalytics(ctx, rows)
+		if err != nil {
+			return fmt.Errorf("failed to save channel analytics: %w", err)
+		}
+		report.DaysImported = inserted
+		report.Existing += len(rows) - inserted
+	}
+
+	collected, err := s.repo.GetStreamSessionsByDateRange(ctx, userID,
+		days[0].Date, days[len(days)-1].Date.Add(24*time.Hour-time.Nanosecond))
+	if err != nil {
+		return fmt.Errorf("failed to get stream sessions: %w", err)
+	}
+	sessions, existing := importedStreamSessions(userID, days, collected)
+	report.Existing += existing
+	if len(sessions) == 0 {
+		return nil
+	}
+	inserted, err := s.repo.ImportStreamSessions(ctx, sessions)
+	if err != nil {
+		return fmt.Errorf("failed to save stream sessions: %w", err)
+	}
+	report.StreamsImported = inserted
+	report.Existing += len(sessions) - inserted
+	return nil
+}
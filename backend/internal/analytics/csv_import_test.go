@@ -0,0 +1,105 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(date string) time.Time {
+	t, _ := time.Parse(time.DateOnly, date)
+	return t
+}
+
+func TestParseChannelCSV(t *testing.T) {
+	csv := "\ufeffDate,Average Viewers,Max Viewers,Minutes Streamed,Follows,Unique Chatters,Chat Messages\n" +
+		"2025-03-02,12.6,30,240,5,14,310\n" +
+		"03/01/2025,0,0,0,\"1,204\",0,0\n" +
+		"2025-03-02,1,1,1,1,1,1\n" +
+		"\n" +
+		"2025-03-03,abc,0,0,0,0,0\n" +
+		"2025-03-04,0,0,0,-2,0,0\n" +
+		"2030-01-01,0,0,0,0,0,0\n"
+
+	file, err := parseChannelCSV(strings.NewReader(csv), day("2025-06-01"))
+	require.NoError(t, err)
+	assert.True(t, file.HasFollows)
+
+	require.Len(t, file.Days, 2)
+	assert.Equal(t, importDay{Date: day("2025-03-01"), Follows: 1204}, file.Days[0])
+	assert.Equal(t, importDay{Date: day("2025-03-02"), MinutesStreamed: 240, AverageViewers: 13,
+		MaxViewers: 30, UniqueChatters: 14, Follows: 5}, file.Days[1])
+
+	report := file.Report
+	assert.Equal(t, 6, report.Rows)
+	assert.Equal(t, 1, report.Duplicates)
+	assert.Equal(t, 3, report.Invalid)
+	assert.Equal(t, "2025-03-01", report.From)
+	assert.Equal(t, "2025-03-02", report.To)
+	require.Len(t, report.Errors, 3)
+	assert.Equal(t, ImportRowError{Line: 6, Error: `invalid average viewers: "abc" is not a number`}, report.Errors[0])
+	assert.Equal(t, 7, report.Errors[1].Line)
+	assert.Equal(t, "date is in the future", report.Errors[2].Error)
+}
+
+func TestParseChannelCSVRejectsOtherFiles(t *testing.T) {
+	for _, csv := range []string{"", "Video,Views\nabc,1\n", "Date,Notes\n2025-03-01,hi\n"} {
+		_, err := parseChannelCSV(strings.NewReader(csv), time.Now())
+		assert.ErrorIs(t, err, ErrInvalidImport, csv)
+	}
+}
+
+func TestImportedChannelRows(t *testing.T) {
+	days := []importDay{
+		{Date: day("2025-03-01"), Follows: 2},
+		{Date: day("2025-03-02"), Follows: 3},
+		{Date: day("2025-03-03"), Follows: 4},
+	}
+
+	// Without snapshots the counts start from zero
+	rows := importedChannelRows("user_1", days, nil)
+	assert.Equal(t, []int{2, 5, 9}, followerCounts(rows))
+	for _, row := range rows {
+		assert.Equal(t, ChannelSourceImport, row.Source)
+		assert.True(t, row.Synthetic())
+	}
+
+	// With one they meet it, before and after
+	anchor := &ChannelAnalytics{Date: day("2025-03-02"), FollowersCount: 100}
+	rows = importedChannelRows("user_1", days, anchor)
+	assert.Equal(t, []int{97, 100, 104}, followerCounts(rows))
+
+	anchor = &ChannelAnalytics{Date: day("2025-04-01"), FollowersCount: 4}
+	rows = importedChannelRows("user_1", days, anchor)
+	assert.Equal(t, []int{0, 0, 4}, followerCounts(rows), "counts don't go below zero")
+}
+
+func followerCounts(rows []ChannelAnalytics) []int {
+	counts := make([]int, len(rows))
+	for i, row := range rows {
+		counts[i] = row.FollowersCount
+	}
+	return counts
+}
+
+func TestImportedStreamSessions(t *testing.T) {
+	days := []importDay{
+		{Date: day("2025-03-01"), MinutesStreamed: 120, MaxViewers: 40, Follows: 3},
+		{Date: day("2025-03-02")},
+		{Date: day("2025-03-03"), MinutesStreamed: 60},
+	}
+	startedAt := day("2025-03-03").Add(18 * time.Hour)
+	collected := []StreamSession{{StreamID: "41234", StartedAt: &startedAt}}
+
+	sessions, existing := importedStreamSessions("user_1", days, collected)
+	assert.Equal(t, 1, existing)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "import:user_1:2025-03-01", sessions[0].StreamID)
+	assert.Equal(t, 120, sessions[0].DurationMinutes)
+	assert.Equal(t, 40, sessions[0].PeakViewers)
+	assert.Equal(t, 3, sessions[0].FollowersGained)
+	assert.Equal(t, day("2025-03-01").Add(2*time.Hour), *sessions[0].EndedAt)
+}
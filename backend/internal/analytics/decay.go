@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// GetVideoDecayCurves returns, per video type, how the views of the user's
+// videos built up over their first days after publishing. Only videos at least
+// days old are used, so every video has had the whole curve to earn views.
+func (s *service) GetVideoDecayCurves(ctx context.Context, userID string, days int) (*VideoDecayReport, error) {
+	views, err := s.repo.GetVideoDayViews(ctx, userID, days)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildDecayCurves(views)
+	report.Days = days
+	return report, nil
+}
+
+// buildDecayCurves normalizes every video by its current views and averages
+// the shares per type and day. Videos aren't collected every day, so each
+// point only counts the videos seen on it.
+func buildDecayCurves(views []VideoDayViews) *VideoDecayReport {
+	type dayTotal struct {
+		share  float64
+		videos int
+	}
+	totals := make(map[string]map[int]*dayTotal)
+	videos := make(map[string]map[string]bool)
+
+	for _, view := range views {
+		if view.CurrentViews <= 0 {
+			continue
+		}
+		if totals[view.VideoType] == nil {
+			totals[view.VideoType] = make(map[int]*dayTotal)
+			videos[view.VideoType] = make(map[string]bool)
+		}
+		total := totals[view.VideoType][view.Day]
+		if total == nil {
+			total = &dayTotal{}
+			totals[view.VideoType][view.Day] = total
+		}
+		// Twitch can lower counts, e.g. when it removes bot views
+		total.share += math.Min(float64(view.ViewCount)/float64(view.CurrentViews), 1)
+		total.videos++
+		videos[view.VideoType][view.VideoID] = true
+	}
+
+	report := &VideoDecayReport{Curves: make([]DecayCurve, 0, len(totals))}
+	for videoType, days := range totals {
+		curve := DecayCurve{
+			VideoType: videoType,
+			Videos:    len(videos[videoType]),
+			Points:    make([]DecayPoint, 0, len(days)),
+		}
+		for day, total := range days {
+			curve.Points = append(curve.Points, DecayPoint{
+				Day:    day,
+				Share:  math.Round(total.share/float64(total.videos)*1000) / 1000,
+				Videos: total.videos,
+			})
+		}
+		sort.Slice(curve.Points, func(i, j int) bool { return curve.Points[i].Day < curve.Points[j].Day })
+
+		curve.HalfLifeDays = dayReaching(curve.Points, 0.5)
+		curve.NinetyPercentDays = dayReaching(curve.Points, 0.9)
+		report.Curves = append(report.Curves, curve)
+	}
+
+	sort.Slice(report.Curves, func(i, j int) bool { return report.Curves[i].VideoType < report.Curves[j].VideoType })
+	return report
+}
+
+// dayReaching returns the first day whose share is at least share
+func dayReaching(points []DecayPoint, share float64) *int {
+	for _, point := range points {
+		if point.Share >= share {
+			day := point.Day
+			return &day
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDecayCurves(t *testing.T) {
+	views := []VideoDayViews{
+		{VideoID: "vod1", VideoType: "archive", Day: 0, ViewCount: 40, CurrentViews: 100},
+		{VideoID: "vod1", VideoType: "archive", Day: 1, ViewCount: 80, CurrentViews: 100},
+		{VideoID: "vod1", VideoType: "archive", Day: 5, ViewCount: 95, CurrentViews: 100},
+		{VideoID: "vod2", VideoType: "archive", Day: 0, ViewCount: 20, CurrentViews: 200},
+		{VideoID: "vod2", VideoType: "archive", Day: 1, ViewCount: 100, CurrentViews: 200},
+		// Twitch lowered the count since, the share stays at 100%
+		{VideoID: "clip1", VideoType: "clip", Day: 0, ViewCount: 60, CurrentViews: 50},
+		// Videos without views can't be normalized
+		{VideoID: "clip2", VideoType: "clip", Day: 0, ViewCount: 0, CurrentViews: 0},
+	}
+
+	report := buildDecayCurves(views)
+	require.Len(t, report.Curves, 2)
+
+	vods := report.Curves[0]
+	assert.Equal(t, "archive", vods.VideoType)
+	assert.Equal(t, 2, vods.Videos)
+	assert.Equal(t, []DecayPoint{
+		{Day: 0, Share: 0.25, Videos: 2},
+		{Day: 1, Share: 0.65, Videos: 2},
+		{Day: 5, Share: 0.95, Videos: 1},
+	}, vods.Points)
+	require.NotNil(t, vods.HalfLifeDays)
+	assert.Equal(t, 1, *vods.HalfLifeDays)
+	require.NotNil(t, vods.NinetyPercentDays)
+	assert.Equal(t, 5, *vods.NinetyPercentDays)
+
+	clips := report.Curves[1]
+	assert.Equal(t, "clip", clips.VideoType)
+	assert.Equal(t, 1, clips.Videos)
+	assert.Equal(t, []DecayPoint{{Day: 0, Share: 1, Videos: 1}}, clips.Points)
+	assert.Equal(t, 0, *clips.HalfLifeDays)
+}
+
+func TestGetVideoDecayCurvesWithoutStats(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _ := newTestService()
+	repo.On("GetVideoDayViews", ctx, "user_1", 30).Return(nil, nil)
+
+	report, err := svc.GetVideoDecayCurves(ctx, "user_1", 30)
+	require.NoError(t, err)
+	assert.Equal(t, 30, report.Days)
+	assert.NotNil(t, report.Curves)
+	assert.Empty(t, report.Curves)
+	assert.Nil(t, dayReaching(nil, 0.5))
+}
@@ -0,0 +1,226 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+const (
+	// diagnosticJobLimit is how many of the user's latest jobs are searched for errors
+	diagnosticJobLimit = 20
+	// diagnosticErrorLimit is how many errors a report lists, newest first
+	diagnosticErrorLimit = 10
+)
+
+// DiagnosticsReport is a live check of why a user's dashboard may be empty.
+// Problems sums up what support should look at; the rest is the evidence.
+type DiagnosticsReport struct {
+	UserID    string           `json:"user_id"`
+	CheckedAt time.Time        `json:"checked_at"`
+	OK        bool             `json:"ok"`
+	Problems  []string         `json:"problems"`
+	Token     TokenDiagnostics `json:"token"`
+	Scopes    ScopeDiagnostics `json:"scopes"`
+	// LastJob is the user's latest job of any type, nil before the first
+	LastJob   *AnalyticsJob     `json:"last_job"`
+	RowCounts map[string]int64  `json:"row_counts"`
+	Errors    []DiagnosticError `json:"errors"`
+}
+
+// TokenDiagnostics is the outcome of validating the user's Twitch token with
+// Twitch. Source is "creatorsync" for tokens from our OAuth flow and "clerk"
+// for the sign-in token.
+type TokenDiagnostics struct {
+	Source       string     `json:"source"`
+	Valid        bool       `json:"valid"`
+	Login        string     `json:"login,omitempty"`
+	TwitchUserID string     `json:"twitch_user_id,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// ScopeDiagnostics compares the scopes we require with those of the token.
+// Granted falls back to the stored scopes when the token couldn't be validated.
+type ScopeDiagnostics struct {
+	Required []string `json:"required"`
+	Granted  []string `json:"granted"`
+	Missing  []string `json:"missing"`
+}
+
+// DiagnosticError is a recent collection error. Source is "job" for a failed
+// job or job step and "retry" for a collection waiting to be retried.
+type DiagnosticError struct {
+	Source      string    `json:"source"`
+	JobType     string    `json:"job_type"`
+	Step        string    `json:"step,omitempty"`
+	FailureKind string    `json:"failure_kind,omitempty"`
+	Attempts    int       `json:"attempts,omitempty"`
+	Parked      bool      `json:"parked,omitempty"`
+	Message     string    `json:"message"`
+	At          time.Time `json:"at"`
+}
+
+// Diagnostics checks a user's collection end to end: their Twitch token
+// against Twitch, their latest jobs and retries, and what has been saved
+type Diagnostics struct {
+	repo         Repository
+	twitchClient TwitchAPI
+	tokens       *TwitchTokenHelper
+}
+
+func NewDiagnostics(repo Repository, twitchClient TwitchAPI) *Diagnostics {
+	return &Diagnostics{
+		repo:         repo,
+		twitchClient: twitchClient,
+		tokens:       NewTwitchTokenHelper(repo, twitchClient),
+	}
+}
+
+// Run checks the user's collection. Problems with Twitch are part of the
+// report; only failing to read the database is returned as an error.
+func (d *Diagnostics) Run(ctx context.Context, userID string) (*DiagnosticsReport, error) {
+	report := &DiagnosticsReport{
+		UserID:    userID,
+		CheckedAt: time.Now(),
+		Problems:  []string{},
+		Errors:    []DiagnosticError{},
+	}
+
+	if err := d.checkToken(ctx, userID, report); err != nil {
+		return nil, err
+	}
+
+	jobs, err := d.repo.GetAnalyticsJobs(ctx, userID, diagnosticJobLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analytics jobs: %w", err)
+	}
+	retries, err := d.repo.ListCollectionRetries(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection retries: %w", err)
+	}
+	report.RowCounts, err = d.repo.CountUserRows(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		report.Problems = append(report.Problems, "No collection has run yet")
+	} else {
+		report.LastJob = &jobs[0]
+		if status := report.LastJob.Status; status == CollectionFailed || status == CollectionPartial {
+			report.Problems = append(report.Problems, fmt.Sprintf("Last %s job was %s", report.LastJob.JobType, status))
+		}
+	}
+	if report.RowCounts["channel_analytics"] == 0 {
+		report.Problems = append(report.Problems, "No channel analytics have been saved")
+	}
+	for _, retry := range retries {
+		if retry.ParkedAt != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s collection stopped retrying after a %s failure", retry.JobType, retry.FailureKind))
+		}
+	}
+
+	report.Errors = recentErrors(jobs, retries)
+	report.OK = len(report.Problems) == 0
+	return report, nil
+}
+
+// checkToken gets a valid token the way collection does, refreshing a stored
+// one if needed, and asks Twitch about it
+func (d *Diagnostics) checkToken(ctx context.Context, userID string, report *DiagnosticsReport) error {
+	stored, err := d.repo.GetTwitchToken(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load stored Twitch token: %w", err)
+	}
+
+	report.Token.Source = "clerk"
+	report.Scopes = ScopeDiagnostics{Required: twitch.RequiredScopes(), Granted: []string{}}
+	if stored != nil {
+		report.Token.Source = "creatorsync"
+		report.Scopes.Granted = strings.Fields(stored.Scopes)
+	}
+
+	token, err := d.tokens.GetValidToken(ctx, userID)
+	if err != nil {
+		report.Token.Error = err.Error()
+	} else if info, err := d.twitchClient.GetTokenInfo(ctx, token); err != nil {
+		report.Token.Error = fmt.Sprintf("failed to validate token: %v", err)
+	} else if info == nil {
+		report.Token.Error = "twitch token is invalid or expired"
+	} else {
+		expiresAt := time.Now().Add(time.Duration(info.ExpiresIn) * time.Second)
+		report.Token = TokenDiagnostics{
+			Source:       report.Token.Source,
+			Valid:        true,
+			Login:        info.Login,
+			TwitchUserID: info.UserID,
+			ExpiresAt:    &expiresAt,
+		}
+		report.Scopes.Granted = info.Scopes
+	}
+
+	if !report.Token.Valid {
+		report.Problems = append(report.Problems, "Twitch token is not valid: "+report.Token.Error)
+	}
+	report.Scopes.Missing = twitch.MissingScopes(report.Scopes.Required, report.Scopes.Granted)
+	if len(report.Scopes.Missing) > 0 {
+		report.Problems = append(report.Problems, "Twitch token is missing scopes: "+strings.Join(report.Scopes.Missing, ", "))
+	}
+	return nil
+}
+
+// recentErrors lists the errors of failed jobs, failed steps of user
+// collections and pending retries, newest first
+func recentErrors(jobs []AnalyticsJob, retries []CollectionRetry) []DiagnosticError {
+	errs := []DiagnosticError{}
+	for _, retry := range retries {
+		if retry.LastError == "" {
+			continue
+		}
+		errs = append(errs, DiagnosticError{
+			Source:      "retry",
+			JobType:     retry.JobType,
+			FailureKind: retry.FailureKind,
+			Attempts:    retry.Attempts,
+			Parked:      retry.ParkedAt != nil,
+			Message:     retry.LastError,
+			At:          retry.UpdatedAt,
+		})
+	}
+
+	for _, job := range jobs {
+		at := job.CreatedAt
+		if job.CompletedAt != nil {
+			at = *job.CompletedAt
+		}
+		if job.ErrorMessage != "" {
+			errs = append(errs, DiagnosticError{Source: "job", JobType: job.JobType, Message: job.ErrorMessage, At: at})
+		}
+		if job.Report == nil {
+			continue
+		}
+		var collection CollectionReport
+		if err := json.Unmarshal(*job.Report, &collection); err != nil {
+			continue
+		}
+		for _, step := range collection.Steps {
+			if step.Error != "" {
+				errs = append(errs, DiagnosticError{Source: "job", JobType: job.JobType, Step: step.Name, Message: step.Error, At: at})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].At.After(errs[j].At)
+	})
+	if len(errs) > diagnosticErrorLimit {
+		errs = errs[:diagnosticErrorLimit]
+	}
+	return errs
+}
@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// storedDiagnosticsToken returns a stored token valid for an hour, with the
+// given scopes
+func storedDiagnosticsToken(t *testing.T, scopes string) *TwitchToken {
+	t.Helper()
+	accessToken, keyID, err := encryptToken(context.Background(), "access-1")
+	require.NoError(t, err)
+	expiresAt := time.Now().Add(time.Hour)
+	return &TwitchToken{
+		UserID:          "user_1",
+		AccessToken:     accessToken,
+		EncryptionKeyID: keyID,
+		Scopes:          scopes,
+		ExpiresAt:       &expiresAt,
+	}
+}
+
+func TestDiagnosticsHealthyUser(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	t.Setenv("TWITCH_SCOPES", "user:read:email channel:read:subscriptions")
+	ctx := context.Background()
+	repo := &mockRepository{}
+	api := &mockTwitchAPI{}
+
+	repo.On("GetTwitchToken", ctx, "user_1").Return(storedDiagnosticsToken(t, "user:read:email"), nil)
+	api.On("GetTokenInfo", ctx, "access-1").Return(&twitch.TokenValidationResponse{
+		Login: "streamer", UserID: "tw_1", ExpiresIn: 3600,
+		Scopes: []string{"user:read:email", "channel:read:subscriptions"},
+	}, nil)
+	repo.On("GetAnalyticsJobs", ctx, "user_1", diagnosticJobLimit).Return([]AnalyticsJob{
+		{ID: 7, JobType: "user_collection", Status: CollectionCompleted, CreatedAt: time.Now()},
+	}, nil)
+	repo.On("ListCollectionRetries", ctx, "user_1").Return([]CollectionRetry(nil), nil)
+	repo.On("CountUserRows", ctx, "user_1").Return(map[string]int64{"channel_analytics": 12, "video_analytics": 3}, nil)
+
+	report, err := NewDiagnostics(repo, api).Run(ctx, "user_1")
+	require.NoError(t, err)
+	assert.True(t, report.OK, report.Problems)
+	assert.Empty(t, report.Problems)
+	assert.Equal(t, "creatorsync", report.Token.Source)
+	assert.True(t, report.Token.Valid)
+	assert.Equal(t, "streamer", report.Token.Login)
+	require.NotNil(t, report.Token.ExpiresAt)
+	assert.Empty(t, report.Scopes.Missing, "the scopes Twitch reports win over the stored ones")
+	require.NotNil(t, report.LastJob)
+	assert.Equal(t, 7, report.LastJob.ID)
+	assert.Equal(t, int64(12), report.RowCounts["channel_analytics"])
+	assert.Empty(t, report.Errors)
+}
+
+func TestDiagnosticsReportsProblems(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	t.Setenv("TWITCH_SCOPES", "user:read:email channel:read:subscriptions")
+	ctx := context.Background()
+	repo := &mockRepository{}
+	api := &mockTwitchAPI{}
+
+	repo.On("GetTwitchToken", ctx, "user_1").Return(storedDiagnosticsToken(t, "user:read:email"), nil)
+	api.On("GetTokenInfo", ctx, "access-1").Return(nil, nil)
+
+	older := time.Now().Add(-2 * time.Hour)
+	newer := time.Now().Add(-time.Hour)
+	report, err := json.Marshal(&CollectionReport{
+		Status: CollectionPartial,
+		Steps: []CollectionStep{
+			{Name: "channel", Status: CollectionCompleted},
+			{Name: "videos", Status: CollectionFailed, Error: "twitch returned 503"},
+		},
+	})
+	require.NoError(t, err)
+	raw := json.RawMessage(report)
+	repo.On("GetAnalyticsJobs", ctx, "user_1", diagnosticJobLimit).Return([]AnalyticsJob{
+		{ID: 2, JobType: "user_collection", Status: CollectionPartial, CompletedAt: &older, Report: &raw},
+	}, nil)
+	parkedAt := newer
+	repo.On("ListCollectionRetries", ctx, "user_1").Return([]CollectionRetry{
+		{JobType: "daily_channel", Attempts: 1, FailureKind: FailureAuth, LastError: "unauthorized", ParkedAt: &parkedAt, UpdatedAt: newer},
+	}, nil)
+	repo.On("CountUserRows", ctx, "user_1").Return(map[string]int64{"channel_analytics": 0}, nil)
+
+	result, err := NewDiagnostics(repo, api).Run(ctx, "user_1")
+	require.NoError(t, err)
+	assert.False(t, result.OK)
+	assert.False(t, result.Token.Valid)
+	assert.Equal(t, "twitch token is invalid or expired", result.Token.Error)
+	assert.Equal(t, []string{"user:read:email"}, result.Scopes.Granted, "the stored scopes are shown when Twitch can't tell")
+	assert.Equal(t, []string{"channel:read:subscriptions"}, result.Scopes.Missing)
+	assert.Len(t, result.Problems, 5)
+
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, "retry", result.Errors[0].Source, "newest first")
+	assert.True(t, result.Errors[0].Parked)
+	assert.Equal(t, "videos", result.Errors[1].Step)
+	assert.Equal(t, "twitch returned 503", result.Errors[1].Message)
+}
+
+func TestDiagnosticsFailsOnDatabaseErrors(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{}
+	repo.On("GetTwitchToken", ctx, "user_1").Return(nil, errors.New("connection refused"))
+
+	_, err := NewDiagnostics(repo, &mockTwitchAPI{}).Run(ctx, "user_1")
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestRecentErrorsAreCapped(t *testing.T) {
+	var jobs []AnalyticsJob
+	for i := 0; i < diagnosticErrorLimit+5; i++ {
+		jobs = append(jobs, AnalyticsJob{JobType: "daily_channel", ErrorMessage: "failed", CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute)})
+	}
+
+	errs := recentErrors(jobs, nil)
+	assert.Len(t, errs, diagnosticErrorLimit)
+	assert.True(t, errs[0].At.After(errs[1].At))
+}
@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// eventSubscription describes one EventSub subscription created per user. Key
+// identifies it in eventsub_subscriptions since some types are subscribed twice
+// with different conditions.
+type eventSubscription struct {
+	Key       string
+	Type      string
+	Version   string
+	Condition func(twitchUserID string) map[string]string
+}
+
+func broadcasterCondition(twitchUserID string) map[string]string {
+	return map[string]string{"broadcaster_user_id": twitchUserID}
+}
+
+// Keys of subscriptions whose type is subscribed more than once
+const (
+	eventSubRaidIncoming = "channel.raid.incoming"
+	eventSubRaidOutgoing = "channel.raid.outgoing"
+)
+
+// eventSubscriptions are created for every connected user
+var eventSubscriptions = []eventSubscription{
+	{twitch.EventSubRedemptionAdd, twitch.EventSubRedemptionAdd, "1", broadcasterCondition},
+	{twitch.EventSubChannelBan, twitch.EventSubChannelBan, "1", broadcasterCondition},
+	{eventSubRaidIncoming, twitch.EventSubChannelRaid, "1", func(id string) map[string]string {
+		return map[string]string{"to_broadcaster_user_id": id}
+	}},
+	{eventSubRaidOutgoing, twitch.EventSubChannelRaid, "1", func(id string) map[string]string {
+		return map[string]string{"from_broadcaster_user_id": id}
+	}},
+	{twitch.EventSubChannelFollow, twitch.EventSubChannelFollow, "2", func(id string) map[string]string {
+		return map[string]string{"broadcaster_user_id": id, "moderator_user_id": id}
+	}},
+}
+
+// EnsureEventSubscriptions subscribes our EventSub webhook to the user's channel
+// events. It is a no-op when webhooks aren't configured, and types that already
+// have a live subscription are skipped.
+func EnsureEventSubscriptions(ctx context.Context, repo Repository, twitchClient TwitchAPI, userID, twitchUserID string) error {
+	cfg := config.EventSub()
+	if !cfg.Enabled() || twitchUserID == "" {
+		return nil
+	}
+
+	var appToken string
+	var errs []error
+	for _, eventType := range eventSubscriptions {
+		existing, err := repo.GetEventSubSubscription(ctx, userID, eventType.Key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load %s subscription: %w", eventType.Key, err))
+			continue
+		}
+		if existing != nil && (existing.Status == "enabled" || existing.Status == "webhook_callback_verification_pending") {
+			continue
+		}
+
+		if appToken == "" {
+			token, err := twitchClient.GetAppAccessToken(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get app access token: %w", err)
+			}
+			appToken = token.AccessToken
+		}
+
+		sub, err := twitchClient.CreateEventSubSubscription(ctx, appToken, eventType.Type, eventType.Version,
+			eventType.Condition(twitchUserID), cfg.CallbackURL, cfg.Secret)
+		if err != nil {
+			var apiErr *twitch.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+				// Created earlier but never recorded, deliveries still arrive
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to create %s subscription: %w", eventType.Key, err))
+			continue
+		}
+
+		if err := repo.SaveEventSubSubscription(ctx, &EventSubSubscription{
+			ID:     sub.ID,
+			UserID: userID,
+			Type:   eventType.Key,
+			Status: sub.Status,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save %s subscription: %w", eventType.Key, err))
+			continue
+		}
+
+		log.Printf("🔔 Subscribed to %s for user %s", eventType.Key, userID)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ensureEventSubscriptions makes sure webhooks are set up for a connected user
+func (dc *dataCollector) ensureEventSubscriptions(ctx context.Context, userID string) error {
+	user, err := dc.repo.GetUserByClerkID(ctx, userID)
+	if err != nil || user == nil {
+		return err
+	}
+	return EnsureEventSubscriptions(ctx, dc.repo, dc.twitchClient, userID, user.TwitchUserID)
+}
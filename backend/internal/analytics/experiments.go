@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// maxExperimentNameLength bounds experiment names and variant descriptions
+const maxExperimentNameLength = 255
+
+// normalizeExperiment trims an experiment's fields and checks their lengths
+func normalizeExperiment(experiment *TitleExperiment) error {
+	experiment.Name = strings.TrimSpace(experiment.Name)
+	experiment.VariantA = strings.TrimSpace(experiment.VariantA)
+	experiment.VariantB = strings.TrimSpace(experiment.VariantB)
+	if experiment.Name == "" {
+		return errors.New("name is required")
+	}
+	for _, field := range []string{experiment.Name, experiment.VariantA, experiment.VariantB} {
+		if len([]rune(field)) > maxExperimentNameLength {
+			return errors.New("name and variants must be at most 255 characters")
+		}
+	}
+	return nil
+}
+
+// normalizeVariant accepts "a" and "b" in either case
+func normalizeVariant(variant string) (string, bool) {
+	variant = strings.ToUpper(strings.TrimSpace(variant))
+	return variant, variant == "A" || variant == "B"
+}
+
+// GetTitleExperiments returns the user's experiments with the performance of
+// their variants, newest first
+func (s *service) GetTitleExperiments(ctx context.Context, userID string) ([]ExperimentResult, error) {
+	experiments, err := s.repo.ListTitleExperiments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	videos, err := s.repo.GetExperimentVideos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byExperiment := make(map[int][]ExperimentVideo)
+	for _, video := range videos {
+		byExperiment[video.ExperimentID] = append(byExperiment[video.ExperimentID], video)
+	}
+
+	results := make([]ExperimentResult, 0, len(experiments))
+	for _, experiment := range experiments {
+		result := buildExperimentResult(experiment, byExperiment[experiment.ID])
+		result.Videos = nil
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// GetTitleExperiment returns an experiment with its VODs, nil if the user has
+// no such experiment
+func (s *service) GetTitleExperiment(ctx context.Context, userID string, id int) (*ExperimentResult, error) {
+	experiment, err := s.repo.GetTitleExperiment(ctx, userID, id)
+	if err != nil || experiment == nil {
+		return nil, err
+	}
+	videos, err := s.repo.GetExperimentVideos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := []ExperimentVideo{}
+	for _, video := range videos {
+		if video.ExperimentID == id {
+			assigned = append(assigned, video)
+		}
+	}
+	return buildExperimentResult(*experiment, assigned), nil
+}
+
+func (s *service) CreateTitleExperiment(ctx context.Context, experiment *TitleExperiment) error {
+	return s.repo.CreateTitleExperiment(ctx, experiment)
+}
+
+func (s *service) DeleteTitleExperiment(ctx context.Context, userID string, id int) (bool, error) {
+	return s.repo.DeleteTitleExperiment(ctx, userID, id)
+}
+
+// AssignExperimentVideo puts a VOD in variant A or B of an experiment
+func (s *service) AssignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID, variant string) (bool, error) {
+	return s.repo.AssignExperimentVideo(ctx, userID, experimentID, videoID, variant)
+}
+
+func (s *service) UnassignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID string) (bool, error) {
+	return s.repo.UnassignExperimentVideo(ctx, userID, experimentID, videoID)
+}
+
+// experimentTotals sums the VODs of a variant before they are averaged
+type experimentTotals struct {
+	videos, views, clips int
+	viewsPerDay          float64
+}
+
+func (t experimentTotals) stats() VariantStats {
+	stats := VariantStats{Videos: t.videos, Views: t.views, Clips: t.clips}
+	if t.videos > 0 {
+		stats.AvgViewsPerDay = t.viewsPerDay / float64(t.videos)
+	}
+	if t.views > 0 {
+		stats.ClipRate = float64(t.clips) / float64(t.views) * 1000
+	}
+	return stats
+}
+
+func buildExperimentResult(experiment TitleExperiment, videos []ExperimentVideo) *ExperimentResult {
+	var a, b experimentTotals
+	for _, video := range videos {
+		totals := &a
+		if video.Variant == "B" {
+			totals = &b
+		}
+		totals.videos++
+		totals.views += video.ViewCount
+		totals.clips += video.Clips
+		totals.viewsPerDay += video.ViewsPerDay
+	}
+
+	result := &ExperimentResult{
+		TitleExperiment: experiment,
+		A:               a.stats(),
+		B:               b.stats(),
+		Videos:          videos,
+	}
+	result.ViewsPerDayLiftPercent = liftPercent(result.B.AvgViewsPerDay, result.A.AvgViewsPerDay, b.videos, a.videos)
+	result.ClipRateLiftPercent = liftPercent(result.B.ClipRate, result.A.ClipRate, b.videos, a.videos)
+	if result.Videos == nil {
+		result.Videos = []ExperimentVideo{}
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildExperimentResult(t *testing.T) {
+	experiment := TitleExperiment{ID: 1, Name: "Questions"}
+	videos := []ExperimentVideo{
+		{VideoID: "a1", Variant: "A", ViewCount: 1000, ViewsPerDay: 100, Clips: 2},
+		{VideoID: "a2", Variant: "A", ViewCount: 1000, ViewsPerDay: 50, Clips: 0},
+		{VideoID: "b1", Variant: "B", ViewCount: 500, ViewsPerDay: 150, Clips: 3},
+	}
+
+	result := buildExperimentResult(experiment, videos)
+	assert.Equal(t, VariantStats{Videos: 2, Views: 2000, Clips: 2, AvgViewsPerDay: 75, ClipRate: 1}, result.A)
+	assert.Equal(t, VariantStats{Videos: 1, Views: 500, Clips: 3, AvgViewsPerDay: 150, ClipRate: 6}, result.B)
+	require.NotNil(t, result.ViewsPerDayLiftPercent)
+	assert.InDelta(t, 100.0, *result.ViewsPerDayLiftPercent, 0.001)
+	require.NotNil(t, result.ClipRateLiftPercent)
+	assert.InDelta(t, 500.0, *result.ClipRateLiftPercent, 0.001)
+	assert.Len(t, result.Videos, 3)
+
+	// No lift without VODs on both sides
+	result = buildExperimentResult(experiment, videos[:2])
+	assert.Nil(t, result.ViewsPerDayLiftPercent)
+	assert.Nil(t, result.ClipRateLiftPercent)
+
+	result = buildExperimentResult(experiment, nil)
+	assert.Equal(t, VariantStats{}, result.A)
+	assert.NotNil(t, result.Videos)
+}
+
+func TestNormalizeExperiment(t *testing.T) {
+	experiment := &TitleExperiment{Name: "  Questions ", VariantA: " Statement ", VariantB: "Question?"}
+	require.NoError(t, normalizeExperiment(experiment))
+	assert.Equal(t, "Questions", experiment.Name)
+	assert.Equal(t, "Statement", experiment.VariantA)
+
+	assert.Error(t, normalizeExperiment(&TitleExperiment{Name: "   "}))
+	assert.Error(t, normalizeExperiment(&TitleExperiment{Name: "x", VariantB: strings.Repeat("é", 256)}))
+	assert.NoError(t, normalizeExperiment(&TitleExperiment{Name: strings.Repeat("é", 255)}))
+}
+
+func TestNormalizeVariant(t *testing.T) {
+	for input, want := range map[string]string{"a": "A", " B ": "B"} {
+		variant, ok := normalizeVariant(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, variant)
+	}
+	for _, input := range []string{"", "C", "AB"} {
+		_, ok := normalizeVariant(input)
+		assert.False(t, ok, input)
+	}
+}
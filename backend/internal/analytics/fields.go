@@ -0,0 +1,112 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fieldTree is a parsed ?fields= list. A nil subtree keeps the whole value.
+type fieldTree map[string]fieldTree
+
+// parseFields turns "overview,topVideos.video_id,topVideos.title" into a tree.
+// Listing a parent and one of its children keeps the whole parent.
+func parseFields(spec string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range strings.Split(spec, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := tree
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, seen := node[part]
+			if seen && child == nil {
+				// The parent is already kept whole
+				break
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if child == nil {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// selectFields keeps only the fields of a JSON document listed in tree.
+// Selections apply to every element of arrays, so "topVideos.title" keeps the
+// title of each video. Unknown top-level fields are an error so typos don't
+// silently return an empty document.
+func selectFields(data []byte, tree fieldTree) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	if object, ok := doc.(map[string]any); ok {
+		for field := range tree {
+			if _, ok := object[field]; !ok {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+		}
+	}
+	return json.Marshal(pruneFields(doc, tree))
+}
+
+func pruneFields(value any, tree fieldTree) any {
+	if tree == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		kept := make(map[string]any, len(tree))
+		for field, subtree := range tree {
+			if child, ok := v[field]; ok {
+				kept[field] = pruneFields(child, subtree)
+			}
+		}
+		return kept
+	case []any:
+		for i := range v {
+			v[i] = pruneFields(v[i], tree)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// jsonWithFields sends v as JSON, trimmed to the ?fields= selection when one is given
+func jsonWithFields(c *fiber.Ctx, v any) error {
+	spec := c.Query("fields")
+	if spec == "" {
+		return c.JSON(v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err = selectFields(data, parseFields(spec))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid fields: %v", err),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(data)
+}
@@ -0,0 +1,39 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	tree := parseFields(" overview, topVideos.title,topVideos.view_count,,recentVideos.title,recentVideos ")
+
+	assert.Equal(t, fieldTree{
+		"overview":     nil,
+		"topVideos":    fieldTree{"title": nil, "view_count": nil},
+		"recentVideos": nil,
+	}, tree)
+}
+
+func TestSelectFields(t *testing.T) {
+	doc := []byte(`{
+		"overview": {"total_views": 1200, "video_count": 3},
+		"topVideos": [
+			{"video_id": "v1", "title": "Best of", "thumbnail_url": "https://example.com/v1.jpg"},
+			{"video_id": "v2", "title": "Patch notes", "thumbnail_url": "https://example.com/v2.jpg"}
+		],
+		"recentVideos": []
+	}`)
+
+	data, err := selectFields(doc, parseFields("overview.total_views,topVideos.video_id"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"overview": {"total_views": 1200},
+		"topVideos": [{"video_id": "v1"}, {"video_id": "v2"}]
+	}`, string(data))
+
+	_, err = selectFields(doc, parseFields("overview,topVideoz"))
+	assert.Error(t, err)
+}
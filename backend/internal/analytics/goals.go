@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// collectCreatorGoals snapshots the creator's active Twitch goals. It is best
+// effort, tokens without channel:read:goals skip it.
+func (dc *dataCollector) collectCreatorGoals(ctx context.Context, userID, twitchToken, broadcasterID string) {
+	twitchGoals, err := dc.twitchClient.GetCreatorGoals(ctx, twitchToken, broadcasterID)
+	if err != nil {
+		log.Printf("Skipping creator goals for user %s: %v", userID, err)
+		return
+	}
+
+	goals := make([]CreatorGoal, 0, len(twitchGoals))
+	for _, g := range twitchGoals {
+		goals = append(goals, CreatorGoal{
+			GoalID:        g.ID,
+			Type:          g.Type,
+			Description:   g.Description,
+			CurrentAmount: g.CurrentAmount,
+			TargetAmount:  g.TargetAmount,
+			StartedAt:     g.CreatedAt,
+		})
+	}
+
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if err := dc.repo.SaveCreatorGoals(ctx, userID, date, goals); err != nil {
+		log.Printf("Failed to save creator goals for user %s: %v", userID, err)
+	}
+}
+
+// GetCreatorGoals returns active goals and those that ended in the last days,
+// with their daily progress
+func (s *service) GetCreatorGoals(ctx context.Context, userID string, days int) (*CreatorGoals, error) {
+	since := time.Now().AddDate(0, 0, -days)
+
+	goals, err := s.repo.GetCreatorGoals(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := s.repo.GetCreatorGoalSnapshots(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	result := buildCreatorGoals(goals, snapshots)
+	result.Days = days
+	return result, nil
+}
+
+// buildCreatorGoals splits goals into active and ended ones and attaches each
+// goal's snapshots
+func buildCreatorGoals(goals []CreatorGoal, snapshots []CreatorGoalSnapshot) *CreatorGoals {
+	history := make(map[int][]CreatorGoalSnapshot)
+	for _, snapshot := range snapshots {
+		history[snapshot.GoalID] = append(history[snapshot.GoalID], snapshot)
+	}
+
+	result := &CreatorGoals{
+		Active: []GoalProgress{},
+		Ended:  []GoalProgress{},
+	}
+	for _, goal := range goals {
+		progress := GoalProgress{
+			Source:      "twitch",
+			CreatorGoal: goal,
+			Completed:   goal.TargetAmount > 0 && goal.CurrentAmount >= goal.TargetAmount,
+			History:     history[goal.ID],
+		}
+		if progress.History == nil {
+			progress.History = []CreatorGoalSnapshot{}
+		}
+		if goal.TargetAmount > 0 {
+			progress.ProgressPercent = math.Min(float64(goal.CurrentAmount)/float64(goal.TargetAmount)*100, 100)
+		}
+
+		if goal.EndedAt == nil {
+			result.Active = append(result.Active, progress)
+		} else {
+			result.Ended = append(result.Ended, progress)
+		}
+	}
+	return result
+}
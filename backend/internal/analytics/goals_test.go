@@ -0,0 +1,39 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCreatorGoals(t *testing.T) {
+	ended := time.Now().AddDate(0, 0, -2)
+	goals := []CreatorGoal{
+		{ID: 1, GoalID: "g1", Type: "follower", CurrentAmount: 750, TargetAmount: 1000},
+		{ID: 2, GoalID: "g2", Type: "subscription_count", CurrentAmount: 60, TargetAmount: 50, EndedAt: &ended},
+		{ID: 3, GoalID: "g3", Type: "new_bit"},
+	}
+	snapshots := []CreatorGoalSnapshot{
+		{GoalID: 1, Date: time.Now().AddDate(0, 0, -1), CurrentAmount: 700, TargetAmount: 1000},
+		{GoalID: 1, Date: time.Now(), CurrentAmount: 750, TargetAmount: 1000},
+		{GoalID: 2, Date: time.Now().AddDate(0, 0, -3), CurrentAmount: 60, TargetAmount: 50},
+	}
+
+	result := buildCreatorGoals(goals, snapshots)
+
+	require.Len(t, result.Active, 2)
+	assert.Equal(t, "twitch", result.Active[0].Source)
+	assert.Equal(t, 75.0, result.Active[0].ProgressPercent)
+	assert.False(t, result.Active[0].Completed)
+	assert.Len(t, result.Active[0].History, 2)
+
+	// Goals without a target have no progress
+	assert.Zero(t, result.Active[1].ProgressPercent)
+	assert.NotNil(t, result.Active[1].History)
+
+	require.Len(t, result.Ended, 1)
+	assert.Equal(t, 100.0, result.Ended[0].ProgressPercent)
+	assert.True(t, result.Ended[0].Completed)
+}
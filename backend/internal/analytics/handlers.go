@@ -1,18 +1,34 @@
 package analytics
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
-	"strconv"
+	"strings"
 	"time"
 
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/billing"
 	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/money"
+	"github.com/baldybuilds/creatorsync/internal/validate"
 	"github.com/gofiber/fiber/v2"
 )
 
 type Handlers struct {
 	service                 Service
 	backgroundCollectionMgr *BackgroundCollectionManager
+	authMiddleware          fiber.Handler
+	localeMiddleware        fiber.Handler
+	degradedMiddleware      fiber.Handler
+	idempotencyMiddleware   fiber.Handler
+	audit                   *audit.Logger
+	limits                  *billing.Limits
+	diagnostics             *Diagnostics
+	quota                   *CollectionQuota
 }
 
 func NewHandlers(service Service, backgroundCollectionMgr *BackgroundCollectionManager) *Handlers {
@@ -22,6 +38,55 @@ func NewHandlers(service Service, backgroundCollectionMgr *BackgroundCollectionM
 	}
 }
 
+// UseAuthMiddleware replaces the Clerk middleware on protected routes, e.g. with
+// one that also accepts API keys. Call it before RegisterRoutes.
+func (h *Handlers) UseAuthMiddleware(middleware fiber.Handler) {
+	h.authMiddleware = middleware
+}
+
+// UseLocaleMiddleware runs after authentication on protected routes to apply
+// the user's saved locale. Call it before RegisterRoutes.
+func (h *Handlers) UseLocaleMiddleware(middleware fiber.Handler) {
+	h.localeMiddleware = middleware
+}
+
+// UseDegradedMiddleware runs last on protected routes, e.g. to answer from
+// cached responses while the database is down. Call it before RegisterRoutes.
+func (h *Handlers) UseDegradedMiddleware(middleware fiber.Handler) {
+	h.degradedMiddleware = middleware
+}
+
+// UseIdempotencyMiddleware runs on the manual collection triggers, e.g. to
+// replay the response of a request repeated with the same Idempotency-Key.
+// Call it before RegisterRoutes.
+func (h *Handlers) UseIdempotencyMiddleware(middleware fiber.Handler) {
+	h.idempotencyMiddleware = middleware
+}
+
+// UseAuditLog records export downloads and admin triggers. Call it before
+// RegisterRoutes.
+func (h *Handlers) UseAuditLog(logger *audit.Logger) {
+	h.audit = logger
+}
+
+// UsePlanLimits gates media kits on the user's plan and cuts ranges to their
+// history. Call it before RegisterRoutes.
+func (h *Handlers) UsePlanLimits(limits *billing.Limits) {
+	h.limits = limits
+}
+
+// UseDiagnostics serves /debug/diagnostics, which checks the user's Twitch
+// token live. Call it before RegisterRoutes.
+func (h *Handlers) UseDiagnostics(diagnostics *Diagnostics) {
+	h.diagnostics = diagnostics
+}
+
+// UseCollectionQuota limits the manual collection triggers per user and day.
+// Call it before RegisterRoutes.
+func (h *Handlers) UseCollectionQuota(quota *CollectionQuota) {
+	h.quota = quota
+}
+
 // Helper function to get user ID from context
 func (h *Handlers) getUserID(c *fiber.Ctx) (string, error) {
 	user, err := clerk.GetUserFromContext(c)
@@ -38,94 +103,1126 @@ func (h *Handlers) RegisterRoutes(app *fiber.App) {
 	// Public routes (no authentication required)
 	api.Get("/health", h.HealthCheck)
 
-	// Protected routes - require authentication
-	protected := api.Group("")
-	protected.Use(clerk.AuthMiddleware())
+	// Protected routes - require authentication
+	protected := api.Group("")
+	if h.authMiddleware != nil {
+		protected.Use(h.authMiddleware)
+	} else {
+		protected.Use(clerk.AuthMiddleware())
+	}
+	if h.localeMiddleware != nil {
+		protected.Use(h.localeMiddleware)
+	}
+	if h.degradedMiddleware != nil {
+		protected.Use(h.degradedMiddleware)
+	}
+
+	// Dashboard overview - returns summary metrics for main dashboard
+	protected.Get("/overview", h.GetDashboardOverview)
+
+	// Analytics page - returns detailed analytics
+	protected.Get("/detailed", h.GetDetailedAnalytics)
+
+	// Enhanced analytics - returns video-based analytics for new dashboard design.
+	// ?fields= trims it, e.g. to overview,performance with the video lists
+	// loaded from /top-videos and /recent-videos.
+	protected.Get("/enhanced", h.GetEnhancedAnalytics)
+	protected.Get("/top-videos", h.GetTopVideos)
+	protected.Get("/recent-videos", h.GetRecentVideos)
+
+	// Stream session detail with attributed VODs, clips and followers
+	protected.Get("/streams/:id", h.GetStreamSessionDetail)
+
+	// Paginated content library
+	protected.Get("/videos", h.ListVideos)
+
+	// Title keywords ranked by view lift
+	protected.Get("/keywords", h.GetKeywordInsights)
+
+	// VODs with muted audio, a sign of copyrighted music
+	protected.Get("/muted-vods", h.GetMutedVideoReport)
+
+	// Heavily clipped VOD moments worth turning into highlights
+	protected.Get("/highlight-suggestions", h.GetHighlightSuggestions)
+
+	// Clips ranked by how ready they are to repost as shorts
+	protected.Get("/repurpose", h.GetRepurposeCandidates)
+
+	// How views build up after publishing, per video type
+	protected.Get("/decay", h.GetVideoDecayCurves)
+
+	// Content and views by broadcast language
+	protected.Get("/audience", h.GetAudienceReport)
+
+	// Collab vs solo content, with collaborators tagged or read from titles
+	protected.Get("/collaborations", h.GetCollaborationReport)
+	protected.Put("/collaborations/:type/:id", h.SetCollaborators)
+	protected.Delete("/collaborations/:type/:id", h.DeleteCollaborators)
+
+	// Title A/B experiments comparing the VODs assigned to each variant
+	protected.Get("/experiments", h.GetTitleExperiments)
+	protected.Post("/experiments", h.CreateTitleExperiment)
+	protected.Get("/experiments/:id", h.GetTitleExperiment)
+	protected.Delete("/experiments/:id", h.DeleteTitleExperiment)
+	protected.Put("/experiments/:id/videos/:videoID", h.AssignExperimentVideo)
+	protected.Delete("/experiments/:id/videos/:videoID", h.UnassignExperimentVideo)
+
+	// Weekly natural-language insights (when a provider is configured)
+	protected.Get("/insights", h.GetWeeklyInsights)
+
+	// What each collection changed: new videos, followers, videos gaining views
+	protected.Get("/changes", h.GetChangeSummaries)
+
+	// Chart data for specific time periods
+	protected.Get("/charts", h.GetAnalyticsChartData)
+
+	// Weekly/monthly rollups of channel metrics past the retention window
+	protected.Get("/history", h.GetChannelHistory)
+
+	// Estimated revenue from subs and bits with a monthly trend
+	protected.Get("/revenue", h.GetRevenue)
+
+	// Channel points redemptions received through EventSub
+	protected.Get("/channel-points", h.GetChannelPointsAnalytics)
+
+	// Bans and timeouts over time relative to viewers
+	protected.Get("/chat-health", h.GetChatHealth)
+
+	// Raid sources/destinations and follower conversion
+	protected.Get("/raids", h.GetRaidAnalytics)
+
+	// Planned vs actual streams from the Twitch schedule
+	protected.Get("/schedule", h.GetStreamSchedule)
+
+	// Follower, subscription and bits goals from Twitch
+	protected.Get("/goals", h.GetCreatorGoals)
+
+	// Growth analysis
+	protected.Get("/growth", h.GetGrowthAnalysis)
+
+	// Content performance
+	protected.Get("/content", h.GetContentPerformance)
+
+	// Job status
+	protected.Get("/jobs", h.GetAnalyticsJobs)
+
+	// History from the channel analytics CSV exported from Twitch
+	protected.Post("/import", h.ImportChannelCSV)
+
+	// Sponsor media kit PDF, rendered in the background
+	protected.Post("/media-kit", h.limits.Require(billing.FeatureExports), h.RequestMediaKit)
+	protected.Get("/media-kit/:id", h.GetMediaKit)
+	protected.Get("/media-kit/:id/download", h.limits.Require(billing.FeatureExports), h.audit.Middleware(audit.ActionExportDownload), h.DownloadMediaKit)
+
+	// Manual data collection triggers, run once per Idempotency-Key
+	idempotent := h.idempotencyMiddleware
+	if idempotent == nil {
+		idempotent = func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	protected.Post("/collect", idempotent, h.TriggerDataCollection)
+	protected.Post("/refresh", idempotent, h.RefreshChannelData)
+
+	// Debug endpoint to check data status
+	protected.Get("/debug/data-status", h.GetDataStatus)
+	if h.diagnostics != nil {
+		protected.Get("/debug/diagnostics", h.GetDiagnostics)
+	}
+
+}
+
+// GetDashboardOverview returns summary metrics for the dashboard
+func (h *Handlers) GetDashboardOverview(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+	userID := user.ID
+
+	// Check if we need to trigger automatic data collection
+	h.triggerAutoDataCollectionIfNeeded(userID)
+
+	// Follower and subscriber changes cover ?days= (default 7)
+	var query struct {
+		Days int `query:"days" default:"7" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	overview, err := h.service.GetDashboardOverview(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("Error getting dashboard overview for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get dashboard overview",
+		})
+	}
+
+	return jsonWithFields(c, overview)
+}
+
+// GetAnalyticsChartData returns chart data for analytics visualization
+func (h *Handlers) GetAnalyticsChartData(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+	userID := user.ID
+
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	chartData, err := h.service.GetAnalyticsChartData(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("Error getting chart data for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get chart data",
+		})
+	}
+
+	return c.JSON(chartData)
+}
+
+// GetDetailedAnalytics returns comprehensive analytics for the analytics page
+func (h *Handlers) GetDetailedAnalytics(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	analytics, err := h.service.GetDetailedAnalytics(c.UserContext(), userID)
+	if err != nil {
+		log.Printf("Error getting detailed analytics for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get detailed analytics",
+		})
+	}
+
+	return jsonWithFields(c, analytics)
+}
+
+// GetEnhancedAnalytics returns video-based analytics for the new dashboard design
+func (h *Handlers) GetEnhancedAnalytics(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	log.Printf("🔍 Enhanced analytics request for user %s", userID)
+
+	// Check if we need to trigger automatic data collection
+	h.triggerAutoDataCollectionIfNeeded(userID)
+
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	log.Printf("📊 Fetching enhanced analytics for user %s (days: %d)", userID, query.Days)
+	analytics, err := h.service.GetEnhancedAnalytics(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("❌ Error getting enhanced analytics for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get enhanced analytics",
+		})
+	}
+
+	log.Printf("✅ Enhanced analytics response for user %s: %+v", userID, analytics.Overview)
+	return jsonWithFields(c, analytics)
+}
+
+// GetTopVideos returns the top videos, ranked by total views or with
+// ?rank=views_per_day by views per day since publishing. ?type= limits them to
+// one video type and ?limit= sets how many. It lets dashboards load the list
+// separately from /enhanced.
+func (h *Handlers) GetTopVideos(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Limit int    `query:"limit" default:"5" validate:"min=1,max=50"`
+		Rank  string `query:"rank" default:"views" validate:"oneof=views views_per_day"`
+		Type  string `query:"type"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	videos, err := h.service.GetTopVideos(c.UserContext(), userID, query.Type, query.Rank, query.Limit)
+	if err != nil {
+		log.Printf("Error getting top videos for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get videos",
+		})
+	}
+
+	return jsonWithFields(c, fiber.Map{
+		"videos": videos,
+	})
+}
+
+// GetRecentVideos returns the newest videos; ?limit= sets how many
+func (h *Handlers) GetRecentVideos(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Limit int `query:"limit" default:"10" validate:"min=1,max=50"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	videos, err := h.service.GetRecentVideos(c.UserContext(), userID, query.Limit)
+	if err != nil {
+		log.Printf("Error getting recent videos for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get videos",
+		})
+	}
+
+	return jsonWithFields(c, fiber.Map{
+		"videos": videos,
+	})
+}
+
+// GetStreamSessionDetail returns a single stream joined with the content attributed to it
+func (h *Handlers) GetStreamSessionDetail(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	streamID := c.Params("id")
+	detail, err := h.service.GetStreamSessionDetail(c.UserContext(), userID, streamID)
+	if err != nil {
+		log.Printf("Error getting stream session %s for user %s: %v", streamID, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get stream session",
+		})
+	}
+
+	if detail == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Stream session not found",
+		})
+	}
+
+	return c.JSON(detail)
+}
+
+// ListVideos returns one page of the user's videos. Supports ?limit= (max 100),
+// ?offset=, ?sort=date|views|duration|views_per_day, ?order=asc|desc, ?type=, ?from=, ?to=
+// (dates or RFC 3339 times, to is inclusive for dates) and ?q= title search.
+// nextOffset in the response points at the following page.
+func (h *Handlers) ListVideos(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Limit  int    `query:"limit" default:"25" validate:"min=1,max=100"`
+		Offset int    `query:"offset" validate:"min=0"`
+		Sort   string `query:"sort"`
+		Order  string `query:"order" default:"desc" validate:"oneof=asc desc"`
+		Type   string `query:"type"`
+		From   string `query:"from"`
+		To     string `query:"to"`
+		Search string `query:"q"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	from, err := parseVideoDate(query.From, false)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid from: %v", err),
+		})
+	}
+	to, err := parseVideoDate(query.To, true)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid to: %v", err),
+		})
+	}
+
+	listQuery, err := VideoListQuery{
+		Limit:     query.Limit,
+		Offset:    query.Offset,
+		Sort:      query.Sort,
+		Ascending: query.Order == "asc",
+		VideoType: query.Type,
+		From:      from,
+		To:        to,
+		Search:    query.Search,
+	}.Normalize()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := h.service.ListVideos(c.UserContext(), userID, listQuery)
+	if err != nil {
+		log.Printf("Error listing videos for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list videos",
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// GetKeywordInsights ranks title keywords by how much their videos outperform the
+// creator's average; ?days= limits the window (0 for all time) and ?min_videos=
+// how often a keyword must appear to be ranked
+func (h *Handlers) GetKeywordInsights(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days      int `query:"days" validate:"min=0,max=3650"`
+		MinVideos int `query:"min_videos" default:"2" validate:"min=1,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	insights, err := h.service.GetKeywordInsights(c.UserContext(), userID, query.Days, query.MinVideos)
+	if err != nil {
+		log.Printf("Error getting keyword insights for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get keyword insights",
+		})
+	}
+
+	return c.JSON(insights)
+}
+
+// GetMutedVideoReport lists videos with heavy muting. ?days= sets the window
+// and ?min_percent= the muted share a video needs to be listed (default 10).
+func (h *Handlers) GetMutedVideoReport(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days       int     `query:"days" default:"90" validate:"min=1,max=365"`
+		MinPercent float64 `query:"min_percent" default:"10" validate:"min=0,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	report, err := h.service.GetMutedVideoReport(c.UserContext(), userID, query.Days, query.MinPercent)
+	if err != nil {
+		log.Printf("Error getting muted video report for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get muted video report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetHighlightSuggestions lists the most clipped VOD moments. ?video_id= limits
+// them to one VOD and ?limit= sets how many.
+func (h *Handlers) GetHighlightSuggestions(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		VideoID string `query:"video_id"`
+		Limit   int    `query:"limit" default:"20" validate:"min=1,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	suggestions, err := h.service.GetHighlightSuggestions(c.UserContext(), userID, query.VideoID, query.Limit)
+	if err != nil {
+		log.Printf("Error getting highlight suggestions for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get highlight suggestions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"suggestions": suggestions,
+	})
+}
+
+// GetRepurposeCandidates ranks recent clips by short-form readiness. ?days=
+// sets how far back clips are checked and ?limit= how many are returned.
+func (h *Handlers) GetRepurposeCandidates(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days  int `query:"days" default:"30" validate:"min=1,max=365"`
+		Limit int `query:"limit" default:"20" validate:"min=1,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	report, err := h.service.GetRepurposeCandidates(c.UserContext(), userID, query.Days, query.Limit)
+	if err != nil {
+		log.Printf("Error getting repurpose candidates for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get repurpose candidates",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetVideoDecayCurves returns the view decay curves of the user's content
+// over the first ?days= (default 30) after publishing
+func (h *Handlers) GetVideoDecayCurves(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	report, err := h.service.GetVideoDecayCurves(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("Error getting video decay curves for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get video decay curves",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetAudienceReport returns the language breakdown of the user's content of
+// the last ?days= (default 90)
+func (h *Handlers) GetAudienceReport(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days int `query:"days" default:"90" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	report, err := h.service.GetAudienceReport(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("Error getting audience report for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get audience report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetCollaborationReport compares collab and solo content of the last ?days=
+func (h *Handlers) GetCollaborationReport(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days int `query:"days" default:"90" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	report, err := h.service.GetCollaborationReport(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("Error getting collaboration report for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get collaboration report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// collabContentType validates the :type of a collaborations route
+func collabContentType(c *fiber.Ctx) (string, bool) {
+	contentType := c.Params("type")
+	return contentType, contentType == "stream" || contentType == "video"
+}
+
+// SetCollaborators tags a stream or video with the Twitch logins in the body's
+// "collaborators", replacing the ones detected from its title. An empty list
+// marks it as solo.
+func (h *Handlers) SetCollaborators(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	contentType, ok := collabContentType(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "type must be stream or video",
+		})
+	}
+
+	var req struct {
+		Collaborators []string `json:"collaborators"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	logins, err := normalizeCollaborators(req.Collaborators)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	contentID := c.Params("id")
+	found, err := h.service.SetCollaborators(c.UserContext(), userID, contentType, contentID, logins)
+	if err != nil {
+		log.Printf("Error tagging collaborators of %s %s for user %s: %v", contentType, contentID, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save collaborators",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Content not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"content_type":  contentType,
+		"content_id":    contentID,
+		"collaborators": logins,
+	})
+}
+
+// DeleteCollaborators removes the tags of a stream or video so its
+// collaborators are detected from the title again
+func (h *Handlers) DeleteCollaborators(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	contentType, ok := collabContentType(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "type must be stream or video",
+		})
+	}
+
+	contentID := c.Params("id")
+	found, err := h.service.DeleteCollaborators(c.UserContext(), userID, contentType, contentID)
+	if err != nil {
+		log.Printf("Error removing collaborators of %s %s for user %s: %v", contentType, contentID, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save collaborators",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Collaborator tags not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Collaborator tags removed",
+	})
+}
+
+// GetTitleExperiments returns the user's title experiments with the
+// performance of both variants
+func (h *Handlers) GetTitleExperiments(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	experiments, err := h.service.GetTitleExperiments(c.UserContext(), userID)
+	if err != nil {
+		log.Printf("Error getting title experiments for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get title experiments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"experiments": experiments,
+	})
+}
+
+// CreateTitleExperiment creates an experiment from the body's "name",
+// "variant_a" and "variant_b"
+func (h *Handlers) CreateTitleExperiment(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req struct {
+		Name     string `json:"name"`
+		VariantA string `json:"variant_a"`
+		VariantB string `json:"variant_b"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	experiment := &TitleExperiment{UserID: userID, Name: req.Name, VariantA: req.VariantA, VariantB: req.VariantB}
+	if err := normalizeExperiment(experiment); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.service.CreateTitleExperiment(c.UserContext(), experiment); err != nil {
+		log.Printf("Error creating title experiment for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create experiment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"experiment": experiment,
+	})
+}
+
+// experimentID parses the :id of an experiments route
+func experimentID(c *fiber.Ctx) (int, bool) {
+	id, err := c.ParamsInt("id")
+	return id, err == nil && id > 0
+}
+
+// GetTitleExperiment returns an experiment with its VODs and how both
+// variants performed
+func (h *Handlers) GetTitleExperiment(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, ok := experimentID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid experiment ID",
+		})
+	}
+
+	result, err := h.service.GetTitleExperiment(c.UserContext(), userID, id)
+	if err != nil {
+		log.Printf("Error getting title experiment %d for user %s: %v", id, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get title experiment",
+		})
+	}
+	if result == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Experiment not found",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// DeleteTitleExperiment deletes an experiment and its VOD assignments
+func (h *Handlers) DeleteTitleExperiment(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, ok := experimentID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid experiment ID",
+		})
+	}
+
+	found, err := h.service.DeleteTitleExperiment(c.UserContext(), userID, id)
+	if err != nil {
+		log.Printf("Error deleting title experiment %d for user %s: %v", id, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete experiment",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Experiment not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Experiment deleted",
+		"id":      id,
+	})
+}
+
+// AssignExperimentVideo puts a VOD in the body's "variant", A or B, of an
+// experiment
+func (h *Handlers) AssignExperimentVideo(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, ok := experimentID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid experiment ID",
+		})
+	}
+
+	var req struct {
+		Variant string `json:"variant"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	variant, ok := normalizeVariant(req.Variant)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "variant must be A or B",
+		})
+	}
+
+	videoID := c.Params("videoID")
+	found, err := h.service.AssignExperimentVideo(c.UserContext(), userID, id, videoID, variant)
+	if err != nil {
+		log.Printf("Error assigning %s to title experiment %d for user %s: %v", videoID, id, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save experiment video",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Experiment or VOD not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"experiment_id": id,
+		"video_id":      videoID,
+		"variant":       variant,
+	})
+}
+
+// UnassignExperimentVideo takes a VOD out of an experiment
+func (h *Handlers) UnassignExperimentVideo(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, ok := experimentID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid experiment ID",
+		})
+	}
+
+	videoID := c.Params("videoID")
+	found, err := h.service.UnassignExperimentVideo(c.UserContext(), userID, id, videoID)
+	if err != nil {
+		log.Printf("Error removing %s from title experiment %d for user %s: %v", videoID, id, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save experiment video",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "VOD not in this experiment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "VOD removed from the experiment",
+	})
+}
+
+// GetWeeklyInsights returns the generated weekly insights, newest week first;
+// ?limit= sets how many weeks
+func (h *Handlers) GetWeeklyInsights(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Limit int `query:"limit" default:"4" validate:"min=1,max=12"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	weeks, err := h.service.GetWeeklyInsights(c.UserContext(), userID, query.Limit)
+	if err != nil {
+		log.Printf("Error getting weekly insights for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get weekly insights",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"weeks": weeks,
+	})
+}
+
+// GetChangeSummaries returns what the user's collections of the last ?days=
+// (default 7) changed, newest first
+func (h *Handlers) GetChangeSummaries(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days  int `query:"days" default:"7" validate:"min=1,max=90"`
+		Limit int `query:"limit" default:"20" validate:"min=1,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
 
-	// Dashboard overview - returns summary metrics for main dashboard
-	protected.Get("/overview", h.GetDashboardOverview)
+	changes, err := h.service.GetChangeSummaries(c.UserContext(), userID, query.Days, query.Limit)
+	if err != nil {
+		log.Printf("Error getting change summaries for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get collection changes",
+		})
+	}
 
-	// Analytics page - returns detailed analytics
-	protected.Get("/detailed", h.GetDetailedAnalytics)
+	return c.JSON(fiber.Map{
+		"changes": changes,
+		"days":    query.Days,
+	})
+}
 
-	// Enhanced analytics - returns video-based analytics for new dashboard design
-	protected.Get("/enhanced", h.GetEnhancedAnalytics)
+// GetRevenue returns estimated subscription and bits revenue; ?months= sets the
+// trend length and ?currency= overrides the user's preferred display currency
+func (h *Handlers) GetRevenue(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
 
-	// Chart data for specific time periods
-	protected.Get("/charts", h.GetAnalyticsChartData)
+	var query struct {
+		Months   int    `query:"months" default:"12" validate:"min=1,max=36"`
+		Currency string `query:"currency"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Months = h.limits.TruncateMonths(c, query.Months)
 
-	// Growth analysis
-	protected.Get("/growth", h.GetGrowthAnalysis)
+	var currency money.Currency
+	if code := query.Currency; code != "" {
+		if currency, err = money.ParseCurrency(code); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":     "Unsupported currency",
+				"supported": money.Supported(),
+			})
+		}
+	}
 
-	// Content performance
-	protected.Get("/content", h.GetContentPerformance)
+	revenue, err := h.service.GetRevenue(c.UserContext(), userID, query.Months, currency)
+	if err != nil {
+		log.Printf("Error getting revenue for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get revenue analytics",
+		})
+	}
 
-	// Job status
-	protected.Get("/jobs", h.GetAnalyticsJobs)
+	return c.JSON(revenue)
+}
 
-	// Manual data collection triggers
-	protected.Post("/collect", h.TriggerDataCollection)
-	protected.Post("/refresh", h.RefreshChannelData)
+// GetChannelPointsAnalytics returns redemption counts, top rewards and a daily trend; ?days= sets the window
+func (h *Handlers) GetChannelPointsAnalytics(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
 
-	// Debug endpoint to check data status
-	protected.Get("/debug/data-status", h.GetDataStatus)
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	result, err := h.service.GetChannelPointsAnalytics(c.UserContext(), userID, query.Days)
+	if err != nil {
+		log.Printf("Error getting channel points analytics for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get channel points analytics",
+		})
+	}
 
+	return c.JSON(result)
 }
 
-// GetDashboardOverview returns summary metrics for the dashboard
-func (h *Handlers) GetDashboardOverview(c *fiber.Ctx) error {
-	user, err := clerk.GetUserFromContext(c)
+// GetChatHealth returns the chat health panel; ?days= sets the window
+func (h *Handlers) GetChatHealth(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "User not authenticated",
 		})
 	}
-	userID := user.ID
 
-	// Check if we need to trigger automatic data collection
-	h.triggerAutoDataCollectionIfNeeded(userID)
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
 
-	overview, err := h.service.GetDashboardOverview(c.Context(), userID)
+	health, err := h.service.GetChatHealth(c.UserContext(), userID, query.Days)
 	if err != nil {
-		log.Printf("Error getting dashboard overview for user %s: %v", userID, err)
+		log.Printf("Error getting chat health for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get dashboard overview",
+			"error": "Failed to get chat health",
 		})
 	}
 
-	return c.JSON(overview)
+	return c.JSON(health)
 }
 
-// GetAnalyticsChartData returns chart data for analytics visualization
-func (h *Handlers) GetAnalyticsChartData(c *fiber.Ctx) error {
-	user, err := clerk.GetUserFromContext(c)
+// GetRaidAnalytics returns top raid partners and follower conversion; ?days= sets the window
+func (h *Handlers) GetRaidAnalytics(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "User not authenticated",
 		})
 	}
-	userID := user.ID
 
-	// Get days parameter (default to 30)
-	daysStr := c.Query("days", "30")
-	days, err := strconv.Atoi(daysStr)
-	if err != nil || days <= 0 {
-		days = 30
+	var query struct {
+		Days int `query:"days" default:"90" validate:"min=1,max=365"`
 	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
 
-	chartData, err := h.service.GetAnalyticsChartData(c.Context(), userID, days)
+	raids, err := h.service.GetRaidAnalytics(c.UserContext(), userID, query.Days)
 	if err != nil {
-		log.Printf("Error getting chart data for user %s: %v", userID, err)
+		log.Printf("Error getting raid analytics for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get chart data",
+			"error": "Failed to get raid analytics",
 		})
 	}
 
-	return c.JSON(chartData)
+	return c.JSON(raids)
 }
 
-// GetDetailedAnalytics returns comprehensive analytics for the analytics page
-func (h *Handlers) GetDetailedAnalytics(c *fiber.Ctx) error {
+// GetStreamSchedule returns schedule adherence with kept, missed and extra
+// streams plus the upcoming schedule; ?days= sets the window
+func (h *Handlers) GetStreamSchedule(c *fiber.Ctx) error {
 	userID, err := h.getUserID(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -133,19 +1230,27 @@ func (h *Handlers) GetDetailedAnalytics(c *fiber.Ctx) error {
 		})
 	}
 
-	analytics, err := h.service.GetDetailedAnalytics(c.Context(), userID)
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
+
+	schedule, err := h.service.GetStreamSchedule(c.UserContext(), userID, query.Days)
 	if err != nil {
-		log.Printf("Error getting detailed analytics for user %s: %v", userID, err)
+		log.Printf("Error getting stream schedule for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get detailed analytics",
+			"error": "Failed to get stream schedule",
 		})
 	}
 
-	return c.JSON(analytics)
+	return c.JSON(schedule)
 }
 
-// GetEnhancedAnalytics returns video-based analytics for the new dashboard design
-func (h *Handlers) GetEnhancedAnalytics(c *fiber.Ctx) error {
+// GetCreatorGoals returns active goals and the ones that ended within ?days=
+func (h *Handlers) GetCreatorGoals(c *fiber.Ctx) error {
 	userID, err := h.getUserID(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -153,29 +1258,23 @@ func (h *Handlers) GetEnhancedAnalytics(c *fiber.Ctx) error {
 		})
 	}
 
-	log.Printf("🔍 Enhanced analytics request for user %s", userID)
-
-	// Check if we need to trigger automatic data collection
-	h.triggerAutoDataCollectionIfNeeded(userID)
-
-	// Get days parameter (default to 30)
-	daysStr := c.Query("days", "30")
-	days, err := strconv.Atoi(daysStr)
-	if err != nil || days <= 0 {
-		days = 30
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
 	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	query.Days = h.limits.TruncateDays(c, query.Days)
 
-	log.Printf("📊 Fetching enhanced analytics for user %s (days: %d)", userID, days)
-	analytics, err := h.service.GetEnhancedAnalytics(c.Context(), userID, days)
+	goals, err := h.service.GetCreatorGoals(c.UserContext(), userID, query.Days)
 	if err != nil {
-		log.Printf("❌ Error getting enhanced analytics for user %s: %v", userID, err)
+		log.Printf("Error getting creator goals for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get enhanced analytics",
+			"error": "Failed to get goals",
 		})
 	}
 
-	log.Printf("✅ Enhanced analytics response for user %s: %+v", userID, analytics.Overview)
-	return c.JSON(analytics)
+	return c.JSON(goals)
 }
 
 // GetGrowthAnalysis provides growth trend analysis
@@ -187,12 +1286,14 @@ func (h *Handlers) GetGrowthAnalysis(c *fiber.Ctx) error {
 		})
 	}
 
-	period := c.Query("period", "month")
-	if period != "week" && period != "month" && period != "quarter" && period != "year" {
-		period = "month"
+	var query struct {
+		Period string `query:"period" default:"month" validate:"oneof=week month quarter year"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
 	}
 
-	analysis, err := h.service.GetGrowthAnalysis(c.Context(), userID, period)
+	analysis, err := h.service.GetGrowthAnalysis(c.UserContext(), userID, query.Period)
 	if err != nil {
 		log.Printf("Error getting growth analysis for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -212,7 +1313,7 @@ func (h *Handlers) GetContentPerformance(c *fiber.Ctx) error {
 		})
 	}
 
-	performance, err := h.service.GetContentPerformance(c.Context(), userID)
+	performance, err := h.service.GetContentPerformance(c.UserContext(), userID)
 	if err != nil {
 		log.Printf("Error getting content performance for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -223,6 +1324,44 @@ func (h *Handlers) GetContentPerformance(c *fiber.Ctx) error {
 	return c.JSON(performance)
 }
 
+// GetChannelHistory returns rolled-up channel metrics older than the raw retention window
+func (h *Handlers) GetChannelHistory(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Period string `query:"period" default:"month" validate:"oneof=week month"`
+		Limit  int    `query:"limit" default:"24" validate:"min=1,max=520"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	rollups, err := h.service.GetChannelHistory(c.UserContext(), userID, query.Period, query.Limit)
+	if err != nil {
+		log.Printf("Error getting channel history for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get channel history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"period":  query.Period,
+		"history": rollups,
+	})
+}
+
+// collectRequest is the optional body of POST /collect; omitted fields use the server defaults
+type collectRequest struct {
+	MaxVideos    *int     `json:"max_videos" validate:"min=1"`
+	IncludeClips *bool    `json:"include_clips"`
+	VideoTypes   []string `json:"video_types"`
+}
+
 // TriggerDataCollection manually triggers data collection for a user
 func (h *Handlers) TriggerDataCollection(c *fiber.Ctx) error {
 	userID, err := h.getUserID(c)
@@ -232,16 +1371,121 @@ func (h *Handlers) TriggerDataCollection(c *fiber.Ctx) error {
 		})
 	}
 
-	// Trigger data collection in background
-	h.backgroundCollectionMgr.TriggerUserCollection(userID)
+	var req collectRequest
+	if err := validate.Body(c, &req); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	opts := DefaultCollectionOptions()
+	if req.MaxVideos != nil {
+		opts.MaxVideos = *req.MaxVideos
+	}
+	if req.IncludeClips != nil {
+		opts.IncludeClips = *req.IncludeClips
+	}
+	if len(req.VideoTypes) > 0 {
+		opts.VideoTypes = req.VideoTypes
+	}
+
+	opts, err = opts.Normalize()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	quota, ok := h.takeCollectionQuota(c, userID)
+	if !ok {
+		return collectionQuotaExceeded(c, quota)
+	}
+
+	// Trigger data collection in background. Its report, saying which parts
+	// succeeded, is on the user_collection job listed under jobs_url.
+	if !h.backgroundCollectionMgr.TriggerUserCollection(userID, opts) {
+		// Nothing started, so it doesn't count against the quota
+		h.quota.Release(c.UserContext(), userID)
+		quota = quota.released()
+		setQuotaHeaders(c, quota)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A data collection is already running",
+			"quota": quota,
+		})
+	}
 
 	return c.JSON(fiber.Map{
 		"message":   "Data collection triggered successfully",
 		"user_id":   userID,
+		"options":   opts,
+		"jobs_url":  "/api/analytics/jobs",
+		"quota":     quota,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// takeCollectionQuota uses one of the user's manual collections for today,
+// and returns false when none are left. The quota is soft: when it can't be
+// checked, the collection runs anyway.
+func (h *Handlers) takeCollectionQuota(c *fiber.Ctx, userID string) (*CollectionQuotaStatus, bool) {
+	quota, err := h.quota.Take(c.UserContext(), userID)
+	setQuotaHeaders(c, quota)
+	if errors.Is(err, ErrCollectionQuotaExceeded) {
+		return quota, false
+	}
+	if err != nil {
+		log.Printf("Failed to check manual collection quota of user %s, collecting anyway: %v", userID, err)
+	}
+	return quota, true
+}
+
+// RegisterAdminRoutes registers collection triggers on an admin-only router
+func (h *Handlers) RegisterAdminRoutes(router fiber.Router) {
+	router.Post("/collect", h.TriggerDailyCollection)
+	router.Post("/warm-cache", h.TriggerCacheWarmUp)
+	router.Get("/schedules", h.GetSchedules)
+}
+
+// GetSchedules lists the scheduler's jobs with their cron expressions and runs
+func (h *Handlers) GetSchedules(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"schedules": h.backgroundCollectionMgr.Schedules(),
+	})
+}
+
+// TriggerDailyCollection starts the daily collection for all users right away
+func (h *Handlers) TriggerDailyCollection(c *fiber.Ctx) error {
+	h.backgroundCollectionMgr.TriggerDailyCollection()
+	h.audit.RecordRequest(c, "", audit.ActionAdminTrigger, "", map[string]any{
+		"job": "daily_collection",
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":   "Daily collection triggered",
 		"timestamp": time.Now().Unix(),
 	})
 }
 
+// TriggerCacheWarmUp precomputes the dashboard payloads of every active user
+// on this replica in the background, tracked by the returned job
+func (h *Handlers) TriggerCacheWarmUp(c *fiber.Ctx) error {
+	job, err := h.service.WarmAllUsers(c.UserContext())
+	if err != nil {
+		log.Printf("Failed to start cache warm-up: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start cache warm-up",
+		})
+	}
+	h.audit.RecordRequest(c, "", audit.ActionAdminTrigger, "", map[string]any{
+		"job":    "cache_warmup",
+		"job_id": job.ID,
+		"users":  job.ProgressTotal,
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Cache warm-up triggered",
+		"job":     job,
+	})
+}
+
 // RefreshChannelData specifically refreshes channel metrics
 func (h *Handlers) RefreshChannelData(c *fiber.Ctx) error {
 	userID, err := h.getUserID(c)
@@ -251,9 +1495,15 @@ func (h *Handlers) RefreshChannelData(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.service.RefreshChannelData(c.Context(), userID)
+	quota, ok := h.takeCollectionQuota(c, userID)
+	if !ok {
+		return collectionQuotaExceeded(c, quota)
+	}
+
+	err = h.service.RefreshChannelData(c.UserContext(), userID)
 	if err != nil {
 		log.Printf("Error refreshing channel data for user %s: %v", userID, err)
+		h.quota.Release(c.UserContext(), userID)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to refresh channel data",
 		})
@@ -262,10 +1512,59 @@ func (h *Handlers) RefreshChannelData(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"message":   "Channel data refreshed successfully",
 		"user_id":   userID,
+		"quota":     quota,
 		"timestamp": time.Now().Unix(),
 	})
 }
 
+// ImportChannelCSV imports the channel analytics CSV exported from the Twitch
+// creator dashboard, sent as the "file" form field or as the request body,
+// and returns the import's report
+func (h *Handlers) ImportChannelCSV(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var file io.Reader
+	if header, err := c.FormFile("file"); err == nil {
+		upload, err := header.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read the uploaded file",
+			})
+		}
+		defer upload.Close()
+		file = upload
+	} else if body := c.Body(); len(body) > 0 && !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		file = bytes.NewReader(body)
+	} else {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Send the CSV as the file form field or as the request body",
+		})
+	}
+
+	report, err := h.service.ImportChannelCSV(c.UserContext(), userID, file)
+	if errors.Is(err, ErrInvalidImport) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		log.Printf("Error importing analytics CSV for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import analytics",
+		})
+	}
+
+	if report.Status == CollectionFailed {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(report)
+	}
+	return c.JSON(report)
+}
+
 // GetAnalyticsJobs returns the status of analytics jobs for a user
 func (h *Handlers) GetAnalyticsJobs(c *fiber.Ctx) error {
 	userID, err := h.getUserID(c)
@@ -275,14 +1574,14 @@ func (h *Handlers) GetAnalyticsJobs(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get limit parameter (default to 10)
-	limitStr := c.Query("limit", "10")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+	var query struct {
+		Limit int `query:"limit" default:"10" validate:"min=1,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
 	}
 
-	jobs, err := h.service.GetAnalyticsJobs(c.Context(), userID, limit)
+	jobs, err := h.service.GetAnalyticsJobs(c.UserContext(), userID, query.Limit)
 	if err != nil {
 		log.Printf("Error getting analytics jobs for user %s: %v", userID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -297,6 +1596,116 @@ func (h *Handlers) GetAnalyticsJobs(c *fiber.Ctx) error {
 	})
 }
 
+// RequestMediaKit starts rendering a media kit PDF and returns where to poll for it
+func (h *Handlers) RequestMediaKit(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	// The body is optional, a content template adds an about section
+	var req struct {
+		TemplateID int `json:"template_id" validate:"min=0"`
+	}
+	if err := validate.Body(c, &req); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	kit, err := h.service.RequestMediaKit(c.UserContext(), userID, req.TemplateID)
+	if errors.Is(err, ErrTemplateNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Template not found",
+		})
+	}
+	if err != nil {
+		log.Printf("Error requesting media kit for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start media kit generation",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(mediaKitResponse(kit))
+}
+
+// GetMediaKit returns a media kit's status, with a download link once it is ready
+func (h *Handlers) GetMediaKit(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid media kit ID",
+		})
+	}
+
+	kit, err := h.service.GetMediaKit(c.UserContext(), userID, id)
+	if err != nil {
+		log.Printf("Error getting media kit %d for user %s: %v", id, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get media kit",
+		})
+	}
+	if kit == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Media kit not found",
+		})
+	}
+
+	return c.JSON(mediaKitResponse(kit))
+}
+
+// DownloadMediaKit serves a completed media kit PDF
+func (h *Handlers) DownloadMediaKit(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid media kit ID",
+		})
+	}
+
+	pdf, err := h.service.GetMediaKitPDF(c.UserContext(), userID, id)
+	if err != nil {
+		log.Printf("Error downloading media kit %d for user %s: %v", id, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to download media kit",
+		})
+	}
+	if pdf == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Media kit not found or not ready",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="media-kit-%d.pdf"`, id))
+	return c.Send(pdf)
+}
+
+func mediaKitResponse(kit *MediaKit) fiber.Map {
+	response := fiber.Map{
+		"media_kit":  kit,
+		"status_url": fmt.Sprintf("/api/analytics/media-kit/%d", kit.ID),
+	}
+	if kit.Status == "completed" {
+		response["download_url"] = fmt.Sprintf("/api/analytics/media-kit/%d/download", kit.ID)
+	}
+	return response
+}
+
 // triggerAutoDataCollectionIfNeeded checks if we should automatically collect data for a user
 func (h *Handlers) triggerAutoDataCollectionIfNeeded(userID string) {
 	log.Printf("🔍 Checking if data collection needed for user %s", userID)
@@ -330,7 +1739,7 @@ func (h *Handlers) triggerAutoDataCollectionIfNeeded(userID string) {
 
 	if shouldCollect {
 		log.Printf("🔄 Auto-triggering data collection for user %s: %s", userID, reason)
-		h.backgroundCollectionMgr.TriggerUserCollection(userID)
+		h.backgroundCollectionMgr.TriggerUserCollection(userID, DefaultCollectionOptions())
 	} else {
 		log.Printf("⏭️ No data collection needed for user %s", userID)
 	}
@@ -345,7 +1754,7 @@ func (h *Handlers) GetDataStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	hasData, lastUpdate, err := h.service.CheckUserAnalyticsData(c.Context(), userID)
+	hasData, lastUpdate, err := h.service.CheckUserAnalyticsData(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -360,6 +1769,27 @@ func (h *Handlers) GetDataStatus(c *fiber.Ctx) error {
 	})
 }
 
+// GetDiagnostics checks the user's collection end to end, for support to see
+// why their dashboard is empty without reading server logs
+func (h *Handlers) GetDiagnostics(c *fiber.Ctx) error {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	report, err := h.diagnostics.Run(c.UserContext(), userID)
+	if err != nil {
+		log.Printf("Error running diagnostics for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run diagnostics",
+		})
+	}
+
+	return c.JSON(report)
+}
+
 // HealthCheck returns the health status of the analytics service
 func (h *Handlers) HealthCheck(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
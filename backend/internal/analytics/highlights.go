@@ -0,0 +1,125 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// highlightClipGap joins a clip to a moment when it starts at most this many
+	// seconds after the moment's last clip ends
+	highlightClipGap = 60
+	// highlightMinClips is how many clips make a moment worth a highlight
+	highlightMinClips = 2
+	// highlightLeadIn starts the suggestion a little before the first clip so
+	// the highlight has context
+	highlightLeadIn = 30
+	// highlightVODMaxAgeDays skips VODs Twitch has most likely deleted already
+	highlightVODMaxAgeDays = 60
+)
+
+// updateHighlightSuggestions clusters the user's clips on their VODs and
+// stores the resulting moments
+func (dc *dataCollector) updateHighlightSuggestions(ctx context.Context, userID string) error {
+	moments, err := dc.repo.GetClipMoments(ctx, userID, time.Now().AddDate(0, 0, -highlightVODMaxAgeDays))
+	if err != nil {
+		return err
+	}
+
+	suggestions := clusterClips(moments)
+	if err := dc.repo.ReplaceHighlightSuggestions(ctx, userID, suggestions); err != nil {
+		return fmt.Errorf("failed to save highlight suggestions: %w", err)
+	}
+
+	if len(suggestions) > 0 {
+		log.Printf("Found %d highlight suggestions in %d clips for user %s", len(suggestions), len(moments), userID)
+	}
+	return nil
+}
+
+// clusterClips groups clips of the same VOD whose time ranges overlap or are
+// close, and keeps groups of at least highlightMinClips clips
+func clusterClips(moments []ClipMoment) []HighlightSuggestion {
+	byVideo := make(map[string][]ClipMoment)
+	for _, moment := range moments {
+		byVideo[moment.VideoID] = append(byVideo[moment.VideoID], moment)
+	}
+
+	suggestions := []HighlightSuggestion{}
+	for videoID, clips := range byVideo {
+		sort.Slice(clips, func(i, j int) bool { return clips[i].OffsetSeconds < clips[j].OffsetSeconds })
+
+		// Moments get a lead in, without running past either end of the VOD
+		videoDuration := clips[0].VideoDuration
+		var current *HighlightSuggestion
+		flush := func() {
+			if current != nil && current.ClipCount >= highlightMinClips {
+				current.StartSeconds = max(current.StartSeconds-highlightLeadIn, 0)
+				if videoDuration > 0 && current.EndSeconds > videoDuration {
+					current.EndSeconds = videoDuration
+				}
+				suggestions = append(suggestions, *current)
+			}
+			current = nil
+		}
+
+		for _, clip := range clips {
+			end := clip.OffsetSeconds + clip.DurationSeconds
+			if current != nil && clip.OffsetSeconds > current.EndSeconds+highlightClipGap {
+				flush()
+			}
+			if current == nil {
+				current = &HighlightSuggestion{
+					VideoID:      videoID,
+					StartSeconds: clip.OffsetSeconds,
+					EndSeconds:   end,
+				}
+			}
+			if end > current.EndSeconds {
+				current.EndSeconds = end
+			}
+			current.ClipCount++
+			current.TotalClipViews += clip.ViewCount
+			current.Clips = append(current.Clips, HighlightClip{
+				ClipID:          clip.ClipID,
+				Title:           clip.Title,
+				ViewCount:       clip.ViewCount,
+				OffsetSeconds:   clip.OffsetSeconds,
+				DurationSeconds: clip.DurationSeconds,
+			})
+		}
+		flush()
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].ClipCount != suggestions[j].ClipCount {
+			return suggestions[i].ClipCount > suggestions[j].ClipCount
+		}
+		if suggestions[i].TotalClipViews != suggestions[j].TotalClipViews {
+			return suggestions[i].TotalClipViews > suggestions[j].TotalClipViews
+		}
+		return suggestions[i].VideoID < suggestions[j].VideoID
+	})
+	return suggestions
+}
+
+// GetHighlightSuggestions returns the most clipped moments, optionally of one VOD
+func (s *service) GetHighlightSuggestions(ctx context.Context, userID, videoID string, limit int) ([]HighlightSuggestion, error) {
+	suggestions, err := s.repo.GetHighlightSuggestions(ctx, userID, videoID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range suggestions {
+		suggestions[i].URL = vodURL(suggestions[i].VideoID, suggestions[i].StartSeconds)
+	}
+	return suggestions, nil
+}
+
+// vodURL links to a VOD at an offset, e.g. https://www.twitch.tv/videos/1?t=1h2m3s
+func vodURL(videoID string, offsetSeconds int) string {
+	t := time.Duration(offsetSeconds) * time.Second
+	return fmt.Sprintf("https://www.twitch.tv/videos/%s?t=%dh%dm%ds", videoID, int(t.Hours()), int(t.Minutes())%60, int(t.Seconds())%60)
+}
@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterClips(t *testing.T) {
+	moments := []ClipMoment{
+		// Three clips of the same moment, the last one starting shortly after
+		{ClipID: "a1", VideoID: "v1", OffsetSeconds: 620, DurationSeconds: 30, ViewCount: 100, VideoDuration: 7200},
+		{ClipID: "a2", VideoID: "v1", OffsetSeconds: 600, DurationSeconds: 30, ViewCount: 50, VideoDuration: 7200},
+		{ClipID: "a3", VideoID: "v1", OffsetSeconds: 700, DurationSeconds: 20, ViewCount: 10, VideoDuration: 7200},
+		// A lone clip is not a moment
+		{ClipID: "b1", VideoID: "v1", OffsetSeconds: 3000, DurationSeconds: 30, ViewCount: 5000, VideoDuration: 7200},
+		// Two clips at the very start of another VOD
+		{ClipID: "c1", VideoID: "v2", OffsetSeconds: 10, DurationSeconds: 30, ViewCount: 20, VideoDuration: 3600},
+		{ClipID: "c2", VideoID: "v2", OffsetSeconds: 15, DurationSeconds: 30, ViewCount: 20, VideoDuration: 3600},
+	}
+
+	suggestions := clusterClips(moments)
+
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "v1", suggestions[0].VideoID)
+	assert.Equal(t, 3, suggestions[0].ClipCount)
+	assert.Equal(t, 160, suggestions[0].TotalClipViews)
+	assert.Equal(t, 570, suggestions[0].StartSeconds)
+	assert.Equal(t, 720, suggestions[0].EndSeconds)
+	assert.Equal(t, "a2", suggestions[0].Clips[0].ClipID)
+
+	assert.Equal(t, "v2", suggestions[1].VideoID)
+	assert.Equal(t, 2, suggestions[1].ClipCount)
+	assert.Equal(t, 0, suggestions[1].StartSeconds)
+	assert.Equal(t, 45, suggestions[1].EndSeconds)
+}
+
+func TestVODURL(t *testing.T) {
+	assert.Equal(t, "https://www.twitch.tv/videos/123?t=1h2m3s", vodURL("123", 3723))
+	assert.Equal(t, "https://www.twitch.tv/videos/123?t=0h0m0s", vodURL("123", 0))
+}
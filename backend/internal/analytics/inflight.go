@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// inflight shares the computation of identical payload requests, e.g. the
+// same dashboard open in several tabs, and bounds how many distinct payloads
+// are computed at once for one user
+type inflight struct {
+	group   singleflight.Group
+	perUser int
+
+	mu    sync.Mutex
+	slots map[string]*userSlots
+}
+
+// userSlots is a user's semaphore, dropped once nobody holds or waits for it
+type userSlots struct {
+	sem   chan struct{}
+	users int
+}
+
+func newInflight(perUser int) *inflight {
+	return &inflight{
+		perUser: max(perUser, 1),
+		slots:   make(map[string]*userSlots),
+	}
+}
+
+// do runs fn once for all concurrent callers with the same user and key and
+// hands each the result. fn runs detached from the caller's cancellation, as
+// other callers may still be waiting for it, and only once one of the user's
+// slots is free. A caller whose context ends stops waiting. A nil inflight
+// just runs fn.
+func (f *inflight) do(ctx context.Context, userID, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	if f == nil {
+		return fn(ctx)
+	}
+
+	shared := context.WithoutCancel(ctx)
+	result := f.group.DoChan(userID+"|"+key, func() (any, error) {
+		release := f.acquire(userID)
+		defer release()
+		return fn(shared)
+	})
+
+	select {
+	case r := <-result:
+		return r.Val, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquire waits for one of the user's slots and returns its release
+func (f *inflight) acquire(userID string) func() {
+	f.mu.Lock()
+	slots, ok := f.slots[userID]
+	if !ok {
+		slots = &userSlots{sem: make(chan struct{}, f.perUser)}
+		f.slots[userID] = slots
+	}
+	slots.users++
+	f.mu.Unlock()
+
+	slots.sem <- struct{}{}
+	return func() {
+		<-slots.sem
+
+		f.mu.Lock()
+		slots.users--
+		if slots.users == 0 {
+			delete(f.slots, userID)
+		}
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInflightSharesIdenticalRequests(t *testing.T) {
+	f := newInflight(4)
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	var wg sync.WaitGroup
+	results := make([]any, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := f.do(context.Background(), "user_1", "overview:user_1:7", func(ctx context.Context) (any, error) {
+				calls.Add(1)
+				<-release
+				return "payload", nil
+			})
+			assert.NoError(t, err)
+			results[i] = value
+		}()
+	}
+
+	// Let every caller join before the computation finishes
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, value := range results {
+		assert.Equal(t, "payload", value)
+	}
+}
+
+func TestInflightBoundsDistinctRequestsPerUser(t *testing.T) {
+	f := newInflight(2)
+	release := make(chan struct{})
+	var running, peak atomic.Int32
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.do(context.Background(), "user_1", key, func(ctx context.Context) (any, error) {
+				n := running.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				<-release
+				running.Add(-1)
+				return nil, nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, time.Millisecond)
+	// Another user isn't held up by user_1's limit
+	_, err := f.do(context.Background(), "user_2", "a", func(ctx context.Context) (any, error) { return nil, nil })
+	require.NoError(t, err)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+	assert.Empty(t, f.slots)
+}
+
+func TestInflightCallerStopsWaitingWhenCancelled(t *testing.T) {
+	f := newInflight(1)
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	go f.do(context.Background(), "user_1", "slow", func(ctx context.Context) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := f.do(ctx, "user_1", "slow", func(ctx context.Context) (any, error) {
+		t.Error("joined callers don't run the computation again")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,218 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/mediakit"
+	"github.com/baldybuilds/creatorsync/internal/templates"
+)
+
+const (
+	// mediaKitTimeout bounds one background render
+	mediaKitTimeout = 2 * time.Minute
+	// mediaKitsKept is how many generated kits are kept per user
+	mediaKitsKept = 5
+	// mediaKitDays is the window for the growth chart and audience stats
+	mediaKitDays = 30
+)
+
+// ErrTemplateNotFound means a media kit was requested with a content template
+// the user doesn't have
+var ErrTemplateNotFound = errors.New("template not found")
+
+// RequestMediaKit queues a media kit render and returns immediately. If one is
+// already being generated for the user, that one is returned instead. With a
+// templateID, the kit has an about section rendered from that content template.
+func (s *service) RequestMediaKit(ctx context.Context, userID string, templateID int) (*MediaKit, error) {
+	var template *templates.Template
+	if templateID != 0 {
+		var err error
+		if template, err = s.templates.GetTemplate(ctx, userID, templateID); err != nil {
+			return nil, fmt.Errorf("failed to load template: %w", err)
+		}
+		if template == nil {
+			return nil, ErrTemplateNotFound
+		}
+	}
+
+	pending, err := s.repo.GetPendingMediaKit(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending media kits: %w", err)
+	}
+	if pending != nil {
+		return pending, nil
+	}
+
+	kit := &MediaKit{UserID: userID, Status: "pending"}
+	if err := s.repo.CreateMediaKit(ctx, kit); err != nil {
+		return nil, fmt.Errorf("failed to create media kit: %w", err)
+	}
+
+	go s.generateMediaKit(kit.ID, userID, template)
+	return kit, nil
+}
+
+// GetMediaKit returns a media kit's status, or nil if the user has no such kit
+func (s *service) GetMediaKit(ctx context.Context, userID string, id int) (*MediaKit, error) {
+	kit, err := s.repo.GetMediaKit(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media kit: %w", err)
+	}
+	return kit, nil
+}
+
+// GetMediaKitPDF returns the rendered PDF, or nil if it isn't ready
+func (s *service) GetMediaKitPDF(ctx context.Context, userID string, id int) ([]byte, error) {
+	pdf, err := s.repo.GetMediaKitPDF(ctx, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media kit PDF: %w", err)
+	}
+	return pdf, nil
+}
+
+func (s *service) generateMediaKit(id int, userID string, template *templates.Template) {
+	ctx, cancel := context.WithTimeout(context.Background(), mediaKitTimeout)
+	defer cancel()
+
+	pdf, err := s.renderMediaKit(ctx, userID, template)
+	if err != nil {
+		log.Printf("Failed to generate media kit %d for user %s: %v", id, userID, err)
+		if err := s.repo.FailMediaKit(context.Background(), id, err.Error()); err != nil {
+			log.Printf("Failed to mark media kit %d as failed: %v", id, err)
+		}
+		return
+	}
+
+	if err := s.repo.CompleteMediaKit(ctx, id, pdf); err != nil {
+		log.Printf("Failed to save media kit %d for user %s: %v", id, userID, err)
+		return
+	}
+	if err := s.repo.DeleteOldMediaKits(ctx, userID, mediaKitsKept); err != nil {
+		log.Printf("Failed to prune media kits for user %s: %v", userID, err)
+	}
+
+	log.Printf("📄 Generated media kit %d for user %s (%d bytes)", id, userID, len(pdf))
+}
+
+// renderMediaKit gathers the user's stored analytics and renders them, with
+// the template filled in for the channel when there is one
+func (s *service) renderMediaKit(ctx context.Context, userID string, template *templates.Template) ([]byte, error) {
+	user, err := s.repo.GetUserByClerkID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	kit := &mediakit.Kit{
+		DisplayName: user.DisplayName,
+		Username:    user.Username,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	latest, err := s.repo.GetLatestChannelAnalytics(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel analytics: %w", err)
+	}
+	if latest != nil {
+		kit.Followers = latest.FollowersCount
+		kit.Subscribers = latest.SubscriberCount
+		kit.TotalViews = latest.TotalViews
+	}
+
+	history, err := s.repo.GetChannelAnalytics(ctx, userID, mediaKitDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load follower history: %w", err)
+	}
+	// History is newest first
+	for i := len(history) - 1; i >= 0; i-- {
+		kit.FollowerHistory = append(kit.FollowerHistory, mediakit.Point{
+			Date:      history[i].Date,
+			Followers: history[i].FollowersCount,
+		})
+	}
+
+	videos, err := s.repo.GetTopVideos(ctx, userID, "", 5)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load top content: %w", err)
+	}
+	for _, video := range videos {
+		kit.TopContent = append(kit.TopContent, mediakit.Content{
+			Title: video.Title,
+			Type:  video.VideoType,
+			Views: video.ViewCount,
+		})
+	}
+
+	end := time.Now()
+	sessions, err := s.repo.GetStreamSessionsByDateRange(ctx, userID, end.AddDate(0, 0, -mediaKitDays), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stream sessions: %w", err)
+	}
+	kit.Audience = summarizeAudience(sessions)
+
+	games, err := s.repo.GetTopGames(ctx, userID, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load top games: %w", err)
+	}
+	for _, game := range games {
+		kit.Audience.TopGames = append(kit.Audience.TopGames, game.GameName)
+	}
+
+	if template != nil {
+		vars, err := s.mediaKitVars(ctx, userID, user.Username, sessions, kit.Audience.TopGames)
+		if err != nil {
+			return nil, err
+		}
+		rendered := template.Render(vars)
+		kit.Headline, kit.About, kit.Tags = rendered.Title, rendered.Description, rendered.Tags
+	}
+
+	return mediakit.Render(kit)
+}
+
+// mediaKitVars fills template variables for the channel as a whole: its top
+// game and clip and its latest stream
+func (s *service) mediaKitVars(ctx context.Context, userID, channel string, sessions []StreamSession, topGames []string) (templates.Vars, error) {
+	vars := templates.Vars{Channel: channel}
+	if len(topGames) > 0 {
+		vars.Game = topGames[0]
+	}
+	for _, session := range sessions {
+		if session.StartedAt != nil && (vars.StreamDate == nil || session.StartedAt.After(*vars.StreamDate)) {
+			vars.StreamDate = session.StartedAt
+		}
+	}
+
+	clips, err := s.repo.GetTopVideos(ctx, userID, "clip", 1)
+	if err != nil {
+		return vars, fmt.Errorf("failed to load top clip: %w", err)
+	}
+	if len(clips) > 0 {
+		vars.ClipID, vars.ClipTitle = clips[0].VideoID, clips[0].Title
+	}
+	return vars, nil
+}
+
+// summarizeAudience averages viewers across streams, weighting by stream length
+func summarizeAudience(sessions []StreamSession) mediakit.Audience {
+	audience := mediakit.Audience{Streams: len(sessions)}
+
+	var minutes, viewerMinutes int
+	for _, session := range sessions {
+		minutes += session.DurationMinutes
+		viewerMinutes += session.AverageViewers * session.DurationMinutes
+		audience.PeakViewers = max(audience.PeakViewers, session.PeakViewers)
+	}
+
+	audience.HoursStreamed = float64(minutes) / 60
+	if minutes > 0 {
+		audience.AverageViewers = viewerMinutes / minutes
+	}
+	return audience
+}
@@ -0,0 +1,382 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/baldybuilds/creatorsync/internal/patreon"
+	"github.com/baldybuilds/creatorsync/internal/streamlabs"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// mockRepository stubs the repository methods the tests exercise. Calls to any
+// other method hit the nil embedded interface and panic, which flags an
+// unexpected query.
+type mockRepository struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepository) GetDashboardOverview(ctx context.Context, userID string, days int) (*DashboardOverview, error) {
+	args := m.Called(ctx, userID, days)
+	overview, _ := args.Get(0).(*DashboardOverview)
+	return overview, args.Error(1)
+}
+
+func (m *mockRepository) GetAnalyticsChartData(ctx context.Context, userID string, days int) (*AnalyticsChartData, error) {
+	args := m.Called(ctx, userID, days)
+	data, _ := args.Get(0).(*AnalyticsChartData)
+	return data, args.Error(1)
+}
+
+func (m *mockRepository) GetEnhancedAnalytics(ctx context.Context, userID string, days int) (*EnhancedAnalytics, error) {
+	args := m.Called(ctx, userID, days)
+	analytics, _ := args.Get(0).(*EnhancedAnalytics)
+	return analytics, args.Error(1)
+}
+
+func (m *mockRepository) GetTopVideos(ctx context.Context, userID, videoType string, limit int) ([]VideoAnalytics, error) {
+	args := m.Called(ctx, userID, videoType, limit)
+	videos, _ := args.Get(0).([]VideoAnalytics)
+	return videos, args.Error(1)
+}
+
+func (m *mockRepository) GetTopVideosByViewsPerDay(ctx context.Context, userID, videoType string, limit int) ([]VideoAnalytics, error) {
+	args := m.Called(ctx, userID, videoType, limit)
+	videos, _ := args.Get(0).([]VideoAnalytics)
+	return videos, args.Error(1)
+}
+
+func (m *mockRepository) GetChannelConsistency(ctx context.Context, userID string) (*ChannelConsistency, error) {
+	args := m.Called(ctx, userID)
+	consistency, _ := args.Get(0).(*ChannelConsistency)
+	return consistency, args.Error(1)
+}
+
+func (m *mockRepository) GetChannelAnalytics(ctx context.Context, userID string, days int) ([]ChannelAnalytics, error) {
+	args := m.Called(ctx, userID, days)
+	analytics, _ := args.Get(0).([]ChannelAnalytics)
+	return analytics, args.Error(1)
+}
+
+func (m *mockRepository) GetVideoAnalytics(ctx context.Context, userID string, limit int) ([]VideoAnalytics, error) {
+	args := m.Called(ctx, userID, limit)
+	videos, _ := args.Get(0).([]VideoAnalytics)
+	return videos, args.Error(1)
+}
+
+func (m *mockRepository) GetTopGames(ctx context.Context, userID string, limit int) ([]GameAnalytics, error) {
+	args := m.Called(ctx, userID, limit)
+	games, _ := args.Get(0).([]GameAnalytics)
+	return games, args.Error(1)
+}
+
+func (m *mockRepository) GetTwitchToken(ctx context.Context, userID string) (*TwitchToken, error) {
+	args := m.Called(ctx, userID)
+	token, _ := args.Get(0).(*TwitchToken)
+	return token, args.Error(1)
+}
+
+func (m *mockRepository) ListVideos(ctx context.Context, userID string, query VideoListQuery) ([]VideoAnalytics, int, error) {
+	args := m.Called(ctx, userID, query)
+	videos, _ := args.Get(0).([]VideoAnalytics)
+	return videos, args.Int(1), args.Error(2)
+}
+
+func (m *mockRepository) SaveTwitchToken(ctx context.Context, token *TwitchToken) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+// mockCollector records collection calls
+type mockCollector struct {
+	DataCollector
+	mock.Mock
+}
+
+func (m *mockCollector) CollectDailyChannelData(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *mockCollector) CollectAllUserData(ctx context.Context, userID string, opts CollectionOptions) (*CollectionReport, error) {
+	args := m.Called(ctx, userID, opts)
+	report, _ := args.Get(0).(*CollectionReport)
+	return report, args.Error(1)
+}
+
+var _ DataCollector = (*mockCollector)(nil)
+
+// mockTwitchAPI stubs the Twitch endpoints used by token handling
+type mockTwitchAPI struct {
+	TwitchAPI
+	mock.Mock
+}
+
+func (m *mockTwitchAPI) GetTokenInfo(ctx context.Context, token string) (*twitch.TokenValidationResponse, error) {
+	args := m.Called(ctx, token)
+	info, _ := args.Get(0).(*twitch.TokenValidationResponse)
+	return info, args.Error(1)
+}
+
+func (m *mockTwitchAPI) RefreshToken(ctx context.Context, refreshToken string) (*twitch.OAuthToken, error) {
+	args := m.Called(ctx, refreshToken)
+	token, _ := args.Get(0).(*twitch.OAuthToken)
+	return token, args.Error(1)
+}
+
+// mockPatreonAPI stubs the Patreon endpoints used to collect income
+type mockPatreonAPI struct {
+	mock.Mock
+}
+
+func (m *mockPatreonAPI) RefreshToken(ctx context.Context, refreshToken string) (*patreon.OAuthToken, error) {
+	args := m.Called(ctx, refreshToken)
+	token, _ := args.Get(0).(*patreon.OAuthToken)
+	return token, args.Error(1)
+}
+
+func (m *mockPatreonAPI) GetCampaign(ctx context.Context, accessToken string) (*patreon.Campaign, error) {
+	args := m.Called(ctx, accessToken)
+	campaign, _ := args.Get(0).(*patreon.Campaign)
+	return campaign, args.Error(1)
+}
+
+func (m *mockPatreonAPI) GetMembersPage(ctx context.Context, accessToken, campaignID string, limit int, cursor string) (*patreon.MembersPage, error) {
+	args := m.Called(ctx, accessToken, campaignID, limit, cursor)
+	page, _ := args.Get(0).(*patreon.MembersPage)
+	return page, args.Error(1)
+}
+
+var _ PatreonAPI = (*mockPatreonAPI)(nil)
+
+// mockStreamlabsAPI stubs the Streamlabs endpoints used to collect tips
+type mockStreamlabsAPI struct {
+	mock.Mock
+}
+
+func (m *mockStreamlabsAPI) RefreshToken(ctx context.Context, refreshToken string) (*streamlabs.OAuthToken, error) {
+	args := m.Called(ctx, refreshToken)
+	token, _ := args.Get(0).(*streamlabs.OAuthToken)
+	return token, args.Error(1)
+}
+
+func (m *mockStreamlabsAPI) GetDonations(ctx context.Context, accessToken string, before int64, limit int, currency string) ([]streamlabs.Donation, error) {
+	args := m.Called(ctx, accessToken, before, limit, currency)
+	donations, _ := args.Get(0).([]streamlabs.Donation)
+	return donations, args.Error(1)
+}
+
+var _ StreamlabsAPI = (*mockStreamlabsAPI)(nil)
+
+func (m *mockRepository) GetStreamlabsToken(ctx context.Context, userID string) (*StreamlabsToken, error) {
+	args := m.Called(ctx, userID)
+	token, _ := args.Get(0).(*StreamlabsToken)
+	return token, args.Error(1)
+}
+
+func (m *mockRepository) GetLatestStreamlabsDonationID(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) SaveStreamlabsDonations(ctx context.Context, donations []StreamlabsDonation) (int, error) {
+	args := m.Called(ctx, donations)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockRepository) GetLatestAnalyticsJob(ctx context.Context, userID, jobType, status string) (*AnalyticsJob, error) {
+	args := m.Called(ctx, userID, jobType, status)
+	job, _ := args.Get(0).(*AnalyticsJob)
+	return job, args.Error(1)
+}
+
+func (m *mockRepository) CreateAnalyticsJob(ctx context.Context, job *AnalyticsJob) error {
+	return m.Called(ctx, job).Error(0)
+}
+
+func (m *mockRepository) BackfillChannelAnalytics(ctx context.Context, rows []ChannelAnalytics) (int, error) {
+	args := m.Called(ctx, rows)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockRepository) GetUserLocale(ctx context.Context, clerkUserID string) (string, error) {
+	args := m.Called(ctx, clerkUserID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) GetUserCurrency(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) GetLatestRevenueAnalytics(ctx context.Context, userID string) (*RevenueAnalytics, error) {
+	args := m.Called(ctx, userID)
+	revenue, _ := args.Get(0).(*RevenueAnalytics)
+	return revenue, args.Error(1)
+}
+
+func (m *mockRepository) GetMonthlyRevenue(ctx context.Context, userID string, months int) ([]MonthlyRevenue, error) {
+	args := m.Called(ctx, userID, months)
+	trend, _ := args.Get(0).([]MonthlyRevenue)
+	return trend, args.Error(1)
+}
+
+func (m *mockRepository) GetLatestPatreonRevenue(ctx context.Context, userID string) (*PatreonRevenue, error) {
+	args := m.Called(ctx, userID)
+	revenue, _ := args.Get(0).(*PatreonRevenue)
+	return revenue, args.Error(1)
+}
+
+func (m *mockRepository) SavePatreonRevenue(ctx context.Context, revenue *PatreonRevenue) error {
+	return m.Called(ctx, revenue).Error(0)
+}
+
+func (m *mockRepository) GetPatreonToken(ctx context.Context, userID string) (*PatreonToken, error) {
+	args := m.Called(ctx, userID)
+	token, _ := args.Get(0).(*PatreonToken)
+	return token, args.Error(1)
+}
+
+func (m *mockRepository) SavePatreonToken(ctx context.Context, token *PatreonToken) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+func (m *mockRepository) HasWeeklyInsights(ctx context.Context, userID string, weekStart time.Time) (bool, error) {
+	args := m.Called(ctx, userID, weekStart)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) SaveWeeklyInsights(ctx context.Context, insights *WeeklyInsights) error {
+	return m.Called(ctx, insights).Error(0)
+}
+
+func (m *mockRepository) CompleteAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string, report []byte) error {
+	return m.Called(ctx, jobID, status, errorMsg, report).Error(0)
+}
+
+func (m *mockRepository) ListCollectableUserIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	userIDs, _ := args.Get(0).([]string)
+	return userIDs, args.Error(1)
+}
+
+func (m *mockRepository) UpdateAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string) error {
+	return m.Called(ctx, jobID, status, errorMsg).Error(0)
+}
+
+func (m *mockRepository) UpdateAnalyticsJobProgress(ctx context.Context, jobID, total, completed, failed int) error {
+	return m.Called(ctx, jobID, total, completed, failed).Error(0)
+}
+
+func (m *mockRepository) GetDueCollectionRetries(ctx context.Context, now time.Time, limit int) ([]CollectionRetry, error) {
+	args := m.Called(ctx, now, limit)
+	retries, _ := args.Get(0).([]CollectionRetry)
+	return retries, args.Error(1)
+}
+
+func (m *mockRepository) DeleteCollectionRetry(ctx context.Context, userID, jobType string) error {
+	return m.Called(ctx, userID, jobType).Error(0)
+}
+
+func (m *mockRepository) QueueInterruptedCollections(ctx context.Context, userIDs []string, jobType string, options []byte) error {
+	return m.Called(ctx, userIDs, jobType, options).Error(0)
+}
+
+func (m *mockRepository) PurgeExpiredDisconnectArchives(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) GetCollectionRetry(ctx context.Context, userID, jobType string) (*CollectionRetry, error) {
+	args := m.Called(ctx, userID, jobType)
+	retry, _ := args.Get(0).(*CollectionRetry)
+	return retry, args.Error(1)
+}
+
+func (m *mockRepository) SaveCollectionRetry(ctx context.Context, retry *CollectionRetry) error {
+	return m.Called(ctx, retry).Error(0)
+}
+
+func (m *mockRepository) CleanupTwitchAccount(ctx context.Context, jobID int, userID, twitchUserID string, expiresAt time.Time) (*DisconnectArchive, error) {
+	args := m.Called(ctx, jobID, userID, twitchUserID, expiresAt)
+	archive, _ := args.Get(0).(*DisconnectArchive)
+	return archive, args.Error(1)
+}
+
+func (m *mockRepository) ListOrphanedTwitchDataUsers(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	userIDs, _ := args.Get(0).([]string)
+	return userIDs, args.Error(1)
+}
+
+func (m *mockRepository) FailInterruptedAnalyticsJobs(ctx context.Context, jobType string, startedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, jobType, startedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) GetAnalyticsJobs(ctx context.Context, userID string, limit int) ([]AnalyticsJob, error) {
+	args := m.Called(ctx, userID, limit)
+	jobs, _ := args.Get(0).([]AnalyticsJob)
+	return jobs, args.Error(1)
+}
+
+func (m *mockRepository) ListCollectionRetries(ctx context.Context, userID string) ([]CollectionRetry, error) {
+	args := m.Called(ctx, userID)
+	retries, _ := args.Get(0).([]CollectionRetry)
+	return retries, args.Error(1)
+}
+
+func (m *mockRepository) CountUserRows(ctx context.Context, userID string) (map[string]int64, error) {
+	args := m.Called(ctx, userID)
+	counts, _ := args.Get(0).(map[string]int64)
+	return counts, args.Error(1)
+}
+
+func (m *mockRepository) TakeManualCollection(ctx context.Context, userID string, day time.Time, limit int) (int, bool, error) {
+	args := m.Called(ctx, userID, day, limit)
+	return args.Int(0), args.Bool(1), args.Error(2)
+}
+
+func (m *mockRepository) ReleaseManualCollection(ctx context.Context, userID string, day time.Time) error {
+	return m.Called(ctx, userID, day).Error(0)
+}
+
+func (m *mockRepository) GetLatestChannelAnalytics(ctx context.Context, userID string) (*ChannelAnalytics, error) {
+	args := m.Called(ctx, userID)
+	latest, _ := args.Get(0).(*ChannelAnalytics)
+	return latest, args.Error(1)
+}
+
+func (m *mockRepository) GetVideoViewCounts(ctx context.Context, userID string) ([]VideoViewCount, error) {
+	args := m.Called(ctx, userID)
+	counts, _ := args.Get(0).([]VideoViewCount)
+	return counts, args.Error(1)
+}
+
+func (m *mockRepository) SaveAnalyticsJobChanges(ctx context.Context, jobID int, changes []byte) error {
+	return m.Called(ctx, jobID, changes).Error(0)
+}
+
+func (m *mockRepository) GetChangeSummaries(ctx context.Context, userID string, since time.Time, limit int) ([]ChangeSummary, error) {
+	args := m.Called(ctx, userID, since, limit)
+	changes, _ := args.Get(0).([]ChangeSummary)
+	return changes, args.Error(1)
+}
+
+func (m *mockRepository) GetVideoDayViews(ctx context.Context, userID string, days int) ([]VideoDayViews, error) {
+	args := m.Called(ctx, userID, days)
+	views, _ := args.Get(0).([]VideoDayViews)
+	return views, args.Error(1)
+}
+
+func (m *mockRepository) GetLanguageShares(ctx context.Context, userID string, since time.Time) ([]LanguageShare, error) {
+	args := m.Called(ctx, userID, since)
+	shares, _ := args.Get(0).([]LanguageShare)
+	return shares, args.Error(1)
+}
+
+func (m *mockRepository) GetBroadcasterLanguage(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
@@ -1,7 +1,10 @@
 package analytics
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/money"
 )
 
 // User represents a creator user in the system
@@ -13,10 +16,43 @@ type User struct {
 	DisplayName     string    `json:"display_name" db:"display_name"`
 	Email           string    `json:"email" db:"email"`
 	ProfileImageURL string    `json:"profile_image_url" db:"profile_image_url"`
+	Locale          string    `json:"locale" db:"locale"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// TwitchToken represents a user's stored Twitch OAuth credentials.
+// AccessToken and RefreshToken hold values encrypted with EncryptionKeyID.
+type TwitchToken struct {
+	UserID          string     `json:"user_id" db:"user_id"`
+	TwitchUserID    string     `json:"twitch_user_id" db:"twitch_user_id"`
+	AccessToken     string     `json:"-" db:"access_token"`
+	RefreshToken    string     `json:"-" db:"refresh_token"`
+	EncryptionKeyID string     `json:"-" db:"encryption_key_id"`
+	Scopes          string     `json:"scopes" db:"scopes"`
+	ExpiresAt       *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// EncryptedToken is a stored OAuth token of any provider as key rotation
+// sees it, still encrypted
+type EncryptedToken struct {
+	UserID          string    `db:"user_id"`
+	AccessToken     string    `db:"access_token"`
+	RefreshToken    string    `db:"refresh_token"`
+	EncryptionKeyID string    `db:"encryption_key_id"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// Sources of channel_analytics rows. Only collected rows have subscriber and
+// view counts, the others reconstruct the follower count alone.
+const (
+	ChannelSourceCollected = "collected"
+	ChannelSourceBackfill  = "backfill"
+	ChannelSourceImport    = "import"
+)
+
 // ChannelAnalytics represents daily channel metrics
 type ChannelAnalytics struct {
 	ID              int       `json:"id" db:"id"`
@@ -26,7 +62,17 @@ type ChannelAnalytics struct {
 	FollowingCount  int       `json:"following_count" db:"following_count"`
 	TotalViews      int       `json:"total_views" db:"total_views"`
 	SubscriberCount int       `json:"subscriber_count" db:"subscriber_count"`
-	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	// Source is one of the ChannelSource* values. Subscriber and view counts
+	// of rows not collected are 0 and mean unknown, not zero.
+	Source    string    `json:"source" db:"source"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// BroadcasterLanguage is the channel's language setting on the day
+	BroadcasterLanguage string `json:"broadcaster_language,omitempty" db:"-"`
+}
+
+// Synthetic reports whether the row was reconstructed rather than collected
+func (c ChannelAnalytics) Synthetic() bool {
+	return c.Source == ChannelSourceBackfill || c.Source == ChannelSourceImport
 }
 
 // StreamSession represents individual stream performance
@@ -61,8 +107,23 @@ type VideoAnalytics struct {
 	CommentCount int        `json:"comment_count" db:"comment_count"`
 	ThumbnailURL string     `json:"thumbnail_url" db:"thumbnail_url"`
 	PublishedAt  *time.Time `json:"published_at" db:"published_at"`
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	// StreamSessionID links archive VODs to the stream they were recorded from
+	StreamSessionID *int      `json:"stream_session_id,omitempty" db:"stream_session_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+	// MutedSegments are the parts Twitch muted, usually for copyrighted audio
+	MutedSegments []MutedSegment `json:"muted_segments,omitempty" db:"-"`
+	// SourceVideoID and VodOffset place a clip on the VOD it was clipped from
+	SourceVideoID string `json:"source_video_id,omitempty" db:"-"`
+	VodOffset     *int   `json:"vod_offset_seconds,omitempty" db:"-"`
+	// Language is the ISO 639-1 code of the language it was broadcast in
+	Language string `json:"language,omitempty" db:"-"`
+}
+
+// MutedSegment is a muted part of a VOD, in seconds from its start
+type MutedSegment struct {
+	Offset   int `json:"offset"`
+	Duration int `json:"duration"`
 }
 
 // VideoDailyStats represents daily video performance tracking
@@ -93,6 +154,15 @@ type GameAnalytics struct {
 	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// VideoViewCount is a stored video's views, compared before and after a
+// collection to find what changed
+type VideoViewCount struct {
+	VideoID   string `db:"video_id"`
+	Title     string `db:"title"`
+	VideoType string `db:"video_type"`
+	ViewCount int    `db:"view_count"`
+}
+
 // AnalyticsJob represents data collection job status
 type AnalyticsJob struct {
 	ID           int        `json:"id" db:"id"`
@@ -104,23 +174,717 @@ type AnalyticsJob struct {
 	ErrorMessage string     `json:"error_message" db:"error_message"`
 	DataDate     *time.Time `json:"data_date" db:"data_date"`
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+
+	// Progress of multi-user runs (UserID is empty for those)
+	ProgressTotal     int `json:"progress_total,omitempty" db:"progress_total"`
+	ProgressCompleted int `json:"progress_completed,omitempty" db:"progress_completed"`
+	ProgressFailed    int `json:"progress_failed,omitempty" db:"progress_failed"`
+
+	// Report is the CollectionReport of user collections, nil for other jobs
+	Report *json.RawMessage `json:"report,omitempty" db:"report"`
+}
+
+// ChannelAnalyticsRollup aggregates raw channel_analytics rows older than the
+// retention window into a weekly or monthly summary
+type ChannelAnalyticsRollup struct {
+	UserID         string    `json:"user_id" db:"user_id"`
+	Period         string    `json:"period" db:"period"`
+	PeriodStart    time.Time `json:"period_start" db:"period_start"`
+	FirstDate      time.Time `json:"first_date" db:"first_date"`
+	LastDate       time.Time `json:"last_date" db:"last_date"`
+	Days           int       `json:"days" db:"days"`
+	FollowersStart int       `json:"followers_start" db:"followers_start"`
+	FollowersEnd   int       `json:"followers_end" db:"followers_end"`
+	FollowersMax   int       `json:"followers_max" db:"followers_max"`
+	TotalViewsEnd  int       `json:"total_views_end" db:"total_views_end"`
+	SubscribersEnd int       `json:"subscribers_end" db:"subscribers_end"`
+}
+
+// DisconnectArchive is a user's Twitch analytics set aside when they
+// disconnected Twitch. It can be restored by reconnecting the same Twitch
+// account until ExpiresAt.
+type DisconnectArchive struct {
+	ID           int64      `json:"id" db:"id"`
+	UserID       string     `json:"user_id" db:"user_id"`
+	TwitchUserID string     `json:"twitch_user_id" db:"twitch_user_id"`
+	RowCount     int        `json:"row_count" db:"row_count"`
+	ArchivedAt   time.Time  `json:"archived_at" db:"archived_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	RestoredAt   *time.Time `json:"restored_at,omitempty" db:"restored_at"`
+}
+
+// CollectionRetry tracks a failed per-user collection waiting to be retried.
+// Parked retries (auth failures) wait for the user to reconnect Twitch.
+type CollectionRetry struct {
+	UserID        string     `json:"user_id" db:"user_id"`
+	JobType       string     `json:"job_type" db:"job_type"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	FailureKind   string     `json:"failure_kind" db:"failure_kind"`
+	LastError     string     `json:"last_error" db:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	ParkedAt      *time.Time `json:"parked_at" db:"parked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	// Options are the CollectionOptions of interrupted user collections
+	Options *json.RawMessage `json:"options,omitempty" db:"options"`
+}
+
+// RevenueAnalytics holds one day of revenue estimates. Subscription columns are a
+// snapshot of the current monthly estimate, bits are what was cheered that day.
+type RevenueAnalytics struct {
+	ID                        int        `json:"id" db:"id"`
+	UserID                    string     `json:"user_id" db:"user_id"`
+	Date                      time.Time  `json:"date" db:"date"`
+	TotalSubscribers          int        `json:"total_subscribers" db:"total_subscribers"`
+	Tier1Subscribers          int        `json:"tier1_subscribers" db:"tier1_subscribers"`
+	Tier2Subscribers          int        `json:"tier2_subscribers" db:"tier2_subscribers"`
+	Tier3Subscribers          int        `json:"tier3_subscribers" db:"tier3_subscribers"`
+	GiftedSubscribers         int        `json:"gifted_subscribers" db:"gifted_subscribers"`
+	SubPoints                 int        `json:"sub_points" db:"sub_points"`
+	EstimatedSubRevenueCents  int        `json:"estimated_sub_revenue_cents" db:"estimated_sub_revenue_cents"`
+	Bits                      int        `json:"bits" db:"bits"`
+	EstimatedBitsRevenueCents int        `json:"estimated_bits_revenue_cents" db:"estimated_bits_revenue_cents"`
+	AdDurationSeconds         int        `json:"ad_duration_seconds" db:"ad_duration_seconds"`
+	AdSnoozeCount             int        `json:"ad_snooze_count" db:"ad_snooze_count"`
+	LastAdAt                  *time.Time `json:"last_ad_at" db:"last_ad_at"`
+	NextAdAt                  *time.Time `json:"next_ad_at" db:"next_ad_at"`
+	CreatedAt                 time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt                 time.Time  `json:"updated_at" db:"updated_at"`
+
+	// The estimates in the display currency, filled in by GetRevenue
+	SubRevenue  *money.Value `json:"sub_revenue,omitempty" db:"-"`
+	BitsRevenue *money.Value `json:"bits_revenue,omitempty" db:"-"`
+}
+
+// MonthlyRevenue is one point of the revenue trend chart
+type MonthlyRevenue struct {
+	Month                     time.Time `json:"month" db:"month"`
+	Bits                      int       `json:"bits" db:"bits"`
+	EstimatedBitsRevenueCents int       `json:"estimated_bits_revenue_cents" db:"estimated_bits_revenue_cents"`
+	EstimatedSubRevenueCents  int       `json:"estimated_sub_revenue_cents" db:"estimated_sub_revenue_cents"`
+	PatreonIncomeCents        int       `json:"patreon_income_cents" db:"patreon_income_cents"`
+	Tips                      int       `json:"tips" db:"tips"`
+	TipsCents                 int       `json:"tips_cents" db:"tips_cents"`
+	TotalRevenueCents         int       `json:"total_revenue_cents" db:"total_revenue_cents"`
+
+	// The estimates in the display currency, filled in by GetRevenue
+	SubRevenue    *money.Value `json:"sub_revenue,omitempty" db:"-"`
+	BitsRevenue   *money.Value `json:"bits_revenue,omitempty" db:"-"`
+	PatreonIncome *money.Value `json:"patreon_income,omitempty" db:"-"`
+	TipsRevenue   *money.Value `json:"tips_revenue,omitempty" db:"-"`
+	TotalRevenue  *money.Value `json:"total_revenue,omitempty" db:"-"`
+}
+
+// PatreonToken represents a user's stored Patreon OAuth credentials.
+// AccessToken and RefreshToken hold values encrypted with EncryptionKeyID.
+type PatreonToken struct {
+	UserID          string     `json:"user_id" db:"user_id"`
+	PatreonUserID   string     `json:"patreon_user_id" db:"patreon_user_id"`
+	CampaignID      string     `json:"campaign_id" db:"campaign_id"`
+	AccessToken     string     `json:"-" db:"access_token"`
+	RefreshToken    string     `json:"-" db:"refresh_token"`
+	EncryptionKeyID string     `json:"-" db:"encryption_key_id"`
+	Scopes          string     `json:"scopes" db:"scopes"`
+	ExpiresAt       *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PatreonRevenue is a daily snapshot of a creator's Patreon membership income.
+// Amounts are monthly, in US cents.
+type PatreonRevenue struct {
+	ID                   int                  `json:"id"`
+	UserID               string               `json:"user_id"`
+	Date                 time.Time            `json:"date"`
+	CampaignID           string               `json:"campaign_id"`
+	PatronCount          int                  `json:"patron_count"`
+	ActiveMembers        int                  `json:"active_members"`
+	MonthlyPledgeCents   int                  `json:"monthly_pledge_cents"`
+	EstimatedIncomeCents int                  `json:"estimated_income_cents"`
+	Tiers                []PatreonTierRevenue `json:"tiers"`
+	CreatedAt            time.Time            `json:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at"`
+
+	// The amounts in the display currency, filled in by GetRevenue
+	MonthlyPledges  *money.Value `json:"monthly_pledges,omitempty"`
+	EstimatedIncome *money.Value `json:"estimated_income,omitempty"`
+}
+
+// PatreonTierRevenue is what one membership tier brings in. Pledges without a
+// tier are counted under an empty TierID.
+type PatreonTierRevenue struct {
+	TierID             string `json:"tier_id"`
+	Title              string `json:"title"`
+	AmountCents        int    `json:"amount_cents"`
+	ActiveMembers      int    `json:"active_members"`
+	MonthlyPledgeCents int    `json:"monthly_pledge_cents"`
+}
+
+// StreamlabsToken represents a user's stored Streamlabs OAuth credentials.
+// AccessToken and RefreshToken hold values encrypted with EncryptionKeyID.
+type StreamlabsToken struct {
+	UserID           string     `json:"user_id" db:"user_id"`
+	StreamlabsUserID string     `json:"streamlabs_user_id" db:"streamlabs_user_id"`
+	AccessToken      string     `json:"-" db:"access_token"`
+	RefreshToken     string     `json:"-" db:"refresh_token"`
+	EncryptionKeyID  string     `json:"-" db:"encryption_key_id"`
+	Scopes           string     `json:"scopes" db:"scopes"`
+	ExpiresAt        *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// StreamlabsDonation is a tip received through Streamlabs, in US cents
+type StreamlabsDonation struct {
+	UserID      string    `json:"user_id" db:"user_id"`
+	DonationID  int64     `json:"donation_id" db:"donation_id"`
+	AmountCents int       `json:"amount_cents" db:"amount_cents"`
+	DonatedAt   time.Time `json:"donated_at" db:"donated_at"`
+}
+
+// RevenueOverview is returned by /api/analytics/revenue. The *_cents fields
+// are always USD, the money values are in Currency.
+type RevenueOverview struct {
+	Latest *RevenueAnalytics `json:"latest"`
+	// Patreon is the latest Patreon snapshot, nil unless Patreon is connected
+	Patreon  *PatreonRevenue  `json:"patreon"`
+	Trend    []MonthlyRevenue `json:"trend"`
+	Currency money.Currency   `json:"currency"`
+	// ExchangeRate converts BaseCurrency into Currency
+	BaseCurrency money.Currency `json:"base_currency"`
+	ExchangeRate float64        `json:"exchange_rate"`
+	Disclaimer   string         `json:"disclaimer"`
+}
+
+// ChannelPointRedemption is a channel points reward redemption delivered by EventSub
+type ChannelPointRedemption struct {
+	ID           int       `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	RedemptionID string    `json:"redemption_id" db:"redemption_id"`
+	RewardID     string    `json:"reward_id" db:"reward_id"`
+	RewardTitle  string    `json:"reward_title" db:"reward_title"`
+	RewardCost   int       `json:"reward_cost" db:"reward_cost"`
+	Status       string    `json:"status" db:"status"`
+	RedeemedAt   time.Time `json:"redeemed_at" db:"redeemed_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// EventSubSubscription records a Twitch EventSub subscription created for a user.
+// Type is the EventSub type, or a more specific key for types subscribed twice
+// (e.g. channel.raid.incoming).
+type EventSubSubscription struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Type      string    `json:"type" db:"type"`
+	Status    string    `json:"status" db:"status"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RewardStats aggregates redemptions of one reward
+type RewardStats struct {
+	RewardID    string `json:"reward_id" db:"reward_id"`
+	RewardTitle string `json:"reward_title" db:"reward_title"`
+	Redemptions int    `json:"redemptions" db:"redemptions"`
+	PointsSpent int    `json:"points_spent" db:"points_spent"`
+}
+
+// RedemptionTrendPoint is one day of redemptions
+type RedemptionTrendPoint struct {
+	Date        string `json:"date" db:"date"`
+	Redemptions int    `json:"redemptions" db:"redemptions"`
+	PointsSpent int    `json:"points_spent" db:"points_spent"`
+}
+
+// ChannelPointsAnalytics is returned by /api/analytics/channel-points
+type ChannelPointsAnalytics struct {
+	Days             int                    `json:"days"`
+	Tracking         bool                   `json:"tracking"`
+	TotalRedemptions int                    `json:"total_redemptions"`
+	PointsSpent      int                    `json:"points_spent"`
+	TopRewards       []RewardStats          `json:"top_rewards"`
+	Trend            []RedemptionTrendPoint `json:"trend"`
+}
+
+// ModerationAction is a ban or timeout delivered by EventSub
+type ModerationAction struct {
+	ID              int       `json:"id" db:"id"`
+	UserID          string    `json:"user_id" db:"user_id"`
+	MessageID       string    `json:"message_id" db:"message_id"`
+	Action          string    `json:"action" db:"action"`
+	DurationSeconds int       `json:"duration_seconds" db:"duration_seconds"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// ModerationActionDay counts moderation actions on one day
+type ModerationActionDay struct {
+	Date     time.Time `json:"date" db:"date"`
+	Bans     int       `json:"bans" db:"bans"`
+	Timeouts int       `json:"timeouts" db:"timeouts"`
+}
+
+// ModerationDailyMetrics is the daily moderation snapshot taken during collection
+type ModerationDailyMetrics struct {
+	ID           int       `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	Date         time.Time `json:"date" db:"date"`
+	ActiveBans   int       `json:"active_bans" db:"active_bans"`
+	AutoModLevel *int      `json:"automod_level" db:"automod_level"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ChatHealthDay is one day of the chat health panel
+type ChatHealthDay struct {
+	Date           string  `json:"date"`
+	Bans           int     `json:"bans"`
+	Timeouts       int     `json:"timeouts"`
+	ActiveBans     *int    `json:"active_bans"`
+	AverageViewers int     `json:"average_viewers"`
+	ActionsPer100  float64 `json:"actions_per_100_viewers"`
+}
+
+// ChatHealth is returned by /api/analytics/chat-health
+type ChatHealth struct {
+	Days          int             `json:"days"`
+	Tracking      bool            `json:"tracking"`
+	TotalBans     int             `json:"total_bans"`
+	TotalTimeouts int             `json:"total_timeouts"`
+	ActiveBans    *int            `json:"active_bans"`
+	AutoModLevel  *int            `json:"automod_level"`
+	Daily         []ChatHealthDay `json:"daily"`
+}
+
+// Raid is an incoming or outgoing raid delivered by EventSub
+type Raid struct {
+	ID           int       `json:"id" db:"id"`
+	UserID       string    `json:"-" db:"user_id"`
+	MessageID    string    `json:"-" db:"message_id"`
+	Direction    string    `json:"direction" db:"direction"` // incoming, outgoing
+	PartnerID    string    `json:"partner_id" db:"partner_id"`
+	PartnerLogin string    `json:"partner_login" db:"partner_login"`
+	PartnerName  string    `json:"partner_name" db:"partner_name"`
+	Viewers      int       `json:"viewers" db:"viewers"`
+	RaidedAt     time.Time `json:"raided_at" db:"raided_at"`
+
+	// FollowersGained counts follows within the conversion window after an incoming raid
+	FollowersGained int `json:"followers_gained" db:"followers_gained"`
+}
+
+// RaidPartner aggregates raids with one channel
+type RaidPartner struct {
+	PartnerID       string  `json:"partner_id"`
+	PartnerLogin    string  `json:"partner_login"`
+	PartnerName     string  `json:"partner_name"`
+	Raids           int     `json:"raids"`
+	Viewers         int     `json:"viewers"`
+	FollowersGained int     `json:"followers_gained,omitempty"`
+	ConversionRate  float64 `json:"conversion_rate,omitempty"`
+}
+
+// RaidAnalytics is returned by /api/analytics/raids
+type RaidAnalytics struct {
+	Days            int           `json:"days"`
+	Tracking        bool          `json:"tracking"`
+	IncomingRaids   int           `json:"incoming_raids"`
+	IncomingViewers int           `json:"incoming_viewers"`
+	OutgoingRaids   int           `json:"outgoing_raids"`
+	OutgoingViewers int           `json:"outgoing_viewers"`
+	FollowersGained int           `json:"followers_gained"`
+	ConversionRate  float64       `json:"conversion_rate"`
+	TopSources      []RaidPartner `json:"top_sources"`
+	TopDestinations []RaidPartner `json:"top_destinations"`
+	RecentRaids     []Raid        `json:"recent_raids"`
+}
+
+// MediaKit is a generated media kit PDF. The PDF bytes are only loaded for download.
+type MediaKit struct {
+	ID           int        `json:"id" db:"id"`
+	UserID       string     `json:"-" db:"user_id"`
+	Status       string     `json:"status" db:"status"`
+	SizeBytes    int        `json:"size_bytes" db:"size_bytes"`
+	ErrorMessage string     `json:"error_message,omitempty" db:"error_message"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+// WeeklyInsights are the natural-language insights generated for one week
+type WeeklyInsights struct {
+	ID        int       `json:"id"`
+	UserID    string    `json:"-"`
+	WeekStart time.Time `json:"week_start"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Insights  []string  `json:"insights"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // Dashboard Analytics Response Types
 
 // DashboardOverview provides high-level metrics for the dashboard
 type DashboardOverview struct {
-	CurrentFollowers      int     `json:"current_followers"`
-	FollowerChange        int     `json:"follower_change"`
-	FollowerChangePercent float64 `json:"follower_change_percent"`
-	CurrentSubscribers    int     `json:"current_subscribers"`
-	SubscriberChange      int     `json:"subscriber_change"`
-	TotalViews            int     `json:"total_views"`
-	ViewChange            int     `json:"view_change"`
-	AverageViewers        int     `json:"average_viewers"`
-	ViewerChange          int     `json:"viewer_change"`
-	StreamsLast30Days     int     `json:"streams_last_30_days"`
-	HoursStreamedLast30   float64 `json:"hours_streamed_last_30"`
+	CurrentFollowers        int     `json:"current_followers"`
+	FollowerChange          int     `json:"follower_change"`
+	FollowerChangePercent   float64 `json:"follower_change_percent"`
+	CurrentSubscribers      int     `json:"current_subscribers"`
+	SubscriberChange        int     `json:"subscriber_change"`
+	SubscriberChangePercent float64 `json:"subscriber_change_percent"`
+	TotalViews              int     `json:"total_views"`
+	ViewChange              int     `json:"view_change"`
+	AverageViewers          int     `json:"average_viewers"`
+	ViewerChange            int     `json:"viewer_change"`
+	StreamsLast30Days       int     `json:"streams_last_30_days"`
+	HoursStreamedLast30     float64 `json:"hours_streamed_last_30"`
+	// Changes compare the latest snapshot with the one ChangeDays before it, or
+	// the oldest one while there is no snapshot that old. ChangeSince is the
+	// date of that snapshot, nil until there are two. Subscriber and view
+	// changes only compare collected snapshots and are 0 until one is
+	// ChangeDays old, backfilled and imported days don't have those counts.
+	ChangeDays  int        `json:"change_days"`
+	ChangeSince *time.Time `json:"change_since,omitempty"`
+	// Consistency is nil until a video collection has computed it
+	Consistency *ChannelConsistency `json:"consistency,omitempty"`
+}
+
+// ChannelConsistency measures how regularly a creator streams and uploads.
+// Averages and the longest gap cover the last consistencyWindowWeeks weeks.
+type ChannelConsistency struct {
+	UserID                string     `json:"-" db:"user_id"`
+	CurrentStreakWeeks    int        `json:"current_streak_weeks" db:"current_streak_weeks"`
+	LongestStreakWeeks    int        `json:"longest_streak_weeks" db:"longest_streak_weeks"`
+	AverageStreamsPerWeek float64    `json:"average_streams_per_week" db:"average_streams_per_week"`
+	AverageUploadsPerWeek float64    `json:"average_uploads_per_week" db:"average_uploads_per_week"`
+	LongestGapDays        int        `json:"longest_gap_days" db:"longest_gap_days"`
+	LastStreamAt          *time.Time `json:"last_stream_at" db:"last_stream_at"`
+	// Stream activity of the last streamActivityDays days, returned at the top
+	// level of DashboardOverview
+	StreamsLast30Days   int       `json:"-" db:"streams_last_30_days"`
+	HoursStreamedLast30 float64   `json:"-" db:"hours_streamed_last_30"`
+	ComputedAt          time.Time `json:"computed_at" db:"computed_at"`
+}
+
+// StreamScheduleSegment is a planned stream from the creator's Twitch schedule
+type StreamScheduleSegment struct {
+	ID           int        `json:"-" db:"id"`
+	UserID       string     `json:"-" db:"user_id"`
+	SegmentID    string     `json:"segment_id" db:"segment_id"`
+	Title        string     `json:"title" db:"title"`
+	CategoryName string     `json:"category_name" db:"category_name"`
+	StartTime    time.Time  `json:"start_time" db:"start_time"`
+	EndTime      *time.Time `json:"end_time" db:"end_time"`
+	IsRecurring  bool       `json:"is_recurring" db:"is_recurring"`
+	Canceled     bool       `json:"canceled" db:"canceled"`
+}
+
+// ScheduleEntry pairs a planned stream with the stream that happened. Missed
+// entries have no stream and extra entries have no segment.
+type ScheduleEntry struct {
+	Status          string                 `json:"status"` // kept, missed, extra
+	Segment         *StreamScheduleSegment `json:"segment,omitempty"`
+	StreamStartedAt *time.Time             `json:"stream_started_at,omitempty"`
+	// StartOffsetMinutes is how late a kept stream went live, negative when early
+	StartOffsetMinutes *int `json:"start_offset_minutes,omitempty"`
+}
+
+// StreamScheduleComparison is returned by /api/analytics/schedule
+type StreamScheduleComparison struct {
+	Days int `json:"days"`
+	// TrackedSince is when the schedule was first collected. Streams before it
+	// are not compared.
+	TrackedSince *time.Time `json:"tracked_since"`
+	Planned      int        `json:"planned"`
+	Kept         int        `json:"kept"`
+	Missed       int        `json:"missed"`
+	Extra        int        `json:"extra"`
+	Canceled     int        `json:"canceled"`
+	// AdherencePercent is nil when nothing was planned
+	AdherencePercent *float64                `json:"adherence_percent"`
+	Entries          []ScheduleEntry         `json:"entries"`
+	Upcoming         []StreamScheduleSegment `json:"upcoming"`
+}
+
+// CreatorGoal is a Twitch creator goal with its latest progress. EndedAt is
+// set once a collection no longer sees the goal.
+type CreatorGoal struct {
+	ID            int        `json:"-" db:"id"`
+	UserID        string     `json:"-" db:"user_id"`
+	GoalID        string     `json:"goal_id" db:"goal_id"`
+	Type          string     `json:"type" db:"goal_type"`
+	Description   string     `json:"description" db:"description"`
+	CurrentAmount int        `json:"current_amount" db:"current_amount"`
+	TargetAmount  int        `json:"target_amount" db:"target_amount"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	LastSeenAt    time.Time  `json:"-" db:"last_seen_at"`
+	EndedAt       *time.Time `json:"ended_at" db:"ended_at"`
+}
+
+// CreatorGoalSnapshot is a goal's progress on one day
+type CreatorGoalSnapshot struct {
+	GoalID        int       `json:"-" db:"goal_id"`
+	Date          time.Time `json:"date" db:"date"`
+	CurrentAmount int       `json:"current_amount" db:"current_amount"`
+	TargetAmount  int       `json:"target_amount" db:"target_amount"`
+}
+
+// GoalProgress is a goal as shown on the dashboard, with its daily history
+type GoalProgress struct {
+	Source string `json:"source"` // twitch
+	CreatorGoal
+	ProgressPercent float64               `json:"progress_percent"`
+	Completed       bool                  `json:"completed"`
+	History         []CreatorGoalSnapshot `json:"history"`
+}
+
+// CreatorGoals is returned by /api/analytics/goals
+type CreatorGoals struct {
+	Days   int            `json:"days"`
+	Active []GoalProgress `json:"active"`
+	// Ended lists goals that ended within the window
+	Ended []GoalProgress `json:"ended"`
+}
+
+// MutedVideo is a VOD, highlight or upload with muted audio
+type MutedVideo struct {
+	VideoID       string         `json:"video_id"`
+	Title         string         `json:"title"`
+	VideoType     string         `json:"video_type"`
+	Duration      int            `json:"duration_seconds"`
+	ViewCount     int            `json:"view_count"`
+	ThumbnailURL  string         `json:"thumbnail_url"`
+	PublishedAt   *time.Time     `json:"published_at"`
+	MutedSegments []MutedSegment `json:"muted_segments"`
+	MutedSeconds  int            `json:"muted_seconds"`
+	MutedPercent  float64        `json:"muted_percent"`
+	Risk          string         `json:"risk"` // high, medium, low
+}
+
+// MutedVideoReport is returned by /api/analytics/muted-vods
+type MutedVideoReport struct {
+	Days            int     `json:"days"`
+	MinMutedPercent float64 `json:"min_muted_percent"`
+	// VideosChecked counts the videos other than clips published in the window
+	VideosChecked     int          `json:"videos_checked"`
+	VideosWithMuting  int          `json:"videos_with_muting"`
+	TotalMutedSeconds int          `json:"total_muted_seconds"`
+	Videos            []MutedVideo `json:"videos"`
+}
+
+// ClipMoment is a clip placed on its source VOD
+type ClipMoment struct {
+	ClipID          string `db:"clip_id"`
+	Title           string `db:"title"`
+	ViewCount       int    `db:"view_count"`
+	DurationSeconds int    `db:"duration_seconds"`
+	VideoID         string `db:"video_id"`
+	OffsetSeconds   int    `db:"offset_seconds"`
+	// VideoDuration is the length of the VOD in seconds
+	VideoDuration int `db:"video_duration"`
+}
+
+// HighlightClip is one of the clips that make up a suggested highlight
+type HighlightClip struct {
+	ClipID          string `json:"clip_id"`
+	Title           string `json:"title"`
+	ViewCount       int    `json:"view_count"`
+	OffsetSeconds   int    `json:"offset_seconds"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// HighlightSuggestion is a moment of a VOD that several viewers clipped
+type HighlightSuggestion struct {
+	ID             int             `json:"id" db:"id"`
+	UserID         string          `json:"-" db:"user_id"`
+	VideoID        string          `json:"video_id" db:"video_id"`
+	VideoTitle     string          `json:"video_title" db:"video_title"`
+	StartSeconds   int             `json:"start_seconds" db:"start_seconds"`
+	EndSeconds     int             `json:"end_seconds" db:"end_seconds"`
+	ClipCount      int             `json:"clip_count" db:"clip_count"`
+	TotalClipViews int             `json:"total_clip_views" db:"total_clip_views"`
+	Clips          []HighlightClip `json:"clips" db:"-"`
+	// URL opens the VOD at the start of the moment
+	URL        string    `json:"url" db:"-"`
+	ComputedAt time.Time `json:"computed_at" db:"computed_at"`
+}
+
+// ClipReadiness is how well a clip fits a short-form upload (Shorts, TikTok,
+// Reels)
+type ClipReadiness struct {
+	ClipID       string     `json:"clip_id" db:"video_id"`
+	Title        string     `json:"title" db:"title"`
+	Duration     int        `json:"duration_seconds" db:"duration_seconds"`
+	ViewCount    int        `json:"view_count" db:"view_count"`
+	ThumbnailURL string     `json:"-" db:"thumbnail_url"`
+	PublishedAt  *time.Time `json:"published_at" db:"published_at"`
+	// VerticalThumbnailURL is the 9:16 cover served by the media proxy, empty
+	// when Twitch has no thumbnail for the clip
+	VerticalThumbnailURL string  `json:"vertical_thumbnail_url" db:"-"`
+	ViewsPerDay          float64 `json:"views_per_day" db:"-"`
+	Ready                bool    `json:"ready" db:"-"`
+	// Issues names the checks the clip fails: too_long, no_thumbnail, low_velocity
+	Issues []string `json:"issues" db:"-"`
+}
+
+// RepurposeReport is returned by /api/analytics/repurpose
+type RepurposeReport struct {
+	Days         int `json:"days"`
+	ClipsChecked int `json:"clips_checked"`
+	ReadyCount   int `json:"ready_count"`
+	// Clips are ranked ready first, then by views per day
+	Clips []ClipReadiness `json:"clips"`
+}
+
+// VideoDayViews is a video's view count on a day after it was published
+type VideoDayViews struct {
+	VideoID   string `db:"video_id"`
+	VideoType string `db:"video_type"`
+	// Day counts the days since publishing, 0 being the day itself
+	Day          int `db:"day"`
+	ViewCount    int `db:"view_count"`
+	CurrentViews int `db:"current_views"`
+}
+
+// DecayPoint is the average share of their current views the videos of a
+// type had Day days after publishing
+type DecayPoint struct {
+	Day    int     `json:"day"`
+	Share  float64 `json:"share"`
+	Videos int     `json:"videos"`
+}
+
+// DecayCurve is how the views of one type of content build up after
+// publishing
+type DecayCurve struct {
+	VideoType string       `json:"video_type"`
+	Videos    int          `json:"videos"`
+	Points    []DecayPoint `json:"points"`
+	// HalfLifeDays and NinetyPercentDays are the first days by which half and
+	// 90% of the views were reached, nil when that's past the curve
+	HalfLifeDays      *int `json:"half_life_days"`
+	NinetyPercentDays *int `json:"ninety_percent_days"`
+}
+
+// VideoDecayReport is returned by /api/analytics/decay
+type VideoDecayReport struct {
+	Days   int          `json:"days"`
+	Curves []DecayCurve `json:"curves"`
+}
+
+// LanguageShare is the content of the last days broadcast in one language.
+// Clips are made by viewers, so they show which language audience engages.
+type LanguageShare struct {
+	Language     string  `json:"language" db:"language"`
+	Videos       int     `json:"videos" db:"videos"`
+	Clips        int     `json:"clips" db:"clips"`
+	Views        int     `json:"views" db:"views"`
+	ViewsPercent float64 `json:"views_percent" db:"-"`
+}
+
+// AudienceReport is returned by /api/analytics/audience. Twitch doesn't expose
+// where viewers are, so the audience is broken down by language only.
+type AudienceReport struct {
+	Days                int    `json:"days"`
+	BroadcasterLanguage string `json:"broadcaster_language"`
+	// Languages are ordered by views
+	Languages []LanguageShare `json:"languages"`
+}
+
+// CollabContent is a stream or video with the creators it was made with
+type CollabContent struct {
+	ContentType string     `json:"content_type" db:"content_type"` // stream or video
+	ContentID   string     `json:"content_id" db:"content_id"`
+	Title       string     `json:"title" db:"title"`
+	OccurredAt  *time.Time `json:"occurred_at" db:"occurred_at"`
+	// Stream metrics
+	AverageViewers  int `json:"average_viewers" db:"average_viewers"`
+	PeakViewers     int `json:"peak_viewers" db:"peak_viewers"`
+	FollowersGained int `json:"followers_gained" db:"followers_gained"`
+	// Video metric
+	ViewCount     int      `json:"view_count" db:"view_count"`
+	Collaborators []string `json:"collaborators" db:"-"`
+	// Source is "tagged" when the user set the collaborators and "detected"
+	// when they were read from the title
+	Source string `json:"source" db:"-"`
+}
+
+// CollabStats averages the performance of a set of streams and videos
+type CollabStats struct {
+	Streams            int     `json:"streams"`
+	AvgViewers         float64 `json:"avg_viewers"`
+	AvgPeakViewers     float64 `json:"avg_peak_viewers"`
+	AvgFollowersGained float64 `json:"avg_followers_gained"`
+	Videos             int     `json:"videos"`
+	AvgVideoViews      float64 `json:"avg_video_views"`
+}
+
+// CollaboratorStats is the performance of the content made with one creator
+type CollaboratorStats struct {
+	Login string `json:"login"`
+	CollabStats
+	// ViewerLiftPercent compares average viewers with solo streams, nil
+	// without streams on both sides
+	ViewerLiftPercent *float64 `json:"viewer_lift_percent"`
+}
+
+// CollaborationReport is returned by /api/analytics/collaborations
+type CollaborationReport struct {
+	Days                 int                 `json:"days"`
+	Solo                 CollabStats         `json:"solo"`
+	Collab               CollabStats         `json:"collab"`
+	ViewerLiftPercent    *float64            `json:"viewer_lift_percent"`
+	VideoViewLiftPercent *float64            `json:"video_view_lift_percent"`
+	Collaborators        []CollaboratorStats `json:"collaborators"`
+	// Content lists the collab streams and videos, newest first
+	Content []CollabContent `json:"content"`
+}
+
+// TitleExperiment compares two title styles on the user's VODs
+type TitleExperiment struct {
+	ID     int    `json:"id" db:"id"`
+	UserID string `json:"-" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// VariantA and VariantB describe what the titles of each variant try
+	VariantA  string    `json:"variant_a" db:"variant_a"`
+	VariantB  string    `json:"variant_b" db:"variant_b"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ExperimentVideo is a VOD assigned to a variant of an experiment
+type ExperimentVideo struct {
+	ExperimentID int        `json:"-" db:"experiment_id"`
+	VideoID      string     `json:"video_id" db:"video_id"`
+	Variant      string     `json:"variant" db:"variant"` // A or B
+	Title        string     `json:"title" db:"title"`
+	VideoType    string     `json:"video_type" db:"video_type"`
+	PublishedAt  *time.Time `json:"published_at" db:"published_at"`
+	ViewCount    int        `json:"view_count" db:"view_count"`
+	ViewsPerDay  float64    `json:"views_per_day" db:"views_per_day"`
+	// Clips counts the clips made from the VOD
+	Clips int `json:"clips" db:"clips"`
+}
+
+// VariantStats is the performance of the VODs of one variant
+type VariantStats struct {
+	Videos int `json:"videos"`
+	Views  int `json:"views"`
+	Clips  int `json:"clips"`
+	// AvgViewsPerDay averages the views per day since publishing of each VOD
+	AvgViewsPerDay float64 `json:"avg_views_per_day"`
+	// ClipRate is clips per 1,000 views
+	ClipRate float64 `json:"clip_rate"`
+}
+
+// ExperimentResult is an experiment with how its variants performed
+type ExperimentResult struct {
+	TitleExperiment
+	A VariantStats `json:"a"`
+	B VariantStats `json:"b"`
+	// ViewsPerDayLiftPercent and ClipRateLiftPercent compare B with A, nil
+	// without VODs on both sides
+	ViewsPerDayLiftPercent *float64 `json:"views_per_day_lift_percent"`
+	ClipRateLiftPercent    *float64 `json:"clip_rate_lift_percent"`
+	// Videos is only filled in by /api/analytics/experiments/:id
+	Videos []ExperimentVideo `json:"videos,omitempty"`
 }
 
 // ChartDataPoint represents a data point for charts
@@ -149,6 +913,20 @@ type DetailedAnalytics struct {
 	RecentActivity []ActivityItem     `json:"recent_activity"`
 }
 
+// StreamSessionDetail joins a stream session with the content attributed to it
+type StreamSessionDetail struct {
+	Session StreamSession    `json:"session"`
+	VODs    []VideoAnalytics `json:"vods"`
+	// Clips created while the stream was live
+	ClipCount int `json:"clip_count"`
+	ClipViews int `json:"clip_views"`
+	VODViews  int `json:"vod_views"`
+	// FollowersGained falls back to the change in daily follower snapshots
+	// across the stream when the session itself has no follower data
+	FollowersGained          int  `json:"followers_gained"`
+	FollowersGainedEstimated bool `json:"followers_gained_estimated"`
+}
+
 // ActivityItem represents recent activity for the dashboard
 type ActivityItem struct {
 	Type        string    `json:"type"` // 'stream', 'video', 'milestone'
@@ -183,6 +961,10 @@ type VideoBasedOverview struct {
 	CurrentSubscribers   int     `json:"currentSubscribers"`
 	FollowerChange       int     `json:"followerChange"`
 	SubscriberChange     int     `json:"subscriberChange"`
+	// Changes cover the requested days, see DashboardOverview
+	FollowerChangePercent   float64    `json:"followerChangePercent"`
+	SubscriberChangePercent float64    `json:"subscriberChangePercent"`
+	ChangeSince             *time.Time `json:"changeSince,omitempty"`
 }
 
 // PerformanceData represents performance metrics over time
@@ -206,3 +988,28 @@ type EnhancedAnalytics struct {
 	TopVideos    []VideoAnalytics   `json:"topVideos"`
 	RecentVideos []VideoAnalytics   `json:"recentVideos"`
 }
+
+// KeywordPerformance compares videos whose title contains a keyword with the
+// creator's average. Lift is the keyword's average views over the baseline.
+type KeywordPerformance struct {
+	Keyword  string  `json:"keyword" db:"keyword"`
+	Videos   int     `json:"videos" db:"videos"`
+	AvgViews float64 `json:"avgViews" db:"avg_views"`
+	Lift     float64 `json:"lift" db:"lift"`
+}
+
+// KeywordInsights ranks title keywords by how much better their videos perform
+type KeywordInsights struct {
+	VideosAnalyzed   int                  `json:"videosAnalyzed"`
+	BaselineAvgViews float64              `json:"baselineAvgViews"`
+	Keywords         []KeywordPerformance `json:"keywords"`
+}
+
+// VideoPage is one page of the content library. NextOffset is nil on the last page.
+type VideoPage struct {
+	Videos     []VideoAnalytics `json:"videos"`
+	Total      int              `json:"total"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	NextOffset *int             `json:"nextOffset"`
+}
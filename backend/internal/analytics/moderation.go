@@ -0,0 +1,170 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// maxBannedUserPages bounds how many pages of banned users are counted per day
+const maxBannedUserPages = 10
+
+// collectModerationData snapshots the number of active bans and the AutoMod
+// level. Both are best effort, a missing scope only skips that value.
+func (dc *dataCollector) collectModerationData(ctx context.Context, userID, twitchToken, broadcasterID string) {
+	metrics := &ModerationDailyMetrics{
+		UserID: userID,
+		Date:   time.Now().UTC().Truncate(24 * time.Hour),
+	}
+
+	cursor := ""
+	for page := 0; page < maxBannedUserPages; page++ {
+		resp, err := dc.twitchClient.GetBannedUsersPage(ctx, twitchToken, broadcasterID, 100, cursor)
+		if err != nil {
+			log.Printf("Skipping moderation metrics for user %s: %v", userID, err)
+			return
+		}
+		metrics.ActiveBans += len(resp.Data)
+
+		cursor = resp.Pagination.Cursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	settings, err := dc.twitchClient.GetAutoModSettings(ctx, twitchToken, broadcasterID, broadcasterID)
+	if err != nil {
+		log.Printf("Skipping AutoMod settings for user %s: %v", userID, err)
+	} else {
+		metrics.AutoModLevel = settings.OverallLevel
+	}
+
+	if err := dc.repo.SaveModerationDailyMetrics(ctx, metrics); err != nil {
+		log.Printf("Failed to save moderation metrics for user %s: %v", userID, err)
+	}
+}
+
+// SaveBanEvent stores a ban or timeout delivered by EventSub. The message ID
+// deduplicates redeliveries. Events for broadcasters we don't know are dropped.
+func SaveBanEvent(ctx context.Context, repo Repository, messageID string, event *twitch.BanEvent) error {
+	userID, err := repo.GetUserIDByTwitchID(ctx, event.BroadcasterUserID)
+	if err != nil {
+		return fmt.Errorf("failed to look up broadcaster: %w", err)
+	}
+	if userID == "" {
+		log.Printf("Ignoring ban for unknown broadcaster %s", event.BroadcasterUserID)
+		return nil
+	}
+
+	action := &ModerationAction{
+		UserID:    userID,
+		MessageID: messageID,
+		Action:    "ban",
+		CreatedAt: event.BannedAt,
+	}
+	if !event.IsPermanent {
+		action.Action = "timeout"
+		if event.EndsAt != nil {
+			action.DurationSeconds = int(event.EndsAt.Sub(event.BannedAt).Seconds())
+		}
+	}
+
+	return repo.SaveModerationAction(ctx, action)
+}
+
+// GetChatHealth returns moderation actions per day next to average viewers
+func (s *service) GetChatHealth(ctx context.Context, userID string, days int) (*ChatHealth, error) {
+	now := time.Now().UTC()
+	since := now.Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+
+	sub, err := s.repo.GetEventSubSubscription(ctx, userID, twitch.EventSubChannelBan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EventSub subscription: %w", err)
+	}
+
+	actions, err := s.repo.GetModerationActionsByDay(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation actions: %w", err)
+	}
+
+	metrics, err := s.repo.GetModerationDailyMetrics(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation metrics: %w", err)
+	}
+
+	sessions, err := s.repo.GetStreamSessionsByDateRange(ctx, userID, since, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream sessions: %w", err)
+	}
+
+	health := &ChatHealth{
+		Days:     days,
+		Tracking: sub != nil && sub.Status == "enabled",
+		Daily:    buildChatHealthDays(since, days, actions, metrics, sessions),
+	}
+	for _, day := range health.Daily {
+		health.TotalBans += day.Bans
+		health.TotalTimeouts += day.Timeouts
+	}
+	if len(metrics) > 0 {
+		latest := metrics[len(metrics)-1]
+		health.ActiveBans = &latest.ActiveBans
+		health.AutoModLevel = latest.AutoModLevel
+	}
+
+	return health, nil
+}
+
+// buildChatHealthDays lines up moderation actions, snapshots and stream viewers
+// for each day starting at since. Average viewers are weighted by stream length.
+func buildChatHealthDays(since time.Time, days int, actions []ModerationActionDay, metrics []ModerationDailyMetrics, sessions []StreamSession) []ChatHealthDay {
+	const layout = "2006-01-02"
+
+	byDate := make(map[string]*ChatHealthDay, days)
+	result := make([]ChatHealthDay, days)
+	for i := range result {
+		result[i].Date = since.AddDate(0, 0, i).Format(layout)
+		byDate[result[i].Date] = &result[i]
+	}
+
+	for _, action := range actions {
+		if day, ok := byDate[action.Date.Format(layout)]; ok {
+			day.Bans = action.Bans
+			day.Timeouts = action.Timeouts
+		}
+	}
+
+	for i := range metrics {
+		if day, ok := byDate[metrics[i].Date.Format(layout)]; ok {
+			day.ActiveBans = &metrics[i].ActiveBans
+		}
+	}
+
+	minutes := make(map[string]int)
+	viewerMinutes := make(map[string]int)
+	for _, session := range sessions {
+		if session.StartedAt == nil {
+			continue
+		}
+		date := session.StartedAt.UTC().Format(layout)
+		minutes[date] += session.DurationMinutes
+		viewerMinutes[date] += session.AverageViewers * session.DurationMinutes
+	}
+
+	for i := range result {
+		day := &result[i]
+		if minutes[day.Date] > 0 {
+			day.AverageViewers = viewerMinutes[day.Date] / minutes[day.Date]
+		}
+		if day.AverageViewers > 0 {
+			per100 := float64(day.Bans+day.Timeouts) / float64(day.AverageViewers) * 100
+			day.ActionsPer100 = math.Round(per100*100) / 100
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildChatHealthDays(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	streamStart := since.AddDate(0, 0, 1).Add(18 * time.Hour)
+	activeBans := 7
+
+	days := buildChatHealthDays(since, 3,
+		[]ModerationActionDay{{Date: since.AddDate(0, 0, 1), Bans: 1, Timeouts: 4}},
+		[]ModerationDailyMetrics{{Date: since.AddDate(0, 0, 2), ActiveBans: activeBans}},
+		[]StreamSession{
+			{StartedAt: &streamStart, DurationMinutes: 60, AverageViewers: 100},
+			{StartedAt: &streamStart, DurationMinutes: 180, AverageViewers: 300},
+			{StartedAt: nil, DurationMinutes: 60, AverageViewers: 1000},
+		})
+
+	require.Len(t, days, 3)
+	assert.Equal(t, "2025-06-01", days[0].Date)
+	assert.Zero(t, days[0].AverageViewers)
+	assert.Zero(t, days[0].ActionsPer100)
+
+	// (60*100 + 180*300) / 240 = 250 viewers, 5 actions
+	assert.Equal(t, 250, days[1].AverageViewers)
+	assert.Equal(t, 2.0, days[1].ActionsPer100)
+	assert.Nil(t, days[1].ActiveBans)
+
+	require.NotNil(t, days[2].ActiveBans)
+	assert.Equal(t, 7, *days[2].ActiveBans)
+}
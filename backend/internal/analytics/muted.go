@@ -0,0 +1,70 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Muting thresholds for a video's risk level. Many muted segments point at a
+// habit of playing copyrighted music even when each one is short.
+const (
+	mutedHighPercent  = 25
+	mutedHighSegments = 5
+
+	mutedMediumPercent  = 10
+	mutedMediumSegments = 2
+)
+
+// GetMutedVideoReport lists the videos of the last days whose muted share is at
+// least minPercent, most muted first
+func (s *service) GetMutedVideoReport(ctx context.Context, userID string, days int, minPercent float64) (*MutedVideoReport, error) {
+	videos, err := s.repo.GetVideoMuting(ctx, userID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildMutedVideoReport(videos, minPercent)
+	report.Days = days
+	return report, nil
+}
+
+func buildMutedVideoReport(videos []MutedVideo, minPercent float64) *MutedVideoReport {
+	report := &MutedVideoReport{
+		MinMutedPercent: minPercent,
+		VideosChecked:   len(videos),
+		Videos:          []MutedVideo{},
+	}
+
+	for _, video := range videos {
+		if video.MutedSeconds <= 0 {
+			continue
+		}
+		report.VideosWithMuting++
+		report.TotalMutedSeconds += video.MutedSeconds
+
+		if video.Duration > 0 {
+			video.MutedPercent = float64(video.MutedSeconds) / float64(video.Duration) * 100
+		}
+		video.Risk = mutingRisk(video.MutedPercent, len(video.MutedSegments))
+		if video.MutedPercent >= minPercent {
+			report.Videos = append(report.Videos, video)
+		}
+	}
+
+	sort.SliceStable(report.Videos, func(i, j int) bool {
+		return report.Videos[i].MutedPercent > report.Videos[j].MutedPercent
+	})
+	return report
+}
+
+func mutingRisk(percent float64, segments int) string {
+	switch {
+	case percent >= mutedHighPercent || segments >= mutedHighSegments:
+		return "high"
+	case percent >= mutedMediumPercent || segments >= mutedMediumSegments:
+		return "medium"
+	default:
+		return "low"
+	}
+}
@@ -0,0 +1,35 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMutedVideoReport(t *testing.T) {
+	videos := []MutedVideo{
+		{VideoID: "clean", Duration: 3600},
+		{VideoID: "light", Duration: 3600, MutedSeconds: 180, MutedSegments: []MutedSegment{{Offset: 600, Duration: 180}}},
+		{VideoID: "heavy", Duration: 3600, MutedSeconds: 1800, MutedSegments: []MutedSegment{{Offset: 0, Duration: 1800}}},
+		{VideoID: "medium", Duration: 7200, MutedSeconds: 720, MutedSegments: []MutedSegment{{Offset: 0, Duration: 360}, {Offset: 3600, Duration: 360}}},
+	}
+
+	report := buildMutedVideoReport(videos, 10)
+
+	assert.Equal(t, 4, report.VideosChecked)
+	assert.Equal(t, 3, report.VideosWithMuting)
+	assert.Equal(t, 2700, report.TotalMutedSeconds)
+
+	require.Len(t, report.Videos, 2)
+	assert.Equal(t, "heavy", report.Videos[0].VideoID)
+	assert.Equal(t, 50.0, report.Videos[0].MutedPercent)
+	assert.Equal(t, "high", report.Videos[0].Risk)
+	assert.Equal(t, "medium", report.Videos[1].VideoID)
+	assert.Equal(t, "medium", report.Videos[1].Risk)
+
+	// Lowering the threshold lists lightly muted videos too
+	all := buildMutedVideoReport(videos, 0)
+	require.Len(t, all.Videos, 3)
+	assert.Equal(t, "low", all.Videos[2].Risk)
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// validVideoTypes are the Twitch video types the collector can fetch
+var validVideoTypes = []string{"archive", "highlight", "upload"}
+
+// CollectionOptions controls what a data collection run fetches from Twitch
+type CollectionOptions struct {
+	MaxVideos    int      `json:"max_videos"`
+	IncludeClips bool     `json:"include_clips"`
+	VideoTypes   []string `json:"video_types"`
+}
+
+// DefaultCollectionOptions returns the server-side defaults from the environment
+func DefaultCollectionOptions() CollectionOptions {
+	cfg := config.Collection()
+	return CollectionOptions{
+		MaxVideos:    cfg.MaxVideos,
+		IncludeClips: cfg.IncludeClips,
+		VideoTypes:   cfg.VideoTypes,
+	}
+}
+
+// Normalize fills in defaults, caps MaxVideos at the configured limit and
+// rejects unknown video types
+func (o CollectionOptions) Normalize() (CollectionOptions, error) {
+	cfg := config.Collection()
+
+	if o.MaxVideos <= 0 {
+		o.MaxVideos = cfg.MaxVideos
+	}
+	if cfg.MaxVideosLimit > 0 && o.MaxVideos > cfg.MaxVideosLimit {
+		o.MaxVideos = cfg.MaxVideosLimit
+	}
+
+	requested := o.VideoTypes
+	if len(requested) == 0 {
+		requested = cfg.VideoTypes
+	}
+
+	seen := make(map[string]bool)
+	var types []string
+	for _, videoType := range requested {
+		videoType = strings.ToLower(strings.TrimSpace(videoType))
+		if !isValidVideoType(videoType) {
+			return o, fmt.Errorf("invalid video type %q (valid: %s)", videoType, strings.Join(validVideoTypes, ", "))
+		}
+		if !seen[videoType] {
+			seen[videoType] = true
+			types = append(types, videoType)
+		}
+	}
+	o.VideoTypes = types
+
+	return o, nil
+}
+
+// twitchVideoTypes returns the type filters to query Twitch with. When every
+// type is wanted a single "all" query is used so MaxVideos applies to the most
+// recent videos regardless of type.
+func (o CollectionOptions) twitchVideoTypes() []string {
+	if len(o.VideoTypes) == len(validVideoTypes) {
+		return []string{"all"}
+	}
+	return o.VideoTypes
+}
+
+func isValidVideoType(videoType string) bool {
+	for _, valid := range validVideoTypes {
+		if videoType == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionOptionsNormalize(t *testing.T) {
+	t.Setenv("COLLECTION_MAX_VIDEOS", "200")
+	t.Setenv("COLLECTION_MAX_VIDEOS_LIMIT", "1000")
+	t.Setenv("COLLECTION_VIDEO_TYPES", "archive,highlight")
+
+	opts, err := CollectionOptions{}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, 200, opts.MaxVideos)
+	assert.Equal(t, []string{"archive", "highlight"}, opts.VideoTypes)
+	assert.Equal(t, []string{"archive", "highlight"}, opts.twitchVideoTypes())
+
+	opts, err = CollectionOptions{MaxVideos: 5000, VideoTypes: []string{"Upload", "archive", "upload", "highlight"}}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, 1000, opts.MaxVideos)
+	assert.Equal(t, []string{"upload", "archive", "highlight"}, opts.VideoTypes)
+	assert.Equal(t, []string{"all"}, opts.twitchVideoTypes())
+
+	_, err = CollectionOptions{VideoTypes: []string{"clip"}}.Normalize()
+	assert.Error(t, err)
+}
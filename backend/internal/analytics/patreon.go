@@ -0,0 +1,205 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/patreon"
+)
+
+// patreonMembersPageSize is the most members Patreon returns per page
+const patreonMembersPageSize = 1000
+
+// ErrPatreonAuthRequired means the user has to reconnect Patreon before income
+// can be collected
+var ErrPatreonAuthRequired = errors.New("patreon authorization required")
+
+// PatreonAPI is the part of the Patreon client used to collect income
+type PatreonAPI interface {
+	RefreshToken(ctx context.Context, refreshToken string) (*patreon.OAuthToken, error)
+	GetCampaign(ctx context.Context, accessToken string) (*patreon.Campaign, error)
+	GetMembersPage(ctx context.Context, accessToken, campaignID string, limit int, cursor string) (*patreon.MembersPage, error)
+}
+
+// PatreonCollector stores Patreon connections and records a daily snapshot of
+// the membership income of each connected user
+type PatreonCollector struct {
+	repo   Repository
+	client PatreonAPI
+}
+
+func NewPatreonCollector(repo Repository, client PatreonAPI) *PatreonCollector {
+	return &PatreonCollector{
+		repo:   repo,
+		client: client,
+	}
+}
+
+// StoreToken encrypts and persists a token obtained from the Patreon OAuth flow
+func (pc *PatreonCollector) StoreToken(ctx context.Context, userID, patreonUserID, campaignID string, token *patreon.OAuthToken) error {
+	accessToken, keyID, err := encryptToken(ctx, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+
+	refreshToken := ""
+	if token.RefreshToken != "" {
+		refreshToken, _, err = encryptToken(ctx, token.RefreshToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt refresh token: %w", err)
+		}
+	}
+
+	return pc.repo.SavePatreonToken(ctx, &PatreonToken{
+		UserID:          userID,
+		PatreonUserID:   patreonUserID,
+		CampaignID:      campaignID,
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		EncryptionKeyID: keyID,
+		Scopes:          token.Scope,
+		ExpiresAt:       token.ExpiresAt(),
+	})
+}
+
+// CollectUser records today's Patreon snapshot of a user. Users without a
+// Patreon connection or campaign are skipped. It has the signature of a
+// CollectionHook so it runs after the daily collection.
+func (pc *PatreonCollector) CollectUser(ctx context.Context, userID string) error {
+	stored, err := pc.repo.GetPatreonToken(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load stored Patreon token: %w", err)
+	}
+	if stored == nil {
+		return nil
+	}
+
+	accessToken, err := pc.validToken(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	campaign, err := pc.client.GetCampaign(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("failed to get Patreon campaign: %w", err)
+	}
+	if campaign == nil {
+		log.Printf("Skipping Patreon income for user %s: no campaign", userID)
+		return nil
+	}
+
+	cfg := config.Patreon()
+	var members []patreon.Member
+	cursor := ""
+	for page := 0; page < cfg.MaxMemberPages; page++ {
+		resp, err := pc.client.GetMembersPage(ctx, accessToken, campaign.ID, patreonMembersPageSize, cursor)
+		if err != nil {
+			return fmt.Errorf("failed to get Patreon members: %w", err)
+		}
+		members = append(members, resp.Members...)
+
+		cursor = resp.NextCursor
+		if cursor == "" || len(resp.Members) == 0 {
+			break
+		}
+	}
+	if cursor != "" {
+		log.Printf("Patreon income of user %s only counts the first %d members", userID, len(members))
+	}
+
+	revenue := summarizePatreonMembers(campaign, members, cfg)
+	revenue.UserID = userID
+	revenue.Date = time.Now().UTC().Truncate(24 * time.Hour)
+	if err := pc.repo.SavePatreonRevenue(ctx, revenue); err != nil {
+		return fmt.Errorf("failed to save Patreon revenue: %w", err)
+	}
+	return nil
+}
+
+// summarizePatreonMembers adds up the active members' monthly pledges, in
+// total and per tier. Members entitled to several tiers count toward the
+// highest one.
+func summarizePatreonMembers(campaign *patreon.Campaign, members []patreon.Member, cfg config.PatreonConfig) *PatreonRevenue {
+	revenue := &PatreonRevenue{
+		CampaignID:  campaign.ID,
+		PatronCount: campaign.PatronCount,
+		Tiers:       []PatreonTierRevenue{},
+	}
+
+	tierIndex := make(map[string]int, len(campaign.Tiers))
+	for _, tier := range campaign.Tiers {
+		tierIndex[tier.ID] = len(revenue.Tiers)
+		revenue.Tiers = append(revenue.Tiers, PatreonTierRevenue{
+			TierID:      tier.ID,
+			Title:       tier.Title,
+			AmountCents: tier.AmountCents,
+		})
+	}
+
+	for _, member := range members {
+		if !member.IsActive() {
+			continue
+		}
+		amount := member.MonthlyAmountCents()
+		revenue.ActiveMembers++
+		revenue.MonthlyPledgeCents += amount
+
+		index, ok := -1, false
+		for _, tierID := range member.TierIDs {
+			if i, found := tierIndex[tierID]; found && (!ok || revenue.Tiers[i].AmountCents > revenue.Tiers[index].AmountCents) {
+				index, ok = i, true
+			}
+		}
+		if !ok {
+			// Custom pledges and unpublished tiers
+			if index, ok = tierIndex[""]; !ok {
+				index = len(revenue.Tiers)
+				tierIndex[""] = index
+				revenue.Tiers = append(revenue.Tiers, PatreonTierRevenue{})
+			}
+		}
+		revenue.Tiers[index].ActiveMembers++
+		revenue.Tiers[index].MonthlyPledgeCents += amount
+	}
+
+	revenue.EstimatedIncomeCents = int(math.Round(float64(revenue.MonthlyPledgeCents) * cfg.CreatorShare))
+	return revenue
+}
+
+// validToken returns the stored access token, refreshing it if it is about to
+// expire
+func (pc *PatreonCollector) validToken(ctx context.Context, stored *PatreonToken) (string, error) {
+	if stored.ExpiresAt == nil || time.Until(*stored.ExpiresAt) > tokenRefreshMargin {
+		return decryptToken(ctx, stored.AccessToken, stored.EncryptionKeyID)
+	}
+
+	if stored.RefreshToken == "" {
+		return "", fmt.Errorf("stored Patreon token expired and has no refresh token: %w", ErrPatreonAuthRequired)
+	}
+	refreshToken, err := decryptToken(ctx, stored.RefreshToken, stored.EncryptionKeyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+
+	log.Printf("Refreshing Patreon token for user %s", stored.UserID)
+	token, err := pc.client.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		var apiErr *patreon.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			// Patreon rejected the refresh token itself, e.g. because access was revoked
+			return "", fmt.Errorf("failed to refresh Patreon token: %v: %w", err, ErrPatreonAuthRequired)
+		}
+		return "", fmt.Errorf("failed to refresh Patreon token: %w", err)
+	}
+
+	if err := pc.StoreToken(ctx, stored.UserID, stored.PatreonUserID, stored.CampaignID, token); err != nil {
+		return "", fmt.Errorf("failed to store refreshed Patreon token: %w", err)
+	}
+	return token.AccessToken, nil
+}
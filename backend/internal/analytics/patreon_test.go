@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/patreon"
+)
+
+func TestSummarizePatreonMembers(t *testing.T) {
+	campaign := &patreon.Campaign{
+		ID:          "c1",
+		PatronCount: 5,
+		Tiers: []patreon.Tier{
+			{ID: "t1", Title: "Supporter", AmountCents: 500},
+			{ID: "t2", Title: "Producer", AmountCents: 2000},
+		},
+	}
+	members := []patreon.Member{
+		{PatronStatus: "active_patron", CurrentlyEntitledAmountCents: 500, PledgeCadence: 1, TierIDs: []string{"t1"}},
+		// Entitled to both tiers, counted toward the higher one
+		{PatronStatus: "active_patron", CurrentlyEntitledAmountCents: 2000, PledgeCadence: 1, TierIDs: []string{"t1", "t2"}},
+		// Annual pledge of a custom amount
+		{PatronStatus: "active_patron", CurrentlyEntitledAmountCents: 12000, PledgeCadence: 12},
+		{PatronStatus: "declined_patron", CurrentlyEntitledAmountCents: 500, PledgeCadence: 1, TierIDs: []string{"t1"}},
+		{PatronStatus: "former_patron"},
+	}
+
+	revenue := summarizePatreonMembers(campaign, members, config.PatreonConfig{CreatorShare: 0.9})
+	assert.Equal(t, "c1", revenue.CampaignID)
+	assert.Equal(t, 3, revenue.ActiveMembers)
+	assert.Equal(t, 3500, revenue.MonthlyPledgeCents)
+	assert.Equal(t, 3150, revenue.EstimatedIncomeCents)
+	assert.Equal(t, []PatreonTierRevenue{
+		{TierID: "t1", Title: "Supporter", AmountCents: 500, ActiveMembers: 1, MonthlyPledgeCents: 500},
+		{TierID: "t2", Title: "Producer", AmountCents: 2000, ActiveMembers: 1, MonthlyPledgeCents: 2000},
+		{ActiveMembers: 1, MonthlyPledgeCents: 1000},
+	}, revenue.Tiers)
+}
+
+func TestPatreonCollectUserPagesMembers(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	t.Setenv("PATREON_CREATOR_SHARE", "0.5")
+	ctx := context.Background()
+	accessToken, keyID, err := encryptToken(ctx, "access")
+	require.NoError(t, err)
+
+	repo := &mockRepository{}
+	client := &mockPatreonAPI{}
+	collector := NewPatreonCollector(repo, client)
+
+	expiresAt := time.Now().Add(time.Hour)
+	repo.On("GetPatreonToken", ctx, "user_1").Return(&PatreonToken{
+		UserID: "user_1", AccessToken: accessToken, EncryptionKeyID: keyID, ExpiresAt: &expiresAt,
+	}, nil)
+	client.On("GetCampaign", ctx, "access").Return(&patreon.Campaign{ID: "c1", PatronCount: 2}, nil)
+	client.On("GetMembersPage", ctx, "access", "c1", patreonMembersPageSize, "").Return(&patreon.MembersPage{
+		Members:    []patreon.Member{{PatronStatus: "active_patron", CurrentlyEntitledAmountCents: 300, PledgeCadence: 1}},
+		NextCursor: "next",
+	}, nil)
+	client.On("GetMembersPage", ctx, "access", "c1", patreonMembersPageSize, "next").Return(&patreon.MembersPage{
+		Members: []patreon.Member{{PatronStatus: "active_patron", CurrentlyEntitledAmountCents: 700, PledgeCadence: 1}},
+	}, nil)
+	repo.On("SavePatreonRevenue", ctx, mock.MatchedBy(func(revenue *PatreonRevenue) bool {
+		return revenue.UserID == "user_1" && revenue.ActiveMembers == 2 &&
+			revenue.MonthlyPledgeCents == 1000 && revenue.EstimatedIncomeCents == 500
+	})).Return(nil)
+
+	require.NoError(t, collector.CollectUser(ctx, "user_1"))
+	repo.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestPatreonCollectUserWithoutConnection(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{}
+	client := &mockPatreonAPI{}
+	repo.On("GetPatreonToken", ctx, "user_1").Return(nil, nil)
+
+	require.NoError(t, NewPatreonCollector(repo, client).CollectUser(ctx, "user_1"))
+	client.AssertNotCalled(t, "GetCampaign", mock.Anything, mock.Anything)
+}
+
+func TestPatreonRevokedRefreshTokenRequiresAuth(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+	refreshToken, keyID, err := encryptToken(ctx, "refresh")
+	require.NoError(t, err)
+
+	repo := &mockRepository{}
+	client := &mockPatreonAPI{}
+	expiresAt := time.Now().Add(-time.Hour)
+	repo.On("GetPatreonToken", ctx, "user_1").Return(&PatreonToken{
+		UserID: "user_1", RefreshToken: refreshToken, EncryptionKeyID: keyID, ExpiresAt: &expiresAt,
+	}, nil)
+	client.On("RefreshToken", ctx, "refresh").Return(nil, &patreon.APIError{StatusCode: http.StatusUnauthorized})
+
+	err = NewPatreonCollector(repo, client).CollectUser(ctx, "user_1")
+	assert.ErrorIs(t, err, ErrPatreonAuthRequired)
+}
@@ -0,0 +1,235 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Periods warmed after collection, matching the dashboard's default requests
+var (
+	warmedOverviewDays = []int{7}
+	warmedEnhancedDays = []int{30, 90}
+	warmedChartDays    = []int{30}
+)
+
+// payloadCache keeps computed dashboard payloads per user and period. Every
+// eviction of a user bumps their generation, and a payload computed before it
+// is dropped rather than stored, so a slow computation can't put data from
+// before a collection back.
+type payloadCache struct {
+	overviews *cache.Memory[*DashboardOverview]
+	enhanced  *cache.Memory[*EnhancedAnalytics]
+	charts    *cache.Memory[*AnalyticsChartData]
+
+	mu          sync.Mutex
+	generations map[string]uint64
+}
+
+// newPayloadCache returns nil, which caches nothing, when the TTL is zero
+func newPayloadCache(cfg config.AnalyticsCacheConfig) *payloadCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	return &payloadCache{
+		overviews:   cache.NewMemory[*DashboardOverview](cfg.TTL, cfg.MaxEntries),
+		enhanced:    cache.NewMemory[*EnhancedAnalytics](cfg.TTL, cfg.MaxEntries),
+		charts:      cache.NewMemory[*AnalyticsChartData](cfg.TTL, cfg.MaxEntries),
+		generations: make(map[string]uint64),
+	}
+}
+
+func payloadKey(userID string, days int) string {
+	return fmt.Sprintf("%s:%d", userID, days)
+}
+
+func (p *payloadCache) generation(userID string) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.generations[userID]
+}
+
+// evict drops everything cached for the user
+func (p *payloadCache) evict(userID string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.generations[userID]++
+	p.mu.Unlock()
+
+	prefix := userID + ":"
+	p.overviews.DeleteFunc(func(key string, _ *DashboardOverview) bool { return strings.HasPrefix(key, prefix) })
+	p.enhanced.DeleteFunc(func(key string, _ *EnhancedAnalytics) bool { return strings.HasPrefix(key, prefix) })
+	p.charts.DeleteFunc(func(key string, _ *AnalyticsChartData) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// cachedPayload returns the cached payload, or loads and caches it. Identical
+// concurrent loads are shared, see inflight. With refresh the cached payload
+// is ignored and replaced.
+func cachedPayload[V any](ctx context.Context, s *service, memory *cache.Memory[V], kind, userID string, days int, refresh bool, load func(ctx context.Context) (V, error)) (V, error) {
+	key := payloadKey(userID, days)
+	if memory != nil && !refresh {
+		if value, ok := memory.GetContext(ctx, key); ok {
+			return value, nil
+		}
+	}
+
+	// Requests after an eviction don't join loads started before it
+	var generation uint64
+	if s.payloads != nil {
+		generation = s.payloads.generation(userID)
+	}
+	flight := fmt.Sprintf("%s:%d:%d", kind, days, generation)
+	if refresh {
+		flight = "warm:" + flight
+	}
+
+	value, err := s.flights.do(ctx, userID, flight, func(ctx context.Context) (any, error) {
+		value, err := load(ctx)
+		if err != nil || s.payloads == nil {
+			return value, err
+		}
+
+		s.payloads.mu.Lock()
+		if s.payloads.generations[userID] == generation {
+			memory.Set(key, value)
+		}
+		s.payloads.mu.Unlock()
+		return value, nil
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return value.(V), nil
+}
+
+// The caches of each payload kind, nil when caching is disabled
+
+func (p *payloadCache) overviewCache() *cache.Memory[*DashboardOverview] {
+	if p == nil {
+		return nil
+	}
+	return p.overviews
+}
+
+func (p *payloadCache) enhancedCache() *cache.Memory[*EnhancedAnalytics] {
+	if p == nil {
+		return nil
+	}
+	return p.enhanced
+}
+
+func (p *payloadCache) chartCache() *cache.Memory[*AnalyticsChartData] {
+	if p == nil {
+		return nil
+	}
+	return p.charts
+}
+
+// EvictUser drops the user's cached dashboard payloads
+func (s *service) EvictUser(userID string) {
+	s.payloads.evict(userID)
+}
+
+// WarmUser computes the user's overview, enhanced analytics and chart
+// payloads for the dashboard's default periods and caches them, so the first
+// dashboard visit after a collection doesn't wait for the queries
+func (s *service) WarmUser(ctx context.Context, userID string) error {
+	if s.payloads == nil {
+		return nil
+	}
+
+	for _, days := range warmedOverviewDays {
+		if _, err := cachedPayload(ctx, s, s.payloads.overviewCache(), "overview", userID, days, true, func(ctx context.Context) (*DashboardOverview, error) {
+			return s.loadDashboardOverview(ctx, userID, days)
+		}); err != nil {
+			return fmt.Errorf("failed to warm overview: %w", err)
+		}
+	}
+	for _, days := range warmedEnhancedDays {
+		if _, err := cachedPayload(ctx, s, s.payloads.enhancedCache(), "enhanced", userID, days, true, func(ctx context.Context) (*EnhancedAnalytics, error) {
+			return s.loadEnhancedAnalytics(ctx, userID, days)
+		}); err != nil {
+			return fmt.Errorf("failed to warm enhanced analytics: %w", err)
+		}
+	}
+	for _, days := range warmedChartDays {
+		if _, err := cachedPayload(ctx, s, s.payloads.chartCache(), "charts", userID, days, true, func(ctx context.Context) (*AnalyticsChartData, error) {
+			return s.loadAnalyticsChartData(ctx, userID, days)
+		}); err != nil {
+			return fmt.Errorf("failed to warm chart data: %w", err)
+		}
+	}
+	return nil
+}
+
+// WarmAllUsers warms the payloads of every collectable user in the background
+// and returns the "cache_warmup" job tracking it. Caches are per replica, so
+// only the replica running it is warmed.
+func (s *service) WarmAllUsers(ctx context.Context) (*AnalyticsJob, error) {
+	if s.payloads == nil {
+		return nil, fmt.Errorf("the analytics cache is disabled (ANALYTICS_CACHE_TTL=0)")
+	}
+
+	users, err := s.repo.ListCollectableUserIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users to warm: %w", err)
+	}
+
+	job := &AnalyticsJob{
+		JobType:       "cache_warmup",
+		Status:        "running",
+		ProgressTotal: len(users),
+	}
+	if err := s.repo.CreateAnalyticsJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create cache warm-up job: %w", err)
+	}
+
+	go s.warmUsers(context.WithoutCancel(ctx), job, users)
+	return job, nil
+}
+
+func (s *service) warmUsers(ctx context.Context, job *AnalyticsJob, users []string) {
+	workers := max(config.AnalyticsCache().WarmUpWorkers, 1)
+	progress := &runProgress{total: len(users)}
+	queue := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range queue {
+				err := s.WarmUser(ctx, userID)
+				if err != nil {
+					log.Printf("Failed to warm analytics cache for user %s: %v", userID, err)
+				}
+				if completed, failed, report := progress.record(err); report {
+					if err := s.repo.UpdateAnalyticsJobProgress(ctx, job.ID, progress.total, completed, failed); err != nil {
+						log.Printf("Failed to save cache warm-up progress: %v", err)
+					}
+				}
+			}
+		}()
+	}
+	for _, userID := range users {
+		queue <- userID
+	}
+	close(queue)
+	wg.Wait()
+
+	completed, failed := progress.counts()
+	if err := s.repo.UpdateAnalyticsJobProgress(ctx, job.ID, progress.total, completed, failed); err != nil {
+		log.Printf("Failed to save cache warm-up progress: %v", err)
+	}
+	s.repo.UpdateAnalyticsJob(ctx, job.ID, "completed", nil)
+	log.Printf("🔥 Warmed analytics cache for %d of %d users (%d failed)", completed, progress.total, failed)
+}
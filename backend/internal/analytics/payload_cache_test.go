@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+func newCachingTestService() (*service, *mockRepository) {
+	svc, repo, _ := newTestService()
+	svc.payloads = newPayloadCache(config.AnalyticsCacheConfig{TTL: time.Minute, MaxEntries: 100})
+	return svc, repo
+}
+
+func TestDashboardPayloadsAreCachedUntilEvicted(t *testing.T) {
+	svc, repo := newCachingTestService()
+	ctx := context.Background()
+
+	repo.On("GetEnhancedAnalytics", ctx, "user_1", 30).Return(&EnhancedAnalytics{
+		Overview: VideoBasedOverview{VideoCount: 1, TotalViews: 10},
+	}, nil).Twice()
+
+	first, err := svc.GetEnhancedAnalytics(ctx, "user_1", 30)
+	require.NoError(t, err)
+	second, err := svc.GetEnhancedAnalytics(ctx, "user_1", 30)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	repo.AssertNumberOfCalls(t, "GetEnhancedAnalytics", 1)
+
+	svc.EvictUser("user_1")
+	_, err = svc.GetEnhancedAnalytics(ctx, "user_1", 30)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetEnhancedAnalytics", 2)
+}
+
+func TestWarmUserCachesDefaultPeriods(t *testing.T) {
+	svc, repo := newCachingTestService()
+	ctx := context.Background()
+
+	repo.On("GetDashboardOverview", ctx, "user_1", 7).Return(&DashboardOverview{CurrentFollowers: 5}, nil).Once()
+	repo.On("GetChannelConsistency", ctx, "user_1").Return(nil, nil).Once()
+	for _, days := range []int{30, 90} {
+		repo.On("GetEnhancedAnalytics", ctx, "user_1", days).Return(&EnhancedAnalytics{
+			Overview: VideoBasedOverview{VideoCount: 1},
+		}, nil).Once()
+	}
+	repo.On("GetAnalyticsChartData", ctx, "user_1", 30).Return(&AnalyticsChartData{
+		FollowerGrowth: []ChartDataPoint{{Value: 5}},
+	}, nil).Once()
+
+	require.NoError(t, svc.WarmUser(ctx, "user_1"))
+	repo.AssertExpectations(t)
+
+	// Served from the cache, the mocks above allow one call each
+	overview, err := svc.GetDashboardOverview(ctx, "user_1", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 5, overview.CurrentFollowers)
+	_, err = svc.GetEnhancedAnalytics(ctx, "user_1", 90)
+	require.NoError(t, err)
+	_, err = svc.GetAnalyticsChartData(ctx, "user_1", 30)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetEnhancedAnalytics", 2)
+}
+
+func TestPayloadLoadedBeforeEvictionIsNotCached(t *testing.T) {
+	svc, repo := newCachingTestService()
+	ctx := context.Background()
+
+	// Collection finishes while the stale payload is being computed
+	repo.On("GetAnalyticsChartData", ctx, "user_1", 30).Run(func(mock.Arguments) {
+		svc.EvictUser("user_1")
+	}).Return(&AnalyticsChartData{FollowerGrowth: []ChartDataPoint{{Value: 1}}}, nil).Once()
+	_, err := svc.GetAnalyticsChartData(ctx, "user_1", 30)
+	require.NoError(t, err)
+
+	repo.On("GetAnalyticsChartData", ctx, "user_1", 30).Return(&AnalyticsChartData{FollowerGrowth: []ChartDataPoint{{Value: 2}}}, nil).Once()
+	data, err := svc.GetAnalyticsChartData(ctx, "user_1", 30)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, data.FollowerGrowth[0].Value)
+}
+
+func TestWarmUserWithoutCacheDoesNothing(t *testing.T) {
+	svc, repo, _ := newTestService()
+	require.NoError(t, svc.WarmUser(context.Background(), "user_1"))
+	repo.AssertExpectations(t)
+}
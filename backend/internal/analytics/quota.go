@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrCollectionQuotaExceeded means the user ran all of today's manual collections
+var ErrCollectionQuotaExceeded = errors.New("daily manual collection limit reached")
+
+// CollectionQuotaStatus is a user's manual collections for the current UTC day
+type CollectionQuotaStatus struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// CollectionQuota limits how many manual collections (POST /collect and
+// /refresh) a user may run per UTC day. Scheduled collections don't go
+// through it. A nil quota, or one with a limit of 0, allows everything.
+type CollectionQuota struct {
+	repo       Repository
+	dailyLimit int
+	now        func() time.Time
+}
+
+func NewCollectionQuota(repo Repository, dailyLimit int) *CollectionQuota {
+	return &CollectionQuota{
+		repo:       repo,
+		dailyLimit: dailyLimit,
+		now:        time.Now,
+	}
+}
+
+// Take uses one of the user's manual collections for today. When none are
+// left it returns ErrCollectionQuotaExceeded with the status. The status is
+// nil without a limit.
+func (q *CollectionQuota) Take(ctx context.Context, userID string) (*CollectionQuotaStatus, error) {
+	if q == nil || q.dailyLimit <= 0 {
+		return nil, nil
+	}
+
+	day := q.today()
+	used, ok, err := q.repo.TakeManualCollection(ctx, userID, day, q.dailyLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CollectionQuotaStatus{
+		Limit:     q.dailyLimit,
+		Used:      used,
+		Remaining: max(q.dailyLimit-used, 0),
+		ResetsAt:  day.AddDate(0, 0, 1),
+	}
+	if !ok {
+		return status, ErrCollectionQuotaExceeded
+	}
+	return status, nil
+}
+
+// Release gives back a collection taken today that failed before doing
+// anything, so the user can try again
+func (q *CollectionQuota) Release(ctx context.Context, userID string) {
+	if q == nil || q.dailyLimit <= 0 {
+		return
+	}
+
+	if err := q.repo.ReleaseManualCollection(ctx, userID, q.today()); err != nil {
+		log.Printf("Failed to release manual collection of user %s: %v", userID, err)
+	}
+}
+
+func (q *CollectionQuota) today() time.Time {
+	return q.now().UTC().Truncate(24 * time.Hour)
+}
+
+// released is the status after the collection it was taken for is given back
+func (s *CollectionQuotaStatus) released() *CollectionQuotaStatus {
+	if s == nil || s.Used == 0 {
+		return s
+	}
+	released := *s
+	released.Used--
+	released.Remaining = max(released.Limit-released.Used, 0)
+	return &released
+}
+
+// setQuotaHeaders sends the user's remaining manual collections. They have
+// their own headers, the X-RateLimit ones belong to the API key rate limit.
+// Nothing is sent without a limit.
+func setQuotaHeaders(c *fiber.Ctx, status *CollectionQuotaStatus) {
+	if status == nil {
+		return
+	}
+
+	c.Set("X-Collection-Quota-Limit", strconv.Itoa(status.Limit))
+	c.Set("X-Collection-Quota-Remaining", strconv.Itoa(status.Remaining))
+	c.Set("X-Collection-Quota-Reset", strconv.FormatInt(status.ResetsAt.Unix(), 10))
+}
+
+// collectionQuotaExceeded answers a manual collection past the user's quota
+func collectionQuotaExceeded(c *fiber.Ctx, status *CollectionQuotaStatus) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(time.Until(status.ResetsAt).Seconds())+1))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error": "You've used all of today's manual refreshes",
+		"quota": status,
+	})
+}
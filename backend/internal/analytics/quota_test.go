@@ -0,0 +1,108 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuota(repo Repository, limit int, now time.Time) *CollectionQuota {
+	quota := NewCollectionQuota(repo, limit)
+	quota.now = func() time.Time { return now }
+	return quota
+}
+
+func TestCollectionQuotaTake(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mockRepository{}
+	repo.On("TakeManualCollection", ctx, "user_1", day, 10).Return(3, true, nil).Once()
+
+	status, err := newTestQuota(repo, 10, now).Take(ctx, "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, &CollectionQuotaStatus{Limit: 10, Used: 3, Remaining: 7, ResetsAt: day.AddDate(0, 0, 1)}, status)
+
+	repo.On("TakeManualCollection", ctx, "user_1", day, 10).Return(10, false, nil).Once()
+	status, err = newTestQuota(repo, 10, now).Take(ctx, "user_1")
+	assert.ErrorIs(t, err, ErrCollectionQuotaExceeded)
+	require.NotNil(t, status)
+	assert.Zero(t, status.Remaining)
+	repo.AssertExpectations(t)
+}
+
+func TestCollectionQuotaUsesUTCDays(t *testing.T) {
+	ctx := context.Background()
+	// Still May 1st in New York, already May 2nd in UTC
+	newYork := time.FixedZone("EDT", -4*60*60)
+	now := time.Date(2024, 5, 1, 22, 0, 0, 0, newYork)
+	day := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	repo := &mockRepository{}
+	repo.On("TakeManualCollection", ctx, "user_1", day, 1).Return(1, true, nil)
+	repo.On("ReleaseManualCollection", ctx, "user_1", day).Return(nil)
+
+	quota := newTestQuota(repo, 1, now)
+	_, err := quota.Take(ctx, "user_1")
+	require.NoError(t, err)
+	quota.Release(ctx, "user_1")
+	repo.AssertExpectations(t)
+}
+
+func TestCollectionQuotaWithoutLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{}
+
+	var none *CollectionQuota
+	status, err := none.Take(ctx, "user_1")
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	none.Release(ctx, "user_1")
+
+	status, err = NewCollectionQuota(repo, 0).Take(ctx, "user_1")
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	repo.AssertNotCalled(t, "TakeManualCollection")
+}
+
+func TestCollectionQuotaDatabaseError(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{}
+	repo.On("TakeManualCollection", ctx, "user_1", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 10).Return(0, false, errors.New("connection refused"))
+
+	status, err := newTestQuota(repo, 10, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Take(ctx, "user_1")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.NotErrorIs(t, err, ErrCollectionQuotaExceeded)
+	assert.Nil(t, status)
+}
+
+func TestReleasedQuotaStatus(t *testing.T) {
+	resetsAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	status := &CollectionQuotaStatus{Limit: 10, Used: 10, Remaining: 0, ResetsAt: resetsAt}
+	assert.Equal(t, &CollectionQuotaStatus{Limit: 10, Used: 9, Remaining: 1, ResetsAt: resetsAt}, status.released())
+	assert.Equal(t, 10, status.Used, "the taken status is unchanged")
+
+	var none *CollectionQuotaStatus
+	assert.Nil(t, none.released())
+}
+
+func TestQuotaHeadersDontOverlapAPIKeyRateLimit(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-RateLimit-Remaining", "59")
+		setQuotaHeaders(c, &CollectionQuotaStatus{Limit: 10, Used: 3, Remaining: 7, ResetsAt: time.Unix(1714608000, 0)})
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "59", resp.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "10", resp.Header.Get("X-Collection-Quota-Limit"))
+	assert.Equal(t, "7", resp.Header.Get("X-Collection-Quota-Remaining"))
+	assert.Equal(t, "1714608000", resp.Header.Get("X-Collection-Quota-Reset"))
+}
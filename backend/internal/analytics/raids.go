@@ -0,0 +1,175 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+const (
+	// raidConversionWindow is how long after an incoming raid follows are credited to it
+	raidConversionWindow = time.Hour
+	// topRaidPartners is how many sources and destinations are listed
+	topRaidPartners = 10
+	// recentRaidsLimit is how many raids are listed individually
+	recentRaidsLimit = 20
+)
+
+// SaveRaidEvent stores a raid for whichever side of it belongs to one of our users
+func SaveRaidEvent(ctx context.Context, repo Repository, messageID string, event *twitch.RaidEvent) error {
+	raidedAt := time.Now().UTC()
+
+	sides := []struct {
+		ownerTwitchID string
+		raid          Raid
+	}{
+		{event.ToBroadcasterUserID, Raid{
+			Direction:    "incoming",
+			PartnerID:    event.FromBroadcasterUserID,
+			PartnerLogin: event.FromBroadcasterUserLogin,
+			PartnerName:  event.FromBroadcasterUserName,
+		}},
+		{event.FromBroadcasterUserID, Raid{
+			Direction:    "outgoing",
+			PartnerID:    event.ToBroadcasterUserID,
+			PartnerLogin: event.ToBroadcasterUserLogin,
+			PartnerName:  event.ToBroadcasterUserName,
+		}},
+	}
+
+	for _, side := range sides {
+		userID, err := repo.GetUserIDByTwitchID(ctx, side.ownerTwitchID)
+		if err != nil {
+			return fmt.Errorf("failed to look up broadcaster: %w", err)
+		}
+		if userID == "" {
+			continue
+		}
+
+		raid := side.raid
+		raid.UserID = userID
+		raid.MessageID = messageID
+		raid.Viewers = event.Viewers
+		raid.RaidedAt = raidedAt
+		if err := repo.SaveRaid(ctx, &raid); err != nil {
+			return fmt.Errorf("failed to save %s raid: %w", raid.Direction, err)
+		}
+	}
+
+	return nil
+}
+
+// SaveFollowEvent records the time of a follow delivered by EventSub
+func SaveFollowEvent(ctx context.Context, repo Repository, messageID string, event *twitch.FollowEvent) error {
+	userID, err := repo.GetUserIDByTwitchID(ctx, event.BroadcasterUserID)
+	if err != nil {
+		return fmt.Errorf("failed to look up broadcaster: %w", err)
+	}
+	if userID == "" {
+		return nil
+	}
+	return repo.SaveFollowEvent(ctx, userID, messageID, event.FollowedAt)
+}
+
+// GetRaidAnalytics summarizes raids over the last days
+func (s *service) GetRaidAnalytics(ctx context.Context, userID string, days int) (*RaidAnalytics, error) {
+	sub, err := s.repo.GetEventSubSubscription(ctx, userID, eventSubRaidIncoming)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EventSub subscription: %w", err)
+	}
+
+	raids, err := s.repo.GetRaids(ctx, userID, time.Now().AddDate(0, 0, -days), raidConversionWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raids: %w", err)
+	}
+
+	result := buildRaidAnalytics(raids)
+	result.Days = days
+	result.Tracking = sub != nil && sub.Status == "enabled"
+	return result, nil
+}
+
+// buildRaidAnalytics totals raids (newest first) and ranks partners by raid count
+func buildRaidAnalytics(raids []Raid) *RaidAnalytics {
+	result := &RaidAnalytics{
+		TopSources:      []RaidPartner{},
+		TopDestinations: []RaidPartner{},
+		RecentRaids:     []Raid{},
+	}
+
+	sources := make(map[string]*RaidPartner)
+	destinations := make(map[string]*RaidPartner)
+
+	for _, raid := range raids {
+		partners := destinations
+		if raid.Direction == "incoming" {
+			result.IncomingRaids++
+			result.IncomingViewers += raid.Viewers
+			result.FollowersGained += raid.FollowersGained
+			partners = sources
+		} else {
+			result.OutgoingRaids++
+			result.OutgoingViewers += raid.Viewers
+		}
+
+		partner, ok := partners[raid.PartnerID]
+		if !ok {
+			// Raids are newest first, so the first one has the current name
+			partner = &RaidPartner{
+				PartnerID:    raid.PartnerID,
+				PartnerLogin: raid.PartnerLogin,
+				PartnerName:  raid.PartnerName,
+			}
+			partners[raid.PartnerID] = partner
+		}
+		partner.Raids++
+		partner.Viewers += raid.Viewers
+		partner.FollowersGained += raid.FollowersGained
+	}
+
+	result.ConversionRate = conversionRate(result.FollowersGained, result.IncomingViewers)
+	result.TopSources = rankRaidPartners(sources)
+	for i := range result.TopSources {
+		result.TopSources[i].ConversionRate = conversionRate(result.TopSources[i].FollowersGained, result.TopSources[i].Viewers)
+	}
+	result.TopDestinations = rankRaidPartners(destinations)
+
+	if len(raids) > recentRaidsLimit {
+		raids = raids[:recentRaidsLimit]
+	}
+	result.RecentRaids = append(result.RecentRaids, raids...)
+
+	return result
+}
+
+func rankRaidPartners(partners map[string]*RaidPartner) []RaidPartner {
+	ranked := make([]RaidPartner, 0, len(partners))
+	for _, partner := range partners {
+		ranked = append(ranked, *partner)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Raids != ranked[j].Raids {
+			return ranked[i].Raids > ranked[j].Raids
+		}
+		if ranked[i].Viewers != ranked[j].Viewers {
+			return ranked[i].Viewers > ranked[j].Viewers
+		}
+		return ranked[i].PartnerLogin < ranked[j].PartnerLogin
+	})
+	if len(ranked) > topRaidPartners {
+		ranked = ranked[:topRaidPartners]
+	}
+	return ranked
+}
+
+// conversionRate is the percentage of raid viewers who followed, to two decimals
+func conversionRate(followers, viewers int) float64 {
+	if viewers == 0 {
+		return 0
+	}
+	return math.Round(float64(followers)/float64(viewers)*10000) / 100
+}
@@ -0,0 +1,43 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRaidAnalytics(t *testing.T) {
+	raids := []Raid{
+		{Direction: "incoming", PartnerID: "1", PartnerLogin: "alice", Viewers: 100, FollowersGained: 10},
+		{Direction: "outgoing", PartnerID: "2", PartnerLogin: "bob", Viewers: 40},
+		{Direction: "incoming", PartnerID: "3", PartnerLogin: "carol", Viewers: 300, FollowersGained: 3},
+		{Direction: "incoming", PartnerID: "1", PartnerLogin: "alice_old", Viewers: 50, FollowersGained: 5},
+	}
+
+	result := buildRaidAnalytics(raids)
+
+	assert.Equal(t, 3, result.IncomingRaids)
+	assert.Equal(t, 450, result.IncomingViewers)
+	assert.Equal(t, 1, result.OutgoingRaids)
+	assert.Equal(t, 40, result.OutgoingViewers)
+	assert.Equal(t, 18, result.FollowersGained)
+	assert.Equal(t, 4.0, result.ConversionRate)
+
+	require.Len(t, result.TopSources, 2)
+	assert.Equal(t, "alice", result.TopSources[0].PartnerLogin)
+	assert.Equal(t, 2, result.TopSources[0].Raids)
+	assert.Equal(t, 10.0, result.TopSources[0].ConversionRate)
+	assert.Equal(t, "carol", result.TopSources[1].PartnerLogin)
+
+	require.Len(t, result.TopDestinations, 1)
+	assert.Equal(t, "bob", result.TopDestinations[0].PartnerLogin)
+	assert.Len(t, result.RecentRaids, 4)
+}
+
+func TestBuildRaidAnalyticsEmpty(t *testing.T) {
+	result := buildRaidAnalytics(nil)
+	assert.Zero(t, result.ConversionRate)
+	assert.NotNil(t, result.TopSources)
+	assert.NotNil(t, result.RecentRaids)
+}
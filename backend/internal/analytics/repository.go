@@ -3,8 +3,11 @@ package analytics
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -14,20 +17,73 @@ type Repository interface {
 	// User Management
 	CreateOrUpdateUser(ctx context.Context, user *User) error
 	GetUserByClerkID(ctx context.Context, clerkUserID string) (*User, error)
+	GetUserLocale(ctx context.Context, clerkUserID string) (string, error)
+	SetUserLocale(ctx context.Context, clerkUserID, locale string) error
+	GetUserCurrency(ctx context.Context, userID string) (string, error)
+	ListCollectableUserIDs(ctx context.Context) ([]string, error)
+	ReconcileUsersFromTokens(ctx context.Context) (int64, error)
+
+	// Twitch Tokens
+	SaveTwitchToken(ctx context.Context, token *TwitchToken) error
+	GetTwitchToken(ctx context.Context, userID string) (*TwitchToken, error)
+	DeleteTwitchToken(ctx context.Context, userID string) error
+
+	// Key rotation over every table in TokenTables
+	ListTokensNotUsingKey(ctx context.Context, table, keyID, afterUserID string, limit int) ([]EncryptedToken, error)
+	UpdateTokenEncryption(ctx context.Context, table string, token *EncryptedToken) (bool, error)
 
 	// Channel Analytics
 	SaveChannelAnalytics(ctx context.Context, analytics *ChannelAnalytics) error
 	GetChannelAnalytics(ctx context.Context, userID string, days int) ([]ChannelAnalytics, error)
 	GetLatestChannelAnalytics(ctx context.Context, userID string) (*ChannelAnalytics, error)
+	BackfillChannelAnalytics(ctx context.Context, rows []ChannelAnalytics) (int, error)
+	GetEarliestChannelAnalytics(ctx context.Context, userID string) (*ChannelAnalytics, error)
 
 	// Stream Sessions
 	SaveStreamSession(ctx context.Context, session *StreamSession) error
 	GetStreamSessions(ctx context.Context, userID string, limit int) ([]StreamSession, error)
 	GetStreamSessionsByDateRange(ctx context.Context, userID string, start, end time.Time) ([]StreamSession, error)
+	// ImportStreamSessions inserts sessions whose stream ID isn't stored yet
+	// and returns the number inserted
+	ImportStreamSessions(ctx context.Context, sessions []StreamSession) (int, error)
+	GetStreamSessionDetail(ctx context.Context, userID, streamID string) (*StreamSessionDetail, error)
+	LinkVideosToStreamSessions(ctx context.Context, userID string) (int, error)
 
 	// Video Analytics
 	SaveVideoAnalytics(ctx context.Context, video *VideoAnalytics) error
+	GetVideoMuting(ctx context.Context, userID string, since time.Time) ([]MutedVideo, error)
+
+	// Highlight Suggestions
+	GetClipMoments(ctx context.Context, userID string, since time.Time) ([]ClipMoment, error)
+	ReplaceHighlightSuggestions(ctx context.Context, userID string, suggestions []HighlightSuggestion) error
+	GetHighlightSuggestions(ctx context.Context, userID, videoID string, limit int) ([]HighlightSuggestion, error)
+
+	// Repurposing
+	GetRecentClips(ctx context.Context, userID string, since time.Time) ([]ClipReadiness, error)
+	GetVideoDayViews(ctx context.Context, userID string, days int) ([]VideoDayViews, error)
+	GetLanguageShares(ctx context.Context, userID string, since time.Time) ([]LanguageShare, error)
+	GetBroadcasterLanguage(ctx context.Context, userID string) (string, error)
+
+	// Collaborations
+	GetCollabContent(ctx context.Context, userID string, since time.Time) ([]CollabContent, error)
+	SetCollaborators(ctx context.Context, userID, contentType, contentID string, logins []string) (bool, error)
+	DeleteCollaborators(ctx context.Context, userID, contentType, contentID string) (bool, error)
+
+	// Title experiments
+	ListTitleExperiments(ctx context.Context, userID string) ([]TitleExperiment, error)
+	GetTitleExperiment(ctx context.Context, userID string, id int) (*TitleExperiment, error)
+	CreateTitleExperiment(ctx context.Context, experiment *TitleExperiment) error
+	DeleteTitleExperiment(ctx context.Context, userID string, id int) (bool, error)
+	AssignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID, variant string) (bool, error)
+	UnassignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID string) (bool, error)
+	GetExperimentVideos(ctx context.Context, userID string) ([]ExperimentVideo, error)
+	GetVideo(ctx context.Context, userID, videoID string) (*VideoAnalytics, error)
 	GetVideoAnalytics(ctx context.Context, userID string, limit int) ([]VideoAnalytics, error)
+	GetVideoViewCounts(ctx context.Context, userID string) ([]VideoViewCount, error)
+	GetTopVideos(ctx context.Context, userID, videoType string, limit int) ([]VideoAnalytics, error)
+	GetTopVideosByViewsPerDay(ctx context.Context, userID, videoType string, limit int) ([]VideoAnalytics, error)
+	ListVideos(ctx context.Context, userID string, query VideoListQuery) ([]VideoAnalytics, int, error)
+	GetKeywordInsights(ctx context.Context, userID string, days, minVideos, limit int) (*KeywordInsights, error)
 	UpdateVideoAnalytics(ctx context.Context, videoID string, views, likes, comments int) error
 
 	// Game Analytics
@@ -35,7 +91,7 @@ type Repository interface {
 	GetTopGames(ctx context.Context, userID string, limit int) ([]GameAnalytics, error)
 
 	// Dashboard Data
-	GetDashboardOverview(ctx context.Context, userID string) (*DashboardOverview, error)
+	GetDashboardOverview(ctx context.Context, userID string, days int) (*DashboardOverview, error)
 	GetAnalyticsChartData(ctx context.Context, userID string, days int) (*AnalyticsChartData, error)
 	GetDetailedAnalytics(ctx context.Context, userID string) (*DetailedAnalytics, error)
 	GetEnhancedAnalytics(ctx context.Context, userID string, days int) (*EnhancedAnalytics, error)
@@ -43,7 +99,118 @@ type Repository interface {
 	// Jobs
 	CreateAnalyticsJob(ctx context.Context, job *AnalyticsJob) error
 	UpdateAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string) error
+	CompleteAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string, report []byte) error
+	UpdateAnalyticsJobProgress(ctx context.Context, jobID, total, completed, failed int) error
 	GetAnalyticsJobs(ctx context.Context, userID string, limit int) ([]AnalyticsJob, error)
+	GetLatestAnalyticsJob(ctx context.Context, userID, jobType, status string) (*AnalyticsJob, error)
+	SaveAnalyticsJobChanges(ctx context.Context, jobID int, changes []byte) error
+	// GetChangeSummaries returns the change summaries of the user's jobs
+	// created since, newest first
+	GetChangeSummaries(ctx context.Context, userID string, since time.Time, limit int) ([]ChangeSummary, error)
+	// CountUserRows counts the user's rows in each table archived on disconnect
+	CountUserRows(ctx context.Context, userID string) (map[string]int64, error)
+
+	// Retention
+	ApplyChannelAnalyticsRetention(ctx context.Context, cutoff time.Time, archive bool) (int64, error)
+	ApplyVideoDailyStatsRetention(ctx context.Context, cutoff time.Time, archive bool) (int64, error)
+	GetChannelAnalyticsRollups(ctx context.Context, userID, period string, limit int) ([]ChannelAnalyticsRollup, error)
+
+	// Disconnect Archives
+	CleanupTwitchAccount(ctx context.Context, jobID int, userID, twitchUserID string, expiresAt time.Time) (*DisconnectArchive, error)
+	ListOrphanedTwitchDataUsers(ctx context.Context) ([]string, error)
+	FailInterruptedAnalyticsJobs(ctx context.Context, jobType string, startedBefore time.Time) (int64, error)
+	GetRestorableDisconnectArchive(ctx context.Context, userID, twitchUserID string) (*DisconnectArchive, error)
+	RestoreDisconnectArchive(ctx context.Context, archiveID int64) (int64, error)
+	PurgeExpiredDisconnectArchives(ctx context.Context) (int64, error)
+
+	// Collection Retries
+	SaveCollectionRetry(ctx context.Context, retry *CollectionRetry) error
+	GetCollectionRetry(ctx context.Context, userID, jobType string) (*CollectionRetry, error)
+	ListCollectionRetries(ctx context.Context, userID string) ([]CollectionRetry, error)
+	GetDueCollectionRetries(ctx context.Context, now time.Time, limit int) ([]CollectionRetry, error)
+	DeleteCollectionRetry(ctx context.Context, userID, jobType string) error
+	// QueueInterruptedCollections queues collections stopped by a shutdown as
+	// retries due now, keeping their attempts. Parked retries stay parked.
+	QueueInterruptedCollections(ctx context.Context, userIDs []string, jobType string, options []byte) error
+	DeleteCollectionRetries(ctx context.Context, userID string) error
+
+	// Manual Collection Quotas
+	// TakeManualCollection counts a manual collection on day unless the user
+	// already ran limit of them that day, and returns how many they ran
+	TakeManualCollection(ctx context.Context, userID string, day time.Time, limit int) (int, bool, error)
+	ReleaseManualCollection(ctx context.Context, userID string, day time.Time) error
+
+	// Revenue
+	SaveRevenueSnapshot(ctx context.Context, revenue *RevenueAnalytics) error
+	SaveDailyBits(ctx context.Context, userID string, date time.Time, bits, revenueCents int) error
+	GetLatestRevenueAnalytics(ctx context.Context, userID string) (*RevenueAnalytics, error)
+	GetMonthlyRevenue(ctx context.Context, userID string, months int) ([]MonthlyRevenue, error)
+
+	// Patreon
+	SavePatreonToken(ctx context.Context, token *PatreonToken) error
+	GetPatreonToken(ctx context.Context, userID string) (*PatreonToken, error)
+	DeletePatreonConnection(ctx context.Context, userID string) error
+	SavePatreonRevenue(ctx context.Context, revenue *PatreonRevenue) error
+	GetLatestPatreonRevenue(ctx context.Context, userID string) (*PatreonRevenue, error)
+
+	// Streamlabs
+	SaveStreamlabsToken(ctx context.Context, token *StreamlabsToken) error
+	GetStreamlabsToken(ctx context.Context, userID string) (*StreamlabsToken, error)
+	DeleteStreamlabsConnection(ctx context.Context, userID string) error
+	SaveStreamlabsDonations(ctx context.Context, donations []StreamlabsDonation) (int, error)
+	GetLatestStreamlabsDonationID(ctx context.Context, userID string) (int64, error)
+
+	// Channel Points
+	SaveChannelPointRedemption(ctx context.Context, redemption *ChannelPointRedemption) error
+	GetTopRewards(ctx context.Context, userID string, since time.Time, limit int) ([]RewardStats, error)
+	GetRedemptionTrend(ctx context.Context, userID string, since time.Time) ([]RedemptionTrendPoint, error)
+
+	// Moderation
+	SaveModerationAction(ctx context.Context, action *ModerationAction) error
+	GetModerationActionsByDay(ctx context.Context, userID string, since time.Time) ([]ModerationActionDay, error)
+	SaveModerationDailyMetrics(ctx context.Context, metrics *ModerationDailyMetrics) error
+	GetModerationDailyMetrics(ctx context.Context, userID string, since time.Time) ([]ModerationDailyMetrics, error)
+
+	// Raids
+	SaveRaid(ctx context.Context, raid *Raid) error
+	SaveFollowEvent(ctx context.Context, userID, messageID string, followedAt time.Time) error
+	GetRaids(ctx context.Context, userID string, since time.Time, conversionWindow time.Duration) ([]Raid, error)
+
+	// EventSub
+	SaveEventSubSubscription(ctx context.Context, sub *EventSubSubscription) error
+	GetEventSubSubscription(ctx context.Context, userID, subType string) (*EventSubSubscription, error)
+	UpdateEventSubSubscriptionStatus(ctx context.Context, id, status string) error
+	GetUserIDByTwitchID(ctx context.Context, twitchUserID string) (string, error)
+
+	// Media Kits
+	CreateMediaKit(ctx context.Context, kit *MediaKit) error
+	CompleteMediaKit(ctx context.Context, id int, pdf []byte) error
+	FailMediaKit(ctx context.Context, id int, errorMessage string) error
+	GetMediaKit(ctx context.Context, userID string, id int) (*MediaKit, error)
+	GetPendingMediaKit(ctx context.Context, userID string) (*MediaKit, error)
+	GetMediaKitPDF(ctx context.Context, userID string, id int) ([]byte, error)
+	DeleteOldMediaKits(ctx context.Context, userID string, keep int) error
+
+	// Consistency
+	GetBroadcastTimes(ctx context.Context, userID string, since time.Time) (streams, uploads []time.Time, err error)
+	GetStreamActivity(ctx context.Context, userID string, since time.Time) (int, float64, error)
+	SaveChannelConsistency(ctx context.Context, consistency *ChannelConsistency) error
+	GetChannelConsistency(ctx context.Context, userID string) (*ChannelConsistency, error)
+
+	// Stream Schedule
+	SaveStreamSchedule(ctx context.Context, userID string, from time.Time, segments []StreamScheduleSegment) error
+	GetStreamSchedule(ctx context.Context, userID string, from, to time.Time) ([]StreamScheduleSegment, error)
+	GetScheduleTrackedSince(ctx context.Context, userID string) (*time.Time, error)
+
+	// Creator Goals
+	SaveCreatorGoals(ctx context.Context, userID string, date time.Time, goals []CreatorGoal) error
+	GetCreatorGoals(ctx context.Context, userID string, endedSince time.Time) ([]CreatorGoal, error)
+	GetCreatorGoalSnapshots(ctx context.Context, userID string, since time.Time) ([]CreatorGoalSnapshot, error)
+
+	// Weekly Insights
+	SaveWeeklyInsights(ctx context.Context, insights *WeeklyInsights) error
+	GetWeeklyInsights(ctx context.Context, userID string, limit int) ([]WeeklyInsights, error)
+	HasWeeklyInsights(ctx context.Context, userID string, weekStart time.Time) (bool, error)
 
 	// System Stats
 	GetSystemStats(ctx context.Context) (*SystemStats, error)
@@ -87,7 +254,7 @@ func (r *repository) CreateOrUpdateUser(ctx context.Context, user *User) error {
 
 func (r *repository) GetUserByClerkID(ctx context.Context, clerkUserID string) (*User, error) {
 	query := `
-		SELECT id, clerk_user_id, twitch_user_id, username, display_name, email, profile_image_url, created_at, updated_at
+		SELECT id, clerk_user_id, twitch_user_id, username, display_name, email, profile_image_url, locale, created_at, updated_at
 		FROM users 
 		WHERE clerk_user_id = $1
 	`
@@ -100,41 +267,208 @@ func (r *repository) GetUserByClerkID(ctx context.Context, clerkUserID string) (
 	return &user, err
 }
 
+// GetUserLocale returns the user's chosen locale, "" when unset or the user is unknown
+func (r *repository) GetUserLocale(ctx context.Context, clerkUserID string) (string, error) {
+	var locale string
+	err := r.db.GetContext(ctx, &locale, `SELECT locale FROM users WHERE clerk_user_id = $1`, clerkUserID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user locale: %w", err)
+	}
+	return locale, nil
+}
+
+// GetUserCurrency returns the currency saved in the user's dashboard
+// preferences, "" when none is saved
+func (r *repository) GetUserCurrency(ctx context.Context, userID string) (string, error) {
+	var currency string
+	query := `SELECT COALESCE(preferences->>'currency', '') FROM user_preferences WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &currency, query, userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user currency: %w", err)
+	}
+	return currency, nil
+}
+
+// SetUserLocale stores the user's locale, "" to clear it
+func (r *repository) SetUserLocale(ctx context.Context, clerkUserID, locale string) error {
+	query := `UPDATE users SET locale = $2, updated_at = NOW() WHERE clerk_user_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, clerkUserID, locale); err != nil {
+		return fmt.Errorf("failed to set user locale: %w", err)
+	}
+	return nil
+}
+
+// ListCollectableUserIDs returns the users connected to Twitch, through a
+// token stored by the OAuth flow or the Twitch account on their users row,
+// except those whose collection is parked after an auth failure
+func (r *repository) ListCollectableUserIDs(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT u.id
+		FROM users u
+		LEFT JOIN user_twitch_tokens t ON t.user_id = u.id
+		WHERE COALESCE(NULLIF(t.twitch_user_id, ''), NULLIF(u.twitch_user_id, '')) IS NOT NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM collection_retries cr
+			WHERE cr.user_id = u.id AND cr.parked_at IS NOT NULL AND cr.failure_kind = 'auth'
+		)
+		ORDER BY u.id
+	`
+
+	userIDs := []string{}
+	if err := r.db.SelectContext(ctx, &userIDs, query); err != nil {
+		return nil, fmt.Errorf("failed to list collectable users: %w", err)
+	}
+	return userIDs, nil
+}
+
+// ReconcileUsersFromTokens copies the Twitch account of each stored token onto
+// its users row where that is missing or out of date, e.g. after a token was
+// stored without the row being updated. It returns the rows it changed.
+func (r *repository) ReconcileUsersFromTokens(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE users u
+		SET twitch_user_id = t.twitch_user_id, updated_at = NOW()
+		FROM user_twitch_tokens t
+		WHERE t.user_id = u.id
+		AND t.twitch_user_id <> ''
+		AND u.twitch_user_id IS DISTINCT FROM t.twitch_user_id
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile users from tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Twitch Token Methods
+
+func (r *repository) SaveTwitchToken(ctx context.Context, token *TwitchToken) error {
+	query := `
+		INSERT INTO user_twitch_tokens (user_id, twitch_user_id, access_token, refresh_token, encryption_key_id, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) 
+		DO UPDATE SET 
+			twitch_user_id = EXCLUDED.twitch_user_id,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			encryption_key_id = EXCLUDED.encryption_key_id,
+			scopes = EXCLUDED.scopes,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.TwitchUserID, token.AccessToken, token.RefreshToken,
+		token.EncryptionKeyID, token.Scopes, token.ExpiresAt)
+	return err
+}
+
+func (r *repository) GetTwitchToken(ctx context.Context, userID string) (*TwitchToken, error) {
+	query := `
+		SELECT user_id, twitch_user_id, access_token, COALESCE(refresh_token, '') as refresh_token,
+			   encryption_key_id, scopes, expires_at, created_at, updated_at
+		FROM user_twitch_tokens 
+		WHERE user_id = $1
+	`
+
+	var token TwitchToken
+	err := r.db.GetContext(ctx, &token, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &token, err
+}
+
+func (r *repository) DeleteTwitchToken(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_twitch_tokens WHERE user_id = $1", userID)
+	return err
+}
+
+// ListTokensNotUsingKey pages through the tokens of one of the TokenTables
+// encrypted with any other key, ordered by user ID
+func (r *repository) ListTokensNotUsingKey(ctx context.Context, table, keyID, afterUserID string, limit int) ([]EncryptedToken, error) {
+	if !slices.Contains(TokenTables, table) {
+		return nil, fmt.Errorf("%s is not a token table", table)
+	}
+
+	query := `
+		SELECT user_id, access_token, COALESCE(refresh_token, '') as refresh_token, encryption_key_id, updated_at
+		FROM ` + table + `
+		WHERE encryption_key_id <> $1 AND user_id > $2
+		ORDER BY user_id
+		LIMIT $3
+	`
+
+	tokens := []EncryptedToken{}
+	err := r.db.SelectContext(ctx, &tokens, query, keyID, afterUserID, limit)
+	return tokens, err
+}
+
+// UpdateTokenEncryption replaces a token's ciphertexts and key ID. It returns
+// false without writing if the token changed since it was read (e.g. it was
+// refreshed), since the refresh already used the current key.
+func (r *repository) UpdateTokenEncryption(ctx context.Context, table string, token *EncryptedToken) (bool, error) {
+	if !slices.Contains(TokenTables, table) {
+		return false, fmt.Errorf("%s is not a token table", table)
+	}
+
+	query := `
+		UPDATE ` + table + `
+		SET access_token = $2, refresh_token = $3, encryption_key_id = $4
+		WHERE user_id = $1 AND updated_at = $5
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.AccessToken, token.RefreshToken, token.EncryptionKeyID, token.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
 // Channel Analytics Methods
 
 func (r *repository) SaveChannelAnalytics(ctx context.Context, analytics *ChannelAnalytics) error {
 	query := `
-		INSERT INTO channel_analytics (user_id, date, followers_count, following_count, total_views, subscriber_count)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO channel_analytics (user_id, date, followers_count, following_count, total_views, subscriber_count, broadcaster_language)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
 		ON CONFLICT (user_id, date) 
 		DO UPDATE SET 
 			followers_count = EXCLUDED.followers_count,
 			following_count = EXCLUDED.following_count,
 			total_views = EXCLUDED.total_views,
-			subscriber_count = EXCLUDED.subscriber_count
+			subscriber_count = EXCLUDED.subscriber_count,
+			source = 'collected',
+			broadcaster_language = COALESCE(EXCLUDED.broadcaster_language, channel_analytics.broadcaster_language)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		analytics.UserID, analytics.Date, analytics.FollowersCount,
-		analytics.FollowingCount, analytics.TotalViews, analytics.SubscriberCount)
+		analytics.FollowingCount, analytics.TotalViews, analytics.SubscriberCount, analytics.BroadcasterLanguage)
 	return err
 }
 
 func (r *repository) GetChannelAnalytics(ctx context.Context, userID string, days int) ([]ChannelAnalytics, error) {
 	query := `
-		SELECT id, user_id, date, followers_count, following_count, total_views, subscriber_count, created_at
+		SELECT id, user_id, date, followers_count, following_count, total_views, subscriber_count, source, created_at
 		FROM channel_analytics 
-		WHERE user_id = $1 AND date >= CURRENT_DATE - INTERVAL '%d days'
+		WHERE user_id = $1 AND date >= CURRENT_DATE - $2::int
 		ORDER BY date DESC
 	`
 
 	var analytics []ChannelAnalytics
-	err := r.db.SelectContext(ctx, &analytics, fmt.Sprintf(query, days), userID)
+	err := r.db.SelectContext(ctx, &analytics, query, userID, days)
 	return analytics, err
 }
 
 func (r *repository) GetLatestChannelAnalytics(ctx context.Context, userID string) (*ChannelAnalytics, error) {
 	query := `
-		SELECT id, user_id, date, followers_count, following_count, total_views, subscriber_count, created_at
+		SELECT id, user_id, date, followers_count, following_count, total_views, subscriber_count, source, created_at
 		FROM channel_analytics 
 		WHERE user_id = $1 
 		ORDER BY date DESC 
@@ -149,6 +483,58 @@ func (r *repository) GetLatestChannelAnalytics(ctx context.Context, userID strin
 	return &analytics, err
 }
 
+func (r *repository) GetEarliestChannelAnalytics(ctx context.Context, userID string) (*ChannelAnalytics, error) {
+	query := `
+		SELECT id, user_id, date, followers_count, following_count, total_views, subscriber_count, source, created_at
+		FROM channel_analytics
+		WHERE user_id = $1
+		ORDER BY date ASC
+		LIMIT 1
+	`
+
+	var analytics ChannelAnalytics
+	err := r.db.GetContext(ctx, &analytics, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &analytics, err
+}
+
+// BackfillChannelAnalytics inserts reconstructed historical rows without touching
+// days that already have real snapshots. Rows are stored with their Source,
+// backfill when it is empty. It returns the number of rows inserted.
+func (r *repository) BackfillChannelAnalytics(ctx context.Context, rows []ChannelAnalytics) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO channel_analytics (user_id, date, followers_count, following_count, total_views, subscriber_count, source)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'backfill'))
+		ON CONFLICT (user_id, date) DO NOTHING
+	`
+
+	inserted := 0
+	for _, row := range rows {
+		result, err := tx.ExecContext(ctx, query,
+			row.UserID, row.Date, row.FollowersCount,
+			row.FollowingCount, row.TotalViews, row.SubscriberCount, row.Source)
+		if err != nil {
+			return 0, err
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			inserted += int(affected)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
 // Stream Sessions Methods
 
 func (r *repository) SaveStreamSession(ctx context.Context, session *StreamSession) error {
@@ -206,26 +592,316 @@ func (r *repository) GetStreamSessionsByDateRange(ctx context.Context, userID st
 	return sessions, err
 }
 
+func (r *repository) ImportStreamSessions(ctx context.Context, sessions []StreamSession) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO stream_sessions (
+			user_id, stream_id, title, game_name, game_id, started_at, ended_at,
+			duration_minutes, peak_viewers, average_viewers, total_chatters,
+			followers_gained, subscribers_gained
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (stream_id) DO NOTHING
+	`
+
+	inserted := 0
+	for _, session := range sessions {
+		result, err := tx.ExecContext(ctx, query,
+			session.UserID, session.StreamID, session.Title, session.GameName, session.GameID,
+			session.StartedAt, session.EndedAt, session.DurationMinutes, session.PeakViewers,
+			session.AverageViewers, session.TotalChatters, session.FollowersGained, session.SubscribersGained)
+		if err != nil {
+			return 0, err
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			inserted += int(affected)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// GetStreamSessionDetail returns a stream session together with its linked VODs,
+// the clips created while it was live and the followers gained during it.
+// It returns nil if the session doesn't exist for the user.
+func (r *repository) GetStreamSessionDetail(ctx context.Context, userID, streamID string) (*StreamSessionDetail, error) {
+	sessionQuery := `
+		SELECT id, user_id, stream_id, title, game_name, game_id, started_at, ended_at,
+			   COALESCE(duration_minutes, 0) as duration_minutes, peak_viewers, average_viewers, total_chatters,
+			   followers_gained, subscribers_gained, created_at
+		FROM stream_sessions 
+		WHERE user_id = $1 AND stream_id = $2
+	`
+
+	var session StreamSession
+	err := r.db.GetContext(ctx, &session, sessionQuery, userID, streamID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &StreamSessionDetail{
+		Session: session,
+		VODs:    []VideoAnalytics{},
+	}
+
+	vodQuery := `
+		SELECT id, user_id, video_id, title, video_type, duration_seconds, view_count,
+			   like_count, comment_count, thumbnail_url, published_at, stream_session_id, created_at, updated_at
+		FROM video_analytics 
+		WHERE user_id = $1 AND stream_session_id = $2
+		ORDER BY published_at ASC
+	`
+	if err := r.db.SelectContext(ctx, &detail.VODs, vodQuery, userID, session.ID); err != nil {
+		return nil, fmt.Errorf("failed to get session VODs: %w", err)
+	}
+	for _, vod := range detail.VODs {
+		detail.VODViews += vod.ViewCount
+	}
+
+	if session.StartedAt == nil {
+		return detail, nil
+	}
+
+	end := time.Now()
+	if session.EndedAt != nil {
+		end = *session.EndedAt
+	} else if session.DurationMinutes > 0 {
+		end = session.StartedAt.Add(time.Duration(session.DurationMinutes) * time.Minute)
+	}
+
+	clipQuery := `
+		SELECT COUNT(*), COALESCE(SUM(view_count), 0)
+		FROM video_analytics 
+		WHERE user_id = $1 AND video_type = 'clip'
+		AND published_at >= $2 AND published_at <= $3
+	`
+	if err := r.db.QueryRowContext(ctx, clipQuery, userID, *session.StartedAt, end).Scan(
+		&detail.ClipCount, &detail.ClipViews); err != nil {
+		return nil, fmt.Errorf("failed to get session clips: %w", err)
+	}
+
+	detail.FollowersGained = session.FollowersGained
+	if detail.FollowersGained == 0 {
+		// Compare the last snapshot before the stream with the first one after it
+		followerQuery := `
+			SELECT 
+				(SELECT followers_count FROM channel_analytics 
+				 WHERE user_id = $1 AND date >= DATE($3) ORDER BY date ASC LIMIT 1) -
+				(SELECT followers_count FROM channel_analytics 
+				 WHERE user_id = $1 AND date < DATE($2) ORDER BY date DESC LIMIT 1)
+		`
+		var gained sql.NullInt64
+		if err := r.db.QueryRowContext(ctx, followerQuery, userID, *session.StartedAt, end).Scan(&gained); err != nil {
+			return nil, fmt.Errorf("failed to estimate followers gained: %w", err)
+		}
+		if gained.Valid {
+			detail.FollowersGained = int(gained.Int64)
+			detail.FollowersGainedEstimated = true
+		}
+	}
+
+	return detail, nil
+}
+
+// LinkVideosToStreamSessions attaches each archive VOD to the stream session its
+// recording overlaps the most. It returns the number of videos whose link changed.
+func (r *repository) LinkVideosToStreamSessions(ctx context.Context, userID string) (int, error) {
+	query := `
+		WITH vods AS (
+			SELECT video_id, published_at AS vod_start,
+				   published_at + make_interval(secs => COALESCE(duration_seconds, 0)) AS vod_end
+			FROM video_analytics
+			WHERE user_id = $1 AND video_type IN ('vod', 'archive') AND published_at IS NOT NULL
+		),
+		sessions AS (
+			SELECT id, started_at AS session_start,
+				   COALESCE(ended_at, started_at + make_interval(mins => COALESCE(duration_minutes, 0))) AS session_end
+			FROM stream_sessions
+			WHERE user_id = $1 AND started_at IS NOT NULL
+		),
+		matches AS (
+			SELECT DISTINCT ON (vods.video_id) vods.video_id, sessions.id AS session_id
+			FROM vods
+			JOIN sessions ON vods.vod_start <= sessions.session_end AND vods.vod_end >= sessions.session_start
+			ORDER BY vods.video_id,
+				LEAST(vods.vod_end, sessions.session_end) - GREATEST(vods.vod_start, sessions.session_start) DESC
+		)
+		UPDATE video_analytics v
+		SET stream_session_id = matches.session_id, updated_at = NOW()
+		FROM matches
+		WHERE v.video_id = matches.video_id
+		AND v.stream_session_id IS DISTINCT FROM matches.session_id
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	linked, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(linked), nil
+}
+
 // Video Analytics Methods
 
 func (r *repository) SaveVideoAnalytics(ctx context.Context, video *VideoAnalytics) error {
+	mutedSegments := video.MutedSegments
+	if mutedSegments == nil {
+		mutedSegments = []MutedSegment{}
+	}
+	mutedJSON, err := json.Marshal(mutedSegments)
+	if err != nil {
+		return fmt.Errorf("failed to encode muted segments: %w", err)
+	}
+	mutedSeconds := 0
+	for _, segment := range mutedSegments {
+		mutedSeconds += segment.Duration
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Muting is replaced on every save since Twitch unmutes segments after a
+	// successful appeal
 	query := `
 		INSERT INTO video_analytics (
 			user_id, video_id, title, video_type, duration_seconds, view_count,
-			like_count, comment_count, thumbnail_url, published_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			like_count, comment_count, thumbnail_url, published_at, muted_segments, muted_seconds,
+			source_video_id, vod_offset_seconds, language
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''), $14, NULLIF($15, ''))
 		ON CONFLICT (video_id) 
 		DO UPDATE SET 
 			title = EXCLUDED.title,
 			view_count = EXCLUDED.view_count,
 			like_count = EXCLUDED.like_count,
 			comment_count = EXCLUDED.comment_count,
+			duration_seconds = EXCLUDED.duration_seconds,
+			thumbnail_url = COALESCE(NULLIF(EXCLUDED.thumbnail_url, ''), video_analytics.thumbnail_url),
+			muted_segments = EXCLUDED.muted_segments,
+			muted_seconds = EXCLUDED.muted_seconds,
+			source_video_id = EXCLUDED.source_video_id,
+			vod_offset_seconds = EXCLUDED.vod_offset_seconds,
+			language = COALESCE(EXCLUDED.language, video_analytics.language),
 			updated_at = NOW()
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		video.UserID, video.VideoID, video.Title, video.VideoType, video.Duration,
-		video.ViewCount, video.LikeCount, video.CommentCount, video.ThumbnailURL, video.PublishedAt)
-	return err
+		video.ViewCount, video.LikeCount, video.CommentCount, video.ThumbnailURL, video.PublishedAt,
+		mutedJSON, mutedSeconds, video.SourceVideoID, video.VodOffset, video.Language)
+	if err != nil {
+		return err
+	}
+
+	// Every save also snapshots the counts of the (UTC) day, which the decay
+	// curves are built from
+	statsQuery := `
+		INSERT INTO video_daily_stats (video_id, date, view_count, like_count, comment_count)
+		VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, $2, $3, $4)
+		ON CONFLICT (video_id, date)
+		DO UPDATE SET
+			view_count = EXCLUDED.view_count,
+			like_count = EXCLUDED.like_count,
+			comment_count = EXCLUDED.comment_count
+	`
+	if _, err := tx.ExecContext(ctx, statsQuery, video.VideoID, video.ViewCount, video.LikeCount, video.CommentCount); err != nil {
+		return fmt.Errorf("failed to save daily video stats: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetVideoMuting returns the user's videos (clips excluded, Twitch doesn't mute
+// them) published since the given time with their muted segments, muted or not
+func (r *repository) GetVideoMuting(ctx context.Context, userID string, since time.Time) ([]MutedVideo, error) {
+	query := `
+		SELECT video_id, COALESCE(title, '') AS title, COALESCE(video_type, '') AS video_type, COALESCE(duration_seconds, 0) AS duration_seconds,
+			   COALESCE(view_count, 0) AS view_count, COALESCE(thumbnail_url, '') AS thumbnail_url,
+			   published_at, muted_segments, muted_seconds
+		FROM video_analytics
+		WHERE user_id = $1 AND published_at >= $2
+		  AND COALESCE(video_type, '') <> 'clip'
+		ORDER BY published_at DESC
+	`
+
+	var rows []struct {
+		VideoID       string          `db:"video_id"`
+		Title         string          `db:"title"`
+		VideoType     string          `db:"video_type"`
+		Duration      int             `db:"duration_seconds"`
+		ViewCount     int             `db:"view_count"`
+		ThumbnailURL  string          `db:"thumbnail_url"`
+		PublishedAt   *time.Time      `db:"published_at"`
+		MutedSegments json.RawMessage `db:"muted_segments"`
+		MutedSeconds  int             `db:"muted_seconds"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to get video muting: %w", err)
+	}
+
+	videos := make([]MutedVideo, 0, len(rows))
+	for _, row := range rows {
+		video := MutedVideo{
+			VideoID:      row.VideoID,
+			Title:        row.Title,
+			VideoType:    row.VideoType,
+			Duration:     row.Duration,
+			ViewCount:    row.ViewCount,
+			ThumbnailURL: row.ThumbnailURL,
+			PublishedAt:  row.PublishedAt,
+			MutedSeconds: row.MutedSeconds,
+		}
+		if err := json.Unmarshal(row.MutedSegments, &video.MutedSegments); err != nil {
+			return nil, fmt.Errorf("failed to decode muted segments of video %s: %w", row.VideoID, err)
+		}
+		videos = append(videos, video)
+	}
+	return videos, nil
+}
+
+// GetVideo returns one of the user's videos or clips, nil when it wasn't collected
+func (r *repository) GetVideo(ctx context.Context, userID, videoID string) (*VideoAnalytics, error) {
+	query := `
+		SELECT id, user_id, video_id, COALESCE(title, '') AS title, COALESCE(video_type, '') AS video_type,
+			   COALESCE(duration_seconds, 0) AS duration_seconds, COALESCE(view_count, 0) AS view_count,
+			   COALESCE(like_count, 0) AS like_count, COALESCE(comment_count, 0) AS comment_count,
+			   COALESCE(thumbnail_url, '') AS thumbnail_url, published_at, created_at, updated_at
+		FROM video_analytics
+		WHERE user_id = $1 AND video_id = $2
+	`
+
+	var video VideoAnalytics
+	err := r.db.GetContext(ctx, &video, query, userID, videoID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &video, err
+}
+
+func (r *repository) GetVideoViewCounts(ctx context.Context, userID string) ([]VideoViewCount, error) {
+	query := `
+		SELECT video_id, COALESCE(title, '') AS title, COALESCE(video_type, '') AS video_type, COALESCE(view_count, 0) AS view_count
+		FROM video_analytics
+		WHERE user_id = $1
+	`
+
+	var counts []VideoViewCount
+	err := r.db.SelectContext(ctx, &counts, query, userID)
+	return counts, err
 }
 
 func (r *repository) GetVideoAnalytics(ctx context.Context, userID string, limit int) ([]VideoAnalytics, error) {
@@ -243,6 +919,115 @@ func (r *repository) GetVideoAnalytics(ctx context.Context, userID string, limit
 	return videos, err
 }
 
+// GetTopVideos returns the user's most viewed videos of one type (e.g. "clip"),
+// or of any type when videoType is empty
+func (r *repository) GetTopVideos(ctx context.Context, userID, videoType string, limit int) ([]VideoAnalytics, error) {
+	query := `
+		SELECT id, user_id, video_id, title, video_type, duration_seconds, view_count,
+			   like_count, comment_count, thumbnail_url, published_at, created_at, updated_at
+		FROM video_analytics 
+		WHERE user_id = $1 AND ($2::text = '' OR video_type = $2::text)
+		ORDER BY view_count DESC 
+		LIMIT $3
+	`
+
+	var videos []VideoAnalytics
+	err := r.db.SelectContext(ctx, &videos, query, userID, videoType, limit)
+	return videos, err
+}
+
+// GetTopVideosByViewsPerDay is GetTopVideos ranked by views per day since
+// publishing, so recent videos aren't outranked by old ones that had longer
+// to collect views
+func (r *repository) GetTopVideosByViewsPerDay(ctx context.Context, userID, videoType string, limit int) ([]VideoAnalytics, error) {
+	query := `
+		SELECT id, user_id, video_id, title, video_type, duration_seconds, view_count,
+			   like_count, comment_count, thumbnail_url, published_at, created_at, updated_at
+		FROM video_analytics 
+		WHERE user_id = $1 AND ($2::text = '' OR video_type = $2::text)
+		ORDER BY ` + viewsPerDaySQL + ` DESC NULLS LAST, id
+		LIMIT $3
+	`
+
+	var videos []VideoAnalytics
+	err := r.db.SelectContext(ctx, &videos, query, userID, videoType, limit)
+	return videos, err
+}
+
+// ListVideos returns one page of the user's videos matching the query's filters,
+// in the requested order, along with the number of matching videos
+func (r *repository) ListVideos(ctx context.Context, userID string, query VideoListQuery) ([]VideoAnalytics, int, error) {
+	where := `
+		WHERE user_id = $1
+		  AND ($2::text = '' OR video_type = $2::text)
+		  AND ($3::timestamptz IS NULL OR published_at >= $3::timestamptz)
+		  AND ($4::timestamptz IS NULL OR published_at < $4::timestamptz)
+		  AND ($5::text = '' OR title ILIKE $5::text)
+	`
+	args := []interface{}{userID, query.VideoType, query.From, query.To, likePattern(query.Search)}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM video_analytics"+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count videos: %w", err)
+	}
+
+	listQuery := `
+		SELECT id, user_id, video_id, title, video_type, duration_seconds, view_count,
+			   like_count, comment_count, thumbnail_url, published_at, created_at, updated_at
+		FROM video_analytics` + where + `
+		ORDER BY ` + query.orderBy() + `
+		LIMIT $6 OFFSET $7
+	`
+
+	var videos []VideoAnalytics
+	if err := r.db.SelectContext(ctx, &videos, listQuery, append(args, query.Limit, query.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list videos: %w", err)
+	}
+
+	return videos, total, nil
+}
+
+// GetKeywordInsights ranks the keywords tagged on the user's enriched videos
+// (clips excluded, since clippers title them) by their view lift over the
+// average video. days <= 0 covers all videos.
+func (r *repository) GetKeywordInsights(ctx context.Context, userID string, days, minVideos, limit int) (*KeywordInsights, error) {
+	enriched := `
+		SELECT view_count, metadata
+		FROM video_analytics
+		WHERE user_id = $1
+		  AND COALESCE(video_type, '') <> 'clip'
+		  AND jsonb_typeof(metadata->'keywords') = 'array'
+		  AND ($2::int <= 0 OR published_at >= NOW() - make_interval(days => $2::int))
+	`
+
+	insights := &KeywordInsights{Keywords: []KeywordPerformance{}}
+	baselineQuery := `SELECT COUNT(*), COALESCE(AVG(view_count), 0)::float8 FROM (` + enriched + `) v`
+	if err := r.db.QueryRowContext(ctx, baselineQuery, userID, days).Scan(&insights.VideosAnalyzed, &insights.BaselineAvgViews); err != nil {
+		return nil, fmt.Errorf("failed to get keyword baseline: %w", err)
+	}
+	if insights.VideosAnalyzed == 0 {
+		return insights, nil
+	}
+
+	keywordQuery := `
+		WITH enriched AS (` + enriched + `)
+		SELECT kw.keyword, COUNT(*) AS videos,
+			   AVG(e.view_count)::float8 AS avg_views,
+			   COALESCE(AVG(e.view_count) / NULLIF($3::float8, 0), 0)::float8 AS lift
+		FROM enriched e
+		CROSS JOIN LATERAL jsonb_array_elements_text(e.metadata->'keywords') AS kw(keyword)
+		GROUP BY kw.keyword
+		HAVING COUNT(*) >= $4
+		ORDER BY lift DESC, videos DESC, kw.keyword
+		LIMIT $5
+	`
+	if err := r.db.SelectContext(ctx, &insights.Keywords, keywordQuery, userID, days, insights.BaselineAvgViews, minVideos, limit); err != nil {
+		return nil, fmt.Errorf("failed to get keyword performance: %w", err)
+	}
+
+	return insights, nil
+}
+
 func (r *repository) UpdateVideoAnalytics(ctx context.Context, videoID string, views, likes, comments int) error {
 	query := `
 		UPDATE video_analytics 
@@ -296,37 +1081,69 @@ func (r *repository) GetTopGames(ctx context.Context, userID string, limit int)
 
 // Dashboard Methods
 
-func (r *repository) GetDashboardOverview(ctx context.Context, userID string) (*DashboardOverview, error) {
+// previousSnapshotSQL selects the channel snapshot follower changes are
+// measured against: the latest one at least $2 days before the "latest"
+// snapshot, or the oldest one when none is that old yet
+const previousSnapshotSQL = `
+	SELECT c.date, c.followers_count
+	FROM channel_analytics c
+	JOIN latest l ON c.date < l.date
+	WHERE c.user_id = $1
+	ORDER BY c.date <= l.date - $2::int DESC,
+			 CASE WHEN c.date <= l.date - $2::int THEN c.date END DESC,
+			 c.date
+	LIMIT 1
+`
+
+// previousCollectedSQL selects the snapshot subscriber and view changes are
+// measured against: the latest collected one at least $2 days before a
+// collected "latest" snapshot. Backfilled and imported snapshots only know
+// the followers, so there is no change until a collected one is that old.
+const previousCollectedSQL = `
+	SELECT c.date, c.subscriber_count, c.total_views
+	FROM channel_analytics c
+	JOIN latest l ON c.date <= l.date - $2::int AND l.source = 'collected'
+	WHERE c.user_id = $1 AND c.source = 'collected'
+	ORDER BY c.date DESC
+	LIMIT 1
+`
+
+// changePercent is change relative to the value before it, 0 when that was 0
+func changePercent(change, current int) float64 {
+	previous := current - change
+	if previous <= 0 {
+		return 0
+	}
+	return float64(change) / float64(previous) * 100
+}
+
+func (r *repository) GetDashboardOverview(ctx context.Context, userID string, days int) (*DashboardOverview, error) {
 	query := `
+WITH latest AS (
+SELECT date, followers_count, subscriber_count, total_views, source
+FROM channel_analytics 
+WHERE user_id = $1 
+ORDER BY date DESC 
+LIMIT 1
+),
+previous AS (` + previousSnapshotSQL + `),
+previous_collected AS (` + previousCollectedSQL + `)
 SELECT 
 COALESCE(current_analytics.followers_count, 0) as current_followers,
 COALESCE(current_analytics.followers_count - previous_analytics.followers_count, 0) as follower_change,
 COALESCE(current_analytics.subscriber_count, 0) as current_subscribers,
-COALESCE(current_analytics.subscriber_count - previous_analytics.subscriber_count, 0) as subscriber_change,
+COALESCE(current_analytics.subscriber_count - previous_collected.subscriber_count, 0) as subscriber_change,
 COALESCE(current_analytics.total_views, 0) as total_views,
-COALESCE(current_analytics.total_views - previous_analytics.total_views, 0) as view_change,
-COALESCE(stream_stats.average_viewers, 0) as average_viewers,
-COALESCE(stream_stats.streams_count, 0) as streams_last_30_days,
-COALESCE(stream_stats.total_hours, 0) as hours_streamed_last_30
-FROM (
-SELECT followers_count, subscriber_count, total_views
-FROM channel_analytics 
-WHERE user_id = $1 
-ORDER BY date DESC 
-LIMIT 1
-) current_analytics
-LEFT JOIN (
-SELECT followers_count, subscriber_count, total_views
-FROM channel_analytics 
-WHERE user_id = $1 
-ORDER BY date DESC 
-LIMIT 1 OFFSET 7
-) previous_analytics ON true
+COALESCE(current_analytics.total_views - previous_collected.total_views, 0) as view_change,
+previous_analytics.date as change_since,
+COALESCE(stream_stats.average_viewers, 0) as average_viewers
+FROM (SELECT 1) base
+LEFT JOIN latest current_analytics ON true
+LEFT JOIN previous previous_analytics ON true
+LEFT JOIN previous_collected ON true
 LEFT JOIN (
 SELECT 
-AVG(average_viewers) as average_viewers,
-COUNT(*) as streams_count,
-SUM(duration_minutes) / 60.0 as total_hours
+AVG(average_viewers) as average_viewers
 FROM stream_sessions 
 WHERE user_id = $1 
 AND started_at >= CURRENT_DATE - INTERVAL '30 days'
@@ -334,14 +1151,14 @@ AND started_at >= CURRENT_DATE - INTERVAL '30 days'
 `
 
 	var overview DashboardOverview
-	row := r.db.QueryRowContext(ctx, query, userID)
+	row := r.db.QueryRowContext(ctx, query, userID, days)
 
 	var avgViewers sql.NullFloat64
 	err := row.Scan(
 		&overview.CurrentFollowers, &overview.FollowerChange,
 		&overview.CurrentSubscribers, &overview.SubscriberChange,
-		&overview.TotalViews, &overview.ViewChange,
-		&avgViewers, &overview.StreamsLast30Days, &overview.HoursStreamedLast30,
+		&overview.TotalViews, &overview.ViewChange, &overview.ChangeSince,
+		&avgViewers,
 	)
 
 	if err != nil {
@@ -351,9 +1168,9 @@ AND started_at >= CURRENT_DATE - INTERVAL '30 days'
 	overview.AverageViewers = int(avgViewers.Float64)
 
 	// Calculate percentage changes
-	if overview.CurrentFollowers > 0 {
-		overview.FollowerChangePercent = float64(overview.FollowerChange) / float64(overview.CurrentFollowers-overview.FollowerChange) * 100
-	}
+	overview.ChangeDays = days
+	overview.FollowerChangePercent = changePercent(overview.FollowerChange, overview.CurrentFollowers)
+	overview.SubscriberChangePercent = changePercent(overview.SubscriberChange, overview.CurrentSubscribers)
 
 	return &overview, nil
 }
@@ -365,11 +1182,11 @@ func (r *repository) GetAnalyticsChartData(ctx context.Context, userID string, d
 	followerQuery := `
 		SELECT date, followers_count 
 		FROM channel_analytics 
-		WHERE user_id = $1 AND date >= CURRENT_DATE - INTERVAL '%d days'
+		WHERE user_id = $1 AND date >= CURRENT_DATE - $2::int
 		ORDER BY date ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(followerQuery, days), userID)
+	rows, err := r.db.QueryContext(ctx, followerQuery, userID, days)
 	if err != nil {
 		return nil, err
 	}
@@ -396,7 +1213,7 @@ func (r *repository) GetDetailedAnalytics(ctx context.Context, userID string) (*
 	analytics := &DetailedAnalytics{}
 
 	// Get overview
-	overview, err := r.GetDashboardOverview(ctx, userID)
+	overview, err := r.GetDashboardOverview(ctx, userID, 30)
 	if err != nil {
 		return nil, err
 	}
@@ -417,7 +1234,7 @@ func (r *repository) GetDetailedAnalytics(ctx context.Context, userID string) (*
 	analytics.TopStreams = topStreams
 
 	// Get top videos
-	topVideos, err := r.GetVideoAnalytics(ctx, userID, 5)
+	topVideos, err := r.GetTopVideos(ctx, userID, "", 5)
 	if err != nil {
 		return nil, err
 	}
@@ -433,242 +1250,1635 @@ func (r *repository) GetDetailedAnalytics(ctx context.Context, userID string) (*
 	return analytics, nil
 }
 
+// How many videos the enhanced analytics list as top and recent videos
+const (
+	enhancedTopVideos    = 5
+	enhancedRecentVideos = 10
+)
+
 // GetEnhancedAnalytics provides video-based analytics for the new dashboard design
 func (r *repository) GetEnhancedAnalytics(ctx context.Context, userID string, days int) (*EnhancedAnalytics, error) {
-	analytics := &EnhancedAnalytics{}
+	// One statement computes the totals over ALL the user's videos (total channel
+	// metrics, not just recent videos), the follower and subscriber changes and
+	// daily series of the last days and the top and recent videos, which come
+	// back as JSON arrays
+	query := `
+		WITH videos AS (
+			SELECT id, user_id, video_id, title, video_type, duration_seconds, view_count,
+				   like_count, comment_count, thumbnail_url, published_at, created_at, updated_at,
+				   ROW_NUMBER() OVER (ORDER BY view_count DESC, id) AS top_rank,
+				   ROW_NUMBER() OVER (ORDER BY published_at DESC, id) AS recent_rank
+			FROM video_analytics
+			WHERE user_id = $1
+		),
+		latest AS (
+			SELECT date, followers_count, subscriber_count, total_views, source
+			FROM channel_analytics
+			WHERE user_id = $1
+			ORDER BY date DESC
+			LIMIT 1
+		),
+		previous AS (` + previousSnapshotSQL + `),
+		previous_collected AS (` + previousCollectedSQL + `),
+		daily AS (
+			SELECT DATE(published_at) AS date, COALESCE(video_type, '') AS video_type,
+				   COALESCE(SUM(view_count), 0) AS views, COUNT(*) AS count
+			FROM videos
+			WHERE published_at >= CURRENT_DATE - make_interval(days => $2)
+			GROUP BY DATE(published_at), video_type
+		)
+		SELECT
+			COALESCE(SUM(v.view_count), 0) AS total_views,
+			COUNT(v.id) AS video_count,
+			COALESCE(AVG(v.view_count), 0) AS avg_views,
+			COALESCE(SUM(v.duration_seconds), 0) / 3600.0 AS total_hours,
+			COALESCE((SELECT followers_count FROM latest), 0) AS current_followers,
+			COALESCE((SELECT subscriber_count FROM latest), 0) AS current_subscribers,
+			COALESCE((SELECT l.followers_count - p.followers_count FROM latest l, previous p), 0) AS follower_change,
+			COALESCE((SELECT l.subscriber_count - p.subscriber_count FROM latest l, previous_collected p), 0) AS subscriber_change,
+			(SELECT date FROM previous) AS change_since,
+			COALESCE((SELECT json_agg(d ORDER BY d.date, d.video_type) FROM daily d), '[]') AS daily,
+			COALESCE((SELECT json_agg(t ORDER BY t.top_rank) FROM videos t WHERE t.top_rank <= $3), '[]') AS top_videos,
+			COALESCE((SELECT json_agg(t ORDER BY t.recent_rank) FROM videos t WHERE t.recent_rank <= $4), '[]') AS recent_videos
+		FROM videos v
+	`
 
-	// Calculate video-based overview metrics
-	overview, err := r.getVideoBasedOverview(ctx, userID, days)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get video-based overview: %w", err)
+	var row struct {
+		TotalViews         int        `db:"total_views"`
+		VideoCount         int        `db:"video_count"`
+		AvgViews           float64    `db:"avg_views"`
+		TotalHours         float64    `db:"total_hours"`
+		CurrentFollowers   int        `db:"current_followers"`
+		CurrentSubscribers int        `db:"current_subscribers"`
+		FollowerChange     int        `db:"follower_change"`
+		SubscriberChange   int        `db:"subscriber_change"`
+		ChangeSince        *time.Time `db:"change_since"`
+		Daily              []byte     `db:"daily"`
+		TopVideos          []byte     `db:"top_videos"`
+		RecentVideos       []byte     `db:"recent_videos"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, userID, days, enhancedTopVideos, enhancedRecentVideos); err != nil {
+		return nil, fmt.Errorf("failed to get enhanced analytics: %w", err)
 	}
-	analytics.Overview = *overview
 
-	// Get performance data over time
-	performance, err := r.getPerformanceData(ctx, userID, days)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get performance data: %w", err)
+	log.Printf("📊 Enhanced analytics query for user %s: found %d videos, %d total views, %.2f avg views, %.2f total hours", userID, row.VideoCount, row.TotalViews, row.AvgViews, row.TotalHours)
+
+	analytics := &EnhancedAnalytics{
+		Overview: VideoBasedOverview{
+			TotalViews:              row.TotalViews,
+			VideoCount:              row.VideoCount,
+			AverageViewsPerVideo:    row.AvgViews,
+			TotalWatchTimeHours:     row.TotalHours,
+			CurrentFollowers:        row.CurrentFollowers,
+			CurrentSubscribers:      row.CurrentSubscribers,
+			FollowerChange:          row.FollowerChange,
+			FollowerChangePercent:   changePercent(row.FollowerChange, row.CurrentFollowers),
+			SubscriberChange:        row.SubscriberChange,
+			SubscriberChangePercent: changePercent(row.SubscriberChange, row.CurrentSubscribers),
+			ChangeSince:             row.ChangeSince,
+		},
 	}
-	analytics.Performance = *performance
 
-	// Get top videos by view count
-	topVideos, err := r.GetVideoAnalytics(ctx, userID, 5)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get top videos: %w", err)
+	var daily []dailyContent
+	if err := json.Unmarshal(row.Daily, &daily); err != nil {
+		return nil, fmt.Errorf("failed to decode daily content: %w", err)
 	}
-	analytics.TopVideos = topVideos
+	analytics.Performance = buildPerformanceData(daily)
 
-	// Get recent videos
-	recentVideos, err := r.GetVideoAnalytics(ctx, userID, 10)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get recent videos: %w", err)
+	if err := json.Unmarshal(row.TopVideos, &analytics.TopVideos); err != nil {
+		return nil, fmt.Errorf("failed to decode top videos: %w", err)
+	}
+	if err := json.Unmarshal(row.RecentVideos, &analytics.RecentVideos); err != nil {
+		return nil, fmt.Errorf("failed to decode recent videos: %w", err)
 	}
-	analytics.RecentVideos = recentVideos
 
 	return analytics, nil
 }
 
-// Helper method to calculate video-based overview metrics
-func (r *repository) getVideoBasedOverview(ctx context.Context, userID string, days int) (*VideoBasedOverview, error) {
-	// Get video metrics - show ALL videos for the user, not filtered by publish date
-	// because we want to show total channel metrics, not just recent videos
-	videoQuery := `
-		SELECT 
-			COALESCE(SUM(view_count), 0) as total_views,
-			COUNT(*) as video_count,
-			COALESCE(AVG(view_count), 0) as avg_views,
-			COALESCE(SUM(duration_seconds), 0) / 3600.0 as total_hours
-		FROM video_analytics 
-		WHERE user_id = $1
-	`
+// dailyContent is the views and number of videos of one type published on a day
+type dailyContent struct {
+	Date      string `json:"date"`
+	VideoType string `json:"video_type"`
+	Views     int    `json:"views"`
+	Count     int    `json:"count"`
+}
 
-	var totalViews, videoCount int
-	var avgViews, totalHours float64
-	err := r.db.QueryRowContext(ctx, videoQuery, userID).Scan(
-		&totalViews, &videoCount, &avgViews, &totalHours)
-	if err != nil {
-		log.Printf("❌ Error executing video query for user %s: %v", userID, err)
-		return nil, err
+// buildPerformanceData turns daily content, ordered by date, into the views
+// per day and the content published per day by kind
+func buildPerformanceData(daily []dailyContent) PerformanceData {
+	var performance PerformanceData
+	for i, day := range daily {
+		if i == 0 || day.Date != daily[i-1].Date {
+			performance.ViewsOverTime = append(performance.ViewsOverTime, ChartDataPoint{Date: day.Date})
+			performance.ContentDistribution = append(performance.ContentDistribution, ContentTypeData{Date: day.Date})
+		}
+		performance.ViewsOverTime[len(performance.ViewsOverTime)-1].Value += float64(day.Views)
+
+		content := &performance.ContentDistribution[len(performance.ContentDistribution)-1]
+		switch day.VideoType {
+		case "archive", "vod":
+			content.Broadcasts += day.Count
+		case "clip":
+			content.Clips += day.Count
+		case "upload":
+			content.Uploads += day.Count
+		}
 	}
+	return performance
+}
 
-	log.Printf("📊 Enhanced analytics query for user %s: found %d videos, %d total views, %.2f avg views, %.2f total hours", userID, videoCount, totalViews, avgViews, totalHours)
+// Analytics Jobs Methods
 
-	// Get channel metrics (followers, subscribers) from latest channel analytics
-	channelQuery := `
-		SELECT 
-			COALESCE(followers_count, 0) as current_followers,
-			COALESCE(subscriber_count, 0) as current_subscribers
-		FROM channel_analytics 
+func (r *repository) CreateAnalyticsJob(ctx context.Context, job *AnalyticsJob) error {
+	query := `
+		INSERT INTO analytics_jobs (user_id, job_type, status, data_date, started_at, progress_total)
+		VALUES (NULLIF($1, ''), $2, $3, $4, NOW(), $5)
+		RETURNING id
+	`
+	return r.db.GetContext(ctx, &job.ID, query, job.UserID, job.JobType, job.Status, job.DataDate, job.ProgressTotal)
+}
+
+func (r *repository) UpdateAnalyticsJobProgress(ctx context.Context, jobID, total, completed, failed int) error {
+	query := `
+		UPDATE analytics_jobs 
+		SET progress_total = $2, progress_completed = $3, progress_failed = $4
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, jobID, total, completed, failed)
+	return err
+}
+
+func (r *repository) UpdateAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string) error {
+	query := `
+		UPDATE analytics_jobs 
+		SET status = $2, completed_at = NOW(), error_message = $3
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, jobID, status, errorMsg)
+	return err
+}
+
+func (r *repository) CompleteAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string, report []byte) error {
+	query := `
+		UPDATE analytics_jobs
+		SET status = $2, completed_at = NOW(), error_message = $3, report = $4
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, jobID, status, errorMsg, report)
+	return err
+}
+
+func (r *repository) GetAnalyticsJobs(ctx context.Context, userID string, limit int) ([]AnalyticsJob, error) {
+	query := `
+		SELECT id, user_id, job_type, status, started_at, completed_at, 
+			   COALESCE(error_message, '') as error_message, data_date, created_at, report
+		FROM analytics_jobs 
 		WHERE user_id = $1 
-		ORDER BY date DESC 
+		ORDER BY created_at DESC 
+		LIMIT $2
+	`
+
+	var jobs []AnalyticsJob
+	err := r.db.SelectContext(ctx, &jobs, query, userID, limit)
+	return jobs, err
+}
+
+func (r *repository) GetLatestAnalyticsJob(ctx context.Context, userID, jobType, status string) (*AnalyticsJob, error) {
+	query := `
+		SELECT id, user_id, job_type, status, started_at, completed_at, 
+			   COALESCE(error_message, '') as error_message, data_date, created_at
+		FROM analytics_jobs 
+		WHERE user_id = $1 AND job_type = $2 AND status = $3
+		ORDER BY created_at DESC 
 		LIMIT 1
 	`
 
-	var currentFollowers, currentSubscribers int
-	err = r.db.QueryRowContext(ctx, channelQuery, userID).Scan(
-		&currentFollowers, &currentSubscribers)
-	if err != nil && err != sql.ErrNoRows {
+	var job AnalyticsJob
+	err := r.db.GetContext(ctx, &job, query, userID, jobType, status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &job, err
+}
+
+func (r *repository) SaveAnalyticsJobChanges(ctx context.Context, jobID int, changes []byte) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE analytics_jobs SET changes = $2 WHERE id = $1", jobID, changes)
+	return err
+}
+
+func (r *repository) GetChangeSummaries(ctx context.Context, userID string, since time.Time, limit int) ([]ChangeSummary, error) {
+	query := `
+		SELECT changes
+		FROM analytics_jobs
+		WHERE user_id = $1 AND changes IS NOT NULL AND created_at >= $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	var rows [][]byte
+	if err := r.db.SelectContext(ctx, &rows, query, userID, since, limit); err != nil {
 		return nil, err
 	}
 
-	// Calculate follower/subscriber changes (simplified for now)
-	followerChange := 0   // TODO: Calculate from previous period
-	subscriberChange := 0 // TODO: Calculate from previous period
+	summaries := make([]ChangeSummary, 0, len(rows))
+	for _, row := range rows {
+		var summary ChangeSummary
+		if err := json.Unmarshal(row, &summary); err != nil {
+			return nil, fmt.Errorf("failed to decode change summary: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (r *repository) CountUserRows(ctx context.Context, userID string) (map[string]int64, error) {
+	counts := make([]string, 0, len(archivedTables))
+	for _, table := range archivedTables {
+		counts = append(counts, fmt.Sprintf(`SELECT '%s' AS table_name, count(*) AS row_count FROM %s WHERE %s`,
+			table.name, table.name, table.owned))
+	}
+
+	var rows []struct {
+		TableName string `db:"table_name"`
+		RowCount  int64  `db:"row_count"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, strings.Join(counts, " UNION ALL "), userID); err != nil {
+		return nil, err
+	}
 
-	return &VideoBasedOverview{
-		TotalViews:           totalViews,
-		VideoCount:           videoCount,
-		AverageViewsPerVideo: avgViews,
-		TotalWatchTimeHours:  totalHours,
-		CurrentFollowers:     currentFollowers,
-		CurrentSubscribers:   currentSubscribers,
-		FollowerChange:       followerChange,
-		SubscriberChange:     subscriberChange,
-	}, nil
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[row.TableName] = row.RowCount
+	}
+	return result, nil
 }
 
-// Helper method to get performance data over time
-func (r *repository) getPerformanceData(ctx context.Context, userID string, days int) (*PerformanceData, error) {
-	performance := &PerformanceData{}
+// Disconnect Archive Methods
 
-	// Views over time (aggregate by day)
-	viewsQuery := `
-		SELECT 
-			DATE(published_at) as date,
-			SUM(view_count) as daily_views
-		FROM video_analytics 
-		WHERE user_id = $1 
-		AND published_at >= CURRENT_DATE - INTERVAL '%d days'
-		GROUP BY DATE(published_at)
-		ORDER BY date ASC
-	`
+const disconnectArchiveColumns = `id, user_id, twitch_user_id, row_count, archived_at, expires_at, restored_at`
+
+// CleanupTwitchAccount disconnects the user's Twitch account in one
+// transaction: their analytics are moved into a disconnect archive kept until
+// expiresAt, and the stored token, EventSub subscriptions, collection retries
+// and the account linked on the user are removed. With a jobID the job's
+// progress is updated after each step, see TwitchCleanupSteps.
+func (r *repository) CleanupTwitchAccount(ctx context.Context, jobID int, userID, twitchUserID string, expiresAt time.Time) (*DisconnectArchive, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin cleanup transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	completed := 0
+	progress := func() {
+		completed++
+		if jobID == 0 {
+			return
+		}
+		// Written outside the transaction so the progress shows while it runs
+		if err := r.UpdateAnalyticsJobProgress(ctx, jobID, TwitchCleanupSteps, completed, 0); err != nil {
+			log.Printf("Failed to update progress of cleanup job %d: %v", jobID, err)
+		}
+	}
 
-	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(viewsQuery, days), userID)
+	var archive DisconnectArchive
+	err = tx.GetContext(ctx, &archive, `
+		INSERT INTO disconnect_archives (user_id, twitch_user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING `+disconnectArchiveColumns, userID, twitchUserID, expiresAt)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create disconnect archive: %w", err)
+	}
+
+	for _, table := range archivedTables {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			INSERT INTO disconnect_archive_rows (archive_id, table_name, row_data)
+			SELECT $2, '%s', to_jsonb(t) FROM %s t WHERE %s
+		`, table.name, table.name, table.owned), userID, archive.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", table.name, err)
+		}
+		rows, _ := result.RowsAffected()
+		archive.RowCount += int(rows)
+		progress()
+	}
+
+	for i := len(archivedTables) - 1; i >= 0; i-- {
+		table := archivedTables[i]
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s`, table.name, table.owned), userID); err != nil {
+			return nil, fmt.Errorf("failed to delete archived %s: %w", table.name, err)
+		}
+	}
+
+	for _, query := range []string{
+		`DELETE FROM user_twitch_tokens WHERE user_id = $1`,
+		`DELETE FROM eventsub_subscriptions WHERE user_id = $1`,
+		`DELETE FROM collection_retries WHERE user_id = $1`,
+		`UPDATE users SET twitch_user_id = '', updated_at = NOW() WHERE id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return nil, fmt.Errorf("failed to remove Twitch connection: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE disconnect_archives SET row_count = $2 WHERE id = $1`, archive.ID, archive.RowCount); err != nil {
+		return nil, fmt.Errorf("failed to count disconnect archive rows: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	progress()
+	return &archive, nil
+}
 
-	for rows.Next() {
-		var date time.Time
-		var views int
-		if err := rows.Scan(&date, &views); err != nil {
-			continue
+// ListOrphanedTwitchDataUsers returns the users without a Twitch account,
+// neither a stored token nor one linked on the user, who still have analytics,
+// EventSub subscriptions or collection retries
+func (r *repository) ListOrphanedTwitchDataUsers(ctx context.Context) ([]string, error) {
+	var owned []string
+	for _, table := range archivedTables {
+		if table.owned == "user_id = $1" {
+			owned = append(owned, fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE user_id = u.id)", table.name))
 		}
-		performance.ViewsOverTime = append(performance.ViewsOverTime, ChartDataPoint{
-			Date:  date.Format("2006-01-02"),
-			Value: float64(views),
-		})
 	}
+	owned = append(owned,
+		"EXISTS (SELECT 1 FROM eventsub_subscriptions WHERE user_id = u.id)",
+		"EXISTS (SELECT 1 FROM collection_retries WHERE user_id = u.id)",
+	)
 
-	// Content distribution by type and date
-	contentQuery := `
-		SELECT 
-			DATE(published_at) as date,
-			video_type,
-			COUNT(*) as count
-		FROM video_analytics 
-		WHERE user_id = $1 
-		AND published_at >= CURRENT_DATE - INTERVAL '%d days'
-		GROUP BY DATE(published_at), video_type
-		ORDER BY date ASC
+	query := `
+		SELECT u.id
+		FROM users u
+		WHERE NULLIF(u.twitch_user_id, '') IS NULL
+		AND NOT EXISTS (SELECT 1 FROM user_twitch_tokens t WHERE t.user_id = u.id)
+		AND (` + strings.Join(owned, " OR ") + `)
+		ORDER BY u.id
 	`
 
-	rows, err = r.db.QueryContext(ctx, fmt.Sprintf(contentQuery, days), userID)
+	userIDs := []string{}
+	if err := r.db.SelectContext(ctx, &userIDs, query); err != nil {
+		return nil, fmt.Errorf("failed to list users with orphaned Twitch data: %w", err)
+	}
+	return userIDs, nil
+}
+
+// FailInterruptedAnalyticsJobs marks jobs of the type still running since
+// before startedBefore as failed, e.g. after the process died during them
+func (r *repository) FailInterruptedAnalyticsJobs(ctx context.Context, jobType string, startedBefore time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE analytics_jobs
+		SET status = 'failed', completed_at = NOW(), error_message = 'interrupted'
+		WHERE job_type = $1 AND status = 'running' AND started_at < $2
+	`, jobType, startedBefore)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("failed to fail interrupted %s jobs: %w", jobType, err)
 	}
-	defer rows.Close()
+	return result.RowsAffected()
+}
 
-	// Group by date
-	contentMap := make(map[string]*ContentTypeData)
-	for rows.Next() {
-		var date time.Time
-		var videoType string
-		var count int
-		if err := rows.Scan(&date, &videoType, &count); err != nil {
-			continue
+// GetRestorableDisconnectArchive returns the latest unexpired archive of the
+// user's analytics of a Twitch account, or nil
+func (r *repository) GetRestorableDisconnectArchive(ctx context.Context, userID, twitchUserID string) (*DisconnectArchive, error) {
+	var archive DisconnectArchive
+	err := r.db.GetContext(ctx, &archive, `
+		SELECT `+disconnectArchiveColumns+`
+		FROM disconnect_archives
+		WHERE user_id = $1 AND twitch_user_id = $2 AND restored_at IS NULL AND expires_at > NOW()
+		ORDER BY archived_at DESC
+		LIMIT 1
+	`, userID, twitchUserID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disconnect archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// RestoreDisconnectArchive puts the archived rows back and returns how many
+// were restored. Rows collected again since the disconnect are kept as they are.
+func (r *repository) RestoreDisconnectArchive(ctx context.Context, archiveID int64) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin restore transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Claims the archive, so a concurrent restore of it fails here
+	result, err := tx.ExecContext(ctx, `
+		UPDATE disconnect_archives SET restored_at = NOW()
+		WHERE id = $1 AND restored_at IS NULL AND expires_at > NOW()
+	`, archiveID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim disconnect archive: %w", err)
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return 0, fmt.Errorf("disconnect archive %d is expired or already restored", archiveID)
+	}
+
+	var restored int64
+	for _, table := range archivedTables {
+		data := table.restored
+		if data == "" {
+			data = "row_data"
 		}
+		filter := table.restoredIf
+		if filter == "" {
+			filter = "TRUE"
+		}
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			INSERT INTO %s
+			SELECT r.* FROM disconnect_archive_rows a, jsonb_populate_record(NULL::%s, %s) r
+			WHERE a.archive_id = $1 AND a.table_name = '%s' AND %s
+			ON CONFLICT DO NOTHING
+		`, table.name, table.name, data, table.name, filter), archiveID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restore %s: %w", table.name, err)
+		}
+		rows, _ := result.RowsAffected()
+		restored += rows
+	}
 
-		dateStr := date.Format("2006-01-02")
-		if contentMap[dateStr] == nil {
-			contentMap[dateStr] = &ContentTypeData{
-				Date: dateStr,
-			}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM disconnect_archive_rows WHERE archive_id = $1`, archiveID); err != nil {
+		return 0, fmt.Errorf("failed to clear restored archive rows: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return restored, nil
+}
+
+// PurgeExpiredDisconnectArchives deletes archives past their restore window
+func (r *repository) PurgeExpiredDisconnectArchives(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM disconnect_archives WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge disconnect archives: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Retention Methods
+
+// rollupPeriods are the aggregate granularities kept for rows past the retention window
+var rollupPeriods = []string{"week", "month"}
+
+// ApplyChannelAnalyticsRetention folds channel_analytics rows older than cutoff into
+// the weekly/monthly rollups, optionally archives them, and deletes them. Rollups
+// are merged rather than replaced because a period can straddle the cutoff and be
+// rolled up across several runs.
+func (r *repository) ApplyChannelAnalyticsRetention(ctx context.Context, cutoff time.Time, archive bool) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rollupQuery := `
+		INSERT INTO channel_analytics_rollups (
+			user_id, period, period_start, first_date, last_date, days,
+			followers_start, followers_end, followers_max, total_views_end, subscribers_end
+		)
+		SELECT 
+			user_id, $2::text, date_trunc($2::text, date)::date, MIN(date), MAX(date), COUNT(*),
+			(array_agg(followers_count ORDER BY date ASC))[1],
+			(array_agg(followers_count ORDER BY date DESC))[1],
+			MAX(followers_count),
+			(array_agg(total_views ORDER BY date DESC))[1],
+			(array_agg(subscriber_count ORDER BY date DESC))[1]
+		FROM channel_analytics 
+		WHERE date < $1
+		GROUP BY user_id, date_trunc($2::text, date)
+		ON CONFLICT (user_id, period, period_start) 
+		DO UPDATE SET 
+			followers_start = CASE WHEN EXCLUDED.first_date < channel_analytics_rollups.first_date 
+				THEN EXCLUDED.followers_start ELSE channel_analytics_rollups.followers_start END,
+			followers_end = CASE WHEN EXCLUDED.last_date > channel_analytics_rollups.last_date 
+				THEN EXCLUDED.followers_end ELSE channel_analytics_rollups.followers_end END,
+			total_views_end = CASE WHEN EXCLUDED.last_date > channel_analytics_rollups.last_date 
+				THEN EXCLUDED.total_views_end ELSE channel_analytics_rollups.total_views_end END,
+			subscribers_end = CASE WHEN EXCLUDED.last_date > channel_analytics_rollups.last_date 
+				THEN EXCLUDED.subscribers_end ELSE channel_analytics_rollups.subscribers_end END,
+			followers_max = GREATEST(channel_analytics_rollups.followers_max, EXCLUDED.followers_max),
+			first_date = LEAST(channel_analytics_rollups.first_date, EXCLUDED.first_date),
+			last_date = GREATEST(channel_analytics_rollups.last_date, EXCLUDED.last_date),
+			days = channel_analytics_rollups.days + EXCLUDED.days,
+			updated_at = NOW()
+	`
+	for _, period := range rollupPeriods {
+		if _, err := tx.ExecContext(ctx, rollupQuery, cutoff, period); err != nil {
+			return 0, fmt.Errorf("failed to roll up channel analytics by %s: %w", period, err)
 		}
+	}
 
-		switch videoType {
-		case "archive", "vod":
-			contentMap[dateStr].Broadcasts += count
-		case "clip":
-			contentMap[dateStr].Clips += count
-		case "upload":
-			contentMap[dateStr].Uploads += count
+	if archive {
+		archiveQuery := `
+			INSERT INTO channel_analytics_archive 
+				(id, user_id, date, followers_count, following_count, total_views, subscriber_count, created_at)
+			SELECT id, user_id, date, followers_count, following_count, total_views, subscriber_count, created_at
+			FROM channel_analytics 
+			WHERE date < $1
+			ON CONFLICT (id) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, archiveQuery, cutoff); err != nil {
+			return 0, fmt.Errorf("failed to archive channel analytics: %w", err)
 		}
 	}
 
-	// Convert map to slice
-	for _, data := range contentMap {
-		performance.ContentDistribution = append(performance.ContentDistribution, *data)
+	result, err := tx.ExecContext(ctx, "DELETE FROM channel_analytics WHERE date < $1", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old channel analytics: %w", err)
 	}
+	deleted, _ := result.RowsAffected()
 
-	return performance, nil
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
 }
 
-// Analytics Jobs Methods
+// ApplyVideoDailyStatsRetention does the same as ApplyChannelAnalyticsRetention for video_daily_stats
+func (r *repository) ApplyVideoDailyStatsRetention(ctx context.Context, cutoff time.Time, archive bool) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-func (r *repository) CreateAnalyticsJob(ctx context.Context, job *AnalyticsJob) error {
+	rollupQuery := `
+		INSERT INTO video_daily_stats_rollups (
+			video_id, period, period_start, first_date, last_date, days,
+			views_start, views_end, likes_end, comments_end, watch_time_minutes
+		)
+		SELECT 
+			video_id, $2::text, date_trunc($2::text, date)::date, MIN(date), MAX(date), COUNT(*),
+			(array_agg(view_count ORDER BY date ASC))[1],
+			(array_agg(view_count ORDER BY date DESC))[1],
+			(array_agg(like_count ORDER BY date DESC))[1],
+			(array_agg(comment_count ORDER BY date DESC))[1],
+			COALESCE(SUM(watch_time_minutes), 0)
+		FROM video_daily_stats 
+		WHERE date < $1
+		GROUP BY video_id, date_trunc($2::text, date)
+		ON CONFLICT (video_id, period, period_start) 
+		DO UPDATE SET 
+			views_start = CASE WHEN EXCLUDED.first_date < video_daily_stats_rollups.first_date 
+				THEN EXCLUDED.views_start ELSE video_daily_stats_rollups.views_start END,
+			views_end = CASE WHEN EXCLUDED.last_date > video_daily_stats_rollups.last_date 
+				THEN EXCLUDED.views_end ELSE video_daily_stats_rollups.views_end END,
+			likes_end = CASE WHEN EXCLUDED.last_date > video_daily_stats_rollups.last_date 
+				THEN EXCLUDED.likes_end ELSE video_daily_stats_rollups.likes_end END,
+			comments_end = CASE WHEN EXCLUDED.last_date > video_daily_stats_rollups.last_date 
+				THEN EXCLUDED.comments_end ELSE video_daily_stats_rollups.comments_end END,
+			watch_time_minutes = video_daily_stats_rollups.watch_time_minutes + EXCLUDED.watch_time_minutes,
+			first_date = LEAST(video_daily_stats_rollups.first_date, EXCLUDED.first_date),
+			last_date = GREATEST(video_daily_stats_rollups.last_date, EXCLUDED.last_date),
+			days = video_daily_stats_rollups.days + EXCLUDED.days,
+			updated_at = NOW()
+	`
+	for _, period := range rollupPeriods {
+		if _, err := tx.ExecContext(ctx, rollupQuery, cutoff, period); err != nil {
+			return 0, fmt.Errorf("failed to roll up video daily stats by %s: %w", period, err)
+		}
+	}
+
+	if archive {
+		archiveQuery := `
+			INSERT INTO video_daily_stats_archive 
+				(id, video_id, date, view_count, like_count, comment_count, watch_time_minutes, created_at)
+			SELECT id, video_id, date, view_count, like_count, comment_count, watch_time_minutes, created_at
+			FROM video_daily_stats 
+			WHERE date < $1
+			ON CONFLICT (id) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, archiveQuery, cutoff); err != nil {
+			return 0, fmt.Errorf("failed to archive video daily stats: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM video_daily_stats WHERE date < $1", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old video daily stats: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func (r *repository) GetChannelAnalyticsRollups(ctx context.Context, userID, period string, limit int) ([]ChannelAnalyticsRollup, error) {
 	query := `
-		INSERT INTO analytics_jobs (user_id, job_type, status, data_date)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
+		SELECT user_id, period, period_start, first_date, last_date, days,
+			   followers_start, followers_end, followers_max, total_views_end, subscribers_end
+		FROM channel_analytics_rollups 
+		WHERE user_id = $1 AND period = $2
+		ORDER BY period_start DESC 
+		LIMIT $3
 	`
-	return r.db.GetContext(ctx, &job.ID, query, job.UserID, job.JobType, job.Status, job.DataDate)
+
+	var rollups []ChannelAnalyticsRollup
+	err := r.db.SelectContext(ctx, &rollups, query, userID, period, limit)
+	return rollups, err
 }
 
-func (r *repository) UpdateAnalyticsJob(ctx context.Context, jobID int, status string, errorMsg *string) error {
+// Collection Retry Methods
+
+func (r *repository) SaveCollectionRetry(ctx context.Context, retry *CollectionRetry) error {
 	query := `
-		UPDATE analytics_jobs 
-		SET status = $2, completed_at = NOW(), error_message = $3
-		WHERE id = $1
+		INSERT INTO collection_retries (user_id, job_type, attempts, failure_kind, last_error, next_attempt_at, parked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, job_type)
+		DO UPDATE SET
+			attempts = EXCLUDED.attempts,
+			failure_kind = EXCLUDED.failure_kind,
+			last_error = EXCLUDED.last_error,
+			next_attempt_at = EXCLUDED.next_attempt_at,
+			parked_at = EXCLUDED.parked_at,
+			updated_at = NOW()
 	`
-	_, err := r.db.ExecContext(ctx, query, jobID, status, errorMsg)
+	_, err := r.db.ExecContext(ctx, query,
+		retry.UserID, retry.JobType, retry.Attempts, retry.FailureKind,
+		retry.LastError, retry.NextAttemptAt, retry.ParkedAt)
 	return err
 }
 
-func (r *repository) GetAnalyticsJobs(ctx context.Context, userID string, limit int) ([]AnalyticsJob, error) {
+func (r *repository) GetCollectionRetry(ctx context.Context, userID, jobType string) (*CollectionRetry, error) {
 	query := `
-		SELECT id, user_id, job_type, status, started_at, completed_at, 
-			   error_message, data_date, created_at
-		FROM analytics_jobs 
-		WHERE user_id = $1 
-		ORDER BY created_at DESC 
-		LIMIT $2
+		SELECT user_id, job_type, attempts, failure_kind, COALESCE(last_error, '') as last_error,
+			   next_attempt_at, parked_at, created_at, updated_at, options
+		FROM collection_retries
+		WHERE user_id = $1 AND job_type = $2
 	`
 
-	var jobs []AnalyticsJob
-	err := r.db.SelectContext(ctx, &jobs, query, userID, limit)
-	return jobs, err
+	var retry CollectionRetry
+	err := r.db.GetContext(ctx, &retry, query, userID, jobType)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &retry, err
 }
 
-func (r *repository) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+func (r *repository) ListCollectionRetries(ctx context.Context, userID string) ([]CollectionRetry, error) {
 	query := `
-		SELECT 
-			COUNT(DISTINCT user_id) as total_users,
-			COUNT(DISTINCT CASE WHEN created_at >= CURRENT_DATE - INTERVAL '7 days' THEN user_id END) as active_users,
-			COUNT(*) as total_jobs,
-			COUNT(CASE WHEN status = 'completed' THEN 1 END) as successful_jobs,
-			COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed_jobs,
-			COALESCE(MAX(created_at), NOW()) as last_collection_run
-		FROM analytics_jobs
-		WHERE created_at >= CURRENT_DATE - INTERVAL '30 days'
+		SELECT user_id, job_type, attempts, failure_kind, COALESCE(last_error, '') as last_error,
+			   next_attempt_at, parked_at, created_at, updated_at, options
+		FROM collection_retries
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
 	`
 
-	var stats SystemStats
-	row := r.db.QueryRowContext(ctx, query)
+	var retries []CollectionRetry
+	err := r.db.SelectContext(ctx, &retries, query, userID)
+	return retries, err
+}
 
-	err := row.Scan(
-		&stats.TotalUsers, &stats.ActiveUsers, &stats.TotalJobs,
+func (r *repository) GetDueCollectionRetries(ctx context.Context, now time.Time, limit int) ([]CollectionRetry, error) {
+	query := `
+		SELECT user_id, job_type, attempts, failure_kind, COALESCE(last_error, '') as last_error,
+			   next_attempt_at, parked_at, created_at, updated_at, options
+		FROM collection_retries
+		WHERE parked_at IS NULL AND next_attempt_at <= $1
+		ORDER BY next_attempt_at ASC
+		LIMIT $2
+	`
+
+	var retries []CollectionRetry
+	err := r.db.SelectContext(ctx, &retries, query, now, limit)
+	return retries, err
+}
+
+func (r *repository) DeleteCollectionRetry(ctx context.Context, userID, jobType string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM collection_retries WHERE user_id = $1 AND job_type = $2", userID, jobType)
+	return err
+}
+
+func (r *repository) QueueInterruptedCollections(ctx context.Context, userIDs []string, jobType string, options []byte) error {
+	ids, err := json.Marshal(userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to encode user IDs: %w", err)
+	}
+	var encodedOptions *string
+	if options != nil {
+		encodedOptions = &[]string{string(options)}[0]
+	}
+
+	// Users deleted meanwhile are skipped rather than failing the batch
+	query := `
+		INSERT INTO collection_retries (user_id, job_type, failure_kind, last_error, next_attempt_at, options)
+		SELECT u.id, $2, $3, 'interrupted by shutdown', NOW(), $4::jsonb
+		FROM jsonb_array_elements_text($1::jsonb) AS ids(user_id)
+		JOIN users u ON u.id = ids.user_id
+		ON CONFLICT (user_id, job_type)
+		DO UPDATE SET
+			failure_kind = EXCLUDED.failure_kind,
+			last_error = EXCLUDED.last_error,
+			next_attempt_at = EXCLUDED.next_attempt_at,
+			options = COALESCE(EXCLUDED.options, collection_retries.options),
+			updated_at = NOW()
+		WHERE collection_retries.parked_at IS NULL
+	`
+	if _, err := r.db.ExecContext(ctx, query, string(ids), jobType, FailureInterrupted, encodedOptions); err != nil {
+		return fmt.Errorf("failed to queue interrupted %s collections: %w", jobType, err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteCollectionRetries(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM collection_retries WHERE user_id = $1", userID)
+	return err
+}
+
+// Manual Collection Quota Methods
+
+func (r *repository) TakeManualCollection(ctx context.Context, userID string, day time.Time, limit int) (int, bool, error) {
+	// A row left from an earlier day starts over
+	query := `
+		INSERT INTO manual_collection_quotas AS q (user_id, day, used)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id) DO UPDATE SET
+			used = CASE WHEN q.day = EXCLUDED.day THEN q.used + 1 ELSE 1 END,
+			day = EXCLUDED.day,
+			updated_at = NOW()
+		WHERE q.day <> EXCLUDED.day OR q.used < $3
+		RETURNING used
+	`
+
+	var used int
+	err := r.db.GetContext(ctx, &used, query, userID, day, limit)
+	if err == nil {
+		return used, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	err = r.db.GetContext(ctx, &used, "SELECT used FROM manual_collection_quotas WHERE user_id = $1", userID)
+	return used, false, err
+}
+
+func (r *repository) ReleaseManualCollection(ctx context.Context, userID string, day time.Time) error {
+	query := `
+		UPDATE manual_collection_quotas
+		SET used = used - 1, updated_at = NOW()
+		WHERE user_id = $1 AND day = $2 AND used > 0
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, day)
+	return err
+}
+
+// Revenue Methods
+
+// SaveRevenueSnapshot upserts the day's subscription and ad schedule columns,
+// leaving bits untouched
+func (r *repository) SaveRevenueSnapshot(ctx context.Context, revenue *RevenueAnalytics) error {
+	query := `
+		INSERT INTO revenue_analytics (
+			user_id, date, total_subscribers, tier1_subscribers, tier2_subscribers, tier3_subscribers,
+			gifted_subscribers, sub_points, estimated_sub_revenue_cents,
+			ad_duration_seconds, ad_snooze_count, last_ad_at, next_ad_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (user_id, date)
+		DO UPDATE SET
+			total_subscribers = EXCLUDED.total_subscribers,
+			tier1_subscribers = EXCLUDED.tier1_subscribers,
+			tier2_subscribers = EXCLUDED.tier2_subscribers,
+			tier3_subscribers = EXCLUDED.tier3_subscribers,
+			gifted_subscribers = EXCLUDED.gifted_subscribers,
+			sub_points = EXCLUDED.sub_points,
+			estimated_sub_revenue_cents = EXCLUDED.estimated_sub_revenue_cents,
+			ad_duration_seconds = EXCLUDED.ad_duration_seconds,
+			ad_snooze_count = EXCLUDED.ad_snooze_count,
+			last_ad_at = EXCLUDED.last_ad_at,
+			next_ad_at = EXCLUDED.next_ad_at,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		revenue.UserID, revenue.Date, revenue.TotalSubscribers, revenue.Tier1Subscribers,
+		revenue.Tier2Subscribers, revenue.Tier3Subscribers, revenue.GiftedSubscribers,
+		revenue.SubPoints, revenue.EstimatedSubRevenueCents, revenue.AdDurationSeconds,
+		revenue.AdSnoozeCount, revenue.LastAdAt, revenue.NextAdAt)
+	return err
+}
+
+// SaveDailyBits upserts the bits cheered on a day, leaving the snapshot columns untouched
+func (r *repository) SaveDailyBits(ctx context.Context, userID string, date time.Time, bits, revenueCents int) error {
+	query := `
+		INSERT INTO revenue_analytics (user_id, date, bits, estimated_bits_revenue_cents)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, date)
+		DO UPDATE SET
+			bits = EXCLUDED.bits,
+			estimated_bits_revenue_cents = EXCLUDED.estimated_bits_revenue_cents,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, date, bits, revenueCents)
+	return err
+}
+
+func (r *repository) GetLatestRevenueAnalytics(ctx context.Context, userID string) (*RevenueAnalytics, error) {
+	query := `
+		SELECT id, user_id, date, total_subscribers, tier1_subscribers, tier2_subscribers, tier3_subscribers,
+			   gifted_subscribers, sub_points, estimated_sub_revenue_cents, bits, estimated_bits_revenue_cents,
+			   ad_duration_seconds, ad_snooze_count, last_ad_at, next_ad_at, created_at, updated_at
+		FROM revenue_analytics
+		WHERE user_id = $1
+		ORDER BY date DESC
+		LIMIT 1
+	`
+
+	var revenue RevenueAnalytics
+	err := r.db.GetContext(ctx, &revenue, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &revenue, err
+}
+
+// GetMonthlyRevenue sums bits and tips per month and takes the month's last
+// subscription estimate and Patreon snapshot, newest month first
+func (r *repository) GetMonthlyRevenue(ctx context.Context, userID string, months int) ([]MonthlyRevenue, error) {
+	query := `
+		SELECT month,
+			   COALESCE(twitch.bits, 0) AS bits,
+			   COALESCE(twitch.estimated_bits_revenue_cents, 0) AS estimated_bits_revenue_cents,
+			   COALESCE(twitch.estimated_sub_revenue_cents, 0) AS estimated_sub_revenue_cents,
+			   COALESCE(patreon.patreon_income_cents, 0) AS patreon_income_cents,
+			   COALESCE(tips.tips, 0) AS tips,
+			   COALESCE(tips.tips_cents, 0) AS tips_cents,
+			   COALESCE(twitch.estimated_bits_revenue_cents, 0) + COALESCE(twitch.estimated_sub_revenue_cents, 0)
+			   + COALESCE(patreon.patreon_income_cents, 0) + COALESCE(tips.tips_cents, 0) AS total_revenue_cents
+		FROM (
+			SELECT
+				DATE_TRUNC('month', date)::date AS month,
+				COALESCE(SUM(bits), 0)::int AS bits,
+				COALESCE(SUM(estimated_bits_revenue_cents), 0)::int AS estimated_bits_revenue_cents,
+				COALESCE((ARRAY_AGG(estimated_sub_revenue_cents ORDER BY date DESC)
+					FILTER (WHERE total_subscribers > 0))[1], 0) AS estimated_sub_revenue_cents
+			FROM revenue_analytics
+			WHERE user_id = $1
+			GROUP BY DATE_TRUNC('month', date)
+		) twitch
+		FULL JOIN (
+			SELECT
+				DATE_TRUNC('month', date)::date AS month,
+				(ARRAY_AGG(estimated_income_cents ORDER BY date DESC))[1] AS patreon_income_cents
+			FROM patreon_revenue
+			WHERE user_id = $1
+			GROUP BY DATE_TRUNC('month', date)
+		) patreon USING (month)
+		FULL JOIN (
+			SELECT
+				DATE_TRUNC('month', donated_at AT TIME ZONE 'UTC')::date AS month,
+				COUNT(*)::int AS tips,
+				SUM(amount_cents)::int AS tips_cents
+			FROM streamlabs_donations
+			WHERE user_id = $1
+			GROUP BY 1
+		) tips USING (month)
+		ORDER BY month DESC
+		LIMIT $2
+	`
+
+	var trend []MonthlyRevenue
+	err := r.db.SelectContext(ctx, &trend, query, userID, months)
+	return trend, err
+}
+
+// Patreon Methods
+
+func (r *repository) SavePatreonToken(ctx context.Context, token *PatreonToken) error {
+	query := `
+		INSERT INTO user_patreon_tokens (
+			user_id, patreon_user_id, campaign_id, access_token, refresh_token, encryption_key_id, scopes, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			patreon_user_id = EXCLUDED.patreon_user_id,
+			campaign_id = EXCLUDED.campaign_id,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			encryption_key_id = EXCLUDED.encryption_key_id,
+			scopes = EXCLUDED.scopes,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.PatreonUserID, token.CampaignID, token.AccessToken, token.RefreshToken,
+		token.EncryptionKeyID, token.Scopes, token.ExpiresAt)
+	return err
+}
+
+func (r *repository) GetPatreonToken(ctx context.Context, userID string) (*PatreonToken, error) {
+	query := `
+		SELECT user_id, patreon_user_id, campaign_id, access_token, refresh_token,
+			   encryption_key_id, scopes, expires_at, created_at, updated_at
+		FROM user_patreon_tokens
+		WHERE user_id = $1
+	`
+
+	var token PatreonToken
+	err := r.db.GetContext(ctx, &token, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &token, err
+}
+
+// DeletePatreonConnection removes the user's Patreon token and income snapshots
+func (r *repository) DeletePatreonConnection(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM patreon_revenue WHERE user_id = $1`,
+		`DELETE FROM user_patreon_tokens WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to remove Patreon connection: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SavePatreonRevenue upserts the day's Patreon snapshot
+func (r *repository) SavePatreonRevenue(ctx context.Context, revenue *PatreonRevenue) error {
+	tiers, err := json.Marshal(revenue.Tiers)
+	if err != nil {
+		return fmt.Errorf("failed to encode Patreon tiers: %w", err)
+	}
+
+	query := `
+		INSERT INTO patreon_revenue (
+			user_id, date, campaign_id, patron_count, active_members,
+			monthly_pledge_cents, estimated_income_cents, tiers
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb)
+		ON CONFLICT (user_id, date)
+		DO UPDATE SET
+			campaign_id = EXCLUDED.campaign_id,
+			patron_count = EXCLUDED.patron_count,
+			active_members = EXCLUDED.active_members,
+			monthly_pledge_cents = EXCLUDED.monthly_pledge_cents,
+			estimated_income_cents = EXCLUDED.estimated_income_cents,
+			tiers = EXCLUDED.tiers,
+			updated_at = NOW()
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		revenue.UserID, revenue.Date, revenue.CampaignID, revenue.PatronCount, revenue.ActiveMembers,
+		revenue.MonthlyPledgeCents, revenue.EstimatedIncomeCents, string(tiers))
+	return err
+}
+
+func (r *repository) GetLatestPatreonRevenue(ctx context.Context, userID string) (*PatreonRevenue, error) {
+	query := `
+		SELECT id, user_id, date, campaign_id, patron_count, active_members,
+			   monthly_pledge_cents, estimated_income_cents, tiers, created_at, updated_at
+		FROM patreon_revenue
+		WHERE user_id = $1
+		ORDER BY date DESC
+		LIMIT 1
+	`
+
+	var row struct {
+		ID                   int             `db:"id"`
+		UserID               string          `db:"user_id"`
+		Date                 time.Time       `db:"date"`
+		CampaignID           string          `db:"campaign_id"`
+		PatronCount          int             `db:"patron_count"`
+		ActiveMembers        int             `db:"active_members"`
+		MonthlyPledgeCents   int             `db:"monthly_pledge_cents"`
+		EstimatedIncomeCents int             `db:"estimated_income_cents"`
+		Tiers                json.RawMessage `db:"tiers"`
+		CreatedAt            time.Time       `db:"created_at"`
+		UpdatedAt            time.Time       `db:"updated_at"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Patreon revenue: %w", err)
+	}
+
+	revenue := &PatreonRevenue{
+		ID:                   row.ID,
+		UserID:               row.UserID,
+		Date:                 row.Date,
+		CampaignID:           row.CampaignID,
+		PatronCount:          row.PatronCount,
+		ActiveMembers:        row.ActiveMembers,
+		MonthlyPledgeCents:   row.MonthlyPledgeCents,
+		EstimatedIncomeCents: row.EstimatedIncomeCents,
+		CreatedAt:            row.CreatedAt,
+		UpdatedAt:            row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Tiers, &revenue.Tiers); err != nil {
+		return nil, fmt.Errorf("failed to decode Patreon tiers: %w", err)
+	}
+	return revenue, nil
+}
+
+// Streamlabs Methods
+
+func (r *repository) SaveStreamlabsToken(ctx context.Context, token *StreamlabsToken) error {
+	query := `
+		INSERT INTO user_streamlabs_tokens (
+			user_id, streamlabs_user_id, access_token, refresh_token, encryption_key_id, scopes, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			streamlabs_user_id = EXCLUDED.streamlabs_user_id,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			encryption_key_id = EXCLUDED.encryption_key_id,
+			scopes = EXCLUDED.scopes,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.StreamlabsUserID, token.AccessToken, token.RefreshToken,
+		token.EncryptionKeyID, token.Scopes, token.ExpiresAt)
+	return err
+}
+
+func (r *repository) GetStreamlabsToken(ctx context.Context, userID string) (*StreamlabsToken, error) {
+	query := `
+		SELECT user_id, streamlabs_user_id, access_token, refresh_token,
+			   encryption_key_id, scopes, expires_at, created_at, updated_at
+		FROM user_streamlabs_tokens
+		WHERE user_id = $1
+	`
+
+	var token StreamlabsToken
+	err := r.db.GetContext(ctx, &token, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &token, err
+}
+
+// DeleteStreamlabsConnection removes the user's Streamlabs token and tips
+func (r *repository) DeleteStreamlabsConnection(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM streamlabs_donations WHERE user_id = $1`,
+		`DELETE FROM user_streamlabs_tokens WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to remove Streamlabs connection: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveStreamlabsDonations stores tips not stored yet and returns how many
+func (r *repository) SaveStreamlabsDonations(ctx context.Context, donations []StreamlabsDonation) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO streamlabs_donations (user_id, donation_id, amount_cents, donated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, donation_id) DO NOTHING
+	`
+	saved := 0
+	for _, donation := range donations {
+		result, err := tx.ExecContext(ctx, query, donation.UserID, donation.DonationID, donation.AmountCents, donation.DonatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save donation %d: %w", donation.DonationID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			saved += int(n)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit donations: %w", err)
+	}
+	return saved, nil
+}
+
+// GetLatestStreamlabsDonationID returns the newest stored donation ID, 0 when
+// none are stored
+func (r *repository) GetLatestStreamlabsDonationID(ctx context.Context, userID string) (int64, error) {
+	var id int64
+	err := r.db.GetContext(ctx, &id, `
+		SELECT COALESCE(MAX(donation_id), 0)
+		FROM streamlabs_donations
+		WHERE user_id = $1
+	`, userID)
+	return id, err
+}
+
+// Channel Points Methods
+
+// SaveChannelPointRedemption stores a redemption, ignoring redeliveries
+func (r *repository) SaveChannelPointRedemption(ctx context.Context, redemption *ChannelPointRedemption) error {
+	query := `
+		INSERT INTO channel_point_redemptions (
+			user_id, redemption_id, reward_id, reward_title, reward_cost, status, redeemed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (redemption_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		redemption.UserID, redemption.RedemptionID, redemption.RewardID, redemption.RewardTitle,
+		redemption.RewardCost, redemption.Status, redemption.RedeemedAt)
+	return err
+}
+
+func (r *repository) GetTopRewards(ctx context.Context, userID string, since time.Time, limit int) ([]RewardStats, error) {
+	query := `
+		SELECT reward_id,
+			   (ARRAY_AGG(reward_title ORDER BY redeemed_at DESC))[1] AS reward_title,
+			   COUNT(*) AS redemptions,
+			   COALESCE(SUM(reward_cost), 0) AS points_spent
+		FROM channel_point_redemptions
+		WHERE user_id = $1 AND redeemed_at >= $2
+		GROUP BY reward_id
+		ORDER BY redemptions DESC, points_spent DESC
+		LIMIT $3
+	`
+
+	var rewards []RewardStats
+	err := r.db.SelectContext(ctx, &rewards, query, userID, since, limit)
+	return rewards, err
+}
+
+func (r *repository) GetRedemptionTrend(ctx context.Context, userID string, since time.Time) ([]RedemptionTrendPoint, error) {
+	query := `
+		SELECT TO_CHAR(DATE(redeemed_at), 'YYYY-MM-DD') AS date,
+			   COUNT(*) AS redemptions,
+			   COALESCE(SUM(reward_cost), 0) AS points_spent
+		FROM channel_point_redemptions
+		WHERE user_id = $1 AND redeemed_at >= $2
+		GROUP BY DATE(redeemed_at)
+		ORDER BY DATE(redeemed_at) ASC
+	`
+
+	var trend []RedemptionTrendPoint
+	err := r.db.SelectContext(ctx, &trend, query, userID, since)
+	return trend, err
+}
+
+// Moderation Methods
+
+// SaveModerationAction stores a ban or timeout, ignoring redeliveries
+func (r *repository) SaveModerationAction(ctx context.Context, action *ModerationAction) error {
+	query := `
+		INSERT INTO moderation_actions (user_id, message_id, action, duration_seconds, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (message_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		action.UserID, action.MessageID, action.Action, action.DurationSeconds, action.CreatedAt)
+	return err
+}
+
+func (r *repository) GetModerationActionsByDay(ctx context.Context, userID string, since time.Time) ([]ModerationActionDay, error) {
+	query := `
+		SELECT DATE(created_at) AS date,
+			   COUNT(*) FILTER (WHERE action = 'ban') AS bans,
+			   COUNT(*) FILTER (WHERE action = 'timeout') AS timeouts
+		FROM moderation_actions
+		WHERE user_id = $1 AND created_at >= $2
+		GROUP BY DATE(created_at)
+		ORDER BY date ASC
+	`
+
+	var days []ModerationActionDay
+	err := r.db.SelectContext(ctx, &days, query, userID, since)
+	return days, err
+}
+
+func (r *repository) SaveModerationDailyMetrics(ctx context.Context, metrics *ModerationDailyMetrics) error {
+	query := `
+		INSERT INTO moderation_daily_metrics (user_id, date, active_bans, automod_level)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, date)
+		DO UPDATE SET
+			active_bans = EXCLUDED.active_bans,
+			automod_level = EXCLUDED.automod_level,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, metrics.UserID, metrics.Date, metrics.ActiveBans, metrics.AutoModLevel)
+	return err
+}
+
+func (r *repository) GetModerationDailyMetrics(ctx context.Context, userID string, since time.Time) ([]ModerationDailyMetrics, error) {
+	query := `
+		SELECT id, user_id, date, active_bans, automod_level, created_at, updated_at
+		FROM moderation_daily_metrics
+		WHERE user_id = $1 AND date >= $2
+		ORDER BY date ASC
+	`
+
+	var metrics []ModerationDailyMetrics
+	err := r.db.SelectContext(ctx, &metrics, query, userID, since)
+	return metrics, err
+}
+
+// Raid Methods
+
+// SaveRaid stores a raid, ignoring redeliveries
+func (r *repository) SaveRaid(ctx context.Context, raid *Raid) error {
+	query := `
+		INSERT INTO raids (user_id, message_id, direction, partner_id, partner_login, partner_name, viewers, raided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, message_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		raid.UserID, raid.MessageID, raid.Direction, raid.PartnerID, raid.PartnerLogin,
+		raid.PartnerName, raid.Viewers, raid.RaidedAt)
+	return err
+}
+
+// SaveFollowEvent records when a follow happened, ignoring redeliveries
+func (r *repository) SaveFollowEvent(ctx context.Context, userID, messageID string, followedAt time.Time) error {
+	query := `
+		INSERT INTO follow_events (user_id, message_id, followed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, messageID, followedAt)
+	return err
+}
+
+// GetRaids returns raids since the given time, newest first. Incoming raids
+// include the follows received within conversionWindow of the raid.
+func (r *repository) GetRaids(ctx context.Context, userID string, since time.Time, conversionWindow time.Duration) ([]Raid, error) {
+	query := `
+		SELECT r.id, r.user_id, r.message_id, r.direction, r.partner_id, r.partner_login, r.partner_name,
+			   r.viewers, r.raided_at,
+			   CASE WHEN r.direction = 'incoming' THEN (
+				   SELECT COUNT(*) FROM follow_events f
+				   WHERE f.user_id = r.user_id
+				   AND f.followed_at >= r.raided_at
+				   AND f.followed_at < r.raided_at + make_interval(secs => $3)
+			   ) ELSE 0 END AS followers_gained
+		FROM raids r
+		WHERE r.user_id = $1 AND r.raided_at >= $2
+		ORDER BY r.raided_at DESC
+	`
+
+	var raids []Raid
+	err := r.db.SelectContext(ctx, &raids, query, userID, since, conversionWindow.Seconds())
+	return raids, err
+}
+
+// EventSub Methods
+
+func (r *repository) SaveEventSubSubscription(ctx context.Context, sub *EventSubSubscription) error {
+	query := `
+		INSERT INTO eventsub_subscriptions (id, user_id, type, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, type)
+		DO UPDATE SET
+			id = EXCLUDED.id,
+			status = EXCLUDED.status,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, sub.ID, sub.UserID, sub.Type, sub.Status)
+	return err
+}
+
+func (r *repository) GetEventSubSubscription(ctx context.Context, userID, subType string) (*EventSubSubscription, error) {
+	query := `
+		SELECT id, user_id, type, status, created_at, updated_at
+		FROM eventsub_subscriptions
+		WHERE user_id = $1 AND type = $2
+	`
+
+	var sub EventSubSubscription
+	err := r.db.GetContext(ctx, &sub, query, userID, subType)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &sub, err
+}
+
+func (r *repository) UpdateEventSubSubscriptionStatus(ctx context.Context, id, status string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE eventsub_subscriptions SET status = $2, updated_at = NOW() WHERE id = $1", id, status)
+	return err
+}
+
+// GetUserIDByTwitchID maps a Twitch user ID to our user ID, or "" if unknown
+func (r *repository) GetUserIDByTwitchID(ctx context.Context, twitchUserID string) (string, error) {
+	var userID string
+	err := r.db.GetContext(ctx, &userID, "SELECT id FROM users WHERE twitch_user_id = $1 LIMIT 1", twitchUserID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// Consistency Methods
+
+// GetBroadcastTimes returns when the user streamed and uploaded since the given
+// time. Streams come from stream sessions plus past-broadcast VODs not linked
+// to a session, so VOD-only history still counts.
+func (r *repository) GetBroadcastTimes(ctx context.Context, userID string, since time.Time) ([]time.Time, []time.Time, error) {
+	query := `
+		SELECT 'stream' AS kind, started_at AS at
+		FROM stream_sessions
+		WHERE user_id = $1 AND started_at >= $2
+		UNION ALL
+		SELECT CASE WHEN video_type = 'upload' THEN 'upload' ELSE 'stream' END, published_at
+		FROM video_analytics
+		WHERE user_id = $1 AND published_at >= $2
+		  AND (video_type = 'upload' OR (video_type IN ('vod', 'archive') AND stream_session_id IS NULL))
+		ORDER BY at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get broadcast times: %w", err)
+	}
+	defer rows.Close()
+
+	var streams, uploads []time.Time
+	for rows.Next() {
+		var kind string
+		var at time.Time
+		if err := rows.Scan(&kind, &at); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan broadcast time: %w", err)
+		}
+		if kind == "upload" {
+			uploads = append(uploads, at)
+		} else {
+			streams = append(streams, at)
+		}
+	}
+	return streams, uploads, rows.Err()
+}
+
+// GetStreamActivity returns how many streams the user did since the given time
+// and how many hours they lasted. Like GetBroadcastTimes it counts stream
+// sessions plus past-broadcast VODs not linked to a session.
+func (r *repository) GetStreamActivity(ctx context.Context, userID string, since time.Time) (int, float64, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(hours), 0)
+		FROM (
+			SELECT COALESCE(duration_minutes, 0) / 60.0 AS hours
+			FROM stream_sessions
+			WHERE user_id = $1 AND started_at >= $2
+			UNION ALL
+			SELECT COALESCE(duration_seconds, 0) / 3600.0
+			FROM video_analytics
+			WHERE user_id = $1 AND published_at >= $2
+			  AND video_type IN ('vod', 'archive') AND stream_session_id IS NULL
+		) streams
+	`
+
+	var streams int
+	var hours float64
+	if err := r.db.QueryRowContext(ctx, query, userID, since).Scan(&streams, &hours); err != nil {
+		return 0, 0, fmt.Errorf("failed to get stream activity: %w", err)
+	}
+	return streams, hours, nil
+}
+
+// SaveChannelConsistency replaces the user's consistency figures
+func (r *repository) SaveChannelConsistency(ctx context.Context, consistency *ChannelConsistency) error {
+	query := `
+		INSERT INTO channel_consistency (
+			user_id, current_streak_weeks, longest_streak_weeks, average_streams_per_week,
+			average_uploads_per_week, longest_gap_days, last_stream_at,
+			streams_last_30_days, hours_streamed_last_30, computed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			current_streak_weeks = EXCLUDED.current_streak_weeks,
+			longest_streak_weeks = EXCLUDED.longest_streak_weeks,
+			average_streams_per_week = EXCLUDED.average_streams_per_week,
+			average_uploads_per_week = EXCLUDED.average_uploads_per_week,
+			longest_gap_days = EXCLUDED.longest_gap_days,
+			last_stream_at = EXCLUDED.last_stream_at,
+			streams_last_30_days = EXCLUDED.streams_last_30_days,
+			hours_streamed_last_30 = EXCLUDED.hours_streamed_last_30,
+			computed_at = EXCLUDED.computed_at
+		RETURNING computed_at
+	`
+	return r.db.QueryRowxContext(ctx, query, consistency.UserID, consistency.CurrentStreakWeeks,
+		consistency.LongestStreakWeeks, consistency.AverageStreamsPerWeek, consistency.AverageUploadsPerWeek,
+		consistency.LongestGapDays, consistency.LastStreamAt, consistency.StreamsLast30Days,
+		consistency.HoursStreamedLast30).Scan(&consistency.ComputedAt)
+}
+
+// GetChannelConsistency returns the stored consistency figures, or nil if none
+// were computed yet
+func (r *repository) GetChannelConsistency(ctx context.Context, userID string) (*ChannelConsistency, error) {
+	query := `
+		SELECT user_id, current_streak_weeks, longest_streak_weeks, average_streams_per_week,
+			   average_uploads_per_week, longest_gap_days, last_stream_at,
+			   streams_last_30_days, hours_streamed_last_30, computed_at
+		FROM channel_consistency
+		WHERE user_id = $1
+	`
+
+	var consistency ChannelConsistency
+	err := r.db.GetContext(ctx, &consistency, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel consistency: %w", err)
+	}
+	return &consistency, nil
+}
+
+// Weekly Insights Methods
+
+// SaveWeeklyInsights stores the insights of a week, replacing any already saved
+func (r *repository) SaveWeeklyInsights(ctx context.Context, insights *WeeklyInsights) error {
+	data, err := json.Marshal(insights.Insights)
+	if err != nil {
+		return fmt.Errorf("failed to encode insights: %w", err)
+	}
+
+	query := `
+		INSERT INTO weekly_insights (user_id, week_start, provider, model, insights)
+		VALUES ($1, $2, $3, $4, $5::jsonb)
+		ON CONFLICT (user_id, week_start) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			model = EXCLUDED.model,
+			insights = EXCLUDED.insights,
+			created_at = NOW()
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowxContext(ctx, query, insights.UserID, insights.WeekStart, insights.Provider,
+		insights.Model, string(data)).Scan(&insights.ID, &insights.CreatedAt)
+}
+
+// GetWeeklyInsights returns the user's most recent weeks of insights, newest first
+func (r *repository) GetWeeklyInsights(ctx context.Context, userID string, limit int) ([]WeeklyInsights, error) {
+	query := `
+		SELECT id, user_id, week_start, provider, model, insights, created_at
+		FROM weekly_insights
+		WHERE user_id = $1
+		ORDER BY week_start DESC
+		LIMIT $2
+	`
+
+	var rows []struct {
+		ID        int             `db:"id"`
+		UserID    string          `db:"user_id"`
+		WeekStart time.Time       `db:"week_start"`
+		Provider  string          `db:"provider"`
+		Model     string          `db:"model"`
+		Insights  json.RawMessage `db:"insights"`
+		CreatedAt time.Time       `db:"created_at"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, userID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get weekly insights: %w", err)
+	}
+
+	weeks := make([]WeeklyInsights, 0, len(rows))
+	for _, row := range rows {
+		week := WeeklyInsights{
+			ID:        row.ID,
+			UserID:    row.UserID,
+			WeekStart: row.WeekStart,
+			Provider:  row.Provider,
+			Model:     row.Model,
+			CreatedAt: row.CreatedAt,
+		}
+		if err := json.Unmarshal(row.Insights, &week.Insights); err != nil {
+			return nil, fmt.Errorf("failed to decode weekly insights %d: %w", row.ID, err)
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks, nil
+}
+
+// HasWeeklyInsights reports whether insights were already generated for the week
+func (r *repository) HasWeeklyInsights(ctx context.Context, userID string, weekStart time.Time) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM weekly_insights WHERE user_id = $1 AND week_start = $2)`
+	if err := r.db.QueryRowContext(ctx, query, userID, weekStart).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check weekly insights: %w", err)
+	}
+	return exists, nil
+}
+
+// Media Kit Methods
+
+func (r *repository) CreateMediaKit(ctx context.Context, kit *MediaKit) error {
+	query := `
+		INSERT INTO media_kits (user_id, status)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowxContext(ctx, query, kit.UserID, kit.Status).Scan(&kit.ID, &kit.CreatedAt)
+}
+
+func (r *repository) CompleteMediaKit(ctx context.Context, id int, pdf []byte) error {
+	query := `
+		UPDATE media_kits 
+		SET status = 'completed', pdf = $2, size_bytes = $3, completed_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, pdf, len(pdf))
+	return err
+}
+
+func (r *repository) FailMediaKit(ctx context.Context, id int, errorMessage string) error {
+	query := `
+		UPDATE media_kits 
+		SET status = 'failed', error_message = $2, completed_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, errorMessage)
+	return err
+}
+
+func (r *repository) GetMediaKit(ctx context.Context, userID string, id int) (*MediaKit, error) {
+	query := `
+		SELECT id, user_id, status, size_bytes, COALESCE(error_message, '') as error_message,
+			   created_at, completed_at
+		FROM media_kits
+		WHERE user_id = $1 AND id = $2
+	`
+
+	var kit MediaKit
+	err := r.db.GetContext(ctx, &kit, query, userID, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &kit, err
+}
+
+// GetPendingMediaKit returns a kit still being generated for the user, if any
+func (r *repository) GetPendingMediaKit(ctx context.Context, userID string) (*MediaKit, error) {
+	query := `
+		SELECT id, user_id, status, size_bytes, COALESCE(error_message, '') as error_message,
+			   created_at, completed_at
+		FROM media_kits
+		WHERE user_id = $1 AND status = 'pending' AND created_at > NOW() - INTERVAL '10 minutes'
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var kit MediaKit
+	err := r.db.GetContext(ctx, &kit, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &kit, err
+}
+
+func (r *repository) GetMediaKitPDF(ctx context.Context, userID string, id int) ([]byte, error) {
+	query := `
+		SELECT pdf FROM media_kits
+		WHERE user_id = $1 AND id = $2 AND status = 'completed'
+	`
+
+	var pdf []byte
+	err := r.db.QueryRowContext(ctx, query, userID, id).Scan(&pdf)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return pdf, err
+}
+
+// DeleteOldMediaKits keeps only the user's most recent kits
+func (r *repository) DeleteOldMediaKits(ctx context.Context, userID string, keep int) error {
+	query := `
+		DELETE FROM media_kits
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM media_kits WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, keep)
+	return err
+}
+
+func (r *repository) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	query := `
+		SELECT 
+			COUNT(DISTINCT user_id) as total_users,
+			COUNT(DISTINCT CASE WHEN created_at >= CURRENT_DATE - INTERVAL '7 days' THEN user_id END) as active_users,
+			COUNT(*) as total_jobs,
+			COUNT(CASE WHEN status = 'completed' THEN 1 END) as successful_jobs,
+			COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed_jobs,
+			COALESCE(MAX(created_at), NOW()) as last_collection_run
+		FROM analytics_jobs
+		WHERE created_at >= CURRENT_DATE - INTERVAL '30 days'
+	`
+
+	var stats SystemStats
+	row := r.db.QueryRowContext(ctx, query)
+
+	err := row.Scan(
+		&stats.TotalUsers, &stats.ActiveUsers, &stats.TotalJobs,
 		&stats.SuccessfulJobs, &stats.FailedJobs, &stats.LastCollectionRun,
 	)
 
@@ -685,6 +2895,554 @@ func (r *repository) GetSystemStats(ctx context.Context) (*SystemStats, error) {
 	return &stats, nil
 }
 
+// Stream Schedule Methods
+
+// SaveStreamSchedule upserts the fetched schedule segments and removes segments
+// starting at or after from that are no longer on the schedule. Earlier
+// segments are kept as history.
+func (r *repository) SaveStreamSchedule(ctx context.Context, userID string, from time.Time, segments []StreamScheduleSegment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var fetchedAt time.Time
+	if err := tx.QueryRowContext(ctx, `SELECT NOW()`).Scan(&fetchedAt); err != nil {
+		return fmt.Errorf("failed to get transaction time: %w", err)
+	}
+
+	upsertQuery := `
+		INSERT INTO stream_schedule_segments (
+			user_id, segment_id, title, category_name, start_time, end_time, is_recurring, canceled, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, segment_id) DO UPDATE SET
+			title = EXCLUDED.title,
+			category_name = EXCLUDED.category_name,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			is_recurring = EXCLUDED.is_recurring,
+			canceled = EXCLUDED.canceled,
+			updated_at = EXCLUDED.updated_at
+	`
+	for _, segment := range segments {
+		if _, err := tx.ExecContext(ctx, upsertQuery, userID, segment.SegmentID, segment.Title, segment.CategoryName,
+			segment.StartTime, segment.EndTime, segment.IsRecurring, segment.Canceled, fetchedAt); err != nil {
+			return fmt.Errorf("failed to save schedule segment %s: %w", segment.SegmentID, err)
+		}
+	}
+
+	deleteQuery := `
+		DELETE FROM stream_schedule_segments
+		WHERE user_id = $1 AND start_time >= $2 AND updated_at <> $3
+	`
+	if _, err := tx.ExecContext(ctx, deleteQuery, userID, from, fetchedAt); err != nil {
+		return fmt.Errorf("failed to remove unscheduled segments: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetStreamSchedule returns the segments starting between from and to, oldest first
+func (r *repository) GetStreamSchedule(ctx context.Context, userID string, from, to time.Time) ([]StreamScheduleSegment, error) {
+	query := `
+		SELECT id, user_id, segment_id, title, category_name, start_time, end_time, is_recurring, canceled
+		FROM stream_schedule_segments
+		WHERE user_id = $1 AND start_time >= $2 AND start_time < $3
+		ORDER BY start_time
+	`
+
+	var segments []StreamScheduleSegment
+	if err := r.db.SelectContext(ctx, &segments, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to get stream schedule: %w", err)
+	}
+	return segments, nil
+}
+
+// GetScheduleTrackedSince returns when the user's schedule was first collected,
+// or nil if it never was
+func (r *repository) GetScheduleTrackedSince(ctx context.Context, userID string) (*time.Time, error) {
+	query := `SELECT MIN(created_at) FROM stream_schedule_segments WHERE user_id = $1`
+
+	var since sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&since); err != nil {
+		return nil, fmt.Errorf("failed to get schedule tracking start: %w", err)
+	}
+	if !since.Valid {
+		return nil, nil
+	}
+	return &since.Time, nil
+}
+
+// Creator Goal Methods
+
+// SaveCreatorGoals stores the goals Twitch currently returns with a snapshot
+// for date, and marks goals that are no longer returned as ended
+func (r *repository) SaveCreatorGoals(ctx context.Context, userID string, date time.Time, goals []CreatorGoal) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seenAt time.Time
+	if err := tx.QueryRowContext(ctx, `SELECT NOW()`).Scan(&seenAt); err != nil {
+		return fmt.Errorf("failed to get transaction time: %w", err)
+	}
+
+	goalQuery := `
+		INSERT INTO creator_goals (
+			user_id, goal_id, goal_type, description, current_amount, target_amount, started_at, last_seen_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, goal_id) DO UPDATE SET
+			goal_type = EXCLUDED.goal_type,
+			description = EXCLUDED.description,
+			current_amount = EXCLUDED.current_amount,
+			target_amount = EXCLUDED.target_amount,
+			last_seen_at = EXCLUDED.last_seen_at,
+			ended_at = NULL
+		RETURNING id
+	`
+	snapshotQuery := `
+		INSERT INTO creator_goal_snapshots (goal_id, date, current_amount, target_amount)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (goal_id, date)
+		DO UPDATE SET
+			current_amount = EXCLUDED.current_amount,
+			target_amount = EXCLUDED.target_amount,
+			updated_at = NOW()
+	`
+	for _, goal := range goals {
+		var id int
+		if err := tx.QueryRowContext(ctx, goalQuery, userID, goal.GoalID, goal.Type, goal.Description,
+			goal.CurrentAmount, goal.TargetAmount, goal.StartedAt, seenAt).Scan(&id); err != nil {
+			return fmt.Errorf("failed to save creator goal %s: %w", goal.GoalID, err)
+		}
+		if _, err := tx.ExecContext(ctx, snapshotQuery, id, date, goal.CurrentAmount, goal.TargetAmount); err != nil {
+			return fmt.Errorf("failed to save snapshot of creator goal %s: %w", goal.GoalID, err)
+		}
+	}
+
+	endQuery := `
+		UPDATE creator_goals SET ended_at = $2
+		WHERE user_id = $1 AND ended_at IS NULL AND last_seen_at < $2
+	`
+	if _, err := tx.ExecContext(ctx, endQuery, userID, seenAt); err != nil {
+		return fmt.Errorf("failed to end creator goals: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetCreatorGoals returns active goals and goals that ended since endedSince,
+// newest first
+func (r *repository) GetCreatorGoals(ctx context.Context, userID string, endedSince time.Time) ([]CreatorGoal, error) {
+	query := `
+		SELECT id, user_id, goal_id, goal_type, description, current_amount, target_amount,
+			   started_at, last_seen_at, ended_at
+		FROM creator_goals
+		WHERE user_id = $1 AND (ended_at IS NULL OR ended_at >= $2)
+		ORDER BY started_at DESC
+	`
+
+	var goals []CreatorGoal
+	if err := r.db.SelectContext(ctx, &goals, query, userID, endedSince); err != nil {
+		return nil, fmt.Errorf("failed to get creator goals: %w", err)
+	}
+	return goals, nil
+}
+
+// GetCreatorGoalSnapshots returns the daily progress of the user's goals since
+// the given date, oldest first
+func (r *repository) GetCreatorGoalSnapshots(ctx context.Context, userID string, since time.Time) ([]CreatorGoalSnapshot, error) {
+	query := `
+		SELECT s.goal_id, s.date, s.current_amount, s.target_amount
+		FROM creator_goal_snapshots s
+		JOIN creator_goals g ON g.id = s.goal_id
+		WHERE g.user_id = $1 AND s.date >= $2
+		ORDER BY s.date ASC
+	`
+
+	var snapshots []CreatorGoalSnapshot
+	if err := r.db.SelectContext(ctx, &snapshots, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to get creator goal snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// Highlight Suggestion Methods
+
+// GetClipMoments returns the user's clips whose source VOD, published since the
+// given time, is collected too
+func (r *repository) GetClipMoments(ctx context.Context, userID string, since time.Time) ([]ClipMoment, error) {
+	query := `
+		SELECT c.video_id AS clip_id, COALESCE(c.title, '') AS title, COALESCE(c.view_count, 0) AS view_count,
+			   COALESCE(c.duration_seconds, 0) AS duration_seconds, v.video_id,
+			   c.vod_offset_seconds AS offset_seconds, COALESCE(v.duration_seconds, 0) AS video_duration
+		FROM video_analytics c
+		JOIN video_analytics v ON v.video_id = c.source_video_id AND v.user_id = c.user_id
+		WHERE c.user_id = $1 AND c.video_type = 'clip' AND c.vod_offset_seconds IS NOT NULL
+		  AND v.published_at >= $2
+		ORDER BY v.video_id, c.vod_offset_seconds
+	`
+
+	var moments []ClipMoment
+	if err := r.db.SelectContext(ctx, &moments, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to get clip moments: %w", err)
+	}
+	return moments, nil
+}
+
+// ReplaceHighlightSuggestions swaps the user's suggestions for a new set
+func (r *repository) ReplaceHighlightSuggestions(ctx context.Context, userID string, suggestions []HighlightSuggestion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM highlight_suggestions WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear highlight suggestions: %w", err)
+	}
+
+	query := `
+		INSERT INTO highlight_suggestions (
+			user_id, video_id, start_seconds, end_seconds, clip_count, total_clip_views, clips
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	for _, suggestion := range suggestions {
+		clips, err := json.Marshal(suggestion.Clips)
+		if err != nil {
+			return fmt.Errorf("failed to encode highlight clips: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, userID, suggestion.VideoID, suggestion.StartSeconds,
+			suggestion.EndSeconds, suggestion.ClipCount, suggestion.TotalClipViews, clips); err != nil {
+			return fmt.Errorf("failed to save highlight suggestion: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetHighlightSuggestions returns the most clipped moments first, optionally
+// only those of one VOD
+func (r *repository) GetHighlightSuggestions(ctx context.Context, userID, videoID string, limit int) ([]HighlightSuggestion, error) {
+	query := `
+		SELECT h.id, h.user_id, h.video_id, COALESCE(v.title, '') AS video_title, h.start_seconds, h.end_seconds,
+			   h.clip_count, h.total_clip_views, h.clips, h.computed_at
+		FROM highlight_suggestions h
+		LEFT JOIN video_analytics v ON v.video_id = h.video_id
+		WHERE h.user_id = $1 AND ($2::text = '' OR h.video_id = $2::text)
+		ORDER BY h.clip_count DESC, h.total_clip_views DESC
+		LIMIT $3
+	`
+
+	var rows []struct {
+		HighlightSuggestion
+		ClipsJSON json.RawMessage `db:"clips"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, userID, videoID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get highlight suggestions: %w", err)
+	}
+
+	suggestions := make([]HighlightSuggestion, 0, len(rows))
+	for _, row := range rows {
+		suggestion := row.HighlightSuggestion
+		if err := json.Unmarshal(row.ClipsJSON, &suggestion.Clips); err != nil {
+			return nil, fmt.Errorf("failed to decode clips of highlight suggestion %d: %w", suggestion.ID, err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+// Repurposing Methods
+
+// GetVideoDayViews returns the daily view counts of the user's videos
+// published at least days ago, for their first days after publishing
+func (r *repository) GetVideoDayViews(ctx context.Context, userID string, days int) ([]VideoDayViews, error) {
+	query := `
+		SELECT s.video_id, COALESCE(va.video_type, '') AS video_type,
+			   s.date - (va.published_at AT TIME ZONE 'UTC')::date AS day,
+			   COALESCE(s.view_count, 0) AS view_count, COALESCE(va.view_count, 0) AS current_views
+		FROM video_daily_stats s
+		JOIN video_analytics va ON va.video_id = s.video_id
+		WHERE va.user_id = $1
+		  AND va.published_at <= NOW() - make_interval(days => $2)
+		  AND s.date - (va.published_at AT TIME ZONE 'UTC')::date BETWEEN 0 AND $2
+		ORDER BY s.video_id, s.date
+	`
+
+	var views []VideoDayViews
+	err := r.db.SelectContext(ctx, &views, query, userID, days)
+	return views, err
+}
+
+// GetLanguageShares groups the user's videos and clips published since the
+// given time by the language they were broadcast in, most viewed first
+func (r *repository) GetLanguageShares(ctx context.Context, userID string, since time.Time) ([]LanguageShare, error) {
+	query := `
+		SELECT COALESCE(NULLIF(language, ''), 'unknown') AS language,
+			   COUNT(*) FILTER (WHERE video_type <> 'clip') AS videos,
+			   COUNT(*) FILTER (WHERE video_type = 'clip') AS clips,
+			   COALESCE(SUM(view_count), 0) AS views
+		FROM video_analytics
+		WHERE user_id = $1 AND published_at >= $2
+		GROUP BY 1
+		ORDER BY views DESC, language
+	`
+
+	var shares []LanguageShare
+	err := r.db.SelectContext(ctx, &shares, query, userID, since)
+	return shares, err
+}
+
+// GetBroadcasterLanguage returns the channel's latest known language setting,
+// or "" when none was collected
+func (r *repository) GetBroadcasterLanguage(ctx context.Context, userID string) (string, error) {
+	query := `
+		SELECT broadcaster_language
+		FROM channel_analytics
+		WHERE user_id = $1 AND broadcaster_language IS NOT NULL
+		ORDER BY date DESC
+		LIMIT 1
+	`
+
+	var language string
+	err := r.db.GetContext(ctx, &language, query, userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return language, err
+}
+
+// GetRecentClips returns the user's clips published since the given time
+func (r *repository) GetRecentClips(ctx context.Context, userID string, since time.Time) ([]ClipReadiness, error) {
+	query := `
+		SELECT video_id, COALESCE(title, '') AS title, COALESCE(duration_seconds, 0) AS duration_seconds,
+			   COALESCE(view_count, 0) AS view_count, COALESCE(thumbnail_url, '') AS thumbnail_url, published_at
+		FROM video_analytics
+		WHERE user_id = $1 AND video_type = 'clip' AND published_at >= $2
+		ORDER BY published_at DESC
+	`
+
+	var clips []ClipReadiness
+	if err := r.db.SelectContext(ctx, &clips, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to get recent clips: %w", err)
+	}
+	return clips, nil
+}
+
+// Collaboration Methods
+
+// GetCollabContent returns the user's streams and their uploads and highlights
+// since the given time, with the collaborators they were tagged with. Archives
+// are left out as they repeat their stream, and clips are titled by viewers.
+func (r *repository) GetCollabContent(ctx context.Context, userID string, since time.Time) ([]CollabContent, error) {
+	query := `
+		SELECT c.*, t.content_id IS NOT NULL AS tagged, COALESCE(t.collaborators, '[]'::jsonb) AS collaborators
+		FROM (
+			SELECT 'stream' AS content_type, stream_id AS content_id, COALESCE(title, '') AS title, started_at AS occurred_at,
+				   COALESCE(average_viewers, 0) AS average_viewers, COALESCE(peak_viewers, 0) AS peak_viewers,
+				   COALESCE(followers_gained, 0) AS followers_gained, 0 AS view_count
+			FROM stream_sessions
+			WHERE user_id = $1 AND started_at >= $2
+			UNION ALL
+			SELECT 'video', video_id, COALESCE(title, ''), published_at, 0, 0, 0, COALESCE(view_count, 0)
+			FROM video_analytics
+			WHERE user_id = $1 AND published_at >= $2 AND video_type IN ('upload', 'highlight')
+		) c
+		LEFT JOIN collaboration_tags t
+			ON t.user_id = $1 AND t.content_type = c.content_type AND t.content_id = c.content_id
+		ORDER BY c.occurred_at DESC
+	`
+
+	var rows []struct {
+		CollabContent
+		Tagged        bool            `db:"tagged"`
+		Collaborators json.RawMessage `db:"collaborators"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to get collab content: %w", err)
+	}
+
+	content := make([]CollabContent, 0, len(rows))
+	for _, row := range rows {
+		item := row.CollabContent
+		if row.Tagged {
+			if err := json.Unmarshal(row.Collaborators, &item.Collaborators); err != nil {
+				return nil, fmt.Errorf("failed to decode collaborators of %s %s: %w", item.ContentType, item.ContentID, err)
+			}
+			item.Source = "tagged"
+		}
+		content = append(content, item)
+	}
+	return content, nil
+}
+
+// SetCollaborators tags one of the user's streams or videos with collaborators,
+// replacing any earlier tags. It returns false if the user has no such content.
+func (r *repository) SetCollaborators(ctx context.Context, userID, contentType, contentID string, logins []string) (bool, error) {
+	data, err := json.Marshal(logins)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode collaborators: %w", err)
+	}
+
+	query := `
+		INSERT INTO collaboration_tags (user_id, content_type, content_id, collaborators)
+		SELECT $1, $2::text, $3::text, $4::jsonb
+		WHERE ($2::text = 'stream' AND EXISTS (SELECT 1 FROM stream_sessions WHERE user_id = $1 AND stream_id = $3::text))
+		   OR ($2::text = 'video' AND EXISTS (SELECT 1 FROM video_analytics WHERE user_id = $1 AND video_id = $3::text))
+		ON CONFLICT (user_id, content_type, content_id) DO UPDATE SET
+			collaborators = EXCLUDED.collaborators,
+			updated_at = NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, contentType, contentID, string(data))
+	if err != nil {
+		return false, fmt.Errorf("failed to save collaborators: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// DeleteCollaborators removes the tags of a stream or video so its
+// collaborators are detected from the title again
+func (r *repository) DeleteCollaborators(ctx context.Context, userID, contentType, contentID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM collaboration_tags WHERE user_id = $1 AND content_type = $2 AND content_id = $3
+	`, userID, contentType, contentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete collaborators: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Title Experiment Methods
+
+// ListTitleExperiments returns the user's experiments, newest first
+func (r *repository) ListTitleExperiments(ctx context.Context, userID string) ([]TitleExperiment, error) {
+	query := `
+		SELECT id, user_id, name, variant_a, variant_b, created_at, updated_at
+		FROM title_experiments
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	experiments := []TitleExperiment{}
+	if err := r.db.SelectContext(ctx, &experiments, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list title experiments: %w", err)
+	}
+	return experiments, nil
+}
+
+// GetTitleExperiment returns one of the user's experiments, nil if there is
+// no such experiment
+func (r *repository) GetTitleExperiment(ctx context.Context, userID string, id int) (*TitleExperiment, error) {
+	query := `
+		SELECT id, user_id, name, variant_a, variant_b, created_at, updated_at
+		FROM title_experiments
+		WHERE user_id = $1 AND id = $2
+	`
+
+	var experiment TitleExperiment
+	err := r.db.GetContext(ctx, &experiment, query, userID, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get title experiment: %w", err)
+	}
+	return &experiment, nil
+}
+
+func (r *repository) CreateTitleExperiment(ctx context.Context, experiment *TitleExperiment) error {
+	query := `
+		INSERT INTO title_experiments (user_id, name, variant_a, variant_b)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, experiment.UserID, experiment.Name, experiment.VariantA, experiment.VariantB).
+		Scan(&experiment.ID, &experiment.CreatedAt, &experiment.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create title experiment: %w", err)
+	}
+	return nil
+}
+
+// DeleteTitleExperiment deletes an experiment with its assignments. It returns
+// false if the user has no such experiment.
+func (r *repository) DeleteTitleExperiment(ctx context.Context, userID string, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM title_experiments WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete title experiment: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// AssignExperimentVideo puts one of the user's VODs in a variant of their
+// experiment, moving it if it was in the other one. It returns false if the
+// user has no such experiment or VOD. Clips can't be assigned as viewers
+// title them.
+func (r *repository) AssignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID, variant string) (bool, error) {
+	query := `
+		INSERT INTO title_experiment_videos (experiment_id, user_id, video_id, variant)
+		SELECT $2, $1, $3::text, $4
+		WHERE EXISTS (SELECT 1 FROM title_experiments WHERE user_id = $1 AND id = $2)
+		  AND EXISTS (SELECT 1 FROM video_analytics WHERE user_id = $1 AND video_id = $3::text AND video_type <> 'clip')
+		ON CONFLICT (experiment_id, video_id) DO UPDATE SET
+			variant = EXCLUDED.variant
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, experimentID, videoID, variant)
+	if err != nil {
+		return false, fmt.Errorf("failed to assign experiment video: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `UPDATE title_experiments SET updated_at = NOW() WHERE id = $1`, experimentID)
+	return true, err
+}
+
+// UnassignExperimentVideo takes a VOD out of an experiment, returning false
+// if it wasn't in it
+func (r *repository) UnassignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM title_experiment_videos WHERE user_id = $1 AND experiment_id = $2 AND video_id = $3
+	`, userID, experimentID, videoID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unassign experiment video: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// GetExperimentVideos returns the VODs assigned in all of the user's
+// experiments with their views per day and the clips made from them, newest
+// first
+func (r *repository) GetExperimentVideos(ctx context.Context, userID string) ([]ExperimentVideo, error) {
+	query := `
+		SELECT e.experiment_id, e.video_id, e.variant, COALESCE(v.title, '') AS title,
+			   COALESCE(v.video_type, '') AS video_type, v.published_at, COALESCE(v.view_count, 0) AS view_count,
+			   COALESCE(` + viewsPerDaySQL + `, 0)::float8 AS views_per_day,
+			   (SELECT COUNT(*) FROM video_analytics c
+				WHERE c.user_id = e.user_id AND c.source_video_id = e.video_id AND c.video_type = 'clip') AS clips
+		FROM title_experiment_videos e
+		JOIN video_analytics v ON v.user_id = e.user_id AND v.video_id = e.video_id
+		WHERE e.user_id = $1
+		ORDER BY v.published_at DESC NULLS LAST, e.video_id
+	`
+
+	videos := []ExperimentVideo{}
+	if err := r.db.SelectContext(ctx, &videos, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get experiment videos: %w", err)
+	}
+	return videos, nil
+}
+
 // CheckUserAnalyticsData checks if a user has analytics data and when it was last updated
 func (r *repository) CheckUserAnalyticsData(ctx context.Context, userID string) (bool, *time.Time, error) {
 	query := `
@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/media"
+)
+
+const (
+	// shortMaxSeconds is the longest clip that fits every short-form platform;
+	// YouTube Shorts is the strictest
+	shortMaxSeconds = 60
+	// minRepurposeViewsPerDay is the velocity below which a clip is unlikely to
+	// carry over to another platform
+	minRepurposeViewsPerDay = 1.0
+)
+
+// GetRepurposeCandidates checks the clips of the last days for short-form
+// suitability and returns the best limit of them
+func (s *service) GetRepurposeCandidates(ctx context.Context, userID string, days, limit int) (*RepurposeReport, error) {
+	clips, err := s.repo.GetRecentClips(ctx, userID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildRepurposeReport(clips, time.Now())
+	report.Days = days
+	if len(report.Clips) > limit {
+		report.Clips = report.Clips[:limit]
+	}
+	return report, nil
+}
+
+func buildRepurposeReport(clips []ClipReadiness, now time.Time) *RepurposeReport {
+	report := &RepurposeReport{
+		ClipsChecked: len(clips),
+		Clips:        make([]ClipReadiness, 0, len(clips)),
+	}
+
+	for _, clip := range clips {
+		clip.ViewsPerDay = viewsPerDay(clip.ViewCount, clip.PublishedAt, now)
+		clip.Issues = []string{}
+		if clip.Duration >= shortMaxSeconds {
+			clip.Issues = append(clip.Issues, "too_long")
+		}
+		if media.HasThumbnail(clip.ThumbnailURL) {
+			clip.VerticalThumbnailURL = media.ThumbnailPath(clip.ClipID, media.AspectVertical)
+		} else {
+			clip.Issues = append(clip.Issues, "no_thumbnail")
+		}
+		if clip.ViewsPerDay < minRepurposeViewsPerDay {
+			clip.Issues = append(clip.Issues, "low_velocity")
+		}
+		clip.Ready = len(clip.Issues) == 0
+		if clip.Ready {
+			report.ReadyCount++
+		}
+		report.Clips = append(report.Clips, clip)
+	}
+
+	sort.SliceStable(report.Clips, func(i, j int) bool {
+		if report.Clips[i].Ready != report.Clips[j].Ready {
+			return report.Clips[i].Ready
+		}
+		return report.Clips[i].ViewsPerDay > report.Clips[j].ViewsPerDay
+	})
+	return report
+}
+
+// viewsPerDay averages views over the days since publishing. Clips younger
+// than a day count as a full day so a fresh spike isn't overstated.
+func viewsPerDay(views int, publishedAt *time.Time, now time.Time) float64 {
+	days := 1.0
+	if publishedAt != nil {
+		if age := now.Sub(*publishedAt).Hours() / 24; age > days {
+			days = age
+		}
+	}
+	return float64(views) / days
+}
@@ -0,0 +1,58 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRepurposeReport(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+	const day = 24 * time.Hour
+	thumb := "https://clips-media-assets2.twitch.tv/abc-preview-480x272.jpg"
+
+	clips := []ClipReadiness{
+		{ClipID: "slow", Duration: 20, ViewCount: 40, ThumbnailURL: thumb, PublishedAt: ago(10 * day)},
+		{ClipID: "long", Duration: 60, ViewCount: 5000, ThumbnailURL: thumb, PublishedAt: ago(1 * day)},
+		{ClipID: "fast", Duration: 45, ViewCount: 300, ThumbnailURL: thumb, PublishedAt: ago(3 * day)},
+		// A clip an hour old counts a full day
+		{ClipID: "fresh", Duration: 30, ViewCount: 12, ThumbnailURL: thumb, PublishedAt: ago(time.Hour)},
+		{ClipID: "nothumb", Duration: 30, ViewCount: 900, PublishedAt: ago(2 * day)},
+	}
+
+	report := buildRepurposeReport(clips, now)
+
+	assert.Equal(t, 5, report.ClipsChecked)
+	assert.Equal(t, 3, report.ReadyCount)
+	require.Len(t, report.Clips, 5)
+
+	var order []string
+	for _, clip := range report.Clips {
+		order = append(order, clip.ClipID)
+	}
+	assert.Equal(t, []string{"fast", "fresh", "slow", "long", "nothumb"}, order)
+
+	assert.Equal(t, 100.0, report.Clips[0].ViewsPerDay)
+	assert.Equal(t, "/api/media/thumbnails/fast?aspect=vertical", report.Clips[0].VerticalThumbnailURL)
+	assert.Equal(t, 12.0, report.Clips[1].ViewsPerDay)
+	assert.Equal(t, []string{"too_long"}, report.Clips[3].Issues)
+	assert.Equal(t, []string{"no_thumbnail"}, report.Clips[4].Issues)
+	assert.Empty(t, report.Clips[4].VerticalThumbnailURL)
+}
+
+func TestBuildRepurposeReportLowVelocity(t *testing.T) {
+	now := time.Now()
+	published := now.AddDate(0, 0, -30)
+	report := buildRepurposeReport([]ClipReadiness{
+		{ClipID: "stale", Duration: 15, ViewCount: 10, ThumbnailURL: "https://clips-media-assets2.twitch.tv/x.jpg", PublishedAt: &published},
+	}, now)
+
+	assert.False(t, report.Clips[0].Ready)
+	assert.Equal(t, []string{"low_velocity"}, report.Clips[0].Issues)
+}
@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// RunRetention rolls up, archives and deletes raw daily rows older than the
+// configured retention window. It is recorded as a "retention" analytics job.
+func RunRetention(ctx context.Context, repo Repository, cfg config.RetentionConfig) error {
+	cutoff := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -cfg.RawDays)
+
+	job := &AnalyticsJob{
+		JobType:  "retention",
+		Status:   "running",
+		DataDate: &cutoff,
+	}
+	if err := repo.CreateAnalyticsJob(ctx, job); err != nil {
+		log.Printf("Failed to create retention job: %v", err)
+	}
+
+	defer func() {
+		if job.ID > 0 {
+			status := "completed"
+			var errorMsg *string
+			if job.ErrorMessage != "" {
+				status = "failed"
+				errorMsg = &job.ErrorMessage
+			}
+			repo.UpdateAnalyticsJob(ctx, job.ID, status, errorMsg)
+		}
+	}()
+
+	log.Printf("🧹 Applying retention to raw analytics older than %s (archive: %v)", cutoff.Format("2006-01-02"), cfg.Archive)
+
+	channelRows, err := repo.ApplyChannelAnalyticsRetention(ctx, cutoff, cfg.Archive)
+	if err != nil {
+		job.ErrorMessage = fmt.Sprintf("Channel analytics retention failed: %v", err)
+		return err
+	}
+
+	videoRows, err := repo.ApplyVideoDailyStatsRetention(ctx, cutoff, cfg.Archive)
+	if err != nil {
+		job.ErrorMessage = fmt.Sprintf("Video daily stats retention failed: %v", err)
+		return err
+	}
+
+	log.Printf("✅ Retention complete: rolled up %d channel rows and %d video stat rows", channelRows, videoRows)
+	return nil
+}
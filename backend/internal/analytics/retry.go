@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// Failure kinds for collection retries
+const (
+	// FailureAuth means the user's Twitch authorization is gone; retrying won't help
+	FailureAuth = "auth"
+	// FailureTransient covers Twitch 5xx responses, timeouts and database hiccups
+	FailureTransient = "transient"
+	// FailureInterrupted means a shutdown stopped the collection. It runs
+	// again right after the restart without counting as an attempt.
+	FailureInterrupted = "interrupted"
+)
+
+const (
+	// maxCollectionAttempts is how many times a transient failure is retried
+	// before waiting for the next scheduled run
+	maxCollectionAttempts = 5
+	// retryBaseDelay and retryMaxDelay bound the exponential backoff (2m, 4m, 8m, 16m, ...)
+	retryBaseDelay = 2 * time.Minute
+	retryMaxDelay  = 30 * time.Minute
+	// retryCheckInterval is how often the scheduler looks for due retries
+	retryCheckInterval = 1 * time.Minute
+)
+
+// ClassifyCollectionError decides whether a collection failure is worth retrying
+func ClassifyCollectionError(err error) string {
+	if errors.Is(err, ErrTwitchAuthRequired) || twitch.IsUnauthorized(err) {
+		return FailureAuth
+	}
+
+	// Clerk reports a missing Twitch connection as a plain error
+	if strings.Contains(err.Error(), "does not have a connected") {
+		return FailureAuth
+	}
+
+	return FailureTransient
+}
+
+// retryDelay returns the backoff before the given (1-based) attempt is retried
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// nextCollectionRetry records another failed attempt on top of the previous retry
+// state (nil for the first failure). Auth failures are parked immediately; transient
+// failures are rescheduled with backoff until maxCollectionAttempts is reached.
+// It returns nil once they are, the next scheduled run collects the user again.
+func nextCollectionRetry(previous *CollectionRetry, userID, jobType string, err error, now time.Time) *CollectionRetry {
+	retry := &CollectionRetry{
+		UserID:      userID,
+		JobType:     jobType,
+		Attempts:    1,
+		FailureKind: ClassifyCollectionError(err),
+		LastError:   err.Error(),
+	}
+	if previous != nil {
+		retry.Attempts = previous.Attempts + 1
+	}
+
+	if retry.FailureKind == FailureAuth {
+		retry.ParkedAt = &now
+		return retry
+	}
+	if retry.Attempts >= maxCollectionAttempts {
+		return nil
+	}
+
+	next := now.Add(retryDelay(retry.Attempts))
+	retry.NextAttemptAt = &next
+	return retry
+}
+
+// collectionOptions returns the options of an interrupted user collection,
+// or the defaults
+func (r CollectionRetry) collectionOptions() CollectionOptions {
+	opts := DefaultCollectionOptions()
+	if r.Options == nil {
+		return opts
+	}
+	if err := json.Unmarshal(*r.Options, &opts); err != nil {
+		log.Printf("Ignoring invalid options of %s retry for user %s: %v", r.JobType, r.UserID, err)
+		return DefaultCollectionOptions()
+	}
+	return opts
+}
@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyCollectionError(t *testing.T) {
+	assert.Equal(t, FailureAuth, ClassifyCollectionError(fmt.Errorf("refresh: %w", ErrTwitchAuthRequired)))
+	assert.Equal(t, FailureAuth, ClassifyCollectionError(&twitch.APIError{StatusCode: 401}))
+	assert.Equal(t, FailureAuth, ClassifyCollectionError(errors.New("user does not have a connected oauth_twitch account")))
+	assert.Equal(t, FailureTransient, ClassifyCollectionError(&twitch.APIError{StatusCode: 503}))
+	assert.Equal(t, FailureTransient, ClassifyCollectionError(errors.New("connection reset by peer")))
+}
+
+func TestNextCollectionRetry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)
+	transient := &twitch.APIError{StatusCode: 502}
+
+	retry := nextCollectionRetry(nil, "user_1", "daily_channel", transient, now)
+	require.NotNil(t, retry.NextAttemptAt)
+	assert.Equal(t, 1, retry.Attempts)
+	assert.Nil(t, retry.ParkedAt)
+	assert.Equal(t, now.Add(2*time.Minute), *retry.NextAttemptAt)
+
+	retry = nextCollectionRetry(&CollectionRetry{Attempts: 3}, "user_1", "daily_channel", transient, now)
+	assert.Equal(t, now.Add(16*time.Minute), *retry.NextAttemptAt)
+
+	// Transient failures never park, the next scheduled run tries again
+	retry = nextCollectionRetry(&CollectionRetry{Attempts: maxCollectionAttempts - 1}, "user_1", "daily_channel", transient, now)
+	assert.Nil(t, retry)
+
+	retry = nextCollectionRetry(nil, "user_1", "daily_channel", ErrTwitchAuthRequired, now)
+	assert.Equal(t, FailureAuth, retry.FailureKind)
+	assert.NotNil(t, retry.ParkedAt)
+}
@@ -0,0 +1,246 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/money"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// revenueDisclaimer is shown with every revenue response
+const revenueDisclaimer = "Estimates only. Subscription revenue uses list prices and the configured revenue share, " +
+	"bits only include the top 100 cheerers per day, Patreon income uses active pledges and the configured creator share, " +
+	"Streamlabs tips are before payment fees, and Twitch does not report ad revenue."
+
+// subscriberTiers counts subscribers by tier
+type subscriberTiers struct {
+	Tier1  int
+	Tier2  int
+	Tier3  int
+	Gifted int
+}
+
+// countSubscriberTiers counts the tiers in a sample of subscriptions and scales
+// the counts up to total when only part of the list was read
+func countSubscriberTiers(sample []twitch.Subscription, total int) subscriberTiers {
+	var counts subscriberTiers
+	for _, sub := range sample {
+		switch sub.Tier {
+		case "2000":
+			counts.Tier2++
+		case "3000":
+			counts.Tier3++
+		default:
+			counts.Tier1++
+		}
+		if sub.IsGift {
+			counts.Gifted++
+		}
+	}
+
+	if len(sample) == 0 || total <= len(sample) {
+		return counts
+	}
+
+	scale := float64(total) / float64(len(sample))
+	scaled := subscriberTiers{
+		Tier2:  int(math.Round(float64(counts.Tier2) * scale)),
+		Tier3:  int(math.Round(float64(counts.Tier3) * scale)),
+		Gifted: int(math.Round(float64(counts.Gifted) * scale)),
+	}
+	// Keep the tiers adding up to the exact total
+	scaled.Tier1 = max(total-scaled.Tier2-scaled.Tier3, 0)
+	return scaled
+}
+
+// estimateSubRevenueCents estimates the creator's monthly share of subscription revenue
+func estimateSubRevenueCents(tiers subscriberTiers, cfg config.RevenueConfig) int {
+	gross := tiers.Tier1*cfg.Tier1PriceCents + tiers.Tier2*cfg.Tier2PriceCents + tiers.Tier3*cfg.Tier3PriceCents
+	return int(math.Round(float64(gross) * cfg.SubShare))
+}
+
+// estimateBitsRevenueCents converts bits to the creator's earnings
+func estimateBitsRevenueCents(bits int, cfg config.RevenueConfig) int {
+	return int(math.Round(float64(bits) * cfg.BitCents))
+}
+
+// collectRevenueData records today's subscription and ad schedule snapshot and
+// yesterday's bits. Each source is best effort, a missing scope only skips that source.
+func (dc *dataCollector) collectRevenueData(ctx context.Context, userID, twitchToken, broadcasterID string) {
+	cfg := config.Revenue()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	snapshot := &RevenueAnalytics{UserID: userID, Date: today}
+	haveSnapshot := false
+
+	if err := dc.collectSubscriberRevenue(ctx, twitchToken, broadcasterID, cfg, snapshot); err != nil {
+		log.Printf("Skipping subscription revenue for user %s: %v", userID, err)
+	} else {
+		haveSnapshot = true
+	}
+
+	schedule, err := dc.twitchClient.GetAdSchedule(ctx, twitchToken, broadcasterID)
+	if err != nil {
+		log.Printf("Skipping ad schedule for user %s: %v", userID, err)
+	} else {
+		snapshot.AdDurationSeconds = schedule.Duration
+		snapshot.AdSnoozeCount = schedule.SnoozeCount
+		snapshot.LastAdAt = schedule.LastAdTime()
+		snapshot.NextAdAt = schedule.NextAdTime()
+		haveSnapshot = true
+	}
+
+	if haveSnapshot {
+		if err := dc.repo.SaveRevenueSnapshot(ctx, snapshot); err != nil {
+			log.Printf("Failed to save revenue snapshot for user %s: %v", userID, err)
+		}
+	}
+
+	// Yesterday is the last complete day on the leaderboard
+	yesterday := today.AddDate(0, 0, -1)
+	leaderboard, err := dc.twitchClient.GetBitsLeaderboard(ctx, twitchToken, "day", yesterday, 100)
+	if err != nil {
+		log.Printf("Skipping bits for user %s: %v", userID, err)
+		return
+	}
+
+	bits := leaderboard.TotalBits()
+	if err := dc.repo.SaveDailyBits(ctx, userID, yesterday, bits, estimateBitsRevenueCents(bits, cfg)); err != nil {
+		log.Printf("Failed to save bits for user %s: %v", userID, err)
+	}
+}
+
+// collectSubscriberRevenue reads up to MaxSubscriberPages of subscribers to work
+// out the tier mix and fills in the subscription estimate
+func (dc *dataCollector) collectSubscriberRevenue(ctx context.Context, twitchToken, broadcasterID string, cfg config.RevenueConfig, snapshot *RevenueAnalytics) error {
+	var sample []twitch.Subscription
+	total, points := 0, 0
+	cursor := ""
+
+	for page := 0; page < cfg.MaxSubscriberPages; page++ {
+		resp, err := dc.twitchClient.GetBroadcasterSubscribers(ctx, twitchToken, broadcasterID, 100, cursor)
+		if err != nil {
+			return err
+		}
+
+		total, points = resp.Total, resp.Points
+		sample = append(sample, resp.Data...)
+
+		cursor = resp.Pagination.Cursor
+		if cursor == "" || len(resp.Data) == 0 {
+			break
+		}
+	}
+
+	tiers := countSubscriberTiers(sample, total)
+	snapshot.TotalSubscribers = total
+	snapshot.Tier1Subscribers = tiers.Tier1
+	snapshot.Tier2Subscribers = tiers.Tier2
+	snapshot.Tier3Subscribers = tiers.Tier3
+	snapshot.GiftedSubscribers = tiers.Gifted
+	snapshot.SubPoints = points
+	snapshot.EstimatedSubRevenueCents = estimateSubRevenueCents(tiers, cfg)
+	return nil
+}
+
+// GetRevenue returns the latest revenue snapshot and a monthly trend, oldest
+// month first. Amounts are converted into currency, or the user's preferred
+// currency when it is empty, and formatted for the request locale.
+func (s *service) GetRevenue(ctx context.Context, userID string, months int, currency money.Currency) (*RevenueOverview, error) {
+	latest, err := s.repo.GetLatestRevenueAnalytics(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue analytics: %w", err)
+	}
+
+	patreonRevenue, err := s.repo.GetLatestPatreonRevenue(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Patreon revenue: %w", err)
+	}
+
+	trend, err := s.repo.GetMonthlyRevenue(ctx, userID, months)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue trend: %w", err)
+	}
+	for i, j := 0, len(trend)-1; i < j; i, j = i+1, j-1 {
+		trend[i], trend[j] = trend[j], trend[i]
+	}
+	if trend == nil {
+		trend = []MonthlyRevenue{}
+	}
+
+	overview := &RevenueOverview{
+		Latest:       latest,
+		Patreon:      patreonRevenue,
+		Trend:        trend,
+		BaseCurrency: money.Base,
+		Disclaimer:   revenueDisclaimer,
+	}
+
+	if currency == "" {
+		currency, err = s.preferredCurrency(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	overview.Currency, overview.ExchangeRate = s.exchangeRate(ctx, currency)
+
+	locale := i18n.FromContext(ctx)
+	display := func(cents int) *money.Value {
+		value := money.Cents(int64(cents)).Exchange(overview.Currency, overview.ExchangeRate).Value(locale)
+		return &value
+	}
+	if latest != nil {
+		latest.SubRevenue = display(latest.EstimatedSubRevenueCents)
+		latest.BitsRevenue = display(latest.EstimatedBitsRevenueCents)
+	}
+	if patreonRevenue != nil {
+		patreonRevenue.MonthlyPledges = display(patreonRevenue.MonthlyPledgeCents)
+		patreonRevenue.EstimatedIncome = display(patreonRevenue.EstimatedIncomeCents)
+	}
+	for i := range trend {
+		trend[i].SubRevenue = display(trend[i].EstimatedSubRevenueCents)
+		trend[i].BitsRevenue = display(trend[i].EstimatedBitsRevenueCents)
+		trend[i].PatreonIncome = display(trend[i].PatreonIncomeCents)
+		trend[i].TipsRevenue = display(trend[i].TipsCents)
+		trend[i].TotalRevenue = display(trend[i].TotalRevenueCents)
+	}
+
+	return overview, nil
+}
+
+// preferredCurrency is the currency saved in the user's preferences, USD by default
+func (s *service) preferredCurrency(ctx context.Context, userID string) (money.Currency, error) {
+	code, err := s.repo.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get currency preference: %w", err)
+	}
+	currency, err := money.ParseCurrency(code)
+	if err != nil {
+		return money.Base, nil
+	}
+	return currency, nil
+}
+
+// exchangeRate returns the rate from USD into currency. Revenue falls back to
+// USD when no rates are available.
+func (s *service) exchangeRate(ctx context.Context, currency money.Currency) (money.Currency, float64) {
+	if currency == money.Base || s.money == nil {
+		return money.Base, 1
+	}
+
+	rates, err := s.money.Rates(ctx)
+	if err == nil {
+		var rate float64
+		if rate, err = rates.Rate(money.Base, currency); err == nil {
+			return currency, rate
+		}
+	}
+	log.Printf("Showing revenue in %s instead of %s: %v", money.Base, currency, err)
+	return money.Base, 1
+}
@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/money"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+func TestCountSubscriberTiers(t *testing.T) {
+	sample := []twitch.Subscription{
+		{Tier: "1000"},
+		{Tier: "1000", IsGift: true},
+		{Tier: "2000"},
+		{Tier: "3000"},
+	}
+
+	assert.Equal(t, subscriberTiers{Tier1: 2, Tier2: 1, Tier3: 1, Gifted: 1}, countSubscriberTiers(sample, 4))
+
+	// A partial sample is scaled up to the reported total
+	assert.Equal(t, subscriberTiers{Tier1: 20, Tier2: 10, Tier3: 10, Gifted: 10}, countSubscriberTiers(sample, 40))
+
+	assert.Equal(t, subscriberTiers{}, countSubscriberTiers(nil, 0))
+}
+
+func TestEstimateRevenue(t *testing.T) {
+	cfg := config.RevenueConfig{
+		Tier1PriceCents: 499,
+		Tier2PriceCents: 999,
+		Tier3PriceCents: 2499,
+		SubShare:        0.5,
+		BitCents:        1,
+	}
+
+	// (10*499 + 2*999 + 1*2499) / 2
+	assert.Equal(t, 4744, estimateSubRevenueCents(subscriberTiers{Tier1: 10, Tier2: 2, Tier3: 1}, cfg))
+	assert.Equal(t, 1500, estimateBitsRevenueCents(1500, cfg))
+}
+
+type fakeRates struct{}
+
+func (fakeRates) FetchRates(ctx context.Context) (*money.Rates, error) {
+	return &money.Rates{Base: "USD", Rates: map[money.Currency]float64{"EUR": 0.9}, FetchedAt: time.Now()}, nil
+}
+
+func TestGetRevenueInPreferredCurrency(t *testing.T) {
+	svc, repo, _ := newTestService()
+	svc.money = money.NewConverter(fakeRates{}, time.Hour)
+	ctx := i18n.WithLocale(context.Background(), "de")
+
+	repo.On("GetLatestRevenueAnalytics", ctx, "user_1").Return(&RevenueAnalytics{
+		EstimatedSubRevenueCents: 123400, EstimatedBitsRevenueCents: 500,
+	}, nil)
+	repo.On("GetLatestPatreonRevenue", ctx, "user_1").Return(&PatreonRevenue{EstimatedIncomeCents: 2000}, nil)
+	repo.On("GetMonthlyRevenue", ctx, "user_1", 12).Return([]MonthlyRevenue{{TipsCents: 500, TotalRevenueCents: 1000}}, nil)
+	repo.On("GetUserCurrency", ctx, "user_1").Return("EUR", nil)
+
+	revenue, err := svc.GetRevenue(ctx, "user_1", 12, "")
+	require.NoError(t, err)
+	assert.Equal(t, money.Currency("EUR"), revenue.Currency)
+	assert.Equal(t, 0.9, revenue.ExchangeRate)
+
+	// Raw cents stay in USD next to the converted values
+	assert.Equal(t, 123400, revenue.Latest.EstimatedSubRevenueCents)
+	assert.Equal(t, money.Value{Minor: 111060, Currency: "EUR", Display: "1.110,60\u00a0€"}, *revenue.Latest.SubRevenue)
+	assert.Equal(t, int64(900), revenue.Trend[0].TotalRevenue.Minor)
+	assert.Equal(t, int64(450), revenue.Trend[0].TipsRevenue.Minor)
+	assert.Equal(t, int64(1800), revenue.Patreon.EstimatedIncome.Minor)
+
+	// An explicit currency skips the preference, and USD needs no rates
+	revenue, err = svc.GetRevenue(ctx, "user_1", 12, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, revenue.ExchangeRate)
+	assert.Equal(t, "5,00\u00a0$", revenue.Latest.BitsRevenue.Display)
+	repo.AssertNumberOfCalls(t, "GetUserCurrency", 1)
+}
@@ -0,0 +1,193 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// scheduleHorizonDays is how far ahead the schedule is collected and shown
+	scheduleHorizonDays = 14
+	// maxSchedulePages bounds the schedule pages (25 segments each) per collection
+	maxSchedulePages = 4
+
+	// A stream keeps a planned slot when it goes live from scheduleEarlyTolerance
+	// before the slot starts until the slot ends, or scheduleLateTolerance after
+	// the start for slots without an end time
+	scheduleEarlyTolerance = time.Hour
+	scheduleLateTolerance  = 2 * time.Hour
+)
+
+// collectStreamSchedule stores the upcoming segments of the creator's Twitch
+// schedule. It is best effort, channels without a schedule store nothing.
+func (dc *dataCollector) collectStreamSchedule(ctx context.Context, userID, twitchToken, broadcasterID string) {
+	now := time.Now()
+	horizon := now.AddDate(0, 0, scheduleHorizonDays)
+
+	var segments []StreamScheduleSegment
+	cursor := ""
+	for page := 0; page < maxSchedulePages; page++ {
+		resp, err := dc.twitchClient.GetChannelStreamSchedulePage(ctx, twitchToken, broadcasterID, now, 25, cursor)
+		if err != nil {
+			log.Printf("Skipping stream schedule for user %s: %v", userID, err)
+			return
+		}
+
+		pastHorizon := false
+		for _, s := range resp.Data.Segments {
+			if !s.StartTime.Before(horizon) {
+				pastHorizon = true
+				break
+			}
+			segment := StreamScheduleSegment{
+				SegmentID:   s.ID,
+				Title:       s.Title,
+				StartTime:   s.StartTime,
+				EndTime:     s.EndTime,
+				IsRecurring: s.IsRecurring,
+				Canceled:    s.CanceledUntil != nil,
+			}
+			if s.Category != nil {
+				segment.CategoryName = s.Category.Name
+			}
+			segments = append(segments, segment)
+		}
+
+		cursor = resp.Pagination.Cursor
+		if pastHorizon || cursor == "" {
+			break
+		}
+	}
+
+	if err := dc.repo.SaveStreamSchedule(ctx, userID, now, segments); err != nil {
+		log.Printf("Failed to save stream schedule for user %s: %v", userID, err)
+		return
+	}
+	log.Printf("Saved %d scheduled streams for user %s", len(segments), userID)
+}
+
+// GetStreamSchedule compares the planned streams of the last days with the
+// streams that happened, and lists the upcoming ones
+func (s *service) GetStreamSchedule(ctx context.Context, userID string, days int) (*StreamScheduleComparison, error) {
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+
+	trackedSince, err := s.repo.GetScheduleTrackedSince(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if trackedSince != nil && trackedSince.After(from) {
+		from = *trackedSince
+	}
+
+	segments, err := s.repo.GetStreamSchedule(ctx, userID, from, now.AddDate(0, 0, scheduleHorizonDays))
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []time.Time
+	if trackedSince != nil {
+		streams, _, err = s.repo.GetBroadcastTimes(ctx, userID, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get streams: %w", err)
+		}
+	}
+
+	comparison := compareSchedule(segments, streams, now)
+	comparison.Days = days
+	comparison.TrackedSince = trackedSince
+	return comparison, nil
+}
+
+// compareSchedule matches each planned segment with the first unmatched stream
+// that went live in its slot. Segments are missed once their slot is over
+// without a stream, streams outside any slot are extra. Canceled segments are
+// only counted, and slots still open without a stream are left out.
+func compareSchedule(segments []StreamScheduleSegment, streams []time.Time, now time.Time) *StreamScheduleComparison {
+	comparison := &StreamScheduleComparison{
+		Entries:  []ScheduleEntry{},
+		Upcoming: []StreamScheduleSegment{},
+	}
+
+	streams = sortedTimes(streams)
+	matched := make([]bool, len(streams))
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].StartTime.Before(segments[j].StartTime) })
+	for i := range segments {
+		segment := &segments[i]
+		if !segment.StartTime.Before(now) {
+			if !segment.Canceled {
+				comparison.Upcoming = append(comparison.Upcoming, *segment)
+			}
+			continue
+		}
+		if segment.Canceled {
+			comparison.Canceled++
+			continue
+		}
+
+		slotEnd := segment.StartTime.Add(scheduleLateTolerance)
+		if segment.EndTime != nil && segment.EndTime.After(segment.StartTime) {
+			slotEnd = *segment.EndTime
+		}
+
+		stream := -1
+		for j, at := range streams {
+			if !matched[j] && !at.Before(segment.StartTime.Add(-scheduleEarlyTolerance)) && !at.After(slotEnd) {
+				stream = j
+				break
+			}
+		}
+
+		if stream < 0 {
+			if slotEnd.After(now) {
+				continue
+			}
+			comparison.Planned++
+			comparison.Missed++
+			comparison.Entries = append(comparison.Entries, ScheduleEntry{Status: "missed", Segment: segment})
+			continue
+		}
+
+		matched[stream] = true
+		startedAt := streams[stream]
+		offset := int(startedAt.Sub(segment.StartTime).Minutes())
+		comparison.Planned++
+		comparison.Kept++
+		comparison.Entries = append(comparison.Entries, ScheduleEntry{
+			Status:             "kept",
+			Segment:            segment,
+			StreamStartedAt:    &startedAt,
+			StartOffsetMinutes: &offset,
+		})
+	}
+
+	for j, at := range streams {
+		if !matched[j] {
+			startedAt := at
+			comparison.Extra++
+			comparison.Entries = append(comparison.Entries, ScheduleEntry{Status: "extra", StreamStartedAt: &startedAt})
+		}
+	}
+
+	sort.SliceStable(comparison.Entries, func(i, j int) bool {
+		return comparison.Entries[i].time().Before(comparison.Entries[j].time())
+	})
+
+	if comparison.Planned > 0 {
+		adherence := float64(comparison.Kept) / float64(comparison.Planned) * 100
+		comparison.AdherencePercent = &adherence
+	}
+	return comparison
+}
+
+// time is when the planned or, for extra streams, the actual stream started
+func (e ScheduleEntry) time() time.Time {
+	if e.Segment != nil {
+		return e.Segment.StartTime
+	}
+	return *e.StreamStartedAt
+}
@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSchedule(t *testing.T) {
+	now := time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 6, day, hour, minute, 0, 0, time.UTC) }
+	end := func(t time.Time) *time.Time { return &t }
+
+	segments := []StreamScheduleSegment{
+		{SegmentID: "kept", StartTime: at(16, 18, 0), EndTime: end(at(16, 21, 0))},
+		{SegmentID: "missed", StartTime: at(17, 18, 0), EndTime: end(at(17, 21, 0))},
+		{SegmentID: "canceled", StartTime: at(18, 18, 0), EndTime: end(at(18, 21, 0)), Canceled: true},
+		{SegmentID: "early", StartTime: at(19, 18, 0)},
+		{SegmentID: "open", StartTime: at(20, 11, 0), EndTime: end(at(20, 14, 0))},
+		{SegmentID: "upcoming", StartTime: at(21, 18, 0)},
+	}
+	streams := []time.Time{
+		at(16, 18, 15), // 15 minutes late
+		at(18, 18, 0),  // streamed despite the cancellation
+		at(19, 17, 30), // 30 minutes early
+	}
+
+	result := compareSchedule(segments, streams, now)
+
+	assert.Equal(t, 3, result.Planned)
+	assert.Equal(t, 2, result.Kept)
+	assert.Equal(t, 1, result.Missed)
+	assert.Equal(t, 1, result.Extra)
+	assert.Equal(t, 1, result.Canceled)
+	require.NotNil(t, result.AdherencePercent)
+	assert.InDelta(t, 66.7, *result.AdherencePercent, 0.1)
+
+	require.Len(t, result.Entries, 4)
+	assert.Equal(t, "kept", result.Entries[0].Status)
+	assert.Equal(t, 15, *result.Entries[0].StartOffsetMinutes)
+	assert.Equal(t, "missed", result.Entries[1].Status)
+	assert.Equal(t, "extra", result.Entries[2].Status)
+	assert.Equal(t, "kept", result.Entries[3].Status)
+	assert.Equal(t, -30, *result.Entries[3].StartOffsetMinutes)
+
+	require.Len(t, result.Upcoming, 1)
+	assert.Equal(t, "upcoming", result.Upcoming[0].SegmentID)
+}
+
+func TestCompareScheduleWithoutSchedule(t *testing.T) {
+	result := compareSchedule(nil, nil, time.Now())
+	assert.Nil(t, result.AdherencePercent)
+	assert.NotNil(t, result.Entries)
+	assert.NotNil(t, result.Upcoming)
+}
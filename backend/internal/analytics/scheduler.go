@@ -2,36 +2,101 @@ package analytics
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"math/rand/v2"
+	"sync"
 	"time"
 
+	"github.com/baldybuilds/creatorsync/internal/config"
 	"github.com/baldybuilds/creatorsync/internal/database"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/time/rate"
 )
 
 type Scheduler interface {
 	Start(ctx context.Context) error
+	// Run is Start without the goroutine: it blocks until ctx is cancelled or
+	// Stop is called, so a supervisor can restart it when it fails
+	Run(ctx context.Context) error
 	Stop() error
 	ScheduleDailyCollection()
-	TriggerUserCollection(userID string)
+	// TriggerUserCollection collects a user in the background. It returns
+	// false, without collecting, when the user is being collected already or
+	// collections are paused.
+	TriggerUserCollection(userID string, opts CollectionOptions) bool
+	// SetPauseCheck makes scheduled work skip while paused returns true, e.g.
+	// during maintenance
+	SetPauseCheck(paused func() bool)
+	// AddWeeklyHook runs hook for every connected user on the weekly digest schedule
+	AddWeeklyHook(hook CollectionHook)
+	// Schedules lists the scheduled jobs with their next and previous runs
+	Schedules() []ScheduleStatus
+	// Drain stops starting collections and waits for the running ones until
+	// ctx ends, see shutdown.go. It reports whether they all finished.
+	Drain(ctx context.Context) bool
 }
 
+const (
+	// dailyChannelRequestCost is roughly how many Twitch API calls one daily channel
+	// collection makes, including revenue (subscribers, bits, ads) and moderation snapshots
+	dailyChannelRequestCost = 12
+	// progressReportInterval is how many finished users trigger a progress update on the run job
+	progressReportInterval = 10
+)
+
 type scheduler struct {
-	collector   DataCollector
-	db          database.Service
-	ticker      *time.Ticker
-	stopChannel chan bool
-	running     bool
+	collector    DataCollector
+	db           database.Service
+	repo         Repository
+	twitchBudget *rate.Limiter
+	stopChannel  chan bool
+	running      bool
+	paused       func() bool
+	weeklyHooks  []CollectionHook
+	// locks coordinate replicas, nil when running alone, see coordination.go
+	locks database.Locker
+	// tracker knows the collections running on this replica, see shutdown.go
+	tracker jobTracker
+
+	// cron runs the scheduled jobs while Run is running
+	mu   sync.Mutex
+	cron *cron.Cron
+	jobs []scheduledJob
 }
 
+var _ Scheduler = (*scheduler)(nil)
+
 func NewScheduler(collector DataCollector, db database.Service) Scheduler {
+	cfg := config.Scheduler()
+	perSecond := rate.Limit(float64(cfg.TwitchRequestsPerMinute) / 60)
+	burst := max(dailyChannelRequestCost*cfg.Workers, dailyChannelRequestCost)
+
 	return &scheduler{
-		collector:   collector,
-		db:          db,
-		stopChannel: make(chan bool),
-		running:     false,
+		collector:    collector,
+		db:           db,
+		repo:         NewRepository(db.GetDB()),
+		twitchBudget: rate.NewLimiter(perSecond, burst),
+		stopChannel:  make(chan bool),
+		running:      false,
+		paused:       func() bool { return false },
+		locks:        database.NewAdvisoryLocker(db),
 	}
 }
 
+func (s *scheduler) SetPauseCheck(paused func() bool) {
+	s.paused = paused
+}
+
+// skip reports whether the job should be skipped because collections are paused
+func (s *scheduler) skip(job string) bool {
+	if !s.paused() {
+		return false
+	}
+	log.Printf("⏸️ Skipping %s, collections are paused", job)
+	return true
+}
+
 func (s *scheduler) Start(ctx context.Context) error {
 	if s.running {
 		return nil
@@ -40,22 +105,54 @@ func (s *scheduler) Start(ctx context.Context) error {
 	log.Println("Starting analytics scheduler...")
 	s.running = true
 
-	// Schedule daily collection at 2 AM UTC
-	s.ticker = time.NewTicker(1 * time.Hour) // Check every hour
+	go s.Run(ctx)
+
+	log.Println("Analytics scheduler started successfully")
+	return nil
+}
+
+// Run schedules the jobs while this replica holds the scheduler lock, see
+// coordination.go. A replica that loses the lock stops scheduling, lets its
+// running jobs finish and waits to take it again.
+func (s *scheduler) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c, err := s.newCron(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.mu.Lock()
+		s.cron = nil
+		s.mu.Unlock()
+	}()
 
 	go func() {
-		for {
-			select {
-			case <-s.ticker.C:
-				s.checkAndRunDailyCollection(ctx)
-			case <-s.stopChannel:
-				return
-			}
+		select {
+		case <-s.stopChannel:
+			cancel()
+		case <-ctx.Done():
 		}
 	}()
 
-	log.Println("Analytics scheduler started successfully")
-	return nil
+	for {
+		lock, err := s.awaitLeadership(ctx)
+		if err != nil {
+			return nil
+		}
+
+		c.Start()
+		s.holdLeadership(ctx, lock)
+		// Running jobs stop early once ctx is cancelled
+		<-c.Stop().Done()
+		if lock != nil {
+			lock.Release()
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
 }
 
 func (s *scheduler) Stop() error {
@@ -66,40 +163,59 @@ func (s *scheduler) Stop() error {
 	log.Println("Stopping analytics scheduler...")
 	s.running = false
 
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-
 	s.stopChannel <- true
 	log.Println("Analytics scheduler stopped")
 	return nil
 }
 
 func (s *scheduler) ScheduleDailyCollection() {
-	ctx := context.Background()
-	s.runDailyCollectionForAllUsers(ctx)
+	s.runTracked(context.Background(), "daily collection", s.runDailyCollectionForAllUsers)
 }
 
-func (s *scheduler) TriggerUserCollection(userID string) {
-	ctx := context.Background()
+func (s *scheduler) TriggerUserCollection(userID string, opts CollectionOptions) bool {
+	if s.skip("collection for user " + userID) {
+		return false
+	}
+	ctx, done, ok := s.tracker.start(context.Background())
+	if !ok {
+		s.queueInterrupted(context.Background(), []string{userID}, "user_collection", &opts)
+		return true
+	}
+
+	// The lock is taken before returning, so callers know whether anything runs
+	release, ok, err := s.lockUser(ctx, userID)
+	if err != nil || !ok {
+		done()
+		if err != nil {
+			log.Printf("Failed to collect data for user %s: %v", userID, err)
+		} else {
+			log.Printf("Skipping collection for user %s, it is already being collected", userID)
+		}
+		return false
+	}
+
 	go func() {
-		if err := s.collector.CollectAllUserData(ctx, userID); err != nil {
+		defer done()
+		report, err := s.collector.CollectAllUserData(ctx, userID, opts)
+		release()
+		if ctx.Err() != nil {
+			s.queueInterrupted(ctx, []string{userID}, "user_collection", &opts)
+			return
+		}
+		if err != nil {
 			log.Printf("Failed to collect data for user %s: %v", userID, err)
+		} else if report.Status != CollectionCompleted {
+			log.Printf("Data collection for user %s was %s: %s", userID, report.Status, report.errorSummary())
 		}
 	}()
+	return true
 }
 
-func (s *scheduler) checkAndRunDailyCollection(ctx context.Context) {
-	now := time.Now().UTC()
-
-	// Run daily collection at 2 AM UTC
-	if now.Hour() == 2 && now.Minute() == 0 {
-		log.Println("Starting daily analytics collection...")
-		s.runDailyCollectionForAllUsers(ctx)
+func (s *scheduler) runDailyCollectionForAllUsers(ctx context.Context) {
+	if s.skip("daily collection") {
+		return
 	}
-}
 
-func (s *scheduler) runDailyCollectionForAllUsers(ctx context.Context) {
 	// Get all users from database
 	users, err := s.getAllUsers(ctx)
 	if err != nil {
@@ -107,65 +223,259 @@ func (s *scheduler) runDailyCollectionForAllUsers(ctx context.Context) {
 		return
 	}
 
-	log.Printf("Starting daily collection for %d users", len(users))
+	cfg := config.Scheduler()
+	log.Printf("Starting daily collection for %d users with %d workers", len(users), cfg.Workers)
 
-	// Process users in batches to avoid overwhelming the API
-	batchSize := 10
-	for i := 0; i < len(users); i += batchSize {
-		end := i + batchSize
-		if end > len(users) {
-			end = len(users)
+	run := &AnalyticsJob{
+		JobType:       "daily_collection_run",
+		Status:        "running",
+		DataDate:      &[]time.Time{time.Now()}[0],
+		ProgressTotal: len(users),
+	}
+	if err := s.repo.CreateAnalyticsJob(ctx, run); err != nil {
+		log.Printf("Failed to create daily collection run job: %v", err)
+	}
+
+	progress := &runProgress{total: len(users)}
+	queue := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range queue {
+				err := s.collectDailyForUser(ctx, userID, cfg.MaxJitter)
+				if completed, failed, report := progress.record(err); report {
+					s.saveRunProgress(ctx, run, progress.total, completed, failed)
+				}
+			}
+		}()
+	}
+
+	paused := false
+	// remaining are the users not handed to a worker when shutdown began
+	var remaining []string
+enqueue:
+	for i, userID := range users {
+		// Users already being collected finish, the rest wait for the next run
+		if s.paused() {
+			paused = true
+			break
+		}
+		// or, on shutdown, for the restart
+		if s.tracker.isDraining() {
+			remaining = users[i:]
+			break
+		}
+		select {
+		case queue <- userID:
+		case <-ctx.Done():
+			if s.tracker.interrupted() {
+				remaining = users[i:]
+			}
+			break enqueue
+		}
+	}
+	close(queue)
+	wg.Wait()
+	s.queueInterrupted(ctx, remaining, "daily_channel", nil)
+
+	completed, failed := progress.counts()
+	s.saveRunProgress(ctx, run, progress.total, completed, failed)
+
+	if run.ID > 0 {
+		status := "completed"
+		var errorMsg *string
+		if ctx.Err() != nil || paused || len(remaining) > 0 {
+			status = "failed"
+			msg := fmt.Sprintf("Run interrupted after %d of %d users", completed+failed, progress.total)
+			switch {
+			case paused:
+				msg = fmt.Sprintf("Run paused after %d of %d users", completed+failed, progress.total)
+			case len(remaining) > 0:
+				msg = fmt.Sprintf("Run stopped by shutdown after %d of %d users, the other %d resume after the restart",
+					completed+failed, progress.total, len(remaining))
+			}
+			errorMsg = &msg
 		}
+		s.repo.UpdateAnalyticsJob(context.Background(), run.ID, status, errorMsg)
+	}
+
+	log.Printf("Daily collection completed: %d succeeded, %d failed, %d total", completed, failed, progress.total)
+}
+
+// collectDailyForUser waits for its jitter and a share of the Twitch budget, then
+// collects the user's channel data, scheduling a retry on failure
+func (s *scheduler) collectDailyForUser(ctx context.Context, userID string, maxJitter time.Duration) error {
+	// Spread users out so they don't hit Twitch in lockstep
+	if maxJitter > 0 {
+		select {
+		case <-time.After(rand.N(maxJitter)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
-		batch := users[i:end]
-		s.processBatch(ctx, batch)
+	if err := s.twitchBudget.WaitN(ctx, dailyChannelRequestCost); err != nil {
+		return err
+	}
 
-		// Wait between batches to respect rate limits
-		time.Sleep(30 * time.Second)
+	ok, err := s.withUserLock(ctx, userID, func() error {
+		return s.collector.CollectDailyChannelData(ctx, userID)
+	})
+	if err != nil {
+		log.Printf("Failed daily collection for user %s: %v", userID, err)
+		s.recordFailure(ctx, userID, "daily_channel", err)
+		return err
+	}
+	if !ok {
+		log.Printf("Skipping daily collection for user %s, it is already being collected", userID)
+		return nil
 	}
 
-	log.Println("Daily collection completed")
+	log.Printf("Completed daily collection for user %s", userID)
+	return nil
 }
 
-func (s *scheduler) processBatch(ctx context.Context, users []string) {
-	for _, userID := range users {
-		go func(uid string) {
-			// Add some jitter to avoid hitting rate limits
-			time.Sleep(time.Duration(len(uid)%10) * time.Second)
+func (s *scheduler) saveRunProgress(ctx context.Context, run *AnalyticsJob, total, completed, failed int) {
+	if run.ID == 0 {
+		return
+	}
+	if err := s.repo.UpdateAnalyticsJobProgress(ctx, run.ID, total, completed, failed); err != nil {
+		log.Printf("Failed to save daily collection progress: %v", err)
+	}
+}
 
-			if err := s.collector.CollectDailyChannelData(ctx, uid); err != nil {
-				log.Printf("Failed daily collection for user %s: %v", uid, err)
-			} else {
-				log.Printf("Completed daily collection for user %s", uid)
-			}
-		}(userID)
+// runProgress counts finished users across workers
+type runProgress struct {
+	mu        sync.Mutex
+	total     int
+	completed int
+	failed    int
+}
+
+// record counts a finished user and reports whether progress should be persisted,
+// which happens every progressReportInterval users
+func (p *runProgress) record(err error) (completed, failed int, report bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		p.failed++
+	} else {
+		p.completed++
 	}
+	return p.completed, p.failed, (p.completed+p.failed)%progressReportInterval == 0
 }
 
-func (s *scheduler) getAllUsers(ctx context.Context) ([]string, error) {
-	query := `
-		SELECT DISTINCT id 
-		FROM users 
-		WHERE twitch_user_id IS NOT NULL 
-		AND twitch_user_id != ''
-	`
-
-	rows, err := s.db.GetDB().QueryContext(ctx, query)
+func (p *runProgress) counts() (completed, failed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.completed, p.failed
+}
+
+// processDueRetries re-runs failed collections whose backoff has elapsed
+func (s *scheduler) processDueRetries(ctx context.Context) {
+	if s.paused() {
+		return
+	}
+
+	retries, err := s.repo.GetDueCollectionRetries(ctx, time.Now(), 50)
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to load due collection retries: %v", err)
+		return
 	}
-	defer rows.Close()
 
-	var users []string
-	for rows.Next() {
-		var userID string
-		if err := rows.Scan(&userID); err != nil {
+	for _, retry := range retries {
+		// The rest stay due for the restarted scheduler
+		if s.tracker.isDraining() {
+			return
+		}
+		log.Printf("🔁 Retrying %s collection for user %s (attempt %d)", retry.JobType, retry.UserID, retry.Attempts+1)
+
+		if err := s.twitchBudget.WaitN(ctx, dailyChannelRequestCost); err != nil {
+			return
+		}
+
+		var err error
+		switch retry.JobType {
+		case "daily_channel":
+			var ok bool
+			ok, err = s.withUserLock(ctx, retry.UserID, func() error {
+				return s.collector.CollectDailyChannelData(ctx, retry.UserID)
+			})
+			if !ok && err == nil {
+				// Retried on the next check
+				log.Printf("Postponing retry for user %s, it is already being collected", retry.UserID)
+				continue
+			}
+		case "user_collection":
+			var ok bool
+			ok, err = s.withUserLock(ctx, retry.UserID, func() error {
+				_, err := s.collector.CollectAllUserData(ctx, retry.UserID, retry.collectionOptions())
+				return err
+			})
+			if !ok && err == nil {
+				log.Printf("Postponing retry for user %s, it is already being collected", retry.UserID)
+				continue
+			}
+		default:
+			log.Printf("Dropping retry with unknown job type %q for user %s", retry.JobType, retry.UserID)
+		}
+
+		if err != nil {
+			log.Printf("Retry of %s collection failed for user %s: %v", retry.JobType, retry.UserID, err)
+			s.recordFailure(ctx, retry.UserID, retry.JobType, err)
 			continue
 		}
-		users = append(users, userID)
+
+		if err := s.repo.DeleteCollectionRetry(ctx, retry.UserID, retry.JobType); err != nil {
+			log.Printf("Failed to clear collection retry for user %s: %v", retry.UserID, err)
+		}
+	}
+}
+
+// recordFailure schedules a retry for a failed collection, or parks the user when
+// the failure is auth related. Once the attempts are exhausted the retry is
+// dropped and the next scheduled run collects the user again.
+func (s *scheduler) recordFailure(ctx context.Context, userID, jobType string, collectErr error) {
+	// Stopped by shutdown rather than failed
+	if s.tracker.interrupted() {
+		s.queueInterrupted(ctx, []string{userID}, jobType, nil)
+		return
+	}
+
+	previous, err := s.repo.GetCollectionRetry(ctx, userID, jobType)
+	if err != nil {
+		log.Printf("Failed to load collection retry for user %s: %v", userID, err)
+		return
+	}
+
+	retry := nextCollectionRetry(previous, userID, jobType, collectErr, time.Now())
+	if retry == nil {
+		if err := s.repo.DeleteCollectionRetry(ctx, userID, jobType); err != nil {
+			log.Printf("Failed to clear collection retry for user %s: %v", userID, err)
+			return
+		}
+		log.Printf("Gave up retrying %s collection for user %s after %d attempts, waiting for the next scheduled run", jobType, userID, maxCollectionAttempts)
+		return
+	}
+	if err := s.repo.SaveCollectionRetry(ctx, retry); err != nil {
+		log.Printf("Failed to save collection retry for user %s: %v", userID, err)
+		return
 	}
 
-	return users, nil
+	if retry.ParkedAt != nil {
+		log.Printf("⏸️ Parked %s collection for user %s after %d attempt(s) (%s failure)", jobType, userID, retry.Attempts, retry.FailureKind)
+	} else {
+		log.Printf("Scheduled %s collection retry for user %s at %v", jobType, userID, retry.NextAttemptAt.Format(time.RFC3339))
+	}
+}
+
+// getAllUsers returns the users to collect, see Repository.ListCollectableUserIDs
+func (s *scheduler) getAllUsers(ctx context.Context) ([]string, error) {
+	return s.repo.ListCollectableUserIDs(ctx)
 }
 
 // BackgroundCollectionManager manages all background collection tasks
@@ -186,14 +496,40 @@ func (bcm *BackgroundCollectionManager) Start(ctx context.Context) error {
 	return bcm.scheduler.Start(ctx)
 }
 
+// Run blocks while scheduling collections, see Scheduler.Run
+func (bcm *BackgroundCollectionManager) Run(ctx context.Context) error {
+	return bcm.scheduler.Run(ctx)
+}
+
 func (bcm *BackgroundCollectionManager) Stop() error {
 	return bcm.scheduler.Stop()
 }
 
-func (bcm *BackgroundCollectionManager) TriggerUserCollection(userID string) {
-	bcm.scheduler.TriggerUserCollection(userID)
+func (bcm *BackgroundCollectionManager) TriggerUserCollection(userID string, opts CollectionOptions) bool {
+	return bcm.scheduler.TriggerUserCollection(userID, opts)
 }
 
 func (bcm *BackgroundCollectionManager) TriggerDailyCollection() {
 	bcm.scheduler.ScheduleDailyCollection()
 }
+
+// SetPauseCheck pauses scheduled collections, retries and retention while
+// paused returns true
+func (bcm *BackgroundCollectionManager) SetPauseCheck(paused func() bool) {
+	bcm.scheduler.SetPauseCheck(paused)
+}
+
+// AddWeeklyHook runs hook for every connected user on the weekly digest schedule
+func (bcm *BackgroundCollectionManager) AddWeeklyHook(hook CollectionHook) {
+	bcm.scheduler.AddWeeklyHook(hook)
+}
+
+// Schedules lists the scheduled jobs with their next and previous runs
+func (bcm *BackgroundCollectionManager) Schedules() []ScheduleStatus {
+	return bcm.scheduler.Schedules()
+}
+
+// Drain lets running collections finish before shutdown, see Scheduler.Drain
+func (bcm *BackgroundCollectionManager) Drain(ctx context.Context) bool {
+	return bcm.scheduler.Drain(ctx)
+}
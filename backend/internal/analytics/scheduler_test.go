@@ -0,0 +1,155 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestSchedulerSkipsWorkWhilePaused(t *testing.T) {
+	s := &scheduler{}
+	s.SetPauseCheck(func() bool { return true })
+	s.AddWeeklyHook(func(ctx context.Context, userID string) error { return nil })
+
+	// Without a database these would panic if they ran
+	s.runDailyCollectionForAllUsers(context.Background())
+	s.processDueRetries(context.Background())
+	s.sampleStreams(context.Background())
+	s.runWeeklyHooks(context.Background())
+	s.runRetention(context.Background())
+	s.TriggerUserCollection("user_1", DefaultCollectionOptions())
+}
+
+func TestSchedulesFollowConfig(t *testing.T) {
+	t.Setenv("SCHEDULE_LIVE_SAMPLING", "off")
+	t.Setenv("SCHEDULE_WEEKLY_DIGEST", "30 4 * * 1")
+	t.Setenv("RETENTION_ENABLED", "true")
+	t.Setenv("RETENTION_HOUR_UTC", "5")
+	s := &scheduler{stopChannel: make(chan bool)}
+
+	schedules := map[string]ScheduleStatus{}
+	for _, status := range s.Schedules() {
+		schedules[status.Name] = status
+	}
+	assert.Equal(t, "0 2 * * *", schedules["daily_snapshot"].Schedule)
+	assert.False(t, schedules["live_sampling"].Enabled)
+	assert.Equal(t, "30 4 * * 1", schedules["weekly_digest"].Schedule)
+	assert.Equal(t, "0 5 * * *", schedules["retention"].Schedule)
+	assert.Nil(t, schedules["daily_snapshot"].NextRun)
+
+	// Runs are known while the scheduler is running
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	require.Eventually(t, func() bool {
+		for _, status := range s.Schedules() {
+			if status.Name == "daily_snapshot" && status.NextRun != nil {
+				next := *status.NextRun
+				return next.Hour() == 2 && next.Minute() == 0 && next.After(time.Now())
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	for _, status := range s.Schedules() {
+		if status.Name == "live_sampling" {
+			assert.Nil(t, status.NextRun)
+		}
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestSchedulerRejectsInvalidSchedule(t *testing.T) {
+	t.Setenv("SCHEDULE_DAILY_SNAPSHOT", "every day at two")
+	s := &scheduler{}
+
+	err := s.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "daily_snapshot")
+}
+
+func newTestScheduler() (*scheduler, *mockRepository, *mockCollector) {
+	repo := &mockRepository{}
+	collector := &mockCollector{}
+	return &scheduler{
+		collector:    collector,
+		repo:         repo,
+		twitchBudget: rate.NewLimiter(rate.Inf, 0),
+		paused:       func() bool { return false },
+	}, repo, collector
+}
+
+func TestDailyRunCollectsDiscoveredUsers(t *testing.T) {
+	t.Setenv("SCHEDULER_MAX_JITTER", "0s")
+	s, repo, collector := newTestScheduler()
+	ctx := context.Background()
+
+	repo.On("ListCollectableUserIDs", ctx).Return([]string{"user_1", "user_2"}, nil)
+	repo.On("CreateAnalyticsJob", ctx, mock.Anything).Return(nil)
+	collector.On("CollectDailyChannelData", ctx, "user_1").Return(nil)
+	collector.On("CollectDailyChannelData", ctx, "user_2").Return(errors.New("twitch unavailable"))
+	repo.On("GetCollectionRetry", ctx, "user_2", "daily_channel").Return(nil, nil)
+	repo.On("SaveCollectionRetry", ctx, mock.MatchedBy(func(retry *CollectionRetry) bool {
+		return retry.UserID == "user_2" && retry.JobType == "daily_channel"
+	})).Return(nil)
+
+	s.runDailyCollectionForAllUsers(ctx)
+
+	repo.AssertExpectations(t)
+	collector.AssertExpectations(t)
+}
+
+func TestDueRetriesRunThroughCollector(t *testing.T) {
+	s, repo, collector := newTestScheduler()
+	ctx := context.Background()
+
+	repo.On("GetDueCollectionRetries", ctx, mock.Anything, 50).Return([]CollectionRetry{
+		{UserID: "user_1", JobType: "daily_channel", Attempts: 1},
+	}, nil)
+	collector.On("CollectDailyChannelData", ctx, "user_1").Return(nil)
+	repo.On("DeleteCollectionRetry", ctx, "user_1", "daily_channel").Return(nil)
+
+	s.processDueRetries(ctx)
+
+	repo.AssertExpectations(t)
+	collector.AssertExpectations(t)
+}
+
+func TestExhaustedTransientRetryIsDropped(t *testing.T) {
+	s, repo, collector := newTestScheduler()
+	ctx := context.Background()
+
+	last := CollectionRetry{UserID: "user_1", JobType: "daily_channel", Attempts: maxCollectionAttempts - 1, FailureKind: FailureTransient}
+	repo.On("GetDueCollectionRetries", ctx, mock.Anything, 50).Return([]CollectionRetry{last}, nil)
+	collector.On("CollectDailyChannelData", ctx, "user_1").Return(errors.New("twitch unavailable"))
+	repo.On("GetCollectionRetry", ctx, "user_1", "daily_channel").Return(&last, nil)
+	// Not parked, so the next daily run collects the user again
+	repo.On("DeleteCollectionRetry", ctx, "user_1", "daily_channel").Return(nil)
+
+	s.processDueRetries(ctx)
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "SaveCollectionRetry", mock.Anything, mock.Anything)
+}
+
+func TestArchivePurgeIsScheduled(t *testing.T) {
+	s, repo, _ := newTestScheduler()
+	ctx := context.Background()
+
+	var scheduled bool
+	for _, job := range s.scheduledJobs() {
+		scheduled = scheduled || job.name == "archive_purge" && job.spec == "15 4 * * *"
+	}
+	assert.True(t, scheduled)
+
+	repo.On("PurgeExpiredDisconnectArchives", ctx).Return(int64(2), nil)
+	s.purgeDisconnectArchives(ctx)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,193 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/robfig/cron/v3"
+)
+
+// ScheduleStatus is a scheduled job with its cron expression and runs. The
+// runs are only known while the scheduler is running.
+type ScheduleStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Enabled  bool       `json:"enabled"`
+	NextRun  *time.Time `json:"next_run,omitempty"`
+	LastRun  *time.Time `json:"last_run,omitempty"`
+}
+
+type scheduledJob struct {
+	name string
+	spec string
+	run  func(ctx context.Context)
+	id   cron.EntryID
+}
+
+// scheduledJobs are the scheduler's jobs with their configured schedules
+func (s *scheduler) scheduledJobs() []scheduledJob {
+	schedules := config.Schedules()
+
+	retention := config.Retention()
+	retentionSpec := config.ScheduleOff
+	if retention.Enabled {
+		retentionSpec = fmt.Sprintf("0 %d * * *", retention.Hour)
+	}
+
+	return []scheduledJob{
+		{name: "user_reconciliation", spec: schedules.UserReconciliation, run: s.reconcileUsers},
+		{name: "daily_snapshot", spec: schedules.DailySnapshot, run: s.runDailyCollectionForAllUsers},
+		{name: "live_sampling", spec: schedules.LiveSampling, run: s.sampleStreams},
+		{name: "weekly_digest", spec: schedules.WeeklyDigest, run: s.runWeeklyHooks},
+		{name: "retention", spec: retentionSpec, run: s.runRetention},
+		{name: "archive_purge", spec: schedules.ArchivePurge, run: s.purgeDisconnectArchives},
+		{name: "collection_retries", spec: "@every " + retryCheckInterval.String(), run: s.processDueRetries},
+	}
+}
+
+// newCron schedules the enabled jobs to run with ctx. A job still running
+// when it is due again is skipped, and a panicking job is logged. Jobs don't
+// start once shutdown has begun.
+func (s *scheduler) newCron(ctx context.Context) (*cron.Cron, error) {
+	logger := cron.PrintfLogger(log.Default())
+	c := cron.New(
+		cron.WithLocation(time.UTC),
+		cron.WithChain(cron.Recover(logger), cron.SkipIfStillRunning(logger)),
+	)
+
+	jobs := s.scheduledJobs()
+	for i := range jobs {
+		job := &jobs[i]
+		if job.spec == config.ScheduleOff {
+			continue
+		}
+		id, err := c.AddFunc(job.spec, func() { s.runTracked(ctx, job.name, job.run) })
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q for %s: %w", job.spec, job.name, err)
+		}
+		job.id = id
+	}
+
+	s.mu.Lock()
+	s.cron = c
+	s.jobs = jobs
+	s.mu.Unlock()
+	return c, nil
+}
+
+func (s *scheduler) AddWeeklyHook(hook CollectionHook) {
+	s.weeklyHooks = append(s.weeklyHooks, hook)
+}
+
+func (s *scheduler) Schedules() []ScheduleStatus {
+	s.mu.Lock()
+	c, jobs := s.cron, s.jobs
+	s.mu.Unlock()
+	if jobs == nil {
+		jobs = s.scheduledJobs()
+	}
+
+	statuses := make([]ScheduleStatus, 0, len(jobs))
+	for _, job := range jobs {
+		status := ScheduleStatus{
+			Name:     job.name,
+			Schedule: job.spec,
+			Enabled:  job.spec != config.ScheduleOff,
+		}
+		if c != nil && job.id != 0 {
+			entry := c.Entry(job.id)
+			if !entry.Next.IsZero() {
+				status.NextRun = &entry.Next
+			}
+			if !entry.Prev.IsZero() {
+				status.LastRun = &entry.Prev
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// sampleStreams collects the stream data of every connected user
+func (s *scheduler) sampleStreams(ctx context.Context) {
+	if s.skip("live sampling") {
+		return
+	}
+
+	users, err := s.getAllUsers(ctx)
+	if err != nil {
+		log.Printf("Failed to get users for live sampling: %v", err)
+		return
+	}
+
+	for _, userID := range users {
+		if err := s.twitchBudget.Wait(ctx); err != nil {
+			return
+		}
+		if err := s.collector.CollectStreamData(ctx, userID); err != nil {
+			log.Printf("Failed to sample streams of user %s: %v", userID, err)
+		}
+	}
+}
+
+// runWeeklyHooks runs the weekly hooks, e.g. insights, for every connected user
+func (s *scheduler) runWeeklyHooks(ctx context.Context) {
+	if len(s.weeklyHooks) == 0 || s.skip("weekly digest") {
+		return
+	}
+
+	users, err := s.getAllUsers(ctx)
+	if err != nil {
+		log.Printf("Failed to get users for weekly digest: %v", err)
+		return
+	}
+
+	log.Printf("Starting weekly digest for %d users", len(users))
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return
+		}
+		for _, hook := range s.weeklyHooks {
+			if err := hook(ctx, userID); err != nil {
+				log.Printf("Weekly hook failed for user %s: %v", userID, err)
+			}
+		}
+	}
+}
+
+// reconcileUsers brings users rows in line with the stored Twitch tokens
+func (s *scheduler) reconcileUsers(ctx context.Context) {
+	updated, err := s.repo.ReconcileUsersFromTokens(ctx)
+	if err != nil {
+		log.Printf("User reconciliation failed: %v", err)
+		return
+	}
+	if updated > 0 {
+		log.Printf("🔗 Reconciled the Twitch account of %d users from their tokens", updated)
+	}
+}
+
+func (s *scheduler) runRetention(ctx context.Context) {
+	if s.skip("retention") {
+		return
+	}
+	if err := RunRetention(ctx, s.repo, config.Retention()); err != nil {
+		log.Printf("Retention job failed: %v", err)
+	}
+}
+
+// purgeDisconnectArchives drops the analytics of disconnected accounts that
+// weren't reconnected within the restore window
+func (s *scheduler) purgeDisconnectArchives(ctx context.Context) {
+	purged, err := s.repo.PurgeExpiredDisconnectArchives(ctx)
+	if err != nil {
+		log.Printf("Disconnect archive purge failed: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("🗑️ Purged %d expired disconnect archives", purged)
+	}
+}
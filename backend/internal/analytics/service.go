@@ -3,27 +3,67 @@ package analytics
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"time"
 
+	"github.com/baldybuilds/creatorsync/internal/config"
 	"github.com/baldybuilds/creatorsync/internal/database"
-	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/money"
+	"github.com/baldybuilds/creatorsync/internal/templates"
 )
 
 type Service interface {
 	// Data retrieval for dashboard
-	GetDashboardOverview(ctx context.Context, userID string) (*DashboardOverview, error)
+	GetDashboardOverview(ctx context.Context, userID string, days int) (*DashboardOverview, error)
 	GetAnalyticsChartData(ctx context.Context, userID string, days int) (*AnalyticsChartData, error)
 	GetDetailedAnalytics(ctx context.Context, userID string) (*DetailedAnalytics, error)
 	GetEnhancedAnalytics(ctx context.Context, userID string, days int) (*EnhancedAnalytics, error)
+	GetStreamSessionDetail(ctx context.Context, userID, streamID string) (*StreamSessionDetail, error)
+	ListVideos(ctx context.Context, userID string, query VideoListQuery) (*VideoPage, error)
+	GetKeywordInsights(ctx context.Context, userID string, days, minVideos int) (*KeywordInsights, error)
+	GetMutedVideoReport(ctx context.Context, userID string, days int, minPercent float64) (*MutedVideoReport, error)
+	GetHighlightSuggestions(ctx context.Context, userID, videoID string, limit int) ([]HighlightSuggestion, error)
+	GetTopVideos(ctx context.Context, userID, videoType, rank string, limit int) ([]VideoAnalytics, error)
+	GetRecentVideos(ctx context.Context, userID string, limit int) ([]VideoAnalytics, error)
+	GetRepurposeCandidates(ctx context.Context, userID string, days, limit int) (*RepurposeReport, error)
+	GetVideoDecayCurves(ctx context.Context, userID string, days int) (*VideoDecayReport, error)
+	GetAudienceReport(ctx context.Context, userID string, days int) (*AudienceReport, error)
+	GetCollaborationReport(ctx context.Context, userID string, days int) (*CollaborationReport, error)
+	SetCollaborators(ctx context.Context, userID, contentType, contentID string, logins []string) (bool, error)
+	DeleteCollaborators(ctx context.Context, userID, contentType, contentID string) (bool, error)
+	GetTitleExperiments(ctx context.Context, userID string) ([]ExperimentResult, error)
+	GetTitleExperiment(ctx context.Context, userID string, id int) (*ExperimentResult, error)
+	CreateTitleExperiment(ctx context.Context, experiment *TitleExperiment) error
+	DeleteTitleExperiment(ctx context.Context, userID string, id int) (bool, error)
+	AssignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID, variant string) (bool, error)
+	UnassignExperimentVideo(ctx context.Context, userID string, experimentID int, videoID string) (bool, error)
+	GetWeeklyInsights(ctx context.Context, userID string, limit int) ([]WeeklyInsights, error)
+	GetChangeSummaries(ctx context.Context, userID string, days, limit int) ([]ChangeSummary, error)
 
 	// Manual data collection triggers
-	TriggerDataCollection(ctx context.Context, userID string) error
+	TriggerDataCollection(ctx context.Context, userID string, opts CollectionOptions) error
 	RefreshChannelData(ctx context.Context, userID string) error
 
 	// Data analysis
 	GetGrowthAnalysis(ctx context.Context, userID string, period string) (*GrowthAnalysis, error)
 	GetContentPerformance(ctx context.Context, userID string) (*ContentPerformance, error)
+	GetChannelHistory(ctx context.Context, userID, period string, limit int) ([]ChannelAnalyticsRollup, error)
+	GetRevenue(ctx context.Context, userID string, months int, currency money.Currency) (*RevenueOverview, error)
+	GetChannelPointsAnalytics(ctx context.Context, userID string, days int) (*ChannelPointsAnalytics, error)
+	GetChatHealth(ctx context.Context, userID string, days int) (*ChatHealth, error)
+	GetRaidAnalytics(ctx context.Context, userID string, days int) (*RaidAnalytics, error)
+	GetStreamSchedule(ctx context.Context, userID string, days int) (*StreamScheduleComparison, error)
+	GetCreatorGoals(ctx context.Context, userID string, days int) (*CreatorGoals, error)
+
+	// Media kits (rendered in the background)
+	RequestMediaKit(ctx context.Context, userID string, templateID int) (*MediaKit, error)
+	GetMediaKit(ctx context.Context, userID string, id int) (*MediaKit, error)
+	GetMediaKitPDF(ctx context.Context, userID string, id int) ([]byte, error)
+
+	// Channel analytics CSV exported from Twitch
+	ImportChannelCSV(ctx context.Context, userID string, r io.Reader) (*ImportReport, error)
 
 	// Job management
 	GetAnalyticsJobs(ctx context.Context, userID string, limit int) ([]AnalyticsJob, error)
@@ -33,47 +73,94 @@ type Service interface {
 
 	// Data freshness check
 	CheckUserAnalyticsData(ctx context.Context, userID string) (hasData bool, lastUpdate *time.Time, err error)
+
+	// Cached dashboard payloads (overview, enhanced analytics and charts)
+	WarmUser(ctx context.Context, userID string) error
+	WarmAllUsers(ctx context.Context) (*AnalyticsJob, error)
+	EvictUser(userID string)
 }
 
 type service struct {
 	repo      Repository
 	collector DataCollector
 	db        database.Service
+	money     *money.Converter
+	// payloads caches dashboard payloads, nil when ANALYTICS_CACHE_TTL=0
+	payloads *payloadCache
+	// flights shares concurrent computations of the same payload
+	flights *inflight
+	// templates fill in the about section of media kits
+	templates templates.Repository
 }
 
-func NewService(db database.Service, twitchClient *twitch.Client) Service {
-	repo := NewRepository(db.GetDB())
-	collector := NewDataCollector(repo, twitchClient)
-
+// NewService creates the analytics service. It shares the collector used by the
+// background scheduler so manual refreshes run the same collection hooks.
+func NewService(db database.Service, collector DataCollector) Service {
 	return &service{
-		repo:      repo,
+		repo:      NewRepository(db.GetDB()),
 		collector: collector,
 		db:        db,
+		money:     money.NewConverterFromConfig(),
+		payloads:  newPayloadCache(config.AnalyticsCache()),
+		flights:   newInflight(config.AnalyticsCache().MaxInFlightPerUser),
+		templates: templates.NewRepository(db.GetDB()),
 	}
 }
 
 // GetDashboardOverview returns summary metrics for the main dashboard
-func (s *service) GetDashboardOverview(ctx context.Context, userID string) (*DashboardOverview, error) {
-	overview, err := s.repo.GetDashboardOverview(ctx, userID)
+func (s *service) GetDashboardOverview(ctx context.Context, userID string, days int) (*DashboardOverview, error) {
+	return cachedPayload(ctx, s, s.payloads.overviewCache(), "overview", userID, days, false, func(ctx context.Context) (*DashboardOverview, error) {
+		return s.loadDashboardOverview(ctx, userID, days)
+	})
+}
+
+func (s *service) loadDashboardOverview(ctx context.Context, userID string, days int) (*DashboardOverview, error) {
+	overview, err := s.repo.GetDashboardOverview(ctx, userID, days)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get dashboard overview: %w", err)
 	}
 
 	// If no data exists, return default overview
 	if overview.CurrentFollowers == 0 && overview.TotalViews == 0 {
-		return &DashboardOverview{
+		overview = &DashboardOverview{
 			CurrentFollowers:   0,
 			CurrentSubscribers: 0,
 			TotalViews:         0,
 			AverageViewers:     0,
-		}, nil
+			ChangeDays:         days,
+		}
+	}
+
+	if err := s.addConsistency(ctx, userID, overview); err != nil {
+		return nil, fmt.Errorf("failed to get dashboard overview: %w", err)
 	}
 
 	return overview, nil
 }
 
+// addConsistency fills in the consistency figures and the stream activity
+// computed with them after the last video collection
+func (s *service) addConsistency(ctx context.Context, userID string, overview *DashboardOverview) error {
+	consistency, err := s.repo.GetChannelConsistency(ctx, userID)
+	if err != nil {
+		return err
+	}
+	overview.Consistency = consistency
+	if consistency != nil {
+		overview.StreamsLast30Days = consistency.StreamsLast30Days
+		overview.HoursStreamedLast30 = consistency.HoursStreamedLast30
+	}
+	return nil
+}
+
 // GetAnalyticsChartData returns chart data for analytics visualization
 func (s *service) GetAnalyticsChartData(ctx context.Context, userID string, days int) (*AnalyticsChartData, error) {
+	return cachedPayload(ctx, s, s.payloads.chartCache(), "charts", userID, days, false, func(ctx context.Context) (*AnalyticsChartData, error) {
+		return s.loadAnalyticsChartData(ctx, userID, days)
+	})
+}
+
+func (s *service) loadAnalyticsChartData(ctx context.Context, userID string, days int) (*AnalyticsChartData, error) {
 	chartData, err := s.repo.GetAnalyticsChartData(ctx, userID, days)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chart data: %w", err)
@@ -94,6 +181,10 @@ func (s *service) GetDetailedAnalytics(ctx context.Context, userID string) (*Det
 		return nil, fmt.Errorf("failed to get detailed analytics: %w", err)
 	}
 
+	if err := s.addConsistency(ctx, userID, &analytics.Overview); err != nil {
+		return nil, fmt.Errorf("failed to get detailed analytics: %w", err)
+	}
+
 	// Generate recent activity
 	analytics.RecentActivity = s.generateRecentActivity(userID)
 
@@ -102,6 +193,12 @@ func (s *service) GetDetailedAnalytics(ctx context.Context, userID string) (*Det
 
 // GetEnhancedAnalytics returns video-based analytics for the new dashboard design
 func (s *service) GetEnhancedAnalytics(ctx context.Context, userID string, days int) (*EnhancedAnalytics, error) {
+	return cachedPayload(ctx, s, s.payloads.enhancedCache(), "enhanced", userID, days, false, func(ctx context.Context) (*EnhancedAnalytics, error) {
+		return s.loadEnhancedAnalytics(ctx, userID, days)
+	})
+}
+
+func (s *service) loadEnhancedAnalytics(ctx context.Context, userID string, days int) (*EnhancedAnalytics, error) {
 	analytics, err := s.repo.GetEnhancedAnalytics(ctx, userID, days)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get enhanced analytics: %w", err)
@@ -117,8 +214,12 @@ func (s *service) GetEnhancedAnalytics(ctx context.Context, userID string, days
 				TotalWatchTimeHours:  0,
 				CurrentFollowers:     analytics.Overview.CurrentFollowers,
 				CurrentSubscribers:   analytics.Overview.CurrentSubscribers,
-				FollowerChange:       0,
-				SubscriberChange:     0,
+				// Followers change without videos too
+				FollowerChange:          analytics.Overview.FollowerChange,
+				FollowerChangePercent:   analytics.Overview.FollowerChangePercent,
+				SubscriberChange:        analytics.Overview.SubscriberChange,
+				SubscriberChangePercent: analytics.Overview.SubscriberChangePercent,
+				ChangeSince:             analytics.Overview.ChangeSince,
 			},
 			Performance: PerformanceData{
 				ViewsOverTime:       []ChartDataPoint{},
@@ -132,21 +233,120 @@ func (s *service) GetEnhancedAnalytics(ctx context.Context, userID string, days
 	return analytics, nil
 }
 
+// Top video rankings
+const (
+	RankByViews       = "views"
+	RankByViewsPerDay = "views_per_day"
+)
+
+// GetTopVideos returns the top videos by total views or by views per day, of
+// one type when videoType is set
+func (s *service) GetTopVideos(ctx context.Context, userID, videoType, rank string, limit int) ([]VideoAnalytics, error) {
+	var videos []VideoAnalytics
+	var err error
+	if rank == RankByViewsPerDay {
+		videos, err = s.repo.GetTopVideosByViewsPerDay(ctx, userID, videoType, limit)
+	} else {
+		videos, err = s.repo.GetTopVideos(ctx, userID, videoType, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top videos: %w", err)
+	}
+	if videos == nil {
+		videos = []VideoAnalytics{}
+	}
+	return videos, nil
+}
+
+// GetRecentVideos returns the newest videos
+func (s *service) GetRecentVideos(ctx context.Context, userID string, limit int) ([]VideoAnalytics, error) {
+	videos, err := s.repo.GetVideoAnalytics(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent videos: %w", err)
+	}
+	if videos == nil {
+		videos = []VideoAnalytics{}
+	}
+	return videos, nil
+}
+
+// GetStreamSessionDetail returns a stream with its attributed VODs, clips and follower gains
+func (s *service) GetStreamSessionDetail(ctx context.Context, userID, streamID string) (*StreamSessionDetail, error) {
+	detail, err := s.repo.GetStreamSessionDetail(ctx, userID, streamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream session detail: %w", err)
+	}
+	return detail, nil
+}
+
+// ListVideos returns one page of the user's content library
+func (s *service) ListVideos(ctx context.Context, userID string, query VideoListQuery) (*VideoPage, error) {
+	videos, total, err := s.repo.ListVideos(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list videos: %w", err)
+	}
+	return newVideoPage(videos, total, query), nil
+}
+
+// maxKeywordResults bounds the keyword ranking
+const maxKeywordResults = 50
+
+// GetKeywordInsights returns which title keywords correlate with higher views
+func (s *service) GetKeywordInsights(ctx context.Context, userID string, days, minVideos int) (*KeywordInsights, error) {
+	insights, err := s.repo.GetKeywordInsights(ctx, userID, days, minVideos, maxKeywordResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keyword insights: %w", err)
+	}
+	return insights, nil
+}
+
+// newVideoPage wraps a page of results, pointing NextOffset past it when more remain
+func newVideoPage(videos []VideoAnalytics, total int, query VideoListQuery) *VideoPage {
+	if videos == nil {
+		videos = []VideoAnalytics{}
+	}
+
+	page := &VideoPage{
+		Videos: videos,
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	if next := query.Offset + len(videos); len(videos) > 0 && next < total {
+		page.NextOffset = &next
+	}
+
+	return page
+}
+
 // TriggerDataCollection manually triggers data collection for a user
-func (s *service) TriggerDataCollection(ctx context.Context, userID string) error {
+func (s *service) TriggerDataCollection(ctx context.Context, userID string, opts CollectionOptions) error {
 	log.Printf("Manually triggering data collection for user %s", userID)
 
 	go func() {
 		// Run in background to avoid blocking the API response
 		bgCtx := context.Background()
-		if err := s.collector.CollectAllUserData(bgCtx, userID); err != nil {
+		report, err := s.collector.CollectAllUserData(bgCtx, userID, opts)
+		if err != nil {
 			log.Printf("Background data collection failed for user %s: %v", userID, err)
+		} else if report.Status != CollectionCompleted {
+			log.Printf("Background data collection for user %s was %s: %s", userID, report.Status, report.errorSummary())
 		}
 	}()
 
 	return nil
 }
 
+// GetChannelHistory returns weekly or monthly rollups of channel metrics that
+// have aged out of the raw daily table
+func (s *service) GetChannelHistory(ctx context.Context, userID, period string, limit int) ([]ChannelAnalyticsRollup, error) {
+	rollups, err := s.repo.GetChannelAnalyticsRollups(ctx, userID, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel history: %w", err)
+	}
+	return rollups, nil
+}
+
 // RefreshChannelData specifically refreshes channel metrics
 func (s *service) RefreshChannelData(ctx context.Context, userID string) error {
 	return s.collector.CollectDailyChannelData(ctx, userID)
@@ -199,6 +399,19 @@ func (s *service) GetGrowthAnalysis(ctx context.Context, userID string, period s
 			Trend:         getTrend(followerPercent),
 		}
 
+	}
+
+	// Backfilled and imported rows have no view counts
+	var collected []ChannelAnalytics
+	for _, row := range analytics {
+		if !row.Synthetic() {
+			collected = append(collected, row)
+		}
+	}
+	if len(collected) >= 2 {
+		latest := collected[0]
+		oldest := collected[len(collected)-1]
+
 		// Calculate view growth
 		viewGrowth := latest.TotalViews - oldest.TotalViews
 		viewPercent := 0.0
@@ -235,7 +448,7 @@ func (s *service) GetContentPerformance(ctx context.Context, userID string) (*Co
 	performance := &ContentPerformance{
 		TopVideos: videos,
 		TopGames:  games,
-		Insights:  s.generateContentInsights(videos, games),
+		Insights:  s.generateContentInsights(i18n.FromContext(ctx), videos, games),
 	}
 
 	return performance, nil
@@ -323,8 +536,8 @@ func (s *service) generateRecentActivity(userID string) []ActivityItem {
 	}
 }
 
-// Helper function to generate content insights
-func (s *service) generateContentInsights(videos []VideoAnalytics, games []GameAnalytics) []string {
+// Helper function to generate content insights in the locale
+func (s *service) generateContentInsights(locale string, videos []VideoAnalytics, games []GameAnalytics) []string {
 	insights := []string{}
 
 	if len(videos) > 0 {
@@ -333,16 +546,16 @@ func (s *service) generateContentInsights(videos []VideoAnalytics, games []GameA
 			totalViews += video.ViewCount
 		}
 		avgViews := totalViews / len(videos)
-		insights = append(insights, fmt.Sprintf("Your videos average %d views", avgViews))
+		insights = append(insights, i18n.T(locale, "Your videos average %d views", avgViews))
 	}
 
 	if len(games) > 0 {
 		topGame := games[0]
-		insights = append(insights, fmt.Sprintf("%s is your most streamed game with %.1f hours", topGame.GameName, topGame.TotalHoursStreamed))
+		insights = append(insights, i18n.T(locale, "%s is your most streamed game with %.1f hours", topGame.GameName, topGame.TotalHoursStreamed))
 	}
 
 	if len(insights) == 0 {
-		insights = append(insights, "Start streaming to see performance insights!")
+		insights = append(insights, i18n.T(locale, "Start streaming to see performance insights!"))
 	}
 
 	return insights
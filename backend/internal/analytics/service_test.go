@@ -0,0 +1,308 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+func newTestService() (*service, *mockRepository, *mockCollector) {
+	repo := &mockRepository{}
+	collector := &mockCollector{}
+	return &service{repo: repo, collector: collector}, repo, collector
+}
+
+func TestGetEnhancedAnalyticsWithoutVideos(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	repo.On("GetEnhancedAnalytics", ctx, "user_1", 30).Return(&EnhancedAnalytics{
+		Overview: VideoBasedOverview{CurrentFollowers: 1200, CurrentSubscribers: 40, TotalWatchTimeHours: 3},
+	}, nil)
+
+	analytics, err := svc.GetEnhancedAnalytics(ctx, "user_1", 30)
+	require.NoError(t, err)
+
+	// Channel counts survive, video metrics are zeroed and lists are empty rather than null
+	assert.Equal(t, 1200, analytics.Overview.CurrentFollowers)
+	assert.Equal(t, 40, analytics.Overview.CurrentSubscribers)
+	assert.Zero(t, analytics.Overview.TotalWatchTimeHours)
+	assert.NotNil(t, analytics.Performance.ViewsOverTime)
+	assert.NotNil(t, analytics.Performance.ContentDistribution)
+	assert.NotNil(t, analytics.TopVideos)
+	assert.NotNil(t, analytics.RecentVideos)
+	repo.AssertExpectations(t)
+}
+
+func TestGetEnhancedAnalyticsPassesThroughVideoData(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	stored := &EnhancedAnalytics{
+		Overview:  VideoBasedOverview{VideoCount: 2, TotalViews: 300, AverageViewsPerVideo: 150},
+		TopVideos: []VideoAnalytics{{ViewCount: 200}, {ViewCount: 100}},
+	}
+	repo.On("GetEnhancedAnalytics", ctx, "user_1", 7).Return(stored, nil)
+
+	analytics, err := svc.GetEnhancedAnalytics(ctx, "user_1", 7)
+	require.NoError(t, err)
+	assert.Same(t, stored, analytics)
+}
+
+func TestGetEnhancedAnalyticsWrapsRepositoryError(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+	dbErr := errors.New("connection refused")
+
+	repo.On("GetEnhancedAnalytics", ctx, "user_1", 30).Return(nil, dbErr)
+
+	_, err := svc.GetEnhancedAnalytics(ctx, "user_1", 30)
+	assert.ErrorIs(t, err, dbErr)
+}
+
+func TestGetTopVideosRanking(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	repo.On("GetTopVideos", ctx, "user_1", "clip", 5).Return([]VideoAnalytics{{VideoID: "most_viewed"}}, nil)
+	repo.On("GetTopVideosByViewsPerDay", ctx, "user_1", "", 5).Return(nil, nil)
+
+	videos, err := svc.GetTopVideos(ctx, "user_1", "clip", RankByViews, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "most_viewed", videos[0].VideoID)
+
+	videos, err = svc.GetTopVideos(ctx, "user_1", "", RankByViewsPerDay, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []VideoAnalytics{}, videos)
+	repo.AssertExpectations(t)
+}
+
+func TestChangePercent(t *testing.T) {
+	assert.InDelta(t, 20, changePercent(200, 1200), 0.001)
+	assert.InDelta(t, -20, changePercent(-10, 40), 0.001)
+	// Growing from nothing has no meaningful percentage
+	assert.Zero(t, changePercent(50, 50))
+	assert.Zero(t, changePercent(0, 0))
+}
+
+func TestBuildPerformanceData(t *testing.T) {
+	performance := buildPerformanceData([]dailyContent{
+		{Date: "2025-06-01", VideoType: "archive", Views: 300, Count: 1},
+		{Date: "2025-06-01", VideoType: "clip", Views: 50, Count: 2},
+		{Date: "2025-06-03", VideoType: "upload", Views: 120, Count: 1},
+		{Date: "2025-06-03", VideoType: "highlight", Views: 30, Count: 1},
+	})
+
+	assert.Equal(t, []ChartDataPoint{
+		{Date: "2025-06-01", Value: 350},
+		{Date: "2025-06-03", Value: 150},
+	}, performance.ViewsOverTime)
+	assert.Equal(t, []ContentTypeData{
+		{Date: "2025-06-01", Broadcasts: 1, Clips: 2},
+		{Date: "2025-06-03", Uploads: 1},
+	}, performance.ContentDistribution)
+
+	assert.Empty(t, buildPerformanceData(nil).ViewsOverTime)
+}
+
+func TestGetDashboardOverviewUsesStoredStreamActivity(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	repo.On("GetDashboardOverview", ctx, "user_1", 7).Return(&DashboardOverview{CurrentFollowers: 1200, ChangeDays: 7}, nil)
+	repo.On("GetChannelConsistency", ctx, "user_1").Return(&ChannelConsistency{
+		CurrentStreakWeeks:  3,
+		StreamsLast30Days:   12,
+		HoursStreamedLast30: 41.5,
+	}, nil)
+
+	overview, err := svc.GetDashboardOverview(ctx, "user_1", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 12, overview.StreamsLast30Days)
+	assert.Equal(t, 41.5, overview.HoursStreamedLast30)
+	assert.Equal(t, 3, overview.Consistency.CurrentStreakWeeks)
+}
+
+func TestGetAnalyticsChartDataFallsBackToPlaceholderSeries(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	repo.On("GetAnalyticsChartData", ctx, "user_1", 7).Return(&AnalyticsChartData{}, nil)
+
+	data, err := svc.GetAnalyticsChartData(ctx, "user_1", 7)
+	require.NoError(t, err)
+	// One point per day, today included
+	assert.Len(t, data.FollowerGrowth, 8)
+	assert.Len(t, data.ViewershipTrends, 8)
+}
+
+func TestGetGrowthAnalysis(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	// Newest first, as the repository returns them
+	repo.On("GetChannelAnalytics", ctx, "user_1", 7).Return([]ChannelAnalytics{
+		{FollowersCount: 1100, TotalViews: 5000, Source: ChannelSourceCollected},
+		{FollowersCount: 1050, TotalViews: 5000, Source: ChannelSourceCollected},
+		{FollowersCount: 1000, TotalViews: 5100, Source: ChannelSourceCollected},
+	}, nil)
+
+	growth, err := svc.GetGrowthAnalysis(ctx, "user_1", "week")
+	require.NoError(t, err)
+
+	followers := growth.Metrics["followers"]
+	assert.Equal(t, 100, followers.Change)
+	assert.InDelta(t, 10.0, followers.PercentChange, 0.001)
+	assert.Equal(t, "up", followers.Trend)
+
+	views := growth.Metrics["views"]
+	assert.Equal(t, -100, views.Change)
+	assert.InDelta(t, -1.96, views.PercentChange, 0.01)
+	assert.Equal(t, "stable", views.Trend)
+}
+
+func TestGetGrowthAnalysisSkipsBackfilledViews(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	repo.On("GetChannelAnalytics", ctx, "user_1", 7).Return([]ChannelAnalytics{
+		{FollowersCount: 1100, TotalViews: 5000, Source: ChannelSourceCollected},
+		{FollowersCount: 1050, TotalViews: 4900, Source: ChannelSourceCollected},
+		{FollowersCount: 900, Source: ChannelSourceBackfill},
+	}, nil)
+
+	growth, err := svc.GetGrowthAnalysis(ctx, "user_1", "week")
+	require.NoError(t, err)
+
+	// Followers reach back to the backfilled day, views only to the collected one
+	assert.Equal(t, 200, growth.Metrics["followers"].Change)
+	assert.Equal(t, 100, growth.Metrics["views"].Change)
+	assert.Equal(t, 4900, growth.Metrics["views"].Previous)
+}
+
+func TestGetGrowthAnalysisNeedsTwoDays(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	// Unknown periods fall back to a month
+	repo.On("GetChannelAnalytics", ctx, "user_1", 30).Return([]ChannelAnalytics{{FollowersCount: 10}}, nil)
+
+	growth, err := svc.GetGrowthAnalysis(ctx, "user_1", "decade")
+	require.NoError(t, err)
+	assert.Empty(t, growth.Metrics)
+}
+
+func TestGetContentPerformanceInsights(t *testing.T) {
+	svc, repo, _ := newTestService()
+	ctx := context.Background()
+
+	repo.On("GetVideoAnalytics", ctx, "user_1", 10).Return([]VideoAnalytics{{ViewCount: 100}, {ViewCount: 51}}, nil)
+	repo.On("GetTopGames", ctx, "user_1", 5).Return([]GameAnalytics{{GameName: "Celeste", TotalHoursStreamed: 12.5}}, nil)
+
+	performance, err := svc.GetContentPerformance(ctx, "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Your videos average 75 views",
+		"Celeste is your most streamed game with 12.5 hours",
+	}, performance.Insights)
+
+	empty, _, _ := newTestService()
+	assert.Equal(t, []string{"Start streaming to see performance insights!"}, empty.generateContentInsights("en", nil, nil))
+	assert.Equal(t, []string{"¡Empieza a transmitir para ver estadísticas de rendimiento!"}, empty.generateContentInsights("es", nil, nil))
+}
+
+func TestRefreshChannelDataUsesCollector(t *testing.T) {
+	svc, _, collector := newTestService()
+	ctx := context.Background()
+	collectErr := errors.New("twitch unavailable")
+
+	collector.On("CollectDailyChannelData", ctx, "user_1").Return(collectErr).Once()
+
+	assert.ErrorIs(t, svc.RefreshChannelData(ctx, "user_1"), collectErr)
+	collector.AssertExpectations(t)
+}
+
+func TestListVideosPagination(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _ := newTestService()
+
+	query := VideoListQuery{Limit: 2, Offset: 2}
+	repo.On("ListVideos", ctx, "user_1", query).Return([]VideoAnalytics{{VideoID: "v3"}, {VideoID: "v4"}}, 5, nil)
+
+	page, err := svc.ListVideos(ctx, "user_1", query)
+	require.NoError(t, err)
+	assert.Equal(t, 5, page.Total)
+	assert.Len(t, page.Videos, 2)
+	require.NotNil(t, page.NextOffset)
+	assert.Equal(t, 4, *page.NextOffset)
+
+	// Last and past-the-end pages have no next offset
+	assert.Nil(t, newVideoPage([]VideoAnalytics{{VideoID: "v5"}}, 5, VideoListQuery{Limit: 2, Offset: 4}).NextOffset)
+	empty := newVideoPage(nil, 5, VideoListQuery{Limit: 2, Offset: 10})
+	assert.Nil(t, empty.NextOffset)
+	assert.NotNil(t, empty.Videos)
+}
+
+func TestGetValidTokenRefreshesExpiringToken(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+
+	repo := &mockRepository{}
+	api := &mockTwitchAPI{}
+	helper := NewTwitchTokenHelper(repo, api)
+
+	refreshToken, keyID, err := encryptToken(ctx, "refresh-1")
+	require.NoError(t, err)
+	expiresAt := time.Now().Add(time.Minute)
+	repo.On("GetTwitchToken", ctx, "user_1").Return(&TwitchToken{
+		UserID:          "user_1",
+		TwitchUserID:    "tw_1",
+		AccessToken:     "stale",
+		RefreshToken:    refreshToken,
+		EncryptionKeyID: keyID,
+		ExpiresAt:       &expiresAt,
+	}, nil)
+	api.On("RefreshToken", ctx, "refresh-1").Return(&twitch.OAuthToken{
+		AccessToken:  "access-2",
+		RefreshToken: "refresh-2",
+		ExpiresIn:    3600,
+	}, nil)
+	repo.On("SaveTwitchToken", ctx, mock.MatchedBy(func(token *TwitchToken) bool {
+		return token.UserID == "user_1" && token.AccessToken != "access-2"
+	})).Return(nil)
+
+	token, err := helper.GetValidToken(ctx, "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", token)
+	repo.AssertExpectations(t)
+}
+
+func TestGetValidTokenRequiresReauthWhenRefreshRejected(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+
+	repo := &mockRepository{}
+	api := &mockTwitchAPI{}
+	helper := NewTwitchTokenHelper(repo, api)
+
+	refreshToken, keyID, err := encryptToken(ctx, "revoked")
+	require.NoError(t, err)
+	expired := time.Now().Add(-time.Hour)
+	repo.On("GetTwitchToken", ctx, "user_1").Return(&TwitchToken{
+		UserID:          "user_1",
+		RefreshToken:    refreshToken,
+		EncryptionKeyID: keyID,
+		ExpiresAt:       &expired,
+	}, nil)
+	api.On("RefreshToken", ctx, "revoked").Return(nil, &twitch.APIError{StatusCode: 400, Body: "Invalid refresh token"})
+
+	_, err = helper.GetValidToken(ctx, "user_1")
+	assert.ErrorIs(t, err, ErrTwitchAuthRequired)
+}
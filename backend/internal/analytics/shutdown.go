@@ -0,0 +1,163 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// interruptGracePeriod is how long interrupted collections get to stop and
+// queue themselves once the drain timeout has passed
+const interruptGracePeriod = 5 * time.Second
+
+// jobTracker tracks the collections and scheduled jobs running on this
+// replica, so shutdown can let them finish. The zero value is ready to use.
+type jobTracker struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	// interrupt is closed when drain gives up waiting
+	interrupt chan struct{}
+}
+
+// interruptChannel returns t.interrupt, creating it. t.mu must be held.
+func (t *jobTracker) interruptChannel() chan struct{} {
+	if t.interrupt == nil {
+		t.interrupt = make(chan struct{})
+	}
+	return t.interrupt
+}
+
+// start registers a job running with ctx. The returned context is also
+// cancelled when drain interrupts the job, and done must be called once it
+// returns. ok is false, and the job must not run, once shutdown has begun.
+func (t *jobTracker) start(ctx context.Context) (jobCtx context.Context, done func(), ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return nil, nil, false
+	}
+
+	t.wg.Add(1)
+	jobCtx, cancel := context.WithCancel(ctx)
+	interrupt := t.interruptChannel()
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-jobCtx.Done():
+		}
+	}()
+	return jobCtx, func() {
+		cancel()
+		t.wg.Done()
+	}, true
+}
+
+// isDraining reports whether shutdown has begun
+func (t *jobTracker) isDraining() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.draining
+}
+
+// interrupted reports whether drain gave up and cancelled the running jobs
+func (t *jobTracker) interrupted() bool {
+	t.mu.Lock()
+	interrupt := t.interruptChannel()
+	t.mu.Unlock()
+
+	select {
+	case <-interrupt:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain stops new jobs and waits for the running ones until ctx ends. Jobs
+// still running then are cancelled and get interruptGracePeriod to return.
+// It reports whether every job finished on its own.
+func (t *jobTracker) drain(ctx context.Context) bool {
+	t.mu.Lock()
+	t.draining = true
+	interrupt := t.interruptChannel()
+	t.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	select {
+	case <-interrupt:
+	default:
+		close(interrupt)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-finished:
+	case <-time.After(interruptGracePeriod):
+		log.Printf("⚠️ Collections still running %s after they were interrupted", interruptGracePeriod)
+	}
+	return false
+}
+
+// Drain stops starting collections and scheduled jobs, and waits for the
+// running ones until ctx ends. Collections still running then are
+// interrupted and queued to resume after the restart.
+func (s *scheduler) Drain(ctx context.Context) bool {
+	log.Println("Draining analytics collections...")
+	if !s.tracker.drain(ctx) {
+		log.Println("Interrupted the collections still running")
+		return false
+	}
+	log.Println("Analytics collections drained")
+	return true
+}
+
+// runTracked runs a job unless shutdown has begun
+func (s *scheduler) runTracked(ctx context.Context, name string, run func(ctx context.Context)) {
+	ctx, done, ok := s.tracker.start(ctx)
+	if !ok {
+		log.Printf("Skipping %s, shutting down", name)
+		return
+	}
+	defer done()
+	run(ctx)
+}
+
+// queueInterrupted queues collections stopped by a shutdown as retries due
+// right away, so the restarted scheduler runs them again. Interruptions
+// don't count as failed attempts and parked users stay parked. opts are the
+// options of user collections, nil for other jobs.
+func (s *scheduler) queueInterrupted(ctx context.Context, userIDs []string, jobType string, opts *CollectionOptions) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	var options []byte
+	if opts != nil {
+		var err error
+		if options, err = json.Marshal(opts); err != nil {
+			log.Printf("Failed to encode options of interrupted %s collections: %v", jobType, err)
+		}
+	}
+
+	if err := s.repo.QueueInterruptedCollections(context.WithoutCancel(ctx), userIDs, jobType, options); err != nil {
+		log.Printf("Failed to queue %d interrupted %s collection(s): %v", len(userIDs), jobType, err)
+		return
+	}
+	log.Printf("⏸️ Queued %d interrupted %s collection(s) to resume after the restart", len(userIDs), jobType)
+}
@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobTrackerDrainWaitsForRunningJobs(t *testing.T) {
+	var tracker jobTracker
+	_, done, ok := tracker.start(context.Background())
+	require.True(t, ok)
+
+	drained := make(chan bool)
+	go func() { drained <- tracker.drain(context.Background()) }()
+
+	require.Eventually(t, tracker.isDraining, time.Second, time.Millisecond)
+	_, _, ok = tracker.start(context.Background())
+	assert.False(t, ok, "no new jobs once draining")
+
+	done()
+	assert.True(t, <-drained)
+	assert.False(t, tracker.interrupted())
+}
+
+func TestJobTrackerInterruptsJobsAfterTimeout(t *testing.T) {
+	var tracker jobTracker
+	jobCtx, done, ok := tracker.start(context.Background())
+	require.True(t, ok)
+	go func() {
+		<-jobCtx.Done()
+		done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, tracker.drain(ctx))
+	assert.True(t, tracker.interrupted())
+	assert.ErrorIs(t, jobCtx.Err(), context.Canceled)
+}
+
+func TestInterruptedUserCollectionIsQueued(t *testing.T) {
+	s, repo, collector := newTestScheduler()
+	opts := CollectionOptions{MaxVideos: 25, VideoTypes: []string{"archive"}}
+	options, err := json.Marshal(opts)
+	require.NoError(t, err)
+
+	collector.On("CollectAllUserData", mock.Anything, "user_1", opts).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Return(&CollectionReport{Status: CollectionFailed}, nil)
+	repo.On("QueueInterruptedCollections", mock.Anything, []string{"user_1"}, "user_collection", options).Return(nil)
+
+	s.TriggerUserCollection("user_1", opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, s.Drain(ctx))
+
+	repo.AssertExpectations(t)
+	collector.AssertExpectations(t)
+}
+
+func TestCollectionsTriggeredDuringShutdownAreQueued(t *testing.T) {
+	s, repo, _ := newTestScheduler()
+	require.True(t, s.Drain(context.Background()))
+
+	repo.On("QueueInterruptedCollections", mock.Anything, []string{"user_1"}, "user_collection", mock.Anything).Return(nil)
+	s.TriggerUserCollection("user_1", DefaultCollectionOptions())
+
+	repo.AssertExpectations(t)
+}
+
+func TestDailyRunQueuesRemainingUsersOnShutdown(t *testing.T) {
+	s, repo, _ := newTestScheduler()
+	ctx := context.Background()
+	require.True(t, s.Drain(ctx))
+
+	repo.On("ListCollectableUserIDs", ctx).Return([]string{"user_1", "user_2"}, nil)
+	repo.On("CreateAnalyticsJob", ctx, mock.Anything).Return(nil)
+	repo.On("QueueInterruptedCollections", mock.Anything, []string{"user_1", "user_2"}, "daily_channel", []byte(nil)).Return(nil)
+
+	s.runDailyCollectionForAllUsers(ctx)
+
+	repo.AssertExpectations(t)
+}
+
+func TestInterruptedUserCollectionRetryKeepsOptions(t *testing.T) {
+	s, repo, collector := newTestScheduler()
+	ctx := context.Background()
+	options := json.RawMessage(`{"max_videos":25,"include_clips":false,"video_types":["archive"]}`)
+
+	repo.On("GetDueCollectionRetries", ctx, mock.Anything, 50).Return([]CollectionRetry{
+		{UserID: "user_1", JobType: "user_collection", FailureKind: FailureInterrupted, Options: &options},
+	}, nil)
+	collector.On("CollectAllUserData", ctx, "user_1", CollectionOptions{MaxVideos: 25, VideoTypes: []string{"archive"}}).
+		Return(&CollectionReport{Status: CollectionCompleted}, nil)
+	repo.On("DeleteCollectionRetry", ctx, "user_1", "user_collection").Return(nil)
+
+	s.processDueRetries(ctx)
+
+	repo.AssertExpectations(t)
+	collector.AssertExpectations(t)
+}
@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout changes
+const snapshotVersion = 1
+
+// UserSnapshot is the raw data exported to object storage after each collection run
+type UserSnapshot struct {
+	Version          int                `json:"version"`
+	GeneratedAt      time.Time          `json:"generated_at"`
+	User             *User              `json:"user"`
+	ChannelAnalytics []ChannelAnalytics `json:"channel_analytics"`
+	Videos           []VideoAnalytics   `json:"videos"`
+	StreamSessions   []StreamSession    `json:"stream_sessions"`
+}
+
+// exportSnapshot uploads a gzipped JSON snapshot of the user's collected data to
+// the configured sink. It is a no-op when exporting is disabled.
+func (dc *dataCollector) exportSnapshot(ctx context.Context, userID string) error {
+	if dc.sink == nil {
+		return nil
+	}
+
+	user, err := dc.repo.GetUserByClerkID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+
+	channel, err := dc.repo.GetChannelAnalytics(ctx, userID, 3650)
+	if err != nil {
+		return fmt.Errorf("failed to load channel analytics: %w", err)
+	}
+
+	videos, err := dc.repo.GetVideoAnalytics(ctx, userID, 10000)
+	if err != nil {
+		return fmt.Errorf("failed to load video analytics: %w", err)
+	}
+
+	sessions, err := dc.repo.GetStreamSessions(ctx, userID, 10000)
+	if err != nil {
+		return fmt.Errorf("failed to load stream sessions: %w", err)
+	}
+
+	now := time.Now().UTC()
+	body, err := encodeSnapshot(&UserSnapshot{
+		Version:          snapshotVersion,
+		GeneratedAt:      now,
+		User:             user,
+		ChannelAnalytics: channel,
+		Videos:           videos,
+		StreamSessions:   sessions,
+	})
+	if err != nil {
+		return err
+	}
+
+	key := snapshotKey(userID, now)
+	if err := dc.sink.Put(ctx, key, body, "application/json", "gzip"); err != nil {
+		return err
+	}
+
+	log.Printf("Exported analytics snapshot for user %s to %s (%d bytes)", userID, key, len(body))
+	return nil
+}
+
+func encodeSnapshot(snapshot *UserSnapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// snapshotKey partitions snapshots by user and day, e.g.
+// users/user_123/2024/05/01/snapshot-20240501T020000Z.json.gz
+func snapshotKey(userID string, now time.Time) string {
+	return fmt.Sprintf("users/%s/%s/snapshot-%s.json.gz", userID, now.Format("2006/01/02"), now.Format("20060102T150405Z"))
+}
@@ -0,0 +1,160 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/money"
+	"github.com/baldybuilds/creatorsync/internal/streamlabs"
+)
+
+// ErrStreamlabsAuthRequired means the user has to reconnect Streamlabs before
+// tips can be collected
+var ErrStreamlabsAuthRequired = errors.New("streamlabs authorization required")
+
+// StreamlabsAPI is the part of the Streamlabs client used to collect tips
+type StreamlabsAPI interface {
+	RefreshToken(ctx context.Context, refreshToken string) (*streamlabs.OAuthToken, error)
+	GetDonations(ctx context.Context, accessToken string, before int64, limit int, currency string) ([]streamlabs.Donation, error)
+}
+
+// StreamlabsCollector stores Streamlabs connections and imports the tips of
+// each connected user
+type StreamlabsCollector struct {
+	repo   Repository
+	client StreamlabsAPI
+}
+
+func NewStreamlabsCollector(repo Repository, client StreamlabsAPI) *StreamlabsCollector {
+	return &StreamlabsCollector{
+		repo:   repo,
+		client: client,
+	}
+}
+
+// StoreToken encrypts and persists a token obtained from the Streamlabs OAuth flow
+func (sc *StreamlabsCollector) StoreToken(ctx context.Context, userID, streamlabsUserID string, token *streamlabs.OAuthToken) error {
+	accessToken, keyID, err := encryptToken(ctx, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+
+	refreshToken := ""
+	if token.RefreshToken != "" {
+		refreshToken, _, err = encryptToken(ctx, token.RefreshToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt refresh token: %w", err)
+		}
+	}
+
+	return sc.repo.SaveStreamlabsToken(ctx, &StreamlabsToken{
+		UserID:           userID,
+		StreamlabsUserID: streamlabsUserID,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		EncryptionKeyID:  keyID,
+		Scopes:           strings.Join(streamlabs.Scopes, " "),
+		ExpiresAt:        token.ExpiresAt(),
+	})
+}
+
+// CollectUser imports the user's tips received since the last collection, or
+// up to MaxDonationPages of history on the first one. Users without a
+// Streamlabs connection are skipped. It has the signature of a CollectionHook
+// so it runs after the daily collection.
+func (sc *StreamlabsCollector) CollectUser(ctx context.Context, userID string) error {
+	stored, err := sc.repo.GetStreamlabsToken(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load stored Streamlabs token: %w", err)
+	}
+	if stored == nil {
+		return nil
+	}
+
+	accessToken, err := sc.validToken(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	latestID, err := sc.repo.GetLatestStreamlabsDonationID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get latest Streamlabs donation: %w", err)
+	}
+
+	var donations []StreamlabsDonation
+	before, caughtUp := int64(0), false
+	for page := 0; page < config.Streamlabs().MaxDonationPages && !caughtUp; page++ {
+		resp, err := sc.client.GetDonations(ctx, accessToken, before, streamlabs.MaxDonationsPerPage, string(money.Base))
+		if err != nil {
+			return fmt.Errorf("failed to get Streamlabs donations: %w", err)
+		}
+
+		for _, donation := range resp {
+			if donation.ID <= latestID {
+				caughtUp = true
+				break
+			}
+			donations = append(donations, StreamlabsDonation{
+				UserID:      userID,
+				DonationID:  donation.ID,
+				AmountCents: donation.AmountCents,
+				DonatedAt:   donation.CreatedAt,
+			})
+			before = donation.ID
+		}
+		if len(resp) < streamlabs.MaxDonationsPerPage {
+			caughtUp = true
+		}
+	}
+	if !caughtUp {
+		log.Printf("Streamlabs tips of user %s stop after the newest %d donations", userID, len(donations))
+	}
+	if len(donations) == 0 {
+		return nil
+	}
+
+	saved, err := sc.repo.SaveStreamlabsDonations(ctx, donations)
+	if err != nil {
+		return fmt.Errorf("failed to save Streamlabs donations: %w", err)
+	}
+	log.Printf("💸 Imported %d Streamlabs tips for user %s", saved, userID)
+	return nil
+}
+
+// validToken returns the stored access token, refreshing it if it is about to
+// expire. Tokens without an expiry are used until Streamlabs rejects them.
+func (sc *StreamlabsCollector) validToken(ctx context.Context, stored *StreamlabsToken) (string, error) {
+	if stored.ExpiresAt == nil || time.Until(*stored.ExpiresAt) > tokenRefreshMargin {
+		return decryptToken(ctx, stored.AccessToken, stored.EncryptionKeyID)
+	}
+
+	if stored.RefreshToken == "" {
+		return "", fmt.Errorf("stored Streamlabs token expired and has no refresh token: %w", ErrStreamlabsAuthRequired)
+	}
+	refreshToken, err := decryptToken(ctx, stored.RefreshToken, stored.EncryptionKeyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+
+	log.Printf("Refreshing Streamlabs token for user %s", stored.UserID)
+	token, err := sc.client.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		var apiErr *streamlabs.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			// Streamlabs rejected the refresh token itself, e.g. because access was revoked
+			return "", fmt.Errorf("failed to refresh Streamlabs token: %v: %w", err, ErrStreamlabsAuthRequired)
+		}
+		return "", fmt.Errorf("failed to refresh Streamlabs token: %w", err)
+	}
+
+	if err := sc.StoreToken(ctx, stored.UserID, stored.StreamlabsUserID, token); err != nil {
+		return "", fmt.Errorf("failed to store refreshed Streamlabs token: %w", err)
+	}
+	return token.AccessToken, nil
+}
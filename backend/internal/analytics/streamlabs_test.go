@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/streamlabs"
+)
+
+// donationPage returns a full page of donations with IDs counting down from first
+func donationPage(first int64) []streamlabs.Donation {
+	page := make([]streamlabs.Donation, streamlabs.MaxDonationsPerPage)
+	for i := range page {
+		page[i] = streamlabs.Donation{ID: first - int64(i), AmountCents: 100, CreatedAt: time.Unix(1760000000, 0)}
+	}
+	return page
+}
+
+func TestStreamlabsCollectUserStopsAtStoredTips(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+	accessToken, keyID, err := encryptToken(ctx, "access")
+	require.NoError(t, err)
+
+	repo := &mockRepository{}
+	client := &mockStreamlabsAPI{}
+	repo.On("GetStreamlabsToken", ctx, "user_1").Return(&StreamlabsToken{
+		UserID: "user_1", AccessToken: accessToken, EncryptionKeyID: keyID,
+	}, nil)
+	repo.On("GetLatestStreamlabsDonationID", ctx, "user_1").Return(int64(850), nil)
+	client.On("GetDonations", ctx, "access", int64(0), streamlabs.MaxDonationsPerPage, "USD").Return(donationPage(1000), nil)
+	// The second page reaches the newest stored tip
+	client.On("GetDonations", ctx, "access", int64(901), streamlabs.MaxDonationsPerPage, "USD").Return(donationPage(900), nil)
+	repo.On("SaveStreamlabsDonations", ctx, mock.MatchedBy(func(donations []StreamlabsDonation) bool {
+		return len(donations) == 150 && donations[0].DonationID == 1000 && donations[149].DonationID == 851 &&
+			donations[0].UserID == "user_1" && donations[0].AmountCents == 100
+	})).Return(150, nil)
+
+	require.NoError(t, NewStreamlabsCollector(repo, client).CollectUser(ctx, "user_1"))
+	repo.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestStreamlabsCollectUserWithoutNewTips(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+	accessToken, keyID, err := encryptToken(ctx, "access")
+	require.NoError(t, err)
+
+	repo := &mockRepository{}
+	client := &mockStreamlabsAPI{}
+	repo.On("GetStreamlabsToken", ctx, "user_1").Return(&StreamlabsToken{
+		UserID: "user_1", AccessToken: accessToken, EncryptionKeyID: keyID,
+	}, nil)
+	repo.On("GetLatestStreamlabsDonationID", ctx, "user_1").Return(int64(0), nil)
+	client.On("GetDonations", ctx, "access", int64(0), streamlabs.MaxDonationsPerPage, "USD").Return([]streamlabs.Donation{}, nil)
+
+	require.NoError(t, NewStreamlabsCollector(repo, client).CollectUser(ctx, "user_1"))
+	repo.AssertNotCalled(t, "SaveStreamlabsDonations", mock.Anything, mock.Anything)
+}
+
+func TestStreamlabsCollectUserWithoutConnection(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{}
+	client := &mockStreamlabsAPI{}
+	repo.On("GetStreamlabsToken", ctx, "user_1").Return(nil, nil)
+
+	require.NoError(t, NewStreamlabsCollector(repo, client).CollectUser(ctx, "user_1"))
+	client.AssertNotCalled(t, "GetDonations", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStreamlabsRevokedRefreshTokenRequiresAuth(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+	ctx := context.Background()
+	refreshToken, keyID, err := encryptToken(ctx, "refresh")
+	require.NoError(t, err)
+
+	repo := &mockRepository{}
+	client := &mockStreamlabsAPI{}
+	expiresAt := time.Now().Add(-time.Hour)
+	repo.On("GetStreamlabsToken", ctx, "user_1").Return(&StreamlabsToken{
+		UserID: "user_1", RefreshToken: refreshToken, EncryptionKeyID: keyID, ExpiresAt: &expiresAt,
+	}, nil)
+	client.On("RefreshToken", ctx, "refresh").Return(nil, &streamlabs.APIError{StatusCode: http.StatusBadRequest})
+
+	err = NewStreamlabsCollector(repo, client).CollectUser(ctx, "user_1")
+	assert.ErrorIs(t, err, ErrStreamlabsAuthRequired)
+}
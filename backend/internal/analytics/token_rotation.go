@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+)
+
+// tokenRotationBatchSize is how many tokens are re-encrypted per query
+const tokenRotationBatchSize = 100
+
+// TokenTables hold OAuth tokens encrypted with the token keyring. Every one
+// has user_id, access_token, refresh_token, encryption_key_id and updated_at
+// columns.
+var TokenTables = []string{"user_twitch_tokens", "user_patreon_tokens", "user_youtube_tokens", "user_streamlabs_tokens"}
+
+// TokenRotationResult summarizes a re-encryption run over all TokenTables
+type TokenRotationResult struct {
+	CurrentKeyID string `json:"current_key_id"`
+	TokenTableRotation
+	Tables []TokenTableRotation `json:"tables"`
+}
+
+// TokenTableRotation counts the tokens of one table, or of all of them
+type TokenTableRotation struct {
+	Table string `json:"table,omitempty"`
+	// Checked is how many tokens were found on an older key
+	Checked     int `json:"checked"`
+	Reencrypted int `json:"reencrypted"`
+	// Skipped tokens changed while the run was in progress
+	Skipped int `json:"skipped"`
+	// Failed tokens could not be decrypted, usually because their key was removed
+	Failed int `json:"failed"`
+}
+
+func (t *TokenTableRotation) add(other TokenTableRotation) {
+	t.Checked += other.Checked
+	t.Reencrypted += other.Reencrypted
+	t.Skipped += other.Skipped
+	t.Failed += other.Failed
+}
+
+// ReencryptTokens moves every stored token in TokenTables that isn't on the
+// current key onto it. Once it reports no failures, older keys can be removed
+// from TWITCH_TOKEN_ENCRYPTION_KEYS. With a KMS provider configured this moves
+// tokens from local keys onto the KMS. With dryRun it only counts the tokens.
+func ReencryptTokens(ctx context.Context, repo Repository, keyring *tokencrypt.Keyring, dryRun bool) (*TokenRotationResult, error) {
+	result := &TokenRotationResult{CurrentKeyID: keyring.CurrentKeyID(), Tables: []TokenTableRotation{}}
+
+	for _, table := range TokenTables {
+		tableResult, err := reencryptTable(ctx, repo, keyring, table, dryRun)
+		result.add(tableResult)
+		result.Tables = append(result.Tables, tableResult)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func reencryptTable(ctx context.Context, repo Repository, keyring *tokencrypt.Keyring, table string, dryRun bool) (TokenTableRotation, error) {
+	result := TokenTableRotation{Table: table}
+
+	after := ""
+	for {
+		tokens, err := repo.ListTokensNotUsingKey(ctx, table, keyring.CurrentKeyID(), after, tokenRotationBatchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list tokens of %s: %w", table, err)
+		}
+		if len(tokens) == 0 {
+			return result, nil
+		}
+		after = tokens[len(tokens)-1].UserID
+
+		for i := range tokens {
+			result.Checked++
+			if dryRun {
+				continue
+			}
+
+			updated, err := reencryptToken(ctx, repo, keyring, table, &tokens[i])
+			switch {
+			case err != nil:
+				log.Printf("Failed to re-encrypt %s token for user %s (key %q): %v", table, tokens[i].UserID, tokens[i].EncryptionKeyID, err)
+				result.Failed++
+			case updated:
+				result.Reencrypted++
+			default:
+				result.Skipped++
+			}
+		}
+	}
+}
+
+func reencryptToken(ctx context.Context, repo Repository, keyring *tokencrypt.Keyring, table string, token *EncryptedToken) (bool, error) {
+	oldKeyID := token.EncryptionKeyID
+
+	accessToken, err := keyring.Decrypt(ctx, token.AccessToken, oldKeyID)
+	if err != nil {
+		return false, err
+	}
+	token.AccessToken, token.EncryptionKeyID, err = keyring.Encrypt(ctx, accessToken)
+	if err != nil {
+		return false, err
+	}
+
+	if token.RefreshToken != "" {
+		refreshToken, err := keyring.Decrypt(ctx, token.RefreshToken, oldKeyID)
+		if err != nil {
+			return false, err
+		}
+		token.RefreshToken, _, err = keyring.Encrypt(ctx, refreshToken)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return repo.UpdateTokenEncryption(ctx, table, token)
+}
@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/dbcheck"
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+)
+
+// tokenTableRepo keeps the rows of the token tables in memory
+type tokenTableRepo struct {
+	Repository
+	tables map[string][]EncryptedToken
+}
+
+func (r *tokenTableRepo) ListTokensNotUsingKey(ctx context.Context, table, keyID, afterUserID string, limit int) ([]EncryptedToken, error) {
+	tokens := []EncryptedToken{}
+	for _, token := range r.tables[table] {
+		if token.EncryptionKeyID != keyID && token.UserID > afterUserID && len(tokens) < limit {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *tokenTableRepo) UpdateTokenEncryption(ctx context.Context, table string, token *EncryptedToken) (bool, error) {
+	for i := range r.tables[table] {
+		if r.tables[table][i].UserID == token.UserID {
+			r.tables[table][i] = *token
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestReencryptTokensCoversEveryTable(t *testing.T) {
+	ctx := context.Background()
+	old, err := tokencrypt.NewKeyring(config.TokenEncryptionConfig{
+		Keys:         map[string]string{"k1": "first-secret"},
+		CurrentKeyID: "k1",
+	})
+	require.NoError(t, err)
+	rotated, err := tokencrypt.NewKeyring(config.TokenEncryptionConfig{
+		Keys:         map[string]string{"k1": "first-secret", "k2": "second-secret"},
+		CurrentKeyID: "k2",
+	})
+	require.NoError(t, err)
+
+	encrypt := func(keyring *tokencrypt.Keyring, plaintext string) (string, string) {
+		encrypted, keyID, err := keyring.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+		return encrypted, keyID
+	}
+
+	// Every table has a token on the old key, a token already on the new one
+	// and one whose key was removed
+	repo := &tokenTableRepo{tables: make(map[string][]EncryptedToken)}
+	for _, table := range TokenTables {
+		access, keyID := encrypt(old, table+"-access")
+		refresh, _ := encrypt(old, table+"-refresh")
+		current, currentKeyID := encrypt(rotated, "current")
+		repo.tables[table] = []EncryptedToken{
+			{UserID: "user_a", AccessToken: access, RefreshToken: refresh, EncryptionKeyID: keyID},
+			{UserID: "user_b", AccessToken: current, EncryptionKeyID: currentKeyID},
+			{UserID: "user_c", AccessToken: "lost", EncryptionKeyID: "k0"},
+		}
+	}
+
+	result, err := ReencryptTokens(ctx, repo, rotated, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2*len(TokenTables), result.Checked, "dry runs count the tokens of every table")
+	assert.Zero(t, result.Reencrypted)
+
+	result, err = ReencryptTokens(ctx, repo, rotated, false)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", result.CurrentKeyID)
+	assert.Equal(t, len(TokenTables), result.Reencrypted)
+	assert.Equal(t, len(TokenTables), result.Failed)
+
+	tables := make([]string, 0, len(result.Tables))
+	for _, table := range result.Tables {
+		tables = append(tables, table.Table)
+		assert.Equal(t, 1, table.Reencrypted, table.Table)
+	}
+	assert.ElementsMatch(t, TokenTables, tables)
+
+	for _, table := range TokenTables {
+		token := repo.tables[table][0]
+		require.Equal(t, "k2", token.EncryptionKeyID, table)
+		access, err := rotated.Decrypt(ctx, token.AccessToken, token.EncryptionKeyID)
+		require.NoError(t, err)
+		assert.Equal(t, table+"-access", access)
+		refresh, err := rotated.Decrypt(ctx, token.RefreshToken, token.EncryptionKeyID)
+		require.NoError(t, err)
+		assert.Equal(t, table+"-refresh", refresh)
+	}
+
+	// Only the undecryptable tokens remain on an old key
+	result, err = ReencryptTokens(ctx, repo, rotated, true)
+	require.NoError(t, err)
+	assert.Equal(t, len(TokenTables), result.Checked)
+}
+
+func TestTokenTablesMatchDBCheck(t *testing.T) {
+	// Tokens dbcheck decrypts but rotation skips become unreadable once the
+	// old key is removed
+	assert.ElementsMatch(t, dbcheck.TokenTables, TokenTables)
+}
@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// tokenRefreshMargin is how long before expiry a stored token gets refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+// ErrTwitchAuthRequired means the user has to reconnect Twitch before data can be collected
+var ErrTwitchAuthRequired = errors.New("twitch authorization required")
+
+// TwitchTokenHelper resolves Twitch access tokens for a user. Tokens stored by our
+// own OAuth flow take precedence; the Clerk-managed token is used as a fallback.
+type TwitchTokenHelper struct {
+	repo         Repository
+	twitchClient TwitchAPI
+}
+
+func NewTwitchTokenHelper(repo Repository, twitchClient TwitchAPI) *TwitchTokenHelper {
+	return &TwitchTokenHelper{
+		repo:         repo,
+		twitchClient: twitchClient,
+	}
+}
+
+// StoreToken encrypts and persists a token obtained from the Twitch OAuth flow
+func (h *TwitchTokenHelper) StoreToken(ctx context.Context, userID, twitchUserID string, token *twitch.OAuthToken) error {
+	keyring, err := tokencrypt.FromEnv()
+	if err != nil {
+		return err
+	}
+
+	accessToken, keyID, err := keyring.Encrypt(ctx, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+
+	refreshToken := ""
+	if token.RefreshToken != "" {
+		refreshToken, _, err = keyring.Encrypt(ctx, token.RefreshToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt refresh token: %w", err)
+		}
+	}
+
+	expiresAt := token.ExpiresAt()
+	return h.repo.SaveTwitchToken(ctx, &TwitchToken{
+		UserID:          userID,
+		TwitchUserID:    twitchUserID,
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		EncryptionKeyID: keyID,
+		Scopes:          strings.Join(token.Scope, " "),
+		ExpiresAt:       &expiresAt,
+	})
+}
+
+// GetValidToken returns an access token for the user, refreshing a stored token
+// if it is about to expire
+func (h *TwitchTokenHelper) GetValidToken(ctx context.Context, userID string) (string, error) {
+	stored, err := h.repo.GetTwitchToken(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load stored Twitch token: %w", err)
+	}
+
+	if stored == nil {
+		return clerk.GetOAuthToken(ctx, userID, "oauth_twitch")
+	}
+
+	if stored.ExpiresAt == nil || time.Until(*stored.ExpiresAt) > tokenRefreshMargin {
+		return decryptToken(ctx, stored.AccessToken, stored.EncryptionKeyID)
+	}
+
+	return h.refreshStoredToken(ctx, stored)
+}
+
+// GetGrantedScopes returns the scopes granted to the token used for the user and
+// where that token comes from ("creatorsync" or "clerk")
+func (h *TwitchTokenHelper) GetGrantedScopes(ctx context.Context, userID string) ([]string, string, error) {
+	stored, err := h.repo.GetTwitchToken(ctx, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load stored Twitch token: %w", err)
+	}
+
+	if stored != nil {
+		return strings.Fields(stored.Scopes), "creatorsync", nil
+	}
+
+	token, err := clerk.GetOAuthToken(ctx, userID, "oauth_twitch")
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := h.twitchClient.GetTokenInfo(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+	if info == nil {
+		return nil, "", errors.New("twitch token is invalid or expired")
+	}
+
+	return info.Scopes, "clerk", nil
+}
+
+func (h *TwitchTokenHelper) refreshStoredToken(ctx context.Context, stored *TwitchToken) (string, error) {
+	if stored.RefreshToken == "" {
+		return "", fmt.Errorf("stored Twitch token expired and has no refresh token: %w", ErrTwitchAuthRequired)
+	}
+
+	refreshToken, err := decryptToken(ctx, stored.RefreshToken, stored.EncryptionKeyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+
+	log.Printf("Refreshing Twitch token for user %s", stored.UserID)
+	token, err := h.twitchClient.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		var apiErr *twitch.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			// Twitch rejected the refresh token itself, e.g. because access was revoked
+			return "", fmt.Errorf("failed to refresh Twitch token: %v: %w", err, ErrTwitchAuthRequired)
+		}
+		return "", fmt.Errorf("failed to refresh Twitch token: %w", err)
+	}
+
+	if err := h.StoreToken(ctx, stored.UserID, stored.TwitchUserID, token); err != nil {
+		return "", fmt.Errorf("failed to store refreshed Twitch token: %w", err)
+	}
+
+	return token.AccessToken, nil
+}
+
+// encryptToken encrypts a token with the current key and returns the ciphertext
+// and the ID of the key to store next to it
+func encryptToken(ctx context.Context, plaintext string) (string, string, error) {
+	keyring, err := tokencrypt.FromEnv()
+	if err != nil {
+		return "", "", err
+	}
+	return keyring.Encrypt(ctx, plaintext)
+}
+
+// decryptToken decrypts a token stored with the given key ID
+func decryptToken(ctx context.Context, encoded, keyID string) (string, error) {
+	keyring, err := tokencrypt.FromEnv()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(ctx, encoded, keyID)
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEncryptTokenRoundTrip(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "test-key")
+
+	encrypted, keyID, err := encryptToken(context.Background(), "secret-access-token")
+	if err != nil {
+		t.Fatalf("encryptToken returned error: %v", err)
+	}
+	if encrypted == "secret-access-token" {
+		t.Fatal("expected token to be encrypted")
+	}
+	if keyID != "default" {
+		t.Fatalf("expected the legacy key to be used, got %s", keyID)
+	}
+
+	decrypted, err := decryptToken(context.Background(), encrypted, keyID)
+	if err != nil {
+		t.Fatalf("decryptToken returned error: %v", err)
+	}
+	if decrypted != "secret-access-token" {
+		t.Fatalf("expected decrypted token to match, got %s", decrypted)
+	}
+}
+
+func TestDecryptTokenWrongKey(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "key-one")
+	encrypted, keyID, err := encryptToken(context.Background(), "secret-access-token")
+	if err != nil {
+		t.Fatalf("encryptToken returned error: %v", err)
+	}
+
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "key-two")
+	if _, err := decryptToken(context.Background(), encrypted, keyID); err == nil {
+		t.Fatal("expected decryption with a different key to fail")
+	}
+}
+
+func TestDecryptTokenAfterRotation(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "key-one")
+	encrypted, keyID, err := encryptToken(context.Background(), "secret-access-token")
+	if err != nil {
+		t.Fatalf("encryptToken returned error: %v", err)
+	}
+
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEYS", "2025a:key-two")
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY_ID", "2025a")
+	if _, newKeyID, err := encryptToken(context.Background(), "another-token"); err != nil || newKeyID != "2025a" {
+		t.Fatalf("expected new tokens to use the new key, got %q (%v)", newKeyID, err)
+	}
+	if decrypted, err := decryptToken(context.Background(), encrypted, keyID); err != nil || decrypted != "secret-access-token" {
+		t.Fatalf("expected token under the old key to stay readable, got %q (%v)", decrypted, err)
+	}
+}
@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// TwitchAPI is the part of the Twitch client analytics collection depends on.
+// *twitch.Client implements it; tests substitute a mock.
+type TwitchAPI interface {
+	// Auth
+	GetTokenInfo(ctx context.Context, token string) (*twitch.TokenValidationResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*twitch.OAuthToken, error)
+	GetUserInfo(ctx context.Context, accessToken string) (*twitch.User, error)
+
+	// Channel
+	GetChannelInfoWithToken(ctx context.Context, accessToken string) (*twitch.ChannelInfo, error)
+	GetFollowerCount(ctx context.Context, accessToken string) (int, error)
+	GetSubscriberCount(ctx context.Context, accessToken string) (int, error)
+	GetChannelFollowers(ctx context.Context, userAccessToken, broadcasterID string, limit int, afterCursor string) (*twitch.FollowersResponse, error)
+
+	// Content
+	GetUserVideosPage(ctx context.Context, userAccessToken, userID, videoType string, limit int, afterCursor string) ([]twitch.VideoInfo, string, error)
+	GetClipsPage(ctx context.Context, userAccessToken string, broadcasterID string, limit int, afterCursor string) (*twitch.ClipsResponse, error)
+	GetChannelStreamSchedulePage(ctx context.Context, userAccessToken, broadcasterID string, startTime time.Time, limit int, afterCursor string) (*twitch.StreamScheduleResponse, error)
+
+	// Revenue
+	GetBroadcasterSubscribers(ctx context.Context, userAccessToken, broadcasterID string, limit int, afterCursor string) (*twitch.SubscriptionsResponse, error)
+	GetBitsLeaderboard(ctx context.Context, userAccessToken, period string, startedAt time.Time, count int) (*twitch.BitsLeaderboardResponse, error)
+	GetAdSchedule(ctx context.Context, userAccessToken, broadcasterID string) (*twitch.AdSchedule, error)
+
+	// Goals
+	GetCreatorGoals(ctx context.Context, userAccessToken, broadcasterID string) ([]twitch.CreatorGoal, error)
+
+	// Moderation
+	GetBannedUsersPage(ctx context.Context, userAccessToken, broadcasterID string, limit int, afterCursor string) (*twitch.BannedUsersResponse, error)
+	GetAutoModSettings(ctx context.Context, userAccessToken, broadcasterID, moderatorID string) (*twitch.AutoModSettings, error)
+
+	// EventSub
+	GetAppAccessToken(ctx context.Context) (*twitch.OAuthToken, error)
+	CreateEventSubSubscription(ctx context.Context, appAccessToken, subType, version string, condition map[string]string, callbackURL, secret string) (*twitch.EventSubSubscription, error)
+}
+
+var _ TwitchAPI = (*twitch.Client)(nil)
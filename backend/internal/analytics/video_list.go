@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVideoPageSize = 25
+	maxVideoPageSize     = 100
+)
+
+// viewsPerDaySQL is viewsPerDay in SQL: views over the days since publishing,
+// counting at least one day. It is NULL for videos without a publish date.
+const viewsPerDaySQL = "view_count / GREATEST(EXTRACT(EPOCH FROM NOW() - published_at) / 86400, 1)"
+
+// videoSortColumns maps the sort keys accepted by GET /videos to the column or
+// expression they order by
+var videoSortColumns = map[string]string{
+	"date":          "published_at",
+	"views":         "view_count",
+	"duration":      "duration_seconds",
+	"views_per_day": viewsPerDaySQL,
+}
+
+// VideoListQuery selects one page of a user's stored videos. Empty filters
+// match everything; From is inclusive and To exclusive.
+type VideoListQuery struct {
+	Limit     int
+	Offset    int
+	Sort      string // "date" (default), "views", "duration" or "views_per_day"
+	Ascending bool
+	VideoType string // archive, highlight, upload or clip
+	From      *time.Time
+	To        *time.Time
+	Search    string // case-insensitive substring of the title
+}
+
+// Normalize fills in the page size and sort order and rejects unknown sort
+// keys, video types and inverted date ranges
+func (q VideoListQuery) Normalize() (VideoListQuery, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultVideoPageSize
+	} else if q.Limit > maxVideoPageSize {
+		q.Limit = maxVideoPageSize
+	}
+	if q.Offset < 0 {
+		return q, fmt.Errorf("offset must be a non-negative integer")
+	}
+
+	q.Sort = strings.ToLower(strings.TrimSpace(q.Sort))
+	if q.Sort == "" {
+		q.Sort = "date"
+	}
+	if _, ok := videoSortColumns[q.Sort]; !ok {
+		return q, fmt.Errorf("invalid sort %q (valid: date, views, duration, views_per_day)", q.Sort)
+	}
+
+	q.VideoType = strings.ToLower(strings.TrimSpace(q.VideoType))
+	if q.VideoType != "" && q.VideoType != "clip" && !isValidVideoType(q.VideoType) {
+		return q, fmt.Errorf("invalid video type %q (valid: %s, clip)", q.VideoType, strings.Join(validVideoTypes, ", "))
+	}
+
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return q, fmt.Errorf("from must be before to")
+	}
+
+	q.Search = strings.TrimSpace(q.Search)
+	return q, nil
+}
+
+// orderBy returns the ORDER BY clause for the query. Videos without a value
+// for the sort column go last either way, and id keeps pages stable.
+func (q VideoListQuery) orderBy() string {
+	column, ok := videoSortColumns[q.Sort]
+	if !ok {
+		column = videoSortColumns["date"]
+	}
+
+	direction := "DESC"
+	if q.Ascending {
+		direction = "ASC"
+	}
+
+	return fmt.Sprintf("%s %s NULLS LAST, id %s", column, direction, direction)
+}
+
+// parseVideoDate parses a from/to query value given as a date (2006-01-02) or an
+// RFC 3339 time. A bare date used as the end of a range covers that whole day.
+func parseVideoDate(value string, endOfRange bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD or an RFC 3339 time, got %q", value)
+	}
+	if endOfRange {
+		day = day.AddDate(0, 0, 1)
+	}
+	return &day, nil
+}
+
+// likePattern turns a search term into an ILIKE pattern matching it anywhere,
+// with LIKE wildcards in the term matched literally
+func likePattern(term string) string {
+	if term == "" {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + escaper.Replace(term) + "%"
+}
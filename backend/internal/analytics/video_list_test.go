@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoListQueryNormalize(t *testing.T) {
+	q, err := VideoListQuery{}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, defaultVideoPageSize, q.Limit)
+	assert.Equal(t, "date", q.Sort)
+	assert.Equal(t, "published_at DESC NULLS LAST, id DESC", q.orderBy())
+
+	q, err = VideoListQuery{Limit: 500, Sort: " Views ", Ascending: true, VideoType: "Clip", Search: "  speedrun "}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, maxVideoPageSize, q.Limit)
+	assert.Equal(t, "view_count ASC NULLS LAST, id ASC", q.orderBy())
+	assert.Equal(t, "clip", q.VideoType)
+	assert.Equal(t, "speedrun", q.Search)
+
+	q, err = VideoListQuery{Sort: "views_per_day"}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, viewsPerDaySQL+" DESC NULLS LAST, id DESC", q.orderBy())
+
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+	for name, query := range map[string]VideoListQuery{
+		"sort":     {Sort: "title"},
+		"type":     {VideoType: "vod"},
+		"offset":   {Offset: -1},
+		"inverted": {From: &from, To: &to},
+	} {
+		_, err := query.Normalize()
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseVideoDate(t *testing.T) {
+	none, err := parseVideoDate("", false)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	from, err := parseVideoDate("2025-06-01", false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), *from)
+
+	// A date as the end of a range includes that day
+	to, err := parseVideoDate("2025-06-01", true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), *to)
+
+	exact, err := parseVideoDate("2025-06-01T12:30:00Z", true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC), *exact)
+
+	_, err = parseVideoDate("last week", false)
+	assert.Error(t, err)
+}
+
+func TestLikePatternEscapesWildcards(t *testing.T) {
+	assert.Equal(t, "", likePattern(""))
+	assert.Equal(t, "%speedrun%", likePattern("speedrun"))
+	assert.Equal(t, `%100\% any\_ \\o/%`, likePattern(`100% any_ \o/`))
+}
@@ -0,0 +1,196 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/insights"
+)
+
+const (
+	// insightsTopVideos bounds how many videos are summarised for the model
+	insightsTopVideos = 5
+	// maxInsightsWeeks bounds how many weeks /insights returns
+	maxInsightsWeeks = 12
+)
+
+// InsightsGenerator asks a language model for a few insights about each
+// user's week. It runs at most once per user per ISO week.
+type InsightsGenerator struct {
+	service  Service
+	repo     Repository
+	provider insights.Provider
+}
+
+// NewInsightsGenerator returns a generator using provider. A nil provider
+// disables generation.
+func NewInsightsGenerator(service Service, repo Repository, provider insights.Provider) *InsightsGenerator {
+	return &InsightsGenerator{
+		service:  service,
+		repo:     repo,
+		provider: provider,
+	}
+}
+
+// GenerateWeekly generates this week's insights for the user unless they exist
+// already. It has the signature of a CollectionHook so it can run after the
+// daily collection.
+func (g *InsightsGenerator) GenerateWeekly(ctx context.Context, userID string) error {
+	if g.provider == nil {
+		return nil
+	}
+
+	weekStart := startOfWeek(time.Now())
+	exists, err := g.repo.HasWeeklyInsights(ctx, userID, weekStart)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// Insights are written in the user's language, English when none is saved
+	locale, err := g.repo.GetUserLocale(ctx, userID)
+	if err != nil {
+		return err
+	}
+	ctx = i18n.WithLocale(ctx, locale)
+
+	input, err := g.buildInput(ctx, userID, weekStart)
+	if err != nil {
+		return err
+	}
+	if input == nil {
+		// Nothing collected yet, so there is nothing to comment on
+		return nil
+	}
+
+	generated, err := g.provider.Generate(ctx, *input)
+	if err != nil {
+		return fmt.Errorf("failed to generate insights with %s: %w", g.provider.Name(), err)
+	}
+
+	week := &WeeklyInsights{
+		UserID:    userID,
+		WeekStart: weekStart,
+		Provider:  g.provider.Name(),
+		Model:     g.provider.Model(),
+		Insights:  generated,
+	}
+	if err := g.repo.SaveWeeklyInsights(ctx, week); err != nil {
+		return fmt.Errorf("failed to save weekly insights: %w", err)
+	}
+
+	log.Printf("💡 Generated %d weekly insights for user %s with %s", len(generated), userID, g.provider.Name())
+	return nil
+}
+
+// buildInput summarises the week's growth and content metrics, or returns nil
+// when the user has no data
+func (g *InsightsGenerator) buildInput(ctx context.Context, userID string, weekStart time.Time) (*insights.Input, error) {
+	growth, err := g.service.GetGrowthAnalysis(ctx, userID, "week")
+	if err != nil {
+		return nil, err
+	}
+	content, err := g.service.GetContentPerformance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(growth.Metrics) == 0 && len(content.TopVideos) == 0 {
+		return nil, nil
+	}
+
+	input := &insights.Input{
+		Period:       "week starting " + weekStart.Format("2006-01-02"),
+		Metrics:      []insights.Metric{},
+		TopVideos:    []insights.Video{},
+		TopGames:     []insights.Game{},
+		Observations: content.Insights,
+		Language:     i18n.Name(i18n.FromContext(ctx)),
+	}
+
+	changes, err := g.repo.GetChangeSummaries(ctx, userID, weekStart, maxChangeSummaries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change summaries: %w", err)
+	}
+	if observation := weeklyChanges(i18n.FromContext(ctx), changes); observation != "" {
+		input.Observations = append(input.Observations, observation)
+	}
+
+	names := make([]string, 0, len(growth.Metrics))
+	for name := range growth.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metric := growth.Metrics[name]
+		input.Metrics = append(input.Metrics, insights.Metric{
+			Name:          name,
+			Current:       metric.Current,
+			Previous:      metric.Previous,
+			Change:        metric.Change,
+			PercentChange: metric.PercentChange,
+		})
+	}
+
+	for i, video := range content.TopVideos {
+		if i == insightsTopVideos {
+			break
+		}
+		input.TopVideos = append(input.TopVideos, insights.Video{
+			Title:           video.Title,
+			Type:            video.VideoType,
+			Views:           video.ViewCount,
+			DurationMinutes: video.Duration / 60,
+		})
+	}
+
+	for _, game := range content.TopGames {
+		input.TopGames = append(input.TopGames, insights.Game{
+			Name:           game.GameName,
+			Streams:        game.TotalStreams,
+			Hours:          game.TotalHoursStreamed,
+			AverageViewers: game.AverageViewers,
+		})
+	}
+
+	return input, nil
+}
+
+// weeklyChanges sums up what the week's collections found, or returns "" when
+// they found nothing. Follower changes are left to the growth metrics.
+func weeklyChanges(locale string, changes []ChangeSummary) string {
+	newVideos, viewsGained := 0, 0
+	for _, change := range changes {
+		newVideos += change.NewVideoCount
+		viewsGained += change.VideoViewsGained
+	}
+	if newVideos == 0 && viewsGained == 0 {
+		return ""
+	}
+	return i18n.T(locale, "This week's collections found %d new videos and %d more views on your existing videos", newVideos, viewsGained)
+}
+
+// startOfWeek returns midnight UTC of the Monday of t's ISO week
+func startOfWeek(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// GetWeeklyInsights returns the user's most recent weeks of generated insights
+func (s *service) GetWeeklyInsights(ctx context.Context, userID string, limit int) ([]WeeklyInsights, error) {
+	if limit <= 0 || limit > maxInsightsWeeks {
+		limit = maxInsightsWeeks
+	}
+
+	weeks, err := s.repo.GetWeeklyInsights(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekly insights: %w", err)
+	}
+	return weeks, nil
+}
@@ -0,0 +1,119 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/insights"
+)
+
+type fakeProvider struct {
+	calls int
+	input insights.Input
+}
+
+func (p *fakeProvider) Name() string  { return "fake" }
+func (p *fakeProvider) Model() string { return "fake-1" }
+
+func (p *fakeProvider) Generate(ctx context.Context, in insights.Input) ([]string, error) {
+	p.calls++
+	p.input = in
+	return []string{"Followers grew 10%.", "Elden Ring drew the most viewers.", "Your best VOD ran over 3 hours."}, nil
+}
+
+func TestGenerateWeeklyInsights(t *testing.T) {
+	svc, repo, _ := newTestService()
+	provider := &fakeProvider{}
+	generator := NewInsightsGenerator(svc, repo, provider)
+	ctx := context.Background()
+	weekStart := startOfWeek(time.Now())
+
+	repo.On("HasWeeklyInsights", ctx, "user_1", weekStart).Return(false, nil)
+	repo.On("GetUserLocale", ctx, "user_1").Return("", nil)
+	repo.On("GetChannelAnalytics", ctx, "user_1", 7).Return([]ChannelAnalytics{
+		{FollowersCount: 110, TotalViews: 5000},
+		{FollowersCount: 100, TotalViews: 4000},
+	}, nil)
+	repo.On("GetVideoAnalytics", ctx, "user_1", 10).Return([]VideoAnalytics{
+		{Title: "Elden Ring blind run", VideoType: "archive", ViewCount: 900, Duration: 3 * 3600},
+	}, nil)
+	repo.On("GetTopGames", ctx, "user_1", 5).Return([]GameAnalytics{
+		{GameName: "Elden Ring", TotalStreams: 3, TotalHoursStreamed: 9, AverageViewers: 42},
+	}, nil)
+	repo.On("GetChangeSummaries", ctx, "user_1", weekStart, maxChangeSummaries).Return([]ChangeSummary{
+		{NewVideoCount: 2, VideoViewsGained: 300},
+		{NewVideoCount: 1, VideoViewsGained: 50},
+	}, nil)
+	repo.On("SaveWeeklyInsights", ctx, mock.MatchedBy(func(w *WeeklyInsights) bool {
+		return w.UserID == "user_1" && w.WeekStart.Equal(weekStart) && w.Provider == "fake" && len(w.Insights) == 3
+	})).Return(nil)
+
+	require.NoError(t, generator.GenerateWeekly(ctx, "user_1"))
+	repo.AssertExpectations(t)
+
+	require.Equal(t, 1, provider.calls)
+	require.Len(t, provider.input.Metrics, 2)
+	assert.Equal(t, "followers", provider.input.Metrics[0].Name)
+	assert.Equal(t, 10, provider.input.Metrics[0].Change)
+	assert.Equal(t, 180, provider.input.TopVideos[0].DurationMinutes)
+	assert.Equal(t, "Elden Ring", provider.input.TopGames[0].Name)
+	assert.Equal(t, "English", provider.input.Language)
+	assert.Contains(t, provider.input.Observations, "This week's collections found 3 new videos and 350 more views on your existing videos")
+}
+
+func TestGenerateWeeklyInsightsInUserLocale(t *testing.T) {
+	svc, repo, _ := newTestService()
+	provider := &fakeProvider{}
+	ctx := context.Background()
+
+	repo.On("HasWeeklyInsights", ctx, "user_1", startOfWeek(time.Now())).Return(false, nil)
+	repo.On("GetUserLocale", ctx, "user_1").Return("de", nil)
+	repo.On("GetChannelAnalytics", mock.Anything, "user_1", 7).Return([]ChannelAnalytics{
+		{FollowersCount: 110}, {FollowersCount: 100},
+	}, nil)
+	repo.On("GetVideoAnalytics", mock.Anything, "user_1", 10).Return([]VideoAnalytics{{ViewCount: 40}}, nil)
+	repo.On("GetTopGames", mock.Anything, "user_1", 5).Return([]GameAnalytics{}, nil)
+	repo.On("GetChangeSummaries", mock.Anything, "user_1", mock.Anything, maxChangeSummaries).Return([]ChangeSummary{}, nil)
+	repo.On("SaveWeeklyInsights", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, NewInsightsGenerator(svc, repo, provider).GenerateWeekly(ctx, "user_1"))
+
+	assert.Equal(t, "German", provider.input.Language)
+	assert.Equal(t, []string{"Deine Videos haben durchschnittlich 40 Aufrufe"}, provider.input.Observations)
+}
+
+func TestGenerateWeeklyInsightsSkips(t *testing.T) {
+	ctx := context.Background()
+
+	// Disabled
+	svc, repo, _ := newTestService()
+	require.NoError(t, NewInsightsGenerator(svc, repo, nil).GenerateWeekly(ctx, "user_1"))
+
+	// Already generated this week
+	provider := &fakeProvider{}
+	repo.On("HasWeeklyInsights", ctx, "user_1", startOfWeek(time.Now())).Return(true, nil)
+	require.NoError(t, NewInsightsGenerator(svc, repo, provider).GenerateWeekly(ctx, "user_1"))
+
+	// No data collected yet
+	svc, repo, _ = newTestService()
+	repo.On("HasWeeklyInsights", ctx, "user_2", startOfWeek(time.Now())).Return(false, nil)
+	repo.On("GetUserLocale", ctx, "user_2").Return("", nil)
+	repo.On("GetChannelAnalytics", ctx, "user_2", 7).Return([]ChannelAnalytics{}, nil)
+	repo.On("GetVideoAnalytics", ctx, "user_2", 10).Return([]VideoAnalytics{}, nil)
+	repo.On("GetTopGames", ctx, "user_2", 5).Return([]GameAnalytics{}, nil)
+	require.NoError(t, NewInsightsGenerator(svc, repo, provider).GenerateWeekly(ctx, "user_2"))
+
+	assert.Equal(t, 0, provider.calls)
+}
+
+func TestStartOfWeek(t *testing.T) {
+	sunday := time.Date(2025, 6, 15, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), startOfWeek(sunday))
+	monday := time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, startOfWeek(monday))
+}
@@ -0,0 +1,73 @@
+// Package apikeys implements personal API keys that give third-party integrations
+// (OBS overlays, spreadsheets) read-only access to a creator's analytics.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// KeyPrefix marks CreatorSync API keys so they can be told apart from Clerk JWTs
+	KeyPrefix = "cs_"
+	// ScopeAnalyticsRead allows GET requests to /api/analytics
+	ScopeAnalyticsRead = "analytics:read"
+
+	// displayPrefixLength is how much of the key is kept in clear text for display
+	displayPrefixLength = 10
+	// defaultRateLimitPerMinute applies to new keys
+	defaultRateLimitPerMinute = 60
+	// maxKeysPerUser bounds how many active keys a user can hold
+	maxKeysPerUser = 10
+)
+
+// APIKey is a stored API key. The key itself is only returned once, at creation.
+type APIKey struct {
+	ID                 int        `json:"id" db:"id"`
+	UserID             string     `json:"-" db:"user_id"`
+	Name               string     `json:"name" db:"name"`
+	KeyPrefix          string     `json:"key_prefix" db:"key_prefix"`
+	KeyHash            string     `json:"-" db:"key_hash"`
+	Scopes             string     `json:"scopes" db:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute" db:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// HasScope reports whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range strings.Fields(k.Scopes) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateKey returns a new random key along with its display prefix and hash
+func GenerateKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:displayPrefixLength], HashKey(key), nil
+}
+
+// HashKey returns the hex sha256 of key, which is what gets stored and looked up
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether token looks like a CreatorSync API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, KeyPrefix)
+}
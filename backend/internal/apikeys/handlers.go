@@ -0,0 +1,162 @@
+package apikeys
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	repo  Repository
+	audit *audit.Logger
+}
+
+func NewHandlers(repo Repository) *Handlers {
+	return &Handlers{repo: repo}
+}
+
+// UseAuditLog records key creation and revocation. Call it before RegisterRoutes.
+func (h *Handlers) UseAuditLog(logger *audit.Logger) {
+	h.audit = logger
+}
+
+// RegisterRoutes registers key management routes on a Clerk-protected router.
+// Keys cannot be used to manage keys.
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	keys := router.Group("/keys")
+	keys.Get("/", h.ListKeys)
+	keys.Post("/", h.CreateKey)
+	keys.Delete("/:id", h.audit.Middleware(audit.ActionAPIKeyRevoke), h.RevokeKey)
+}
+
+// ListKeys returns the user's active API keys (without the secret part)
+func (h *Handlers) ListKeys(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	keys, err := h.repo.ListKeys(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing API keys for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list API keys",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"keys": keys,
+	})
+}
+
+// CreateKey issues a new read-only analytics key. The key is only shown in this response.
+func (h *Handlers) CreateKey(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required and must be at most 100 characters",
+		})
+	}
+
+	count, err := h.repo.CountActiveKeys(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error counting API keys for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create API key",
+		})
+	}
+	if count >= maxKeysPerUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "API key limit reached, revoke an existing key first",
+		})
+	}
+
+	plaintext, prefix, hash, err := GenerateKey()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create API key",
+		})
+	}
+
+	key := &APIKey{
+		UserID:             user.ID,
+		Name:               req.Name,
+		KeyPrefix:          prefix,
+		KeyHash:            hash,
+		Scopes:             ScopeAnalyticsRead,
+		RateLimitPerMinute: defaultRateLimitPerMinute,
+	}
+	if err := h.repo.CreateKey(c.UserContext(), key); err != nil {
+		log.Printf("Error creating API key for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create API key",
+		})
+	}
+
+	log.Printf("🔑 Created API key %d (%s) for user %s", key.ID, key.KeyPrefix, user.ID)
+	h.audit.RecordRequest(c, user.ID, audit.ActionAPIKeyCreate, strconv.Itoa(key.ID), map[string]any{
+		"key_prefix": key.KeyPrefix,
+		"scopes":     key.Scopes,
+	})
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"key":     plaintext,
+		"api_key": key,
+	})
+}
+
+// RevokeKey revokes one of the user's keys
+func (h *Handlers) RevokeKey(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	keyID, err := c.ParamsInt("id")
+	if err != nil || keyID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid key ID",
+		})
+	}
+
+	revoked, err := h.repo.RevokeKey(c.UserContext(), user.ID, keyID)
+	if err != nil {
+		log.Printf("Error revoking API key %d for user %s: %v", keyID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke API key",
+		})
+	}
+	if !revoked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "API key not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "API key revoked",
+		"id":      keyID,
+	})
+}
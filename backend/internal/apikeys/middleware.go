@@ -0,0 +1,111 @@
+package apikeys
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/time/rate"
+)
+
+// Middleware authenticates requests carrying an API key (X-API-Key header or
+// "Authorization: Bearer cs_...") and hands everything else to fallback, usually
+// clerk.AuthMiddleware(). Key-authenticated requests are read-only and rate limited
+// per key; the key owner is stored in the same "user" local Clerk uses, so
+// handlers don't need to know how the request was authenticated.
+func Middleware(repo Repository, fallback fiber.Handler) fiber.Handler {
+	limiters := newKeyLimiters()
+
+	return func(c *fiber.Ctx) error {
+		key := extractAPIKey(c)
+		if key == "" {
+			return fallback(c)
+		}
+
+		apiKey, err := repo.GetActiveKeyByHash(c.UserContext(), HashKey(key))
+		if err != nil {
+			log.Printf("Failed to look up API key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to verify API key",
+			})
+		}
+		if apiKey == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or revoked API key",
+			})
+		}
+
+		if c.Method() != fiber.MethodGet || !apiKey.HasScope(ScopeAnalyticsRead) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "API keys only have read access to analytics",
+			})
+		}
+
+		limiter := limiters.get(apiKey.ID, apiKey.RateLimitPerMinute)
+		c.Set("X-RateLimit-Limit", strconv.Itoa(apiKey.RateLimitPerMinute))
+		if !limiter.Allow() {
+			c.Set("Retry-After", "60")
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "API key rate limit exceeded",
+			})
+		}
+
+		if err := repo.TouchKey(c.UserContext(), apiKey.ID); err != nil {
+			log.Printf("Failed to update last use of API key %d: %v", apiKey.ID, err)
+		}
+
+		c.Locals("user", clerk.User{ID: apiKey.UserID})
+		c.Locals("api_key_id", apiKey.ID)
+		return c.Next()
+	}
+}
+
+func extractAPIKey(c *fiber.Ctx) string {
+	if key := strings.TrimSpace(c.Get("X-API-Key")); key != "" {
+		return key
+	}
+
+	parts := strings.SplitN(c.Get("Authorization"), " ", 2)
+	if len(parts) == 2 && parts[0] == "Bearer" && IsAPIKey(parts[1]) {
+		return parts[1]
+	}
+	return ""
+}
+
+// keyLimiters holds a token bucket per API key
+type keyLimiters struct {
+	mu       sync.Mutex
+	limiters map[int]*keyLimiter
+}
+
+type keyLimiter struct {
+	perMinute int
+	limiter   *rate.Limiter
+}
+
+func newKeyLimiters() *keyLimiters {
+	return &keyLimiters{limiters: make(map[int]*keyLimiter)}
+}
+
+func (l *keyLimiters) get(keyID, perMinute int) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if perMinute <= 0 {
+		perMinute = defaultRateLimitPerMinute
+	}
+
+	existing, ok := l.limiters[keyID]
+	if !ok || existing.perMinute != perMinute {
+		existing = &keyLimiter{
+			perMinute: perMinute,
+			limiter:   rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute),
+		}
+		l.limiters[keyID] = existing
+	}
+	return existing.limiter
+}
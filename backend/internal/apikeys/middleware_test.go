@@ -0,0 +1,66 @@
+package apikeys
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	Repository
+	keys map[string]*APIKey
+}
+
+func (f *fakeRepository) GetActiveKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	return f.keys[hash], nil
+}
+
+func (f *fakeRepository) TouchKey(ctx context.Context, keyID int) error {
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	key, prefix, hash, err := GenerateKey()
+	require.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.Equal(t, key[:displayPrefixLength], prefix)
+
+	repo := &fakeRepository{keys: map[string]*APIKey{
+		hash: {ID: 1, UserID: "user_1", Scopes: ScopeAnalyticsRead, RateLimitPerMinute: 2},
+	}}
+	fallback := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusTeapot)
+	}
+
+	app := fiber.New()
+	app.Use(Middleware(repo, fallback))
+	app.All("/stats", func(c *fiber.Ctx) error {
+		user, err := clerk.GetUserFromContext(c)
+		if err != nil {
+			return err
+		}
+		return c.SendString(user.ID)
+	})
+
+	do := func(method, header, value string) int {
+		req := httptest.NewRequest(method, "/stats", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusTeapot, do("GET", "Authorization", "Bearer some.clerk.jwt"))
+	assert.Equal(t, fiber.StatusUnauthorized, do("GET", "X-API-Key", "cs_unknown"))
+	assert.Equal(t, fiber.StatusForbidden, do("POST", "X-API-Key", key))
+	assert.Equal(t, fiber.StatusOK, do("GET", "X-API-Key", key))
+	assert.Equal(t, fiber.StatusOK, do("GET", "Authorization", "Bearer "+key))
+	assert.Equal(t, fiber.StatusTooManyRequests, do("GET", "X-API-Key", key))
+}
@@ -0,0 +1,96 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	CreateKey(ctx context.Context, key *APIKey) error
+	ListKeys(ctx context.Context, userID string) ([]APIKey, error)
+	CountActiveKeys(ctx context.Context, userID string) (int, error)
+	RevokeKey(ctx context.Context, userID string, keyID int) (bool, error)
+	GetActiveKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	TouchKey(ctx context.Context, keyID int) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+func (r *repository) CreateKey(ctx context.Context, key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, rate_limit_per_minute)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowxContext(ctx, query,
+		key.UserID, key.Name, key.KeyPrefix, key.KeyHash, key.Scopes, key.RateLimitPerMinute,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (r *repository) ListKeys(ctx context.Context, userID string) ([]APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_prefix, key_hash, scopes, rate_limit_per_minute,
+			   last_used_at, revoked_at, created_at
+		FROM api_keys 
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	keys := []APIKey{}
+	err := r.db.SelectContext(ctx, &keys, query, userID)
+	return keys, err
+}
+
+func (r *repository) CountActiveKeys(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return count, err
+}
+
+func (r *repository) RevokeKey(ctx context.Context, userID string, keyID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		keyID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *repository) GetActiveKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_prefix, key_hash, scopes, rate_limit_per_minute,
+			   last_used_at, revoked_at, created_at
+		FROM api_keys 
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	var key APIKey
+	err := r.db.GetContext(ctx, &key, query, hash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &key, err
+}
+
+// TouchKey records key usage, at most once a minute to keep writes down
+func (r *repository) TouchKey(ctx context.Context, keyID int) error {
+	query := `
+		UPDATE api_keys 
+		SET last_used_at = NOW() 
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	_, err := r.db.ExecContext(ctx, query, keyID)
+	return err
+}
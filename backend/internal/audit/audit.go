@@ -0,0 +1,132 @@
+// Package audit records sensitive actions (token changes, exports, admin
+// triggers) with the actor, client IP and time, for later security review.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionTwitchTokenStore      = "twitch_token.store"
+	ActionTwitchTokenDelete     = "twitch_token.delete"
+	ActionTwitchAccountSwitch   = "twitch_account.switch"
+	ActionAnalyticsRestore      = "analytics.restore"
+	ActionPatreonTokenStore     = "patreon_token.store"
+	ActionPatreonTokenDelete    = "patreon_token.delete"
+	ActionStreamlabsTokenStore  = "streamlabs_token.store"
+	ActionStreamlabsTokenDelete = "streamlabs_token.delete"
+	ActionYouTubeTokenStore     = "youtube_token.store"
+	ActionYouTubeTokenDelete    = "youtube_token.delete"
+	ActionAPIKeyCreate          = "api_key.create"
+	ActionAPIKeyRevoke          = "api_key.revoke"
+	ActionOverlayTokenCreate    = "overlay_token.create"
+	ActionOverlayTokenRevoke    = "overlay_token.revoke"
+	ActionExportDownload        = "export.download"
+	ActionAdminTrigger          = "admin.trigger"
+	ActionAuditLogQuery         = "audit_log.query"
+	ActionMaintenanceUpdate     = "maintenance.update"
+)
+
+// SystemActor is the actor of actions not taken by a user
+const SystemActor = "system"
+
+// Entry is one audit log record
+type Entry struct {
+	ID        int64           `json:"id" db:"id"`
+	ActorID   string          `json:"actor_id" db:"actor_id"`
+	Action    string          `json:"action" db:"action"`
+	TargetID  string          `json:"target_id" db:"target_id"`
+	IP        string          `json:"ip" db:"ip"`
+	UserAgent string          `json:"user_agent" db:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata" db:"metadata"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Logger writes audit entries. A nil Logger records nothing, so it can be
+// left out of handlers in tests.
+type Logger struct {
+	repo Repository
+}
+
+func NewLogger(repo Repository) *Logger {
+	return &Logger{repo: repo}
+}
+
+// Record stores an entry. Failures are logged rather than returned: the action
+// has already happened and shouldn't be reported as failed.
+func (l *Logger) Record(ctx context.Context, entry *Entry, metadata map[string]any) {
+	if l == nil {
+		return
+	}
+
+	entry.Metadata = json.RawMessage("{}")
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			log.Printf("Failed to encode audit metadata for %s: %v", entry.Action, err)
+		} else {
+			entry.Metadata = encoded
+		}
+	}
+
+	if err := l.repo.Insert(ctx, entry); err != nil {
+		log.Printf("⚠️ Failed to write audit log entry %s by %s: %v", entry.Action, entry.ActorID, err)
+	}
+}
+
+// RecordRequest records an action taken through an HTTP request. actorID may be
+// empty to use the authenticated user.
+func (l *Logger) RecordRequest(c *fiber.Ctx, actorID, action, targetID string, metadata map[string]any) {
+	if l == nil {
+		return
+	}
+
+	if actorID == "" {
+		if user, err := clerk.GetUserFromContext(c); err == nil {
+			actorID = user.ID
+		}
+	}
+
+	l.Record(c.UserContext(), &Entry{
+		ActorID:   actorID,
+		Action:    action,
+		TargetID:  targetID,
+		IP:        ClientIP(c),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}, metadata)
+}
+
+// Middleware records action after the wrapped route succeeds, using the :id
+// route parameter as the target. Requests that fail are not recorded.
+func (l *Logger) Middleware(action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if status := c.Response().StatusCode(); status < 400 {
+			l.RecordRequest(c, "", action, c.Params("id"), nil)
+		}
+		return nil
+	}
+}
+
+// ClientIP returns the first X-Forwarded-For address set by our proxy, falling
+// back to the connection address
+func ClientIP(c *fiber.Ctx) string {
+	if forwarded := c.Get(fiber.HeaderXForwardedFor); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := strings.TrimSpace(first); ip != "" {
+			return ip
+		}
+	}
+	return c.IP()
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	Repository
+	entries []Entry
+}
+
+func (f *fakeRepository) Insert(ctx context.Context, entry *Entry) error {
+	f.entries = append(f.entries, *entry)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	repo := &fakeRepository{}
+	logger := NewLogger(repo)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", clerk.User{ID: "user_1"})
+		return c.Next()
+	})
+	app.Get("/kits/:id", logger.Middleware(ActionExportDownload), func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return c.SendString("pdf")
+	})
+
+	req := httptest.NewRequest("GET", "/kits/42", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	req.Header.Set("User-Agent", "test-agent")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	_, err = app.Test(httptest.NewRequest("GET", "/kits/missing", nil))
+	require.NoError(t, err)
+
+	require.Len(t, repo.entries, 1)
+	entry := repo.entries[0]
+	assert.Equal(t, "user_1", entry.ActorID)
+	assert.Equal(t, ActionExportDownload, entry.Action)
+	assert.Equal(t, "42", entry.TargetID)
+	assert.Equal(t, "203.0.113.7", entry.IP)
+	assert.Equal(t, "test-agent", entry.UserAgent)
+	assert.JSONEq(t, "{}", string(entry.Metadata))
+}
+
+func TestNilLogger(t *testing.T) {
+	var logger *Logger
+	logger.Record(context.Background(), &Entry{Action: ActionAdminTrigger}, map[string]any{"job": "x"})
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	repo   Repository
+	logger *Logger
+}
+
+func NewHandlers(repo Repository, logger *Logger) *Handlers {
+	return &Handlers{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the audit log query on an admin-only router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/audit-log", h.logger.Middleware(ActionAuditLogQuery), h.QueryLog)
+}
+
+// QueryLog returns audit entries newest first. Filters: ?actor=, ?action=,
+// ?since= and ?until= (RFC 3339), ?before= (an entry ID, for paging) and ?limit=.
+func (h *Handlers) QueryLog(c *fiber.Ctx) error {
+	filter := Filter{
+		ActorID:  c.Query("actor"),
+		Action:   c.Query("action"),
+		BeforeID: int64(c.QueryInt("before", 0)),
+		Limit:    c.QueryInt("limit", 100),
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": param + " must be an RFC 3339 timestamp",
+			})
+		}
+		*dst = parsed
+	}
+
+	entries, err := h.repo.Query(c.UserContext(), filter)
+	if err != nil {
+		log.Printf("Error querying audit log: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to query audit log",
+		})
+	}
+
+	response := fiber.Map{
+		"entries": entries,
+	}
+	if len(entries) == filter.Limit {
+		response["next_before"] = entries[len(entries)-1].ID
+	}
+	return c.JSON(response)
+}
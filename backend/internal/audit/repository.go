@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Filter narrows an audit log query. Zero fields are ignored.
+type Filter struct {
+	ActorID string
+	Action  string
+	Since   time.Time
+	Until   time.Time
+	// BeforeID pages backwards from a previous page's last ID
+	BeforeID int64
+	Limit    int
+}
+
+type Repository interface {
+	Insert(ctx context.Context, entry *Entry) error
+	Query(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+func (r *repository) Insert(ctx context.Context, entry *Entry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_id, ip, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowxContext(ctx, query, entry.ActorID, entry.Action, entry.TargetID,
+		entry.IP, entry.UserAgent, string(entry.Metadata)).
+		Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// Query returns matching entries, newest first
+func (r *repository) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < $%d", filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		add("id < $%d", filter.BeforeID)
+	}
+
+	query := `
+		SELECT id, actor_id, action, target_id, ip, user_agent, metadata, created_at
+		FROM audit_log
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	entries := []Entry{}
+	err := r.db.SelectContext(ctx, &entries, query, args...)
+	return entries, err
+}
@@ -0,0 +1,270 @@
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/stripe"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+var (
+	freePlan = Plan{ID: PlanFree, Name: "Free", MaxPlatforms: intPtr(1), HistoryDays: intPtr(30)}
+	proPlan  = Plan{ID: PlanPro, Name: "Pro", Exports: true}
+)
+
+type fakeRepository struct {
+	Repository
+	subscriptions map[string]*Subscription
+	trials        map[string]*Trial
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{subscriptions: map[string]*Subscription{}, trials: map[string]*Trial{}}
+}
+
+func (f *fakeRepository) GetPlan(_ context.Context, id string) (*Plan, error) {
+	switch id {
+	case PlanFree:
+		return &freePlan, nil
+	case PlanPro:
+		return &proPlan, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) GetSubscription(_ context.Context, userID string) (*Subscription, error) {
+	return f.subscriptions[userID], nil
+}
+
+func (f *fakeRepository) GetSubscriptionByStripeID(_ context.Context, id string) (*Subscription, error) {
+	for _, sub := range f.subscriptions {
+		if sub.StripeSubscriptionID != nil && *sub.StripeSubscriptionID == id {
+			return sub, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) SaveSubscription(_ context.Context, sub *Subscription) (bool, error) {
+	if existing := f.subscriptions[sub.UserID]; existing != nil && existing.LastEventAt.After(sub.LastEventAt) {
+		return false, nil
+	}
+	f.subscriptions[sub.UserID] = sub
+	return true, nil
+}
+
+func (f *fakeRepository) GetTrial(_ context.Context, userID string) (*Trial, error) {
+	return f.trials[userID], nil
+}
+
+func (f *fakeRepository) CreateTrial(_ context.Context, trial *Trial) (bool, error) {
+	if f.trials[trial.UserID] != nil {
+		return false, nil
+	}
+	f.trials[trial.UserID] = trial
+	return true, nil
+}
+
+func TestPlanAllows(t *testing.T) {
+	assert.False(t, freePlan.Allows(FeatureExports))
+	assert.False(t, freePlan.Allows(FeatureMultiPlatform))
+	assert.True(t, proPlan.Allows(FeatureExports))
+	assert.True(t, proPlan.Allows(FeatureMultiPlatform))
+}
+
+func TestEntitlementFlags(t *testing.T) {
+	cfg := config.EntitlementsConfig{
+		DisabledFeatures: []string{FeatureMultiPlatform},
+		Grants:           map[string][]string{"partner": {FeatureExports}},
+	}
+	entitlements := NewEntitlements(newFakeRepository(), cfg)
+
+	partner, err := entitlements.Get(context.Background(), "partner")
+	require.NoError(t, err)
+	assert.Equal(t, PlanFree, partner.PlanID())
+	assert.True(t, partner.Can(FeatureExports))
+	assert.False(t, partner.Can(FeatureMultiPlatform))
+
+	// Without billing only the flags apply
+	entitlements = NewEntitlements(nil, cfg)
+	user, err := entitlements.Get(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.True(t, user.Can(FeatureExports))
+	assert.False(t, user.Can(FeatureMultiPlatform))
+	days, truncated := user.ClampDays(0)
+	assert.Equal(t, 0, days)
+	assert.False(t, truncated)
+}
+
+func TestStartTrial(t *testing.T) {
+	repo := newFakeRepository()
+	entitlements := NewEntitlements(repo, config.EntitlementsConfig{TrialDays: 14})
+
+	before, err := entitlements.Get(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.False(t, before.Can(FeatureExports))
+
+	trial, err := entitlements.StartTrial(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 14), trial.EndsAt, time.Minute)
+
+	during, err := entitlements.Get(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, PlanPro, during.PlanID())
+	assert.NotNil(t, during.Trial)
+
+	_, err = entitlements.StartTrial(context.Background(), "user_1")
+	assert.ErrorIs(t, err, ErrTrialUsed)
+
+	// Ended trials fall back to free
+	repo.trials["user_2"] = &Trial{UserID: "user_2", PlanID: PlanPro, EndsAt: time.Now().Add(-time.Hour)}
+	after, err := entitlements.Get(context.Background(), "user_2")
+	require.NoError(t, err)
+	assert.Equal(t, PlanFree, after.PlanID())
+	assert.Nil(t, after.Trial)
+
+	_, err = NewEntitlements(repo, config.EntitlementsConfig{}).StartTrial(context.Background(), "user_3")
+	assert.ErrorIs(t, err, ErrTrialsDisabled)
+}
+
+func limitedApp(limits *Limits) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", clerk.User{ID: c.Get("X-User")})
+		return c.Next()
+	})
+	app.Get("/export", limits.Require(FeatureExports), func(c *fiber.Ctx) error {
+		return c.SendString("export")
+	})
+	app.Get("/history", func(c *fiber.Ctx) error {
+		days := limits.TruncateDays(c, c.QueryInt("days", 90))
+		months := limits.TruncateMonths(c, c.QueryInt("months", 12))
+		return c.SendString(strconv.Itoa(days) + "," + strconv.Itoa(months))
+	})
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, userID, path string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-User", userID)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func status(t *testing.T, app *fiber.App, userID, path string) int {
+	t.Helper()
+	return get(t, app, userID, path).StatusCode
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestLimits(t *testing.T) {
+	repo := newFakeRepository()
+	repo.subscriptions["pro_user"] = &Subscription{UserID: "pro_user", PlanID: PlanPro, Status: "active"}
+	repo.subscriptions["lapsed_user"] = &Subscription{UserID: "lapsed_user", PlanID: PlanPro, Status: "canceled"}
+	app := limitedApp(NewLimits(NewEntitlements(repo, config.EntitlementsConfig{})))
+
+	assert.Equal(t, fiber.StatusOK, status(t, app, "pro_user", "/export"))
+	assert.Equal(t, fiber.StatusPaymentRequired, status(t, app, "free_user", "/export"))
+	assert.Equal(t, fiber.StatusPaymentRequired, status(t, app, "lapsed_user", "/export"))
+
+	// Longer ranges are cut to the plan's history rather than rejected
+	resp := get(t, app, "free_user", "/history")
+	assert.Equal(t, "30,1", body(t, resp))
+	assert.Equal(t, "30", resp.Header.Get(HistoryTruncatedHeader))
+	resp = get(t, app, "free_user", "/history?days=7&months=1")
+	assert.Equal(t, "7,1", body(t, resp))
+	assert.Empty(t, resp.Header.Get(HistoryTruncatedHeader))
+	assert.Equal(t, "90,12", body(t, get(t, app, "pro_user", "/history")))
+
+	// A nil *Limits allows everything
+	app = limitedApp(nil)
+	assert.Equal(t, fiber.StatusOK, status(t, app, "free_user", "/export"))
+	assert.Equal(t, "365,12", body(t, get(t, app, "free_user", "/history?days=365")))
+}
+
+func signedRequest(t *testing.T, secret, body string) *http.Request {
+	t.Helper()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+
+	req, err := http.NewRequest(http.MethodPost, "/api/webhooks/stripe", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(stripe.SignatureHeader, "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestWebhookUpdatesPlan(t *testing.T) {
+	repo := newFakeRepository()
+	entitlements := NewEntitlements(repo, config.EntitlementsConfig{})
+	cfg := config.BillingConfig{StripeSecretKey: "sk_test", StripeWebhookSecret: "whsec", ProPriceID: "price_pro"}
+	handlers := NewHandlers(repo, entitlements, stripe.NewClient(cfg.StripeSecretKey), cfg)
+
+	app := fiber.New()
+	app.Post("/api/webhooks/stripe", handlers.WebhookHandler)
+	send := func(req *http.Request) int {
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	entitlement, err := entitlements.Get(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, PlanFree, entitlement.PlanID())
+
+	assert.Equal(t, fiber.StatusForbidden, send(signedRequest(t, "other", `{"type": "checkout.session.completed"}`)))
+
+	assert.Equal(t, fiber.StatusNoContent, send(signedRequest(t, "whsec", `{
+		"id": "evt_1", "type": "customer.subscription.created", "created": 1760000000,
+		"data": {"object": {"id": "sub_1", "customer": "cus_1", "status": "active",
+			"current_period_end": 1762600000, "metadata": {"user_id": "user_1"},
+			"items": {"data": [{"price": {"id": "price_pro"}}]}}}
+	}`)))
+	entitlement, err = entitlements.Get(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, PlanPro, entitlement.PlanID(), "the webhook evicts the cached entitlement")
+
+	// Deletions from the portal only carry the subscription
+	assert.Equal(t, fiber.StatusNoContent, send(signedRequest(t, "whsec", `{
+		"id": "evt_2", "type": "customer.subscription.deleted", "created": 1760000100,
+		"data": {"object": {"id": "sub_1", "customer": "cus_1", "status": "canceled",
+			"items": {"data": [{"price": {"id": "price_pro"}}]}}}
+	}`)))
+	assert.Equal(t, "canceled", repo.subscriptions["user_1"].Status)
+
+	// Events delivered out of order don't undo newer ones
+	assert.Equal(t, fiber.StatusNoContent, send(signedRequest(t, "whsec", `{
+		"id": "evt_0", "type": "customer.subscription.updated", "created": 1760000050,
+		"data": {"object": {"id": "sub_1", "customer": "cus_1", "status": "active",
+			"items": {"data": [{"price": {"id": "price_pro"}}]}}}
+	}`)))
+	entitlement, err = entitlements.Get(context.Background(), "user_1")
+	require.NoError(t, err)
+	assert.Equal(t, PlanFree, entitlement.PlanID())
+}
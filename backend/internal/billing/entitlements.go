@@ -0,0 +1,217 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// entitlementCacheTTL bounds how long a plan change takes to apply on
+	// other instances, the webhook evicts it on the instance receiving it
+	entitlementCacheTTL        = time.Minute
+	entitlementCacheMaxEntries = 10000
+
+	// entitlementLocal caches the entitlement for the rest of a request
+	entitlementLocal = "entitlement"
+)
+
+var (
+	ErrTrialsDisabled = errors.New("trials are disabled")
+	ErrTrialUsed      = errors.New("trial already used")
+	ErrSubscribed     = errors.New("already subscribed")
+)
+
+// Entitlement is what a user may use: their plan, adjusted by feature flags
+type Entitlement struct {
+	UserID string
+	// Plan is nil without billing, when every feature is included
+	Plan *Plan
+	// Trial is the running trial Plan comes from, if any
+	Trial *Trial
+
+	disabled []string
+	granted  []string
+}
+
+// Can reports whether the user may use a feature
+func (e *Entitlement) Can(feature string) bool {
+	if slices.Contains(e.disabled, feature) {
+		return false
+	}
+	if e.Plan == nil || slices.Contains(e.granted, feature) {
+		return true
+	}
+	return e.Plan.Allows(feature)
+}
+
+// PlanID is the ID of the user's plan, empty without billing
+func (e *Entitlement) PlanID() string {
+	if e.Plan == nil {
+		return ""
+	}
+	return e.Plan.ID
+}
+
+// HistoryDays is how far back the user's analytics reach, 0 for all time
+func (e *Entitlement) HistoryDays() int {
+	if e.Plan == nil || e.Plan.HistoryDays == nil {
+		return 0
+	}
+	return *e.Plan.HistoryDays
+}
+
+// ClampDays limits a range of days, 0 being all time, to the user's history
+// and reports whether it did
+func (e *Entitlement) ClampDays(days int) (int, bool) {
+	limit := e.HistoryDays()
+	if limit == 0 || (days > 0 && days <= limit) {
+		return days, false
+	}
+	return limit, true
+}
+
+func (e *Entitlement) MarshalJSON() ([]byte, error) {
+	features := make(map[string]bool, len(allFeatures))
+	for _, feature := range allFeatures {
+		features[feature] = e.Can(feature)
+	}
+	var historyDays *int
+	if e.Plan != nil {
+		historyDays = e.Plan.HistoryDays
+	}
+
+	return json.Marshal(struct {
+		Plan        string          `json:"plan,omitempty"`
+		Trial       *Trial          `json:"trial"`
+		Features    map[string]bool `json:"features"`
+		HistoryDays *int            `json:"history_days"`
+	}{e.PlanID(), e.Trial, features, historyDays})
+}
+
+// Entitlements answers what users may use from their subscription, trial and
+// the feature flags
+type Entitlements struct {
+	// repo is nil without billing, leaving only the feature flags
+	repo  Repository
+	cfg   config.EntitlementsConfig
+	cache *cache.Memory[*Entitlement]
+}
+
+func NewEntitlements(repo Repository, cfg config.EntitlementsConfig) *Entitlements {
+	return &Entitlements{
+		repo:  repo,
+		cfg:   cfg,
+		cache: cache.NewMemory[*Entitlement](entitlementCacheTTL, entitlementCacheMaxEntries),
+	}
+}
+
+// Get returns the user's entitlement. The plan is their subscription's while
+// it is active, then their trial's while it runs, free otherwise.
+func (e *Entitlements) Get(ctx context.Context, userID string) (*Entitlement, error) {
+	if entitlement, ok := e.cache.GetContext(ctx, userID); ok {
+		return entitlement, nil
+	}
+
+	entitlement := &Entitlement{
+		UserID:   userID,
+		disabled: e.cfg.DisabledFeatures,
+		granted:  e.cfg.Grants[userID],
+	}
+	if e.repo != nil {
+		planID := PlanFree
+		sub, err := e.repo.GetSubscription(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if sub != nil && sub.Active() {
+			planID = sub.PlanID
+		} else {
+			trial, err := e.repo.GetTrial(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if trial != nil && trial.Active(time.Now()) {
+				planID = trial.PlanID
+				entitlement.Trial = trial
+			}
+		}
+
+		plan, err := e.repo.GetPlan(ctx, planID)
+		if err != nil {
+			return nil, err
+		}
+		if plan == nil {
+			return nil, fmt.Errorf("plan %q not found", planID)
+		}
+		entitlement.Plan = plan
+	}
+
+	e.cache.Set(userID, entitlement)
+	return entitlement, nil
+}
+
+// ForRequest returns the authenticated user's entitlement, looked up once per
+// request
+func (e *Entitlements) ForRequest(c *fiber.Ctx) (*Entitlement, error) {
+	if entitlement, ok := c.Locals(entitlementLocal).(*Entitlement); ok {
+		return entitlement, nil
+	}
+
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+	entitlement, err := e.Get(c.UserContext(), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	c.Locals(entitlementLocal, entitlement)
+	return entitlement, nil
+}
+
+// EvictUser forgets the user's cached entitlement, e.g. after their
+// subscription changed
+func (e *Entitlements) EvictUser(userID string) {
+	e.cache.Delete(userID)
+}
+
+// StartTrial starts the user's one trial of the pro plan
+func (e *Entitlements) StartTrial(ctx context.Context, userID string) (*Trial, error) {
+	if e.repo == nil || e.cfg.TrialDays <= 0 {
+		return nil, ErrTrialsDisabled
+	}
+
+	sub, err := e.repo.GetSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub != nil && sub.Active() {
+		return nil, ErrSubscribed
+	}
+
+	now := time.Now()
+	trial := &Trial{
+		UserID:    userID,
+		PlanID:    PlanPro,
+		StartedAt: now,
+		EndsAt:    now.AddDate(0, 0, e.cfg.TrialDays),
+	}
+	created, err := e.repo.CreateTrial(ctx, trial)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrTrialUsed
+	}
+	e.EvictUser(userID)
+	return trial, nil
+}
@@ -0,0 +1,326 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/stripe"
+	"github.com/gofiber/fiber/v2"
+)
+
+// billingPath is the frontend page Checkout and the portal return to
+const billingPath = "/dashboard/billing"
+
+type Handlers struct {
+	repo         Repository
+	entitlements *Entitlements
+	stripe       *stripe.Client
+	cfg          config.BillingConfig
+}
+
+func NewHandlers(repo Repository, entitlements *Entitlements, stripeClient *stripe.Client, cfg config.BillingConfig) *Handlers {
+	return &Handlers{
+		repo:         repo,
+		entitlements: entitlements,
+		stripe:       stripeClient,
+		cfg:          cfg,
+	}
+}
+
+// RegisterRoutes registers billing routes on a Clerk-protected router. The
+// webhook is public, see WebhookHandler.
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	billing := router.Group("/billing")
+	billing.Get("/", h.GetBilling)
+	billing.Post("/trial", h.StartTrial)
+	billing.Post("/checkout", h.CreateCheckout)
+	billing.Post("/portal", h.CreatePortal)
+}
+
+// GetBilling returns the user's plan, what it entitles them to, their
+// subscription if they have one and the plans on offer
+func (h *Handlers) GetBilling(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	ctx := c.UserContext()
+	entitlement, err := h.entitlements.Get(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting entitlements for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get billing",
+		})
+	}
+	sub, err := h.repo.GetSubscription(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting subscription for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get billing",
+		})
+	}
+	plans, err := h.repo.ListPlans(ctx)
+	if err != nil {
+		log.Printf("Error listing plans: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get billing",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"plan":         entitlement.Plan,
+		"entitlements": entitlement,
+		"subscription": sub,
+		"plans":        plans,
+	})
+}
+
+// StartTrial starts the user's one pro trial of BILLING_TRIAL_DAYS
+func (h *Handlers) StartTrial(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	trial, err := h.entitlements.StartTrial(c.UserContext(), user.ID)
+	switch {
+	case errors.Is(err, ErrTrialsDisabled):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Trials are not available",
+		})
+	case errors.Is(err, ErrTrialUsed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You have already used your trial",
+		})
+	case errors.Is(err, ErrSubscribed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You already have a subscription, manage it in the billing portal",
+		})
+	case err != nil:
+		log.Printf("Error starting trial for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start your trial",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(trial)
+}
+
+// CreateCheckout starts a Stripe Checkout for the pro plan and returns its
+// URL. Like the OAuth flows it takes a return_to frontend.
+func (h *Handlers) CreateCheckout(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	frontendURL, ok := config.Frontend().FrontendOrigin(c.Query("return_to", c.Get(fiber.HeaderOrigin)))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "return_to is not an allowed frontend",
+		})
+	}
+
+	ctx := c.UserContext()
+	sub, err := h.repo.GetSubscription(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting subscription for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start checkout",
+		})
+	}
+	if sub != nil && sub.Active() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You already have a subscription, manage it in the billing portal",
+		})
+	}
+
+	params := stripe.CheckoutParams{
+		PriceID:           h.cfg.ProPriceID,
+		ClientReferenceID: user.ID,
+		CustomerEmail:     user.Email,
+		SuccessURL:        frontendURL + billingPath + "?checkout=success",
+		CancelURL:         frontendURL + billingPath + "?checkout=canceled",
+		Metadata:          map[string]string{"user_id": user.ID},
+	}
+	if sub != nil {
+		params.CustomerID = sub.StripeCustomerID
+	}
+	session, err := h.stripe.CreateCheckoutSession(ctx, params)
+	if err != nil {
+		log.Printf("Error creating checkout for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to start checkout",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"url": session.URL,
+	})
+}
+
+// CreatePortal returns the Stripe customer portal, where subscribers change
+// their payment method or cancel
+func (h *Handlers) CreatePortal(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	frontendURL, ok := config.Frontend().FrontendOrigin(c.Query("return_to", c.Get(fiber.HeaderOrigin)))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "return_to is not an allowed frontend",
+		})
+	}
+
+	ctx := c.UserContext()
+	sub, err := h.repo.GetSubscription(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting subscription for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open the billing portal",
+		})
+	}
+	if sub == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No subscription to manage",
+		})
+	}
+
+	session, err := h.stripe.CreatePortalSession(ctx, sub.StripeCustomerID, frontendURL+billingPath)
+	if err != nil {
+		log.Printf("Error creating billing portal for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to open the billing portal",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"url": session.URL,
+	})
+}
+
+// WebhookHandler receives Stripe events at /api/webhooks/stripe and keeps
+// subscriptions in sync. It is public, deliveries are authenticated by their
+// signature.
+func (h *Handlers) WebhookHandler(c *fiber.Ctx) error {
+	body := c.Body()
+	if !stripe.VerifyWebhookSignature(h.cfg.StripeWebhookSecret, c.Get(stripe.SignatureHeader), body, time.Now()) {
+		log.Printf("⚠️ Rejected Stripe delivery with invalid signature")
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+
+	var event stripe.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid Stripe event",
+		})
+	}
+
+	if err := h.handleEvent(c.UserContext(), &event); err != nil {
+		// Let Stripe redeliver
+		log.Printf("Failed to handle Stripe event %s (%s): %v", event.ID, event.Type, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handlers) handleEvent(ctx context.Context, event *stripe.Event) error {
+	switch event.Type {
+	case stripe.EventCheckoutCompleted:
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+			return fmt.Errorf("failed to decode checkout session: %w", err)
+		}
+		if session.ClientReferenceID == "" || session.Subscription == "" {
+			return nil
+		}
+		return h.save(ctx, &Subscription{
+			UserID:               session.ClientReferenceID,
+			PlanID:               PlanPro,
+			Status:               "active",
+			StripeCustomerID:     session.Customer,
+			StripeSubscriptionID: &session.Subscription,
+			LastEventAt:          event.CreatedAt(),
+		})
+
+	case stripe.EventSubscriptionCreated, stripe.EventSubscriptionUpdated, stripe.EventSubscriptionDeleted:
+		var stripeSub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Object, &stripeSub); err != nil {
+			return fmt.Errorf("failed to decode subscription: %w", err)
+		}
+
+		// Checkout copies the user ID onto the subscription, subscriptions
+		// from elsewhere are matched to the one saved at checkout
+		userID := stripeSub.Metadata["user_id"]
+		if userID == "" {
+			existing, err := h.repo.GetSubscriptionByStripeID(ctx, stripeSub.ID)
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				log.Printf("Ignoring Stripe subscription %s without a user", stripeSub.ID)
+				return nil
+			}
+			userID = existing.UserID
+		}
+
+		planID := PlanFree
+		if stripeSub.PriceID() == h.cfg.ProPriceID {
+			planID = PlanPro
+		} else {
+			log.Printf("Stripe subscription %s has unknown price %s, treating it as free", stripeSub.ID, stripeSub.PriceID())
+		}
+		status := stripeSub.Status
+		if event.Type == stripe.EventSubscriptionDeleted {
+			status = "canceled"
+		}
+		var periodEnd *time.Time
+		if stripeSub.CurrentPeriodEnd > 0 {
+			end := time.Unix(stripeSub.CurrentPeriodEnd, 0)
+			periodEnd = &end
+		}
+		return h.save(ctx, &Subscription{
+			UserID:               userID,
+			PlanID:               planID,
+			Status:               status,
+			StripeCustomerID:     stripeSub.Customer,
+			StripeSubscriptionID: &stripeSub.ID,
+			CurrentPeriodEnd:     periodEnd,
+			CancelAtPeriodEnd:    stripeSub.CancelAtPeriodEnd,
+			LastEventAt:          event.CreatedAt(),
+		})
+
+	default:
+		return nil
+	}
+}
+
+// save stores the subscription and drops the user's cached plan
+func (h *Handlers) save(ctx context.Context, sub *Subscription) error {
+	saved, err := h.repo.SaveSubscription(ctx, sub)
+	if err != nil {
+		return fmt.Errorf("failed to save subscription for user %s: %w", sub.UserID, err)
+	}
+	if saved {
+		log.Printf("💳 Subscription of user %s is now %s (%s)", sub.UserID, sub.PlanID, sub.Status)
+		h.entitlements.EvictUser(sub.UserID)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HistoryTruncatedHeader is set to the number of days analytics were cut to
+// when the requested range reached further back than the user's plan
+const HistoryTruncatedHeader = "X-CreatorSync-History-Truncated"
+
+// Limits enforces each user's entitlements on routes. A nil *Limits allows
+// everything.
+type Limits struct {
+	entitlements *Entitlements
+}
+
+func NewLimits(entitlements *Entitlements) *Limits {
+	return &Limits{
+		entitlements: entitlements,
+	}
+}
+
+// Require rejects users who may not use the feature with 402 Payment
+// Required
+func (l *Limits) Require(feature string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if l == nil {
+			return c.Next()
+		}
+
+		entitlement, err := l.entitlements.ForRequest(c)
+		if err != nil {
+			log.Printf("Error getting entitlements: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check your plan",
+			})
+		}
+		if !entitlement.Can(feature) {
+			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+				"error":   "Upgrade your plan to use this feature",
+				"feature": feature,
+				"plan":    entitlement.PlanID(),
+			})
+		}
+		return c.Next()
+	}
+}
+
+// TruncateDays cuts a requested range of days, 0 being all time, to the
+// user's history rather than rejecting it, and says so in
+// HistoryTruncatedHeader. The range is kept when entitlements can't be read.
+func (l *Limits) TruncateDays(c *fiber.Ctx, days int) int {
+	if l == nil {
+		return days
+	}
+
+	entitlement, err := l.entitlements.ForRequest(c)
+	if err != nil {
+		log.Printf("Error getting entitlements, keeping %d days: %v", days, err)
+		return days
+	}
+	clamped, truncated := entitlement.ClampDays(days)
+	if truncated {
+		c.Set(HistoryTruncatedHeader, strconv.Itoa(clamped))
+	}
+	return clamped
+}
+
+// TruncateMonths is TruncateDays for ranges of months, keeping at least the
+// current month
+func (l *Limits) TruncateMonths(c *fiber.Ctx, months int) int {
+	days := l.TruncateDays(c, months*30)
+	if days >= months*30 {
+		return months
+	}
+	return max(days/30, 1)
+}
+
+// Hook wraps a per-user collection hook so it only runs for users who may use
+// the feature, e.g. scheduled reports for users with exports
+func (l *Limits) Hook(feature string, hook func(ctx context.Context, userID string) error) func(ctx context.Context, userID string) error {
+	if l == nil {
+		return hook
+	}
+	return func(ctx context.Context, userID string) error {
+		entitlement, err := l.entitlements.Get(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get entitlements: %w", err)
+		}
+		if !entitlement.Can(feature) {
+			return nil
+		}
+		return hook(ctx, userID)
+	}
+}
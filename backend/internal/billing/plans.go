@@ -0,0 +1,85 @@
+// Package billing sells the pro plan through Stripe and enforces the limits
+// of each user's plan
+package billing
+
+import (
+	"slices"
+	"time"
+)
+
+// Plan IDs seeded by migration 042
+const (
+	PlanFree = "free"
+	PlanPro  = "pro"
+)
+
+// Features gated by plan
+const (
+	// FeatureExports covers reports, media kits and their downloads
+	FeatureExports = "exports"
+	// FeatureMultiPlatform covers connecting platforms besides Twitch
+	FeatureMultiPlatform = "multi_platform"
+)
+
+// Plan is a tier with its limits
+type Plan struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// MaxPlatforms counts connected platforms including Twitch, nil for all
+	MaxPlatforms *int `json:"max_platforms" db:"max_platforms"`
+	// HistoryDays is how far back analytics reach, nil for all time
+	HistoryDays *int `json:"history_days" db:"history_days"`
+	Exports     bool `json:"exports" db:"exports"`
+}
+
+// Allows reports whether the plan includes a feature. Twitch is always the
+// first platform, so more than one platform is what allows the others.
+func (p *Plan) Allows(feature string) bool {
+	switch feature {
+	case FeatureExports:
+		return p.Exports
+	case FeatureMultiPlatform:
+		return p.MaxPlatforms == nil || *p.MaxPlatforms > 1
+	default:
+		return false
+	}
+}
+
+// allFeatures are listed in entitlements
+var allFeatures = []string{FeatureExports, FeatureMultiPlatform}
+
+// activeStatuses keep the subscription's plan. Past due subscriptions keep it
+// while Stripe retries the payment.
+var activeStatuses = []string{"active", "trialing", "past_due"}
+
+// Subscription is a user's Stripe subscription as last reported by webhooks
+type Subscription struct {
+	UserID               string     `json:"-" db:"user_id"`
+	PlanID               string     `json:"plan_id" db:"plan_id"`
+	Status               string     `json:"status" db:"status"`
+	StripeCustomerID     string     `json:"-" db:"stripe_customer_id"`
+	StripeSubscriptionID *string    `json:"-" db:"stripe_subscription_id"`
+	CurrentPeriodEnd     *time.Time `json:"current_period_end" db:"current_period_end"`
+	CancelAtPeriodEnd    bool       `json:"cancel_at_period_end" db:"cancel_at_period_end"`
+	LastEventAt          time.Time  `json:"-" db:"last_event_at"`
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Active reports whether the subscription's plan applies
+func (s *Subscription) Active() bool {
+	return slices.Contains(activeStatuses, s.Status)
+}
+
+// Trial is a user's one trial of a paid plan
+type Trial struct {
+	UserID    string    `json:"-" db:"user_id"`
+	PlanID    string    `json:"plan_id" db:"plan_id"`
+	StartedAt time.Time `json:"started_at" db:"started_at"`
+	EndsAt    time.Time `json:"ends_at" db:"ends_at"`
+}
+
+// Active reports whether the trial's plan applies at now
+func (t *Trial) Active(now time.Time) bool {
+	return now.Before(t.EndsAt)
+}
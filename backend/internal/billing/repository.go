@@ -0,0 +1,150 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListPlans(ctx context.Context) ([]Plan, error)
+	GetPlan(ctx context.Context, id string) (*Plan, error)
+
+	GetSubscription(ctx context.Context, userID string) (*Subscription, error)
+	GetSubscriptionByStripeID(ctx context.Context, stripeSubscriptionID string) (*Subscription, error)
+	// SaveSubscription creates or replaces the user's subscription unless it
+	// was last changed by a newer event, and reports whether it did
+	SaveSubscription(ctx context.Context, sub *Subscription) (bool, error)
+
+	GetTrial(ctx context.Context, userID string) (*Trial, error)
+	// CreateTrial starts the user's trial unless they already had one, and
+	// reports whether it did
+	CreateTrial(ctx context.Context, trial *Trial) (bool, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+func (r *repository) ListPlans(ctx context.Context) ([]Plan, error) {
+	query := `
+		SELECT id, name, max_platforms, history_days, exports
+		FROM plans
+		ORDER BY max_platforms NULLS LAST, id
+	`
+
+	plans := []Plan{}
+	err := r.db.SelectContext(ctx, &plans, query)
+	return plans, err
+}
+
+func (r *repository) GetPlan(ctx context.Context, id string) (*Plan, error) {
+	query := `
+		SELECT id, name, max_platforms, history_days, exports
+		FROM plans
+		WHERE id = $1
+	`
+
+	var plan Plan
+	err := r.db.GetContext(ctx, &plan, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+const subscriptionColumns = `user_id, plan_id, status, stripe_customer_id, stripe_subscription_id,
+	current_period_end, cancel_at_period_end, last_event_at, created_at, updated_at`
+
+func (r *repository) GetSubscription(ctx context.Context, userID string) (*Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE user_id = $1`
+
+	var sub Subscription
+	err := r.db.GetContext(ctx, &sub, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *repository) GetSubscriptionByStripeID(ctx context.Context, stripeSubscriptionID string) (*Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE stripe_subscription_id = $1`
+
+	var sub Subscription
+	err := r.db.GetContext(ctx, &sub, query, stripeSubscriptionID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *repository) SaveSubscription(ctx context.Context, sub *Subscription) (bool, error) {
+	query := `
+		INSERT INTO subscriptions (user_id, plan_id, status, stripe_customer_id, stripe_subscription_id,
+			current_period_end, cancel_at_period_end, last_event_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			plan_id = EXCLUDED.plan_id,
+			status = EXCLUDED.status,
+			stripe_customer_id = EXCLUDED.stripe_customer_id,
+			stripe_subscription_id = COALESCE(EXCLUDED.stripe_subscription_id, subscriptions.stripe_subscription_id),
+			current_period_end = COALESCE(EXCLUDED.current_period_end, subscriptions.current_period_end),
+			cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = NOW()
+		WHERE subscriptions.last_event_at <= EXCLUDED.last_event_at
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sub.UserID, sub.PlanID, sub.Status, sub.StripeCustomerID,
+		sub.StripeSubscriptionID, sub.CurrentPeriodEnd, sub.CancelAtPeriodEnd, sub.LastEventAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *repository) GetTrial(ctx context.Context, userID string) (*Trial, error) {
+	query := `SELECT user_id, plan_id, started_at, ends_at FROM trials WHERE user_id = $1`
+
+	var trial Trial
+	err := r.db.GetContext(ctx, &trial, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &trial, nil
+}
+
+func (r *repository) CreateTrial(ctx context.Context, trial *Trial) (bool, error) {
+	query := `
+		INSERT INTO trials (user_id, plan_id, started_at, ends_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, trial.UserID, trial.PlanID, trial.StartedAt, trial.EndsAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
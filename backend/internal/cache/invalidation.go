@@ -0,0 +1,37 @@
+package cache
+
+import "sync"
+
+// Invalidator evicts what every registered cache holds about a user. Flows that
+// change a user's data, like connecting Twitch or collecting analytics, call
+// InvalidateUser instead of knowing which caches exist. Caches are per replica,
+// so other replicas catch up when their entries expire.
+type Invalidator struct {
+	mu       sync.RWMutex
+	evictors []func(userID string)
+}
+
+func NewInvalidator() *Invalidator {
+	return &Invalidator{}
+}
+
+// Register adds a cache's eviction for a user
+func (i *Invalidator) Register(evict func(userID string)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.evictors = append(i.evictors, evict)
+}
+
+// InvalidateUser evicts userID from every registered cache. A nil Invalidator
+// does nothing.
+func (i *Invalidator) InvalidateUser(userID string) {
+	if i == nil {
+		return
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, evict := range i.evictors {
+		evict(userID)
+	}
+}
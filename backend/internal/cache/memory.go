@@ -0,0 +1,104 @@
+// Package cache provides small in-process caches for hot endpoints and a way
+// to evict everything cached about a user at once.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Memory is a size-bounded TTL cache safe for concurrent use
+type Memory[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]entry[V]
+	// kind names the cache in traces after its value type
+	kind string
+}
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// NewMemory creates a cache whose entries live for ttl. Expired entries are
+// pruned once the cache holds maxEntries.
+func NewMemory[V any](ttl time.Duration, maxEntries int) *Memory[V] {
+	return &Memory[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]entry[V]),
+		kind:       fmt.Sprintf("%T", *new(V)),
+	}
+}
+
+// Get returns the cached value and whether it was present and fresh
+func (m *Memory[V]) Get(key string) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// GetContext is Get, recorded as an event on the span in ctx. Lookups take
+// microseconds, so they are events rather than spans of their own. Keys are
+// left out as some are tokens.
+func (m *Memory[V]) GetContext(ctx context.Context, key string) (V, bool) {
+	value, ok := m.Get(key)
+	trace.SpanFromContext(ctx).AddEvent("cache.get", trace.WithAttributes(
+		attribute.String("cache.kind", m.kind),
+		attribute.Bool("cache.hit", ok),
+	))
+	return value, ok
+}
+
+// Set stores value under key for the cache TTL
+func (m *Memory[V]) Set(key string, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = entry[V]{value: value, expires: now.Add(m.ttl)}
+}
+
+// Delete evicts key
+func (m *Memory[V]) Delete(key string) {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+}
+
+// DeleteFunc evicts every entry for which match returns true
+func (m *Memory[V]) DeleteFunc(match func(key string, value V) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, e := range m.entries {
+		if match(k, e.value) {
+			delete(m.entries, k)
+		}
+	}
+}
+
+// TTL returns how long entries live
+func (m *Memory[V]) TTL() time.Duration {
+	return m.ttl
+}
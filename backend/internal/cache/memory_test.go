@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMemoryDeleteFunc(t *testing.T) {
+	m := NewMemory[string](time.Minute, 0)
+	m.Set("overlay_a", "user_1")
+	m.Set("overlay_b", "user_2")
+	m.Set("overlay_c", "user_1")
+
+	m.DeleteFunc(func(_ string, userID string) bool { return userID == "user_1" })
+
+	if _, ok := m.Get("overlay_a"); ok {
+		t.Error("expected overlay_a to be evicted")
+	}
+	if _, ok := m.Get("overlay_c"); ok {
+		t.Error("expected overlay_c to be evicted")
+	}
+	if _, ok := m.Get("overlay_b"); !ok {
+		t.Error("expected overlay_b to be kept")
+	}
+}
+
+func TestInvalidatorEvictsFromEveryCache(t *testing.T) {
+	connections := NewMemory[string](time.Minute, 0)
+	profiles := NewMemory[string](time.Minute, 0)
+	connections.Set("user_1", "token")
+	profiles.Set("some-slug", "user_1")
+
+	invalidator := NewInvalidator()
+	invalidator.Register(connections.Delete)
+	invalidator.Register(func(userID string) {
+		profiles.DeleteFunc(func(_ string, owner string) bool { return owner == userID })
+	})
+	invalidator.InvalidateUser("user_1")
+
+	if _, ok := connections.Get("user_1"); ok {
+		t.Error("expected the connection to be evicted")
+	}
+	if _, ok := profiles.Get("some-slug"); ok {
+		t.Error("expected the profile to be evicted")
+	}
+
+	// A nil invalidator is a no-op
+	var none *Invalidator
+	none.InvalidateUser("user_1")
+}
+
+func TestMemoryGetContextRecordsLookups(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	m := NewMemory[int](time.Minute, 0)
+	m.Set("a", 1)
+
+	ctx, span := tracer.Start(context.Background(), "request")
+	if v, ok := m.GetContext(ctx, "a"); !ok || v != 1 {
+		t.Errorf("expected a hit for a, got %v, %v", v, ok)
+	}
+	m.GetContext(ctx, "b")
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 cache events, got %d", len(events))
+	}
+	for i, hit := range []bool{true, false} {
+		for _, attr := range events[i].Attributes {
+			if attr.Key == "cache.hit" && attr.Value.AsBool() != hit {
+				t.Errorf("event %d: expected cache.hit %v", i, hit)
+			}
+			if attr.Key == "cache.kind" && attr.Value.AsString() != "int" {
+				t.Errorf("event %d: expected cache.kind int, got %s", i, attr.Value.AsString())
+			}
+		}
+	}
+}
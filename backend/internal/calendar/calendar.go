@@ -0,0 +1,239 @@
+// Package calendar is the content planner: creators plan streams and uploads,
+// and after each collection the planned entries are reconciled with the
+// streams and videos that actually happened.
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+// Entry kinds
+const (
+	KindStream = "stream"
+	KindUpload = "upload"
+)
+
+// Entry statuses. Clients only create planned entries, reconciliation moves
+// them to done or missed.
+const (
+	StatusPlanned = "planned"
+	StatusDone    = "done"
+	StatusMissed  = "missed"
+)
+
+const (
+	maxTitleLength = 200
+	maxNotesLength = 2000
+
+	// A stream counts for a planned entry when it goes live from streamEarly
+	// before the entry until the entry ends, or streamLate after the start for
+	// entries without an end
+	streamEarly = time.Hour
+	streamLate  = 2 * time.Hour
+	// Uploads count when published within uploadTolerance either side of the
+	// planned time
+	uploadTolerance = 24 * time.Hour
+)
+
+// Entry is a planned stream or upload
+type Entry struct {
+	ID       int        `json:"id" db:"id"`
+	UserID   string     `json:"-" db:"user_id"`
+	Kind     string     `json:"kind" db:"kind"`
+	Title    string     `json:"title" db:"title"`
+	Notes    string     `json:"notes" db:"notes"`
+	StartsAt time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at" db:"ends_at"`
+	Status   string     `json:"status" db:"status"`
+	// MatchedID is the stream or video the entry was reconciled with, and
+	// MatchedAt when it went live or was published
+	MatchedID string     `json:"matched_id,omitempty" db:"matched_id"`
+	MatchedAt *time.Time `json:"matched_at,omitempty" db:"matched_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Normalize trims and validates the fields a client can set
+func (e *Entry) Normalize() error {
+	e.Title = strings.TrimSpace(e.Title)
+	e.Notes = strings.TrimSpace(e.Notes)
+
+	if e.Kind != KindStream && e.Kind != KindUpload {
+		return fmt.Errorf("unknown kind %q (use %s or %s)", e.Kind, KindStream, KindUpload)
+	}
+	if e.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if utf8.RuneCountInString(e.Title) > maxTitleLength {
+		return fmt.Errorf("title must be at most %d characters", maxTitleLength)
+	}
+	if utf8.RuneCountInString(e.Notes) > maxNotesLength {
+		return fmt.Errorf("notes must be at most %d characters", maxNotesLength)
+	}
+	if e.StartsAt.IsZero() {
+		return fmt.Errorf("starts_at is required")
+	}
+	if e.EndsAt != nil && !e.EndsAt.After(e.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// window is when a stream or video has to happen to count for the entry
+func (e *Entry) window() (time.Time, time.Time) {
+	if e.Kind == KindUpload {
+		return e.StartsAt.Add(-uploadTolerance), e.StartsAt.Add(uploadTolerance)
+	}
+	if e.EndsAt != nil {
+		return e.StartsAt.Add(-streamEarly), *e.EndsAt
+	}
+	return e.StartsAt.Add(-streamEarly), e.StartsAt.Add(streamLate)
+}
+
+// actual is a stream or video that can fulfil a planned entry
+type actual struct {
+	id   string
+	kind string
+	at   time.Time
+}
+
+// Reconcile matches entries that aren't done yet with the streams and uploads
+// that happened. In planned order, each entry takes the closest stream or
+// video in its window that no earlier entry took. Entries whose window has
+// passed without a match are missed. It returns the entries whose status changed.
+func Reconcile(entries []Entry, streams []analytics.StreamSession, videos []analytics.VideoAnalytics, now time.Time) []Entry {
+	var actuals []actual
+	for _, stream := range streams {
+		if stream.StartedAt != nil {
+			actuals = append(actuals, actual{id: stream.StreamID, kind: KindStream, at: *stream.StartedAt})
+		}
+	}
+	for _, video := range videos {
+		// Archives are the recordings of streams, clips aren't planned
+		if video.PublishedAt != nil && (video.VideoType == "upload" || video.VideoType == "highlight") {
+			actuals = append(actuals, actual{id: video.VideoID, kind: KindUpload, at: *video.PublishedAt})
+		}
+	}
+
+	claimed := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Status == StatusDone {
+			claimed[entry.Kind+":"+entry.MatchedID] = true
+		}
+	}
+
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartsAt.Before(sorted[j].StartsAt)
+	})
+
+	var changed []Entry
+	for _, entry := range sorted {
+		if entry.Status == StatusDone {
+			continue
+		}
+
+		from, to := entry.window()
+		best := -1
+		for i, a := range actuals {
+			if a.kind != entry.Kind || claimed[a.kind+":"+a.id] || a.at.Before(from) || a.at.After(to) {
+				continue
+			}
+			if best < 0 || distance(a.at, entry.StartsAt) < distance(actuals[best].at, entry.StartsAt) {
+				best = i
+			}
+		}
+
+		switch {
+		case best >= 0:
+			match := actuals[best]
+			claimed[match.kind+":"+match.id] = true
+			entry.Status = StatusDone
+			entry.MatchedID = match.id
+			entry.MatchedAt = &match.at
+			changed = append(changed, entry)
+		case entry.Status == StatusPlanned && now.After(to):
+			entry.Status = StatusMissed
+			changed = append(changed, entry)
+		}
+	}
+	return changed
+}
+
+func distance(a, b time.Time) time.Duration {
+	if a.Before(b) {
+		return b.Sub(a)
+	}
+	return a.Sub(b)
+}
+
+// Month is the planner's month view
+type Month struct {
+	Month    string `json:"month"` // 2006-01
+	Timezone string `json:"timezone"`
+	Planned  int    `json:"planned"`
+	Done     int    `json:"done"`
+	Missed   int    `json:"missed"`
+	Days     []Day  `json:"days"`
+}
+
+// Day lists the entries starting on one day of the month
+type Day struct {
+	Date    string  `json:"date"` // 2006-01-02
+	Entries []Entry `json:"entries"`
+}
+
+// MonthBounds returns the start of month and of the next month in loc
+func MonthBounds(year int, month time.Month, loc *time.Location) (time.Time, time.Time) {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// BuildMonth groups the entries of a month by the day they start on in the
+// month's location. Every day of the month is listed, with or without entries.
+func BuildMonth(start time.Time, entries []Entry) *Month {
+	loc := start.Location()
+	view := &Month{
+		Month:    start.Format("2006-01"),
+		Timezone: loc.String(),
+		Days:     []Day{},
+	}
+
+	index := make(map[string]int)
+	for day := start; day.Month() == start.Month(); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		index[date] = len(view.Days)
+		view.Days = append(view.Days, Day{Date: date, Entries: []Entry{}})
+	}
+
+	for _, entry := range entries {
+		i, ok := index[entry.StartsAt.In(loc).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		view.Days[i].Entries = append(view.Days[i].Entries, entry)
+
+		switch entry.Status {
+		case StatusDone:
+			view.Done++
+		case StatusMissed:
+			view.Missed++
+		default:
+			view.Planned++
+		}
+	}
+
+	for _, day := range view.Days {
+		sort.SliceStable(day.Entries, func(i, j int) bool {
+			return day.Entries[i].StartsAt.Before(day.Entries[j].StartsAt)
+		})
+	}
+	return view
+}
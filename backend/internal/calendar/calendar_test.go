@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+func TestEntryNormalize(t *testing.T) {
+	start := time.Date(2025, 6, 10, 18, 0, 0, 0, time.UTC)
+
+	entry := &Entry{Kind: KindStream, Title: "  Speedrun night ", StartsAt: start}
+	require.NoError(t, entry.Normalize())
+	assert.Equal(t, "Speedrun night", entry.Title)
+
+	assert.Error(t, (&Entry{Kind: "podcast", Title: "x", StartsAt: start}).Normalize())
+	assert.Error(t, (&Entry{Kind: KindUpload, Title: " ", StartsAt: start}).Normalize())
+	assert.Error(t, (&Entry{Kind: KindUpload, Title: "x"}).Normalize())
+
+	before := start.Add(-time.Hour)
+	assert.Error(t, (&Entry{Kind: KindStream, Title: "x", StartsAt: start, EndsAt: &before}).Normalize())
+}
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time { return time.Date(2025, 6, day, hour, 0, 0, 0, time.UTC) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	entries := []Entry{
+		{ID: 1, Kind: KindStream, StartsAt: at(10, 18), Status: StatusPlanned},
+		// One stream fulfils only the first of two overlapping entries
+		{ID: 2, Kind: KindStream, StartsAt: at(12, 18), Status: StatusPlanned},
+		{ID: 3, Kind: KindStream, StartsAt: at(12, 20), Status: StatusPlanned},
+		{ID: 4, Kind: KindUpload, StartsAt: at(15, 12), Status: StatusPlanned},
+		// Still in its window
+		{ID: 5, Kind: KindStream, StartsAt: at(20, 11), Status: StatusPlanned},
+		// A late upload turns a missed entry done
+		{ID: 6, Kind: KindUpload, StartsAt: at(18, 9), Status: StatusMissed},
+		{ID: 7, Kind: KindStream, StartsAt: at(8, 18), Status: StatusDone, MatchedID: "s_done"},
+	}
+	streams := []analytics.StreamSession{
+		{StreamID: "s_done", StartedAt: ptr(at(8, 18))},
+		{StreamID: "s_early", StartedAt: ptr(at(10, 17))},
+		{StreamID: "s_late", StartedAt: ptr(time.Date(2025, 6, 12, 19, 30, 0, 0, time.UTC))},
+	}
+	videos := []analytics.VideoAnalytics{
+		{VideoID: "v_archive", VideoType: "archive", PublishedAt: ptr(at(15, 12))},
+		{VideoID: "v_upload", VideoType: "upload", PublishedAt: ptr(at(19, 8))},
+	}
+
+	changed := Reconcile(entries, streams, videos, now)
+
+	byID := make(map[int]Entry)
+	for _, entry := range changed {
+		byID[entry.ID] = entry
+	}
+	require.Len(t, byID, 5)
+
+	assert.Equal(t, StatusDone, byID[1].Status)
+	assert.Equal(t, "s_early", byID[1].MatchedID)
+	assert.Equal(t, "s_late", byID[2].MatchedID)
+	assert.Equal(t, StatusMissed, byID[3].Status)
+	assert.Equal(t, StatusMissed, byID[4].Status, "archives don't count as uploads")
+	assert.Equal(t, "v_upload", byID[6].MatchedID)
+	assert.Equal(t, at(19, 8), *byID[6].MatchedAt)
+
+	_, ok := byID[5]
+	assert.False(t, ok)
+}
+
+func TestBuildMonth(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	start, end := MonthBounds(2025, time.February, berlin)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, berlin), end)
+
+	entries := []Entry{
+		{ID: 1, Kind: KindStream, StartsAt: time.Date(2025, 2, 3, 19, 0, 0, 0, time.UTC), Status: StatusDone},
+		// 23:30 UTC on the 2nd is already the 3rd in Berlin
+		{ID: 2, Kind: KindUpload, StartsAt: time.Date(2025, 2, 2, 23, 30, 0, 0, time.UTC), Status: StatusPlanned},
+		{ID: 3, Kind: KindStream, StartsAt: time.Date(2025, 2, 27, 18, 0, 0, 0, time.UTC), Status: StatusMissed},
+	}
+
+	view := BuildMonth(start, entries)
+
+	assert.Equal(t, "2025-02", view.Month)
+	assert.Equal(t, "Europe/Berlin", view.Timezone)
+	require.Len(t, view.Days, 28)
+	assert.Equal(t, "2025-02-01", view.Days[0].Date)
+	assert.Empty(t, view.Days[1].Entries)
+
+	require.Len(t, view.Days[2].Entries, 2)
+	assert.Equal(t, 2, view.Days[2].Entries[0].ID)
+	assert.Equal(t, 1, view.Days[2].Entries[1].ID)
+	assert.Equal(t, 1, view.Planned)
+	assert.Equal(t, 1, view.Done)
+	assert.Equal(t, 1, view.Missed)
+}
@@ -0,0 +1,244 @@
+package calendar
+
+import (
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	service *Service
+	repo    Repository
+}
+
+func NewHandlers(service *Service, repo Repository) *Handlers {
+	return &Handlers{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// RegisterRoutes registers calendar routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	calendar := router.Group("/calendar")
+	calendar.Get("/", h.GetMonth)
+	calendar.Post("/", h.CreateEntry)
+	calendar.Get("/:id", h.GetEntry)
+	calendar.Put("/:id", h.UpdateEntry)
+	calendar.Delete("/:id", h.DeleteEntry)
+}
+
+// GetMonth returns the month view. ?month=2006-01 picks the month (the current
+// one by default) and ?tz= an IANA time zone the days are cut in (UTC by default).
+func (h *Handlers) GetMonth(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	loc, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown time zone",
+		})
+	}
+
+	month := time.Now().In(loc)
+	if raw := c.Query("month"); raw != "" {
+		month, err = time.ParseInLocation("2006-01", raw, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "month must be formatted as YYYY-MM",
+			})
+		}
+	}
+
+	view, err := h.service.GetMonth(c.UserContext(), user.ID, month.Year(), month.Month(), loc)
+	if err != nil {
+		log.Printf("Error getting calendar for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get calendar",
+		})
+	}
+
+	return c.JSON(view)
+}
+
+// entryRequest is the body for creating or updating an entry
+type entryRequest struct {
+	Kind     string     `json:"kind"`
+	Title    string     `json:"title"`
+	Notes    string     `json:"notes"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+func (req entryRequest) entry(userID string) (*Entry, error) {
+	entry := &Entry{
+		UserID:   userID,
+		Kind:     req.Kind,
+		Title:    req.Title,
+		Notes:    req.Notes,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}
+	return entry, entry.Normalize()
+}
+
+// CreateEntry plans a stream or upload
+func (h *Handlers) CreateEntry(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req entryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	entry, err := req.entry(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.repo.CreateEntry(c.UserContext(), entry); err != nil {
+		log.Printf("Error creating calendar entry for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create calendar entry",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"entry": entry,
+	})
+}
+
+// GetEntry returns one of the user's entries
+func (h *Handlers) GetEntry(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	entryID, err := c.ParamsInt("id")
+	if err != nil || entryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar entry ID",
+		})
+	}
+
+	entry, err := h.repo.GetEntry(c.UserContext(), user.ID, entryID)
+	if err != nil {
+		log.Printf("Error getting calendar entry %d for user %s: %v", entryID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get calendar entry",
+		})
+	}
+	if entry == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar entry not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entry": entry,
+	})
+}
+
+// UpdateEntry replaces one of the user's entries. An edited entry is planned
+// again until the next collection reconciles it.
+func (h *Handlers) UpdateEntry(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	entryID, err := c.ParamsInt("id")
+	if err != nil || entryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar entry ID",
+		})
+	}
+
+	var req entryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	entry, err := req.entry(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	entry.ID = entryID
+
+	found, err := h.repo.UpdateEntry(c.UserContext(), entry)
+	if err != nil {
+		log.Printf("Error updating calendar entry %d for user %s: %v", entryID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update calendar entry",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar entry not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entry": entry,
+	})
+}
+
+// DeleteEntry deletes one of the user's entries
+func (h *Handlers) DeleteEntry(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	entryID, err := c.ParamsInt("id")
+	if err != nil || entryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar entry ID",
+		})
+	}
+
+	found, err := h.repo.DeleteEntry(c.UserContext(), user.ID, entryID)
+	if err != nil {
+		log.Printf("Error deleting calendar entry %d for user %s: %v", entryID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete calendar entry",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar entry not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Calendar entry deleted",
+		"id":      entryID,
+	})
+}
@@ -0,0 +1,107 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListEntries(ctx context.Context, userID string, from, to time.Time) ([]Entry, error)
+	GetEntry(ctx context.Context, userID string, entryID int) (*Entry, error)
+	CreateEntry(ctx context.Context, entry *Entry) error
+	UpdateEntry(ctx context.Context, entry *Entry) (bool, error)
+	DeleteEntry(ctx context.Context, userID string, entryID int) (bool, error)
+	SaveReconciliation(ctx context.Context, entry *Entry) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const entryColumns = `id, user_id, kind, title, notes, starts_at, ends_at, status, matched_id, matched_at, created_at, updated_at`
+
+// ListEntries returns the user's entries starting in [from, to)
+func (r *repository) ListEntries(ctx context.Context, userID string, from, to time.Time) ([]Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM calendar_entries
+		WHERE user_id = $1 AND starts_at >= $2 AND starts_at < $3
+		ORDER BY starts_at, id`
+
+	entries := []Entry{}
+	err := r.db.SelectContext(ctx, &entries, query, userID, from, to)
+	return entries, err
+}
+
+// GetEntry returns one of the user's entries, or nil if there is no such entry
+func (r *repository) GetEntry(ctx context.Context, userID string, entryID int) (*Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM calendar_entries WHERE id = $1 AND user_id = $2`
+
+	var entry Entry
+	err := r.db.GetContext(ctx, &entry, query, entryID, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *repository) CreateEntry(ctx context.Context, entry *Entry) error {
+	query := `
+		INSERT INTO calendar_entries (user_id, kind, title, notes, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, matched_id, matched_at, created_at, updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, entry.UserID, entry.Kind, entry.Title, entry.Notes,
+		entry.StartsAt, entry.EndsAt).
+		Scan(&entry.ID, &entry.Status, &entry.MatchedID, &entry.MatchedAt, &entry.CreatedAt, &entry.UpdatedAt)
+}
+
+// UpdateEntry saves an edited entry, returning false if the user has no such
+// entry. The entry goes back to planned so the next reconciliation matches it
+// against its new time.
+func (r *repository) UpdateEntry(ctx context.Context, entry *Entry) (bool, error) {
+	query := `
+		UPDATE calendar_entries
+		SET kind = $3, title = $4, notes = $5, starts_at = $6, ends_at = $7,
+			status = 'planned', matched_id = '', matched_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING status, matched_id, matched_at, created_at, updated_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, entry.ID, entry.UserID, entry.Kind, entry.Title, entry.Notes,
+		entry.StartsAt, entry.EndsAt).
+		Scan(&entry.Status, &entry.MatchedID, &entry.MatchedAt, &entry.CreatedAt, &entry.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *repository) DeleteEntry(ctx context.Context, userID string, entryID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM calendar_entries WHERE id = $1 AND user_id = $2`, entryID, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// SaveReconciliation stores the status and match reconciliation found
+func (r *repository) SaveReconciliation(ctx context.Context, entry *Entry) error {
+	query := `
+		UPDATE calendar_entries
+		SET status = $3, matched_id = $4, matched_at = $5, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, entry.ID, entry.UserID, entry.Status, entry.MatchedID, entry.MatchedAt)
+	return err
+}
@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+const (
+	// reconcileDays is how far back planned entries are still reconciled
+	reconcileDays = 30
+	// recentVideosScanned is how many recent videos are searched for uploads
+	recentVideosScanned = 100
+)
+
+// Service reconciles planned entries with collected streams and videos
+type Service struct {
+	repo          Repository
+	analyticsRepo analytics.Repository
+}
+
+func NewService(repo Repository, analyticsRepo analytics.Repository) *Service {
+	return &Service{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+	}
+}
+
+// Reconcile marks the user's recent entries done or missed. It is hooked into
+// collection, so it only reads what has already been stored.
+func (s *Service) Reconcile(ctx context.Context, userID string) error {
+	now := time.Now().UTC()
+	from := now.AddDate(0, 0, -reconcileDays)
+
+	// Entries planned slightly ahead can already be fulfilled by an early start
+	entries, err := s.repo.ListEntries(ctx, userID, from, now.Add(uploadTolerance))
+	if err != nil {
+		return fmt.Errorf("failed to get calendar entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	streams, err := s.analyticsRepo.GetStreamSessionsByDateRange(ctx, userID, from.Add(-uploadTolerance), now)
+	if err != nil {
+		return fmt.Errorf("failed to get stream sessions: %w", err)
+	}
+	videos, err := s.analyticsRepo.GetVideoAnalytics(ctx, userID, recentVideosScanned)
+	if err != nil {
+		return fmt.Errorf("failed to get videos: %w", err)
+	}
+
+	changed := Reconcile(entries, streams, videos, now)
+	for i := range changed {
+		if err := s.repo.SaveReconciliation(ctx, &changed[i]); err != nil {
+			return fmt.Errorf("failed to save calendar entry %d: %w", changed[i].ID, err)
+		}
+	}
+	if len(changed) > 0 {
+		log.Printf("📅 Reconciled %d calendar entries for user %s", len(changed), userID)
+	}
+	return nil
+}
+
+// GetMonth returns the month view of the user's calendar
+func (s *Service) GetMonth(ctx context.Context, userID string, year int, month time.Month, loc *time.Location) (*Month, error) {
+	start, end := MonthBounds(year, month, loc)
+	entries, err := s.repo.ListEntries(ctx, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar entries: %w", err)
+	}
+	return BuildMonth(start, entries), nil
+}
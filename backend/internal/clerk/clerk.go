@@ -6,16 +6,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
 	clerk "github.com/clerk/clerk-sdk-go/v2"
 	"github.com/clerk/clerk-sdk-go/v2/jwt"
 	"github.com/clerk/clerk-sdk-go/v2/user"
 	"github.com/gofiber/fiber/v2"
 )
 
+// callTimeout bounds each call to Clerk unless the caller's context ends sooner
+const callTimeout = 10 * time.Second
+
+// httpClient makes the Clerk API calls the SDK doesn't cover. Requests are
+// bounded by their context, see callTimeout. Initialize replaces it with the
+// shared, traced client, which the SDK then uses as well.
+var httpClient = &http.Client{}
+
 // Helper function to extract user from verified claims (handles any return type)
 func extractUserFromVerifyResult(result interface{}) User {
 	user := User{}
@@ -55,6 +65,7 @@ func extractUserFromVerifyResult(result interface{}) User {
 			} else if lastName, ok := claimsMap["lastName"].(string); ok {
 				user.LastName = lastName
 			}
+			applyAuthorizationClaims(&user, claimsMap)
 		}
 	}
 
@@ -70,6 +81,13 @@ type User struct {
 	Email     string `json:"email,omitempty"`
 	FirstName string `json:"first_name,omitempty"`
 	LastName  string `json:"last_name,omitempty"`
+	// Role is the application-wide role from the custom "role" claim
+	Role string `json:"role,omitempty"`
+	// Active organization, role (e.g. "org:admin") and permissions
+	OrgID          string   `json:"org_id,omitempty"`
+	OrgSlug        string   `json:"org_slug,omitempty"`
+	OrgRole        string   `json:"org_role,omitempty"`
+	OrgPermissions []string `json:"org_permissions,omitempty"`
 }
 
 func Initialize() error {
@@ -78,6 +96,11 @@ func Initialize() error {
 		return errors.New("CLERK_SECRET_KEY environment variable not set")
 	}
 
+	httpClient = httpclient.New("clerk")
+	clerk.SetBackend(clerk.NewBackend(&clerk.BackendConfig{
+		HTTPClient: httpClient,
+		Key:        clerk.String(secretKey),
+	}))
 	return nil
 }
 
@@ -110,11 +133,16 @@ func AuthMiddleware() fiber.Handler {
 
 		token := parts[1]
 
-		// Always try manual JWT parsing first in production to avoid Clerk SDK issues
-		// This is more reliable for production deployment
-		user, err := parseJWTManually(token)
+		// Verify locally against the cached JWKS. The Clerk API is only asked when
+		// the signing key is unknown to us or the JWKS can't be loaded.
+		user, err := sharedJWKS.verify(c.UserContext(), token)
 		if err != nil {
-			return tryClerkVerification(c, token)
+			if errors.Is(err, errUnknownSigningKey) || errors.Is(err, errJWKSUnavailable) {
+				return tryClerkVerification(c, token)
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired token",
+			})
 		}
 		c.Locals("user", *user)
 		return c.Next()
@@ -129,6 +157,16 @@ func GetUserFromContext(c *fiber.Ctx) (*User, error) {
 	return &user, nil
 }
 
+// UserIDFromContext returns the authenticated user of a request context, such
+// as c.UserContext(), which resolves the request's Locals, or "" outside an
+// authenticated request
+func UserIDFromContext(ctx context.Context) string {
+	if user, ok := ctx.Value("user").(User); ok {
+		return user.ID
+	}
+	return ""
+}
+
 func GetUserByID(ctx context.Context, userID string) (*clerk.User, error) {
 	secretKey := os.Getenv("CLERK_SECRET_KEY")
 	if secretKey == "" {
@@ -137,6 +175,8 @@ func GetUserByID(ctx context.Context, userID string) (*clerk.User, error) {
 
 	clerk.SetKey(secretKey)
 
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
 	return user.Get(ctx, userID)
 }
 
@@ -150,47 +190,25 @@ func decodeJWTSegment(seg string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(seg)
 }
 
+// CheckSecretKey makes the cheapest authenticated API call, listing one user,
+// so a mistyped or revoked CLERK_SECRET_KEY shows before serving. Call
+// Initialize first.
+func CheckSecretKey(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+	_, err := user.List(ctx, &user.ListParams{ListParams: clerk.ListParams{Limit: clerk.Int64(1)}})
+	return err
+}
+
 func SyncUserData(ctx context.Context, userID string) error {
 	// TODO: Implement database sync logic - create/update user in database
 	_, err := GetUserByID(ctx, userID)
 	return err
 }
 
-// Parse JWT manually - more reliable for production than Clerk SDK
-func parseJWTManually(token string) (*User, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid JWT format - expected 3 parts, got %d", len(parts))
-	}
-
-	// Decode the payload (second part)
-	payload, err := decodeJWTSegment(parts[1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode JWT payload: %w", err)
-	}
-
-	// Parse the claims
-	var claims map[string]interface{}
-	if err := json.Unmarshal(payload, &claims); err != nil {
-		return nil, fmt.Errorf("failed to parse JWT claims: %w", err)
-	}
-
-	// Extract user ID
-	userID, ok := claims["sub"].(string)
-	if !ok || userID == "" {
-		return nil, fmt.Errorf("missing or invalid 'sub' claim in JWT")
-	}
-
-	// Basic JWT validation - check expiration
-	if exp, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
-			return nil, fmt.Errorf("JWT token has expired")
-		}
-	}
-
-	user := &User{ID: userID}
-
-	// Extract additional user info
+// userFromClaims reads the profile claims Clerk adds to session tokens
+func userFromClaims(claims map[string]interface{}) User {
+	user := User{}
 	if email, ok := claims["email"].(string); ok {
 		user.Email = email
 	}
@@ -204,13 +222,15 @@ func parseJWTManually(token string) (*User, error) {
 	} else if lastName, ok := claims["lastName"].(string); ok {
 		user.LastName = lastName
 	}
-
-	return user, nil
+	applyAuthorizationClaims(&user, claims)
+	return user
 }
 
 func tryClerkVerification(c *fiber.Ctx, token string) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), callTimeout)
+	defer cancel()
 
-	verifyResult, err := jwt.Verify(c.Context(), &jwt.VerifyParams{
+	verifyResult, err := jwt.Verify(ctx, &jwt.VerifyParams{
 		Token: token,
 	})
 
@@ -231,54 +251,3 @@ func tryClerkVerification(c *fiber.Ctx, token string) error {
 	c.Locals("user", user)
 	return c.Next()
 }
-
-// Helper function for manual JWT parsing (development only)
-func handleManualJWTParsing(c *fiber.Ctx, token string) error {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token format - expected JWT with 3 parts",
-		})
-	}
-
-	payload, err := decodeJWTSegment(parts[1])
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": fmt.Sprintf("Invalid token payload: %v", err),
-		})
-	}
-
-	var claims map[string]interface{}
-	if err := json.Unmarshal(payload, &claims); err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": fmt.Sprintf("Invalid token claims: %v", err),
-		})
-	}
-
-	userID, ok := claims["sub"].(string)
-	if !ok || userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid or missing user ID in token",
-		})
-	}
-
-	user := User{ID: userID}
-
-	// Extract user info from claims
-	if email, ok := claims["email"].(string); ok {
-		user.Email = email
-	}
-	if firstName, ok := claims["first_name"].(string); ok {
-		user.FirstName = firstName
-	} else if firstName, ok := claims["firstName"].(string); ok {
-		user.FirstName = firstName
-	}
-	if lastName, ok := claims["last_name"].(string); ok {
-		user.LastName = lastName
-	} else if lastName, ok := claims["lastName"].(string); ok {
-		user.LastName = lastName
-	}
-
-	c.Locals("user", user)
-	return c.Next()
-}
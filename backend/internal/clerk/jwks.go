@@ -0,0 +1,237 @@
+package clerk
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSURL = "https://api.clerk.com/v1/jwks"
+	// jwksRefreshInterval is how long fetched keys are trusted before a refetch
+	jwksRefreshInterval = time.Hour
+	// jwksMinRefetchInterval stops tokens with unknown key IDs from making us
+	// hammer the JWKS endpoint
+	jwksMinRefetchInterval = time.Minute
+	// jwtLeeway tolerates clock skew between Clerk and this server
+	jwtLeeway = 5 * time.Second
+)
+
+var (
+	// errUnknownSigningKey means the token's key ID is not in the JWKS, e.g.
+	// right after Clerk rotated keys
+	errUnknownSigningKey = errors.New("unknown JWT signing key")
+	// errJWKSUnavailable means no keys could be loaded to verify against
+	errJWKSUnavailable  = errors.New("JWKS unavailable")
+	errInvalidSignature = errors.New("invalid JWT signature")
+	errTokenExpired     = errors.New("JWT has expired")
+	errTokenNotYetValid = errors.New("JWT is not valid yet")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verifyJWT checks an RS256 token against keys and returns the user it was
+// issued for
+func verifyJWT(token string, keys map[string]*rsa.PublicKey, now time.Time) (*User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid JWT format - expected 3 parts, got %d", len(parts))
+	}
+
+	headerBytes, err := decodeJWTSegment(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT header: %w", err)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse JWT header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	key, ok := keys[header.Kid]
+	if !ok {
+		return nil, errUnknownSigningKey
+	}
+
+	signature, err := decodeJWTSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errInvalidSignature
+	}
+
+	payload, err := decodeJWTSegment(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT payload: %w", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse JWT claims: %w", err)
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("missing 'exp' claim in JWT")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return nil, errTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errTokenNotYetValid
+	}
+
+	userID, ok := claims["sub"].(string)
+	if !ok || userID == "" {
+		return nil, errors.New("missing or invalid 'sub' claim in JWT")
+	}
+
+	user := userFromClaims(claims)
+	user.ID = userID
+	return &user, nil
+}
+
+// jwksCache holds the Clerk instance's signing keys
+type jwksCache struct {
+	url        string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+var sharedJWKS = &jwksCache{
+	httpClient: httpClient,
+}
+
+// verify checks the token against cached keys, refetching them when they are
+// stale or the token names a key we haven't seen
+func (j *jwksCache) verify(ctx context.Context, token string) (*User, error) {
+	keys, err := j.currentKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := verifyJWT(token, keys, time.Now())
+	if !errors.Is(err, errUnknownSigningKey) {
+		return user, err
+	}
+
+	keys, err = j.currentKeys(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return verifyJWT(token, keys, time.Now())
+}
+
+func (j *jwksCache) currentKeys(ctx context.Context, forceRefresh bool) (map[string]*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	stale := time.Since(j.fetchedAt) > jwksRefreshInterval
+	if (!stale && !forceRefresh) || time.Since(j.lastAttempt) < jwksMinRefetchInterval {
+		if j.keys == nil {
+			return nil, errJWKSUnavailable
+		}
+		return j.keys, nil
+	}
+	j.lastAttempt = time.Now()
+
+	keys, err := j.fetch(ctx)
+	if err != nil {
+		if j.keys != nil {
+			// Keep serving the last good keys while Clerk is unreachable
+			return j.keys, nil
+		}
+		return nil, fmt.Errorf("%w: %v", errJWKSUnavailable, err)
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return keys, nil
+}
+
+func (j *jwksCache) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	url := j.url
+	if url == "" {
+		url = os.Getenv("CLERK_JWKS_URL")
+	}
+	if url == "" {
+		url = defaultJWKSURL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	if url == defaultJWKSURL {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv("CLERK_SECRET_KEY"))
+	}
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request failed with status code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(body.Keys))
+	for _, jwk := range body.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no RSA keys")
+	}
+	return keys, nil
+}
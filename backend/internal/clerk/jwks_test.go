@@ -0,0 +1,122 @@
+package clerk
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+	headerJSON, err := json.Marshal(header)
+	require.NoError(t, err)
+	claimsJSON, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forger, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := map[string]*rsa.PublicKey{"ins_1": &key.PublicKey}
+	now := time.Now()
+	header := map[string]any{"alg": "RS256", "kid": "ins_1"}
+	claims := map[string]any{"sub": "user_123", "email": "a@b.c", "exp": now.Add(time.Minute).Unix()}
+
+	user, err := verifyJWT(signTestJWT(t, key, header, claims), keys, now)
+	require.NoError(t, err)
+	assert.Equal(t, "user_123", user.ID)
+	assert.Equal(t, "a@b.c", user.Email)
+
+	// Signed by someone else under our key ID
+	_, err = verifyJWT(signTestJWT(t, forger, header, claims), keys, now)
+	assert.ErrorIs(t, err, errInvalidSignature)
+
+	// Claims swapped after signing
+	original := strings.Split(signTestJWT(t, key, header, claims), ".")
+	escalated := strings.Split(signTestJWT(t, key, header, map[string]any{"sub": "user_admin", "exp": now.Add(time.Minute).Unix()}), ".")
+	_, err = verifyJWT(original[0]+"."+escalated[1]+"."+original[2], keys, now)
+	assert.ErrorIs(t, err, errInvalidSignature)
+
+	expired := map[string]any{"sub": "user_123", "exp": now.Add(-time.Minute).Unix()}
+	_, err = verifyJWT(signTestJWT(t, key, header, expired), keys, now)
+	assert.ErrorIs(t, err, errTokenExpired)
+
+	notYet := map[string]any{"sub": "user_123", "exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Minute).Unix()}
+	_, err = verifyJWT(signTestJWT(t, key, header, notYet), keys, now)
+	assert.ErrorIs(t, err, errTokenNotYetValid)
+
+	_, err = verifyJWT(signTestJWT(t, key, map[string]any{"alg": "RS256", "kid": "ins_2"}, claims), keys, now)
+	assert.ErrorIs(t, err, errUnknownSigningKey)
+
+	_, err = verifyJWT(signTestJWT(t, key, map[string]any{"alg": "none", "kid": "ins_1"}, claims), keys, now)
+	assert.Error(t, err)
+}
+
+func TestJWKSCacheRefetchesOnUnknownKey(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	published := map[string]*rsa.PrivateKey{"ins_1": first}
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		var keys []map[string]string
+		for kid, key := range published {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+
+	cache := &jwksCache{url: srv.URL, httpClient: srv.Client()}
+	claims := map[string]any{"sub": "user_123", "exp": time.Now().Add(time.Minute).Unix()}
+	ctx := context.Background()
+
+	_, err = cache.verify(ctx, signTestJWT(t, first, map[string]any{"alg": "RS256", "kid": "ins_1"}, claims))
+	require.NoError(t, err)
+	_, err = cache.verify(ctx, signTestJWT(t, first, map[string]any{"alg": "RS256", "kid": "ins_1"}, claims))
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches, "keys should be cached")
+
+	// Clerk rotates keys; an unknown kid triggers one refetch once the backoff has passed
+	published["ins_2"] = second
+	cache.lastAttempt = time.Now().Add(-2 * jwksMinRefetchInterval)
+	user, err := cache.verify(ctx, signTestJWT(t, second, map[string]any{"alg": "RS256", "kid": "ins_2"}, claims))
+	require.NoError(t, err)
+	assert.Equal(t, "user_123", user.ID)
+	assert.Equal(t, 2, fetches)
+
+	// Unknown kids inside the backoff don't hit the endpoint
+	_, err = cache.verify(ctx, signTestJWT(t, second, map[string]any{"alg": "RS256", "kid": "ins_3"}, claims))
+	assert.ErrorIs(t, err, errUnknownSigningKey)
+	assert.Equal(t, 2, fetches)
+}
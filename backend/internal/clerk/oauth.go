@@ -20,6 +20,9 @@ func GetOAuthToken(ctx context.Context, userID, provider string) (string, error)
 
 	clerk.SetKey(secretKey)
 
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
 	user, err := GetUserByID(ctx, userID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get user: %w", err)
@@ -36,8 +39,7 @@ func GetOAuthToken(ctx context.Context, userID, provider string) (string, error)
 			req.Header.Add("Authorization", "Bearer "+secretKey)
 			req.Header.Add("Content-Type", "application/json")
 
-			client := &http.Client{}
-			resp, err := client.Do(req)
+			resp, err := httpClient.Do(req)
 			if err != nil {
 				return "", fmt.Errorf("failed to make request: %w", err)
 			}
@@ -0,0 +1,166 @@
+package clerk
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// orgRolePrefix is how Clerk namespaces organization roles and permissions
+const orgRolePrefix = "org:"
+
+// applyAuthorizationClaims copies the role claims of a session token onto user.
+// Organization claims come in two shapes: v1 tokens carry org_id, org_role and
+// org_permissions, v2 tokens an "o" object whose permissions are packed per
+// feature (see buildOrgPermissions). Role is a custom claim added through the
+// session token template, e.g. {"role": "{{user.public_metadata.role}}"}.
+func applyAuthorizationClaims(user *User, claims map[string]interface{}) {
+	if role, ok := claims["role"].(string); ok {
+		user.Role = role
+	}
+
+	if org, ok := claims["o"].(map[string]interface{}); ok {
+		user.OrgID, _ = org["id"].(string)
+		user.OrgSlug, _ = org["slg"].(string)
+		if role, ok := org["rol"].(string); ok && role != "" {
+			user.OrgRole = orgRolePrefix + role
+		}
+		features, _ := claims["fea"].(string)
+		permissions, _ := org["per"].(string)
+		featurePermissionMap, _ := org["fpm"].(string)
+		user.OrgPermissions = buildOrgPermissions(features, permissions, featurePermissionMap)
+		return
+	}
+
+	user.OrgID, _ = claims["org_id"].(string)
+	user.OrgSlug, _ = claims["org_slug"].(string)
+	user.OrgRole, _ = claims["org_role"].(string)
+	if permissions, ok := claims["org_permissions"].([]interface{}); ok {
+		for _, permission := range permissions {
+			if p, ok := permission.(string); ok {
+				user.OrgPermissions = append(user.OrgPermissions, p)
+			}
+		}
+	}
+}
+
+// buildOrgPermissions expands v2 claims into org:<feature>:<permission> names.
+// features lists "<scopes>:<feature>" entries (organization features have an
+// "o" scope), and the i-th organization feature grants the permissions whose
+// bits are set in the i-th entry of featurePermissionMap.
+func buildOrgPermissions(features, permissions, featurePermissionMap string) []string {
+	if features == "" || permissions == "" || featurePermissionMap == "" {
+		return nil
+	}
+
+	var orgFeatures []string
+	for _, entry := range strings.Split(features, ",") {
+		scopes, feature, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if ok && strings.Contains(scopes, "o") {
+			orgFeatures = append(orgFeatures, feature)
+		}
+	}
+
+	permissionNames := strings.Split(permissions, ",")
+	masks := strings.Split(featurePermissionMap, ",")
+
+	var result []string
+	for i, feature := range orgFeatures {
+		if i >= len(masks) {
+			break
+		}
+		mask, err := strconv.Atoi(strings.TrimSpace(masks[i]))
+		if err != nil {
+			continue
+		}
+		for j, permission := range permissionNames {
+			if mask&(1<<j) != 0 {
+				result = append(result, orgRolePrefix+feature+":"+strings.TrimSpace(permission))
+			}
+		}
+	}
+	return result
+}
+
+// HasRole reports whether the user has the application-wide role, which comes
+// from the token's custom "role" claim
+func (u *User) HasRole(role string) bool {
+	return role != "" && u.Role == role
+}
+
+// HasOrgRole reports whether the user holds the role in their active
+// organization. "admin" and "org:admin" are equivalent.
+func (u *User) HasOrgRole(role string) bool {
+	if u.OrgRole == "" {
+		return false
+	}
+	return strings.TrimPrefix(u.OrgRole, orgRolePrefix) == strings.TrimPrefix(role, orgRolePrefix)
+}
+
+// HasOrgPermission reports whether the active organization grants the
+// permission, e.g. "org:analytics:read"
+func (u *User) HasOrgPermission(permission string) bool {
+	for _, granted := range u.OrgPermissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole only lets users with one of the application-wide roles through.
+// Organization roles don't count, since anyone can create an organization and
+// be its admin. It must run after AuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return requireUser("role", func(user *User) bool {
+		for _, role := range roles {
+			if user.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequireOrgRole only lets users holding one of the roles in their active
+// organization through. It must run after AuthMiddleware.
+func RequireOrgRole(roles ...string) fiber.Handler {
+	return requireUser("organization role", func(user *User) bool {
+		for _, role := range roles {
+			if user.HasOrgRole(role) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequireOrgPermission only lets users whose active organization grants the
+// permission through. It must run after AuthMiddleware.
+func RequireOrgPermission(permission string) fiber.Handler {
+	return requireUser("organization permission", func(user *User) bool {
+		return user.HasOrgPermission(permission)
+	})
+}
+
+func requireUser(what string, allowed func(*User) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := GetUserFromContext(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not authenticated",
+			})
+		}
+
+		if !allowed(user) {
+			log.Printf("⛔ User %s lacks the required %s for %s", user.ID, what, c.Path())
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Insufficient permissions",
+			})
+		}
+
+		return c.Next()
+	}
+}
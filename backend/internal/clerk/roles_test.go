@@ -0,0 +1,75 @@
+package clerk
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationClaimsV1(t *testing.T) {
+	user := userFromClaims(map[string]interface{}{
+		"role":            "admin",
+		"org_id":          "org_1",
+		"org_slug":        "team",
+		"org_role":        "org:admin",
+		"org_permissions": []interface{}{"org:analytics:read", "org:sys_memberships:manage"},
+	})
+
+	assert.Equal(t, "org_1", user.OrgID)
+	assert.Equal(t, "team", user.OrgSlug)
+	assert.True(t, user.HasRole("admin"))
+	assert.True(t, user.HasOrgRole("admin"))
+	assert.True(t, user.HasOrgRole("org:admin"))
+	assert.False(t, user.HasOrgRole("member"))
+	assert.True(t, user.HasOrgPermission("org:analytics:read"))
+	assert.False(t, user.HasOrgPermission("org:analytics:manage"))
+}
+
+func TestAuthorizationClaimsV2(t *testing.T) {
+	user := userFromClaims(map[string]interface{}{
+		"v":   float64(2),
+		"fea": "o:analytics,u:billing,o:memberships",
+		"o": map[string]interface{}{
+			"id":  "org_1",
+			"slg": "team",
+			"rol": "member",
+			"per": "read,manage",
+			"fpm": "1,3",
+		},
+	})
+
+	assert.Equal(t, "org:member", user.OrgRole)
+	assert.Equal(t, []string{
+		"org:analytics:read",
+		"org:memberships:read",
+		"org:memberships:manage",
+	}, user.OrgPermissions)
+	assert.False(t, user.HasRole("admin"))
+}
+
+func TestRequireRole(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		user := User{ID: "user_1", Role: c.Get("X-Test-Role"), OrgRole: "org:admin"}
+		c.Locals("user", user)
+		return c.Next()
+	})
+	app.Get("/admin", RequireRole("admin"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for role, expected := range map[string]int{
+		"admin": http.StatusOK,
+		"":      http.StatusForbidden, // being an organization admin is not enough
+	} {
+		req, err := http.NewRequest("GET", "/admin", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Test-Role", role)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp.StatusCode, "role %q", role)
+	}
+}
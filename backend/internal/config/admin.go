@@ -0,0 +1,24 @@
+package config
+
+// AdminConfig lists who may use the /api/admin endpoints
+type AdminConfig struct {
+	// UserIDs are Clerk user IDs with admin access
+	UserIDs []string
+}
+
+// IsAdmin reports whether the Clerk user is an admin
+func (c AdminConfig) IsAdmin(userID string) bool {
+	for _, id := range c.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Admin returns the admin configuration
+func Admin() AdminConfig {
+	return AdminConfig{
+		UserIDs: List("ADMIN_USER_IDS", nil),
+	}
+}
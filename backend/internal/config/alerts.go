@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// AlertsConfig controls how triggered alerts are pushed outside the app
+type AlertsConfig struct {
+	// WebhookTimeout bounds each webhook delivery
+	WebhookTimeout time.Duration
+	// EmailFrom is the sender of alert emails (sent through Resend)
+	EmailFrom string
+}
+
+// Alerts returns the alerts configuration
+func Alerts() AlertsConfig {
+	return AlertsConfig{
+		WebhookTimeout: Duration("ALERTS_WEBHOOK_TIMEOUT", 10*time.Second),
+		EmailFrom:      String("ALERTS_EMAIL_FROM", "alerts@creatorsync.app"),
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import "time"
+
+// AnalyticsCacheConfig controls the in-memory cache of dashboard payloads
+// (overview, enhanced analytics and charts)
+type AnalyticsCacheConfig struct {
+	// TTL is how long a computed payload is served, 0 disables the cache.
+	// Collecting a user's data evicts their payloads on the collecting replica.
+	TTL time.Duration
+	// MaxEntries bounds the number of cached payloads of each kind
+	MaxEntries int
+	// WarmUp recomputes a user's payloads right after their data is collected
+	WarmUp bool
+	// WarmUpWorkers is how many users an admin-triggered warm-up computes at once
+	WarmUpWorkers int
+	// MaxInFlightPerUser bounds the distinct payloads computed at once for one
+	// user. Identical requests share a computation and don't count twice.
+	MaxInFlightPerUser int
+}
+
+// AnalyticsCache returns the dashboard payload cache configuration
+func AnalyticsCache() AnalyticsCacheConfig {
+	return AnalyticsCacheConfig{
+		TTL:                Duration("ANALYTICS_CACHE_TTL", 30*time.Minute),
+		MaxEntries:         Int("ANALYTICS_CACHE_MAX_ENTRIES", 10000),
+		WarmUp:             Bool("ANALYTICS_CACHE_WARMUP", true),
+		WarmUpWorkers:      Int("ANALYTICS_CACHE_WARMUP_WORKERS", 4),
+		MaxInFlightPerUser: Int("ANALYTICS_MAX_IN_FLIGHT_PER_USER", 4),
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// BackgroundConfig controls how failed background services are restarted
+type BackgroundConfig struct {
+	// MinBackoff is the wait before the first restart, doubled on every
+	// failure up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Background returns the background service configuration
+func Background() BackgroundConfig {
+	return BackgroundConfig{
+		MinBackoff: Duration("BACKGROUND_RESTART_MIN_BACKOFF", time.Second),
+		MaxBackoff: Duration("BACKGROUND_RESTART_MAX_BACKOFF", 5*time.Minute),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+// BillingConfig controls paid plans through Stripe. Without it every user has
+// every feature, e.g. on self-hosted installs.
+type BillingConfig struct {
+	StripeSecretKey     string
+	StripeWebhookSecret string
+	// ProPriceID is the Stripe price of the pro plan's subscription
+	ProPriceID string
+}
+
+// Billing returns the billing configuration
+func Billing() BillingConfig {
+	return BillingConfig{
+		StripeSecretKey:     String("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret: String("STRIPE_WEBHOOK_SECRET", ""),
+		ProPriceID:          String("STRIPE_PRO_PRICE_ID", ""),
+	}
+}
+
+// Enabled reports whether plans are enforced and can be bought
+func (c BillingConfig) Enabled() bool {
+	return c.StripeSecretKey != "" && c.StripeWebhookSecret != "" && c.ProPriceID != ""
+}
@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+// CollectionConfig holds the server-side defaults and limits for analytics collection
+type CollectionConfig struct {
+	// MaxVideos is the default number of videos fetched per collection
+	MaxVideos int
+	// MaxVideosLimit caps what a request may ask for
+	MaxVideosLimit int
+	// IncludeClips controls whether clips are collected by default
+	IncludeClips bool
+	// MaxClips is the number of clips fetched when clips are included
+	MaxClips int
+	// VideoTypes are the Twitch video types fetched by default
+	VideoTypes []string
+	// ChannelTimeout and VideoTimeout bound the channel and video parts of a
+	// user's collection, which run side by side
+	ChannelTimeout time.Duration
+	VideoTimeout   time.Duration
+	// ManualDailyLimit is how many manual collections a user may run per UTC
+	// day, 0 for no limit. Scheduled collections don't count.
+	ManualDailyLimit int
+}
+
+// Collection returns the collection configuration
+func Collection() CollectionConfig {
+	return CollectionConfig{
+		MaxVideos:        Int("COLLECTION_MAX_VIDEOS", 500),
+		MaxVideosLimit:   Int("COLLECTION_MAX_VIDEOS_LIMIT", 2000),
+		IncludeClips:     Bool("COLLECTION_INCLUDE_CLIPS", true),
+		MaxClips:         Int("COLLECTION_MAX_CLIPS", 100),
+		VideoTypes:       List("COLLECTION_VIDEO_TYPES", []string{"archive", "highlight", "upload"}),
+		ChannelTimeout:   Duration("COLLECTION_CHANNEL_TIMEOUT", 2*time.Minute),
+		VideoTimeout:     Duration("COLLECTION_VIDEO_TIMEOUT", 10*time.Minute),
+		ManualDailyLimit: Int("COLLECTION_MANUAL_DAILY_LIMIT", 10),
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "strings"
+
+// Compression levels for HTTP_COMPRESSION_LEVEL
+const (
+	CompressionSpeed   = "speed"
+	CompressionDefault = "default"
+	CompressionBest    = "best"
+)
+
+// CompressionConfig controls gzip/brotli compression of API responses
+type CompressionConfig struct {
+	Enabled bool
+	// Level is "speed", "default" or "best"
+	Level string
+}
+
+// Compression returns the response compression configuration
+func Compression() CompressionConfig {
+	return CompressionConfig{
+		Enabled: Bool("HTTP_COMPRESSION", true),
+		Level:   strings.ToLower(String("HTTP_COMPRESSION_LEVEL", CompressionDefault)),
+	}
+}
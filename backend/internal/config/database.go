@@ -0,0 +1,16 @@
+package config
+
+// DatabaseConfig controls how the API uses Postgres
+type DatabaseConfig struct {
+	// RowLevelSecurity scopes each request's queries to the rows of its user
+	// with the policies of migration 034, on top of the user_id filters of the
+	// repositories. It has no effect for superusers and BYPASSRLS roles.
+	RowLevelSecurity bool
+}
+
+// Database returns the database configuration
+func Database() DatabaseConfig {
+	return DatabaseConfig{
+		RowLevelSecurity: Bool("DB_ROW_LEVEL_SECURITY", false),
+	}
+}
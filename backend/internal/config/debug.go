@@ -0,0 +1,24 @@
+package config
+
+// debugTokenMinLength keeps guessable tokens from exposing the profiles
+const debugTokenMinLength = 32
+
+// DebugConfig protects /debug/pprof and /debug/runtime. They are only mounted
+// when a token is set, e.g. in staging while investigating a regression.
+type DebugConfig struct {
+	// Token is sent as "Authorization: Bearer <token>", at least 32
+	// characters, e.g. from openssl rand -hex 32
+	Token string
+}
+
+// Enabled reports whether the debug endpoints are mounted
+func (c DebugConfig) Enabled() bool {
+	return len(c.Token) >= debugTokenMinLength
+}
+
+// Debug returns the debug endpoint configuration
+func Debug() DebugConfig {
+	return DebugConfig{
+		Token: String("DEBUG_TOKEN", ""),
+	}
+}
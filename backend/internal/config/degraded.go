@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// DegradedConfig controls serving cached analytics while the database is down
+type DegradedConfig struct {
+	// Enabled keeps each user's last successful analytics responses in memory
+	Enabled bool
+	// CacheTTL is how old a cached response may be and still be served
+	CacheTTL time.Duration
+	// MaxEntries bounds the number of cached responses
+	MaxEntries int
+}
+
+// Degraded returns the degraded mode configuration
+func Degraded() DegradedConfig {
+	return DegradedConfig{
+		Enabled:    Bool("DEGRADED_MODE", true),
+		CacheTTL:   Duration("DEGRADED_CACHE_TTL", 24*time.Hour),
+		MaxEntries: Int("DEGRADED_CACHE_MAX_ENTRIES", 5000),
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// DisconnectConfig controls what happens to a user's analytics when they
+// disconnect Twitch
+type DisconnectConfig struct {
+	// RestoreWindow is how long archived analytics can be restored by
+	// reconnecting the same Twitch account before they are purged
+	RestoreWindow time.Duration
+}
+
+// Disconnect returns the disconnect configuration
+func Disconnect() DisconnectConfig {
+	return DisconnectConfig{
+		RestoreWindow: Duration("DISCONNECT_RESTORE_WINDOW", 30*24*time.Hour),
+	}
+}
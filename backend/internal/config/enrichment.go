@@ -0,0 +1,16 @@
+package config
+
+// EnrichmentConfig controls the title enrichment stage that runs after video collection
+type EnrichmentConfig struct {
+	Enabled bool
+	// Sentiment adds a lexicon-based sentiment score (English titles only)
+	Sentiment bool
+}
+
+// Enrichment returns the enrichment configuration
+func Enrichment() EnrichmentConfig {
+	return EnrichmentConfig{
+		Enabled:   Bool("ENRICHMENT_ENABLED", true),
+		Sentiment: Bool("ENRICHMENT_SENTIMENT", false),
+	}
+}
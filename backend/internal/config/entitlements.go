@@ -0,0 +1,40 @@
+package config
+
+import (
+	"log"
+	"strings"
+)
+
+// EntitlementsConfig holds trials and the feature flags applied on top of
+// each user's plan
+type EntitlementsConfig struct {
+	// TrialDays is the length of the pro trial users can start once, 0 turns
+	// trials off
+	TrialDays int
+	// DisabledFeatures are off for everyone, whatever their plan
+	DisabledFeatures []string
+	// Grants are features given to users whatever their plan, e.g. partners,
+	// by user ID
+	Grants map[string][]string
+}
+
+// Entitlements returns the entitlements configuration. FEATURE_GRANTS holds
+// comma separated user_id:feature pairs.
+func Entitlements() EntitlementsConfig {
+	grants := make(map[string][]string)
+	for _, pair := range List("FEATURE_GRANTS", nil) {
+		userID, feature, ok := strings.Cut(pair, ":")
+		userID, feature = strings.TrimSpace(userID), strings.TrimSpace(feature)
+		if !ok || userID == "" || feature == "" {
+			log.Printf("Ignoring malformed entry in FEATURE_GRANTS (expected user_id:feature)")
+			continue
+		}
+		grants[userID] = append(grants[userID], feature)
+	}
+
+	return EntitlementsConfig{
+		TrialDays:        Int("BILLING_TRIAL_DAYS", 14),
+		DisabledFeatures: List("FEATURES_DISABLED", nil),
+		Grants:           grants,
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+// EventSubConfig configures Twitch EventSub webhooks
+type EventSubConfig struct {
+	// CallbackURL is the public URL of /api/webhooks/twitch/eventsub
+	CallbackURL string
+	// Secret signs webhook deliveries (10-100 characters)
+	Secret string
+}
+
+// Enabled reports whether webhooks can be subscribed to
+func (c EventSubConfig) Enabled() bool {
+	return c.CallbackURL != "" && len(c.Secret) >= 10 && len(c.Secret) <= 100
+}
+
+// EventSub returns the EventSub configuration
+func EventSub() EventSubConfig {
+	return EventSubConfig{
+		CallbackURL: String("TWITCH_EVENTSUB_CALLBACK_URL", ""),
+		Secret:      String("TWITCH_EVENTSUB_SECRET", ""),
+	}
+}
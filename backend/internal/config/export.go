@@ -0,0 +1,31 @@
+package config
+
+// ExportConfig configures the optional object-storage sink for analytics snapshots.
+// Any S3-compatible endpoint works, including GCS through its XML API with HMAC keys
+// (EXPORT_ENDPOINT=https://storage.googleapis.com, EXPORT_REGION=auto).
+type ExportConfig struct {
+	Enabled         bool
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket as endpoint/bucket/key instead of bucket.endpoint/key
+	PathStyle bool
+}
+
+// Export returns the export sink configuration
+func Export() ExportConfig {
+	region := String("EXPORT_REGION", "us-east-1")
+	return ExportConfig{
+		Enabled:         Bool("EXPORT_ENABLED", false),
+		Endpoint:        String("EXPORT_ENDPOINT", "https://s3."+region+".amazonaws.com"),
+		Region:          region,
+		Bucket:          String("EXPORT_BUCKET", ""),
+		Prefix:          String("EXPORT_PREFIX", "creatorsync"),
+		AccessKeyID:     String("EXPORT_ACCESS_KEY_ID", ""),
+		SecretAccessKey: String("EXPORT_SECRET_ACCESS_KEY", ""),
+		PathStyle:       Bool("EXPORT_PATH_STYLE", true),
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// FrontendConfig lists the frontends using the API and the Twitch callbacks it
+// accepts, so preview deployments can complete the Twitch connect flow
+type FrontendConfig struct {
+	// URL is the frontend users return to unless the connect flow started on
+	// another allowed frontend
+	URL string
+	// Origins may call the API and be returned to after connecting Twitch.
+	// "https://*.example.com" allows any single subdomain, e.g. preview branches.
+	Origins []string
+	// TwitchRedirectURIs are the callbacks registered with Twitch, the first
+	// is the default
+	TwitchRedirectURIs []string
+}
+
+// Frontend returns the frontend configuration. The built-in origins of APP_ENV
+// and FRONTEND_URL are always allowed.
+func Frontend() FrontendConfig {
+	cfg := FrontendConfig{
+		URL:                strings.TrimSuffix(String("FRONTEND_URL", "http://localhost:3000"), "/"),
+		TwitchRedirectURIs: List("TWITCH_REDIRECT_URI", nil),
+	}
+
+	switch os.Getenv("APP_ENV") {
+	case "production":
+		cfg.Origins = []string{"https://creatorsync.app", "https://www.creatorsync.app"}
+	case "staging":
+		cfg.Origins = []string{"https://dev.creatorsync.app"}
+	default:
+		cfg.Origins = []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:5174"}
+	}
+	if origin, ok := originOf(cfg.URL); ok && !slices.Contains(cfg.Origins, origin) {
+		cfg.Origins = append(cfg.Origins, origin)
+	}
+	cfg.Origins = append(cfg.Origins, List("FRONTEND_ORIGINS", nil)...)
+	return cfg
+}
+
+// FrontendOrigin returns the origin of requested, e.g. a preview deployment's
+// URL, when it is an allowed frontend. An empty requested is URL.
+func (c FrontendConfig) FrontendOrigin(requested string) (string, bool) {
+	if requested == "" {
+		return c.URL, true
+	}
+
+	origin, ok := originOf(requested)
+	if !ok {
+		return "", false
+	}
+	for _, allowed := range c.Origins {
+		if originMatches(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// AllowsOrigin reports whether a browser on origin may call the API
+func (c FrontendConfig) AllowsOrigin(origin string) bool {
+	resolved, ok := c.FrontendOrigin(origin)
+	return ok && origin != "" && resolved == origin
+}
+
+// TwitchRedirectURI picks the callback Twitch sends the user back to.
+// requested, when set, must be one of TwitchRedirectURIs. Otherwise the one on
+// base, the URL the API was reached on, is preferred over the default.
+func (c FrontendConfig) TwitchRedirectURI(requested, base string) (string, bool) {
+	return pickRedirectURI(c.TwitchRedirectURIs, requested, base)
+}
+
+// AllowsTwitchRedirectURI reports whether uri is a configured callback
+func (c FrontendConfig) AllowsTwitchRedirectURI(uri string) bool {
+	return slices.Contains(c.TwitchRedirectURIs, uri)
+}
+
+// pickRedirectURI picks one of the registered callbacks uris, see
+// FrontendConfig.TwitchRedirectURI
+func pickRedirectURI(uris []string, requested, base string) (string, bool) {
+	if len(uris) == 0 {
+		return "", false
+	}
+	if requested != "" {
+		return requested, slices.Contains(uris, requested)
+	}
+
+	if origin, ok := originOf(base); ok {
+		for _, uri := range uris {
+			if uriOrigin, _ := originOf(uri); uriOrigin == origin {
+				return uri, true
+			}
+		}
+	}
+	return uris[0], true
+}
+
+// originOf returns the scheme and host of an absolute http(s) URL
+func originOf(rawURL string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", false
+	}
+	return parsed.Scheme + "://" + strings.ToLower(parsed.Host), true
+}
+
+// originMatches matches origin against an allowed origin, which may start
+// with a single-label wildcard
+func originMatches(allowed, origin string) bool {
+	allowed = strings.ToLower(strings.TrimSuffix(allowed, "/"))
+	scheme, host, ok := strings.Cut(allowed, "://*.")
+	if !ok {
+		return allowed == origin
+	}
+
+	prefix := scheme + "://"
+	if !strings.HasPrefix(origin, prefix) {
+		return false
+	}
+	label, found := strings.CutSuffix(strings.TrimPrefix(origin, prefix), "."+host)
+	return found && label != "" && !strings.ContainsAny(label, ".:/")
+}
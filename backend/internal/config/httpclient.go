@@ -0,0 +1,31 @@
+package config
+
+import "time"
+
+// HTTPClientConfig controls outbound calls to third-party APIs such as Twitch
+// and Resend
+type HTTPClientConfig struct {
+	// MaxAttempts includes the first try. Only requests that are safe to
+	// repeat are retried.
+	MaxAttempts int
+	// RetryMinBackoff is the wait before the first retry, doubled after each
+	// one up to RetryMaxBackoff
+	RetryMinBackoff time.Duration
+	RetryMaxBackoff time.Duration
+	// MaxIdleConnsPerHost is how many connections to one API are kept open
+	MaxIdleConnsPerHost int
+}
+
+// HTTPClient returns the outbound HTTP client configuration
+func HTTPClient() HTTPClientConfig {
+	cfg := HTTPClientConfig{
+		MaxAttempts:         Int("HTTP_CLIENT_MAX_ATTEMPTS", 3),
+		RetryMinBackoff:     Duration("HTTP_CLIENT_RETRY_MIN_BACKOFF", 200*time.Millisecond),
+		RetryMaxBackoff:     Duration("HTTP_CLIENT_RETRY_MAX_BACKOFF", 5*time.Second),
+		MaxIdleConnsPerHost: Int("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", 20),
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return cfg
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// IdempotencyConfig controls replaying POST responses for a repeated Idempotency-Key
+type IdempotencyConfig struct {
+	// Enabled keeps the responses of requests sent with an Idempotency-Key
+	Enabled bool
+	// TTL is how long a key's response is replayed
+	TTL time.Duration
+	// MaxEntries bounds the number of kept responses
+	MaxEntries int
+}
+
+// Idempotency returns the Idempotency-Key configuration
+func Idempotency() IdempotencyConfig {
+	return IdempotencyConfig{
+		Enabled:    Bool("IDEMPOTENCY_ENABLED", true),
+		TTL:        Duration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		MaxEntries: Int("IDEMPOTENCY_MAX_ENTRIES", 10000),
+	}
+}
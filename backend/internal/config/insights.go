@@ -0,0 +1,43 @@
+package config
+
+import "time"
+
+// Insights providers
+const (
+	InsightsProviderNone      = "none"
+	InsightsProviderOpenAI    = "openai"
+	InsightsProviderAnthropic = "anthropic"
+)
+
+// InsightsConfig controls the optional weekly natural-language insights
+type InsightsConfig struct {
+	// Provider is none (disabled), openai or anthropic
+	Provider string
+	// Model overrides the provider's default model
+	Model   string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// Enabled reports whether a provider is configured
+func (c InsightsConfig) Enabled() bool {
+	return c.Provider != "" && c.Provider != InsightsProviderNone
+}
+
+// Insights returns the insights configuration. The API key is read from the
+// variable matching the provider.
+func Insights() InsightsConfig {
+	cfg := InsightsConfig{
+		Provider: String("INSIGHTS_PROVIDER", InsightsProviderNone),
+		Model:    String("INSIGHTS_MODEL", ""),
+		Timeout:  Duration("INSIGHTS_TIMEOUT", 60*time.Second),
+	}
+
+	switch cfg.Provider {
+	case InsightsProviderOpenAI:
+		cfg.APIKey = String("OPENAI_API_KEY", "")
+	case InsightsProviderAnthropic:
+		cfg.APIKey = String("ANTHROPIC_API_KEY", "")
+	}
+	return cfg
+}
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// MaintenanceConfig is the maintenance mode the server starts in. Admins can
+// change it at runtime through /api/admin/maintenance.
+type MaintenanceConfig struct {
+	// ReadOnly rejects write requests with 503 and pauses collections
+	ReadOnly bool
+	// PauseCollections stops scheduled and triggered collections while the API
+	// keeps accepting writes
+	PauseCollections bool
+	// Message is shown to clients while read-only
+	Message string
+	// RetryAfter is sent in the Retry-After header of rejected requests
+	RetryAfter time.Duration
+}
+
+// Maintenance returns the maintenance mode configuration
+func Maintenance() MaintenanceConfig {
+	return MaintenanceConfig{
+		ReadOnly:         Bool("MAINTENANCE_READ_ONLY", false),
+		PauseCollections: Bool("MAINTENANCE_PAUSE_COLLECTIONS", false),
+		Message:          String("MAINTENANCE_MESSAGE", ""),
+		RetryAfter:       Duration("MAINTENANCE_RETRY_AFTER", 5*time.Minute),
+	}
+}
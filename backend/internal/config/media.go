@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Thumbnail storage backends
+const (
+	ThumbnailStorageDisk   = "disk"
+	ThumbnailStorageObject = "object"
+)
+
+// MediaConfig controls the thumbnail proxy
+type MediaConfig struct {
+	// ThumbnailStorage is "disk" or "object" (the EXPORT_* bucket under a thumbnails/ prefix)
+	ThumbnailStorage string
+	// ThumbnailDir is where resized thumbnails are written with disk storage
+	ThumbnailDir string
+	// ThumbnailMaxAge is the Cache-Control max-age sent to browsers and CDNs
+	ThumbnailMaxAge time.Duration
+}
+
+// Media returns the thumbnail proxy configuration
+func Media() MediaConfig {
+	return MediaConfig{
+		ThumbnailStorage: String("THUMBNAIL_STORAGE", ThumbnailStorageDisk),
+		ThumbnailDir:     String("THUMBNAIL_CACHE_DIR", filepath.Join(os.TempDir(), "creatorsync-thumbnails")),
+		ThumbnailMaxAge:  Duration("THUMBNAIL_MAX_AGE", 7*24*time.Hour),
+	}
+}
+
+// ClipRenderConfig controls the worker downloading clips and transcoding them
+// to vertical video. Renders are kept in the EXPORT_* bucket under a renders/
+// prefix.
+type ClipRenderConfig struct {
+	Enabled bool
+	// FFmpegPath is the ffmpeg binary used for vertical presets
+	FFmpegPath string
+	// X264Preset and CRF trade encoding speed and file size for quality
+	X264Preset string
+	CRF        int
+	// PollInterval is how often the worker looks for queued renders
+	PollInterval time.Duration
+	// JobTimeout bounds downloading, transcoding and storing one clip. Renders
+	// running for longer, e.g. on a replica that went away, are queued again.
+	JobTimeout time.Duration
+	// MaxAttempts is how often a render is tried before it fails for good
+	MaxAttempts int
+	// MaxClipBytes bounds the size of a downloaded clip
+	MaxClipBytes int64
+	// MaxQueued bounds the unfinished renders of one user
+	MaxQueued int
+	// URLExpiry is how long signed download URLs stay valid
+	URLExpiry time.Duration
+}
+
+// ClipRenders returns the clip render configuration
+func ClipRenders() ClipRenderConfig {
+	cfg := ClipRenderConfig{
+		Enabled:      Bool("CLIP_RENDERS_ENABLED", false),
+		FFmpegPath:   String("FFMPEG_PATH", "ffmpeg"),
+		X264Preset:   String("CLIP_RENDER_X264_PRESET", "veryfast"),
+		CRF:          Int("CLIP_RENDER_CRF", 23),
+		PollInterval: Duration("CLIP_RENDER_POLL_INTERVAL", 15*time.Second),
+		JobTimeout:   Duration("CLIP_RENDER_JOB_TIMEOUT", 10*time.Minute),
+		MaxAttempts:  Int("CLIP_RENDER_MAX_ATTEMPTS", 3),
+		MaxClipBytes: int64(Int("CLIP_RENDER_MAX_CLIP_MB", 256)) << 20,
+		MaxQueued:    Int("CLIP_RENDER_MAX_QUEUED", 20),
+		URLExpiry:    Duration("CLIP_RENDER_URL_EXPIRY", time.Hour),
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 15 * time.Second
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = 10 * time.Minute
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return cfg
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// MigrationConfig controls how the server treats the database schema on boot
+type MigrationConfig struct {
+	// AutoMigrate applies pending migrations before serving
+	AutoMigrate bool
+	// Dir overrides the migrations built into the binary with the NNN_name.sql
+	// files in a directory, for development
+	Dir string
+	// LockTimeout bounds how long a replica waits for another replica's migrations
+	LockTimeout time.Duration
+	// SchemaCheck refuses to serve while migrations are pending
+	SchemaCheck bool
+}
+
+// Migrations returns the migration configuration
+func Migrations() MigrationConfig {
+	return MigrationConfig{
+		AutoMigrate: Bool("AUTO_MIGRATE", false),
+		Dir:         String("MIGRATIONS_DIR", ""),
+		LockTimeout: Duration("MIGRATION_LOCK_TIMEOUT", 5*time.Minute),
+		SchemaCheck: Bool("MIGRATION_SCHEMA_CHECK", true),
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// MoneyConfig controls currency conversion of revenue figures
+type MoneyConfig struct {
+	// RatesURL returns the latest exchange rates as JSON
+	// ({"base_code" or "base": "USD", "rates": {"EUR": 0.92, ...}})
+	RatesURL string
+	// RatesTTL is how long fetched rates are reused
+	RatesTTL     time.Duration
+	RatesTimeout time.Duration
+}
+
+// Money returns the currency conversion configuration
+func Money() MoneyConfig {
+	return MoneyConfig{
+		RatesURL:     String("MONEY_RATES_URL", "https://open.er-api.com/v6/latest/USD"),
+		RatesTTL:     Duration("MONEY_RATES_TTL", 12*time.Hour),
+		RatesTimeout: Duration("MONEY_RATES_TIMEOUT", 10*time.Second),
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// OverlayConfig controls the public overlay endpoint
+type OverlayConfig struct {
+	// CacheTTL is how long an overlay payload is served from memory
+	CacheTTL time.Duration
+	// LiveCounts fetches follower/subscriber totals from Twitch instead of the daily snapshot
+	LiveCounts bool
+}
+
+// Overlay returns the overlay configuration
+func Overlay() OverlayConfig {
+	return OverlayConfig{
+		CacheTTL:   Duration("OVERLAY_CACHE_TTL", 15*time.Second),
+		LiveCounts: Bool("OVERLAY_LIVE_COUNTS", true),
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import "slices"
+
+// PatreonConfig controls the Patreon integration, which adds membership income
+// to revenue analytics
+type PatreonConfig struct {
+	ClientID     string
+	ClientSecret string
+	// RedirectURIs are the callbacks registered with Patreon, the first is
+	// the default, see FrontendConfig.TwitchRedirectURI
+	RedirectURIs []string
+	// CreatorShare is the share of pledges creators keep after Patreon's
+	// platform and payment processing fees (0-1)
+	CreatorShare float64
+	// MaxMemberPages bounds how many pages of members, 1000 each, are read
+	// per collection
+	MaxMemberPages int
+}
+
+// Patreon returns the Patreon configuration
+func Patreon() PatreonConfig {
+	cfg := PatreonConfig{
+		ClientID:       String("PATREON_CLIENT_ID", ""),
+		ClientSecret:   String("PATREON_CLIENT_SECRET", ""),
+		RedirectURIs:   List("PATREON_REDIRECT_URI", nil),
+		CreatorShare:   Float("PATREON_CREATOR_SHARE", 0.88),
+		MaxMemberPages: Int("PATREON_MAX_MEMBER_PAGES", 20),
+	}
+	if cfg.CreatorShare < 0 || cfg.CreatorShare > 1 {
+		cfg.CreatorShare = 0.88
+	}
+	if cfg.MaxMemberPages < 1 {
+		cfg.MaxMemberPages = 1
+	}
+	return cfg
+}
+
+// Enabled reports whether Patreon can be connected
+func (c PatreonConfig) Enabled() bool {
+	return c.ClientID != "" && c.ClientSecret != "" && len(c.RedirectURIs) > 0
+}
+
+// RedirectURI picks the callback Patreon sends the user back to, like
+// FrontendConfig.TwitchRedirectURI
+func (c PatreonConfig) RedirectURI(requested, base string) (string, bool) {
+	return pickRedirectURI(c.RedirectURIs, requested, base)
+}
+
+// AllowsRedirectURI reports whether uri is a configured callback
+func (c PatreonConfig) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// PublicProfileConfig controls the public creator stats pages
+type PublicProfileConfig struct {
+	// CacheTTL is how long a public summary is served from memory and by browsers/CDNs
+	CacheTTL time.Duration
+	// TopClips is how many clips the summary lists
+	TopClips int
+	// SparklineDays is how many days of follower history the sparkline covers
+	SparklineDays int
+}
+
+// PublicProfile returns the public profile configuration
+func PublicProfile() PublicProfileConfig {
+	return PublicProfileConfig{
+		CacheTTL:      Duration("PUBLIC_PROFILE_CACHE_TTL", 5*time.Minute),
+		TopClips:      Int("PUBLIC_PROFILE_TOP_CLIPS", 5),
+		SparklineDays: Int("PUBLIC_PROFILE_SPARKLINE_DAYS", 30),
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"slices"
+	"time"
+)
+
+// PublishingConfig controls uploading Twitch clips to YouTube
+type PublishingConfig struct {
+	YouTubeClientID     string
+	YouTubeClientSecret string
+	// YouTubeRedirectURIs are the callbacks registered with Google, the first
+	// is the default, see FrontendConfig.TwitchRedirectURI
+	YouTubeRedirectURIs []string
+	// PollInterval is how often the worker looks for queued jobs
+	PollInterval time.Duration
+	// JobTimeout bounds downloading and uploading one clip. Jobs running for
+	// longer, e.g. on a replica that went away, are queued again.
+	JobTimeout time.Duration
+	// MaxAttempts is how often a job is tried before it fails for good
+	MaxAttempts int
+	// MaxClipBytes bounds the size of a downloaded clip
+	MaxClipBytes int64
+	// MaxQueuedJobs bounds the unfinished jobs of one user
+	MaxQueuedJobs int
+}
+
+// Publishing returns the publishing configuration
+func Publishing() PublishingConfig {
+	cfg := PublishingConfig{
+		YouTubeClientID:     String("YOUTUBE_CLIENT_ID", ""),
+		YouTubeClientSecret: String("YOUTUBE_CLIENT_SECRET", ""),
+		YouTubeRedirectURIs: List("YOUTUBE_REDIRECT_URI", nil),
+		PollInterval:        Duration("PUBLISHING_POLL_INTERVAL", 15*time.Second),
+		JobTimeout:          Duration("PUBLISHING_JOB_TIMEOUT", 10*time.Minute),
+		MaxAttempts:         Int("PUBLISHING_MAX_ATTEMPTS", 3),
+		MaxClipBytes:        int64(Int("PUBLISHING_MAX_CLIP_MB", 256)) << 20,
+		MaxQueuedJobs:       Int("PUBLISHING_MAX_QUEUED_JOBS", 20),
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 15 * time.Second
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = 10 * time.Minute
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return cfg
+}
+
+// Enabled reports whether clips can be published to YouTube
+func (c PublishingConfig) Enabled() bool {
+	return c.YouTubeClientID != "" && c.YouTubeClientSecret != "" && len(c.YouTubeRedirectURIs) > 0
+}
+
+// YouTubeRedirectURI picks the callback Google sends the user back to, like
+// FrontendConfig.TwitchRedirectURI
+func (c PublishingConfig) YouTubeRedirectURI(requested, base string) (string, bool) {
+	return pickRedirectURI(c.YouTubeRedirectURIs, requested, base)
+}
+
+// AllowsYouTubeRedirectURI reports whether uri is a configured callback
+func (c PublishingConfig) AllowsYouTubeRedirectURI(uri string) bool {
+	return slices.Contains(c.YouTubeRedirectURIs, uri)
+}
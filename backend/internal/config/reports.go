@@ -0,0 +1,17 @@
+package config
+
+// ReportsConfig controls saved report delivery
+type ReportsConfig struct {
+	// EmailFrom is the sender of report emails (sent through Resend)
+	EmailFrom string
+	// RunsKept is how many generated runs are kept per report
+	RunsKept int
+}
+
+// Reports returns the reports configuration
+func Reports() ReportsConfig {
+	return ReportsConfig{
+		EmailFrom: String("REPORTS_EMAIL_FROM", "reports@creatorsync.app"),
+		RunsKept:  Int("REPORTS_RUNS_KEPT", 10),
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+// RetentionConfig controls how long raw daily analytics rows are kept
+type RetentionConfig struct {
+	// Enabled turns the nightly retention job on or off
+	Enabled bool
+	// RawDays is how many days of raw daily rows stay in the hot tables
+	RawDays int
+	// Archive copies raw rows into the *_archive tables before deleting them
+	Archive bool
+	// Hour is the UTC hour after which the nightly job runs
+	Hour int
+}
+
+// Retention returns the retention configuration
+func Retention() RetentionConfig {
+	cfg := RetentionConfig{
+		Enabled: Bool("RETENTION_ENABLED", true),
+		RawDays: Int("RETENTION_RAW_DAYS", 365),
+		Archive: Bool("RETENTION_ARCHIVE", true),
+		Hour:    Int("RETENTION_HOUR_UTC", 3),
+	}
+	// Charts read up to a year of raw rows; never roll up anything more recent than 90 days
+	if cfg.RawDays < 90 {
+		cfg.RawDays = 90
+	}
+	if cfg.Hour < 0 || cfg.Hour > 23 {
+		cfg.Hour = 3
+	}
+	return cfg
+}
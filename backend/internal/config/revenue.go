@@ -0,0 +1,36 @@
+package config
+
+// RevenueConfig holds the assumptions used to estimate creator revenue. Twitch
+// does not expose payouts, so subscription revenue is estimated from list prices.
+type RevenueConfig struct {
+	// Tier prices in cents (USD list prices by default)
+	Tier1PriceCents int
+	Tier2PriceCents int
+	Tier3PriceCents int
+	// SubShare is the creator's share of subscription revenue (0-1)
+	SubShare float64
+	// BitCents is what the creator earns per bit, in cents
+	BitCents float64
+	// MaxSubscriberPages bounds how many pages of subscribers are read to work
+	// out the tier mix; larger channels are extrapolated from the sample
+	MaxSubscriberPages int
+}
+
+// Revenue returns the revenue estimation configuration
+func Revenue() RevenueConfig {
+	cfg := RevenueConfig{
+		Tier1PriceCents:    Int("REVENUE_TIER1_PRICE_CENTS", 499),
+		Tier2PriceCents:    Int("REVENUE_TIER2_PRICE_CENTS", 999),
+		Tier3PriceCents:    Int("REVENUE_TIER3_PRICE_CENTS", 2499),
+		SubShare:           Float("REVENUE_SUB_SHARE", 0.5),
+		BitCents:           Float("REVENUE_BIT_CENTS", 1),
+		MaxSubscriberPages: Int("REVENUE_MAX_SUBSCRIBER_PAGES", 10),
+	}
+	if cfg.SubShare < 0 || cfg.SubShare > 1 {
+		cfg.SubShare = 0.5
+	}
+	if cfg.MaxSubscriberPages < 1 {
+		cfg.MaxSubscriberPages = 1
+	}
+	return cfg
+}
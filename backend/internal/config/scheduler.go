@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+// SchedulerConfig controls how the daily collection run fans out across users
+type SchedulerConfig struct {
+	// Workers is the number of users collected concurrently
+	Workers int
+	// MaxJitter is the upper bound of the random delay before each user's collection
+	MaxJitter time.Duration
+	// TwitchRequestsPerMinute is the Twitch API budget shared by all workers.
+	// Twitch allows 800 points per minute per client ID; leave room for user traffic.
+	TwitchRequestsPerMinute int
+	// LeaderCheckInterval is how often a standby replica tries to take over
+	// the scheduled jobs, and how often the replica running them checks it
+	// still holds the lock
+	LeaderCheckInterval time.Duration
+}
+
+// Scheduler returns the scheduler configuration
+func Scheduler() SchedulerConfig {
+	cfg := SchedulerConfig{
+		Workers:                 Int("SCHEDULER_WORKERS", 4),
+		MaxJitter:               Duration("SCHEDULER_MAX_JITTER", 5*time.Second),
+		TwitchRequestsPerMinute: Int("TWITCH_REQUESTS_PER_MINUTE", 400),
+		LeaderCheckInterval:     Duration("SCHEDULER_LEADER_CHECK_INTERVAL", 30*time.Second),
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.TwitchRequestsPerMinute < 1 {
+		cfg.TwitchRequestsPerMinute = 1
+	}
+	if cfg.LeaderCheckInterval <= 0 {
+		cfg.LeaderCheckInterval = 30 * time.Second
+	}
+	return cfg
+}
@@ -0,0 +1,30 @@
+package config
+
+// ScheduleOff in place of a cron expression disables the job
+const ScheduleOff = "off"
+
+// SchedulesConfig holds the cron expressions (minute hour day month weekday,
+// in UTC) of the scheduler's jobs. ScheduleOff disables a job.
+type SchedulesConfig struct {
+	// UserReconciliation copies Twitch accounts from stored tokens onto users
+	UserReconciliation string
+	// DailySnapshot collects every connected user's channel data
+	DailySnapshot string
+	// LiveSampling samples the stream data of connected users
+	LiveSampling string
+	// WeeklyDigest writes weekly insights once the week's snapshots are in
+	WeeklyDigest string
+	// ArchivePurge deletes disconnect archives past their restore window
+	ArchivePurge string
+}
+
+// Schedules returns the scheduler's job schedules
+func Schedules() SchedulesConfig {
+	return SchedulesConfig{
+		UserReconciliation: String("SCHEDULE_USER_RECONCILIATION", "30 1 * * *"),
+		DailySnapshot:      String("SCHEDULE_DAILY_SNAPSHOT", "0 2 * * *"),
+		LiveSampling:       String("SCHEDULE_LIVE_SAMPLING", "0 * * * *"),
+		WeeklyDigest:       String("SCHEDULE_WEEKLY_DIGEST", "0 3 * * 0"),
+		ArchivePurge:       String("SCHEDULE_ARCHIVE_PURGE", "15 4 * * *"),
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// ShutdownConfig controls how the API stops on SIGTERM
+type ShutdownConfig struct {
+	// DrainTimeout is how long in-flight requests and collections may take to
+	// finish. Collections still running then are interrupted and resumed after
+	// the restart.
+	DrainTimeout time.Duration
+}
+
+// Shutdown returns the shutdown configuration
+func Shutdown() ShutdownConfig {
+	return ShutdownConfig{
+		DrainTimeout: Duration("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import "slices"
+
+// StreamlabsConfig controls the Streamlabs integration, which adds tips to
+// revenue analytics
+type StreamlabsConfig struct {
+	ClientID     string
+	ClientSecret string
+	// RedirectURIs are the callbacks registered with Streamlabs, the first is
+	// the default, see FrontendConfig.TwitchRedirectURI
+	RedirectURIs []string
+	// MaxDonationPages bounds how many pages of donations, 100 each, are read
+	// per collection. The first collection after connecting imports that much
+	// history, later ones stop at the donations already stored.
+	MaxDonationPages int
+}
+
+// Streamlabs returns the Streamlabs configuration
+func Streamlabs() StreamlabsConfig {
+	cfg := StreamlabsConfig{
+		ClientID:         String("STREAMLABS_CLIENT_ID", ""),
+		ClientSecret:     String("STREAMLABS_CLIENT_SECRET", ""),
+		RedirectURIs:     List("STREAMLABS_REDIRECT_URI", nil),
+		MaxDonationPages: Int("STREAMLABS_MAX_DONATION_PAGES", 20),
+	}
+	if cfg.MaxDonationPages < 1 {
+		cfg.MaxDonationPages = 1
+	}
+	return cfg
+}
+
+// Enabled reports whether Streamlabs can be connected
+func (c StreamlabsConfig) Enabled() bool {
+	return c.ClientID != "" && c.ClientSecret != "" && len(c.RedirectURIs) > 0
+}
+
+// RedirectURI picks the callback Streamlabs sends the user back to, like
+// FrontendConfig.TwitchRedirectURI
+func (c StreamlabsConfig) RedirectURI(requested, base string) (string, bool) {
+	return pickRedirectURI(c.RedirectURIs, requested, base)
+}
+
+// AllowsRedirectURI reports whether uri is a configured callback
+func (c StreamlabsConfig) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
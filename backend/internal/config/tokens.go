@@ -0,0 +1,76 @@
+package config
+
+import (
+	"log"
+	"strings"
+)
+
+// LegacyTokenKeyID is the key ID of TWITCH_TOKEN_ENCRYPTION_KEY. Tokens stored
+// before key IDs were recorded were encrypted with it.
+const LegacyTokenKeyID = "default"
+
+// Token encryption providers. With a KMS provider each token is encrypted with
+// its own data key, which is wrapped by the KMS key and stored with the token.
+const (
+	TokenProviderLocal  = "local"
+	TokenProviderAWSKMS = "aws-kms"
+	TokenProviderGCPKMS = "gcp-kms"
+)
+
+// TokenEncryptionConfig holds the keys stored Twitch tokens are encrypted with
+type TokenEncryptionConfig struct {
+	// Provider is local (keys below) or one of the KMS providers; empty means local
+	Provider string
+	// Keys maps key IDs to secrets; tokens encrypted with any of them can be read
+	Keys map[string]string
+	// CurrentKeyID is the key new tokens are encrypted with. With a KMS
+	// provider it is the provider name.
+	CurrentKeyID string
+	// KMSKey is the AWS key ID/ARN/alias or the GCP CryptoKey resource name
+	KMSKey string
+	// KMSRegion is the AWS region of the key
+	KMSRegion string
+	// AWS credentials; when empty, ECS task role credentials are used
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+}
+
+// UsesKMS reports whether new tokens are encrypted through a KMS provider
+func (c TokenEncryptionConfig) UsesKMS() bool {
+	return c.Provider != "" && c.Provider != TokenProviderLocal
+}
+
+// TokenEncryption returns the token encryption keys. TWITCH_TOKEN_ENCRYPTION_KEYS
+// holds comma separated id:secret pairs in addition to the legacy single key.
+func TokenEncryption() TokenEncryptionConfig {
+	keys := make(map[string]string)
+	if legacy := String("TWITCH_TOKEN_ENCRYPTION_KEY", ""); legacy != "" {
+		keys[LegacyTokenKeyID] = legacy
+	}
+
+	for _, pair := range List("TWITCH_TOKEN_ENCRYPTION_KEYS", nil) {
+		id, secret, ok := strings.Cut(pair, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" || secret == "" {
+			log.Printf("Ignoring malformed entry in TWITCH_TOKEN_ENCRYPTION_KEYS (expected id:secret)")
+			continue
+		}
+		keys[id] = secret
+	}
+
+	cfg := TokenEncryptionConfig{
+		Provider:           strings.ToLower(String("TOKEN_ENCRYPTION_PROVIDER", TokenProviderLocal)),
+		Keys:               keys,
+		CurrentKeyID:       String("TWITCH_TOKEN_ENCRYPTION_KEY_ID", LegacyTokenKeyID),
+		KMSKey:             String("TOKEN_KMS_KEY", ""),
+		KMSRegion:          String("TOKEN_KMS_REGION", String("AWS_REGION", "")),
+		AWSAccessKeyID:     String("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretAccessKey: String("AWS_SECRET_ACCESS_KEY", ""),
+		AWSSessionToken:    String("AWS_SESSION_TOKEN", ""),
+	}
+	if cfg.UsesKMS() {
+		cfg.CurrentKeyID = cfg.Provider
+	}
+	return cfg
+}
@@ -0,0 +1,31 @@
+package config
+
+// TracingConfig controls exporting OpenTelemetry traces. The standard
+// OTEL_EXPORTER_OTLP_HEADERS variable is read by the exporter itself, e.g. for
+// an API key of the tracing backend.
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318.
+	// Tracing is off when it is empty.
+	Endpoint string
+	// ServiceName identifies this API in the tracing backend
+	ServiceName string
+	// SampleRatio is the share of requests traced, from 0 to 1. Requests whose
+	// caller is already sampled are always traced.
+	SampleRatio float64
+}
+
+// Enabled reports whether traces are exported
+func (c TracingConfig) Enabled() bool {
+	return c.Endpoint != ""
+}
+
+// Tracing returns the tracing configuration
+func Tracing() TracingConfig {
+	cfg := TracingConfig{
+		Endpoint:    String("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		ServiceName: String("OTEL_SERVICE_NAME", "creatorsync-api"),
+		SampleRatio: Float("OTEL_TRACES_SAMPLE_RATIO", 1),
+	}
+	cfg.SampleRatio = max(0, min(cfg.SampleRatio, 1))
+	return cfg
+}
@@ -0,0 +1,43 @@
+package config
+
+import "time"
+
+// TrendsConfig controls the sampling of the top games on Twitch
+type TrendsConfig struct {
+	// Enabled turns the sampling worker on or off
+	Enabled bool
+	// SampleInterval is how often the top games are sampled
+	SampleInterval time.Duration
+	// TopGames is how many of the top games each sample covers (at most 100)
+	TopGames int
+	// StreamPages is how many pages of 100 streams are summed per game.
+	// Streams come most viewers first, so a few pages cover most viewers.
+	StreamPages int
+	// RetentionDays is how long samples are kept
+	RetentionDays int
+}
+
+// Trends returns the trends configuration
+func Trends() TrendsConfig {
+	cfg := TrendsConfig{
+		Enabled:        Bool("TRENDS_ENABLED", true),
+		SampleInterval: Duration("TRENDS_SAMPLE_INTERVAL", time.Hour),
+		TopGames:       Int("TRENDS_TOP_GAMES", 50),
+		StreamPages:    Int("TRENDS_STREAM_PAGES", 3),
+		RetentionDays:  Int("TRENDS_RETENTION_DAYS", 90),
+	}
+	if cfg.SampleInterval < time.Minute {
+		cfg.SampleInterval = time.Hour
+	}
+	if cfg.TopGames <= 0 || cfg.TopGames > 100 {
+		cfg.TopGames = 50
+	}
+	if cfg.StreamPages <= 0 {
+		cfg.StreamPages = 3
+	}
+	// The endpoints compare up to 90 days
+	if cfg.RetentionDays < 90 {
+		cfg.RetentionDays = 90
+	}
+	return cfg
+}
@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// TwitchConfig controls requests to the Twitch API
+type TwitchConfig struct {
+	// CallTimeout bounds each request unless the caller's context ends sooner
+	CallTimeout time.Duration
+}
+
+// Twitch returns the Twitch API configuration
+func Twitch() TwitchConfig {
+	return TwitchConfig{
+		CallTimeout: Duration("TWITCH_CALL_TIMEOUT", 10*time.Second),
+	}
+}
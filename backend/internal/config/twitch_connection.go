@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// TwitchConnectionConfig controls how the Twitch routes reuse a user's connection
+type TwitchConnectionConfig struct {
+	// CacheTTL is how long a user's linked Twitch account and access token are
+	// reused before Clerk and the token store are asked again. Keep it well under
+	// the 5 minute token refresh margin.
+	CacheTTL time.Duration
+}
+
+// TwitchConnection returns the Twitch connection configuration
+func TwitchConnection() TwitchConnectionConfig {
+	return TwitchConnectionConfig{
+		CacheTTL: Duration("TWITCH_CONNECTION_CACHE_TTL", time.Minute),
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// UsageConfig controls counting each user's API requests
+type UsageConfig struct {
+	// Enabled counts authenticated requests per user and endpoint
+	Enabled bool
+	// FlushInterval is how often the counts are written to the database
+	FlushInterval time.Duration
+	// MaxPending flushes early once this many user and endpoint counts are waiting
+	MaxPending int
+}
+
+// Usage returns the API usage tracking configuration
+func Usage() UsageConfig {
+	return UsageConfig{
+		Enabled:       Bool("USAGE_TRACKING_ENABLED", true),
+		FlushInterval: Duration("USAGE_FLUSH_INTERVAL", 30*time.Second),
+		MaxPending:    Int("USAGE_MAX_PENDING", 5000),
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+// WatchlistConfig controls the watchlist of public Twitch channels
+type WatchlistConfig struct {
+	// MaxChannels bounds how many channels one user can watch
+	MaxChannels int
+	// CollectInterval is how often a watched channel's stats are collected
+	CollectInterval time.Duration
+	// PollInterval is how often the worker looks for channels due
+	PollInterval time.Duration
+}
+
+// Watchlist returns the watchlist configuration
+func Watchlist() WatchlistConfig {
+	cfg := WatchlistConfig{
+		MaxChannels:     Int("WATCHLIST_MAX_CHANNELS", 10),
+		CollectInterval: Duration("WATCHLIST_COLLECT_INTERVAL", 24*time.Hour),
+		PollInterval:    Duration("WATCHLIST_POLL_INTERVAL", 15*time.Minute),
+	}
+	if cfg.CollectInterval <= 0 {
+		cfg.CollectInterval = 24 * time.Hour
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 15 * time.Minute
+	}
+	return cfg
+}
@@ -9,7 +9,9 @@ import (
 	"strconv"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/baldybuilds/creatorsync/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -25,9 +27,25 @@ type Service interface {
 type service struct {
 	db     *sql.DB
 	connStr string
+	opts    options
 }
 
-func New() Service {
+func New(opts ...Option) Service {
+	s, err := Open(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s
+}
+
+// Open connects like New, returning the error rather than exiting, e.g. for
+// the checks of cmd/debug
+func Open(opts ...Option) (Service, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var connStr string
 
 	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
@@ -50,9 +68,9 @@ func New() Service {
 		log.Println("Using individual environment variables for connection")
 	}
 
-	db, err := sql.Open("pgx", connStr)
+	db, err := open(connStr, o)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Configure connection pool settings
@@ -66,15 +84,49 @@ func New() Service {
 	defer cancel()
 	
 	if err := db.PingContext(ctx); err != nil {
-		log.Printf("Failed to ping database: %v", err)
-		log.Fatal(err)
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	log.Println("Database connection established successfully")
+	if o.userID != nil {
+		warnIfBypassingRowLevelSecurity(ctx, db)
+	}
 
 	return &service{
 		db:     db,
 		connStr: connStr,
+		opts:    o,
+	}, nil
+}
+
+// open connects through pgx with queries traced as part of their request
+func open(connStr string, o options) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database connection string: %w", err)
+	}
+	connConfig.Tracer = tracing.QueryTracer{}
+
+	connector := stdlib.GetConnector(*connConfig)
+	if o.userID != nil {
+		connector = rlsConnector{Connector: connector, userID: o.userID}
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// warnIfBypassingRowLevelSecurity logs when policies can't apply to the
+// database role, so enabling row-level security isn't mistaken for protection
+func warnIfBypassingRowLevelSecurity(ctx context.Context, db *sql.DB) {
+	var bypasses bool
+	err := db.QueryRowContext(ctx,
+		"SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypasses)
+	if err != nil {
+		log.Printf("Failed to check the database role for row-level security: %v", err)
+		return
+	}
+	if bypasses {
+		log.Println("⚠️ Row-level security is on, but the database role is a superuser or has BYPASSRLS, so it is not enforced")
 	}
 }
 
@@ -88,7 +140,7 @@ func (s *service) Health() map[string]string {
 	if err != nil {
 		stats["status"] = "down"
 		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Fatalf("db down: %v", err)
+		log.Printf("db down: %v", err)
 		return stats
 	}
 
@@ -142,7 +194,7 @@ func (s *service) GetDB() *sql.DB {
 
 func (s *service) RunMigrations() error {
 	migrationRunner := NewMigrationRunner(s.db)
-	return migrationRunner.RunMigrations("migrations")
+	return migrationRunner.RunMigrations(Migrations(""))
 }
 
 func (s *service) CheckConnection() error {
@@ -161,7 +213,7 @@ func (s *service) Reconnect() error {
 	}
 	
 	// Create a new connection
-	db, err := sql.Open("pgx", s.connStr)
+	db, err := open(s.connStr, s.opts)
 	if err != nil {
 		return fmt.Errorf("failed to reconnect to database: %w", err)
 	}
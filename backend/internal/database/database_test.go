@@ -4,7 +4,9 @@ import (
 	"context"
 	"log"
 	"os"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
@@ -92,6 +94,22 @@ func TestHealth(t *testing.T) {
 	}
 }
 
+func TestHealthReportsDownWithoutExiting(t *testing.T) {
+	srv := New()
+	if err := srv.Close(); err != nil {
+		t.Fatalf("expected Close() to return nil, got %v", err)
+	}
+
+	stats := srv.Health()
+
+	if stats["status"] != "down" {
+		t.Fatalf("expected status to be down, got %s", stats["status"])
+	}
+	if stats["error"] == "" {
+		t.Fatalf("expected error details")
+	}
+}
+
 func TestClose(t *testing.T) {
 	srv := New()
 
@@ -99,3 +117,84 @@ func TestClose(t *testing.T) {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
+
+func TestRunMigrationsLockedAppliesOnce(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	migrations := fstest.MapFS{
+		"001_create_widgets.sql":  {Data: []byte("-- Migration: 001_create_widgets.sql\nCREATE TABLE widgets (id SERIAL PRIMARY KEY)")},
+		"002_add_widget_name.sql": {Data: []byte("ALTER TABLE widgets ADD COLUMN name TEXT")},
+	}
+
+	runner := NewMigrationRunner(srv.GetDB())
+	pending, err := runner.PendingMigrations(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending migrations, got %v", pending)
+	}
+
+	// Replicas booting together: the second waits and finds nothing to apply
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- runner.RunMigrationsLocked(context.Background(), migrations)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected migrations to apply once, got %v", err)
+		}
+	}
+
+	pending, err = runner.PendingMigrations(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending migrations, got %v", pending)
+	}
+
+	if _, err := runner.PendingMigrations(fstest.MapFS{}); err == nil {
+		t.Fatal("expected an error without migration files")
+	}
+}
+
+func TestAdvisoryLockIsExclusiveUntilReleased(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+	locker := NewAdvisoryLocker(srv)
+	ctx := context.Background()
+
+	lock, ok, err := locker.TryLock(ctx, "collection:user_1")
+	if err != nil || !ok {
+		t.Fatalf("expected to take the lock, got ok=%v err=%v", ok, err)
+	}
+	if err := lock.Check(ctx); err != nil {
+		t.Fatalf("expected the lock to be held, got %v", err)
+	}
+
+	// Another session, e.g. another replica, can't take it
+	if _, ok, err := locker.TryLock(ctx, "collection:user_1"); err != nil || ok {
+		t.Fatalf("expected the lock to be taken, got ok=%v err=%v", ok, err)
+	}
+	other, ok, err := locker.TryLock(ctx, "collection:user_2")
+	if err != nil || !ok {
+		t.Fatalf("expected another lock to be free, got ok=%v err=%v", ok, err)
+	}
+	other.Release()
+
+	lock.Release()
+	lock, ok, err = locker.TryLock(ctx, "collection:user_1")
+	if err != nil || !ok {
+		t.Fatalf("expected the released lock to be free, got ok=%v err=%v", ok, err)
+	}
+	lock.Release()
+}
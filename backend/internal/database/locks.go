@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// Locker takes locks shared by every replica using the database
+type Locker interface {
+	// TryLock takes the lock called name without waiting. ok is false when
+	// another holder, on any replica, has it.
+	TryLock(ctx context.Context, name string) (lock Lock, ok bool, err error)
+}
+
+// Lock is a held lock
+type Lock interface {
+	// Check returns an error when the lock may have been lost, e.g. because
+	// the database restarted
+	Check(ctx context.Context) error
+	Release()
+}
+
+// AdvisoryLocker takes Postgres advisory locks. Each held lock keeps its own
+// connection, so Postgres releases it if the replica dies.
+type AdvisoryLocker struct {
+	db Service
+}
+
+var _ Locker = (*AdvisoryLocker)(nil)
+
+// NewAdvisoryLocker returns a Locker using db's current connection pool, so
+// locks taken after a Reconnect use the new pool
+func NewAdvisoryLocker(db Service) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (Lock, bool, error) {
+	// Advisory locks belong to a session, so lock and unlock on one connection
+	conn, err := l.db.GetDB().Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", name).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take lock %s: %w", name, err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+	return &advisoryLock{conn: conn, name: name}, true, nil
+}
+
+type advisoryLock struct {
+	conn *sql.Conn
+	name string
+}
+
+func (l *advisoryLock) Check(ctx context.Context) error {
+	if err := l.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("lost the connection holding lock %s: %w", l.name, err)
+	}
+	return nil
+}
+
+func (l *advisoryLock) Release() {
+	if _, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtextextended($1, 0))", l.name); err != nil {
+		log.Printf("Failed to release lock %s: %v", l.name, err)
+	}
+	l.conn.Close()
+}
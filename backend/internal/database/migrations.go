@@ -1,15 +1,31 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
-	"path/filepath"
+	"os"
+	"path"
 	"sort"
 	"strings"
+
+	"github.com/baldybuilds/creatorsync/migrations"
 )
 
+// migrationLockKey is the Postgres advisory lock held while migrating
+const migrationLockKey = 4827310533
+
+// Migrations returns the migrations built into the binary, or the ones in dir
+// when it is set, e.g. while writing a migration
+func Migrations(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(dir)
+	}
+	return migrations.FS
+}
+
 // MigrationRunner handles database migrations
 type MigrationRunner struct {
 	db *sql.DB
@@ -21,14 +37,14 @@ func NewMigrationRunner(db *sql.DB) *MigrationRunner {
 }
 
 // RunMigrations executes all pending migrations
-func (mr *MigrationRunner) RunMigrations(migrationsDir string) error {
+func (mr *MigrationRunner) RunMigrations(migrationFiles fs.FS) error {
 	// Create migrations table if it doesn't exist
 	if err := mr.createMigrationsTable(); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
 	// Get list of migration files
-	files, err := mr.getMigrationFiles(migrationsDir)
+	files, err := mr.getMigrationFiles(migrationFiles)
 	if err != nil {
 		return fmt.Errorf("failed to get migration files: %w", err)
 	}
@@ -41,14 +57,14 @@ func (mr *MigrationRunner) RunMigrations(migrationsDir string) error {
 
 	// Execute pending migrations
 	for _, file := range files {
-		filename := filepath.Base(file)
+		filename := path.Base(file)
 		if applied[filename] {
 			log.Printf("Migration %s already applied, skipping", filename)
 			continue
 		}
 
 		log.Printf("Applying migration: %s", filename)
-		if err := mr.executeMigration(file, filename); err != nil {
+		if err := mr.executeMigration(migrationFiles, file, filename); err != nil {
 			return fmt.Errorf("failed to execute migration %s: %w", filename, err)
 		}
 		log.Printf("Successfully applied migration: %s", filename)
@@ -57,6 +73,55 @@ func (mr *MigrationRunner) RunMigrations(migrationsDir string) error {
 	return nil
 }
 
+// RunMigrationsLocked runs RunMigrations while holding a Postgres advisory
+// lock, so replicas booting together don't apply the same migration twice. The
+// replicas that waited find nothing left to apply. ctx bounds the wait.
+func (mr *MigrationRunner) RunMigrationsLocked(ctx context.Context, migrationFiles fs.FS) error {
+	// Advisory locks belong to a session, so lock and unlock on one connection
+	conn, err := mr.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	return mr.RunMigrations(migrationFiles)
+}
+
+// PendingMigrations returns the migration files not applied yet. It is an
+// error for migrationFiles to hold no migrations, since the schema can't be
+// checked then.
+func (mr *MigrationRunner) PendingMigrations(migrationFiles fs.FS) ([]string, error) {
+	files, err := mr.getMigrationFiles(migrationFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migration files found")
+	}
+
+	applied, err := mr.getAppliedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	var pending []string
+	for _, file := range files {
+		if filename := path.Base(file); !applied[filename] {
+			pending = append(pending, filename)
+		}
+	}
+	return pending, nil
+}
+
 // createMigrationsTable creates the migrations tracking table
 func (mr *MigrationRunner) createMigrationsTable() error {
 	query := `
@@ -70,9 +135,8 @@ func (mr *MigrationRunner) createMigrationsTable() error {
 }
 
 // getMigrationFiles returns sorted list of migration files
-func (mr *MigrationRunner) getMigrationFiles(dir string) ([]string, error) {
-	pattern := filepath.Join(dir, "*.sql")
-	files, err := filepath.Glob(pattern)
+func (mr *MigrationRunner) getMigrationFiles(migrationFiles fs.FS) ([]string, error) {
+	files, err := fs.Glob(migrationFiles, "*.sql")
 	if err != nil {
 		return nil, err
 	}
@@ -82,31 +146,52 @@ func (mr *MigrationRunner) getMigrationFiles(dir string) ([]string, error) {
 	return files, nil
 }
 
-// getAppliedMigrations returns a map of already applied migrations
-func (mr *MigrationRunner) getAppliedMigrations() (map[string]bool, error) {
-	query := "SELECT filename FROM schema_migrations"
-	rows, err := mr.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// appliedMigrationTables record applied migrations: schema_migrations, and
+// migrations where cmd/migrate used to record them
+var appliedMigrationTables = []string{"schema_migrations", "migrations"}
 
+// getAppliedMigrations returns a map of already applied migrations. Tables that
+// don't exist yet, like on a fresh database, have none.
+func (mr *MigrationRunner) getAppliedMigrations() (map[string]bool, error) {
 	applied := make(map[string]bool)
-	for rows.Next() {
-		var filename string
-		if err := rows.Scan(&filename); err != nil {
+	for _, table := range appliedMigrationTables {
+		var exists bool
+		if err := mr.db.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.tables
+				WHERE table_schema = current_schema() AND table_name = $1
+			)`, table).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		rows, err := mr.db.Query("SELECT filename FROM " + table)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var filename string
+			if err := rows.Scan(&filename); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			applied[filename] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
 			return nil, err
 		}
-		applied[filename] = true
 	}
 
-	return applied, rows.Err()
+	return applied, nil
 }
 
 // executeMigration executes a single migration file
-func (mr *MigrationRunner) executeMigration(filePath, filename string) error {
+func (mr *MigrationRunner) executeMigration(migrationFiles fs.FS, filePath, filename string) error {
 	// Read migration file
-	content, err := ioutil.ReadFile(filePath)
+	content, err := fs.ReadFile(migrationFiles, filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read migration file: %w", err)
 	}
@@ -121,8 +206,8 @@ func (mr *MigrationRunner) executeMigration(filePath, filename string) error {
 	// Split content by semicolons and execute each statement
 	statements := strings.Split(string(content), ";")
 	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" || strings.HasPrefix(stmt, "--") {
+		stmt = stripCommentLines(stmt)
+		if stmt == "" {
 			continue
 		}
 
@@ -138,3 +223,16 @@ func (mr *MigrationRunner) executeMigration(filePath, filename string) error {
 
 	return tx.Commit()
 }
+
+// stripCommentLines drops full-line comments, so a statement preceded by a
+// comment (like every migration's header) still runs
+func stripCommentLines(stmt string) string {
+	var lines []string
+	for _, line := range strings.Split(stmt, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Option configures the connection made by New
+type Option func(*options)
+
+type options struct {
+	userID func(ctx context.Context) string
+}
+
+// WithRowLevelSecurity sets the app.user_id setting that the policies of
+// migration 034 check to userID(ctx) before each query, so a query missing its
+// user_id filter still only sees that user's rows. An empty user, e.g. for
+// background jobs, sees every row.
+func WithRowLevelSecurity(userID func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.userID = userID
+	}
+}
+
+// rlsConnector hands out connections that follow the user of each query
+type rlsConnector struct {
+	driver.Connector
+	userID func(ctx context.Context) string
+}
+
+func (c rlsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rlsConn{Conn: conn.(*stdlib.Conn), userID: c.userID}, nil
+}
+
+// rlsConn changes app.user_id only when the user differs from the previous
+// query's, and never inside a transaction, where a rollback would undo it. A
+// transaction keeps the user it began with.
+type rlsConn struct {
+	*stdlib.Conn
+	userID  func(ctx context.Context) string
+	current string
+	inTx    bool
+}
+
+func (c *rlsConn) scope(ctx context.Context) error {
+	if c.inTx {
+		return nil
+	}
+	userID := c.userID(ctx)
+	if userID == c.current {
+		return nil
+	}
+	if _, err := c.Conn.Conn().Exec(ctx, "SELECT set_config('app.user_id', $1, false)", userID); err != nil {
+		return fmt.Errorf("failed to scope connection to user: %w", err)
+	}
+	c.current = userID
+	return nil
+}
+
+func (c *rlsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.Conn.QueryContext(ctx, query, args)
+}
+
+func (c *rlsConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.Conn.ExecContext(ctx, query, args)
+}
+
+// PrepareContext scopes the connection to the user preparing the statement
+func (c *rlsConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.Conn.PrepareContext(ctx, query)
+}
+
+func (c *rlsConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &rlsTx{Tx: tx, conn: c}, nil
+}
+
+type rlsTx struct {
+	driver.Tx
+	conn *rlsConn
+}
+
+func (t *rlsTx) Commit() error {
+	t.conn.inTx = false
+	return t.Tx.Commit()
+}
+
+func (t *rlsTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,266 @@
+// Package dbcheck verifies a database after a backup restore or a migration:
+// tables hold the rows expected of them, no rows outlive their user and every
+// stored OAuth token still decrypts with the configured keys.
+package dbcheck
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// tokenBatchSize is how many tokens are decrypted per query
+	tokenBatchSize = 100
+	// maxListedUsers bounds the user IDs listed per token table
+	maxListedUsers = 20
+)
+
+// TokenTables hold OAuth tokens encrypted with the token keyring
+var TokenTables = []string{"user_twitch_tokens", "user_patreon_tokens", "user_youtube_tokens", "user_streamlabs_tokens"}
+
+// orphanExempt have a user_id that may legitimately outlive the user: OAuth
+// sessions start before the user's row exists and archived rollups are kept
+// for their retention period
+var orphanExempt = []string{"users", "oauth_sessions", "channel_analytics_archive"}
+
+// Expectation bounds the row count of a table. Either side may be left out.
+type Expectation struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// Expectations are keyed by table name
+type Expectations map[string]Expectation
+
+// LoadExpectations reads expectations from a JSON file like
+// {"users": {"min": 1}, "channel_analytics": {"min": 1000}}
+func LoadExpectations(path string) (Expectations, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expectations: %w", err)
+	}
+	var expectations Expectations
+	if err := json.Unmarshal(data, &expectations); err != nil {
+		return nil, fmt.Errorf("failed to parse expectations %s: %w", path, err)
+	}
+	return expectations, nil
+}
+
+// TableCount is the row count of a table and what was expected of it
+type TableCount struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+	Expectation
+}
+
+// OrphanCount is how many rows of a table belong to users that don't exist
+type OrphanCount struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// TokenCheck is the result of decrypting every token of a table
+type TokenCheck struct {
+	Table         string `json:"table"`
+	Checked       int    `json:"checked"`
+	Undecryptable int    `json:"undecryptable"`
+	// UserIDs lists the first users whose tokens failed
+	UserIDs []string `json:"user_ids"`
+}
+
+// Report is the outcome of a check. OK is false when Problems isn't empty.
+type Report struct {
+	CheckedAt time.Time     `json:"checked_at"`
+	OK        bool          `json:"ok"`
+	Problems  []string      `json:"problems"`
+	Tables    []TableCount  `json:"tables"`
+	Orphans   []OrphanCount `json:"orphans"`
+	// Tokens is empty when tokens were not checked
+	Tokens []TokenCheck `json:"tokens"`
+}
+
+// Checker runs the checks against a database
+type Checker struct {
+	db           *sqlx.DB
+	expectations Expectations
+	// keyring is nil to skip decrypting tokens
+	keyring *tokencrypt.Keyring
+}
+
+func NewChecker(db *sql.DB, expectations Expectations, keyring *tokencrypt.Keyring) *Checker {
+	return &Checker{
+		db:           sqlx.NewDb(db, "postgres"),
+		expectations: expectations,
+		keyring:      keyring,
+	}
+}
+
+// Run checks the database. Problems found are reported, errors mean the
+// database could not be checked.
+func (c *Checker) Run(ctx context.Context) (*Report, error) {
+	report := &Report{
+		CheckedAt: time.Now().UTC(),
+		Problems:  []string{},
+		Tables:    []TableCount{},
+		Orphans:   []OrphanCount{},
+		Tokens:    []TokenCheck{},
+	}
+
+	var tables []string
+	err := c.db.SelectContext(ctx, &tables, `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	for _, table := range tables {
+		var rows int64
+		if err := c.db.GetContext(ctx, &rows, `SELECT COUNT(*) FROM `+quote(table)); err != nil {
+			return nil, fmt.Errorf("failed to count rows of %s: %w", table, err)
+		}
+		report.Tables = append(report.Tables, TableCount{Table: table, Rows: rows, Expectation: c.expectations[table]})
+	}
+
+	var userTables []string
+	err = c.db.SelectContext(ctx, &userTables, `
+		SELECT table_name
+		FROM information_schema.columns
+		WHERE table_schema = 'public' AND column_name = 'user_id'
+		  AND data_type IN ('character varying', 'text')
+		ORDER BY table_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables with users: %w", err)
+	}
+	for _, table := range userTables {
+		if slices.Contains(orphanExempt, table) || !slices.Contains(tables, table) {
+			continue
+		}
+		var rows int64
+		err := c.db.GetContext(ctx, &rows, `
+			SELECT COUNT(*) FROM `+quote(table)+` t
+			WHERE t.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.user_id)
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count orphaned rows of %s: %w", table, err)
+		}
+		report.Orphans = append(report.Orphans, OrphanCount{Table: table, Rows: rows})
+	}
+
+	if c.keyring != nil {
+		for _, table := range TokenTables {
+			if !slices.Contains(tables, table) {
+				continue
+			}
+			check, err := c.checkTokens(ctx, table)
+			if err != nil {
+				return nil, err
+			}
+			report.Tokens = append(report.Tokens, *check)
+		}
+	}
+
+	report.Problems = problems(report, tables, c.expectations, c.keyring != nil)
+	report.OK = len(report.Problems) == 0
+	return report, nil
+}
+
+// checkTokens decrypts the access and refresh token of every row of table
+func (c *Checker) checkTokens(ctx context.Context, table string) (*TokenCheck, error) {
+	check := &TokenCheck{Table: table, UserIDs: []string{}}
+	query := `
+		SELECT user_id, access_token, COALESCE(refresh_token, '') AS refresh_token, encryption_key_id
+		FROM ` + quote(table) + `
+		WHERE user_id > $1
+		ORDER BY user_id
+		LIMIT $2
+	`
+
+	after := ""
+	for {
+		var tokens []struct {
+			UserID          string `db:"user_id"`
+			AccessToken     string `db:"access_token"`
+			RefreshToken    string `db:"refresh_token"`
+			EncryptionKeyID string `db:"encryption_key_id"`
+		}
+		if err := c.db.SelectContext(ctx, &tokens, query, after, tokenBatchSize); err != nil {
+			return nil, fmt.Errorf("failed to read tokens of %s: %w", table, err)
+		}
+		if len(tokens) == 0 {
+			return check, nil
+		}
+		after = tokens[len(tokens)-1].UserID
+
+		for _, token := range tokens {
+			check.Checked++
+			_, err := c.keyring.Decrypt(ctx, token.AccessToken, token.EncryptionKeyID)
+			if err == nil && token.RefreshToken != "" {
+				_, err = c.keyring.Decrypt(ctx, token.RefreshToken, token.EncryptionKeyID)
+			}
+			if err != nil {
+				check.Undecryptable++
+				if len(check.UserIDs) < maxListedUsers {
+					check.UserIDs = append(check.UserIDs, token.UserID)
+				}
+			}
+		}
+	}
+}
+
+// problems lists what the report shows is wrong with the database
+func problems(report *Report, tables []string, expectations Expectations, checkedTokens bool) []string {
+	found := []string{}
+
+	for table := range expectations {
+		if !slices.Contains(tables, table) {
+			found = append(found, fmt.Sprintf("table %s is missing", table))
+		}
+	}
+	if checkedTokens {
+		for _, table := range TokenTables {
+			if !slices.Contains(tables, table) {
+				found = append(found, fmt.Sprintf("table %s is missing", table))
+			}
+		}
+	}
+	slices.Sort(found)
+	found = slices.Compact(found)
+
+	for _, count := range report.Tables {
+		if count.Min != nil && count.Rows < *count.Min {
+			found = append(found, fmt.Sprintf("%s has %d rows, expected at least %d", count.Table, count.Rows, *count.Min))
+		}
+		if count.Max != nil && count.Rows > *count.Max {
+			found = append(found, fmt.Sprintf("%s has %d rows, expected at most %d", count.Table, count.Rows, *count.Max))
+		}
+	}
+	for _, orphans := range report.Orphans {
+		if orphans.Rows > 0 {
+			found = append(found, fmt.Sprintf("%s has %d rows of users that don't exist", orphans.Table, orphans.Rows))
+		}
+	}
+	for _, tokens := range report.Tokens {
+		if tokens.Undecryptable > 0 {
+			found = append(found, fmt.Sprintf("%s has %d of %d tokens that don't decrypt", tokens.Table, tokens.Undecryptable, tokens.Checked))
+		}
+	}
+	return found
+}
+
+func quote(table string) string {
+	return pgx.Identifier{table}.Sanitize()
+}
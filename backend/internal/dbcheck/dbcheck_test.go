@@ -0,0 +1,57 @@
+package dbcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func count(n int64) *int64 {
+	return &n
+}
+
+func TestLoadExpectations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expect.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"users": {"min": 1}, "plans": {"min": 2, "max": 2}}`), 0o600))
+
+	expectations, err := LoadExpectations(path)
+	require.NoError(t, err)
+	assert.Equal(t, Expectations{
+		"users": {Min: count(1)},
+		"plans": {Min: count(2), Max: count(2)},
+	}, expectations)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"users": 1}`), 0o600))
+	_, err = LoadExpectations(path)
+	assert.Error(t, err)
+}
+
+func TestProblems(t *testing.T) {
+	report := &Report{
+		Tables: []TableCount{
+			{Table: "users", Rows: 0, Expectation: Expectation{Min: count(1)}},
+			{Table: "plans", Rows: 3, Expectation: Expectation{Max: count(2)}},
+			{Table: "raids", Rows: 10},
+		},
+		Orphans: []OrphanCount{{Table: "raids", Rows: 2}, {Table: "alerts", Rows: 0}},
+		Tokens:  []TokenCheck{{Table: "user_twitch_tokens", Checked: 5, Undecryptable: 1}},
+	}
+	tables := append([]string{"users", "plans", "raids"}, TokenTables[:3]...)
+	expectations := Expectations{"users": {Min: count(1)}, "creator_goals": {Min: count(1)}}
+
+	assert.Equal(t, []string{
+		"table creator_goals is missing",
+		"table user_streamlabs_tokens is missing",
+		"users has 0 rows, expected at least 1",
+		"plans has 3 rows, expected at most 2",
+		"raids has 2 rows of users that don't exist",
+		"user_twitch_tokens has 1 of 5 tokens that don't decrypt",
+	}, problems(report, tables, expectations, true))
+
+	// Token tables only matter when tokens are checked
+	report.Tokens = nil
+	assert.NotContains(t, problems(report, tables, nil, false), "table user_streamlabs_tokens is missing")
+}
@@ -2,23 +2,35 @@ package email
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
 )
 
 type ResendClient struct {
 	apiKey     string
 	apiBaseURL string
+	httpClient *http.Client
 }
 type EmailRequest struct {
-	From    string   `json:"from"`
-	To      []string `json:"to"`
-	Subject string   `json:"subject"`
-	HTML    string   `json:"html"`
+	From        string       `json:"from"`
+	To          []string     `json:"to"`
+	Subject     string       `json:"subject"`
+	HTML        string       `json:"html"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file sent with an email; Content is base64 encoded
+type Attachment struct {
+	Filename string `json:"filename"`
+	Content  string `json:"content"`
 }
 type WaitlistRequest struct {
 	Email string `json:"email"`
@@ -41,6 +53,7 @@ func NewResendClient() (*ResendClient, error) {
 	return &ResendClient{
 		apiKey:     apiKey,
 		apiBaseURL: "https://api.resend.com",
+		httpClient: httpclient.New("resend"),
 	}, nil
 }
 
@@ -88,6 +101,11 @@ func (c *ResendClient) AddToWaitlist(req WaitlistRequest) error {
 	return nil
 }
 
+// Send delivers a single email
+func (c *ResendClient) Send(req EmailRequest) error {
+	return c.sendEmail(req)
+}
+
 func (c *ResendClient) sendEmail(req EmailRequest) error {
 
 	jsonData, err := json.Marshal(req)
@@ -102,9 +120,10 @@ func (c *ResendClient) sendEmail(req EmailRequest) error {
 
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
+	// Resend drops a repeated key, so a retried send isn't delivered twice
+	httpReq.Header.Set(httpclient.IdempotencyKeyHeader, idempotencyKey())
 
-	client := &http.Client{}
-	resp, err := client.Do(httpReq)
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -130,3 +149,9 @@ func (c *ResendClient) sendEmail(req EmailRequest) error {
 
 	return nil
 }
+
+func idempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+// Package enrichment derives tags from video titles after collection: language,
+// keywords and optionally sentiment. The results are stored in
+// video_analytics.metadata and power the keyword insights endpoint.
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Version is bumped whenever the analysis changes so stored metadata is recomputed
+const Version = 1
+
+// batchSize bounds how many videos are enriched per query
+const batchSize = 500
+
+// Metadata is what gets stored for a video. Title records the analysed title,
+// so a renamed video is enriched again.
+type Metadata struct {
+	Version   int      `json:"version"`
+	Title     string   `json:"title"`
+	Language  string   `json:"language,omitempty"`
+	Keywords  []string `json:"keywords"`
+	Sentiment *float64 `json:"sentiment,omitempty"`
+}
+
+// Analyze computes the metadata of one title
+func Analyze(title string, withSentiment bool) Metadata {
+	keywords := Keywords(title)
+	if keywords == nil {
+		keywords = []string{}
+	}
+
+	metadata := Metadata{
+		Version:  Version,
+		Title:    title,
+		Language: DetectLanguage(title),
+		Keywords: keywords,
+	}
+	if withSentiment {
+		if score, ok := Sentiment(title); ok {
+			metadata.Sentiment = &score
+		}
+	}
+	return metadata
+}
+
+// Enricher tags a user's videos whose metadata is missing or out of date
+type Enricher struct {
+	repo Repository
+	cfg  config.EnrichmentConfig
+}
+
+func NewEnricher(repo Repository) *Enricher {
+	return &Enricher{
+		repo: repo,
+		cfg:  config.Enrichment(),
+	}
+}
+
+// EnrichUser analyses every video of the user that needs it. It has the
+// signature of an analytics.CollectionHook so it can run after video collection.
+func (e *Enricher) EnrichUser(ctx context.Context, userID string) error {
+	if !e.cfg.Enabled {
+		return nil
+	}
+
+	enriched := 0
+	for {
+		videos, err := e.repo.GetVideosToEnrich(ctx, userID, Version, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, video := range videos {
+			if err := e.repo.SaveVideoMetadata(ctx, video.VideoID, Analyze(video.Title, e.cfg.Sentiment)); err != nil {
+				return fmt.Errorf("failed to enrich video %s: %w", video.VideoID, err)
+			}
+		}
+		enriched += len(videos)
+
+		if len(videos) < batchSize {
+			break
+		}
+	}
+
+	if enriched > 0 {
+		log.Printf("Enriched %d video titles for user %s", enriched, userID)
+	}
+	return nil
+}
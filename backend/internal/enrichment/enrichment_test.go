@@ -0,0 +1,121 @@
+package enrichment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"Elden Ring - First Playthrough Part 3 | !discord !merch", []string{"elden", "ring"}},
+		{"Speedrunning Celeste: any% WR attempts #celeste", []string{"speedrunning", "celeste", "attempts"}},
+		{"Let's play Minecraft with the chat!!", []string{"minecraft", "chat"}},
+		{"100 days of Hardcore Minecraft — day 42", []string{"days", "hardcore", "minecraft"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := Keywords(tt.title)
+		if got == nil {
+			got = []string{}
+		}
+		assert.Equal(t, tt.want, got, tt.title)
+	}
+
+	assert.Len(t, Keywords("alpha bravo charlie delta echo foxtrot golf hotel india juliet"), maxKeywords)
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"Chill stream with the community and some cozy games": "en",
+		"Jugando con los amigos para la victoria":             "es",
+		"Heute spielen wir mit der Community und die Bosse":   "de",
+		"Le grand retour avec les abonnés pour la fin":        "fr",
+		"マインクラフト実況プレイ":                                        "ja",
+		"마인크래프트 생존 방송":                                        "ko",
+		"原神 直播":                                               "zh",
+		"Стрим по Minecraft выживание":                        "ru",
+		"Elden Ring": "",
+	}
+
+	for title, want := range tests {
+		assert.Equal(t, want, DetectLanguage(title), title)
+	}
+}
+
+func TestSentiment(t *testing.T) {
+	score, ok := Sentiment("EPIC clutch win!")
+	require.True(t, ok)
+	assert.Equal(t, 1.0, score)
+
+	score, ok = Sentiment("Rage quit after the worst run")
+	require.True(t, ok)
+	assert.Equal(t, -1.0, score)
+
+	score, ok = Sentiment("Not bad, not great")
+	require.True(t, ok)
+	assert.Equal(t, 0.0, score)
+
+	_, ok = Sentiment("Elden Ring part 3")
+	assert.False(t, ok)
+}
+
+type fakeRepository struct {
+	titles map[string]string
+	saved  map[string]Metadata
+}
+
+func (r *fakeRepository) GetVideosToEnrich(ctx context.Context, userID string, version, limit int) ([]Video, error) {
+	var videos []Video
+	for id, title := range r.titles {
+		if saved, ok := r.saved[id]; ok && saved.Version == version && saved.Title == title {
+			continue
+		}
+		videos = append(videos, Video{VideoID: id, Title: title})
+	}
+	return videos, nil
+}
+
+func (r *fakeRepository) SaveVideoMetadata(ctx context.Context, videoID string, metadata Metadata) error {
+	r.saved[videoID] = metadata
+	return nil
+}
+
+func TestEnrichUser(t *testing.T) {
+	t.Setenv("ENRICHMENT_SENTIMENT", "true")
+	repo := &fakeRepository{
+		titles: map[string]string{"v1": "Epic Celeste speedrun", "v2": "Elden Ring part 3"},
+		saved:  map[string]Metadata{},
+	}
+	enricher := NewEnricher(repo)
+	ctx := context.Background()
+
+	require.NoError(t, enricher.EnrichUser(ctx, "user_1"))
+	require.Len(t, repo.saved, 2)
+
+	v1 := repo.saved["v1"]
+	assert.Equal(t, Version, v1.Version)
+	assert.Equal(t, []string{"epic", "celeste", "speedrun"}, v1.Keywords)
+	require.NotNil(t, v1.Sentiment)
+	assert.Equal(t, 1.0, *v1.Sentiment)
+	assert.Nil(t, repo.saved["v2"].Sentiment)
+
+	// Renamed videos are analysed again
+	repo.titles["v2"] = "Elden Ring DLC"
+	require.NoError(t, enricher.EnrichUser(ctx, "user_1"))
+	assert.Equal(t, []string{"elden", "ring", "dlc"}, repo.saved["v2"].Keywords)
+}
+
+func TestEnrichUserDisabled(t *testing.T) {
+	t.Setenv("ENRICHMENT_ENABLED", "false")
+	repo := &fakeRepository{titles: map[string]string{"v1": "Celeste"}, saved: map[string]Metadata{}}
+
+	require.NoError(t, NewEnricher(repo).EnrichUser(context.Background(), "user_1"))
+	assert.Empty(t, repo.saved)
+}
@@ -0,0 +1,65 @@
+package enrichment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Video is a title waiting to be enriched
+type Video struct {
+	VideoID string `db:"video_id"`
+	Title   string `db:"title"`
+}
+
+type Repository interface {
+	GetVideosToEnrich(ctx context.Context, userID string, version, limit int) ([]Video, error)
+	SaveVideoMetadata(ctx context.Context, videoID string, metadata Metadata) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+// GetVideosToEnrich returns videos never enriched, enriched by an older
+// Version or renamed since
+func (r *repository) GetVideosToEnrich(ctx context.Context, userID string, version, limit int) ([]Video, error) {
+	query := `
+		SELECT video_id, COALESCE(title, '') AS title
+		FROM video_analytics
+		WHERE user_id = $1
+		  AND (COALESCE((metadata->>'version')::int, 0) <> $2
+		       OR metadata->>'title' IS DISTINCT FROM COALESCE(title, ''))
+		ORDER BY id
+		LIMIT $3
+	`
+
+	var videos []Video
+	if err := r.db.SelectContext(ctx, &videos, query, userID, version, limit); err != nil {
+		return nil, fmt.Errorf("failed to get videos to enrich: %w", err)
+	}
+	return videos, nil
+}
+
+// SaveVideoMetadata replaces the enrichment metadata of a video
+func (r *repository) SaveVideoMetadata(ctx context.Context, videoID string, metadata Metadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	query := `UPDATE video_analytics SET metadata = $2::jsonb WHERE video_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, videoID, string(data)); err != nil {
+		return fmt.Errorf("failed to save video metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package enrichment
+
+// A small English lexicon tuned for stream titles. It is deliberately coarse:
+// the score only needs to separate hype titles from rage/fail ones.
+var (
+	positiveWords = map[string]bool{
+		"amazing": true, "awesome": true, "best": true, "chill": true, "cozy": true, "epic": true,
+		"fun": true, "funny": true, "good": true, "great": true, "happy": true, "hype": true,
+		"insane": true, "incredible": true, "legendary": true, "love": true, "perfect": true,
+		"relaxing": true, "victory": true, "win": true, "winning": true, "wins": true, "wholesome": true,
+		"celebration": true, "record": true, "clutch": true, "beautiful": true,
+	}
+	negativeWords = map[string]bool{
+		"angry": true, "awful": true, "bad": true, "boring": true, "broken": true, "fail": true,
+		"fails": true, "hate": true, "lose": true, "losing": true, "loss": true, "rage": true,
+		"sad": true, "terrible": true, "tilted": true, "worst": true, "pain": true, "suffering": true,
+		"died": true, "death": true, "disaster": true, "cursed": true, "struggle": true,
+	}
+	negators = map[string]bool{
+		"not": true, "no": true, "never": true, "isn't": true, "don't": true, "can't": true, "won't": true,
+	}
+)
+
+// Sentiment scores a title from -1 (negative) to 1 (positive). A negator flips
+// the next sentiment word. The bool is false when no sentiment words appear.
+func Sentiment(title string) (float64, bool) {
+	positive, negative := 0, 0
+	negate := false
+
+	for _, token := range tokenize(title) {
+		if negators[token] {
+			negate = true
+			continue
+		}
+
+		isPositive, isNegative := positiveWords[token], negativeWords[token]
+		if negate && (isPositive || isNegative) {
+			isPositive, isNegative = isNegative, isPositive
+		}
+		if isPositive {
+			positive++
+		}
+		if isNegative {
+			negative++
+		}
+		negate = false
+	}
+
+	total := positive + negative
+	if total == 0 {
+		return 0, false
+	}
+	return float64(positive-negative) / float64(total), true
+}
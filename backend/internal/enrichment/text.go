@@ -0,0 +1,191 @@
+package enrichment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxKeywords bounds how many keywords are kept per title
+const maxKeywords = 8
+
+// stopwords per language, used both to drop filler from keywords and to guess
+// the language of Latin-script titles
+var stopwords = map[string][]string{
+	"en": {"the", "and", "for", "with", "you", "your", "are", "was", "this", "that", "from", "but", "not", "all", "can", "have", "has", "out", "our", "its", "into", "how", "what", "who", "why", "when", "will", "just", "get", "got", "let", "lets", "let's", "more", "some", "then", "than", "too", "very", "off", "over", "again", "any", "day", "time", "new", "now"},
+	"es": {"el", "la", "los", "las", "que", "con", "para", "por", "una", "uno", "del", "como", "pero", "más", "mas", "este", "esta", "hoy", "vamos", "jugando", "nuevo"},
+	"pt": {"o", "os", "as", "que", "com", "para", "por", "uma", "um", "do", "da", "dos", "das", "não", "nao", "mais", "hoje", "vamos", "jogando", "novo"},
+	"fr": {"le", "la", "les", "des", "une", "un", "avec", "pour", "par", "sur", "dans", "est", "pas", "plus", "que", "qui", "ce", "cette", "aujourd'hui", "nouveau"},
+	"de": {"der", "die", "das", "und", "mit", "für", "fur", "auf", "ist", "nicht", "ein", "eine", "den", "dem", "wir", "heute", "noch", "neue", "neu"},
+	"it": {"il", "lo", "gli", "che", "con", "per", "una", "uno", "del", "della", "non", "più", "piu", "oggi", "questo", "questa", "nuovo"},
+}
+
+// streamFiller is vocabulary nearly every title on the platform uses, which
+// would otherwise dominate the keyword rankings
+var streamFiller = []string{
+	"stream", "streaming", "live", "vod", "part", "episode", "ep", "pt", "full", "playthrough",
+	"gameplay", "lets", "play", "playing", "road", "first", "time", "twitch", "youtube",
+	"!prime", "!discord", "!socials", "!merch",
+}
+
+var stopwordSet = func() map[string]bool {
+	set := make(map[string]bool)
+	for _, words := range stopwords {
+		for _, w := range words {
+			set[w] = true
+		}
+	}
+	for _, w := range streamFiller {
+		set[w] = true
+	}
+	return set
+}()
+
+// tokenize lowercases text and splits it into words. Hashtags keep their text
+// without the #, and apostrophes inside words are kept ("let's").
+func tokenize(text string) []string {
+	var tokens []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, strings.Trim(current.String(), "'"))
+			current.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			current.WriteRune(r)
+		case r == '\'' || r == '’':
+			if current.Len() > 0 {
+				current.WriteRune('\'')
+			}
+		case r == '!' && current.Len() == 0:
+			// Chat commands like !discord are filtered as a whole
+			current.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// Keywords extracts the distinctive words of a title in order of appearance:
+// stopwords, stream filler, chat commands, bare numbers and words shorter than
+// three characters are dropped
+func Keywords(title string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+
+	for _, token := range tokenize(title) {
+		if strings.HasPrefix(token, "!") || stopwordSet[token] || seen[token] || isNumber(token) {
+			continue
+		}
+		// CJK titles have no spaces, so short tokens there are still meaningful
+		if len([]rune(token)) < 3 && !isCJK(token) {
+			continue
+		}
+
+		seen[token] = true
+		keywords = append(keywords, token)
+		if len(keywords) == maxKeywords {
+			break
+		}
+	}
+
+	return keywords
+}
+
+// DetectLanguage guesses the ISO 639-1 language of a title. Non-Latin scripts
+// are identified by script; Latin titles by which language's stopwords appear
+// most. It returns "" when there is no clear winner.
+func DetectLanguage(title string) string {
+	if lang := scriptLanguage(title); lang != "" {
+		return lang
+	}
+
+	hits := make(map[string]int)
+	for _, token := range tokenize(title) {
+		for lang, words := range stopwords {
+			for _, w := range words {
+				if w == token {
+					hits[lang]++
+				}
+			}
+		}
+	}
+
+	best, bestHits, tied := "", 0, false
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			best, bestHits, tied = lang, n, false
+		case n == bestHits:
+			tied = true
+		}
+	}
+	if tied || bestHits == 0 {
+		return ""
+	}
+	return best
+}
+
+// scriptLanguage identifies languages by the script most of the letters are in
+func scriptLanguage(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		}
+	}
+
+	// Kanji appear in Japanese too; any kana makes it Japanese
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+
+	for lang, n := range counts {
+		if n*2 > letters {
+			return lang
+		}
+	}
+	return ""
+}
+
+func isNumber(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isCJK(token string) bool {
+	for _, r := range token {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/awsv4"
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// S3Sink uploads objects to an S3-compatible bucket using AWS Signature Version 4
+type S3Sink struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	prefix          string
+	accessKeyID     string
+	secretAccessKey string
+	pathStyle       bool
+	httpClient      *http.Client
+}
+
+func NewS3Sink(cfg config.ExportConfig) *S3Sink {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		endpoint = &url.URL{Scheme: "https", Host: cfg.Endpoint}
+	}
+
+	return &S3Sink{
+		endpoint:        endpoint,
+		region:          cfg.Region,
+		bucket:          cfg.Bucket,
+		prefix:          strings.Trim(cfg.Prefix, "/"),
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		pathStyle:       cfg.PathStyle,
+		httpClient:      &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Put uploads body to prefix/key
+func (s *S3Sink) Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	objectKey, objectURL := s.object(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	creds := awsv4.Credentials{AccessKeyID: s.accessKeyID, SecretAccessKey: s.secretAccessKey}
+	awsv4.Sign(req, awsv4.PayloadHash(body), creds, s.region, "s3", time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", objectKey, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("object storage error uploading %s: status %d, body: %s", objectKey, resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// PutStream uploads size bytes read from body to prefix/key without holding
+// them in memory. The body isn't part of the signature, TLS protects it.
+func (s *S3Sink) PutStream(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	objectKey, objectURL := s.object(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	creds := awsv4.Credentials{AccessKeyID: s.accessKeyID, SecretAccessKey: s.secretAccessKey}
+	awsv4.Sign(req, awsv4.UnsignedPayload, creds, s.region, "s3", time.Now())
+
+	// Large objects take longer than the client timeout, ctx bounds them
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", objectKey, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("object storage error uploading %s: status %d, body: %s", objectKey, resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// PresignGet returns a URL downloading prefix/key for expires without
+// credentials. A filename makes browsers save the object under that name.
+func (s *S3Sink) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	_, objectURL := s.object(key)
+
+	req, err := http.NewRequest(http.MethodGet, objectURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create download request: %w", err)
+	}
+	if filename != "" {
+		query := req.URL.Query()
+		query.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		req.URL.RawQuery = query.Encode()
+	}
+
+	creds := awsv4.Credentials{AccessKeyID: s.accessKeyID, SecretAccessKey: s.secretAccessKey}
+	return awsv4.Presign(req, creds, s.region, "s3", time.Now(), expires), nil
+}
+
+// Get downloads prefix/key. It returns nil, nil when the object does not exist.
+func (s *S3Sink) Get(ctx context.Context, key string) ([]byte, error) {
+	objectKey, objectURL := s.object(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	creds := awsv4.Credentials{AccessKeyID: s.accessKeyID, SecretAccessKey: s.secretAccessKey}
+	awsv4.Sign(req, awsv4.PayloadHash(nil), creds, s.region, "s3", time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", objectKey, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("object storage error downloading %s: status %d, body: %s", objectKey, resp.StatusCode, string(respBody))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", objectKey, err)
+	}
+	return body, nil
+}
+
+// object returns the prefixed object key and its URL
+func (s *S3Sink) object(key string) (string, string) {
+	objectKey := strings.TrimLeft(key, "/")
+	if s.prefix != "" {
+		objectKey = s.prefix + "/" + objectKey
+	}
+
+	objectURL := *s.endpoint
+	if s.pathStyle {
+		objectURL.Path = "/" + s.bucket + "/" + objectKey
+	} else {
+		objectURL.Host = s.bucket + "." + s.endpoint.Host
+		objectURL.Path = "/" + objectKey
+	}
+
+	return objectKey, objectURL.String()
+}
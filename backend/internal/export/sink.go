@@ -0,0 +1,30 @@
+// Package export uploads analytics snapshots to object storage.
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Sink stores an object under a key
+type Sink interface {
+	Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error
+}
+
+// NewSinkFromConfig returns the configured sink, or nil when exporting is disabled
+func NewSinkFromConfig() (Sink, error) {
+	cfg := config.Export()
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("EXPORT_BUCKET, EXPORT_ACCESS_KEY_ID and EXPORT_SECRET_ACCESS_KEY must be set when EXPORT_ENABLED=true")
+	}
+
+	log.Printf("Analytics snapshots will be exported to %s/%s/%s", cfg.Endpoint, cfg.Bucket, cfg.Prefix)
+	return NewS3Sink(cfg), nil
+}
@@ -0,0 +1,183 @@
+// Package httpclient is the shared client for calls to third-party APIs. All
+// clients reuse one pool of connections, every attempt is traced as a span,
+// and failures that are safe to repeat are retried with backoff.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/baldybuilds/creatorsync/internal/httpclient"
+
+// IdempotencyKeyHeader marks a POST as safe to retry, for APIs such as Resend
+// that deduplicate requests carrying the same key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// retryStatuses are responses worth trying again after a wait
+var retryStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// sharedTransport pools connections across every client. A DefaultTransport
+// replaced by the caller, e.g. to reach fake APIs in tests, is used as is.
+var sharedTransport = sync.OnceValue(func() http.RoundTripper {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+	transport = transport.Clone()
+	transport.MaxIdleConnsPerHost = config.HTTPClient().MaxIdleConnsPerHost
+	return transport
+})
+
+// New returns a client for service, the API's name in spans and errors.
+// Requests are bounded by their context, the client has no timeout of its own.
+func New(service string) *http.Client {
+	return &http.Client{Transport: NewTransport(service, nil)}
+}
+
+// NewTransport wraps next, or the shared pooled transport when next is nil,
+// with tracing and retries
+func NewTransport(service string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = sharedTransport()
+	}
+	cfg := config.HTTPClient()
+	return &transport{
+		service:    service,
+		next:       next,
+		tracer:     otel.Tracer(tracerName),
+		attempts:   cfg.MaxAttempts,
+		minBackoff: cfg.RetryMinBackoff,
+		maxBackoff: cfg.RetryMaxBackoff,
+	}
+}
+
+type transport struct {
+	service    string
+	next       http.RoundTripper
+	tracer     trace.Tracer
+	attempts   int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := canRetry(req)
+	backoff := t.minBackoff
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind %s request body: %w", t.service, err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.attempt(req, attempt)
+		if !retryable || attempt >= t.attempts || !shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		wait := backoff + rand.N(backoff/2+1)
+		if after := retryAfter(resp); after > 0 {
+			wait = after
+		}
+		if wait > t.maxBackoff {
+			wait = t.maxBackoff
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff = min(backoff*2, t.maxBackoff)
+	}
+}
+
+// attempt sends req once inside its own span. Query strings are left out of
+// spans because some APIs take credentials there.
+func (t *transport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), t.service+" "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", t.service),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+			attribute.Int("http.request.resend_count", attempt-1),
+		),
+	)
+	defer span.End()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// canRetry reports whether sending req again can't repeat a side effect
+func canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return retryStatuses[resp.StatusCode]
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
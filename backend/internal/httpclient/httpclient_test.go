@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unavailableServer(t *testing.T, failures int32, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetriesUnavailableGet(t *testing.T) {
+	t.Setenv("HTTP_CLIENT_RETRY_MIN_BACKOFF", "1ms")
+	srv, calls := unavailableServer(t, 2, nil)
+
+	resp, err := New("test").Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGivesUpAfterMaxAttempts(t *testing.T) {
+	t.Setenv("HTTP_CLIENT_RETRY_MIN_BACKOFF", "1ms")
+	t.Setenv("HTTP_CLIENT_MAX_ATTEMPTS", "2")
+	srv, calls := unavailableServer(t, 5, nil)
+
+	resp, err := New("test").Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetriesPostOnlyWithIdempotencyKey(t *testing.T) {
+	t.Setenv("HTTP_CLIENT_RETRY_MIN_BACKOFF", "1ms")
+	srv, calls := unavailableServer(t, 1, nil)
+	client := New("test")
+
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	calls.Store(0)
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set(IdempotencyKeyHeader, "key")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHonoursRetryAfter(t *testing.T) {
+	t.Setenv("HTTP_CLIENT_RETRY_MIN_BACKOFF", "1ms")
+	srv, _ := unavailableServer(t, 1, http.Header{"Retry-After": {"1"}})
+
+	start := time.Now()
+	resp, err := New("test").Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,148 @@
+// Package i18n translates user-facing text: API error messages, alert and
+// report emails and generated insights. Catalogs are keyed by the English
+// source string, so untranslated text falls back to English and call sites
+// keep reading naturally. A locale is a bare language code such as "es".
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the locale of the source strings, used when nothing else matches
+const Default = "en"
+
+// names are the supported locales and the English name of their language,
+// which is how AI providers are told what to write in
+var names = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"pt": "Portuguese",
+	"fr": "French",
+	"de": "German",
+}
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalogs maps locale to source string to translation
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read catalogs: %v", err))
+	}
+
+	catalogs := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", entry.Name(), err))
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	return catalogs
+}
+
+// Supported lists the supported locales, sorted
+func Supported() []string {
+	locales := make([]string, 0, len(names))
+	for locale := range names {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Normalize reduces a language tag such as "pt-BR" or "es_MX" to a supported
+// locale, or "" when the language isn't supported
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := names[tag]; !ok {
+		return ""
+	}
+	return tag
+}
+
+// Name is the English name of the locale's language
+func Name(locale string) string {
+	if name, ok := names[Normalize(locale)]; ok {
+		return name
+	}
+	return names[Default]
+}
+
+// FromAcceptLanguage picks the supported locale the client prefers most from
+// an Accept-Language header, or "" when none is supported
+func FromAcceptLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		locale := Normalize(tag)
+		if locale != "" && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+// Lookup returns the translation of a source string, or the string itself
+func Lookup(locale, msg string) string {
+	if translated, ok := catalogs[Normalize(locale)][msg]; ok && translated != "" {
+		return translated
+	}
+	return msg
+}
+
+// T translates a source string and, when args are given, formats it like
+// fmt.Sprintf. Translations keep the verbs of the source in the same order.
+func T(locale, format string, args ...any) string {
+	format = Lookup(locale, format)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context carrying the locale. An unsupported or empty
+// locale leaves the context unchanged.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	if locale = Normalize(locale); locale == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the locale carried by the context, or Default. Fiber
+// request contexts carry the locale set by Middleware.
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default
+}
@@ -0,0 +1,117 @@
+package i18n
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogsMatchSourceStrings(t *testing.T) {
+	require.NotEmpty(t, catalogs)
+
+	var reference map[string]string
+	for locale, catalog := range catalogs {
+		_, supported := names[locale]
+		assert.True(t, supported, "catalog %s is not a supported locale", locale)
+		assert.NotEqual(t, Default, locale, "the default locale needs no catalog")
+
+		// Every catalog translates the same strings
+		if reference == nil {
+			reference = catalog
+		}
+		assert.Len(t, catalog, len(reference), locale)
+
+		for source, translated := range catalog {
+			_, ok := reference[source]
+			assert.True(t, ok, "%s translates unknown string %q", locale, source)
+			assert.NotEmpty(t, translated, "%s: %q", locale, source)
+			assert.Equal(t, verbPattern.FindAllString(source, -1), verbPattern.FindAllString(translated, -1),
+				"%s: %q changes the format verbs", locale, source)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "Informe no encontrado", T("es", "Report not found"))
+	assert.Equal(t, "Report not found", T("en", "Report not found"))
+	assert.Equal(t, "Report not found", T("ja", "Report not found"))
+	assert.Equal(t, "Not in any catalog", T("es", "Not in any catalog"))
+
+	assert.Equal(t, "Deine Videos haben durchschnittlich 75 Aufrufe", T("de-DE", "Your videos average %d views", 75))
+	assert.Equal(t, "Your videos average 75 views", T("", "Your videos average %d views", 75))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "pt", Normalize("pt-BR"))
+	assert.Equal(t, "es", Normalize(" ES_mx "))
+	assert.Equal(t, "en", Normalize("en"))
+	assert.Equal(t, "", Normalize("ja"))
+	assert.Equal(t, "", Normalize(""))
+
+	assert.Equal(t, "Spanish", Name("es"))
+	assert.Equal(t, "English", Name("xx"))
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	assert.Equal(t, "fr", FromAcceptLanguage("fr-CA,fr;q=0.9,en;q=0.8"))
+	assert.Equal(t, "de", FromAcceptLanguage("ja;q=1, de;q=0.7, en;q=0.5"))
+	assert.Equal(t, "en", FromAcceptLanguage("en-US,es;q=0.5"))
+	assert.Equal(t, "es", FromAcceptLanguage("en;q=0.2,es"))
+	assert.Equal(t, "", FromAcceptLanguage("ja,zh;q=0.9"))
+	assert.Equal(t, "", FromAcceptLanguage(""))
+}
+
+func TestContextLocale(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, Default, FromContext(ctx))
+	assert.Equal(t, ctx, WithLocale(ctx, "ja"))
+	assert.Equal(t, "pt", FromContext(WithLocale(ctx, "pt-PT")))
+}
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Report not found", "id": 7})
+	})
+	app.Get("/user", UserLocale(func(*fiber.Ctx) string { return "de" }), func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
+	})
+	app.Get("/locale", func(c *fiber.Ctx) error {
+		return c.SendString(FromContext(c.Context()))
+	})
+
+	get := func(path, acceptLanguage string) (int, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/missing", "es-ES,es;q=0.9")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.JSONEq(t, `{"error": "Informe no encontrado", "id": 7}`, body)
+
+	_, body = get("/missing", "")
+	assert.JSONEq(t, `{"error": "Report not found", "id": 7}`, body)
+
+	// The user's saved locale wins over the header
+	_, body = get("/user", "fr")
+	assert.JSONEq(t, `{"error": "Benutzer nicht authentifiziert"}`, body)
+
+	_, body = get("/locale", "pt-BR")
+	assert.Equal(t, "pt", body)
+}
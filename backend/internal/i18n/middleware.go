@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware sets the request locale from the Accept-Language header and
+// translates the "error" message of JSON error responses into it. Handlers
+// read the locale with Locale(c), or FromContext(c.UserContext()) in services.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if locale := FromAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)); locale != "" {
+			SetLocale(c, locale)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if locale := Locale(c); locale != Default {
+			translateError(c, locale)
+		}
+		return nil
+	}
+}
+
+// UserLocale overrides the Accept-Language locale with the one resolve returns
+// for the request, typically the authenticated user's saved locale. An empty
+// locale keeps the current one.
+func UserLocale(resolve func(c *fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if locale := resolve(c); locale != "" {
+			SetLocale(c, locale)
+		}
+		return c.Next()
+	}
+}
+
+// SetLocale sets the locale of the request
+func SetLocale(c *fiber.Ctx, locale string) {
+	if locale = Normalize(locale); locale != "" {
+		c.Locals(localeKey{}, locale)
+	}
+}
+
+// Locale returns the locale of the request, or Default
+func Locale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals(localeKey{}).(string); ok {
+		return locale
+	}
+	return Default
+}
+
+// translateError rewrites {"error": "..."} bodies of 4xx/5xx JSON responses.
+// Other fields are kept, and messages without a translation are left alone.
+func translateError(c *fiber.Ctx, locale string) {
+	resp := c.Response()
+	if resp.StatusCode() < fiber.StatusBadRequest ||
+		!bytes.HasPrefix(resp.Header.ContentType(), []byte(fiber.MIMEApplicationJSON)) {
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		return
+	}
+	var msg string
+	if err := json.Unmarshal(body["error"], &msg); err != nil {
+		return
+	}
+
+	translated := Lookup(locale, msg)
+	if translated == msg {
+		return
+	}
+	encoded, err := json.Marshal(translated)
+	if err != nil {
+		return
+	}
+	body["error"] = encoded
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	resp.SetBody(data)
+	c.Set(fiber.HeaderContentLanguage, locale)
+}
@@ -0,0 +1,112 @@
+package insights
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+const (
+	anthropicURL          = "https://api.anthropic.com/v1/messages"
+	anthropicVersion      = "2023-06-01"
+	defaultAnthropicModel = "claude-3-5-haiku-latest"
+)
+
+// Anthropic generates insights with the Messages API
+type Anthropic struct {
+	apiKey     string
+	model      string
+	url        string
+	httpClient *http.Client
+}
+
+func NewAnthropic(cfg config.InsightsConfig) *Anthropic {
+	model := cfg.Model
+	if model == "" {
+		model = defaultAnthropicModel
+	}
+	return &Anthropic{
+		apiKey:     cfg.APIKey,
+		model:      model,
+		url:        anthropicURL,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (p *Anthropic) Name() string  { return config.InsightsProviderAnthropic }
+func (p *Anthropic) Model() string { return p.model }
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system"`
+	Messages  []anthropicMessage `json:"messages"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func (p *Anthropic) Generate(ctx context.Context, in Input) ([]string, error) {
+	prompt, err := userPrompt(in)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(anthropicRequest{
+		Model:     p.model,
+		MaxTokens: 1024,
+		System:    instructions(in),
+		Messages:  []anthropicMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Anthropic request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Anthropic request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("Anthropic API error: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var decoded anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Anthropic response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range decoded.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return parseInsights(text.String())
+}
@@ -0,0 +1,145 @@
+// Package insights turns a creator's weekly metrics into a few natural-language
+// insights using a hosted language model. Providers are swappable and the
+// whole feature is off unless INSIGHTS_PROVIDER is set.
+package insights
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// maxInsights caps how many insights are kept from a reply
+const maxInsights = 5
+
+// ErrNoInsights is returned when a model response contains no usable insights
+var ErrNoInsights = errors.New("model returned no insights")
+
+// Provider generates insights from a week of metrics
+type Provider interface {
+	Name() string
+	Model() string
+	Generate(ctx context.Context, in Input) ([]string, error)
+}
+
+// Input is the metrics summary sent to the model. Only aggregates and titles
+// are included, never viewer identities.
+type Input struct {
+	Period       string   `json:"period"`
+	Metrics      []Metric `json:"metrics"`
+	TopVideos    []Video  `json:"top_videos"`
+	TopGames     []Game   `json:"top_games"`
+	Observations []string `json:"observations,omitempty"`
+	// Language is the English name of the language to write in, e.g. "Spanish".
+	// Empty means English.
+	Language string `json:"-"`
+}
+
+type Metric struct {
+	Name          string  `json:"name"`
+	Current       int     `json:"current"`
+	Previous      int     `json:"previous"`
+	Change        int     `json:"change"`
+	PercentChange float64 `json:"percent_change"`
+}
+
+type Video struct {
+	Title           string `json:"title"`
+	Type            string `json:"type"`
+	Views           int    `json:"views"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+type Game struct {
+	Name           string  `json:"name"`
+	Streams        int     `json:"streams"`
+	Hours          float64 `json:"hours"`
+	AverageViewers float64 `json:"average_viewers"`
+}
+
+// NewProviderFromConfig returns the configured provider, or nil when insights
+// are disabled
+func NewProviderFromConfig() (Provider, error) {
+	cfg := config.Insights()
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("an API key is required for INSIGHTS_PROVIDER=%s", cfg.Provider)
+	}
+
+	switch cfg.Provider {
+	case config.InsightsProviderOpenAI:
+		return NewOpenAI(cfg), nil
+	case config.InsightsProviderAnthropic:
+		return NewAnthropic(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown INSIGHTS_PROVIDER %q (valid: none, openai, anthropic)", cfg.Provider)
+	}
+}
+
+const systemPrompt = `You are an analytics assistant for a Twitch creator dashboard.
+Given a JSON summary of the creator's last week, write between 3 and 5 short,
+specific insights (one or two sentences each) the creator can act on. Refer to
+the numbers provided, do not invent data, and skip generic advice.
+Respond with only a JSON array of strings.`
+
+// instructions is the system prompt for the input's language
+func instructions(in Input) string {
+	if in.Language == "" || in.Language == "English" {
+		return systemPrompt
+	}
+	return systemPrompt + "\nWrite the insights in " + in.Language + "."
+}
+
+// userPrompt encodes the input for the model
+func userPrompt(in Input) (string, error) {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode insights input: %w", err)
+	}
+	return "Weekly metrics:\n" + string(data), nil
+}
+
+// parseInsights reads the model's reply: a JSON array of strings, possibly in a
+// code fence, or failing that a bulleted or numbered list. At most maxInsights
+// are kept.
+func parseInsights(reply string) ([]string, error) {
+	reply = strings.TrimSpace(reply)
+	if start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]"); start >= 0 && end > start {
+		var list []string
+		if err := json.Unmarshal([]byte(reply[start:end+1]), &list); err == nil {
+			return clean(list)
+		}
+	}
+
+	var list []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		trimmed := strings.TrimLeft(line, "-*•0123456789.) ")
+		if trimmed != line && trimmed != "" {
+			list = append(list, trimmed)
+		}
+	}
+	return clean(list)
+}
+
+func clean(list []string) ([]string, error) {
+	var out []string
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+		if len(out) == maxInsights {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrNoInsights
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package insights
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+var testInput = Input{
+	Period:  "week starting 2025-06-09",
+	Metrics: []Metric{{Name: "followers", Current: 110, Previous: 100, Change: 10, PercentChange: 10}},
+}
+
+func TestParseInsights(t *testing.T) {
+	got, err := parseInsights("```json\n[\"One.\", \"Two.\", \" \", \"Three.\"]\n```")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"One.", "Two.", "Three."}, got)
+
+	got, err = parseInsights("Here you go:\n- First\n* Second\n3. Third\n4) Fourth\n5. Fifth\n6. Sixth")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First", "Second", "Third", "Fourth", "Fifth"}, got)
+
+	_, err = parseInsights("I can't help with that.")
+	assert.Equal(t, ErrNoInsights, err)
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "gpt-4o-mini", req.Model)
+		require.Len(t, req.Messages, 2)
+		assert.Equal(t, "system", req.Messages[0].Role)
+		assert.Contains(t, req.Messages[1].Content, `"followers"`)
+
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"[\"Followers are up 10%.\",\"Keep streaming.\",\"Try mornings.\"]"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAI(config.InsightsConfig{APIKey: "sk-test", Timeout: time.Second})
+	provider.url = server.URL
+
+	got, err := provider.Generate(context.Background(), testInput)
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, "Followers are up 10%.", got[0])
+}
+
+func TestAnthropicGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "sk-ant-test", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+
+		var req anthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "custom-model", req.Model)
+		assert.Equal(t, systemPrompt, req.System)
+
+		w.Write([]byte(`{"content":[{"type":"text","text":"- Followers are up 10%.\n- Keep streaming.\n- Try mornings."}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropic(config.InsightsConfig{APIKey: "sk-ant-test", Model: "custom-model", Timeout: time.Second})
+	provider.url = server.URL
+
+	got, err := provider.Generate(context.Background(), testInput)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Followers are up 10%.", "Keep streaming.", "Try mornings."}, got)
+}
+
+func TestProviderErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"rate limited"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAI(config.InsightsConfig{APIKey: "sk-test", Timeout: time.Second})
+	provider.url = server.URL
+
+	_, err := provider.Generate(context.Background(), testInput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+}
+
+func TestNewProviderFromConfig(t *testing.T) {
+	t.Setenv("INSIGHTS_PROVIDER", "none")
+	provider, err := NewProviderFromConfig()
+	require.NoError(t, err)
+	assert.Nil(t, provider)
+
+	t.Setenv("INSIGHTS_PROVIDER", "anthropic")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	_, err = NewProviderFromConfig()
+	assert.Error(t, err)
+
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	provider, err = NewProviderFromConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", provider.Name())
+}
+
+func TestInstructionsLanguage(t *testing.T) {
+	assert.Equal(t, systemPrompt, instructions(Input{}))
+	assert.Equal(t, systemPrompt, instructions(Input{Language: "English"}))
+	assert.True(t, strings.HasSuffix(instructions(Input{Language: "Spanish"}), "Write the insights in Spanish."))
+}
@@ -0,0 +1,105 @@
+package insights
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+const (
+	openAIURL          = "https://api.openai.com/v1/chat/completions"
+	defaultOpenAIModel = "gpt-4o-mini"
+)
+
+// OpenAI generates insights with the Chat Completions API
+type OpenAI struct {
+	apiKey     string
+	model      string
+	url        string
+	httpClient *http.Client
+}
+
+func NewOpenAI(cfg config.InsightsConfig) *OpenAI {
+	model := cfg.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAI{
+		apiKey:     cfg.APIKey,
+		model:      model,
+		url:        openAIURL,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (p *OpenAI) Name() string  { return config.InsightsProviderOpenAI }
+func (p *OpenAI) Model() string { return p.model }
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAI) Generate(ctx context.Context, in Input) ([]string, error) {
+	prompt, err := userPrompt(in)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(openAIRequest{
+		Model: p.model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: instructions(in)},
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.4,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAI request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAI request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenAI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("OpenAI API error: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var decoded openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAI response: %w", err)
+	}
+	if len(decoded.Choices) == 0 {
+		return nil, ErrNoInsights
+	}
+
+	return parseInsights(decoded.Choices[0].Message.Content)
+}
@@ -0,0 +1,269 @@
+// Package loadtest replays dashboard traffic against a deployment and checks
+// its latency against performance budgets. Each worker signs in as one user
+// after another and loads the dashboard pages the way the frontend does.
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint is a dashboard request. Visits take the queries in turn.
+type Endpoint struct {
+	Name    string
+	Path    string
+	Queries []string
+}
+
+// DashboardEndpoints are the requests of a dashboard visit, in the order the
+// frontend makes them, with the ranges users pick most
+var DashboardEndpoints = []Endpoint{
+	{Name: "overview", Path: "/api/analytics/overview", Queries: []string{"days=7", "days=30"}},
+	{Name: "enhanced", Path: "/api/analytics/enhanced", Queries: []string{"days=30", "days=7", "days=90"}},
+	{Name: "charts", Path: "/api/analytics/charts", Queries: []string{"days=30", "days=90"}},
+}
+
+// Budget is the latency and error rate every endpoint has to stay within
+type Budget struct {
+	P95 time.Duration
+	P99 time.Duration
+	// MaxErrorRate is the share of requests that may fail (0-1)
+	MaxErrorRate float64
+}
+
+// Config describes a run
+type Config struct {
+	// Target is the base URL of the API, e.g. https://staging.example.com
+	Target      string
+	Users       []string
+	Concurrency int
+	Duration    time.Duration
+	// ThinkTime is the pause between a user's requests
+	ThinkTime time.Duration
+	Budget    Budget
+	Endpoints []Endpoint
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// EndpointReport holds the latency percentiles of one endpoint in milliseconds
+type EndpointReport struct {
+	Name     string         `json:"name"`
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Statuses map[string]int `json:"statuses"`
+	P50      float64        `json:"p50_ms"`
+	P90      float64        `json:"p90_ms"`
+	P95      float64        `json:"p95_ms"`
+	P99      float64        `json:"p99_ms"`
+	Max      float64        `json:"max_ms"`
+}
+
+// Report is the outcome of a run. OK is false when a budget was exceeded.
+type Report struct {
+	Target            string           `json:"target"`
+	StartedAt         time.Time        `json:"started_at"`
+	DurationSeconds   float64          `json:"duration_seconds"`
+	Users             int              `json:"users"`
+	Concurrency       int              `json:"concurrency"`
+	Requests          int              `json:"requests"`
+	RequestsPerSecond float64          `json:"requests_per_second"`
+	Endpoints         []EndpointReport `json:"endpoints"`
+	BudgetP95         float64          `json:"budget_p95_ms"`
+	BudgetP99         float64          `json:"budget_p99_ms"`
+	MaxErrorRate      float64          `json:"max_error_rate"`
+	OK                bool             `json:"ok"`
+	Violations        []string         `json:"violations"`
+}
+
+// sample is the outcome of one request
+type sample struct {
+	endpoint int
+	latency  time.Duration
+	status   int // 0 when the request failed before a response
+}
+
+// Run sends traffic until cfg.Duration has passed or ctx ends. Tokens are
+// signed once per user, valid for the whole run.
+func Run(ctx context.Context, cfg Config, signer *Signer) (*Report, error) {
+	if len(cfg.Users) == 0 {
+		return nil, fmt.Errorf("no users to sign in as")
+	}
+	if len(cfg.Endpoints) == 0 {
+		cfg.Endpoints = DashboardEndpoints
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	concurrency := max(cfg.Concurrency, 1)
+	target := strings.TrimSuffix(cfg.Target, "/")
+
+	expiresAt := time.Now().Add(cfg.Duration + 10*time.Minute)
+	tokens := make([]string, len(cfg.Users))
+	for i, userID := range cfg.Users {
+		token, err := signer.Token(userID, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = token
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		wg      sync.WaitGroup
+	)
+	startedAt := time.Now()
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for visit := 0; ctx.Err() == nil; visit++ {
+				// Workers take the users in turn, so all of them are signed in
+				token := tokens[(worker+visit*concurrency)%len(tokens)]
+				for i, endpoint := range cfg.Endpoints {
+					url := target + endpoint.Path
+					if len(endpoint.Queries) > 0 {
+						url += "?" + endpoint.Queries[visit%len(endpoint.Queries)]
+					}
+					s, ok := send(ctx, client, url, token)
+					if !ok {
+						return
+					}
+					s.endpoint = i
+					mu.Lock()
+					samples = append(samples, s)
+					mu.Unlock()
+
+					if cfg.ThinkTime > 0 {
+						select {
+						case <-ctx.Done():
+							return
+						case <-time.After(cfg.ThinkTime):
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(startedAt)
+
+	report := summarize(cfg.Endpoints, samples, cfg.Budget)
+	report.Target = target
+	report.StartedAt = startedAt.UTC()
+	report.DurationSeconds = elapsed.Seconds()
+	report.Users = len(cfg.Users)
+	report.Concurrency = concurrency
+	if elapsed > 0 {
+		report.RequestsPerSecond = float64(report.Requests) / elapsed.Seconds()
+	}
+	return report, nil
+}
+
+// send makes one request and times it until the body is read. It reports
+// false for requests cut short by the end of the run, which aren't counted.
+func send(ctx context.Context, client *http.Client, url, token string) (sample, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return sample{}, false
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{latency: time.Since(start)}, ctx.Err() == nil
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if err != nil && ctx.Err() != nil {
+		return sample{}, false
+	}
+	return sample{latency: time.Since(start), status: resp.StatusCode}, true
+}
+
+// summarize computes the percentiles of each endpoint and checks them
+// against the budget
+func summarize(endpoints []Endpoint, samples []sample, budget Budget) *Report {
+	report := &Report{
+		Endpoints:    make([]EndpointReport, len(endpoints)),
+		BudgetP95:    milliseconds(budget.P95),
+		BudgetP99:    milliseconds(budget.P99),
+		MaxErrorRate: budget.MaxErrorRate,
+		Violations:   []string{},
+	}
+
+	latencies := make([][]time.Duration, len(endpoints))
+	for i, endpoint := range endpoints {
+		report.Endpoints[i] = EndpointReport{Name: endpoint.Name, Statuses: map[string]int{}}
+	}
+	for _, s := range samples {
+		endpoint := &report.Endpoints[s.endpoint]
+		endpoint.Requests++
+		status := "error"
+		if s.status > 0 {
+			status = strconv.Itoa(s.status)
+		}
+		endpoint.Statuses[status]++
+		if s.status == 0 || s.status >= http.StatusBadRequest {
+			endpoint.Errors++
+		}
+		latencies[s.endpoint] = append(latencies[s.endpoint], s.latency)
+	}
+
+	for i := range report.Endpoints {
+		endpoint := &report.Endpoints[i]
+		report.Requests += endpoint.Requests
+		if endpoint.Requests == 0 {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s: no requests completed", endpoint.Name))
+			continue
+		}
+
+		sorted := latencies[i]
+		slices.Sort(sorted)
+		endpoint.P50 = milliseconds(percentile(sorted, 50))
+		endpoint.P90 = milliseconds(percentile(sorted, 90))
+		endpoint.P95 = milliseconds(percentile(sorted, 95))
+		endpoint.P99 = milliseconds(percentile(sorted, 99))
+		endpoint.Max = milliseconds(sorted[len(sorted)-1])
+
+		if budget.P95 > 0 && percentile(sorted, 95) > budget.P95 {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s: p95 of %.0fms is over the %.0fms budget", endpoint.Name, endpoint.P95, report.BudgetP95))
+		}
+		if budget.P99 > 0 && percentile(sorted, 99) > budget.P99 {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s: p99 of %.0fms is over the %.0fms budget", endpoint.Name, endpoint.P99, report.BudgetP99))
+		}
+		if errorRate := float64(endpoint.Errors) / float64(endpoint.Requests); errorRate > budget.MaxErrorRate {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s: %.1f%% of requests failed, over the %.1f%% budget", endpoint.Name, errorRate*100, budget.MaxErrorRate*100))
+		}
+	}
+
+	report.OK = len(report.Violations) == 0
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
@@ -0,0 +1,178 @@
+package loadtest
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSigner(t *testing.T) *Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return NewSigner(key, "test-key")
+}
+
+func TestSignerTokenVerifiesAgainstJWKS(t *testing.T) {
+	signer := testSigner(t)
+
+	// The key is read back from a PKCS #8 file, as openssl writes them
+	der, err := x509.MarshalPKCS8PrivateKey(signer.key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	signer, err = LoadSigner(path, "test-key")
+	require.NoError(t, err)
+
+	token, err := signer.Token("user_loadtest_1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	data, err := signer.JWKS()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &jwks))
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "test-key", jwks.Keys[0].Kid)
+
+	n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+	require.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].E)
+	require.NoError(t, err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature))
+
+	var claims map[string]any
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, "user_loadtest_1", claims["sub"])
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 95*time.Millisecond, percentile(latencies, 95))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, 3*time.Millisecond, percentile([]time.Duration{3 * time.Millisecond}, 99))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func TestSummarizeChecksBudgets(t *testing.T) {
+	endpoints := []Endpoint{{Name: "overview"}, {Name: "enhanced"}, {Name: "charts"}}
+	var samples []sample
+	for i := 0; i < 100; i++ {
+		samples = append(samples, sample{endpoint: 0, latency: 100 * time.Millisecond, status: http.StatusOK})
+		enhanced := sample{endpoint: 1, latency: 100 * time.Millisecond, status: http.StatusOK}
+		if i >= 90 {
+			enhanced.latency = 800 * time.Millisecond
+		}
+		if i == 0 {
+			enhanced.status = http.StatusInternalServerError
+		}
+		samples = append(samples, enhanced)
+	}
+	samples = append(samples, sample{endpoint: 0, latency: 2 * time.Second})
+
+	report := summarize(endpoints, samples, Budget{P95: 500 * time.Millisecond, P99: time.Second, MaxErrorRate: 0.005})
+	assert.False(t, report.OK)
+	assert.Equal(t, 201, report.Requests)
+
+	overview := report.Endpoints[0]
+	assert.Equal(t, 101, overview.Requests)
+	assert.Equal(t, 1, overview.Errors)
+	assert.Equal(t, map[string]int{"200": 100, "error": 1}, overview.Statuses)
+	assert.Equal(t, 100.0, overview.P99)
+	assert.Equal(t, 2000.0, overview.Max)
+
+	assert.Equal(t, []string{
+		"overview: 1.0% of requests failed, over the 0.5% budget",
+		"enhanced: p95 of 800ms is over the 500ms budget",
+		"enhanced: 1.0% of requests failed, over the 0.5% budget",
+		"charts: no requests completed",
+	}, report.Violations)
+}
+
+func TestRunCyclesUsersThroughDashboard(t *testing.T) {
+	signer := testSigner(t)
+	var (
+		mu       sync.Mutex
+		paths    = map[string]int{}
+		subjects = map[string]bool{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		payload, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var claims struct {
+			Sub string `json:"sub"`
+		}
+		_ = json.Unmarshal(payload, &claims)
+
+		mu.Lock()
+		paths[r.URL.Path]++
+		subjects[claims.Sub] = true
+		mu.Unlock()
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{
+		Target:      server.URL + "/",
+		Users:       []string{"user_a", "user_b", "user_c"},
+		Concurrency: 2,
+		Duration:    200 * time.Millisecond,
+		Budget:      Budget{P95: time.Second, P99: time.Second},
+	}, signer)
+	require.NoError(t, err)
+
+	assert.True(t, report.OK, report.Violations)
+	assert.Equal(t, server.URL, report.Target)
+	assert.Positive(t, report.RequestsPerSecond)
+	require.Len(t, report.Endpoints, 3)
+	for _, endpoint := range report.Endpoints {
+		assert.Positive(t, endpoint.Requests, endpoint.Name)
+		assert.Zero(t, endpoint.Errors, endpoint.Name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, paths, 3)
+	assert.Positive(t, paths["/api/analytics/charts"])
+	assert.Equal(t, map[string]bool{"user_a": true, "user_b": true, "user_c": true}, subjects)
+}
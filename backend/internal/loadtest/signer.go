@@ -0,0 +1,91 @@
+package loadtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+)
+
+// Signer issues RS256 session tokens in the shape of Clerk's. The target only
+// accepts them when CLERK_JWKS_URL serves the signer's JWKS.
+type Signer struct {
+	key   *rsa.PrivateKey
+	keyID string
+}
+
+func NewSigner(key *rsa.PrivateKey, keyID string) *Signer {
+	return &Signer{key: key, keyID: keyID}
+}
+
+// LoadSigner reads a PEM encoded RSA private key, PKCS #1 or PKCS #8
+func LoadSigner(path, keyID string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewSigner(key, keyID), nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+	return NewSigner(key, keyID), nil
+}
+
+// Token signs a session token of userID valid until expiresAt
+func (s *Signer) Token(userID string, expiresAt time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": s.keyID, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"sub": userID,
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// JWKS returns the signer's public key as a JWKS document for CLERK_JWKS_URL
+func (s *Signer) JWKS() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kid": s.keyID,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
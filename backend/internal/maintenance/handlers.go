@@ -0,0 +1,94 @@
+package maintenance
+
+import (
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Path is the admin endpoint, exempt from read-only mode so it can be turned off
+const Path = "/api/admin/maintenance"
+
+type Handlers struct {
+	maintenance *Switch
+	audit       *audit.Logger
+}
+
+func NewHandlers(maintenance *Switch) *Handlers {
+	return &Handlers{maintenance: maintenance}
+}
+
+// UseAuditLog records maintenance changes. Call it before RegisterRoutes.
+func (h *Handlers) UseAuditLog(logger *audit.Logger) {
+	h.audit = logger
+}
+
+// RegisterRoutes registers the maintenance switch on an admin-only router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/maintenance", h.GetState)
+	router.Put("/maintenance", h.UpdateState)
+}
+
+// GetState returns the current maintenance mode
+func (h *Handlers) GetState(c *fiber.Ctx) error {
+	return c.JSON(h.maintenance.State())
+}
+
+// updateRequest changes only the fields that are set
+type updateRequest struct {
+	ReadOnly          *bool   `json:"read_only"`
+	PauseCollections  *bool   `json:"pause_collections"`
+	Message           *string `json:"message"`
+	RetryAfterSeconds *int    `json:"retry_after_seconds"`
+}
+
+// UpdateState switches read-only mode and paused collections on or off
+func (h *Handlers) UpdateState(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req updateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.RetryAfterSeconds != nil && *req.RetryAfterSeconds < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "retry_after_seconds can't be negative",
+		})
+	}
+
+	state := h.maintenance.State()
+	if req.ReadOnly != nil {
+		state.ReadOnly = *req.ReadOnly
+	}
+	if req.PauseCollections != nil {
+		state.PauseCollections = *req.PauseCollections
+	}
+	if req.Message != nil {
+		state.Message = *req.Message
+	}
+	if req.RetryAfterSeconds != nil {
+		state.RetryAfter = *req.RetryAfterSeconds
+	}
+	now := time.Now()
+	state.UpdatedAt = &now
+	state.UpdatedBy = user.ID
+	h.maintenance.Set(state)
+
+	log.Printf("🚧 Maintenance updated by %s: read-only=%t, collections paused=%t", user.ID, state.ReadOnly, h.maintenance.CollectionsPaused())
+	h.audit.RecordRequest(c, user.ID, audit.ActionMaintenanceUpdate, "", map[string]any{
+		"read_only":         state.ReadOnly,
+		"pause_collections": state.PauseCollections,
+	})
+
+	return c.JSON(state)
+}
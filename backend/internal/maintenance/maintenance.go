@@ -0,0 +1,68 @@
+// Package maintenance is the operational switch that puts the API into
+// read-only mode and pauses data collection, e.g. during database migrations
+// or incidents.
+package maintenance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// State is the current maintenance mode
+type State struct {
+	// ReadOnly rejects write requests with 503. It also pauses collections.
+	ReadOnly         bool   `json:"read_only"`
+	PauseCollections bool   `json:"pause_collections"`
+	Message          string `json:"message"`
+	// RetryAfter is in seconds, as sent in the Retry-After header
+	RetryAfter int        `json:"retry_after_seconds"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy  string     `json:"updated_by,omitempty"`
+}
+
+// Switch holds the maintenance state of this server instance. It starts from
+// the MAINTENANCE_* configuration and is changed by admins at runtime.
+type Switch struct {
+	mu    sync.RWMutex
+	state State
+}
+
+// New returns a switch in the configured state
+func New(cfg config.MaintenanceConfig) *Switch {
+	return &Switch{
+		state: State{
+			ReadOnly:         cfg.ReadOnly,
+			PauseCollections: cfg.PauseCollections,
+			Message:          cfg.Message,
+			RetryAfter:       int(cfg.RetryAfter / time.Second),
+		},
+	}
+}
+
+// State returns the current state
+func (s *Switch) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Set replaces the state
+func (s *Switch) Set(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+}
+
+// ReadOnly reports whether write requests are rejected
+func (s *Switch) ReadOnly() bool {
+	return s.State().ReadOnly
+}
+
+// CollectionsPaused reports whether collections should be skipped. Read-only
+// mode always pauses them since they write.
+func (s *Switch) CollectionsPaused() bool {
+	state := s.State()
+	return state.ReadOnly || state.PauseCollections
+}
@@ -0,0 +1,61 @@
+package maintenance
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApp(s *Switch) *fiber.App {
+	app := fiber.New()
+	app.Use(s.Middleware(Path))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/api/analytics/overview", ok)
+	app.Post("/api/alerts/rules", ok)
+	app.Put(Path, ok)
+	return app
+}
+
+func TestMiddlewareRejectsWritesWhenReadOnly(t *testing.T) {
+	s := New(config.MaintenanceConfig{RetryAfter: 5 * time.Minute})
+	app := newApp(s)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/api/alerts/rules", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	s.Set(State{ReadOnly: true, RetryAfter: 300, Message: "Migrating"})
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodPost, "/api/alerts/rules", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "300", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	// Reads keep being served
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/api/analytics/overview", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "read-only", resp.Header.Get("X-Maintenance-Mode"))
+
+	// The switch itself can still be turned off
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodPut, Path, nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestCollectionsPaused(t *testing.T) {
+	s := New(config.MaintenanceConfig{})
+	assert.False(t, s.CollectionsPaused())
+
+	s.Set(State{PauseCollections: true})
+	assert.True(t, s.CollectionsPaused())
+	assert.False(t, s.ReadOnly())
+
+	s.Set(State{ReadOnly: true})
+	assert.True(t, s.CollectionsPaused())
+}
@@ -0,0 +1,47 @@
+package maintenance
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware rejects POST, PUT, PATCH and DELETE requests with 503 and
+// Retry-After while the switch is read-only. Reads are served as usual, from
+// the data collected before maintenance started. Requests to the exempt paths,
+// such as the endpoint turning maintenance off, always pass.
+func (s *Switch) Middleware(exempt ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state := s.State()
+		if !state.ReadOnly {
+			return c.Next()
+		}
+
+		c.Set("X-Maintenance-Mode", "read-only")
+		if !isWrite(c.Method()) {
+			return c.Next()
+		}
+		for _, path := range exempt {
+			if c.Path() == path {
+				return c.Next()
+			}
+		}
+
+		if state.RetryAfter > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(state.RetryAfter))
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":       "CreatorSync is in maintenance mode, changes can't be saved right now",
+			"maintenance": true,
+			"message":     state.Message,
+		})
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// ErrTooLarge is returned for downloads over their size limit
+var ErrTooLarge = errors.New("file is too large")
+
+// DownloadToTempFile saves the body of url to a temporary file, so a large
+// clip isn't held in memory and its size is known before it is uploaded. The
+// file is positioned at its start, the caller closes and removes it.
+func DownloadToTempFile(ctx context.Context, client *http.Client, url string, maxBytes int64) (*os.File, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("failed to download: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, 0, fmt.Errorf("%w: over %d MB", ErrTooLarge, maxBytes>>20)
+	}
+
+	file, err := os.CreateTemp("", "creatorsync-clip-*.mp4")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create download file: %w", err)
+	}
+	size, err := io.Copy(file, io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		err = fmt.Errorf("failed to save download: %w", err)
+	} else if size > maxBytes {
+		err = fmt.Errorf("%w: over %d MB", ErrTooLarge, maxBytes>>20)
+	} else if _, err = file.Seek(0, io.SeekStart); err != nil {
+		err = fmt.Errorf("failed to save download: %w", err)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, err
+	}
+	return file, size, nil
+}
@@ -0,0 +1,67 @@
+package media
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	service *Service
+}
+
+func NewHandlers(service *Service) *Handlers {
+	return &Handlers{service: service}
+}
+
+// Thumbnail serves a collected video's thumbnail. It is public so <img> tags can
+// load it; ?w= picks one of ThumbnailWidths (320 by default) and
+// ?aspect=vertical a 9:16 crop for short-form covers.
+func (h *Handlers) Thumbnail(c *fiber.Ctx) error {
+	width := DefaultThumbnailWidth
+	if raw := c.Query("w"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || !IsValidWidth(parsed) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("w must be one of %v", ThumbnailWidths),
+			})
+		}
+		width = parsed
+	}
+	aspect, ok := ParseAspect(c.Query("aspect"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "aspect must be landscape or vertical",
+		})
+	}
+
+	videoID := c.Params("videoID")
+	thumb, err := h.service.GetThumbnail(c.UserContext(), videoID, width, aspect)
+	if err == ErrInvalidVideoID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid video ID",
+		})
+	}
+	if err != nil {
+		log.Printf("Error serving thumbnail for %s: %v", videoID, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to load thumbnail",
+		})
+	}
+	if thumb == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Thumbnail not found",
+		})
+	}
+
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.service.MaxAge().Seconds())))
+	c.Set("ETag", thumb.ETag)
+	if c.Get("If-None-Match") == thumb.ETag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set("Content-Type", thumb.ContentType)
+	return c.Send(thumb.Data)
+}
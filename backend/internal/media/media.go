@@ -0,0 +1,100 @@
+// Package media serves Twitch thumbnails through a resizing, caching proxy so
+// the frontend never hotlinks templated or expiring Twitch URLs.
+package media
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ThumbnailWidths are the widths the proxy renders; heights follow 16:9
+var ThumbnailWidths = []int{160, 320, 640, 1280}
+
+// DefaultThumbnailWidth is served when no width is requested
+const DefaultThumbnailWidth = 320
+
+// Aspect is the shape of a rendered thumbnail
+type Aspect string
+
+const (
+	// AspectLandscape is the 16:9 frame Twitch serves
+	AspectLandscape Aspect = "landscape"
+	// AspectVertical is a centered 9:16 crop, the cover format of Shorts,
+	// TikTok and Reels. It is cut from the 1280 wide source, so it is never
+	// wider than 405 pixels.
+	AspectVertical Aspect = "vertical"
+)
+
+// ParseAspect returns the aspect named by s, landscape when s is empty
+func ParseAspect(s string) (Aspect, bool) {
+	switch Aspect(s) {
+	case "", AspectLandscape:
+		return AspectLandscape, true
+	case AspectVertical:
+		return AspectVertical, true
+	}
+	return "", false
+}
+
+// ErrInvalidVideoID is returned for IDs that can't be Twitch video or clip IDs
+var ErrInvalidVideoID = errors.New("invalid video ID")
+
+// videoIDPattern matches VOD IDs (digits) and clip slugs (letters, digits, - and _)
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Thumbnail is a rendered thumbnail ready to serve
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	// ETag identifies the source URL and size, so a new upstream thumbnail gets a new tag
+	ETag string
+}
+
+// Source is the stored thumbnail URL of a video
+type Source struct {
+	VideoID      string `db:"video_id"`
+	VideoType    string `db:"video_type"`
+	ThumbnailURL string `db:"thumbnail_url"`
+}
+
+// IsValidWidth reports whether width is one of ThumbnailWidths
+func IsValidWidth(width int) bool {
+	for _, w := range ThumbnailWidths {
+		if w == width {
+			return true
+		}
+	}
+	return false
+}
+
+// needsRefresh reports whether a stored URL can't be served: missing, or the
+// placeholder Twitch returns while a VOD is still processing
+func needsRefresh(thumbnailURL string) bool {
+	return thumbnailURL == "" || strings.Contains(thumbnailURL, "/_404/")
+}
+
+// sizedURL fills in the %{width}/%{height} template Twitch uses for VOD thumbnails.
+// Clip thumbnails are fixed-size URLs and are returned unchanged.
+func sizedURL(thumbnailURL string, width, height int) string {
+	return strings.NewReplacer(
+		"%{width}", strconv.Itoa(width),
+		"%{height}", strconv.Itoa(height),
+	).Replace(thumbnailURL)
+}
+
+// HasThumbnail reports whether a stored thumbnail URL can be rendered as is
+func HasThumbnail(thumbnailURL string) bool {
+	return !needsRefresh(thumbnailURL)
+}
+
+// ThumbnailPath is the proxy path serving a video's thumbnail in the aspect
+func ThumbnailPath(videoID string, aspect Aspect) string {
+	path := "/api/media/thumbnails/" + url.PathEscape(videoID)
+	if aspect == AspectVertical {
+		path += "?aspect=vertical"
+	}
+	return path
+}
@@ -0,0 +1,225 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+type fakeRepository struct {
+	sources map[string]*Source
+	updated map[string]string
+}
+
+func (r *fakeRepository) GetThumbnailSource(ctx context.Context, videoID string) (*Source, error) {
+	if source, ok := r.sources[videoID]; ok {
+		copied := *source
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) UpdateThumbnailURL(ctx context.Context, videoID, thumbnailURL string) error {
+	r.updated[videoID] = thumbnailURL
+	r.sources[videoID].ThumbnailURL = thumbnailURL
+	return nil
+}
+
+type fakeVideos struct {
+	thumbnailURL string
+}
+
+func (f *fakeVideos) GetAppAccessToken(ctx context.Context) (*twitch.OAuthToken, error) {
+	return &twitch.OAuthToken{AccessToken: "app-token"}, nil
+}
+
+func (f *fakeVideos) GetVideosByID(ctx context.Context, token string, ids []string) ([]twitch.VideoInfo, error) {
+	return []twitch.VideoInfo{{ID: ids[0], ThumbnailURL: f.thumbnailURL}}, nil
+}
+
+// upstream serves a solid PNG at /thumb-WxH.png and 404s /expired.png
+func upstream(t *testing.T, fetches *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		if r.URL.Path == "/expired.png" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Always 1280x720 so resizing is exercised
+		img := image.NewRGBA(image.Rect(0, 0, 1280, 720))
+		for y := 0; y < 720; y++ {
+			for x := 0; x < 1280; x++ {
+				img.Set(x, y, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+			}
+		}
+		w.Header().Set("Content-Type", "image/png")
+		require.NoError(t, png.Encode(w, img))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestService(t *testing.T, sources map[string]*Source, videos VideoLookup) (*Service, *fakeRepository) {
+	repo := &fakeRepository{sources: sources, updated: map[string]string{}}
+	return NewService(repo, NewDiskStore(t.TempDir()), videos), repo
+}
+
+func TestGetThumbnailResizesAndCaches(t *testing.T) {
+	var fetches int32
+	server := upstream(t, &fetches)
+	svc, _ := newTestService(t, map[string]*Source{
+		"2201452511": {VideoID: "2201452511", VideoType: "archive", ThumbnailURL: server.URL + "/thumb-%{width}x%{height}.png"},
+	}, nil)
+	ctx := context.Background()
+
+	thumb, err := svc.GetThumbnail(ctx, "2201452511", 320, AspectLandscape)
+	require.NoError(t, err)
+	require.NotNil(t, thumb)
+	assert.Equal(t, "image/jpeg", thumb.ContentType)
+
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 320, 180), img.Bounds())
+
+	again, err := svc.GetThumbnail(ctx, "2201452511", 320, AspectLandscape)
+	require.NoError(t, err)
+	assert.Equal(t, thumb.ETag, again.ETag)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches), "second request should be served from the store")
+
+	// Another size is a separate entry
+	_, err = svc.GetThumbnail(ctx, "2201452511", 640, AspectLandscape)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestGetThumbnailVerticalCrop(t *testing.T) {
+	var fetches int32
+	server := upstream(t, &fetches)
+	svc, _ := newTestService(t, map[string]*Source{
+		"2201452511": {VideoID: "2201452511", VideoType: "archive", ThumbnailURL: server.URL + "/thumb-%{width}x%{height}.png"},
+	}, nil)
+	ctx := context.Background()
+
+	landscape, err := svc.GetThumbnail(ctx, "2201452511", 320, AspectLandscape)
+	require.NoError(t, err)
+
+	thumb, err := svc.GetThumbnail(ctx, "2201452511", 320, AspectVertical)
+	require.NoError(t, err)
+	require.NotNil(t, thumb)
+	assert.NotEqual(t, landscape.ETag, thumb.ETag)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches), "vertical crop is cached separately")
+
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 320, 568), img.Bounds())
+
+	// Wider than the 405 pixel crop is served at the crop's size
+	thumb, err = svc.GetThumbnail(ctx, "2201452511", 1280, AspectVertical)
+	require.NoError(t, err)
+	img, err = jpeg.Decode(bytes.NewReader(thumb.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 405, 720), img.Bounds())
+}
+
+func TestGetThumbnailRefreshesExpiredURL(t *testing.T) {
+	var fetches int32
+	server := upstream(t, &fetches)
+	fresh := server.URL + "/thumb-%{width}x%{height}.png"
+	svc, repo := newTestService(t, map[string]*Source{
+		"1": {VideoID: "1", VideoType: "archive", ThumbnailURL: server.URL + "/expired.png"},
+		"2": {VideoID: "2", VideoType: "archive", ThumbnailURL: "https://vod-secure.twitch.tv/_404/404_processing_%{width}x%{height}.png"},
+	}, &fakeVideos{thumbnailURL: fresh})
+	ctx := context.Background()
+
+	thumb, err := svc.GetThumbnail(ctx, "1", 160, AspectLandscape)
+	require.NoError(t, err)
+	require.NotNil(t, thumb)
+	assert.Equal(t, fresh, repo.updated["1"])
+
+	thumb, err = svc.GetThumbnail(ctx, "2", 160, AspectLandscape)
+	require.NoError(t, err)
+	require.NotNil(t, thumb)
+	assert.Equal(t, fresh, repo.updated["2"])
+}
+
+func TestGetThumbnailNotFound(t *testing.T) {
+	var fetches int32
+	server := upstream(t, &fetches)
+	svc, _ := newTestService(t, map[string]*Source{
+		"AwkwardHelplessSalamanderSwiftRage": {VideoID: "AwkwardHelplessSalamanderSwiftRage", VideoType: "clip", ThumbnailURL: server.URL + "/expired.png"},
+	}, &fakeVideos{})
+	ctx := context.Background()
+
+	thumb, err := svc.GetThumbnail(ctx, "unknown", 320, AspectLandscape)
+	require.NoError(t, err)
+	assert.Nil(t, thumb)
+
+	// Clips can't be refreshed
+	thumb, err = svc.GetThumbnail(ctx, "AwkwardHelplessSalamanderSwiftRage", 320, AspectLandscape)
+	require.NoError(t, err)
+	assert.Nil(t, thumb)
+
+	_, err = svc.GetThumbnail(ctx, "../../etc/passwd", 320, AspectLandscape)
+	assert.Equal(t, ErrInvalidVideoID, err)
+}
+
+func TestThumbnailHandlerCacheHeaders(t *testing.T) {
+	t.Setenv("THUMBNAIL_MAX_AGE", "24h")
+	var fetches int32
+	server := upstream(t, &fetches)
+	svc, _ := newTestService(t, map[string]*Source{
+		"42": {VideoID: "42", VideoType: "upload", ThumbnailURL: server.URL + "/thumb-%{width}x%{height}.png"},
+	}, nil)
+
+	app := fiber.New()
+	app.Get("/api/media/thumbnails/:videoID", NewHandlers(svc).Thumbnail)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/media/thumbnails/42?w=160", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=86400", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/media/thumbnails/42?w=160", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/media/thumbnails/42?w=123", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/media/thumbnails/42?aspect=square", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/media/thumbnails/404", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestSizedURL(t *testing.T) {
+	assert.Equal(t, "https://static-cdn.jtvnw.net/thumb0-640x360.jpg",
+		sizedURL("https://static-cdn.jtvnw.net/thumb0-%{width}x%{height}.jpg", 640, 360))
+	clip := "https://clips-media-assets2.twitch.tv/abc-preview-480x272.jpg"
+	assert.Equal(t, clip, sizedURL(clip, 640, 360))
+	assert.True(t, needsRefresh(""))
+	assert.True(t, needsRefresh("https://vod-secure.twitch.tv/_404/404_processing_%{width}x%{height}.png"))
+}
@@ -0,0 +1,120 @@
+package media
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Render statuses. Queued renders are picked up by the worker.
+const (
+	RenderQueued      = "queued"
+	RenderDownloading = "downloading"
+	RenderTranscoding = "transcoding"
+	RenderStoring     = "storing"
+	RenderCompleted   = "completed"
+	RenderFailed      = "failed"
+)
+
+// Preset is how a clip is rendered
+type Preset string
+
+const (
+	// PresetOriginal stores the clip as Twitch serves it
+	PresetOriginal Preset = "original"
+	// PresetVerticalCrop crops the center of the frame to 9:16
+	PresetVerticalCrop Preset = "vertical_crop"
+	// PresetVerticalBlur fits the whole frame over a blurred, zoomed copy of it
+	PresetVerticalBlur Preset = "vertical_blur"
+	// PresetVerticalFit fits the whole frame between black bars
+	PresetVerticalFit Preset = "vertical_fit"
+)
+
+// Presets lists the presets renders can be requested with
+var Presets = []Preset{PresetOriginal, PresetVerticalCrop, PresetVerticalBlur, PresetVerticalFit}
+
+// Vertical renders are 1080x1920, the resolution of Shorts, TikTok and Reels
+const (
+	verticalWidth  = 1080
+	verticalHeight = 1920
+)
+
+// ParsePreset returns the preset named by s, original when s is empty
+func ParsePreset(s string) (Preset, bool) {
+	if s == "" {
+		return PresetOriginal, true
+	}
+	for _, preset := range Presets {
+		if Preset(s) == preset {
+			return preset, true
+		}
+	}
+	return "", false
+}
+
+// Vertical reports whether the preset renders 9:16 video
+func (p Preset) Vertical() bool {
+	return p != PresetOriginal
+}
+
+// Render is a clip downloaded, and for vertical presets transcoded, into
+// object storage
+type Render struct {
+	ID           int    `json:"id" db:"id"`
+	UserID       string `json:"-" db:"user_id"`
+	ClipID       string `json:"clip_id" db:"clip_id"`
+	Preset       Preset `json:"preset" db:"preset"`
+	Status       string `json:"status" db:"status"`
+	Attempts     int    `json:"attempts" db:"attempts"`
+	ErrorMessage string `json:"error_message,omitempty" db:"error_message"`
+	ObjectKey    string `json:"-" db:"object_key"`
+	SizeBytes    int64  `json:"size_bytes" db:"size_bytes"`
+	// DownloadURL is a signed URL of a completed render, see config.ClipRenderConfig.URLExpiry
+	DownloadURL string     `json:"download_url,omitempty" db:"-"`
+	StartedAt   *time.Time `json:"started_at" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// objectKey is where a render is stored, under the renders/ prefix
+func (r *Render) objectKey() string {
+	return fmt.Sprintf("%s/%d-%s-%s.mp4", r.UserID, r.ID, r.ClipID, r.Preset)
+}
+
+// filename is the name browsers save a render under
+func (r *Render) filename() string {
+	return fmt.Sprintf("%s-%s.mp4", r.ClipID, r.Preset)
+}
+
+// ffmpegArgs transcodes input to a vertical MP4 at output. Audio is kept, and
+// the moov atom is moved to the front so players can start before the
+// download finishes.
+func ffmpegArgs(preset Preset, input, output string, cfg config.ClipRenderConfig) ([]string, error) {
+	w, h := strconv.Itoa(verticalWidth), strconv.Itoa(verticalHeight)
+
+	var filter []string
+	switch preset {
+	case PresetVerticalCrop:
+		filter = []string{"-vf", "crop=ih*9/16:ih,scale=" + w + ":" + h + ",setsar=1"}
+	case PresetVerticalBlur:
+		filter = []string{"-filter_complex",
+			"[0:v]scale=" + w + ":" + h + ":force_original_aspect_ratio=increase,crop=" + w + ":" + h + ",boxblur=20:2[bg];" +
+				"[0:v]scale=" + w + ":-2[fg];[bg][fg]overlay=(W-w)/2:(H-h)/2,setsar=1"}
+	case PresetVerticalFit:
+		filter = []string{"-vf", "scale=" + w + ":-2,pad=" + w + ":" + h + ":(ow-iw)/2:(oh-ih)/2:black,setsar=1"}
+	default:
+		return nil, fmt.Errorf("preset %s is not transcoded", preset)
+	}
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", input}
+	args = append(args, filter...)
+	return append(args,
+		"-c:v", "libx264", "-preset", cfg.X264Preset, "-crf", strconv.Itoa(cfg.CRF), "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+		output,
+	), nil
+}
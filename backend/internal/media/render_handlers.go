@@ -0,0 +1,205 @@
+package media
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// renderListLimit bounds how many renders are listed
+const renderListLimit = 50
+
+// RenderHandlers queue clip renders and hand out their download URLs
+type RenderHandlers struct {
+	repo  RenderRepository
+	store RenderStore
+	cfg   config.ClipRenderConfig
+}
+
+func NewRenderHandlers(repo RenderRepository, store RenderStore) *RenderHandlers {
+	return &RenderHandlers{
+		repo:  repo,
+		store: store,
+		cfg:   config.ClipRenders(),
+	}
+}
+
+// RegisterRoutes registers render routes on a Clerk-protected router
+func (h *RenderHandlers) RegisterRoutes(router fiber.Router) {
+	renders := router.Group("/media/renders")
+	renders.Get("/", h.ListRenders)
+	renders.Post("/", h.CreateRender)
+	renders.Get("/:id", h.GetRender)
+}
+
+// renderRequest is the body for queueing a render
+type renderRequest struct {
+	ClipID string `json:"clip_id"`
+	Preset string `json:"preset"`
+}
+
+// CreateRender queues one of the user's collected clips for rendering. A clip
+// already rendered, or being rendered, with the preset is returned as is.
+func (h *RenderHandlers) CreateRender(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req renderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.ClipID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "clip_id is required",
+		})
+	}
+	preset, ok := ParsePreset(req.Preset)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("preset must be one of %v", Presets),
+		})
+	}
+
+	ctx := c.UserContext()
+	exists, err := h.repo.ClipExists(ctx, user.ID, req.ClipID)
+	if err != nil {
+		log.Printf("Error loading clip %s for user %s: %v", req.ClipID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue render",
+		})
+	}
+	if !exists {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Clip not found",
+		})
+	}
+
+	existing, err := h.repo.FindRender(ctx, user.ID, req.ClipID, preset)
+	if err != nil {
+		log.Printf("Error looking up renders of clip %s for user %s: %v", req.ClipID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue render",
+		})
+	}
+	if existing != nil {
+		return c.JSON(fiber.Map{
+			"render": h.withDownloadURL(existing),
+		})
+	}
+
+	unfinished, err := h.repo.CountUnfinishedRenders(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error counting renders for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue render",
+		})
+	}
+	if unfinished >= h.cfg.MaxQueued {
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "Too many clips are waiting to be rendered, try again later",
+		})
+	}
+
+	render := &Render{
+		UserID: user.ID,
+		ClipID: req.ClipID,
+		Preset: preset,
+		Status: RenderQueued,
+	}
+	if err := h.repo.CreateRender(ctx, render); err != nil {
+		log.Printf("Error queueing render of clip %s for user %s: %v", req.ClipID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue render",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"render": render,
+	})
+}
+
+// ListRenders returns the user's most recent renders
+func (h *RenderHandlers) ListRenders(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	renders, err := h.repo.ListRenders(c.UserContext(), user.ID, renderListLimit)
+	if err != nil {
+		log.Printf("Error listing renders for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list renders",
+		})
+	}
+	for i := range renders {
+		h.withDownloadURL(&renders[i])
+	}
+
+	return c.JSON(fiber.Map{
+		"renders":              renders,
+		"download_url_expires": time.Now().Add(h.cfg.URLExpiry).UTC(),
+	})
+}
+
+// GetRender returns one of the user's renders, for polling its status. A
+// completed render has a signed download URL.
+func (h *RenderHandlers) GetRender(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid render ID",
+		})
+	}
+
+	render, err := h.repo.GetRender(c.UserContext(), user.ID, id)
+	if err != nil {
+		log.Printf("Error loading render %d for user %s: %v", id, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load render",
+		})
+	}
+	if render == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Render not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"render":               h.withDownloadURL(render),
+		"download_url_expires": time.Now().Add(h.cfg.URLExpiry).UTC(),
+	})
+}
+
+// withDownloadURL signs the download URL of a completed render
+func (h *RenderHandlers) withDownloadURL(render *Render) *Render {
+	if render.Status != RenderCompleted || render.ObjectKey == "" {
+		return render
+	}
+	downloadURL, err := h.store.PresignGet(render.ObjectKey, render.filename(), h.cfg.URLExpiry)
+	if err != nil {
+		log.Printf("⚠️ Failed to sign download URL of render %d: %v", render.ID, err)
+		return render
+	}
+	render.DownloadURL = downloadURL
+	return render
+}
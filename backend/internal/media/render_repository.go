@@ -0,0 +1,156 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RenderRepository interface {
+	ClipExists(ctx context.Context, userID, clipID string) (bool, error)
+
+	CreateRender(ctx context.Context, render *Render) error
+	GetRender(ctx context.Context, userID string, id int) (*Render, error)
+	FindRender(ctx context.Context, userID, clipID string, preset Preset) (*Render, error)
+	ListRenders(ctx context.Context, userID string, limit int) ([]Render, error)
+	CountUnfinishedRenders(ctx context.Context, userID string) (int, error)
+
+	// The worker's methods
+	ClaimNextRender(ctx context.Context, staleBefore time.Time) (*Render, error)
+	SetRenderStatus(ctx context.Context, id int, status string) error
+	CompleteRender(ctx context.Context, id int, objectKey string, size int64) error
+	FailRender(ctx context.Context, id int, message string, requeue bool) error
+}
+
+type renderRepository struct {
+	db *sqlx.DB
+}
+
+func NewRenderRepository(db *sql.DB) RenderRepository {
+	return &renderRepository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const renderColumns = `id, user_id, clip_id, preset, status, attempts, error_message, object_key, size_bytes,
+	started_at, completed_at, created_at, updated_at`
+
+// ClipExists reports whether the clip was collected for the user
+func (r *renderRepository) ClipExists(ctx context.Context, userID, clipID string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		`SELECT EXISTS (SELECT 1 FROM video_analytics WHERE user_id = $1 AND video_id = $2 AND video_type = 'clip')`,
+		userID, clipID)
+	return exists, err
+}
+
+func (r *renderRepository) CreateRender(ctx context.Context, render *Render) error {
+	query := `
+		INSERT INTO clip_renders (user_id, clip_id, preset, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, render.UserID, render.ClipID, render.Preset, render.Status).
+		Scan(&render.ID, &render.CreatedAt, &render.UpdatedAt)
+}
+
+func (r *renderRepository) GetRender(ctx context.Context, userID string, id int) (*Render, error) {
+	query := `SELECT ` + renderColumns + ` FROM clip_renders WHERE id = $1 AND user_id = $2`
+
+	var render Render
+	err := r.db.GetContext(ctx, &render, query, id, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &render, err
+}
+
+// FindRender returns the latest render of the clip with the preset that
+// hasn't failed, so the same clip isn't rendered twice
+func (r *renderRepository) FindRender(ctx context.Context, userID, clipID string, preset Preset) (*Render, error) {
+	query := `
+		SELECT ` + renderColumns + `
+		FROM clip_renders
+		WHERE user_id = $1 AND clip_id = $2 AND preset = $3 AND status <> 'failed'
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var render Render
+	err := r.db.GetContext(ctx, &render, query, userID, clipID, preset)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &render, err
+}
+
+// ListRenders returns the user's most recent renders, newest first
+func (r *renderRepository) ListRenders(ctx context.Context, userID string, limit int) ([]Render, error) {
+	query := `SELECT ` + renderColumns + ` FROM clip_renders WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	renders := []Render{}
+	err := r.db.SelectContext(ctx, &renders, query, userID, limit)
+	return renders, err
+}
+
+func (r *renderRepository) CountUnfinishedRenders(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM clip_renders WHERE user_id = $1 AND status NOT IN ('completed', 'failed')`, userID)
+	return count, err
+}
+
+// ClaimNextRender marks the oldest queued render as downloading and returns
+// it, like publishing's ClaimNextJob
+func (r *renderRepository) ClaimNextRender(ctx context.Context, staleBefore time.Time) (*Render, error) {
+	query := `
+		UPDATE clip_renders
+		SET status = 'downloading', attempts = attempts + 1, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM clip_renders
+			WHERE status = 'queued' OR (status IN ('downloading', 'transcoding', 'storing') AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + renderColumns
+
+	var render Render
+	err := r.db.GetContext(ctx, &render, query, staleBefore)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &render, err
+}
+
+func (r *renderRepository) SetRenderStatus(ctx context.Context, id int, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE clip_renders SET status = $2, updated_at = NOW() WHERE id = $1`, id, status)
+	return err
+}
+
+func (r *renderRepository) CompleteRender(ctx context.Context, id int, objectKey string, size int64) error {
+	query := `
+		UPDATE clip_renders
+		SET status = 'completed', object_key = $2, size_bytes = $3, error_message = '',
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, objectKey, size)
+	return err
+}
+
+// FailRender records why an attempt failed and queues the render again, or
+// fails it for good unless requeue is set
+func (r *renderRepository) FailRender(ctx context.Context, id int, message string, requeue bool) error {
+	query := `
+		UPDATE clip_renders
+		SET status = CASE WHEN $3 THEN 'queued' ELSE 'failed' END, error_message = $2,
+			completed_at = CASE WHEN $3 THEN NULL ELSE NOW() END, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, message, requeue)
+	return err
+}
@@ -0,0 +1,278 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+type fakeRenderRepository struct {
+	RenderRepository
+	queued    []*Render
+	statuses  []string
+	completed map[int]string
+	failed    map[int]bool // requeue, by render ID
+	messages  map[int]string
+}
+
+func newFakeRenderRepository(renders ...*Render) *fakeRenderRepository {
+	return &fakeRenderRepository{
+		queued:    renders,
+		completed: map[int]string{},
+		failed:    map[int]bool{},
+		messages:  map[int]string{},
+	}
+}
+
+func (r *fakeRenderRepository) ClaimNextRender(ctx context.Context, staleBefore time.Time) (*Render, error) {
+	if len(r.queued) == 0 {
+		return nil, nil
+	}
+	render := r.queued[0]
+	r.queued = r.queued[1:]
+	render.Attempts++
+	return render, nil
+}
+
+func (r *fakeRenderRepository) SetRenderStatus(ctx context.Context, id int, status string) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func (r *fakeRenderRepository) CompleteRender(ctx context.Context, id int, objectKey string, size int64) error {
+	r.completed[id] = objectKey
+	return nil
+}
+
+func (r *fakeRenderRepository) FailRender(ctx context.Context, id int, message string, requeue bool) error {
+	r.failed[id] = requeue
+	r.messages[id] = message
+	return nil
+}
+
+type fakeClipSource struct {
+	download *twitch.ClipDownload
+	err      error
+}
+
+func (f *fakeClipSource) GetClipDownload(ctx context.Context, userID, clipID string) (*twitch.ClipDownload, error) {
+	return f.download, f.err
+}
+
+type fakeRenderStore struct {
+	objects map[string]string
+}
+
+func (s *fakeRenderStore) PutStream(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("read %d bytes, expected %d", len(data), size)
+	}
+	s.objects[key] = string(data)
+	return nil
+}
+
+func (s *fakeRenderStore) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	return "https://bucket.example.com/" + key, nil
+}
+
+// cdn serves clip MP4s as their path, and 403s /expired.mp4
+func cdn(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/expired.mp4" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestRenderer(repo RenderRepository, clips ClipSource, store RenderStore, transcoded *[]Preset) *Renderer {
+	return &Renderer{
+		repo:  repo,
+		clips: clips,
+		store: store,
+		transcode: func(ctx context.Context, preset Preset, input, output string) error {
+			*transcoded = append(*transcoded, preset)
+			data, err := os.ReadFile(input)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(output, append(data, "+"+string(preset)...), 0o600)
+		},
+		httpClient: http.DefaultClient,
+		cfg: config.ClipRenderConfig{
+			JobTimeout:   time.Minute,
+			MaxAttempts:  3,
+			MaxClipBytes: 1 << 20,
+		},
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	preset, ok := ParsePreset("")
+	assert.True(t, ok)
+	assert.Equal(t, PresetOriginal, preset)
+
+	preset, ok = ParsePreset("vertical_blur")
+	assert.True(t, ok)
+	assert.Equal(t, PresetVerticalBlur, preset)
+	assert.True(t, preset.Vertical())
+
+	_, ok = ParsePreset("square")
+	assert.False(t, ok)
+}
+
+func TestFFmpegArgs(t *testing.T) {
+	cfg := config.ClipRenderConfig{X264Preset: "veryfast", CRF: 23}
+
+	args, err := ffmpegArgs(PresetVerticalCrop, "in.mp4", "out.mp4", cfg)
+	require.NoError(t, err)
+	joined := strings.Join(args, " ")
+	assert.Contains(t, joined, "-i in.mp4 -vf crop=ih*9/16:ih,scale=1080:1920")
+	assert.Contains(t, joined, "-preset veryfast -crf 23")
+	assert.Equal(t, "out.mp4", args[len(args)-1])
+
+	args, err = ffmpegArgs(PresetVerticalBlur, "in.mp4", "out.mp4", cfg)
+	require.NoError(t, err)
+	assert.Contains(t, args, "-filter_complex")
+
+	_, err = ffmpegArgs(PresetOriginal, "in.mp4", "out.mp4", cfg)
+	assert.Error(t, err)
+}
+
+func TestRendererStoresOriginalAndTranscodesVertical(t *testing.T) {
+	server := cdn(t)
+	repo := newFakeRenderRepository(
+		&Render{ID: 1, UserID: "user_1", ClipID: "Clip1", Preset: PresetOriginal},
+		&Render{ID: 2, UserID: "user_1", ClipID: "Clip1", Preset: PresetVerticalCrop},
+	)
+	clips := &fakeClipSource{download: &twitch.ClipDownload{ClipID: "Clip1", LandscapeURL: server.URL + "/landscape.mp4"}}
+	store := &fakeRenderStore{objects: map[string]string{}}
+	var transcoded []Preset
+	renderer := newTestRenderer(repo, clips, store, &transcoded)
+
+	renderer.drain(context.Background())
+
+	assert.Equal(t, "user_1/1-Clip1-original.mp4", repo.completed[1])
+	assert.Equal(t, "user_1/2-Clip1-vertical_crop.mp4", repo.completed[2])
+	assert.Equal(t, "/landscape.mp4", store.objects["user_1/1-Clip1-original.mp4"])
+	assert.Equal(t, "/landscape.mp4+vertical_crop", store.objects["user_1/2-Clip1-vertical_crop.mp4"])
+	assert.Equal(t, []Preset{PresetVerticalCrop}, transcoded)
+	assert.Equal(t, []string{RenderStoring, RenderTranscoding, RenderStoring}, repo.statuses)
+	assert.Empty(t, repo.failed)
+}
+
+func TestRendererUsesCreatorsPortraitVersion(t *testing.T) {
+	server := cdn(t)
+	repo := newFakeRenderRepository(&Render{ID: 1, UserID: "user_1", ClipID: "Clip1", Preset: PresetVerticalFit})
+	clips := &fakeClipSource{download: &twitch.ClipDownload{
+		ClipID:       "Clip1",
+		LandscapeURL: server.URL + "/landscape.mp4",
+		PortraitURL:  server.URL + "/portrait.mp4",
+	}}
+	store := &fakeRenderStore{objects: map[string]string{}}
+	var transcoded []Preset
+	renderer := newTestRenderer(repo, clips, store, &transcoded)
+
+	renderer.drain(context.Background())
+
+	assert.Equal(t, "/portrait.mp4", store.objects["user_1/1-Clip1-vertical_fit.mp4"])
+	assert.Empty(t, transcoded)
+}
+
+func TestRendererFailures(t *testing.T) {
+	server := cdn(t)
+
+	tests := []struct {
+		name    string
+		clips   *fakeClipSource
+		requeue bool
+	}{
+		{
+			name:    "expired download URL is retried",
+			clips:   &fakeClipSource{download: &twitch.ClipDownload{LandscapeURL: server.URL + "/expired.mp4"}},
+			requeue: true,
+		},
+		{
+			name:    "Twitch outage is retried",
+			clips:   &fakeClipSource{err: &twitch.APIError{StatusCode: http.StatusServiceUnavailable}},
+			requeue: true,
+		},
+		{
+			name:  "deleted clip fails",
+			clips: &fakeClipSource{err: &twitch.APIError{StatusCode: http.StatusNotFound}},
+		},
+		{
+			name:  "revoked Twitch access fails",
+			clips: &fakeClipSource{err: fmt.Errorf("%w: reconnect Twitch", ErrClipUnavailable)},
+		},
+		{
+			name:  "clip without video fails",
+			clips: &fakeClipSource{download: &twitch.ClipDownload{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRenderRepository(&Render{ID: 1, UserID: "user_1", ClipID: "Clip1", Preset: PresetOriginal})
+			store := &fakeRenderStore{objects: map[string]string{}}
+			var transcoded []Preset
+			renderer := newTestRenderer(repo, tt.clips, store, &transcoded)
+
+			renderer.drain(context.Background())
+
+			requeue, failed := repo.failed[1]
+			require.True(t, failed)
+			assert.Equal(t, tt.requeue, requeue)
+			assert.Empty(t, store.objects)
+		})
+	}
+}
+
+func TestRendererFailsOversizedClips(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 2<<20))
+	}))
+	defer server.Close()
+
+	repo := newFakeRenderRepository(&Render{ID: 1, UserID: "user_1", ClipID: "Clip1", Preset: PresetOriginal})
+	clips := &fakeClipSource{download: &twitch.ClipDownload{LandscapeURL: server.URL + "/big.mp4"}}
+	var transcoded []Preset
+	renderer := newTestRenderer(repo, clips, &fakeRenderStore{objects: map[string]string{}}, &transcoded)
+
+	renderer.drain(context.Background())
+
+	assert.False(t, repo.failed[1])
+	assert.Contains(t, repo.messages[1], "too large")
+}
+
+func TestRendererGivesUpOnTimedOutRenders(t *testing.T) {
+	repo := newFakeRenderRepository(&Render{ID: 1, UserID: "user_1", ClipID: "Clip1", Attempts: 3})
+	var transcoded []Preset
+	renderer := newTestRenderer(repo, &fakeClipSource{}, &fakeRenderStore{objects: map[string]string{}}, &transcoded)
+
+	renderer.drain(context.Background())
+
+	requeue, failed := repo.failed[1]
+	require.True(t, failed)
+	assert.False(t, requeue)
+	assert.Equal(t, "rendering timed out", repo.messages[1])
+}
@@ -0,0 +1,216 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// RenderStore keeps rendered clips and signs their download URLs. The export
+// bucket's S3Sink is one.
+type RenderStore interface {
+	PutStream(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+}
+
+// ErrClipUnavailable means a clip can't be downloaded until the user acts,
+// e.g. by reconnecting Twitch
+var ErrClipUnavailable = errors.New("clip can't be downloaded")
+
+// ClipSource resolves the MP4 URLs of a user's clips, see
+// analytics.ClipDownloads. Failures that trying again can't fix wrap
+// ErrClipUnavailable.
+type ClipSource interface {
+	GetClipDownload(ctx context.Context, userID, clipID string) (*twitch.ClipDownload, error)
+}
+
+// transcodeFunc writes the preset's rendering of input to output
+type transcodeFunc func(ctx context.Context, preset Preset, input, output string) error
+
+// Renderer is the background worker that downloads queued clips from Twitch,
+// transcodes them to vertical video and stores them. Several replicas can run
+// it, each render is claimed by one of them.
+type Renderer struct {
+	repo       RenderRepository
+	clips      ClipSource
+	store      RenderStore
+	transcode  transcodeFunc
+	httpClient *http.Client
+	cfg        config.ClipRenderConfig
+}
+
+func NewRenderer(repo RenderRepository, clips ClipSource, store RenderStore) *Renderer {
+	cfg := config.ClipRenders()
+	return &Renderer{
+		repo:       repo,
+		clips:      clips,
+		store:      store,
+		transcode:  ffmpegTranscoder(cfg),
+		httpClient: httpclient.New("twitch_clips"),
+		cfg:        cfg,
+	}
+}
+
+// Run processes queued renders until ctx ends
+func (r *Renderer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain processes renders until none are left
+func (r *Renderer) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := r.processNext(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to process clip render: %v", err)
+			return
+		}
+		if !processed {
+			return
+		}
+	}
+}
+
+// processNext claims and processes the next render. It returns false when no
+// render is queued.
+func (r *Renderer) processNext(ctx context.Context) (bool, error) {
+	// Renders claimed before this are past their timeout, their replica is gone
+	render, err := r.repo.ClaimNextRender(ctx, time.Now().Add(-2*r.cfg.JobTimeout))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim render: %w", err)
+	}
+	if render == nil {
+		return false, nil
+	}
+
+	// Only renders whose last attempt never finished get here
+	if render.Attempts > r.cfg.MaxAttempts {
+		log.Printf("Clip render %d gave up after %d attempts", render.ID, r.cfg.MaxAttempts)
+		return true, r.repo.FailRender(ctx, render.ID, "rendering timed out", false)
+	}
+
+	renderCtx, cancel := context.WithTimeout(ctx, r.cfg.JobTimeout)
+	defer cancel()
+
+	size, err := r.render(renderCtx, render)
+	if err != nil {
+		requeue := !permanentRenderError(err) && render.Attempts < r.cfg.MaxAttempts
+		log.Printf("Clip render %d of user %s failed (attempt %d, retry=%t): %v", render.ID, render.UserID, render.Attempts, requeue, err)
+		return true, r.repo.FailRender(ctx, render.ID, err.Error(), requeue)
+	}
+
+	log.Printf("✅ Rendered clip %s of user %s as %s (%d bytes)", render.ClipID, render.UserID, render.Preset, size)
+	return true, r.repo.CompleteRender(ctx, render.ID, render.objectKey(), size)
+}
+
+// render downloads, transcodes and stores the clip and returns the stored size
+func (r *Renderer) render(ctx context.Context, render *Render) (int64, error) {
+	download, err := r.clips.GetClipDownload(ctx, render.UserID, render.ClipID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get clip download: %w", err)
+	}
+
+	// A vertical version the creator made on Twitch beats any automatic one
+	mediaURL, transcode := download.LandscapeURL, render.Preset.Vertical()
+	if transcode && download.PortraitURL != "" {
+		mediaURL, transcode = download.PortraitURL, false
+	}
+	if mediaURL == "" {
+		mediaURL = download.PortraitURL
+	}
+	if mediaURL == "" {
+		return 0, fmt.Errorf("%w: twitch has no video of it", ErrClipUnavailable)
+	}
+
+	file, size, err := DownloadToTempFile(ctx, r.httpClient, mediaURL, r.cfg.MaxClipBytes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download clip: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if transcode {
+		if err := r.repo.SetRenderStatus(ctx, render.ID, RenderTranscoding); err != nil {
+			return 0, fmt.Errorf("failed to update render status: %w", err)
+		}
+		output := strings.TrimSuffix(file.Name(), ".mp4") + "-" + string(render.Preset) + ".mp4"
+		defer os.Remove(output)
+		if err := r.transcode(ctx, render.Preset, file.Name(), output); err != nil {
+			return 0, err
+		}
+
+		file.Close()
+		if file, err = os.Open(output); err != nil {
+			return 0, fmt.Errorf("failed to open transcoded clip: %w", err)
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("failed to open transcoded clip: %w", err)
+		}
+		size = info.Size()
+	}
+
+	if err := r.repo.SetRenderStatus(ctx, render.ID, RenderStoring); err != nil {
+		return 0, fmt.Errorf("failed to update render status: %w", err)
+	}
+	if err := r.store.PutStream(ctx, render.objectKey(), file, size, "video/mp4"); err != nil {
+		return 0, fmt.Errorf("failed to store render: %w", err)
+	}
+	return size, nil
+}
+
+// ffmpegTranscoder runs ffmpeg with the preset's filters
+func ffmpegTranscoder(cfg config.ClipRenderConfig) transcodeFunc {
+	return func(ctx context.Context, preset Preset, input, output string) error {
+		args, err := ffmpegArgs(preset, input, output, cfg)
+		if err != nil {
+			return err
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, cfg.FFmpegPath, args...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			message := strings.TrimSpace(stderr.String())
+			if len(message) > 500 {
+				message = message[len(message)-500:]
+			}
+			return fmt.Errorf("ffmpeg failed: %w: %s", err, message)
+		}
+		return nil
+	}
+}
+
+// permanentRenderError reports whether trying again can't fix err, such as
+// revoked access or a clip over the size limit
+func permanentRenderError(err error) bool {
+	if errors.Is(err, ErrTooLarge) || errors.Is(err, ErrClipUnavailable) {
+		return true
+	}
+	var apiErr *twitch.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusBadRequest &&
+		apiErr.StatusCode < http.StatusInternalServerError && apiErr.StatusCode != http.StatusTooManyRequests
+}
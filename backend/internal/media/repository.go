@@ -0,0 +1,53 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetThumbnailSource(ctx context.Context, videoID string) (*Source, error)
+	UpdateThumbnailURL(ctx context.Context, videoID, thumbnailURL string) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+// GetThumbnailSource returns the stored thumbnail of a collected video or clip,
+// or nil if the video is unknown
+func (r *repository) GetThumbnailSource(ctx context.Context, videoID string) (*Source, error) {
+	query := `
+		SELECT video_id, COALESCE(video_type, '') AS video_type, COALESCE(thumbnail_url, '') AS thumbnail_url
+		FROM video_analytics
+		WHERE video_id = $1
+	`
+
+	var source Source
+	err := r.db.GetContext(ctx, &source, query, videoID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thumbnail source: %w", err)
+	}
+	return &source, nil
+}
+
+// UpdateThumbnailURL stores a freshly resolved thumbnail URL
+func (r *repository) UpdateThumbnailURL(ctx context.Context, videoID, thumbnailURL string) error {
+	query := `UPDATE video_analytics SET thumbnail_url = $2, updated_at = NOW() WHERE video_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, videoID, thumbnailURL); err != nil {
+		return fmt.Errorf("failed to update thumbnail URL: %w", err)
+	}
+	return nil
+}
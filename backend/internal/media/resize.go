@@ -0,0 +1,81 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// cropCenter cuts the largest ratioW:ratioH region out of the middle of src
+func cropCenter(src image.Image, ratioW, ratioH int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w*ratioH > h*ratioW {
+		w = h * ratioW / ratioH
+	} else {
+		h = w * ratioH / ratioW
+	}
+	if w < 1 || h < 1 {
+		return src
+	}
+
+	x0 := bounds.Min.X + (bounds.Dx()-w)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-h)/2
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// resizeToWidth scales src down to width, keeping its aspect ratio, by
+// averaging the source pixels that fall into each destination pixel. Images
+// that are already narrow enough are returned unchanged.
+func resizeToWidth(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if width <= 0 || srcW <= width {
+		return src
+	}
+
+	height := srcH * width / srcW
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := bounds.Min.Y + (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := bounds.Min.X + (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
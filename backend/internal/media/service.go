@@ -0,0 +1,196 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+const (
+	// maxSourceBytes bounds how much of an upstream image is read
+	maxSourceBytes = 10 << 20
+	jpegQuality    = 85
+)
+
+// VideoLookup re-resolves VOD thumbnails whose stored URL is missing or expired
+type VideoLookup interface {
+	GetAppAccessToken(ctx context.Context) (*twitch.OAuthToken, error)
+	GetVideosByID(ctx context.Context, userAccessToken string, videoIDs []string) ([]twitch.VideoInfo, error)
+}
+
+// errUpstreamNotFound means Twitch no longer serves the thumbnail URL
+var errUpstreamNotFound = errors.New("thumbnail not found upstream")
+
+// Service resolves, resizes and caches thumbnails
+type Service struct {
+	repo       Repository
+	store      Store
+	videos     VideoLookup
+	cfg        config.MediaConfig
+	httpClient *http.Client
+}
+
+func NewService(repo Repository, store Store, videos VideoLookup) *Service {
+	return &Service{
+		repo:       repo,
+		store:      store,
+		videos:     videos,
+		cfg:        config.Media(),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// MaxAge is the Cache-Control max-age for served thumbnails
+func (s *Service) MaxAge() time.Duration {
+	return s.cfg.ThumbnailMaxAge
+}
+
+// GetThumbnail returns the thumbnail of a collected video at the given width
+// and aspect, or nil if the video is unknown or Twitch has no thumbnail for it
+func (s *Service) GetThumbnail(ctx context.Context, videoID string, width int, aspect Aspect) (*Thumbnail, error) {
+	if !videoIDPattern.MatchString(videoID) {
+		return nil, ErrInvalidVideoID
+	}
+	if !IsValidWidth(width) {
+		width = DefaultThumbnailWidth
+	}
+
+	source, err := s.repo.GetThumbnailSource(ctx, videoID)
+	if err != nil || source == nil {
+		return nil, err
+	}
+
+	if needsRefresh(source.ThumbnailURL) {
+		if !s.refreshSource(ctx, source) {
+			return nil, nil
+		}
+	}
+
+	thumb, err := s.render(ctx, source, width, aspect)
+	if err == errUpstreamNotFound && s.refreshSource(ctx, source) {
+		thumb, err = s.render(ctx, source, width, aspect)
+	}
+	if err == errUpstreamNotFound {
+		return nil, nil
+	}
+	return thumb, err
+}
+
+// render serves the thumbnail from the store, fetching and resizing it on a miss
+func (s *Service) render(ctx context.Context, source *Source, width int, aspect Aspect) (*Thumbnail, error) {
+	key, etag := storeKey(source, width, aspect)
+
+	cached, err := s.store.Get(ctx, key)
+	if err != nil {
+		log.Printf("⚠️ Thumbnail cache read failed for %s: %v", key, err)
+	}
+	if cached != nil {
+		return &Thumbnail{Data: cached, ContentType: "image/jpeg", ETag: etag}, nil
+	}
+
+	fetchWidth := width
+	if aspect == AspectVertical {
+		// The crop keeps only 9/16 of the height as width, so start from the largest frame
+		fetchWidth = ThumbnailWidths[len(ThumbnailWidths)-1]
+	}
+	img, err := s.fetch(ctx, sizedURL(source.ThumbnailURL, fetchWidth, fetchWidth*9/16))
+	if err != nil {
+		return nil, err
+	}
+	if aspect == AspectVertical {
+		img = cropCenter(img, 9, 16)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resizeToWidth(img, width), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	if err := s.store.Put(ctx, key, buf.Bytes(), "image/jpeg"); err != nil {
+		log.Printf("⚠️ Thumbnail cache write failed for %s: %v", key, err)
+	}
+
+	return &Thumbnail{Data: buf.Bytes(), ContentType: "image/jpeg", ETag: etag}, nil
+}
+
+func (s *Service) fetch(ctx context.Context, imageURL string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create thumbnail request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch thumbnail: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil, errUpstreamNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("thumbnail fetch failed: status %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(io.LimitReader(resp.Body, maxSourceBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode thumbnail: %w", err)
+	}
+	return img, nil
+}
+
+// refreshSource asks Twitch for the VOD's current thumbnail URL and stores it.
+// Clips have no refreshable URL. It reports whether a usable URL was found.
+func (s *Service) refreshSource(ctx context.Context, source *Source) bool {
+	if s.videos == nil || source.VideoType == "clip" {
+		return false
+	}
+
+	token, err := s.videos.GetAppAccessToken(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to get app token to refresh thumbnail of %s: %v", source.VideoID, err)
+		return false
+	}
+
+	videos, err := s.videos.GetVideosByID(ctx, token.AccessToken, []string{source.VideoID})
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh thumbnail of %s: %v", source.VideoID, err)
+		return false
+	}
+	if len(videos) == 0 || needsRefresh(videos[0].ThumbnailURL) || videos[0].ThumbnailURL == source.ThumbnailURL {
+		return false
+	}
+
+	source.ThumbnailURL = videos[0].ThumbnailURL
+	if err := s.repo.UpdateThumbnailURL(ctx, source.VideoID, source.ThumbnailURL); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	return true
+}
+
+// storeKey derives the cache key and ETag from the source URL, so a changed
+// upstream thumbnail is fetched again instead of served from a stale entry.
+// Vertical crops get a "v" suffix on the width, landscape keys are unchanged.
+func storeKey(source *Source, width int, aspect Aspect) (string, string) {
+	sum := sha256.Sum256([]byte(source.ThumbnailURL))
+	hash := hex.EncodeToString(sum[:8])
+	size := strconv.Itoa(width)
+	if aspect == AspectVertical {
+		size += "v"
+	}
+	return fmt.Sprintf("%s/%s-%s.jpg", source.VideoID, size, hash), fmt.Sprintf(`"%s-%s"`, hash, size)
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/export"
+)
+
+// Store keeps rendered thumbnails. Get returns nil, nil for missing keys.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// NewStoreFromConfig returns the configured thumbnail store
+func NewStoreFromConfig(cfg config.MediaConfig) (Store, error) {
+	switch cfg.ThumbnailStorage {
+	case config.ThumbnailStorageDisk:
+		return NewDiskStore(cfg.ThumbnailDir), nil
+	case config.ThumbnailStorageObject:
+		exportCfg := config.Export()
+		if exportCfg.Bucket == "" || exportCfg.AccessKeyID == "" || exportCfg.SecretAccessKey == "" {
+			return nil, fmt.Errorf("EXPORT_BUCKET, EXPORT_ACCESS_KEY_ID and EXPORT_SECRET_ACCESS_KEY must be set when THUMBNAIL_STORAGE=object")
+		}
+		exportCfg.Prefix = strings.Trim(exportCfg.Prefix+"/thumbnails", "/")
+		return &objectStore{sink: export.NewS3Sink(exportCfg)}, nil
+	default:
+		return nil, fmt.Errorf("unknown THUMBNAIL_STORAGE %q (valid: disk, object)", cfg.ThumbnailStorage)
+	}
+}
+
+// NewRenderStoreFromConfig returns the store of rendered clips, the EXPORT_*
+// bucket under a renders/ prefix
+func NewRenderStoreFromConfig() (RenderStore, error) {
+	exportCfg := config.Export()
+	if exportCfg.Bucket == "" || exportCfg.AccessKeyID == "" || exportCfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("EXPORT_BUCKET, EXPORT_ACCESS_KEY_ID and EXPORT_SECRET_ACCESS_KEY must be set when CLIP_RENDERS_ENABLED=true")
+	}
+	exportCfg.Prefix = strings.Trim(exportCfg.Prefix+"/renders", "/")
+	return export.NewS3Sink(exportCfg), nil
+}
+
+// DiskStore writes thumbnails under a local directory
+type DiskStore struct {
+	dir string
+}
+
+func NewDiskStore(dir string) *DiskStore {
+	return &DiskStore{dir: dir}
+}
+
+func (s *DiskStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached thumbnail: %w", err)
+	}
+	return data, nil
+}
+
+// Put writes through a temporary file so concurrent readers never see a partial image
+func (s *DiskStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create thumbnail directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".thumb-*")
+	if err != nil {
+		return fmt.Errorf("failed to create thumbnail file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+	return nil
+}
+
+func (s *DiskStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// objectStore keeps thumbnails in the export bucket
+type objectStore struct {
+	sink *export.S3Sink
+}
+
+func (s *objectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.sink.Get(ctx, key)
+}
+
+func (s *objectStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return s.sink.Put(ctx, key, data, contentType, "")
+}
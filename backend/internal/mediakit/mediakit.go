@@ -0,0 +1,284 @@
+// Package mediakit renders sponsor-facing media kit PDFs from a creator's
+// analytics summary.
+package mediakit
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Kit is everything that goes into a media kit
+type Kit struct {
+	DisplayName string
+	Username    string
+	GeneratedAt time.Time
+
+	// Headline, About and Tags are rendered from a content template, the
+	// section is left out without one
+	Headline string
+	About    string
+	Tags     []string
+
+	Followers   int
+	Subscribers int
+	TotalViews  int
+
+	// FollowerHistory is oldest first
+	FollowerHistory []Point
+	TopContent      []Content
+	Audience        Audience
+}
+
+// Point is one day of follower history
+type Point struct {
+	Date      time.Time
+	Followers int
+}
+
+// Content is one of the creator's best performing videos or clips
+type Content struct {
+	Title string
+	Type  string
+	Views int
+}
+
+// Audience summarizes recent streams
+type Audience struct {
+	Streams        int
+	HoursStreamed  float64
+	AverageViewers int
+	PeakViewers    int
+	TopGames       []string
+}
+
+// Brand colors (CreatorSync purple and neutrals)
+var (
+	brandColor = [3]int{124, 58, 237}
+	textColor  = [3]int{31, 41, 55}
+	mutedColor = [3]int{107, 114, 128}
+	panelColor = [3]int{243, 244, 246}
+)
+
+const (
+	pageMargin = 15.0
+	pageWidth  = 210.0
+	chartH     = 55.0
+	// maxAboutLength keeps the about text from pushing the kit onto a second page
+	maxAboutLength = 400
+)
+
+// Render lays the kit out on a single A4 page
+func Render(kit *Kit) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(fmt.Sprintf("%s media kit", kit.DisplayName), true)
+	pdf.SetCreator("CreatorSync", true)
+	pdf.AddPage()
+
+	// Core fonts are cp1252, so titles from Twitch need translating
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	contentW := pageWidth - 2*pageMargin
+
+	// Header band
+	pdf.SetFillColor(brandColor[0], brandColor[1], brandColor[2])
+	pdf.Rect(0, 0, pageWidth, 38, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(pageMargin, 10)
+	pdf.SetFont("Helvetica", "B", 24)
+	pdf.CellFormat(contentW, 10, tr(kit.DisplayName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(contentW, 7, tr("twitch.tv/"+kit.Username+"  |  Media kit"), "", 1, "L", false, 0, "")
+	pdf.SetY(46)
+
+	// Headline numbers
+	stats := []struct {
+		label string
+		value string
+	}{
+		{"Followers", formatCount(kit.Followers)},
+		{"Subscribers", formatCount(kit.Subscribers)},
+		{"Total views", formatCount(kit.TotalViews)},
+		{"30 day growth", formatGrowth(kit.FollowerHistory)},
+	}
+	boxW := (contentW - 3*4) / 4
+	y := pdf.GetY()
+	for i, stat := range stats {
+		x := pageMargin + float64(i)*(boxW+4)
+		pdf.SetFillColor(panelColor[0], panelColor[1], panelColor[2])
+		pdf.Rect(x, y, boxW, 22, "F")
+		pdf.SetXY(x, y+3)
+		pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.CellFormat(boxW, 8, stat.value, "", 2, "C", false, 0, "")
+		pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(boxW, 6, stat.label, "", 0, "C", false, 0, "")
+	}
+	pdf.SetY(y + 30)
+
+	if kit.Headline != "" || kit.About != "" || len(kit.Tags) > 0 {
+		sectionTitle(pdf, "About")
+		if kit.Headline != "" {
+			pdf.SetFont("Helvetica", "B", 11)
+			pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+			pdf.MultiCell(contentW, 6, tr(truncate(kit.Headline, 100)), "", "L", false)
+		}
+		if kit.About != "" {
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+			pdf.MultiCell(contentW, 5, tr(truncate(kit.About, maxAboutLength)), "", "L", false)
+		}
+		if len(kit.Tags) > 0 {
+			pdf.SetFont("Helvetica", "", 9)
+			pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+			pdf.MultiCell(contentW, 5, tr(truncate("#"+strings.Join(kit.Tags, "  #"), 200)), "", "L", false)
+		}
+		pdf.Ln(5)
+	}
+
+	sectionTitle(pdf, "Follower growth")
+	drawChart(pdf, pageMargin, pdf.GetY(), contentW, chartH, kit.FollowerHistory)
+	pdf.SetY(pdf.GetY() + chartH + 8)
+
+	sectionTitle(pdf, "Top content")
+	pdf.SetFont("Helvetica", "", 10)
+	if len(kit.TopContent) == 0 {
+		mutedLine(pdf, "No videos collected yet")
+	}
+	for i, content := range kit.TopContent {
+		pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+		pdf.CellFormat(8, 7, fmt.Sprintf("%d.", i+1), "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentW-8-50, 7, tr(truncate(content.Title, 70)), "", 0, "L", false, 0, "")
+		pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+		pdf.CellFormat(20, 7, content.Type, "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 7, formatCount(content.Views)+" views", "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	sectionTitle(pdf, "Audience (last 30 days)")
+	pdf.SetFont("Helvetica", "", 10)
+	audience := [][2]string{
+		{"Streams", fmt.Sprintf("%d", kit.Audience.Streams)},
+		{"Hours streamed", fmt.Sprintf("%.1f", kit.Audience.HoursStreamed)},
+		{"Average viewers", formatCount(kit.Audience.AverageViewers)},
+		{"Peak viewers", formatCount(kit.Audience.PeakViewers)},
+	}
+	for _, row := range audience {
+		pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+		pdf.CellFormat(50, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+		pdf.CellFormat(contentW-50, 7, row[1], "", 1, "L", false, 0, "")
+	}
+	if len(kit.Audience.TopGames) > 0 {
+		pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+		pdf.CellFormat(50, 7, "Top categories", "", 0, "L", false, 0, "")
+		pdf.SetTextColor(textColor[0], textColor[1], textColor[2])
+		pdf.MultiCell(contentW-50, 7, tr(strings.Join(kit.Audience.TopGames, ", ")), "", "L", false)
+	}
+
+	// Footer
+	pdf.SetY(-pageMargin - 6)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+	pdf.CellFormat(contentW, 6, "Generated by CreatorSync on "+kit.GeneratedAt.Format("January 2, 2006"), "", 0, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render media kit: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func sectionTitle(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.SetTextColor(brandColor[0], brandColor[1], brandColor[2])
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+	pdf.Ln(1)
+}
+
+func mutedLine(pdf *fpdf.Fpdf, text string) {
+	pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+	pdf.CellFormat(0, 7, text, "", 1, "L", false, 0, "")
+}
+
+// drawChart draws a follower line chart scaled to the series' own range
+func drawChart(pdf *fpdf.Fpdf, x, y, w, h float64, points []Point) {
+	pdf.SetFillColor(panelColor[0], panelColor[1], panelColor[2])
+	pdf.Rect(x, y, w, h, "F")
+
+	if len(points) < 2 {
+		pdf.SetXY(x, y+h/2-3)
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+		pdf.CellFormat(w, 6, "Not enough history yet", "", 0, "C", false, 0, "")
+		return
+	}
+
+	low, high := points[0].Followers, points[0].Followers
+	for _, p := range points {
+		low = min(low, p.Followers)
+		high = max(high, p.Followers)
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	const pad = 6.0
+	plotX, plotY := x+pad, y+pad
+	plotW, plotH := w-2*pad, h-2*pad-4
+	stepX := plotW / float64(len(points)-1)
+
+	pdf.SetDrawColor(brandColor[0], brandColor[1], brandColor[2])
+	pdf.SetLineWidth(0.6)
+	for i := 1; i < len(points); i++ {
+		x1 := plotX + float64(i-1)*stepX
+		x2 := plotX + float64(i)*stepX
+		y1 := plotY + plotH - plotH*float64(points[i-1].Followers-low)/float64(high-low)
+		y2 := plotY + plotH - plotH*float64(points[i].Followers-low)/float64(high-low)
+		pdf.Line(x1, y1, x2, y2)
+	}
+
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetTextColor(mutedColor[0], mutedColor[1], mutedColor[2])
+	pdf.SetXY(plotX, y+h-pad)
+	pdf.CellFormat(plotW/2, 4, points[0].Date.Format("Jan 2"), "", 0, "L", false, 0, "")
+	pdf.CellFormat(plotW/2, 4, points[len(points)-1].Date.Format("Jan 2"), "", 0, "R", false, 0, "")
+	pdf.SetXY(plotX, y+1)
+	pdf.CellFormat(plotW, 4, formatCount(high)+" followers", "", 0, "R", false, 0, "")
+}
+
+// formatCount abbreviates large numbers the way creators quote them (12.3K, 1.2M)
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+func formatGrowth(points []Point) string {
+	if len(points) < 2 {
+		return "-"
+	}
+	change := points[len(points)-1].Followers - points[0].Followers
+	if change >= 0 {
+		return "+" + formatCount(change)
+	}
+	return "-" + formatCount(-change)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
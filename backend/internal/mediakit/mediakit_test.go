@@ -0,0 +1,58 @@
+package mediakit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	kit := &Kit{
+		DisplayName: "Baldy Builds",
+		Username:    "baldybuilds",
+		GeneratedAt: day,
+		Followers:   12345,
+		TotalViews:  987654,
+		FollowerHistory: []Point{
+			{Date: day.AddDate(0, 0, -2), Followers: 12000},
+			{Date: day.AddDate(0, 0, -1), Followers: 12200},
+			{Date: day, Followers: 12345},
+		},
+		TopContent: []Content{{Title: "Building a desk — part 2", Type: "vod", Views: 4200}},
+		Audience:   Audience{Streams: 12, HoursStreamed: 40.5, AverageViewers: 85, PeakViewers: 240, TopGames: []string{"Minecraft"}},
+	}
+
+	pdf, err := Render(kit)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+
+	// So does one with an about section
+	kit.Headline = "Cozy building streams"
+	kit.About = strings.Repeat("Building desks, shelves and more. ", 30)
+	kit.Tags = []string{"woodworking", "diy"}
+	pdf, err = Render(kit)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+
+	// An empty kit still renders
+	_, err = Render(&Kit{DisplayName: "New Creator", GeneratedAt: day})
+	require.NoError(t, err)
+}
+
+func TestFormatCount(t *testing.T) {
+	assert.Equal(t, "999", formatCount(999))
+	assert.Equal(t, "9999", formatCount(9999))
+	assert.Equal(t, "12.3K", formatCount(12345))
+	assert.Equal(t, "1.2M", formatCount(1_234_567))
+}
+
+func TestFormatGrowth(t *testing.T) {
+	assert.Equal(t, "-", formatGrowth(nil))
+	assert.Equal(t, "+345", formatGrowth([]Point{{Followers: 100}, {Followers: 445}}))
+	assert.Equal(t, "-5", formatGrowth([]Point{{Followers: 100}, {Followers: 95}}))
+}
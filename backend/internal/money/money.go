@@ -0,0 +1,163 @@
+// Package money handles currency amounts: values in minor units (cents) tagged
+// with their currency, conversion through cached exchange rates and
+// locale-aware display formatting.
+package money
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+)
+
+// Currency is an ISO 4217 code
+type Currency string
+
+// Base is the currency revenue is estimated and stored in
+const Base Currency = "USD"
+
+// currencyInfo describes how a currency is written
+type currencyInfo struct {
+	// Digits is the number of minor unit digits, 2 for cents
+	Digits int
+	Symbol string
+}
+
+var currencies = map[Currency]currencyInfo{
+	"USD": {2, "$"},
+	"EUR": {2, "€"},
+	"GBP": {2, "£"},
+	"CAD": {2, "CA$"},
+	"AUD": {2, "A$"},
+	"BRL": {2, "R$"},
+	"MXN": {2, "MX$"},
+	"JPY": {0, "¥"},
+	"INR": {2, "₹"},
+	"SEK": {2, "kr"},
+}
+
+// ParseCurrency returns the supported currency for a code such as "eur"
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := currencies[currency]; !ok {
+		return "", fmt.Errorf("unsupported currency %q", code)
+	}
+	return currency, nil
+}
+
+// Supported lists the supported currency codes, sorted
+func Supported() []string {
+	codes := make([]string, 0, len(currencies))
+	for currency := range currencies {
+		codes = append(codes, string(currency))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Digits returns the number of minor unit digits of the currency
+func (c Currency) Digits() int {
+	return currencies[c].Digits
+}
+
+// Amount is a value in the minor units of its currency
+type Amount struct {
+	Minor    int64
+	Currency Currency
+}
+
+// Cents returns a USD amount
+func Cents(cents int64) Amount {
+	return Amount{Minor: cents, Currency: Base}
+}
+
+// Exchange converts the amount at rate, the units of to one unit of the
+// amount's currency buys, rounding to the minor unit of to
+func (a Amount) Exchange(to Currency, rate float64) Amount {
+	scale := math.Pow10(to.Digits() - a.Currency.Digits())
+	return Amount{
+		Minor:    int64(math.Round(float64(a.Minor) * rate * scale)),
+		Currency: to,
+	}
+}
+
+// Value is an amount as returned by the API: raw minor units for calculations
+// and a display string formatted for the user's locale
+type Value struct {
+	Minor    int64    `json:"minor"`
+	Currency Currency `json:"currency"`
+	Display  string   `json:"display"`
+}
+
+// Value formats the amount for the locale
+func (a Amount) Value(locale string) Value {
+	return Value{
+		Minor:    a.Minor,
+		Currency: a.Currency,
+		Display:  Format(a, locale),
+	}
+}
+
+// formatRule is how a locale writes numbers and places the currency symbol
+type formatRule struct {
+	group, decimal string
+	symbolAfter    bool
+	// space separates the symbol from the number
+	space string
+}
+
+// Spaces are non-breaking so amounts never wrap
+var formatRules = map[string]formatRule{
+	"en": {group: ",", decimal: "."},
+	"es": {group: ".", decimal: ",", symbolAfter: true, space: "\u00a0"},
+	"pt": {group: ".", decimal: ",", space: "\u00a0"},
+	"fr": {group: "\u202f", decimal: ",", symbolAfter: true, space: "\u00a0"},
+	"de": {group: ".", decimal: ",", symbolAfter: true, space: "\u00a0"},
+}
+
+// Format writes the amount the way the locale does, e.g. "$1,234.56" for en
+// and "1.234,56 €" for de. Unknown locales use English conventions.
+func Format(a Amount, locale string) string {
+	rule, ok := formatRules[i18n.Normalize(locale)]
+	if !ok {
+		rule = formatRules[i18n.Default]
+	}
+
+	info, ok := currencies[a.Currency]
+	if !ok {
+		info = currencyInfo{Digits: 2, Symbol: string(a.Currency)}
+	}
+
+	minor := a.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	digits := strconv.FormatInt(minor, 10)
+	if len(digits) <= info.Digits {
+		digits = strings.Repeat("0", info.Digits-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-info.Digits], digits[len(digits)-info.Digits:]
+
+	var number strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			number.WriteString(rule.group)
+		}
+		number.WriteRune(r)
+	}
+	if fraction != "" {
+		number.WriteString(rule.decimal)
+		number.WriteString(fraction)
+	}
+
+	if rule.symbolAfter {
+		return sign + number.String() + rule.space + info.Symbol
+	}
+	return sign + info.Symbol + rule.space + number.String()
+}
@@ -0,0 +1,121 @@
+package money
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	amount := Amount{Minor: 123456, Currency: "EUR"}
+
+	assert.Equal(t, "€1,234.56", Format(amount, "en"))
+	assert.Equal(t, "1.234,56\u00a0€", Format(amount, "de-DE"))
+	assert.Equal(t, "1\u202f234,56\u00a0€", Format(amount, "fr"))
+	assert.Equal(t, "R$\u00a01.234,56", Format(Amount{Minor: 123456, Currency: "BRL"}, "pt-BR"))
+	assert.Equal(t, "€1,234.56", Format(amount, "ja"))
+
+	assert.Equal(t, "$0.05", Format(Cents(5), "en"))
+	assert.Equal(t, "-$12.00", Format(Cents(-1200), "en"))
+	assert.Equal(t, "$1,000,000.00", Format(Cents(100000000), "en"))
+	assert.Equal(t, "¥1,235", Format(Amount{Minor: 1235, Currency: "JPY"}, "en"))
+}
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := ParseCurrency(" eur ")
+	require.NoError(t, err)
+	assert.Equal(t, Currency("EUR"), currency)
+
+	_, err = ParseCurrency("XYZ")
+	assert.Error(t, err)
+	assert.Contains(t, Supported(), "USD")
+}
+
+func TestRatesConvert(t *testing.T) {
+	rates := &Rates{Base: "USD", Rates: map[Currency]float64{"EUR": 0.9, "JPY": 150, "GBP": 0.75}}
+
+	eur, err := rates.Convert(Cents(1000), "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, Amount{Minor: 900, Currency: "EUR"}, eur)
+
+	// JPY has no minor unit
+	yen, err := rates.Convert(Cents(1000), "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, Amount{Minor: 1500, Currency: "JPY"}, yen)
+
+	// Cross rates go through the base
+	gbp, err := rates.Convert(Amount{Minor: 900, Currency: "EUR"}, "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, Amount{Minor: 750, Currency: "GBP"}, gbp)
+
+	_, err = rates.Convert(Cents(1000), "SEK")
+	assert.Error(t, err)
+}
+
+type fakeSource struct {
+	calls int
+	rates *Rates
+	err   error
+}
+
+func (s *fakeSource) FetchRates(ctx context.Context) (*Rates, error) {
+	s.calls++
+	return s.rates, s.err
+}
+
+func TestConverterCachesRates(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{rates: &Rates{Base: "USD", Rates: map[Currency]float64{"EUR": 0.5}, FetchedAt: time.Now()}}
+	converter := NewConverter(source, time.Hour)
+
+	// Same currency never needs rates
+	same, err := converter.Convert(ctx, Cents(100), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, Cents(100), same)
+	assert.Equal(t, 0, source.calls)
+
+	for i := 0; i < 3; i++ {
+		eur, err := converter.Convert(ctx, Cents(100), "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), eur.Minor)
+	}
+	assert.Equal(t, 1, source.calls)
+
+	// A failed refresh keeps serving the previous rates
+	converter.refreshAt = time.Now().Add(-time.Second)
+	source.err = errors.New("unavailable")
+	eur, err := converter.Convert(ctx, Cents(100), "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), eur.Minor)
+	assert.Equal(t, 2, source.calls)
+
+	// Without any rates the error is returned
+	_, err = NewConverter(source, time.Hour).Convert(ctx, Cents(100), "EUR")
+	assert.Error(t, err)
+}
+
+func TestHTTPRateSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"success","base_code":"USD","rates":{"USD":1,"EUR":0.92}}`))
+	}))
+	defer server.Close()
+
+	rates, err := NewHTTPRateSource(server.URL, time.Second).FetchRates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Currency("USD"), rates.Base)
+	assert.Equal(t, 0.92, rates.Rates["EUR"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"error","error-type":"unsupported-code"}`))
+	}))
+	defer failing.Close()
+
+	_, err = NewHTTPRateSource(failing.URL, time.Second).FetchRates(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,195 @@
+package money
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Rates are exchange rates relative to a base currency: one unit of Base buys
+// Rates[c] units of c
+type Rates struct {
+	Base      Currency
+	Rates     map[Currency]float64
+	FetchedAt time.Time
+}
+
+// Rate returns how many units of to one unit of from buys
+func (r *Rates) Rate(from, to Currency) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromRate, err := r.baseRate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.baseRate(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+func (r *Rates) baseRate(c Currency) (float64, error) {
+	if c == r.Base {
+		return 1, nil
+	}
+	rate, ok := r.Rates[c]
+	if !ok || rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("no exchange rate for %s", c)
+	}
+	return rate, nil
+}
+
+// Convert converts the amount, rounding to the minor unit of to
+func (r *Rates) Convert(a Amount, to Currency) (Amount, error) {
+	rate, err := r.Rate(a.Currency, to)
+	if err != nil {
+		return Amount{}, err
+	}
+	return a.Exchange(to, rate), nil
+}
+
+// RateSource fetches the latest exchange rates
+type RateSource interface {
+	FetchRates(ctx context.Context) (*Rates, error)
+}
+
+// httpRateSource reads rates from a JSON endpoint such as open.er-api.com or
+// Frankfurter
+type httpRateSource struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewHTTPRateSource fetches rates from url
+func NewHTTPRateSource(url string, timeout time.Duration) RateSource {
+	return &httpRateSource{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type ratesResponse struct {
+	Result   string             `json:"result"`
+	BaseCode string             `json:"base_code"`
+	Base     string             `json:"base"`
+	Rates    map[string]float64 `json:"rates"`
+}
+
+func (s *httpRateSource) FetchRates(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rates request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rates returned status %d", resp.StatusCode)
+	}
+
+	var body ratesResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange rates: %w", err)
+	}
+	if body.Result != "" && body.Result != "success" {
+		return nil, fmt.Errorf("exchange rates returned result %q", body.Result)
+	}
+
+	base := body.BaseCode
+	if base == "" {
+		base = body.Base
+	}
+	if base == "" || len(body.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rates response has no base or rates")
+	}
+
+	rates := &Rates{
+		Base:      Currency(base),
+		Rates:     make(map[Currency]float64, len(body.Rates)),
+		FetchedAt: time.Now(),
+	}
+	for code, rate := range body.Rates {
+		rates.Rates[Currency(code)] = rate
+	}
+	return rates, nil
+}
+
+// refreshRetry is how long stale rates are used after a failed refresh before
+// the source is tried again
+const refreshRetry = time.Minute
+
+// Converter converts amounts with rates that are fetched at most once per TTL.
+// When a refresh fails the previous rates keep being used.
+type Converter struct {
+	source RateSource
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	rates     *Rates
+	refreshAt time.Time
+}
+
+func NewConverter(source RateSource, ttl time.Duration) *Converter {
+	return &Converter{
+		source: source,
+		ttl:    ttl,
+	}
+}
+
+// NewConverterFromConfig returns a converter reading MONEY_RATES_URL
+func NewConverterFromConfig() *Converter {
+	cfg := config.Money()
+	return NewConverter(NewHTTPRateSource(cfg.RatesURL, cfg.RatesTimeout), cfg.RatesTTL)
+}
+
+// Rates returns the cached rates, refreshing them once they are older than the TTL
+func (c *Converter) Rates(ctx context.Context) (*Rates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.rates != nil && now.Before(c.refreshAt) {
+		return c.rates, nil
+	}
+
+	rates, err := c.source.FetchRates(ctx)
+	if err != nil {
+		if c.rates != nil {
+			log.Printf("Using exchange rates from %s, refresh failed: %v", c.rates.FetchedAt.Format(time.RFC3339), err)
+			c.refreshAt = now.Add(refreshRetry)
+			return c.rates, nil
+		}
+		return nil, err
+	}
+	c.rates = rates
+	c.refreshAt = now.Add(c.ttl)
+	return rates, nil
+}
+
+// Convert converts the amount into to. Amounts already in to are returned
+// without fetching rates.
+func (c *Converter) Convert(ctx context.Context, a Amount, to Currency) (Amount, error) {
+	if a.Currency == to {
+		return a, nil
+	}
+	rates, err := c.Rates(ctx)
+	if err != nil {
+		return Amount{}, err
+	}
+	return rates.Convert(a, to)
+}
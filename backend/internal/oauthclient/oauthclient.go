@@ -0,0 +1,210 @@
+// Package oauthclient is the OAuth 2.0 authorization code client shared by the
+// platforms connected next to Twitch. Provider packages embed Client and add
+// their API calls.
+package oauthclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
+)
+
+// DefaultCallTimeout bounds each request, including reading its response,
+// unless the caller's context ends sooner
+const DefaultCallTimeout = 10 * time.Second
+
+// Provider describes the OAuth endpoints of a platform
+type Provider struct {
+	// Name labels traces and errors, e.g. "patreon"
+	Name         string
+	AuthorizeURL string
+	TokenURL     string
+	// Scopes are requested when connecting
+	Scopes []string
+	// AuthorizeParams are added to every authorization URL
+	AuthorizeParams url.Values
+}
+
+type Client struct {
+	provider     Provider
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	callTimeout  time.Duration
+}
+
+func NewClient(provider Provider, clientID, clientSecret string) *Client {
+	return &Client{
+		provider:     provider,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   httpclient.New(provider.Name),
+		callTimeout:  DefaultCallTimeout,
+	}
+}
+
+// SetCallTimeout changes the time budget of each request. Zero leaves requests
+// bounded only by their context.
+func (c *Client) SetCallTimeout(timeout time.Duration) {
+	c.callTimeout = timeout
+}
+
+// SetTransport replaces the transport used for requests, e.g. in tests.
+// Requests are still traced and retried.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = httpclient.NewTransport(c.provider.Name, rt)
+}
+
+// APIError is returned when a provider responds with an unexpected status code
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	provider := e.Provider
+	if provider == "" {
+		provider = "oauth"
+	}
+	if e.Body == "" {
+		return fmt.Sprintf("%s API error: %d", provider, e.StatusCode)
+	}
+	return fmt.Sprintf("%s API error: status %d, body: %s", provider, e.StatusCode, e.Body)
+}
+
+// CallContext bounds ctx by the call budget
+func (c *Client) CallContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.callTimeout > 0 {
+		return context.WithTimeout(ctx, c.callTimeout)
+	}
+	return ctx, func() {}
+}
+
+// Send executes req and returns the response of a 200 or 201, which the
+// caller closes. It doesn't apply the call budget.
+func (c *Client) Send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{Provider: c.provider.Name, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, nil
+}
+
+// Do sends req within the call budget and decodes the response into out
+func (c *Client) Do(req *http.Request, out any) error {
+	ctx, cancel := c.CallContext(req.Context())
+	defer cancel()
+
+	resp, err := c.Send(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// GetJSON performs an authorized GET of apiURL
+func (c *Client) GetJSON(ctx context.Context, accessToken, apiURL string, params url.Values, out any) error {
+	if len(params) > 0 {
+		apiURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return c.Do(req, out)
+}
+
+// Token is the response of a token endpoint
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is 0 for tokens that stay valid until access is revoked
+	ExpiresIn int `json:"expires_in"`
+	// Scope is space separated
+	Scope     string `json:"scope"`
+	TokenType string `json:"token_type"`
+}
+
+// ExpiresAt returns the absolute expiry time of the token relative to now, nil
+// when it doesn't expire
+func (t *Token) ExpiresAt() *time.Time {
+	if t.ExpiresIn <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	return &expiresAt
+}
+
+// Scopes returns the scopes requested when connecting
+func (c *Client) Scopes() []string {
+	return c.provider.Scopes
+}
+
+// AuthorizeURL builds the authorization URL for the authorization code flow
+func (c *Client) AuthorizeURL(redirectURI, state string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.clientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(c.provider.Scopes, " "))
+	params.Set("state", state)
+	for key, values := range c.provider.AuthorizeParams {
+		params[key] = values
+	}
+	return c.provider.AuthorizeURL + "?" + params.Encode()
+}
+
+// ExchangeCode exchanges an authorization code for an access token
+func (c *Client) ExchangeCode(ctx context.Context, code, redirectURI string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	return c.requestToken(ctx, form)
+}
+
+// RefreshToken exchanges a refresh token for a new access token
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	return c.requestToken(ctx, form)
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token Token
+	if err := c.Do(req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
@@ -0,0 +1,86 @@
+package oauthclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respond(status int, body string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+}
+
+var testProvider = Provider{
+	Name:            "example",
+	AuthorizeURL:    "https://example.com/oauth/authorize",
+	TokenURL:        "https://example.com/oauth/token",
+	Scopes:          []string{"read", "write"},
+	AuthorizeParams: url.Values{"prompt": {"consent"}},
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	client := NewClient(testProvider, "client", "secret")
+
+	authURL, err := url.Parse(client.AuthorizeURL("https://app.example/callback", "state1"))
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", authURL.Host)
+	query := authURL.Query()
+	assert.Equal(t, "client", query.Get("client_id"))
+	assert.Equal(t, "read write", query.Get("scope"))
+	assert.Equal(t, "state1", query.Get("state"))
+	assert.Equal(t, "consent", query.Get("prompt"))
+	assert.Empty(t, query.Get("client_secret"))
+}
+
+func TestExchangeCode(t *testing.T) {
+	client := NewClient(testProvider, "client", "secret")
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/oauth/token", req.URL.Path)
+		require.NoError(t, req.ParseForm())
+		assert.Equal(t, "authorization_code", req.PostForm.Get("grant_type"))
+		assert.Equal(t, "code1", req.PostForm.Get("code"))
+		assert.Equal(t, "secret", req.PostForm.Get("client_secret"))
+		return respond(http.StatusOK, `{"access_token": "access", "refresh_token": "refresh", "expires_in": 3600, "scope": "read write"}`)(req)
+	}))
+
+	token, err := client.ExchangeCode(context.Background(), "code1", "https://app.example/callback")
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.Equal(t, "read write", token.Scope)
+}
+
+func TestTokensWithoutExpiry(t *testing.T) {
+	assert.Nil(t, (&Token{}).ExpiresAt())
+	assert.NotNil(t, (&Token{ExpiresIn: 3600}).ExpiresAt())
+}
+
+func TestErrorsKeepStatus(t *testing.T) {
+	client := NewClient(testProvider, "client", "secret")
+	client.SetTransport(respond(http.StatusBadRequest, `{"error":"invalid_grant"}`))
+
+	_, err := client.RefreshToken(context.Background(), "refresh")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, `example API error: status 400, body: {"error":"invalid_grant"}`, err.Error())
+}
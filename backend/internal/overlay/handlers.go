@@ -0,0 +1,219 @@
+package overlay
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxTokensPerUser bounds how many active overlay tokens a user can hold
+const maxTokensPerUser = 20
+
+type Handlers struct {
+	service *Service
+	repo    Repository
+	audit   *audit.Logger
+}
+
+func NewHandlers(service *Service, repo Repository) *Handlers {
+	return &Handlers{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// UseAuditLog records token creation and revocation. Call it before RegisterRoutes.
+func (h *Handlers) UseAuditLog(logger *audit.Logger) {
+	h.audit = logger
+}
+
+// RegisterRoutes registers token management routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	tokens := router.Group("/overlay/tokens")
+	tokens.Get("/", h.ListTokens)
+	tokens.Post("/", h.CreateToken)
+	tokens.Delete("/:id", h.audit.Middleware(audit.ActionOverlayTokenRevoke), h.RevokeToken)
+}
+
+// PublicOverlay serves overlay stats for a share token. It is public and meant
+// to be polled by OBS browser sources; ?fields=followers,latest_milestone
+// narrows the payload further than the token's own field list.
+func (h *Handlers) PublicOverlay(c *fiber.Ctx) error {
+	// Overlays are loaded from local files or third-party overlay hosts
+	c.Set("Access-Control-Allow-Origin", "*")
+
+	token, stats, err := h.service.GetOverlay(c.UserContext(), c.Params("token"))
+	if err != nil {
+		log.Printf("Error loading overlay: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load overlay",
+		})
+	}
+	if token == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Overlay not found",
+		})
+	}
+
+	allowed, err := ParseFields(token.Fields)
+	if err != nil {
+		allowed = AllFields
+	}
+	fields := allowed
+	if requested := c.Query("fields"); requested != "" {
+		parsed, err := ParseFields(requested)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		fields = SelectFields(allowed, parsed)
+	}
+
+	maxAge := int(h.service.CacheTTL().Seconds())
+	if maxAge < 1 {
+		maxAge = 1
+	}
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+
+	return c.JSON(stats.Render(fields))
+}
+
+// ListTokens returns the user's active overlay tokens
+func (h *Handlers) ListTokens(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	tokens, err := h.repo.ListTokens(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing overlay tokens for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list overlay tokens",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"tokens": tokens,
+		"fields": AllFields,
+	})
+}
+
+// CreateToken creates a share token, optionally limited to some fields
+func (h *Handlers) CreateToken(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req struct {
+		Name   string   `json:"name"`
+		Fields []string `json:"fields"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required and must be at most 100 characters",
+		})
+	}
+
+	fields, err := ParseFields(strings.Join(req.Fields, ","))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	existing, err := h.repo.ListTokens(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing overlay tokens for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create overlay token",
+		})
+	}
+	if len(existing) >= maxTokensPerUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Overlay token limit reached, revoke an existing token first",
+		})
+	}
+
+	value, err := generateToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create overlay token",
+		})
+	}
+
+	token := &Token{
+		UserID: user.ID,
+		Name:   req.Name,
+		Token:  value,
+		Fields: strings.Join(fields, ","),
+	}
+	if err := h.repo.CreateToken(c.UserContext(), token); err != nil {
+		log.Printf("Error creating overlay token for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create overlay token",
+		})
+	}
+
+	h.audit.RecordRequest(c, user.ID, audit.ActionOverlayTokenCreate, strconv.Itoa(token.ID), map[string]any{
+		"fields": token.Fields,
+	})
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"token": token,
+		"path":  "/api/public/overlay/" + token.Token,
+	})
+}
+
+// RevokeToken revokes one of the user's overlay tokens
+func (h *Handlers) RevokeToken(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	tokenID, err := c.ParamsInt("id")
+	if err != nil || tokenID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid token ID",
+		})
+	}
+
+	value, err := h.repo.RevokeToken(c.UserContext(), user.ID, tokenID)
+	if err != nil {
+		log.Printf("Error revoking overlay token %d for user %s: %v", tokenID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke overlay token",
+		})
+	}
+	if value == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Overlay token not found",
+		})
+	}
+
+	h.service.Evict(value)
+	return c.JSON(fiber.Map{
+		"message": "Overlay token revoked",
+		"id":      tokenID,
+	})
+}
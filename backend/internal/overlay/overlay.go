@@ -0,0 +1,157 @@
+// Package overlay serves minimal, heavily cached stats for OBS browser-source
+// overlays through public share tokens.
+package overlay
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fields that can be selected on an overlay
+const (
+	FieldDisplayName     = "display_name"
+	FieldFollowers       = "followers"
+	FieldSubscribers     = "subscribers"
+	FieldTotalViews      = "total_views"
+	FieldLatestMilestone = "latest_milestone"
+)
+
+// AllFields lists every selectable overlay field
+var AllFields = []string{FieldDisplayName, FieldFollowers, FieldSubscribers, FieldTotalViews, FieldLatestMilestone}
+
+// followerMilestones are the follower counts worth celebrating on stream
+var followerMilestones = []int{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000, 250000, 500000, 1000000}
+
+// Token is a user-managed overlay share token
+type Token struct {
+	ID        int        `json:"id" db:"id"`
+	UserID    string     `json:"-" db:"user_id"`
+	Name      string     `json:"name" db:"name"`
+	Token     string     `json:"token" db:"token"`
+	Fields    string     `json:"fields" db:"fields"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Milestone describes the last follower milestone reached and the next one
+type Milestone struct {
+	Reached  int     `json:"reached"`
+	Next     int     `json:"next"`
+	Progress float64 `json:"progress"` // 0-1 progress from Reached towards Next
+}
+
+// Stats is the full overlay payload before field selection
+type Stats struct {
+	DisplayName     string
+	Followers       int
+	Subscribers     int
+	TotalViews      int
+	LatestMilestone *Milestone
+	UpdatedAt       time.Time
+}
+
+// generateToken returns a random URL-safe share token
+func generateToken() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate overlay token: %w", err)
+	}
+	return "ov_" + hex.EncodeToString(buf), nil
+}
+
+// ParseFields validates a comma separated field list. Empty means all fields.
+func ParseFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return AllFields, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown overlay field %q (valid: %s)", field, strings.Join(AllFields, ", "))
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// SelectFields narrows the token's fields to those requested; a requested field
+// the token does not allow is ignored
+func SelectFields(allowed []string, requested []string) []string {
+	var selected []string
+	for _, field := range requested {
+		for _, ok := range allowed {
+			if field == ok {
+				selected = append(selected, field)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// Render builds the JSON payload with only the selected fields
+func (s *Stats) Render(fields []string) map[string]interface{} {
+	payload := map[string]interface{}{
+		"updated_at": s.UpdatedAt,
+	}
+	for _, field := range fields {
+		switch field {
+		case FieldDisplayName:
+			payload[field] = s.DisplayName
+		case FieldFollowers:
+			payload[field] = s.Followers
+		case FieldSubscribers:
+			payload[field] = s.Subscribers
+		case FieldTotalViews:
+			payload[field] = s.TotalViews
+		case FieldLatestMilestone:
+			payload[field] = s.LatestMilestone
+		}
+	}
+	return payload
+}
+
+// MilestoneFor returns the follower milestone reached and progress to the next one
+func MilestoneFor(followers int) *Milestone {
+	reached := 0
+	next := followerMilestones[0]
+	for _, milestone := range followerMilestones {
+		if followers >= milestone {
+			reached = milestone
+			continue
+		}
+		next = milestone
+		break
+	}
+
+	if followers >= followerMilestones[len(followerMilestones)-1] {
+		// Past the table: keep counting in steps of a million
+		reached = followers / 1000000 * 1000000
+		next = reached + 1000000
+	}
+
+	return &Milestone{
+		Reached:  reached,
+		Next:     next,
+		Progress: float64(followers-reached) / float64(next-reached),
+	}
+}
+
+func isField(field string) bool {
+	for _, known := range AllFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
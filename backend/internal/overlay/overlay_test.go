@@ -0,0 +1,31 @@
+package overlay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMilestoneFor(t *testing.T) {
+	assert.Equal(t, &Milestone{Reached: 0, Next: 10, Progress: 0.5}, MilestoneFor(5))
+	assert.Equal(t, &Milestone{Reached: 100, Next: 250, Progress: 0}, MilestoneFor(100))
+	assert.Equal(t, &Milestone{Reached: 1000, Next: 2500, Progress: 0.5}, MilestoneFor(1750))
+	assert.Equal(t, &Milestone{Reached: 2000000, Next: 3000000, Progress: 0.5}, MilestoneFor(2500000))
+}
+
+func TestFieldSelection(t *testing.T) {
+	allowed, err := ParseFields("followers,latest_milestone")
+	require.NoError(t, err)
+
+	requested, err := ParseFields("followers, subscribers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{FieldFollowers}, SelectFields(allowed, requested))
+
+	_, err = ParseFields("followers,email")
+	assert.Error(t, err)
+
+	payload := (&Stats{Followers: 42, Subscribers: 7}).Render([]string{FieldFollowers})
+	assert.Equal(t, 42, payload["followers"])
+	assert.NotContains(t, payload, "subscribers")
+}
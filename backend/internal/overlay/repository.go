@@ -0,0 +1,81 @@
+package overlay
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	CreateToken(ctx context.Context, token *Token) error
+	ListTokens(ctx context.Context, userID string) ([]Token, error)
+	RevokeToken(ctx context.Context, userID string, tokenID int) (string, error)
+	GetActiveToken(ctx context.Context, token string) (*Token, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+func (r *repository) CreateToken(ctx context.Context, token *Token) error {
+	query := `
+		INSERT INTO overlay_tokens (user_id, name, token, fields)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowxContext(ctx, query, token.UserID, token.Name, token.Token, token.Fields).
+		Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *repository) ListTokens(ctx context.Context, userID string) ([]Token, error) {
+	query := `
+		SELECT id, user_id, name, token, fields, revoked_at, created_at
+		FROM overlay_tokens 
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	tokens := []Token{}
+	err := r.db.SelectContext(ctx, &tokens, query, userID)
+	return tokens, err
+}
+
+// RevokeToken revokes a token and returns its value so it can be evicted from
+// the cache, or "" if the user has no such active token
+func (r *repository) RevokeToken(ctx context.Context, userID string, tokenID int) (string, error) {
+	query := `
+		UPDATE overlay_tokens 
+		SET revoked_at = NOW() 
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		RETURNING token
+	`
+
+	var token string
+	err := r.db.GetContext(ctx, &token, query, tokenID, userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return token, err
+}
+
+func (r *repository) GetActiveToken(ctx context.Context, token string) (*Token, error) {
+	query := `
+		SELECT id, user_id, name, token, fields, revoked_at, created_at
+		FROM overlay_tokens 
+		WHERE token = $1 AND revoked_at IS NULL
+	`
+
+	var t Token
+	err := r.db.GetContext(ctx, &t, query, token)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &t, err
+}
@@ -0,0 +1,138 @@
+package overlay
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// maxCacheEntries triggers pruning of expired entries
+const maxCacheEntries = 10000
+
+// Service resolves overlay tokens to stats, keeping results in memory for the
+// configured TTL so overlays can poll every few seconds without reaching the
+// database or Twitch on each request
+type Service struct {
+	repo          Repository
+	analyticsRepo analytics.Repository
+	tokens        *analytics.TwitchTokenHelper
+	twitchClient  *twitch.Client
+	cfg           config.OverlayConfig
+	cache         *cache.Memory[cachedOverlay]
+}
+
+type cachedOverlay struct {
+	token *Token // nil for unknown or revoked tokens
+	stats *Stats
+}
+
+func NewService(repo Repository, analyticsRepo analytics.Repository, twitchClient *twitch.Client) *Service {
+	cfg := config.Overlay()
+	return &Service{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+		tokens:        analytics.NewTwitchTokenHelper(analyticsRepo, twitchClient),
+		twitchClient:  twitchClient,
+		cfg:           cfg,
+		cache:         cache.NewMemory[cachedOverlay](cfg.CacheTTL, maxCacheEntries),
+	}
+}
+
+// GetOverlay returns the token and its stats, or nil, nil, nil if the token is
+// unknown or revoked
+func (s *Service) GetOverlay(ctx context.Context, value string) (*Token, *Stats, error) {
+	if cached, ok := s.cache.GetContext(ctx, value); ok {
+		return cached.token, cached.stats, nil
+	}
+
+	token, err := s.repo.GetActiveToken(ctx, value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stats *Stats
+	if token != nil {
+		stats, err = s.loadStats(ctx, token.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s.cache.Set(value, cachedOverlay{token: token, stats: stats})
+	return token, stats, nil
+}
+
+// Evict drops a token from the cache, e.g. after it was revoked
+func (s *Service) Evict(value string) {
+	s.cache.Delete(value)
+}
+
+// EvictUser drops every cached overlay of a user, e.g. after their data was collected
+func (s *Service) EvictUser(userID string) {
+	s.cache.DeleteFunc(func(_ string, cached cachedOverlay) bool {
+		return cached.token != nil && cached.token.UserID == userID
+	})
+}
+
+// CacheTTL is how long overlay payloads are cached
+func (s *Service) CacheTTL() time.Duration {
+	return s.cfg.CacheTTL
+}
+
+func (s *Service) loadStats(ctx context.Context, userID string) (*Stats, error) {
+	stats := &Stats{UpdatedAt: time.Now().UTC()}
+
+	user, err := s.analyticsRepo.GetUserByClerkID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.analyticsRepo.GetLatestChannelAnalytics(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		stats.Followers = latest.FollowersCount
+		stats.Subscribers = latest.SubscriberCount
+		stats.TotalViews = latest.TotalViews
+		stats.UpdatedAt = latest.CreatedAt
+	}
+
+	if user != nil {
+		stats.DisplayName = user.DisplayName
+		if s.cfg.LiveCounts && user.TwitchUserID != "" {
+			s.applyLiveCounts(ctx, userID, user.TwitchUserID, stats)
+		}
+	}
+
+	stats.LatestMilestone = MilestoneFor(stats.Followers)
+	return stats, nil
+}
+
+// applyLiveCounts replaces snapshot counts with current totals from Twitch; on
+// any error the snapshot values are kept
+func (s *Service) applyLiveCounts(ctx context.Context, userID, twitchUserID string, stats *Stats) {
+	token, err := s.tokens.GetValidToken(ctx, userID)
+	if err != nil {
+		log.Printf("Overlay using snapshot for user %s: %v", userID, err)
+		return
+	}
+
+	followers, err := s.twitchClient.GetChannelFollowers(ctx, token, twitchUserID, 1, "")
+	if err != nil {
+		log.Printf("Overlay failed to get live followers for user %s: %v", userID, err)
+		return
+	}
+	stats.Followers = followers.Total
+	stats.UpdatedAt = time.Now().UTC()
+
+	subs, err := s.twitchClient.GetBroadcasterSubscribers(ctx, token, twitchUserID, 1, "")
+	if err == nil {
+		stats.Subscribers = subs.Total
+	}
+}
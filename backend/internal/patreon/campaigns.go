@@ -0,0 +1,222 @@
+package patreon
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// User is the Patreon account that authorized the app
+type User struct {
+	ID       string `json:"id"`
+	FullName string `json:"full_name"`
+	Vanity   string `json:"vanity"`
+}
+
+// Campaign is a creator's Patreon page with its published tiers
+type Campaign struct {
+	ID          string `json:"id"`
+	PatronCount int    `json:"patron_count"`
+	URL         string `json:"url"`
+	Tiers       []Tier `json:"tiers"`
+}
+
+// Tier is a membership tier. Amounts are in the campaign's currency.
+type Tier struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	AmountCents int    `json:"amount_cents"`
+	PatronCount int    `json:"patron_count"`
+}
+
+// Member is a patron of a campaign
+type Member struct {
+	ID string `json:"id"`
+	// PatronStatus is active_patron, declined_patron or former_patron, empty
+	// for followers who never pledged
+	PatronStatus string `json:"patron_status"`
+	// CurrentlyEntitledAmountCents is what the member pays each PledgeCadence
+	// months, in the campaign's currency
+	CurrentlyEntitledAmountCents int      `json:"currently_entitled_amount_cents"`
+	PledgeCadence                int      `json:"pledge_cadence"`
+	TierIDs                      []string `json:"tier_ids"`
+}
+
+// IsActive reports whether the member currently pays
+func (m Member) IsActive() bool {
+	return m.PatronStatus == "active_patron"
+}
+
+// MonthlyAmountCents is the member's pledge spread over a month, so annual
+// pledges count a twelfth
+func (m Member) MonthlyAmountCents() int {
+	return m.CurrentlyEntitledAmountCents / max(m.PledgeCadence, 1)
+}
+
+// MembersPage is a page of members and the cursor of the next page, empty on
+// the last one
+type MembersPage struct {
+	Members    []Member
+	NextCursor string
+}
+
+// JSON:API documents returned by the API
+
+type resourceID struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type identityResponse struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			FullName string `json:"full_name"`
+			Vanity   string `json:"vanity"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type campaignsResponse struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			PatronCount int    `json:"patron_count"`
+			URL         string `json:"url"`
+		} `json:"attributes"`
+		Relationships struct {
+			Tiers struct {
+				Data []resourceID `json:"data"`
+			} `json:"tiers"`
+		} `json:"relationships"`
+	} `json:"data"`
+	Included []struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			Title       string `json:"title"`
+			AmountCents int    `json:"amount_cents"`
+			PatronCount int    `json:"patron_count"`
+			Published   bool   `json:"published"`
+		} `json:"attributes"`
+	} `json:"included"`
+}
+
+type membersResponse struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			PatronStatus                 *string `json:"patron_status"`
+			CurrentlyEntitledAmountCents int     `json:"currently_entitled_amount_cents"`
+			PledgeCadence                *int    `json:"pledge_cadence"`
+		} `json:"attributes"`
+		Relationships struct {
+			CurrentlyEntitledTiers struct {
+				Data []resourceID `json:"data"`
+			} `json:"currently_entitled_tiers"`
+		} `json:"relationships"`
+	} `json:"data"`
+	Meta struct {
+		Pagination struct {
+			Cursors struct {
+				Next *string `json:"next"`
+			} `json:"cursors"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+// GetIdentity returns the user the access token belongs to
+func (c *Client) GetIdentity(ctx context.Context, accessToken string) (*User, error) {
+	params := url.Values{}
+	params.Set("fields[user]", "full_name,vanity")
+
+	var resp identityResponse
+	if err := c.getJSON(ctx, accessToken, "/identity", params, &resp); err != nil {
+		return nil, err
+	}
+	return &User{
+		ID:       resp.Data.ID,
+		FullName: resp.Data.Attributes.FullName,
+		Vanity:   resp.Data.Attributes.Vanity,
+	}, nil
+}
+
+// GetCampaign returns the user's campaign with its published tiers, or nil
+// when the user has no campaign, i.e. isn't a creator on Patreon
+func (c *Client) GetCampaign(ctx context.Context, accessToken string) (*Campaign, error) {
+	params := url.Values{}
+	params.Set("include", "tiers")
+	params.Set("fields[campaign]", "patron_count,url")
+	params.Set("fields[tier]", "title,amount_cents,patron_count,published")
+
+	var resp campaignsResponse
+	if err := c.getJSON(ctx, accessToken, "/campaigns", params, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+
+	data := resp.Data[0]
+	campaign := &Campaign{
+		ID:          data.ID,
+		PatronCount: data.Attributes.PatronCount,
+		URL:         data.Attributes.URL,
+		Tiers:       []Tier{},
+	}
+	linked := make(map[string]bool, len(data.Relationships.Tiers.Data))
+	for _, tier := range data.Relationships.Tiers.Data {
+		linked[tier.ID] = true
+	}
+	for _, included := range resp.Included {
+		if included.Type != "tier" || !linked[included.ID] || !included.Attributes.Published {
+			continue
+		}
+		campaign.Tiers = append(campaign.Tiers, Tier{
+			ID:          included.ID,
+			Title:       included.Attributes.Title,
+			AmountCents: included.Attributes.AmountCents,
+			PatronCount: included.Attributes.PatronCount,
+		})
+	}
+	return campaign, nil
+}
+
+// GetMembersPage returns up to limit members of the campaign, starting at cursor
+func (c *Client) GetMembersPage(ctx context.Context, accessToken, campaignID string, limit int, cursor string) (*MembersPage, error) {
+	params := url.Values{}
+	params.Set("include", "currently_entitled_tiers")
+	params.Set("fields[member]", "patron_status,currently_entitled_amount_cents,pledge_cadence")
+	params.Set("page[count]", strconv.Itoa(limit))
+	if cursor != "" {
+		params.Set("page[cursor]", cursor)
+	}
+
+	var resp membersResponse
+	if err := c.getJSON(ctx, accessToken, "/campaigns/"+url.PathEscape(campaignID)+"/members", params, &resp); err != nil {
+		return nil, err
+	}
+
+	page := &MembersPage{Members: make([]Member, 0, len(resp.Data))}
+	for _, data := range resp.Data {
+		member := Member{
+			ID:                           data.ID,
+			CurrentlyEntitledAmountCents: data.Attributes.CurrentlyEntitledAmountCents,
+			PledgeCadence:                1,
+		}
+		if data.Attributes.PatronStatus != nil {
+			member.PatronStatus = *data.Attributes.PatronStatus
+		}
+		if data.Attributes.PledgeCadence != nil {
+			member.PledgeCadence = *data.Attributes.PledgeCadence
+		}
+		for _, tier := range data.Relationships.CurrentlyEntitledTiers.Data {
+			member.TierIDs = append(member.TierIDs, tier.ID)
+		}
+		page.Members = append(page.Members, member)
+	}
+	if next := resp.Meta.Pagination.Cursors.Next; next != nil {
+		page.NextCursor = *next
+	}
+	return page, nil
+}
@@ -0,0 +1,41 @@
+// Package patreon is a client for the Patreon API v2, used to read a creator's
+// campaign, membership tiers and members
+package patreon
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+)
+
+const apiBaseURL = "https://www.patreon.com/api/oauth2/v2"
+
+// Scopes are requested when connecting Patreon. campaigns.members is needed
+// for pledge amounts.
+var Scopes = []string{"identity", "campaigns", "campaigns.members"}
+
+// OAuthToken is the response of the Patreon token endpoint
+type OAuthToken = oauthclient.Token
+
+// APIError is returned when Patreon responds with an unexpected status code
+type APIError = oauthclient.APIError
+
+// Client handles the Patreon OAuth flow and API calls
+type Client struct {
+	*oauthclient.Client
+}
+
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{Client: oauthclient.NewClient(oauthclient.Provider{
+		Name:         "patreon",
+		AuthorizeURL: "https://www.patreon.com/oauth2/authorize",
+		TokenURL:     "https://www.patreon.com/api/oauth2/token",
+		Scopes:       Scopes,
+	}, clientID, clientSecret)}
+}
+
+// getJSON performs an authorized GET of an API path
+func (c *Client) getJSON(ctx context.Context, accessToken, path string, params url.Values, out any) error {
+	return c.GetJSON(ctx, accessToken, apiBaseURL+path, params, out)
+}
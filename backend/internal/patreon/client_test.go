@@ -0,0 +1,100 @@
+package patreon
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respond(status int, body string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestGetCampaignKeepsPublishedTiers(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(respond(http.StatusOK, `{
+		"data": [{
+			"id": "c1", "type": "campaign",
+			"attributes": {"patron_count": 42, "url": "https://www.patreon.com/creator"},
+			"relationships": {"tiers": {"data": [{"id": "t1", "type": "tier"}, {"id": "t2", "type": "tier"}]}}
+		}],
+		"included": [
+			{"id": "t1", "type": "tier", "attributes": {"title": "Supporter", "amount_cents": 500, "patron_count": 40, "published": true}},
+			{"id": "t2", "type": "tier", "attributes": {"title": "Draft", "amount_cents": 10000, "patron_count": 0, "published": false}}
+		]
+	}`))
+
+	campaign, err := client.GetCampaign(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", campaign.ID)
+	assert.Equal(t, 42, campaign.PatronCount)
+	assert.Equal(t, []Tier{{ID: "t1", Title: "Supporter", AmountCents: 500, PatronCount: 40}}, campaign.Tiers)
+}
+
+func TestGetCampaignWithoutCampaign(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(respond(http.StatusOK, `{"data": []}`))
+
+	campaign, err := client.GetCampaign(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Nil(t, campaign)
+}
+
+func TestGetMembersPage(t *testing.T) {
+	client := NewClient("client", "secret")
+	var query string
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.RawQuery
+		assert.Equal(t, "/api/oauth2/v2/campaigns/c1/members", req.URL.Path)
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		return respond(http.StatusOK, `{
+			"data": [
+				{"id": "m1", "attributes": {"patron_status": "active_patron", "currently_entitled_amount_cents": 500, "pledge_cadence": 1},
+				 "relationships": {"currently_entitled_tiers": {"data": [{"id": "t1", "type": "tier"}]}}},
+				{"id": "m2", "attributes": {"patron_status": "active_patron", "currently_entitled_amount_cents": 6000, "pledge_cadence": 12}},
+				{"id": "m3", "attributes": {"patron_status": null, "currently_entitled_amount_cents": 0, "pledge_cadence": null}}
+			],
+			"meta": {"pagination": {"cursors": {"next": "abc"}}}
+		}`)(req)
+	}))
+
+	page, err := client.GetMembersPage(context.Background(), "token", "c1", 500, "prev")
+	require.NoError(t, err)
+	assert.Contains(t, query, "page%5Bcursor%5D=prev")
+	assert.Equal(t, "abc", page.NextCursor)
+	require.Len(t, page.Members, 3)
+
+	assert.True(t, page.Members[0].IsActive())
+	assert.Equal(t, []string{"t1"}, page.Members[0].TierIDs)
+	assert.Equal(t, 500, page.Members[1].MonthlyAmountCents())
+	assert.False(t, page.Members[2].IsActive())
+	assert.Equal(t, 1, page.Members[2].PledgeCadence)
+}
+
+func TestErrorsKeepStatus(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(respond(http.StatusUnauthorized, `{"errors":[{"code_name":"Unauthorized"}]}`))
+
+	_, err := client.GetIdentity(context.Background(), "token")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
@@ -0,0 +1,100 @@
+package preferences
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	repo Repository
+}
+
+func NewHandlers(repo Repository) *Handlers {
+	return &Handlers{
+		repo: repo,
+	}
+}
+
+// RegisterRoutes registers the preferences routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/user/preferences", h.GetPreferences)
+	router.Put("/user/preferences", h.UpdatePreferences)
+}
+
+// GetPreferences returns the user's preferences, or the defaults with a null
+// updated_at if they never saved any
+func (h *Handlers) GetPreferences(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	record, err := h.repo.Get(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error loading preferences for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load preferences",
+		})
+	}
+	if record == nil {
+		record = &Record{Preferences: Default()}
+	}
+
+	return c.JSON(record)
+}
+
+// UpdatePreferences replaces the user's preferences. The body carries the
+// updated_at it was based on (null for the first save); if the preferences
+// changed since, it answers 409 with the current ones.
+func (h *Handlers) UpdatePreferences(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req struct {
+		Preferences json.RawMessage `json:"preferences"`
+		UpdatedAt   *time.Time      `json:"updated_at"`
+	}
+	if err := json.Unmarshal(c.Body(), &req); err != nil || len(req.Preferences) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	prefs, err := Parse(req.Preferences)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	record, err := h.repo.Save(c.UserContext(), user.ID, prefs, req.UpdatedAt)
+	if errors.Is(err, ErrConflict) {
+		current, getErr := h.repo.Get(c.UserContext(), user.ID)
+		if getErr != nil {
+			log.Printf("Error loading preferences for user %s: %v", user.ID, getErr)
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   err.Error(),
+			"current": current,
+		})
+	}
+	if err != nil {
+		log.Printf("Error saving preferences for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save preferences",
+		})
+	}
+
+	return c.JSON(record)
+}
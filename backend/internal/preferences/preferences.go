@@ -0,0 +1,138 @@
+// Package preferences stores each user's dashboard customization: widget
+// layout, default date range, theme and display currency. Documents are validated before they
+// are saved, and updates must name the version they replace.
+package preferences
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/money"
+)
+
+const (
+	// GridColumns is the width of the dashboard grid widgets are placed on
+	GridColumns = 12
+	// maxWidgets bounds the size of a layout
+	maxWidgets = 50
+	// maxGridRows bounds how far down a widget can be placed
+	maxGridRows = 200
+)
+
+var (
+	// ErrConflict is returned when the stored preferences changed since the
+	// version the update was based on
+	ErrConflict = errors.New("preferences were changed elsewhere")
+
+	widgetIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+	dateRanges = map[string]bool{"7d": true, "30d": true, "90d": true, "365d": true}
+	themes     = map[string]bool{"system": true, "light": true, "dark": true}
+)
+
+// Preferences is the document the frontend saves
+type Preferences struct {
+	Layout           []Widget `json:"layout"`
+	DefaultDateRange string   `json:"default_date_range"`
+	Theme            string   `json:"theme"`
+	// Currency is what revenue is shown in
+	Currency string `json:"currency"`
+}
+
+// Widget is one dashboard widget's position on the grid
+type Widget struct {
+	ID     string `json:"id"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	W      int    `json:"w"`
+	H      int    `json:"h"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// Record is the stored preferences of a user. UpdatedAt is nil until the user
+// saves for the first time.
+type Record struct {
+	Preferences Preferences `json:"preferences"`
+	UpdatedAt   *time.Time  `json:"updated_at"`
+}
+
+// Default is what users get before they customize anything. An empty layout
+// means the frontend's default arrangement.
+func Default() Preferences {
+	return Preferences{
+		Layout:           []Widget{},
+		DefaultDateRange: "30d",
+		Theme:            "system",
+		Currency:         string(money.Base),
+	}
+}
+
+// Parse decodes and validates a preferences document. Unknown fields are
+// rejected so typos don't get stored silently.
+func Parse(data []byte) (Preferences, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var prefs Preferences
+	if err := decoder.Decode(&prefs); err != nil {
+		return Preferences{}, fmt.Errorf("invalid preferences: %w", err)
+	}
+	if err := prefs.Validate(); err != nil {
+		return Preferences{}, err
+	}
+	return prefs, nil
+}
+
+// Validate checks the document against the schema, filling in defaults for
+// omitted settings
+func (p *Preferences) Validate() error {
+	defaults := Default()
+	if p.Layout == nil {
+		p.Layout = defaults.Layout
+	}
+	if p.DefaultDateRange == "" {
+		p.DefaultDateRange = defaults.DefaultDateRange
+	}
+	if p.Theme == "" {
+		p.Theme = defaults.Theme
+	}
+	if p.Currency == "" {
+		p.Currency = defaults.Currency
+	}
+
+	if !dateRanges[p.DefaultDateRange] {
+		return fmt.Errorf("default_date_range must be one of 7d, 30d, 90d or 365d")
+	}
+	if !themes[p.Theme] {
+		return fmt.Errorf("theme must be one of system, light or dark")
+	}
+	currency, err := money.ParseCurrency(p.Currency)
+	if err != nil {
+		return fmt.Errorf("currency must be one of %s", strings.Join(money.Supported(), ", "))
+	}
+	p.Currency = string(currency)
+	if len(p.Layout) > maxWidgets {
+		return fmt.Errorf("layout can have at most %d widgets", maxWidgets)
+	}
+
+	seen := make(map[string]bool, len(p.Layout))
+	for i, w := range p.Layout {
+		if !widgetIDPattern.MatchString(w.ID) {
+			return fmt.Errorf("layout[%d]: id must be 1-64 lowercase letters, digits, - or _", i)
+		}
+		if seen[w.ID] {
+			return fmt.Errorf("layout[%d]: duplicate widget %q", i, w.ID)
+		}
+		seen[w.ID] = true
+
+		if w.W < 1 || w.H < 1 || w.X < 0 || w.Y < 0 || w.X+w.W > GridColumns || w.Y+w.H > maxGridRows {
+			return fmt.Errorf("layout[%d]: widget %q must fit a %d column grid", i, w.ID, GridColumns)
+		}
+	}
+	return nil
+}
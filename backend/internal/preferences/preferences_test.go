@@ -0,0 +1,45 @@
+package preferences
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFillsDefaults(t *testing.T) {
+	prefs, err := Parse([]byte(`{"layout":[{"id":"followers","x":0,"y":0,"w":6,"h":4},{"id":"top_clips","x":6,"y":0,"w":6,"h":4,"hidden":true}]}`))
+	require.NoError(t, err)
+	assert.Len(t, prefs.Layout, 2)
+	assert.True(t, prefs.Layout[1].Hidden)
+	assert.Equal(t, "30d", prefs.DefaultDateRange)
+	assert.Equal(t, "system", prefs.Theme)
+	assert.Equal(t, "USD", prefs.Currency)
+
+	prefs, err = Parse([]byte(`{"currency":"eur"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", prefs.Currency)
+
+	prefs, err = Parse([]byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, Default(), prefs)
+}
+
+func TestParseRejectsInvalidDocuments(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown field":   `{"colour":"red"}`,
+		"wrong type":      `{"theme":1}`,
+		"theme":           `{"theme":"neon"}`,
+		"date range":      `{"default_date_range":"14d"}`,
+		"currency":        `{"currency":"XYZ"}`,
+		"widget id":       `{"layout":[{"id":"Bad Widget","x":0,"y":0,"w":1,"h":1}]}`,
+		"duplicate":       `{"layout":[{"id":"a","x":0,"y":0,"w":1,"h":1},{"id":"a","x":1,"y":0,"w":1,"h":1}]}`,
+		"overflows grid":  `{"layout":[{"id":"a","x":8,"y":0,"w":6,"h":1}]}`,
+		"empty widget":    `{"layout":[{"id":"a","x":0,"y":0,"w":0,"h":1}]}`,
+		"negative offset": `{"layout":[{"id":"a","x":-1,"y":0,"w":1,"h":1}]}`,
+		"not an object":   `[]`,
+	} {
+		_, err := Parse([]byte(doc))
+		assert.Error(t, err, name)
+	}
+}
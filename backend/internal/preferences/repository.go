@@ -0,0 +1,86 @@
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Get(ctx context.Context, userID string) (*Record, error)
+	Save(ctx context.Context, userID string, prefs Preferences, expected *time.Time) (*Record, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+// Get returns the user's saved preferences, or nil if they never saved any
+func (r *repository) Get(ctx context.Context, userID string) (*Record, error) {
+	query := `SELECT preferences, updated_at FROM user_preferences WHERE user_id = $1`
+
+	var data json.RawMessage
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&data, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	record := &Record{Preferences: Default(), UpdatedAt: &updatedAt}
+	if err := json.Unmarshal(data, &record.Preferences); err != nil {
+		return nil, fmt.Errorf("failed to decode preferences: %w", err)
+	}
+	return record, nil
+}
+
+// Save stores the preferences if the stored version still matches expected:
+// nil means the user has none saved yet. Otherwise it returns ErrConflict.
+func (r *repository) Save(ctx context.Context, userID string, prefs Preferences, expected *time.Time) (*Record, error) {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode preferences: %w", err)
+	}
+
+	var query string
+	args := []interface{}{userID, string(data)}
+	if expected == nil {
+		query = `
+			INSERT INTO user_preferences (user_id, preferences)
+			VALUES ($1, $2::jsonb)
+			ON CONFLICT (user_id) DO NOTHING
+			RETURNING updated_at
+		`
+	} else {
+		query = `
+			UPDATE user_preferences
+			SET preferences = $2::jsonb, updated_at = NOW()
+			WHERE user_id = $1 AND updated_at = $3
+			RETURNING updated_at
+		`
+		args = append(args, *expected)
+	}
+
+	var updatedAt time.Time
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	return &Record{Preferences: prefs, UpdatedAt: &updatedAt}, nil
+}
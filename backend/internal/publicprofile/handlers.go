@@ -0,0 +1,149 @@
+package publicprofile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	service *Service
+	repo    Repository
+}
+
+func NewHandlers(service *Service, repo Repository) *Handlers {
+	return &Handlers{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// RegisterRoutes registers profile settings routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/profile/public", h.GetSettings)
+	router.Put("/profile/public", h.UpdateSettings)
+}
+
+// PublicStats serves the public summary for a creator slug. It needs no auth
+// and is cacheable by browsers and CDNs so media kit pages can embed it.
+func (h *Handlers) PublicStats(c *fiber.Ctx) error {
+	c.Set("Access-Control-Allow-Origin", "*")
+
+	slug := strings.ToLower(c.Params("slug"))
+	stats, err := h.service.GetPublicStats(c.UserContext(), slug)
+	if err != nil {
+		log.Printf("Error loading public profile %s: %v", slug, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load creator stats",
+		})
+	}
+	if stats == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Creator not found",
+		})
+	}
+
+	maxAge := int(h.service.CacheTTL().Seconds())
+	if maxAge < 1 {
+		maxAge = 1
+	}
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d", maxAge, maxAge))
+
+	return c.JSON(stats)
+}
+
+// GetSettings returns the user's public profile settings
+func (h *Handlers) GetSettings(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	profile, err := h.repo.GetProfile(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error loading public profile for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load public profile",
+		})
+	}
+	if profile == nil {
+		return c.JSON(fiber.Map{
+			"profile": nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"profile": profile,
+		"path":    "/api/public/creators/" + profile.Slug,
+	})
+}
+
+// UpdateSettings claims a slug and turns the public page on or off
+func (h *Handlers) UpdateSettings(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req struct {
+		Slug    string `json:"slug"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	slug, err := NormalizeSlug(req.Slug)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	previous, err := h.repo.GetProfile(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error loading public profile for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update public profile",
+		})
+	}
+
+	profile := &Profile{
+		UserID:  user.ID,
+		Slug:    slug,
+		Enabled: req.Enabled,
+	}
+	if err := h.repo.SaveProfile(c.UserContext(), profile); err != nil {
+		if errors.Is(err, ErrSlugTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("Error saving public profile for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update public profile",
+		})
+	}
+
+	// Stop serving the old slug (and any cached 404 for the new one) right away
+	if previous != nil {
+		h.service.Evict(previous.Slug)
+	}
+	h.service.Evict(slug)
+
+	log.Printf("🌐 Public profile for user %s set to %s (enabled=%t)", user.ID, slug, profile.Enabled)
+	return c.JSON(fiber.Map{
+		"profile": profile,
+		"path":    "/api/public/creators/" + profile.Slug,
+	})
+}
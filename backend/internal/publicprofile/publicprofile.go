@@ -0,0 +1,95 @@
+// Package publicprofile serves opt-in public creator stats pages ("media kit"
+// data) addressed by a user-chosen slug.
+package publicprofile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+// slugPattern allows 3-32 lowercase letters, digits and inner hyphens
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,30}[a-z0-9]$`)
+
+// reservedSlugs could be confused with pages of the app itself
+var reservedSlugs = map[string]bool{
+	"admin": true, "api": true, "app": true, "creatorsync": true, "dashboard": true,
+	"help": true, "login": true, "settings": true, "signup": true, "support": true,
+}
+
+// Profile is a user's public page settings
+type Profile struct {
+	UserID    string    `json:"-" db:"user_id"`
+	Slug      string    `json:"slug" db:"slug"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Stats is the whitelisted summary shown on a public page. Only add fields here
+// that creators expect to be public.
+type Stats struct {
+	Slug            string           `json:"slug"`
+	DisplayName     string           `json:"display_name"`
+	ProfileImageURL string           `json:"profile_image_url"`
+	Followers       int              `json:"followers"`
+	TotalViews      int              `json:"total_views"`
+	FollowerGrowth  int              `json:"follower_growth"`
+	Sparkline       []SparklinePoint `json:"follower_sparkline"`
+	TopClips        []Clip           `json:"top_clips"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// SparklinePoint is one day of the follower sparkline
+type SparklinePoint struct {
+	Date      string `json:"date"`
+	Followers int    `json:"followers"`
+}
+
+// Clip is a public clip summary
+type Clip struct {
+	Title        string `json:"title"`
+	Views        int    `json:"views"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	URL          string `json:"url"`
+}
+
+// NormalizeSlug lowercases a slug and validates it
+func NormalizeSlug(slug string) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) || strings.Contains(slug, "--") {
+		return "", fmt.Errorf("slug must be 3-32 characters of lowercase letters, digits and single hyphens")
+	}
+	if reservedSlugs[slug] {
+		return "", fmt.Errorf("slug %q is reserved", slug)
+	}
+	return slug, nil
+}
+
+// buildSparkline turns daily snapshots (any order) into an oldest-first series
+// and returns it with the follower change across the series
+func buildSparkline(history []analytics.ChannelAnalytics) ([]SparklinePoint, int) {
+	sorted := make([]analytics.ChannelAnalytics, len(history))
+	copy(sorted, history)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	points := make([]SparklinePoint, 0, len(sorted))
+	for _, day := range sorted {
+		points = append(points, SparklinePoint{
+			Date:      day.Date.Format("2006-01-02"),
+			Followers: day.FollowersCount,
+		})
+	}
+
+	growth := 0
+	if len(points) > 1 {
+		growth = points[len(points)-1].Followers - points[0].Followers
+	}
+	return points, growth
+}
@@ -0,0 +1,43 @@
+package publicprofile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+func TestNormalizeSlug(t *testing.T) {
+	slug, err := NormalizeSlug("  Baldy-Builds ")
+	require.NoError(t, err)
+	assert.Equal(t, "baldy-builds", slug)
+
+	for _, invalid := range []string{"", "ab", "-leading", "trailing-", "double--hyphen", "under_score", "admin", "a23456789012345678901234567890123"} {
+		_, err := NormalizeSlug(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBuildSparkline(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	history := []analytics.ChannelAnalytics{
+		{Date: day.AddDate(0, 0, 2), FollowersCount: 130},
+		{Date: day, FollowersCount: 100},
+		{Date: day.AddDate(0, 0, 1), FollowersCount: 110},
+	}
+
+	points, growth := buildSparkline(history)
+	assert.Equal(t, []SparklinePoint{
+		{Date: "2025-06-01", Followers: 100},
+		{Date: "2025-06-02", Followers: 110},
+		{Date: "2025-06-03", Followers: 130},
+	}, points)
+	assert.Equal(t, 30, growth)
+
+	points, growth = buildSparkline(nil)
+	assert.Empty(t, points)
+	assert.Zero(t, growth)
+}
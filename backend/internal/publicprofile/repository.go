@@ -0,0 +1,81 @@
+package publicprofile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrSlugTaken is returned when another user already uses the slug
+var ErrSlugTaken = errors.New("slug is already taken")
+
+type Repository interface {
+	GetProfile(ctx context.Context, userID string) (*Profile, error)
+	GetEnabledProfileBySlug(ctx context.Context, slug string) (*Profile, error)
+	SaveProfile(ctx context.Context, profile *Profile) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+func (r *repository) GetProfile(ctx context.Context, userID string) (*Profile, error) {
+	query := `
+		SELECT user_id, slug, enabled, created_at, updated_at
+		FROM public_profiles 
+		WHERE user_id = $1
+	`
+
+	var profile Profile
+	err := r.db.GetContext(ctx, &profile, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &profile, err
+}
+
+func (r *repository) GetEnabledProfileBySlug(ctx context.Context, slug string) (*Profile, error) {
+	query := `
+		SELECT user_id, slug, enabled, created_at, updated_at
+		FROM public_profiles 
+		WHERE slug = $1 AND enabled = TRUE
+	`
+
+	var profile Profile
+	err := r.db.GetContext(ctx, &profile, query, slug)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &profile, err
+}
+
+func (r *repository) SaveProfile(ctx context.Context, profile *Profile) error {
+	query := `
+		INSERT INTO public_profiles (user_id, slug, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) 
+		DO UPDATE SET 
+			slug = EXCLUDED.slug,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query, profile.UserID, profile.Slug, profile.Enabled).
+		Scan(&profile.CreatedAt, &profile.UpdatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrSlugTaken
+	}
+	return err
+}
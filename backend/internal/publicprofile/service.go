@@ -0,0 +1,127 @@
+package publicprofile
+
+import (
+	"context"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// maxCacheEntries triggers pruning of expired entries
+const maxCacheEntries = 10000
+
+// Service builds public summaries from stored analytics only, so public traffic
+// never reaches Twitch. Summaries (and misses) are cached for the configured TTL.
+type Service struct {
+	repo          Repository
+	analyticsRepo analytics.Repository
+	cfg           config.PublicProfileConfig
+	cache         *cache.Memory[cachedStats]
+}
+
+type cachedStats struct {
+	userID string // empty for unknown or disabled slugs
+	stats  *Stats
+}
+
+func NewService(repo Repository, analyticsRepo analytics.Repository) *Service {
+	cfg := config.PublicProfile()
+	return &Service{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+		cfg:           cfg,
+		cache:         cache.NewMemory[cachedStats](cfg.CacheTTL, maxCacheEntries),
+	}
+}
+
+// GetPublicStats returns the summary for an enabled slug, or nil if there is none
+func (s *Service) GetPublicStats(ctx context.Context, slug string) (*Stats, error) {
+	if cached, ok := s.cache.GetContext(ctx, slug); ok {
+		return cached.stats, nil
+	}
+
+	profile, err := s.repo.GetEnabledProfileBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached cachedStats
+	if profile != nil {
+		cached.userID = profile.UserID
+		cached.stats, err = s.loadStats(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.cache.Set(slug, cached)
+	return cached.stats, nil
+}
+
+// Evict drops a slug from the cache, e.g. after the profile was renamed or disabled
+func (s *Service) Evict(slug string) {
+	s.cache.Delete(slug)
+}
+
+// EvictUser drops the cached summary of a user's profile, e.g. after their data was collected
+func (s *Service) EvictUser(userID string) {
+	s.cache.DeleteFunc(func(_ string, cached cachedStats) bool {
+		return cached.userID == userID
+	})
+}
+
+// CacheTTL is how long public summaries are cached
+func (s *Service) CacheTTL() time.Duration {
+	return s.cfg.CacheTTL
+}
+
+func (s *Service) loadStats(ctx context.Context, profile *Profile) (*Stats, error) {
+	stats := &Stats{
+		Slug:      profile.Slug,
+		Sparkline: []SparklinePoint{},
+		TopClips:  []Clip{},
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	user, err := s.analyticsRepo.GetUserByClerkID(ctx, profile.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		stats.DisplayName = user.DisplayName
+		stats.ProfileImageURL = user.ProfileImageURL
+	}
+
+	latest, err := s.analyticsRepo.GetLatestChannelAnalytics(ctx, profile.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		stats.Followers = latest.FollowersCount
+		stats.TotalViews = latest.TotalViews
+		stats.UpdatedAt = latest.CreatedAt
+	}
+
+	history, err := s.analyticsRepo.GetChannelAnalytics(ctx, profile.UserID, s.cfg.SparklineDays)
+	if err != nil {
+		return nil, err
+	}
+	stats.Sparkline, stats.FollowerGrowth = buildSparkline(history)
+
+	clips, err := s.analyticsRepo.GetTopVideos(ctx, profile.UserID, "clip", s.cfg.TopClips)
+	if err != nil {
+		return nil, err
+	}
+	for _, clip := range clips {
+		stats.TopClips = append(stats.TopClips, Clip{
+			Title:        clip.Title,
+			Views:        clip.ViewCount,
+			ThumbnailURL: clip.ThumbnailURL,
+			URL:          "https://clips.twitch.tv/" + clip.VideoID,
+		})
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,392 @@
+package publishing
+
+import (
+	"context"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/templates"
+	"github.com/gofiber/fiber/v2"
+)
+
+// jobListLimit bounds how many jobs are listed
+const jobListLimit = 50
+
+// ContentTemplates are the user's saved templates, see templates.Repository
+type ContentTemplates interface {
+	GetTemplate(ctx context.Context, userID string, id int) (*templates.Template, error)
+	ClipVars(ctx context.Context, userID, clipID string) (*templates.Vars, error)
+}
+
+type Handlers struct {
+	repo      Repository
+	clips     Clips
+	templates ContentTemplates
+	cfg       config.PublishingConfig
+}
+
+func NewHandlers(repo Repository, clips Clips, contentTemplates ContentTemplates) *Handlers {
+	return &Handlers{
+		repo:      repo,
+		clips:     clips,
+		templates: contentTemplates,
+		cfg:       config.Publishing(),
+	}
+}
+
+// RegisterRoutes registers publishing routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	publishing := router.Group("/publishing")
+	publishing.Get("/template", h.GetTemplate)
+	publishing.Put("/template", h.SaveTemplate)
+
+	publishing.Get("/jobs", h.ListJobs)
+	publishing.Post("/jobs", h.CreateJob)
+	publishing.Get("/jobs/:id", h.GetJob)
+	publishing.Post("/jobs/:id/retry", h.RetryJob)
+}
+
+// GetTemplate returns the user's upload template and the YouTube channel
+// clips are published to, which is null until YouTube is connected
+func (h *Handlers) GetTemplate(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	template, err := h.loadTemplate(c, user.ID)
+	if template == nil {
+		return err
+	}
+
+	token, err := h.repo.GetYouTubeToken(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error loading YouTube connection for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load publishing template",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"template": template,
+		"youtube":  token,
+	})
+}
+
+// SaveTemplate replaces the user's upload template
+func (h *Handlers) SaveTemplate(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var template Template
+	if err := c.BodyParser(&template); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	template.UserID = user.ID
+	if err := template.Normalize(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.repo.SaveTemplate(c.UserContext(), &template); err != nil {
+		log.Printf("Error saving publishing template for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save publishing template",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"template": template,
+	})
+}
+
+// jobRequest is the body for queueing a clip. A content template replaces the
+// user's upload template's title and description and adds its tags. Title,
+// description and privacy override both.
+type jobRequest struct {
+	ClipID      string `json:"clip_id"`
+	TemplateID  int    `json:"template_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Privacy     string `json:"privacy"`
+}
+
+// CreateJob queues one of the user's collected clips for upload to YouTube
+func (h *Handlers) CreateJob(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req jobRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.ClipID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "clip_id is required",
+		})
+	}
+	if req.Privacy != "" && !validPrivacy(req.Privacy) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "privacy must be private, unlisted or public",
+		})
+	}
+
+	ctx := c.UserContext()
+	token, err := h.repo.GetYouTubeToken(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error loading YouTube connection for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue clip",
+		})
+	}
+	if token == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "YouTube is not connected",
+		})
+	}
+
+	video, err := h.clips.GetVideo(ctx, user.ID, req.ClipID)
+	if err != nil {
+		log.Printf("Error loading clip %s for user %s: %v", req.ClipID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue clip",
+		})
+	}
+	if video == nil || video.VideoType != "clip" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Clip not found",
+		})
+	}
+
+	unfinished, err := h.repo.CountUnfinishedJobs(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error counting publishing jobs for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue clip",
+		})
+	}
+	if unfinished >= h.cfg.MaxQueuedJobs {
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "Too many clips are waiting to be published, try again later",
+		})
+	}
+
+	template, err := h.loadTemplate(c, user.ID)
+	if template == nil {
+		return err
+	}
+	channel := ""
+	if owner, err := h.clips.GetUserByClerkID(ctx, user.ID); err == nil && owner != nil {
+		channel = owner.Username
+	}
+	clip := Clip{
+		ID:          video.VideoID,
+		Title:       video.Title,
+		Channel:     channel,
+		Views:       video.ViewCount,
+		PublishedAt: video.PublishedAt,
+	}
+
+	job := &Job{
+		UserID:      user.ID,
+		ClipID:      video.VideoID,
+		Target:      TargetYouTube,
+		Status:      StatusQueued,
+		Title:       uploadTitle(Render(template.Title, clip)),
+		Description: uploadDescription(Render(template.Description, clip)),
+		Privacy:     template.Privacy,
+	}
+	if req.TemplateID != 0 {
+		rendered, err := h.renderContentTemplate(c, user.ID, req.TemplateID, clip)
+		if rendered == nil {
+			return err
+		}
+		job.Title = uploadTitle(rendered.Title)
+		job.Description = uploadDescription(rendered.Description)
+		job.Tags = rendered.Tags
+	}
+	if req.Title != "" {
+		job.Title = uploadTitle(req.Title)
+	}
+	if req.Description != "" {
+		job.Description = uploadDescription(req.Description)
+	}
+	if req.Privacy != "" {
+		job.Privacy = req.Privacy
+	}
+
+	if err := h.repo.CreateJob(ctx, job); err != nil {
+		log.Printf("Error queueing clip %s for user %s: %v", req.ClipID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue clip",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"job": job,
+	})
+}
+
+// ListJobs returns the user's most recent publishing jobs
+func (h *Handlers) ListJobs(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	jobs, err := h.repo.ListJobs(c.UserContext(), user.ID, jobListLimit)
+	if err != nil {
+		log.Printf("Error listing publishing jobs for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list publishing jobs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"jobs": jobs,
+	})
+}
+
+// GetJob returns one of the user's publishing jobs, for polling its status
+func (h *Handlers) GetJob(c *fiber.Ctx) error {
+	job, err := h.loadJob(c)
+	if job == nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"job": job,
+	})
+}
+
+// RetryJob queues a failed job again
+func (h *Handlers) RetryJob(c *fiber.Ctx) error {
+	job, err := h.loadJob(c)
+	if job == nil {
+		return err
+	}
+
+	retried, err := h.repo.RetryJob(c.UserContext(), job.UserID, job.ID)
+	if err != nil {
+		log.Printf("Error retrying publishing job %d for user %s: %v", job.ID, job.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retry publishing job",
+		})
+	}
+	if !retried {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only failed jobs can be retried",
+		})
+	}
+
+	queued, err := h.repo.GetJob(c.UserContext(), job.UserID, job.ID)
+	if err != nil || queued == nil {
+		log.Printf("Error reloading publishing job %d for user %s: %v", job.ID, job.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retry publishing job",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"job": queued,
+	})
+}
+
+// loadTemplate returns the user's template, or the default one if they
+// haven't saved one
+func (h *Handlers) loadTemplate(c *fiber.Ctx, userID string) (*Template, error) {
+	template, err := h.repo.GetTemplate(c.UserContext(), userID)
+	if err != nil {
+		log.Printf("Error loading publishing template for user %s: %v", userID, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load publishing template",
+		})
+	}
+	if template == nil {
+		template = DefaultTemplate(userID)
+	}
+	return template, nil
+}
+
+// renderContentTemplate fills one of the user's content templates in for the
+// clip
+func (h *Handlers) renderContentTemplate(c *fiber.Ctx, userID string, templateID int, clip Clip) (*templates.Rendered, error) {
+	ctx := c.UserContext()
+	template, err := h.templates.GetTemplate(ctx, userID, templateID)
+	if err != nil {
+		log.Printf("Error loading template %d for user %s: %v", templateID, userID, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue clip",
+		})
+	}
+	if template == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Template not found",
+		})
+	}
+
+	vars, err := h.templates.ClipVars(ctx, userID, clip.ID)
+	if err != nil {
+		log.Printf("Error loading template variables of clip %s for user %s: %v", clip.ID, userID, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue clip",
+		})
+	}
+	if vars == nil {
+		vars = &templates.Vars{ClipID: clip.ID, ClipTitle: clip.Title, Channel: clip.Channel, StreamDate: clip.PublishedAt}
+	}
+	if vars.Channel == "" {
+		vars.Channel = clip.Channel
+	}
+
+	rendered := template.Render(*vars)
+	return &rendered, nil
+}
+
+func (h *Handlers) loadJob(c *fiber.Ctx) (*Job, error) {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid job ID",
+		})
+	}
+
+	job, err := h.repo.GetJob(c.UserContext(), user.ID, id)
+	if err != nil {
+		log.Printf("Error loading publishing job %d for user %s: %v", id, user.ID, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load publishing job",
+		})
+	}
+	if job == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Publishing job not found",
+		})
+	}
+	return job, nil
+}
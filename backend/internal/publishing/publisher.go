@@ -0,0 +1,217 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
+	"github.com/baldybuilds/creatorsync/internal/media"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/youtube"
+)
+
+// ClipDownloader is the part of the Twitch client used to download clips
+type ClipDownloader interface {
+	GetClipDownload(ctx context.Context, userAccessToken, editorID, broadcasterID, clipID string) (*twitch.ClipDownload, error)
+}
+
+// TwitchTokens hands out the Twitch access tokens of users
+type TwitchTokens interface {
+	GetValidToken(ctx context.Context, userID string) (string, error)
+}
+
+// Clips looks up users and their collected clips
+type Clips interface {
+	GetUserByClerkID(ctx context.Context, clerkUserID string) (*analytics.User, error)
+	GetVideo(ctx context.Context, userID, videoID string) (*analytics.VideoAnalytics, error)
+}
+
+// permanentError marks a failure that trying again won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Publisher is the background worker that downloads queued clips from Twitch
+// and uploads them to YouTube. Several replicas can run it, each job is
+// claimed by one of them.
+type Publisher struct {
+	repo       Repository
+	clips      Clips
+	downloader ClipDownloader
+	twitch     TwitchTokens
+	youtube    YouTubeAPI
+	tokens     *YouTubeTokens
+	httpClient *http.Client
+	cfg        config.PublishingConfig
+}
+
+func NewPublisher(repo Repository, clips Clips, downloader ClipDownloader, twitchTokens TwitchTokens, youtubeClient YouTubeAPI) *Publisher {
+	return &Publisher{
+		repo:       repo,
+		clips:      clips,
+		downloader: downloader,
+		twitch:     twitchTokens,
+		youtube:    youtubeClient,
+		tokens:     NewYouTubeTokens(repo, youtubeClient),
+		httpClient: httpclient.New("twitch_clips"),
+		cfg:        config.Publishing(),
+	}
+}
+
+// Run processes queued jobs until ctx ends
+func (p *Publisher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		p.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain processes jobs until none are left
+func (p *Publisher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := p.processNext(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to process publishing job: %v", err)
+			return
+		}
+		if !processed {
+			return
+		}
+	}
+}
+
+// processNext claims and processes the next job. It returns false when no job
+// is queued.
+func (p *Publisher) processNext(ctx context.Context) (bool, error) {
+	// Jobs claimed before this are past their timeout, their replica is gone
+	job, err := p.repo.ClaimNextJob(ctx, time.Now().Add(-2*p.cfg.JobTimeout))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	// Only jobs whose last attempt never finished get here
+	if job.Attempts > p.cfg.MaxAttempts {
+		log.Printf("Publishing job %d gave up after %d attempts", job.ID, p.cfg.MaxAttempts)
+		return true, p.repo.FailJob(ctx, job.ID, "publishing timed out", false)
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, p.cfg.JobTimeout)
+	defer cancel()
+
+	video, err := p.publish(jobCtx, job)
+	if err != nil {
+		requeue := !isPermanent(err) && job.Attempts < p.cfg.MaxAttempts
+		log.Printf("Publishing job %d of user %s failed (attempt %d, retry=%t): %v", job.ID, job.UserID, job.Attempts, requeue, err)
+		return true, p.repo.FailJob(ctx, job.ID, err.Error(), requeue)
+	}
+
+	log.Printf("✅ Published clip %s of user %s to %s", job.ClipID, job.UserID, video.URL())
+	return true, p.repo.CompleteJob(ctx, job.ID, video.ID, video.URL())
+}
+
+// publish downloads the job's clip and uploads it to YouTube
+func (p *Publisher) publish(ctx context.Context, job *Job) (*youtube.Video, error) {
+	user, err := p.clips.GetUserByClerkID(ctx, job.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil || user.TwitchUserID == "" {
+		return nil, &permanentError{errors.New("twitch is not connected")}
+	}
+
+	twitchToken, err := p.twitch.GetValidToken(ctx, job.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Twitch token: %w", err)
+	}
+	download, err := p.downloader.GetClipDownload(ctx, twitchToken, user.TwitchUserID, user.TwitchUserID, job.ClipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clip download: %w", err)
+	}
+
+	// Vertical clips become Shorts, others are uploaded as regular videos
+	mediaURL := download.PortraitURL
+	if mediaURL == "" {
+		mediaURL = download.LandscapeURL
+	}
+	if mediaURL == "" {
+		return nil, &permanentError{errors.New("clip has no downloadable video")}
+	}
+
+	file, size, err := media.DownloadToTempFile(ctx, p.httpClient, mediaURL, p.cfg.MaxClipBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download clip: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	accessToken, err := p.tokens.ValidToken(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.repo.SetJobStatus(ctx, job.ID, StatusUploading); err != nil {
+		return nil, fmt.Errorf("failed to update job status: %w", err)
+	}
+	video, err := p.youtube.UploadVideo(ctx, accessToken, youtube.VideoMetadata{
+		Title:       job.Title,
+		Description: job.Description,
+		Tags:        jobTags(job, user.Username),
+		Privacy:     job.Privacy,
+	}, file, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload clip: %w", err)
+	}
+	return video, nil
+}
+
+// isPermanent reports whether trying again can't fix err, such as revoked
+// access or a clip that no longer exists
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) || errors.Is(err, media.ErrTooLarge) ||
+		errors.Is(err, ErrYouTubeAuthRequired) || errors.Is(err, analytics.ErrTwitchAuthRequired) {
+		return true
+	}
+
+	status := 0
+	var youtubeErr *youtube.APIError
+	var twitchErr *twitch.APIError
+	switch {
+	case errors.As(err, &youtubeErr):
+		status = youtubeErr.StatusCode
+	case errors.As(err, &twitchErr):
+		status = twitchErr.StatusCode
+	}
+	return status >= http.StatusBadRequest && status < http.StatusInternalServerError &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// jobTags are the job's rendered tags, or the channel's defaults for jobs
+// queued without a content template
+func jobTags(job *Job, channel string) []string {
+	if len(job.Tags) > 0 {
+		return uploadTags(job.Tags)
+	}
+	return uploadTags([]string{"Twitch", channel})
+}
@@ -0,0 +1,194 @@
+// Package publishing uploads Twitch clips to YouTube. Users queue a clip
+// through the API and a background worker downloads it from Twitch and uploads
+// it to their connected YouTube channel, with a title and description rendered
+// from their template.
+package publishing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/baldybuilds/creatorsync/internal/templates"
+	"github.com/baldybuilds/creatorsync/internal/youtube"
+)
+
+// Job statuses. Queued jobs are picked up by the worker, failed jobs can be
+// retried.
+const (
+	StatusQueued      = "queued"
+	StatusDownloading = "downloading"
+	StatusUploading   = "uploading"
+	StatusCompleted   = "completed"
+	StatusFailed      = "failed"
+)
+
+// TargetYouTube is the only place clips are published to so far
+const TargetYouTube = "youtube"
+
+// YouTube limits on video metadata
+const (
+	maxTitleLength       = 100  // characters
+	maxDescriptionLength = 5000 // bytes
+	maxTagsLength        = 500  // characters, counting a comma between tags
+)
+
+// Job is a clip queued for publishing
+type Job struct {
+	ID          int    `json:"id" db:"id"`
+	UserID      string `json:"-" db:"user_id"`
+	ClipID      string `json:"clip_id" db:"clip_id"`
+	Target      string `json:"target" db:"target"`
+	Status      string `json:"status" db:"status"`
+	Title       string `json:"title" db:"title"`
+	Description string `json:"description" db:"description"`
+	Privacy     string `json:"privacy" db:"privacy"`
+	// Tags are rendered from a content template, the defaults are used without one
+	Tags     templates.TagList `json:"tags" db:"tags"`
+	Attempts int               `json:"attempts" db:"attempts"`
+	// ErrorMessage is why the last attempt failed
+	ErrorMessage string `json:"error_message,omitempty" db:"error_message"`
+	// ExternalID and ExternalURL are the published video's
+	ExternalID  string     `json:"external_id,omitempty" db:"external_id"`
+	ExternalURL string     `json:"external_url,omitempty" db:"external_url"`
+	StartedAt   *time.Time `json:"started_at" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Finished reports whether the worker is done with the job
+func (j *Job) Finished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed
+}
+
+// Template is how a user's uploads are titled and described. The texts may use
+// the placeholders {title}, {channel}, {url}, {views} and {date}.
+type Template struct {
+	UserID      string     `json:"-" db:"user_id"`
+	Title       string     `json:"title" db:"title_template"`
+	Description string     `json:"description" db:"description_template"`
+	Privacy     string     `json:"privacy" db:"privacy"`
+	UpdatedAt   *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultTemplate is used until the user saves their own. Uploads are private
+// so nothing goes public without the creator's review.
+func DefaultTemplate(userID string) *Template {
+	return &Template{
+		UserID:      userID,
+		Title:       "{title} #Shorts",
+		Description: "Clipped live on twitch.tv/{channel}\n\n{url}",
+		Privacy:     youtube.PrivacyPrivate,
+	}
+}
+
+// Normalize trims and validates the template
+func (t *Template) Normalize() error {
+	t.Title = strings.TrimSpace(t.Title)
+	t.Description = strings.TrimSpace(t.Description)
+	if t.Title == "" || utf8.RuneCountInString(t.Title) > maxTitleLength {
+		return fmt.Errorf("title must be 1-%d characters", maxTitleLength)
+	}
+	if len(t.Description) > maxDescriptionLength {
+		return fmt.Errorf("description must be at most %d bytes", maxDescriptionLength)
+	}
+	if t.Privacy == "" {
+		t.Privacy = youtube.PrivacyPrivate
+	}
+	if !validPrivacy(t.Privacy) {
+		return fmt.Errorf("privacy must be private, unlisted or public")
+	}
+	return nil
+}
+
+func validPrivacy(privacy string) bool {
+	switch privacy {
+	case youtube.PrivacyPrivate, youtube.PrivacyUnlisted, youtube.PrivacyPublic:
+		return true
+	}
+	return false
+}
+
+// Clip is what a template can refer to
+type Clip struct {
+	ID          string
+	Title       string
+	Channel     string
+	Views       int
+	PublishedAt *time.Time
+}
+
+// URL is the clip's page on Twitch
+func (c Clip) URL() string {
+	return "https://clips.twitch.tv/" + c.ID
+}
+
+// Render fills in a template text for the clip
+func Render(text string, clip Clip) string {
+	date := ""
+	if clip.PublishedAt != nil {
+		date = clip.PublishedAt.UTC().Format("2006-01-02")
+	}
+	return strings.NewReplacer(
+		"{title}", clip.Title,
+		"{channel}", clip.Channel,
+		"{url}", clip.URL(),
+		"{views}", strconv.Itoa(clip.Views),
+		"{date}", date,
+	).Replace(text)
+}
+
+// uploadTitle makes a rendered title acceptable to YouTube, which rejects
+// angle brackets and titles over 100 characters
+func uploadTitle(title string) string {
+	title = strings.TrimSpace(strings.NewReplacer("<", "", ">", "").Replace(title))
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = strings.TrimSpace(string([]rune(title)[:maxTitleLength]))
+	}
+	if title == "" {
+		return "Twitch clip"
+	}
+	return title
+}
+
+// uploadTags keeps the tags that fit YouTube's limit, in order. Tags with
+// spaces count their quotes too.
+func uploadTags(tags []string) []string {
+	kept := []string{}
+	length := 0
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.NewReplacer("<", "", ">", "", ",", "").Replace(tag))
+		if tag == "" {
+			continue
+		}
+		size := utf8.RuneCountInString(tag)
+		if strings.Contains(tag, " ") {
+			size += 2
+		}
+		if len(kept) > 0 {
+			size++
+		}
+		if length+size > maxTagsLength {
+			break
+		}
+		length += size
+		kept = append(kept, tag)
+	}
+	return kept
+}
+
+// uploadDescription trims a rendered description to YouTube's limit
+func uploadDescription(description string) string {
+	description = strings.NewReplacer("<", "", ">", "").Replace(description)
+	if len(description) > maxDescriptionLength {
+		description = description[:maxDescriptionLength]
+		// Don't leave half a character at the cut
+		for !utf8.ValidString(description) {
+			description = description[:len(description)-1]
+		}
+	}
+	return strings.TrimSpace(description)
+}
@@ -0,0 +1,245 @@
+package publishing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/youtube"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	published := time.Date(2025, 3, 14, 22, 30, 0, 0, time.UTC)
+	clip := Clip{ID: "FunnyClip-abc", Title: "Clutch 1v4", Channel: "baldy", Views: 1234, PublishedAt: &published}
+
+	template := DefaultTemplate("user_1")
+	assert.Equal(t, "Clutch 1v4 #Shorts", Render(template.Title, clip))
+	assert.Equal(t, "Clipped live on twitch.tv/baldy\n\nhttps://clips.twitch.tv/FunnyClip-abc", Render(template.Description, clip))
+	assert.Equal(t, "1234 views on 2025-03-14", Render("{views} views on {date}", clip))
+
+	// YouTube rejects angle brackets and long titles
+	assert.Equal(t, "a b", uploadTitle("<a> b"))
+	assert.Equal(t, strings.Repeat("é", maxTitleLength), uploadTitle(strings.Repeat("é", maxTitleLength+5)))
+	assert.Equal(t, "Twitch clip", uploadTitle(" <> "))
+
+	// Descriptions are cut at the byte limit without splitting a character
+	description := uploadDescription(strings.Repeat("é", maxDescriptionLength))
+	assert.Len(t, description, maxDescriptionLength)
+	assert.Equal(t, "x", uploadDescription(" <x> "))
+
+	// Tags stop at YouTube's 500 character limit, quotes and commas included
+	tags := uploadTags([]string{"Twitch", "", "a,b", strings.Repeat("x", 490), "more"})
+	assert.Equal(t, []string{"Twitch", "ab", strings.Repeat("x", 490)}, tags)
+	assert.Equal(t, []string{"Twitch", "baldy"}, jobTags(&Job{}, "baldy"))
+	assert.Equal(t, []string{"Elden Ring"}, jobTags(&Job{Tags: []string{"Elden Ring"}}, "baldy"))
+}
+
+func TestTemplateNormalize(t *testing.T) {
+	template := &Template{Title: "  {title}  ", Description: " {url} "}
+	require.NoError(t, template.Normalize())
+	assert.Equal(t, "{title}", template.Title)
+	assert.Equal(t, "{url}", template.Description)
+	assert.Equal(t, youtube.PrivacyPrivate, template.Privacy)
+
+	assert.Error(t, (&Template{Title: " "}).Normalize())
+	assert.Error(t, (&Template{Title: "{title}", Privacy: "friends"}).Normalize())
+	assert.Error(t, (&Template{Title: "{title}", Description: strings.Repeat("x", maxDescriptionLength+1)}).Normalize())
+}
+
+// fakeRepository keeps one job and the user's YouTube token
+type fakeRepository struct {
+	Repository
+	job      *Job
+	token    *YouTubeToken
+	statuses []string
+	requeue  bool
+}
+
+func (f *fakeRepository) SaveYouTubeToken(ctx context.Context, token *YouTubeToken) error {
+	f.token = token
+	return nil
+}
+
+func (f *fakeRepository) GetYouTubeToken(ctx context.Context, userID string) (*YouTubeToken, error) {
+	return f.token, nil
+}
+
+func (f *fakeRepository) ClaimNextJob(ctx context.Context, staleBefore time.Time) (*Job, error) {
+	if f.job == nil || f.job.Status != StatusQueued {
+		return nil, nil
+	}
+	f.job.Status = StatusDownloading
+	f.job.Attempts++
+	f.statuses = append(f.statuses, StatusDownloading)
+	job := *f.job
+	return &job, nil
+}
+
+func (f *fakeRepository) SetJobStatus(ctx context.Context, id int, status string) error {
+	f.job.Status = status
+	f.statuses = append(f.statuses, status)
+	return nil
+}
+
+func (f *fakeRepository) CompleteJob(ctx context.Context, id int, externalID, externalURL string) error {
+	f.job.Status = StatusCompleted
+	f.job.ExternalID = externalID
+	f.job.ExternalURL = externalURL
+	f.statuses = append(f.statuses, StatusCompleted)
+	return nil
+}
+
+func (f *fakeRepository) FailJob(ctx context.Context, id int, message string, requeue bool) error {
+	f.job.ErrorMessage = message
+	f.requeue = requeue
+	f.job.Status = StatusFailed
+	if requeue {
+		f.job.Status = StatusQueued
+	}
+	f.statuses = append(f.statuses, f.job.Status)
+	return nil
+}
+
+type fakeClips struct{}
+
+func (fakeClips) GetUserByClerkID(ctx context.Context, clerkUserID string) (*analytics.User, error) {
+	return &analytics.User{ClerkUserID: clerkUserID, TwitchUserID: "1234", Username: "baldy"}, nil
+}
+
+func (fakeClips) GetVideo(ctx context.Context, userID, videoID string) (*analytics.VideoAnalytics, error) {
+	return nil, nil
+}
+
+type fakeTwitch struct {
+	download *twitch.ClipDownload
+}
+
+func (f *fakeTwitch) GetValidToken(ctx context.Context, userID string) (string, error) {
+	return "twitch-token", nil
+}
+
+func (f *fakeTwitch) GetClipDownload(ctx context.Context, userAccessToken, editorID, broadcasterID, clipID string) (*twitch.ClipDownload, error) {
+	return f.download, nil
+}
+
+type fakeYouTube struct {
+	err      error
+	metadata youtube.VideoMetadata
+	media    string
+	size     int64
+}
+
+func (f *fakeYouTube) RefreshToken(ctx context.Context, refreshToken string) (*youtube.OAuthToken, error) {
+	return &youtube.OAuthToken{AccessToken: "refreshed", ExpiresIn: 3600}, nil
+}
+
+func (f *fakeYouTube) UploadVideo(ctx context.Context, accessToken string, metadata youtube.VideoMetadata, media io.Reader, size int64) (*youtube.Video, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	body, err := io.ReadAll(media)
+	if err != nil {
+		return nil, err
+	}
+	f.metadata, f.media, f.size = metadata, string(body), size
+	return &youtube.Video{ID: "vid123"}, nil
+}
+
+func newTestPublisher(t *testing.T, yt *fakeYouTube) (*Publisher, *fakeRepository) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "publishing-test-key")
+
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "mp4:"+r.URL.Path)
+	}))
+	t.Cleanup(cdn.Close)
+
+	repo := &fakeRepository{job: &Job{
+		ID: 1, UserID: "user_1", ClipID: "FunnyClip-abc", Status: StatusQueued,
+		Title: "Clutch", Description: "desc", Privacy: youtube.PrivacyUnlisted,
+	}}
+	downloads := &fakeTwitch{download: &twitch.ClipDownload{
+		LandscapeURL: cdn.URL + "/landscape.mp4",
+		PortraitURL:  cdn.URL + "/portrait.mp4",
+	}}
+	publisher := &Publisher{
+		repo:       repo,
+		clips:      fakeClips{},
+		downloader: downloads,
+		twitch:     downloads,
+		youtube:    yt,
+		tokens:     NewYouTubeTokens(repo, yt),
+		httpClient: cdn.Client(),
+		cfg:        config.PublishingConfig{JobTimeout: time.Minute, MaxAttempts: 2, MaxClipBytes: 1 << 20},
+	}
+	require.NoError(t, publisher.tokens.StoreToken(context.Background(), "user_1",
+		&youtube.Channel{ID: "UC1", Title: "Baldy"}, &youtube.OAuthToken{AccessToken: "yt-token", ExpiresIn: 3600}))
+	return publisher, repo
+}
+
+func TestPublisherUploadsClip(t *testing.T) {
+	yt := &fakeYouTube{}
+	publisher, repo := newTestPublisher(t, yt)
+
+	processed, err := publisher.processNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	// The vertical version is uploaded when there is one
+	assert.Equal(t, "mp4:/portrait.mp4", yt.media)
+	assert.Equal(t, int64(len("mp4:/portrait.mp4")), yt.size)
+	assert.Equal(t, "Clutch", yt.metadata.Title)
+	assert.Equal(t, youtube.PrivacyUnlisted, yt.metadata.Privacy)
+
+	assert.Equal(t, []string{StatusDownloading, StatusUploading, StatusCompleted}, repo.statuses)
+	assert.Equal(t, "https://www.youtube.com/watch?v=vid123", repo.job.ExternalURL)
+
+	processed, err = publisher.processNext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestPublisherRetriesTransientFailures(t *testing.T) {
+	yt := &fakeYouTube{err: &youtube.APIError{StatusCode: http.StatusServiceUnavailable}}
+	publisher, repo := newTestPublisher(t, yt)
+
+	_, err := publisher.processNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, repo.requeue)
+	assert.Equal(t, StatusQueued, repo.job.Status)
+
+	// The last attempt fails the job for good
+	_, err = publisher.processNext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, repo.requeue)
+	assert.Equal(t, StatusFailed, repo.job.Status)
+	assert.Contains(t, repo.job.ErrorMessage, "503")
+}
+
+func TestPublisherFailsPermanentErrors(t *testing.T) {
+	yt := &fakeYouTube{err: &youtube.APIError{StatusCode: http.StatusForbidden, Body: "forbidden"}}
+	publisher, repo := newTestPublisher(t, yt)
+
+	_, err := publisher.processNext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, repo.requeue)
+	assert.Equal(t, StatusFailed, repo.job.Status)
+
+	// Clips over the size limit aren't downloaded again
+	repo.job.Status, repo.job.Attempts = StatusQueued, 0
+	publisher.cfg.MaxClipBytes = 4
+	yt.err = nil
+	_, err = publisher.processNext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, repo.requeue)
+	assert.Contains(t, repo.job.ErrorMessage, "too large")
+}
@@ -0,0 +1,229 @@
+package publishing
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	SaveYouTubeToken(ctx context.Context, token *YouTubeToken) error
+	GetYouTubeToken(ctx context.Context, userID string) (*YouTubeToken, error)
+	DeleteYouTubeToken(ctx context.Context, userID string) (bool, error)
+
+	GetTemplate(ctx context.Context, userID string) (*Template, error)
+	SaveTemplate(ctx context.Context, template *Template) error
+
+	CreateJob(ctx context.Context, job *Job) error
+	GetJob(ctx context.Context, userID string, id int) (*Job, error)
+	ListJobs(ctx context.Context, userID string, limit int) ([]Job, error)
+	CountUnfinishedJobs(ctx context.Context, userID string) (int, error)
+	RetryJob(ctx context.Context, userID string, id int) (bool, error)
+
+	// The worker's methods
+	ClaimNextJob(ctx context.Context, staleBefore time.Time) (*Job, error)
+	SetJobStatus(ctx context.Context, id int, status string) error
+	CompleteJob(ctx context.Context, id int, externalID, externalURL string) error
+	FailJob(ctx context.Context, id int, message string, requeue bool) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const jobColumns = `id, user_id, clip_id, target, status, title, description, privacy, tags, attempts,
+	error_message, external_id, external_url, started_at, completed_at, created_at, updated_at`
+
+func (r *repository) SaveYouTubeToken(ctx context.Context, token *YouTubeToken) error {
+	query := `
+		INSERT INTO user_youtube_tokens (
+			user_id, channel_id, channel_title, access_token, refresh_token, encryption_key_id, scopes, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			channel_id = EXCLUDED.channel_id,
+			channel_title = EXCLUDED.channel_title,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			encryption_key_id = EXCLUDED.encryption_key_id,
+			scopes = EXCLUDED.scopes,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.ChannelID, token.ChannelTitle, token.AccessToken, token.RefreshToken,
+		token.EncryptionKeyID, token.Scopes, token.ExpiresAt)
+	return err
+}
+
+func (r *repository) GetYouTubeToken(ctx context.Context, userID string) (*YouTubeToken, error) {
+	query := `
+		SELECT user_id, channel_id, channel_title, access_token, refresh_token,
+			   encryption_key_id, scopes, expires_at, created_at, updated_at
+		FROM user_youtube_tokens
+		WHERE user_id = $1
+	`
+
+	var token YouTubeToken
+	err := r.db.GetContext(ctx, &token, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &token, err
+}
+
+func (r *repository) DeleteYouTubeToken(ctx context.Context, userID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_youtube_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *repository) GetTemplate(ctx context.Context, userID string) (*Template, error) {
+	query := `
+		SELECT user_id, title_template, description_template, privacy, updated_at
+		FROM publish_templates
+		WHERE user_id = $1
+	`
+
+	var template Template
+	err := r.db.GetContext(ctx, &template, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &template, err
+}
+
+func (r *repository) SaveTemplate(ctx context.Context, template *Template) error {
+	query := `
+		INSERT INTO publish_templates (user_id, title_template, description_template, privacy)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			title_template = EXCLUDED.title_template,
+			description_template = EXCLUDED.description_template,
+			privacy = EXCLUDED.privacy,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, template.UserID, template.Title, template.Description,
+		template.Privacy).Scan(&template.UpdatedAt)
+}
+
+func (r *repository) CreateJob(ctx context.Context, job *Job) error {
+	query := `
+		INSERT INTO publish_jobs (user_id, clip_id, target, status, title, description, privacy, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, job.UserID, job.ClipID, job.Target, job.Status,
+		job.Title, job.Description, job.Privacy, job.Tags).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+}
+
+func (r *repository) GetJob(ctx context.Context, userID string, id int) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM publish_jobs WHERE id = $1 AND user_id = $2`
+
+	var job Job
+	err := r.db.GetContext(ctx, &job, query, id, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &job, err
+}
+
+// ListJobs returns the user's most recent jobs, newest first
+func (r *repository) ListJobs(ctx context.Context, userID string, limit int) ([]Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM publish_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	jobs := []Job{}
+	err := r.db.SelectContext(ctx, &jobs, query, userID, limit)
+	return jobs, err
+}
+
+func (r *repository) CountUnfinishedJobs(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM publish_jobs WHERE user_id = $1 AND status NOT IN ('completed', 'failed')`, userID)
+	return count, err
+}
+
+// RetryJob queues a failed job again with fresh attempts. It returns false
+// when the job doesn't exist or hasn't failed.
+func (r *repository) RetryJob(ctx context.Context, userID string, id int) (bool, error) {
+	query := `
+		UPDATE publish_jobs
+		SET status = 'queued', attempts = 0, error_message = '', started_at = NULL, completed_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = 'failed'
+	`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ClaimNextJob marks the oldest queued job as downloading and returns it,
+// skipping jobs other replicas are claiming. Jobs started before staleBefore
+// that never finished are claimed again. It returns nil when there is nothing
+// to do.
+func (r *repository) ClaimNextJob(ctx context.Context, staleBefore time.Time) (*Job, error) {
+	query := `
+		UPDATE publish_jobs
+		SET status = 'downloading', attempts = attempts + 1, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM publish_jobs
+			WHERE status = 'queued' OR (status IN ('downloading', 'uploading') AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	var job Job
+	err := r.db.GetContext(ctx, &job, query, staleBefore)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &job, err
+}
+
+func (r *repository) SetJobStatus(ctx context.Context, id int, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE publish_jobs SET status = $2, updated_at = NOW() WHERE id = $1`, id, status)
+	return err
+}
+
+func (r *repository) CompleteJob(ctx context.Context, id int, externalID, externalURL string) error {
+	query := `
+		UPDATE publish_jobs
+		SET status = 'completed', external_id = $2, external_url = $3, error_message = '',
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, externalID, externalURL)
+	return err
+}
+
+// FailJob records why an attempt failed and queues the job again, or fails it
+// for good unless requeue is set
+func (r *repository) FailJob(ctx context.Context, id int, message string, requeue bool) error {
+	query := `
+		UPDATE publish_jobs
+		SET status = CASE WHEN $3 THEN 'queued' ELSE 'failed' END, error_message = $2,
+			completed_at = CASE WHEN $3 THEN NULL ELSE NOW() END, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, message, requeue)
+	return err
+}
@@ -0,0 +1,136 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+	"github.com/baldybuilds/creatorsync/internal/youtube"
+)
+
+// tokenRefreshMargin is how long before expiry a stored token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+// ErrYouTubeAuthRequired means the user has to reconnect YouTube before clips
+// can be published
+var ErrYouTubeAuthRequired = errors.New("youtube authorization required")
+
+// YouTubeToken is a user's encrypted Google OAuth token and the channel it
+// uploads to
+type YouTubeToken struct {
+	UserID          string     `json:"user_id" db:"user_id"`
+	ChannelID       string     `json:"channel_id" db:"channel_id"`
+	ChannelTitle    string     `json:"channel_title" db:"channel_title"`
+	AccessToken     string     `json:"-" db:"access_token"`
+	RefreshToken    string     `json:"-" db:"refresh_token"`
+	EncryptionKeyID string     `json:"-" db:"encryption_key_id"`
+	Scopes          string     `json:"scopes" db:"scopes"`
+	ExpiresAt       *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// YouTubeAPI is the part of the YouTube client used to publish clips
+type YouTubeAPI interface {
+	RefreshToken(ctx context.Context, refreshToken string) (*youtube.OAuthToken, error)
+	UploadVideo(ctx context.Context, accessToken string, metadata youtube.VideoMetadata, media io.Reader, size int64) (*youtube.Video, error)
+}
+
+// YouTubeTokens stores YouTube connections and hands out valid access tokens
+type YouTubeTokens struct {
+	repo   Repository
+	client YouTubeAPI
+}
+
+func NewYouTubeTokens(repo Repository, client YouTubeAPI) *YouTubeTokens {
+	return &YouTubeTokens{
+		repo:   repo,
+		client: client,
+	}
+}
+
+// StoreToken encrypts and persists a token obtained from the Google OAuth flow
+func (t *YouTubeTokens) StoreToken(ctx context.Context, userID string, channel *youtube.Channel, token *youtube.OAuthToken) error {
+	keyring, err := tokencrypt.FromEnv()
+	if err != nil {
+		return err
+	}
+
+	accessToken, keyID, err := keyring.Encrypt(ctx, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+
+	refreshToken := ""
+	if token.RefreshToken != "" {
+		refreshToken, _, err = keyring.Encrypt(ctx, token.RefreshToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt refresh token: %w", err)
+		}
+	}
+
+	return t.repo.SaveYouTubeToken(ctx, &YouTubeToken{
+		UserID:          userID,
+		ChannelID:       channel.ID,
+		ChannelTitle:    channel.Title,
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		EncryptionKeyID: keyID,
+		Scopes:          token.Scope,
+		ExpiresAt:       token.ExpiresAt(),
+	})
+}
+
+// ValidToken returns the user's YouTube access token, refreshing it if it is
+// about to expire
+func (t *YouTubeTokens) ValidToken(ctx context.Context, userID string) (string, error) {
+	stored, err := t.repo.GetYouTubeToken(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load stored YouTube token: %w", err)
+	}
+	if stored == nil {
+		return "", fmt.Errorf("youtube is not connected: %w", ErrYouTubeAuthRequired)
+	}
+
+	keyring, err := tokencrypt.FromEnv()
+	if err != nil {
+		return "", err
+	}
+	if stored.ExpiresAt == nil || time.Until(*stored.ExpiresAt) > tokenRefreshMargin {
+		return keyring.Decrypt(ctx, stored.AccessToken, stored.EncryptionKeyID)
+	}
+
+	if stored.RefreshToken == "" {
+		return "", fmt.Errorf("stored YouTube token expired and has no refresh token: %w", ErrYouTubeAuthRequired)
+	}
+	refreshToken, err := keyring.Decrypt(ctx, stored.RefreshToken, stored.EncryptionKeyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+
+	log.Printf("Refreshing YouTube token for user %s", userID)
+	token, err := t.client.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		var apiErr *youtube.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			// Google rejected the refresh token itself, e.g. because access was revoked
+			return "", fmt.Errorf("failed to refresh YouTube token: %v: %w", err, ErrYouTubeAuthRequired)
+		}
+		return "", fmt.Errorf("failed to refresh YouTube token: %w", err)
+	}
+	// Google keeps the refresh token valid and doesn't send it again
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	channel := &youtube.Channel{ID: stored.ChannelID, Title: stored.ChannelTitle}
+	if err := t.StoreToken(ctx, userID, channel, token); err != nil {
+		return "", fmt.Errorf("failed to store refreshed YouTube token: %w", err)
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,301 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+// topItems bounds the top videos and games tables
+const topItems = 10
+
+// DataSource is the analytics data reports are built from
+type DataSource interface {
+	GetChannelAnalytics(ctx context.Context, userID string, days int) ([]analytics.ChannelAnalytics, error)
+	GetBroadcastTimes(ctx context.Context, userID string, since time.Time) (streams, uploads []time.Time, err error)
+	ListVideos(ctx context.Context, userID string, query analytics.VideoListQuery) ([]analytics.VideoAnalytics, int, error)
+	GetTopGames(ctx context.Context, userID string, limit int) ([]analytics.GameAnalytics, error)
+}
+
+// Result is the content of a generated report
+type Result struct {
+	Report      string             `json:"report"`
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Summary     map[string]Summary `json:"summary"`
+	Daily       []DailyRow         `json:"daily,omitempty"`
+	TopVideos   []VideoRow         `json:"top_videos,omitempty"`
+	TopGames    []GameRow          `json:"top_games,omitempty"`
+
+	metrics []string
+}
+
+// Summary is a metric's value at the start and end of the range
+type Summary struct {
+	Start  int `json:"start"`
+	End    int `json:"end"`
+	Change int `json:"change"`
+}
+
+// DailyRow is one day of the selected daily metrics; unselected ones are nil
+type DailyRow struct {
+	Date        string `json:"date"`
+	Followers   *int   `json:"followers,omitempty"`
+	Subscribers *int   `json:"subscribers,omitempty"`
+	Views       *int   `json:"views,omitempty"`
+	Streams     *int   `json:"streams,omitempty"`
+}
+
+type VideoRow struct {
+	Title       string `json:"title"`
+	Type        string `json:"type"`
+	Views       int    `json:"views"`
+	PublishedAt string `json:"published_at"`
+}
+
+type GameRow struct {
+	Name           string  `json:"name"`
+	Streams        int     `json:"streams"`
+	Hours          float64 `json:"hours"`
+	AverageViewers float64 `json:"average_viewers"`
+}
+
+// Build gathers the report's metrics over its date range, ending now
+func Build(ctx context.Context, source DataSource, report *Report, now time.Time) (*Result, error) {
+	now = now.UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -report.RangeDays)
+
+	result := &Result{
+		Report:      report.Name,
+		From:        from.Format("2006-01-02"),
+		To:          now.Format("2006-01-02"),
+		GeneratedAt: now,
+		Summary:     map[string]Summary{},
+		metrics:     report.Metrics,
+	}
+
+	days := map[string]*DailyRow{}
+	day := func(date string) *DailyRow {
+		if days[date] == nil {
+			days[date] = &DailyRow{Date: date}
+		}
+		return days[date]
+	}
+
+	if report.Has(MetricFollowers) || report.Has(MetricSubscribers) || report.Has(MetricViews) {
+		history, err := source.GetChannelAnalytics(ctx, report.UserID, report.RangeDays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get channel analytics: %w", err)
+		}
+
+		// Stored newest first
+		for i := len(history) - 1; i >= 0; i-- {
+			snapshot := history[i]
+			row := day(snapshot.Date.Format("2006-01-02"))
+			if report.Has(MetricFollowers) {
+				row.Followers = intPtr(snapshot.FollowersCount)
+			}
+			// Backfilled and imported days only know the followers
+			if snapshot.Synthetic() {
+				continue
+			}
+			if report.Has(MetricSubscribers) {
+				row.Subscribers = intPtr(snapshot.SubscriberCount)
+			}
+			if report.Has(MetricViews) {
+				row.Views = intPtr(snapshot.TotalViews)
+			}
+		}
+
+		summarize := func(metric string, start, end int) {
+			if report.Has(metric) {
+				result.Summary[metric] = Summary{Start: start, End: end, Change: end - start}
+			}
+		}
+		if len(history) > 0 {
+			first, last := history[len(history)-1], history[0]
+			summarize(MetricFollowers, first.FollowersCount, last.FollowersCount)
+		}
+		if collected := collectedSnapshots(history); len(collected) > 0 {
+			first, last := collected[len(collected)-1], collected[0]
+			summarize(MetricSubscribers, first.SubscriberCount, last.SubscriberCount)
+			summarize(MetricViews, first.TotalViews, last.TotalViews)
+		}
+	}
+
+	if report.Has(MetricStreams) {
+		streams, _, err := source.GetBroadcastTimes(ctx, report.UserID, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, at := range streams {
+			row := day(at.UTC().Format("2006-01-02"))
+			if row.Streams == nil {
+				row.Streams = intPtr(0)
+			}
+			*row.Streams++
+		}
+		result.Summary[MetricStreams] = Summary{End: len(streams), Change: len(streams)}
+	}
+
+	for _, row := range days {
+		result.Daily = append(result.Daily, *row)
+	}
+	sort.Slice(result.Daily, func(i, j int) bool { return result.Daily[i].Date < result.Daily[j].Date })
+
+	if report.Has(MetricTopVideos) {
+		query, err := analytics.VideoListQuery{Limit: topItems, Sort: "views", From: &from}.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		videos, _, err := source.ListVideos(ctx, report.UserID, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top videos: %w", err)
+		}
+		for _, video := range videos {
+			row := VideoRow{Title: video.Title, Type: video.VideoType, Views: video.ViewCount}
+			if video.PublishedAt != nil {
+				row.PublishedAt = video.PublishedAt.UTC().Format(time.RFC3339)
+			}
+			result.TopVideos = append(result.TopVideos, row)
+		}
+	}
+
+	if report.Has(MetricTopGames) {
+		games, err := source.GetTopGames(ctx, report.UserID, topItems)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top games: %w", err)
+		}
+		for _, game := range games {
+			result.TopGames = append(result.TopGames, GameRow{
+				Name:           game.GameName,
+				Streams:        game.TotalStreams,
+				Hours:          game.TotalHoursStreamed,
+				AverageViewers: game.AverageViewers,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// Render encodes the result in the format
+func Render(result *Result, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(result, "", "  ")
+	case FormatCSV:
+		return renderCSV(result)
+	default:
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// ContentType is the MIME type of a report format
+func ContentType(format string) string {
+	if format == FormatJSON {
+		return "application/json"
+	}
+	return "text/csv"
+}
+
+// renderCSV writes the daily metrics, top videos and top games as separate
+// tables divided by blank lines
+func renderCSV(result *Result) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	tables := 0
+	startTable := func() {
+		if tables > 0 {
+			w.Write(nil)
+		}
+		tables++
+	}
+
+	var daily []string
+	for _, metric := range result.metrics {
+		switch metric {
+		case MetricFollowers, MetricSubscribers, MetricViews, MetricStreams:
+			daily = append(daily, metric)
+		}
+	}
+	if len(daily) > 0 {
+		startTable()
+		w.Write(append([]string{"date"}, daily...))
+		for _, row := range result.Daily {
+			record := []string{row.Date}
+			for _, metric := range daily {
+				record = append(record, formatCount(row.value(metric)))
+			}
+			w.Write(record)
+		}
+	}
+
+	if len(result.TopVideos) > 0 {
+		startTable()
+		w.Write([]string{"video", "type", "views", "published_at"})
+		for _, video := range result.TopVideos {
+			w.Write([]string{video.Title, video.Type, strconv.Itoa(video.Views), video.PublishedAt})
+		}
+	}
+
+	if len(result.TopGames) > 0 {
+		startTable()
+		w.Write([]string{"game", "streams", "hours", "average_viewers"})
+		for _, game := range result.TopGames {
+			w.Write([]string{game.Name, strconv.Itoa(game.Streams),
+				strconv.FormatFloat(game.Hours, 'f', 1, 64), strconv.FormatFloat(game.AverageViewers, 'f', 1, 64)})
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (row DailyRow) value(metric string) *int {
+	switch metric {
+	case MetricFollowers:
+		return row.Followers
+	case MetricSubscribers:
+		return row.Subscribers
+	case MetricViews:
+		return row.Views
+	case MetricStreams:
+		if row.Streams == nil {
+			return intPtr(0)
+		}
+		return row.Streams
+	}
+	return nil
+}
+
+func formatCount(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// collectedSnapshots leaves out the backfilled and imported snapshots, whose
+// subscriber and view counts are unknown
+func collectedSnapshots(history []analytics.ChannelAnalytics) []analytics.ChannelAnalytics {
+	var collected []analytics.ChannelAnalytics
+	for _, snapshot := range history {
+		if !snapshot.Synthetic() {
+			collected = append(collected, snapshot)
+		}
+	}
+	return collected
+}
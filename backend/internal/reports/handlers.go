@@ -0,0 +1,366 @@
+package reports
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/billing"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxReportsPerUser bounds how many reports a user can save
+const maxReportsPerUser = 25
+
+type Handlers struct {
+	service *Service
+	repo    Repository
+	audit   *audit.Logger
+	limits  *billing.Limits
+}
+
+func NewHandlers(service *Service, repo Repository) *Handlers {
+	return &Handlers{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// UseAuditLog records report downloads. Call it before RegisterRoutes.
+func (h *Handlers) UseAuditLog(logger *audit.Logger) {
+	h.audit = logger
+}
+
+// UsePlanLimits keeps reports to plans with exports. Saved reports stay
+// listable and deletable after a downgrade. Call it before RegisterRoutes.
+func (h *Handlers) UsePlanLimits(limits *billing.Limits) {
+	h.limits = limits
+}
+
+// RegisterRoutes registers report routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	exports := h.limits.Require(billing.FeatureExports)
+
+	reports := router.Group("/reports")
+	reports.Get("/", h.ListReports)
+	reports.Post("/", exports, h.CreateReport)
+	reports.Get("/:id", h.GetReport)
+	reports.Put("/:id", exports, h.UpdateReport)
+	reports.Delete("/:id", h.DeleteReport)
+
+	reports.Post("/:id/run", exports, h.RunReport)
+	reports.Get("/:id/runs", h.ListRuns)
+	reports.Get("/:id/runs/:runID/download", exports, h.audit.Middleware(audit.ActionExportDownload), h.DownloadRun)
+}
+
+// reportRequest is the body for creating or updating a report
+type reportRequest struct {
+	Name      string   `json:"name"`
+	Metrics   []string `json:"metrics"`
+	RangeDays int      `json:"range_days"`
+	Format    string   `json:"format"`
+	Schedule  string   `json:"schedule"`
+	Email     bool     `json:"email"`
+}
+
+func (req reportRequest) report(userID string) (*Report, error) {
+	report := &Report{
+		UserID:    userID,
+		Name:      req.Name,
+		Metrics:   req.Metrics,
+		RangeDays: req.RangeDays,
+		Format:    req.Format,
+		Schedule:  req.Schedule,
+		Email:     req.Email,
+	}
+	if err := report.Normalize(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports returns the user's saved reports
+func (h *Handlers) ListReports(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	reports, err := h.repo.ListReports(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing reports for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list reports",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"reports": reports,
+	})
+}
+
+// CreateReport saves a report definition. Scheduled reports first run at the
+// next boundary of their schedule.
+func (h *Handlers) CreateReport(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req reportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	report, err := req.report(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	existing, err := h.repo.ListReports(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing reports for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create report",
+		})
+	}
+	if len(existing) >= maxReportsPerUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Report limit reached, delete an existing report first",
+		})
+	}
+
+	report.NextRunAt = NextRun(report.Schedule, time.Now())
+	if err := h.repo.CreateReport(c.UserContext(), report); err != nil {
+		log.Printf("Error creating report for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create report",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"report": report,
+	})
+}
+
+// GetReport returns one of the user's reports
+func (h *Handlers) GetReport(c *fiber.Ctx) error {
+	report, err := h.loadReport(c)
+	if report == nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"report": report,
+	})
+}
+
+// UpdateReport replaces one of the user's report definitions. Changing the
+// schedule restarts it from now.
+func (h *Handlers) UpdateReport(c *fiber.Ctx) error {
+	current, err := h.loadReport(c)
+	if current == nil {
+		return err
+	}
+
+	var req reportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	report, err := req.report(current.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	report.ID = current.ID
+	report.NextRunAt = current.NextRunAt
+	if report.Schedule != current.Schedule {
+		report.NextRunAt = NextRun(report.Schedule, time.Now())
+	}
+
+	found, err := h.repo.UpdateReport(c.UserContext(), report)
+	if err != nil {
+		log.Printf("Error updating report %d for user %s: %v", report.ID, report.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update report",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"report": report,
+	})
+}
+
+// DeleteReport deletes one of the user's reports with its runs
+func (h *Handlers) DeleteReport(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	found, err := h.repo.DeleteReport(c.UserContext(), user.ID, id)
+	if err != nil {
+		log.Printf("Error deleting report %d for user %s: %v", id, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete report",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Report deleted",
+		"id":      id,
+	})
+}
+
+// RunReport generates a report now and returns the run with its download link
+func (h *Handlers) RunReport(c *fiber.Ctx) error {
+	report, err := h.loadReport(c)
+	if report == nil {
+		return err
+	}
+
+	run, err := h.service.Run(c.UserContext(), report, TriggerManual)
+	if err != nil {
+		log.Printf("Error running report %d for user %s: %v", report.ID, report.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run report",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(runResponse(run))
+}
+
+// ListRuns returns a report's most recent runs
+func (h *Handlers) ListRuns(c *fiber.Ctx) error {
+	report, err := h.loadReport(c)
+	if report == nil {
+		return err
+	}
+
+	runs, err := h.repo.ListRuns(c.UserContext(), report.UserID, report.ID, h.service.cfg.RunsKept)
+	if err != nil {
+		log.Printf("Error listing runs of report %d for user %s: %v", report.ID, report.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list report runs",
+		})
+	}
+
+	response := make([]fiber.Map, 0, len(runs))
+	for i := range runs {
+		response = append(response, runResponse(&runs[i]))
+	}
+
+	return c.JSON(fiber.Map{
+		"runs": response,
+	})
+}
+
+// DownloadRun serves the output of a completed run
+func (h *Handlers) DownloadRun(c *fiber.Ctx) error {
+	report, err := h.loadReport(c)
+	if report == nil {
+		return err
+	}
+
+	runID, err := c.ParamsInt("runID")
+	if err != nil || runID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid run ID",
+		})
+	}
+
+	run, err := h.repo.GetRunOutput(c.UserContext(), report.UserID, report.ID, runID)
+	if err != nil {
+		log.Printf("Error downloading run %d of report %d for user %s: %v", runID, report.ID, report.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to download report",
+		})
+	}
+	if run == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report run not found",
+		})
+	}
+
+	// The filename uses the run's format in case the report changed since
+	named := *report
+	named.Format = run.Format
+	c.Set(fiber.HeaderContentType, ContentType(run.Format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, Filename(&named, run.CreatedAt)))
+	return c.Send(run.Output)
+}
+
+// loadReport returns the report named by :id. When it returns nil the error
+// response has been written and err is what the handler should return.
+func (h *Handlers) loadReport(c *fiber.Ctx) (*Report, error) {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	report, err := h.repo.GetReport(c.UserContext(), user.ID, id)
+	if err != nil {
+		log.Printf("Error loading report %d for user %s: %v", id, user.ID, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load report",
+		})
+	}
+	if report == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found",
+		})
+	}
+	return report, nil
+}
+
+func runResponse(run *Run) fiber.Map {
+	response := fiber.Map{
+		"run": run,
+	}
+	if run.Status == "completed" {
+		response["download_url"] = fmt.Sprintf("/api/reports/%d/runs/%d/download", run.ReportID, run.ID)
+	}
+	return response
+}
@@ -0,0 +1,191 @@
+// Package reports lets users save report definitions (metrics, date range and
+// format), run them on demand or on a schedule, keep the generated files and
+// optionally email them.
+package reports
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metrics a report can include
+const (
+	MetricFollowers   = "followers"
+	MetricSubscribers = "subscribers"
+	MetricViews       = "views"
+	MetricStreams     = "streams"
+	MetricTopVideos   = "top_videos"
+	MetricTopGames    = "top_games"
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Schedules. An empty schedule means the report only runs on demand.
+const (
+	ScheduleNone    = ""
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+const (
+	maxRangeDays  = 365
+	maxNameLength = 100
+)
+
+var knownMetrics = []string{MetricFollowers, MetricSubscribers, MetricViews, MetricStreams, MetricTopVideos, MetricTopGames}
+
+// MetricList is stored as a JSON array
+type MetricList []string
+
+func (m MetricList) Value() (driver.Value, error) {
+	if m == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(m))
+	return string(data), err
+}
+
+func (m *MetricList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		*m = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into MetricList", src)
+	}
+}
+
+// Report is a saved report definition
+type Report struct {
+	ID        int        `json:"id" db:"id"`
+	UserID    string     `json:"-" db:"user_id"`
+	Name      string     `json:"name" db:"name"`
+	Metrics   MetricList `json:"metrics" db:"metrics"`
+	RangeDays int        `json:"range_days" db:"range_days"`
+	Format    string     `json:"format" db:"format"`
+	Schedule  string     `json:"schedule" db:"schedule"`
+	// Email sends each run's output to the user's address
+	Email     bool       `json:"email" db:"email"`
+	NextRunAt *time.Time `json:"next_run_at" db:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at" db:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Run is one generated report. Output is only loaded for download.
+type Run struct {
+	ID           int       `json:"id" db:"id"`
+	ReportID     int       `json:"report_id" db:"report_id"`
+	UserID       string    `json:"-" db:"user_id"`
+	Status       string    `json:"status" db:"status"`
+	TriggeredBy  string    `json:"triggered_by" db:"triggered_by"`
+	Format       string    `json:"format" db:"format"`
+	Output       []byte    `json:"-" db:"output"`
+	SizeBytes    int       `json:"size_bytes" db:"size_bytes"`
+	StorageKey   string    `json:"storage_key,omitempty" db:"storage_key"`
+	Emailed      bool      `json:"emailed" db:"emailed"`
+	ErrorMessage string    `json:"error_message,omitempty" db:"error_message"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Normalize trims and validates the definition, de-duplicating metrics
+func (r *Report) Normalize() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > maxNameLength {
+		return fmt.Errorf("name must be 1-%d characters", maxNameLength)
+	}
+
+	if r.RangeDays == 0 {
+		r.RangeDays = 30
+	}
+	if r.RangeDays < 1 || r.RangeDays > maxRangeDays {
+		return fmt.Errorf("range_days must be between 1 and %d", maxRangeDays)
+	}
+
+	if r.Format == "" {
+		r.Format = FormatCSV
+	}
+	if r.Format != FormatJSON && r.Format != FormatCSV {
+		return fmt.Errorf("format must be json or csv")
+	}
+
+	switch r.Schedule {
+	case ScheduleNone, ScheduleDaily, ScheduleWeekly, ScheduleMonthly:
+	default:
+		return fmt.Errorf("schedule must be empty, daily, weekly or monthly")
+	}
+
+	if len(r.Metrics) == 0 {
+		return fmt.Errorf("at least one metric is required (%s)", strings.Join(knownMetrics, ", "))
+	}
+	seen := make(map[string]bool)
+	metrics := MetricList{}
+	for _, metric := range r.Metrics {
+		metric = strings.ToLower(strings.TrimSpace(metric))
+		if !isKnownMetric(metric) {
+			return fmt.Errorf("unknown metric %q (valid: %s)", metric, strings.Join(knownMetrics, ", "))
+		}
+		if !seen[metric] {
+			seen[metric] = true
+			metrics = append(metrics, metric)
+		}
+	}
+	r.Metrics = metrics
+	return nil
+}
+
+// Has reports whether the report includes the metric
+func (r *Report) Has(metric string) bool {
+	for _, m := range r.Metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownMetric(metric string) bool {
+	for _, m := range knownMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRun returns when a report on the schedule runs after from, or nil for
+// on-demand reports. Runs are due at midnight UTC: the next day, the next
+// Monday or the first of the next month.
+func NextRun(schedule string, from time.Time) *time.Time {
+	from = from.UTC()
+	midnight := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+
+	var next time.Time
+	switch schedule {
+	case ScheduleDaily:
+		next = midnight.AddDate(0, 0, 1)
+	case ScheduleWeekly:
+		days := (8 - int(midnight.Weekday())) % 7
+		if days == 0 {
+			days = 7
+		}
+		next = midnight.AddDate(0, 0, days)
+	case ScheduleMonthly:
+		next = time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nil
+	}
+	return &next
+}
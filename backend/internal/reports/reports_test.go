@@ -0,0 +1,141 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+)
+
+type fakeSource struct {
+	history []analytics.ChannelAnalytics
+	streams []time.Time
+	videos  []analytics.VideoAnalytics
+	games   []analytics.GameAnalytics
+	query   analytics.VideoListQuery
+}
+
+func (f *fakeSource) GetChannelAnalytics(ctx context.Context, userID string, days int) ([]analytics.ChannelAnalytics, error) {
+	return f.history, nil
+}
+
+func (f *fakeSource) GetBroadcastTimes(ctx context.Context, userID string, since time.Time) ([]time.Time, []time.Time, error) {
+	return f.streams, nil, nil
+}
+
+func (f *fakeSource) ListVideos(ctx context.Context, userID string, query analytics.VideoListQuery) ([]analytics.VideoAnalytics, int, error) {
+	f.query = query
+	return f.videos, len(f.videos), nil
+}
+
+func (f *fakeSource) GetTopGames(ctx context.Context, userID string, limit int) ([]analytics.GameAnalytics, error) {
+	return f.games, nil
+}
+
+func TestReportNormalize(t *testing.T) {
+	report := &Report{Name: "  Weekly growth ", Metrics: MetricList{"Followers", "views", "followers"}}
+	require.NoError(t, report.Normalize())
+	assert.Equal(t, "Weekly growth", report.Name)
+	assert.Equal(t, MetricList{"followers", "views"}, report.Metrics)
+	assert.Equal(t, 30, report.RangeDays)
+	assert.Equal(t, FormatCSV, report.Format)
+
+	for name, invalid := range map[string]Report{
+		"no name":    {Metrics: MetricList{"views"}},
+		"no metrics": {Name: "r"},
+		"metric":     {Name: "r", Metrics: MetricList{"revenue"}},
+		"range":      {Name: "r", Metrics: MetricList{"views"}, RangeDays: 400},
+		"format":     {Name: "r", Metrics: MetricList{"views"}, Format: "pdf"},
+		"schedule":   {Name: "r", Metrics: MetricList{"views"}, Schedule: "hourly"},
+	} {
+		assert.Error(t, invalid.Normalize(), name)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	// Wednesday afternoon
+	from := time.Date(2025, 6, 18, 15, 0, 0, 0, time.UTC)
+	assert.Nil(t, NextRun(ScheduleNone, from))
+	assert.Equal(t, time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), *NextRun(ScheduleDaily, from))
+	assert.Equal(t, time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC), *NextRun(ScheduleWeekly, from))
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), *NextRun(ScheduleMonthly, from))
+
+	monday := time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), *NextRun(ScheduleWeekly, monday))
+	december := time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *NextRun(ScheduleMonthly, december))
+}
+
+func testSource() *fakeSource {
+	day := time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)
+	published := day.Add(20 * time.Hour)
+	return &fakeSource{
+		// Newest first, like the repository
+		history: []analytics.ChannelAnalytics{
+			{Date: day.AddDate(0, 0, 1), FollowersCount: 120, TotalViews: 5200, SubscriberCount: 11},
+			{Date: day, FollowersCount: 100, TotalViews: 5000, SubscriberCount: 10},
+		},
+		streams: []time.Time{day.Add(20 * time.Hour), day.Add(22 * time.Hour)},
+		videos:  []analytics.VideoAnalytics{{Title: "Speedrun, any%", VideoType: "vod", ViewCount: 900, PublishedAt: &published}},
+		games:   []analytics.GameAnalytics{{GameName: "Celeste", TotalStreams: 3, TotalHoursStreamed: 7.5, AverageViewers: 40}},
+	}
+}
+
+func TestBuildAndRenderCSV(t *testing.T) {
+	source := testSource()
+	report := &Report{Name: "Growth", Metrics: MetricList{"followers", "streams", "top_videos", "top_games"}, RangeDays: 7, Format: FormatCSV}
+	now := time.Date(2025, 6, 18, 9, 0, 0, 0, time.UTC)
+
+	result, err := Build(context.Background(), source, report, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06-11", result.From)
+	assert.Equal(t, Summary{Start: 100, End: 120, Change: 20}, result.Summary["followers"])
+	assert.Equal(t, 2, result.Summary["streams"].End)
+	assert.Equal(t, "views", source.query.Sort)
+
+	output, err := Render(result, FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"date,followers,streams",
+		"2025-06-16,100,2",
+		"2025-06-17,120,0",
+		"",
+		"video,type,views,published_at",
+		`"Speedrun, any%",vod,900,2025-06-16T20:00:00Z`,
+		"",
+		"game,streams,hours,average_viewers",
+		"Celeste,3,7.5,40.0",
+		"",
+	}, "\n"), string(output))
+}
+
+func TestBuildAndRenderJSON(t *testing.T) {
+	report := &Report{Name: "Views", Metrics: MetricList{"views"}, RangeDays: 30, Format: FormatJSON}
+
+	result, err := Build(context.Background(), testSource(), report, time.Date(2025, 6, 18, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	output, err := Render(result, FormatJSON)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Summary map[string]Summary `json:"summary"`
+		Daily   []map[string]any   `json:"daily"`
+	}
+	require.NoError(t, json.Unmarshal(output, &decoded))
+	assert.Equal(t, 200, decoded.Summary["views"].Change)
+	require.Len(t, decoded.Daily, 2)
+	assert.NotContains(t, decoded.Daily[0], "followers")
+	assert.Nil(t, result.TopVideos)
+}
+
+func TestFilename(t *testing.T) {
+	at := time.Date(2025, 6, 18, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, "weekly-growth-2025-06-18.csv", Filename(&Report{Name: "Weekly Growth!", Format: FormatCSV}, at))
+	assert.Equal(t, "report-2025-06-18.json", Filename(&Report{Name: "📈", Format: FormatJSON}, at))
+}
@@ -0,0 +1,166 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListReports(ctx context.Context, userID string) ([]Report, error)
+	GetReport(ctx context.Context, userID string, id int) (*Report, error)
+	GetDueReports(ctx context.Context, userID string, now time.Time) ([]Report, error)
+	CreateReport(ctx context.Context, report *Report) error
+	UpdateReport(ctx context.Context, report *Report) (bool, error)
+	DeleteReport(ctx context.Context, userID string, id int) (bool, error)
+	MarkReportRun(ctx context.Context, id int, ranAt time.Time, nextRunAt *time.Time) error
+
+	CreateRun(ctx context.Context, run *Run) error
+	ListRuns(ctx context.Context, userID string, reportID, limit int) ([]Run, error)
+	GetRunOutput(ctx context.Context, userID string, reportID, runID int) (*Run, error)
+	DeleteOldRuns(ctx context.Context, reportID, keep int) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const reportColumns = `id, user_id, name, metrics, range_days, format, schedule, email, next_run_at, last_run_at, created_at, updated_at`
+
+const runColumns = `id, report_id, user_id, status, triggered_by, format, size_bytes, storage_key, emailed, error_message, created_at`
+
+func (r *repository) ListReports(ctx context.Context, userID string) ([]Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE user_id = $1 ORDER BY created_at`
+
+	reports := []Report{}
+	err := r.db.SelectContext(ctx, &reports, query, userID)
+	return reports, err
+}
+
+func (r *repository) GetReport(ctx context.Context, userID string, id int) (*Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE id = $1 AND user_id = $2`
+
+	var report Report
+	err := r.db.GetContext(ctx, &report, query, id, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &report, err
+}
+
+// GetDueReports returns the user's scheduled reports whose next run has come
+func (r *repository) GetDueReports(ctx context.Context, userID string, now time.Time) ([]Report, error) {
+	query := `
+		SELECT ` + reportColumns + ` FROM reports
+		WHERE user_id = $1 AND next_run_at IS NOT NULL AND next_run_at <= $2
+		ORDER BY next_run_at
+	`
+
+	reports := []Report{}
+	err := r.db.SelectContext(ctx, &reports, query, userID, now)
+	return reports, err
+}
+
+func (r *repository) CreateReport(ctx context.Context, report *Report) error {
+	query := `
+		INSERT INTO reports (user_id, name, metrics, range_days, format, schedule, email, next_run_at)
+		VALUES ($1, $2, $3::jsonb, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, report.UserID, report.Name, report.Metrics, report.RangeDays,
+		report.Format, report.Schedule, report.Email, report.NextRunAt).
+		Scan(&report.ID, &report.CreatedAt, &report.UpdatedAt)
+}
+
+// UpdateReport saves a definition, returning false if the user has no such report
+func (r *repository) UpdateReport(ctx context.Context, report *Report) (bool, error) {
+	query := `
+		UPDATE reports
+		SET name = $3, metrics = $4::jsonb, range_days = $5, format = $6, schedule = $7,
+			email = $8, next_run_at = $9, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING last_run_at, created_at, updated_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, report.ID, report.UserID, report.Name, report.Metrics,
+		report.RangeDays, report.Format, report.Schedule, report.Email, report.NextRunAt).
+		Scan(&report.LastRunAt, &report.CreatedAt, &report.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *repository) DeleteReport(ctx context.Context, userID string, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM reports WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *repository) MarkReportRun(ctx context.Context, id int, ranAt time.Time, nextRunAt *time.Time) error {
+	query := `UPDATE reports SET last_run_at = $2, next_run_at = $3 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, ranAt, nextRunAt)
+	return err
+}
+
+func (r *repository) CreateRun(ctx context.Context, run *Run) error {
+	query := `
+		INSERT INTO report_runs (report_id, user_id, status, triggered_by, format, output, size_bytes,
+			storage_key, emailed, error_message)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowxContext(ctx, query, run.ReportID, run.UserID, run.Status, run.TriggeredBy, run.Format,
+		run.Output, len(run.Output), run.StorageKey, run.Emailed, run.ErrorMessage).
+		Scan(&run.ID, &run.CreatedAt)
+}
+
+func (r *repository) ListRuns(ctx context.Context, userID string, reportID, limit int) ([]Run, error) {
+	query := `
+		SELECT ` + runColumns + ` FROM report_runs
+		WHERE report_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	runs := []Run{}
+	err := r.db.SelectContext(ctx, &runs, query, reportID, userID, limit)
+	return runs, err
+}
+
+// GetRunOutput returns a completed run with its output, or nil if not found
+func (r *repository) GetRunOutput(ctx context.Context, userID string, reportID, runID int) (*Run, error) {
+	query := `
+		SELECT ` + runColumns + `, output FROM report_runs
+		WHERE id = $1 AND report_id = $2 AND user_id = $3 AND status = 'completed'
+	`
+
+	var run Run
+	err := r.db.GetContext(ctx, &run, query, runID, reportID, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &run, err
+}
+
+// DeleteOldRuns keeps only the newest runs of a report
+func (r *repository) DeleteOldRuns(ctx context.Context, reportID, keep int) error {
+	query := `
+		DELETE FROM report_runs
+		WHERE report_id = $1 AND id NOT IN (
+			SELECT id FROM report_runs WHERE report_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`
+	_, err := r.db.ExecContext(ctx, query, reportID, keep)
+	return err
+}
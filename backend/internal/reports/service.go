@@ -0,0 +1,196 @@
+package reports
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/email"
+	"github.com/baldybuilds/creatorsync/internal/export"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+)
+
+// What started a run
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+)
+
+// Service runs saved reports, stores their output and delivers it
+type Service struct {
+	repo          Repository
+	analyticsRepo analytics.Repository
+	sink          export.Sink
+	email         *email.ResendClient
+	cfg           config.ReportsConfig
+}
+
+// NewService creates the reports service. Outputs are also uploaded to sink
+// when it is non-nil, and emails are skipped when Resend isn't configured.
+func NewService(repo Repository, analyticsRepo analytics.Repository, sink export.Sink) *Service {
+	emailClient, err := email.NewResendClient()
+	if err != nil {
+		log.Printf("⚠️ Report emails disabled: %v", err)
+	}
+
+	return &Service{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+		sink:          sink,
+		email:         emailClient,
+		cfg:           config.Reports(),
+	}
+}
+
+// Run generates the report now and stores the run. A report that fails to
+// build is stored as a failed run, so the error shows up in its history.
+func (s *Service) Run(ctx context.Context, report *Report, triggeredBy string) (*Run, error) {
+	now := time.Now().UTC()
+	run := &Run{
+		ReportID:    report.ID,
+		UserID:      report.UserID,
+		Status:      "completed",
+		TriggeredBy: triggeredBy,
+		Format:      report.Format,
+	}
+
+	output, err := s.generate(ctx, report, now)
+	if err != nil {
+		log.Printf("Failed to run report %d for user %s: %v", report.ID, report.UserID, err)
+		run.Status = "failed"
+		run.ErrorMessage = err.Error()
+	} else {
+		run.Output = output
+		run.SizeBytes = len(output)
+		s.deliver(ctx, report, run, now)
+	}
+
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to save report run: %w", err)
+	}
+
+	// Manual runs don't move the schedule
+	nextRunAt := report.NextRunAt
+	if triggeredBy == TriggerSchedule {
+		nextRunAt = NextRun(report.Schedule, now)
+	}
+	if err := s.repo.MarkReportRun(ctx, report.ID, now, nextRunAt); err != nil {
+		return nil, fmt.Errorf("failed to update report: %w", err)
+	}
+	report.LastRunAt, report.NextRunAt = &now, nextRunAt
+
+	if err := s.repo.DeleteOldRuns(ctx, report.ID, s.cfg.RunsKept); err != nil {
+		log.Printf("Failed to prune runs of report %d: %v", report.ID, err)
+	}
+
+	log.Printf("📊 Ran report %d for user %s (%s, %s, %d bytes)", report.ID, report.UserID, triggeredBy, run.Status, run.SizeBytes)
+	return run, nil
+}
+
+// RunDueReports runs the user's scheduled reports that are due. It has the
+// signature of an analytics.CollectionHook so reports use freshly collected data.
+func (s *Service) RunDueReports(ctx context.Context, userID string) error {
+	due, err := s.repo.GetDueReports(ctx, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get due reports: %w", err)
+	}
+
+	for i := range due {
+		if _, err := s.Run(ctx, &due[i], TriggerSchedule); err != nil {
+			log.Printf("Failed to run scheduled report %d for user %s: %v", due[i].ID, userID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) generate(ctx context.Context, report *Report, now time.Time) ([]byte, error) {
+	result, err := Build(ctx, s.analyticsRepo, report, now)
+	if err != nil {
+		return nil, err
+	}
+	output, err := Render(result, report.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return output, nil
+}
+
+// deliver uploads the output to the export bucket and emails it. Failures are
+// logged only; the run is still available for download.
+func (s *Service) deliver(ctx context.Context, report *Report, run *Run, now time.Time) {
+	filename := Filename(report, now)
+
+	if s.sink != nil {
+		key := fmt.Sprintf("reports/%s/%d/%s", report.UserID, report.ID, filename)
+		if err := s.sink.Put(ctx, key, run.Output, ContentType(report.Format), ""); err != nil {
+			log.Printf("Failed to export report %d for user %s: %v", report.ID, report.UserID, err)
+		} else {
+			run.StorageKey = key
+		}
+	}
+
+	if report.Email {
+		if err := s.sendEmail(ctx, report, run.Output, filename); err != nil {
+			log.Printf("Failed to email report %d for user %s: %v", report.ID, report.UserID, err)
+		} else {
+			run.Emailed = true
+		}
+	}
+}
+
+func (s *Service) sendEmail(ctx context.Context, report *Report, output []byte, filename string) error {
+	if s.email == nil {
+		return fmt.Errorf("email is not configured")
+	}
+
+	user, err := s.analyticsRepo.GetUserByClerkID(ctx, report.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || user.Email == "" {
+		return fmt.Errorf("user has no email address")
+	}
+
+	return s.email.Send(email.EmailRequest{
+		From:    s.cfg.EmailFrom,
+		To:      []string{user.Email},
+		Subject: i18n.T(user.Locale, "Your CreatorSync report: %s", report.Name),
+		HTML: fmt.Sprintf(`
+			<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+				<h2 style="color: #6366f1;">%s</h2>
+				<p>%s</p>
+				<p style="color: #6b7280; font-size: 12px;">%s</p>
+			</div>
+		`, html.EscapeString(report.Name),
+			html.EscapeString(i18n.T(user.Locale, "Your report covering the last %d days is attached.", report.RangeDays)),
+			html.EscapeString(i18n.T(user.Locale, "Metrics: %s", strings.Join(report.Metrics, ", ")))),
+		Attachments: []email.Attachment{{
+			Filename: filename,
+			Content:  base64.StdEncoding.EncodeToString(output),
+		}},
+	})
+}
+
+// Filename is the download and attachment name of a report generated at t
+func Filename(report *Report, t time.Time) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(report.Name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			slug.WriteRune(r)
+		case slug.Len() > 0 && !strings.HasSuffix(slug.String(), "-"):
+			slug.WriteRune('-')
+		}
+	}
+	name := strings.Trim(slug.String(), "-")
+	if name == "" {
+		name = "report"
+	}
+	return fmt.Sprintf("%s-%s.%s", name, t.UTC().Format("2006-01-02"), report.Format)
+}
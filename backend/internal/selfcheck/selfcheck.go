@@ -0,0 +1,225 @@
+// Package selfcheck verifies a deployment can serve before it takes traffic:
+// its configuration, the database and its schema, and the Clerk and Twitch
+// credentials. cmd/debug -check runs it as a container health gate.
+package selfcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/database"
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/robfig/cron/v3"
+)
+
+// Check statuses
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Check verifies one dependency. Run returns what it found, or why the
+// dependency isn't usable.
+type Check struct {
+	Name string
+	// Needs are checks that must pass first, the check is skipped otherwise
+	Needs []string
+	Run   func(ctx context.Context) (detail string, err error)
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report lists the results in the order the checks ran. OK is false when
+// any check failed or was skipped.
+type Report struct {
+	CheckedAt time.Time `json:"checked_at"`
+	OK        bool      `json:"ok"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Run runs the checks one after another, each within timeout
+func Run(ctx context.Context, checks []Check, timeout time.Duration) *Report {
+	report := &Report{CheckedAt: time.Now().UTC(), OK: true, Checks: []Result{}}
+	passed := map[string]bool{}
+
+	for _, check := range checks {
+		result := Result{Name: check.Name}
+		var missing []string
+		for _, need := range check.Needs {
+			if !passed[need] {
+				missing = append(missing, need)
+			}
+		}
+
+		if len(missing) > 0 {
+			result.Status = StatusSkipped
+			result.Error = "needs " + strings.Join(missing, ", ")
+		} else {
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			started := time.Now()
+			detail, err := check.Run(checkCtx)
+			result.DurationMs = time.Since(started).Milliseconds()
+			cancel()
+
+			result.Status, result.Detail = StatusOK, detail
+			if err != nil {
+				result.Status, result.Error = StatusFailed, err.Error()
+			}
+		}
+
+		passed[check.Name] = result.Status == StatusOK
+		report.OK = report.OK && passed[check.Name]
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// Default returns the checks of a deployment of the API. close releases the
+// database connection the checks open.
+func Default() (checks []Check, close func()) {
+	var db database.Service
+	checks = []Check{
+		{Name: "config", Run: checkConfig},
+		{Name: "database", Run: func(ctx context.Context) (string, error) {
+			var err error
+			if db, err = database.Open(); err != nil {
+				return "", err
+			}
+			var version string
+			if err := db.GetDB().QueryRowContext(ctx, `SHOW server_version`).Scan(&version); err != nil {
+				return "", fmt.Errorf("failed to query server version: %w", err)
+			}
+			return "PostgreSQL " + version, nil
+		}},
+		{Name: "schema", Needs: []string{"database"}, Run: func(ctx context.Context) (string, error) {
+			return checkSchema(db, config.Migrations())
+		}},
+		{Name: "clerk", Run: checkClerk},
+		{Name: "twitch", Run: checkTwitch},
+	}
+	return checks, func() {
+		if db != nil {
+			db.Close()
+		}
+	}
+}
+
+// requiredEnv are the variables the API doesn't start without
+var requiredEnv = []string{"CLERK_SECRET_KEY", "TWITCH_CLIENT_ID", "TWITCH_CLIENT_SECRET"}
+
+// checkConfig reports every missing or invalid setting at once
+func checkConfig(ctx context.Context) (string, error) {
+	var problems []string
+	for _, key := range requiredEnv {
+		if os.Getenv(key) == "" {
+			problems = append(problems, key+" is not set")
+		}
+	}
+	if os.Getenv("DATABASE_URL") == "" && os.Getenv("POSTGRES_DB_HOST") == "" {
+		problems = append(problems, "DATABASE_URL or POSTGRES_DB_HOST is not set")
+	}
+
+	if _, err := tokencrypt.FromEnv(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	schedules := config.Schedules()
+	for key, spec := range map[string]string{
+		"SCHEDULE_USER_RECONCILIATION": schedules.UserReconciliation,
+		"SCHEDULE_DAILY_SNAPSHOT":      schedules.DailySnapshot,
+		"SCHEDULE_LIVE_SAMPLING":       schedules.LiveSampling,
+		"SCHEDULE_WEEKLY_DIGEST":       schedules.WeeklyDigest,
+		"SCHEDULE_ARCHIVE_PURGE":       schedules.ArchivePurge,
+	} {
+		if spec == config.ScheduleOff {
+			continue
+		}
+		if _, err := cron.ParseStandard(spec); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not a valid schedule: %v", key, err))
+		}
+	}
+
+	// Optional features that are half configured are silently off
+	if eventSub := config.EventSub(); eventSub.CallbackURL != "" && !eventSub.Enabled() {
+		problems = append(problems, "TWITCH_EVENTSUB_SECRET must be 10-100 characters when TWITCH_EVENTSUB_CALLBACK_URL is set")
+	}
+	if debug := config.Debug(); debug.Token != "" && !debug.Enabled() {
+		problems = append(problems, "DEBUG_TOKEN is too short, the debug endpoints stay off")
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	return "required settings present", nil
+}
+
+// checkSchema applies the rules of the API's start: pending migrations only
+// fail the check when they wouldn't be applied or ignored on start
+func checkSchema(db database.Service, cfg config.MigrationConfig) (string, error) {
+	migrations := database.Migrations(cfg.Dir)
+	pending, err := database.NewMigrationRunner(db.GetDB()).PendingMigrations(migrations)
+	if err != nil {
+		return "", err
+	}
+
+	if len(pending) == 0 {
+		files, err := fs.Glob(migrations, "*.sql")
+		if err != nil || len(files) == 0 {
+			return "up to date", nil
+		}
+		slices.Sort(files)
+		return "up to date at " + files[len(files)-1], nil
+	}
+
+	detail := fmt.Sprintf("%d migrations pending, from %s", len(pending), pending[0])
+	switch {
+	case cfg.AutoMigrate:
+		return detail + ", applied on start (AUTO_MIGRATE)", nil
+	case !cfg.SchemaCheck:
+		return detail + ", ignored on start (MIGRATION_SCHEMA_CHECK=false)", nil
+	}
+	return "", errors.New("database schema is behind: " + detail + " (run cmd/migrate or set AUTO_MIGRATE=true)")
+}
+
+func checkClerk(ctx context.Context) (string, error) {
+	if err := clerk.Initialize(); err != nil {
+		return "", err
+	}
+	if err := clerk.CheckSecretKey(ctx); err != nil {
+		return "", fmt.Errorf("secret key rejected: %w", err)
+	}
+	return "secret key accepted", nil
+}
+
+// checkTwitch fetches an app access token, which only valid app credentials get
+func checkTwitch(ctx context.Context) (string, error) {
+	client, err := twitch.NewClient(os.Getenv("TWITCH_CLIENT_ID"), os.Getenv("TWITCH_CLIENT_SECRET"))
+	if err != nil {
+		return "", err
+	}
+	client.SetCallTimeout(config.Twitch().CallTimeout)
+
+	token, err := client.GetAppAccessToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get an app access token: %w", err)
+	}
+	return fmt.Sprintf("app access token valid for %s", time.Duration(token.ExpiresIn)*time.Second), nil
+}
@@ -0,0 +1,70 @@
+package selfcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSkipsChecksWhoseNeedsFailed(t *testing.T) {
+	var ran []string
+	check := func(name string, err error, needs ...string) Check {
+		return Check{Name: name, Needs: needs, Run: func(ctx context.Context) (string, error) {
+			ran = append(ran, name)
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+			return name + " found", err
+		}}
+	}
+
+	report := Run(context.Background(), []Check{
+		check("config", nil),
+		check("database", errors.New("connection refused")),
+		check("schema", nil, "database"),
+		check("twitch", nil, "config"),
+	}, time.Second)
+
+	assert.False(t, report.OK)
+	assert.Equal(t, []string{"config", "database", "twitch"}, ran)
+	require.Len(t, report.Checks, 4)
+	assert.Equal(t, Result{Name: "config", Status: StatusOK, Detail: "config found"}, withoutDuration(report.Checks[0]))
+	assert.Equal(t, Result{Name: "database", Status: StatusFailed, Detail: "database found", Error: "connection refused"}, withoutDuration(report.Checks[1]))
+	assert.Equal(t, Result{Name: "schema", Status: StatusSkipped, Error: "needs database"}, report.Checks[2])
+	assert.Equal(t, StatusOK, report.Checks[3].Status)
+}
+
+func withoutDuration(result Result) Result {
+	result.DurationMs = 0
+	return result
+}
+
+func TestRunPassesWhenEveryCheckPasses(t *testing.T) {
+	ok := func(ctx context.Context) (string, error) { return "", nil }
+	report := Run(context.Background(), []Check{{Name: "a", Run: ok}, {Name: "b", Needs: []string{"a"}, Run: ok}}, time.Second)
+	assert.True(t, report.OK)
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Setenv("CLERK_SECRET_KEY", "sk_test")
+	t.Setenv("TWITCH_CLIENT_ID", "client")
+	t.Setenv("TWITCH_CLIENT_SECRET", "secret")
+	t.Setenv("DATABASE_URL", "postgres://localhost/creatorsync")
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "secret-key")
+
+	_, err := checkConfig(context.Background())
+	require.NoError(t, err)
+
+	t.Setenv("TWITCH_CLIENT_SECRET", "")
+	t.Setenv("SCHEDULE_DAILY_SNAPSHOT", "every day")
+	t.Setenv("TWITCH_EVENTSUB_CALLBACK_URL", "https://api.creatorsync.app/api/webhooks/twitch/eventsub")
+	t.Setenv("TWITCH_EVENTSUB_SECRET", "short")
+	_, err = checkConfig(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TWITCH_CLIENT_SECRET is not set")
+	assert.Contains(t, err.Error(), "SCHEDULE_DAILY_SNAPSHOT is not a valid schedule")
+	assert.Contains(t, err.Error(), "TWITCH_EVENTSUB_SECRET must be")
+}
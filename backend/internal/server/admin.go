@@ -0,0 +1,29 @@
+package server
+
+import (
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireAdmin only lets users listed in ADMIN_USER_IDS or holding the "admin"
+// role claim through. It must run after the Clerk middleware.
+func requireAdmin(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	if !config.Admin().IsAdmin(user.ID) && !user.HasRole("admin") {
+		log.Printf("⛔ Non-admin user %s requested %s", user.ID, c.Path())
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin access required",
+		})
+	}
+
+	return c.Next()
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/preferences"
+	"github.com/baldybuilds/creatorsync/internal/publishing"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bootstrapOverviewDays is the overview's window, the dashboard's default
+const bootstrapOverviewDays = 7
+
+// connectionStatus is whether the user connected a platform. Enabled is false
+// when the server isn't configured for it.
+type connectionStatus struct {
+	Enabled   bool   `json:"enabled"`
+	Connected bool   `json:"connected"`
+	Account   string `json:"account,omitempty"`
+}
+
+// twitchConnectionStatus adds the scopes a stored token is missing. Tokens
+// from the Clerk sign-in aren't checked here, see /api/twitch/scopes.
+type twitchConnectionStatus struct {
+	connectionStatus
+	TokenSource    string   `json:"token_source,omitempty"`
+	MissingScopes  []string `json:"missing_scopes"`
+	NeedsReconsent bool     `json:"needs_reconsent"`
+}
+
+// bootstrapHandler returns what the dashboard needs on load in one response:
+// the user's profile, connections, the server's features, preferences and the
+// cached overview. Sections are loaded concurrently, and one that fails is
+// null and listed in failed_sections rather than failing the response.
+func (s *FiberServer) bootstrapHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	// Like /user/sync, so the first load creates the user's row
+	if err := s.ensureUserExistsInDatabase(c.UserContext(), user.ID); err != nil {
+		log.Printf("Failed to sync user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load dashboard",
+		})
+	}
+
+	ctx := c.UserContext()
+	repo := analytics.NewRepository(s.db.GetDB())
+	var (
+		profile     *analytics.User
+		twitchToken *analytics.TwitchToken
+		patreon     *connectionStatus
+		streamlabs  *connectionStatus
+		youtube     *connectionStatus
+		prefs       *preferences.Preferences
+		overview    *analytics.DashboardOverview
+
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+	)
+	load := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				log.Printf("Failed to load %s for bootstrap of user %s: %v", section, user.ID, err)
+				mu.Lock()
+				failed = append(failed, section)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	load("profile", func() error {
+		found, err := repo.GetUserByClerkID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		token, err := repo.GetTwitchToken(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		profile, twitchToken = found, token
+		return nil
+	})
+	load("patreon", func() error {
+		status := &connectionStatus{Enabled: config.Patreon().Enabled()}
+		if status.Enabled {
+			token, err := repo.GetPatreonToken(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			status.Connected = token != nil
+		}
+		patreon = status
+		return nil
+	})
+	load("streamlabs", func() error {
+		status := &connectionStatus{Enabled: config.Streamlabs().Enabled()}
+		if status.Enabled {
+			token, err := repo.GetStreamlabsToken(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			status.Connected = token != nil
+		}
+		streamlabs = status
+		return nil
+	})
+	load("youtube", func() error {
+		status := &connectionStatus{Enabled: config.Publishing().Enabled()}
+		if status.Enabled {
+			token, err := publishing.NewRepository(s.db.GetDB()).GetYouTubeToken(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			if token != nil {
+				status.Connected, status.Account = true, token.ChannelTitle
+			}
+		}
+		youtube = status
+		return nil
+	})
+	load("preferences", func() error {
+		record, err := preferences.NewRepository(s.db.GetDB()).Get(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		loaded := preferences.Default()
+		if record != nil {
+			loaded = record.Preferences
+		}
+		prefs = &loaded
+		return nil
+	})
+	load("overview", func() error {
+		loaded, err := s.analyticsService.GetDashboardOverview(ctx, user.ID, bootstrapOverviewDays)
+		overview = loaded
+		return err
+	})
+	wg.Wait()
+
+	response := fiber.Map{
+		"user":        user,
+		"profile":     profile,
+		"locale":      i18n.Locale(c),
+		"features":    s.features(user),
+		"preferences": prefs,
+		"overview":    overview,
+		"connections": fiber.Map{
+			"twitch":     twitchConnection(profile, twitchToken),
+			"patreon":    patreon,
+			"streamlabs": streamlabs,
+			"youtube":    youtube,
+		},
+	}
+	if len(failed) > 0 {
+		response["failed_sections"] = failed
+	}
+	return c.JSON(response)
+}
+
+// twitchConnection reports the Twitch account of the user's row, and the
+// scopes their stored token lacks
+func twitchConnection(profile *analytics.User, token *analytics.TwitchToken) twitchConnectionStatus {
+	status := twitchConnectionStatus{
+		connectionStatus: connectionStatus{Enabled: true},
+		MissingScopes:    []string{},
+	}
+	if profile != nil && profile.TwitchUserID != "" {
+		status.Connected, status.Account = true, profile.Username
+		status.TokenSource = "clerk"
+	}
+	if token != nil {
+		status.Connected, status.TokenSource = true, "creatorsync"
+		status.MissingScopes = twitch.MissingScopes(twitch.RequiredScopes(), strings.Fields(token.Scopes))
+		status.NeedsReconsent = len(status.MissingScopes) > 0
+	}
+	return status
+}
+
+// features tells the frontend which optional parts of the API this server has
+// enabled, and whether the user may open the admin pages
+func (s *FiberServer) features(user *clerk.User) fiber.Map {
+	features := fiber.Map{
+		"publishing":   config.Publishing().Enabled(),
+		"clip_renders": config.ClipRenders().Enabled,
+		"patreon":      config.Patreon().Enabled(),
+		"streamlabs":   config.Streamlabs().Enabled(),
+		"insights":     config.Insights().Enabled(),
+		"eventsub":     config.EventSub().Enabled(),
+		"exports":      config.Export().Enabled,
+		"admin":        config.Admin().IsAdmin(user.ID) || user.HasRole("admin"),
+		"read_only":    false,
+	}
+	if s.maintenance != nil {
+		features["read_only"] = s.maintenance.ReadOnly()
+	}
+	return features
+}
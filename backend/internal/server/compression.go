@@ -0,0 +1,43 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+)
+
+// uncompressedPrefixes are routes whose responses are already compressed
+// images, so compressing them again only costs CPU
+var uncompressedPrefixes = []string{"/api/media/"}
+
+// compressionMiddleware compresses responses with brotli, gzip or deflate,
+// whichever the client's Accept-Encoding prefers
+func compressionMiddleware(cfg config.CompressionConfig) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	level := compress.LevelDefault
+	switch cfg.Level {
+	case config.CompressionSpeed:
+		level = compress.LevelBestSpeed
+	case config.CompressionBest:
+		level = compress.LevelBestCompression
+	}
+
+	return compress.New(compress.Config{
+		Level: level,
+		Next: func(c *fiber.Ctx) bool {
+			for _, prefix := range uncompressedPrefixes {
+				if strings.HasPrefix(c.Path(), prefix) {
+					return true
+				}
+			}
+			return false
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+)
+
+// startedAt is when the process started, for the uptime in /debug/runtime
+var startedAt = time.Now()
+
+// registerDebugRoutes mounts the pprof profiles under /debug/pprof and runtime
+// stats at /debug/runtime, behind the debug token. Profiles are taken with e.g.
+//
+//	curl -H "Authorization: Bearer $DEBUG_TOKEN" https://api/debug/pprof/profile?seconds=30 > cpu.pprof
+func (s *FiberServer) registerDebugRoutes(token string) {
+	debug := s.App.Group("/debug", requireDebugToken(token))
+	debug.Get("/runtime", runtimeStatsHandler)
+	debug.Use(pprof.New())
+}
+
+// requireDebugToken only lets requests with the bearer token through. Clerk
+// sessions aren't used, so profiles can be fetched from the command line.
+func requireDebugToken(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sent, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			log.Printf("⛔ Rejected debug request for %s from %s", c.Path(), audit.ClientIP(c))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid debug token",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// runtimeStatsHandler reports goroutines, memory and GC of the process, cheap
+// enough to poll while a regression is investigated
+func runtimeStatsHandler(c *fiber.Ctx) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	gc := fiber.Map{
+		"count":           mem.NumGC,
+		"forced":          mem.NumForcedGC,
+		"pause_total_ms":  float64(mem.PauseTotalNs) / float64(time.Millisecond),
+		"cpu_fraction":    mem.GCCPUFraction,
+		"next_heap_bytes": mem.NextGC,
+		"last_at":         nil,
+		"last_pause_ms":   0.0,
+	}
+	if mem.NumGC > 0 {
+		gc["last_at"] = time.Unix(0, int64(mem.LastGC)).UTC()
+		gc["last_pause_ms"] = float64(mem.PauseNs[(mem.NumGC+255)%256]) / float64(time.Millisecond)
+	}
+
+	return c.JSON(fiber.Map{
+		"go_version":     runtime.Version(),
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+		"goroutines":     runtime.NumGoroutine(),
+		"cpus":           runtime.NumCPU(),
+		"gomaxprocs":     runtime.GOMAXPROCS(0),
+		"memory": fiber.Map{
+			"heap_alloc_bytes":    mem.HeapAlloc,
+			"heap_inuse_bytes":    mem.HeapInuse,
+			"heap_idle_bytes":     mem.HeapIdle,
+			"heap_released_bytes": mem.HeapReleased,
+			"heap_objects":        mem.HeapObjects,
+			"stack_inuse_bytes":   mem.StackInuse,
+			"sys_bytes":           mem.Sys,
+			"total_alloc_bytes":   mem.TotalAlloc,
+		},
+		"gc": gc,
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// degradedHeader marks responses served from the cache while the database is down
+const degradedHeader = "X-CreatorSync-Degraded"
+
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	storedAt    time.Time
+}
+
+// degradedMiddleware remembers each user's last successful GET responses and
+// replays them when a request fails because the database is unreachable. ping
+// reports whether the database is reachable.
+func degradedMiddleware(cfg config.DegradedConfig, ping func() error) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	responses := cache.NewMemory[cachedResponse](cfg.CacheTTL, cfg.MaxEntries)
+
+	return func(c *fiber.Ctx) error {
+		user, err := clerk.GetUserFromContext(c)
+		if c.Method() != fiber.MethodGet || err != nil {
+			return c.Next()
+		}
+		key := user.ID + " " + c.OriginalURL()
+
+		err = c.Next()
+		status := c.Response().StatusCode()
+		if err == nil && status == fiber.StatusOK {
+			responses.Set(key, cachedResponse{
+				contentType: string(c.Response().Header.ContentType()),
+				body:        append([]byte(nil), c.Response().Body()...),
+				storedAt:    time.Now(),
+			})
+			return nil
+		}
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		if status < fiber.StatusInternalServerError {
+			return err
+		}
+
+		cached, ok := responses.GetContext(c.UserContext(), key)
+		if !ok {
+			return err
+		}
+		pingErr := ping()
+		if pingErr == nil {
+			return err
+		}
+		log.Printf("⚠️ Database unreachable, serving cached %s to user %s: %v", c.Path(), user.ID, pingErr)
+
+		c.Status(fiber.StatusOK)
+		c.Set(fiber.HeaderContentType, cached.contentType)
+		c.Set(fiber.HeaderAge, strconv.Itoa(int(time.Since(cached.storedAt).Seconds())))
+		c.Set(degradedHeader, "true")
+		return c.Send(cached.body)
+	}
+}
@@ -2,16 +2,27 @@ package handlers
 
 import (
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/baldybuilds/creatorsync/internal/server/helpers"
 	"github.com/baldybuilds/creatorsync/internal/server/models"
 	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/validate"
 	"github.com/gofiber/fiber/v2"
 )
 
 func GetTwitchVideoAnalyticsSummaryHandler(c *fiber.Ctx) error {
+	// period_days of 0 covers all of the fetched videos, and Twitch returns
+	// at most 100 videos per request
+	var query struct {
+		PeriodDays int `query:"period_days" validate:"min=0,max=3650"`
+		VideoLimit int `query:"video_limit" default:"20" validate:"min=1,max=100"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+	periodDays, videoLimit := query.PeriodDays, query.VideoLimit
+
 	twitchContext, err := helpers.GetTwitchRequestContext(c)
 	if err != nil {
 		return helpers.HandleTwitchError(c, err)
@@ -21,24 +32,8 @@ func GetTwitchVideoAnalyticsSummaryHandler(c *fiber.Ctx) error {
 	twitchToken := twitchContext.AccessToken
 	twitchClient := twitchContext.Client
 
-	// Parse query parameters
-	periodDaysQuery := c.Query("period_days", "0") // Default to 0 (all time / up to video_limit)
-	periodDays, convErr := strconv.Atoi(periodDaysQuery)
-	if convErr != nil {
-		periodDays = 0 // Default on parse error
-	}
-
-	videoLimitQuery := c.Query("video_limit", "20") // Default to 20 videos
-	videoLimit, convErr := strconv.Atoi(videoLimitQuery)
-	if convErr != nil || videoLimit <= 0 {
-		videoLimit = 20 // Default on parse error or invalid value
-	}
-	if videoLimit > 100 {
-		videoLimit = 100 // Max limit for Twitch API GetUserVideos
-	}
-
 	// Fetch videos - GetUserVideos fetches most recent 'videoLimit' videos
-	fetchedVideos, _, err := twitchClient.GetUserVideos(c.Context(), twitchToken, twitchUserID, videoLimit)
+	fetchedVideos, _, err := twitchClient.GetUserVideos(c.UserContext(), twitchToken, twitchUserID, videoLimit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("Failed to fetch Twitch videos: %v", err)})
 	}
@@ -17,7 +17,7 @@ func GetTwitchChannelHandler(c *fiber.Ctx) error {
 	twitchToken := twitchContext.AccessToken
 	twitchClient := twitchContext.Client
 
-	channelInfo, err := twitchClient.GetChannelInfo(c.Context(), twitchToken, twitchUserID)
+	channelInfo, err := twitchClient.GetChannelInfo(c.UserContext(), twitchToken, twitchUserID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to fetch Twitch channel info: %v", err),
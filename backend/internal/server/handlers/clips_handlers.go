@@ -18,7 +18,7 @@ func GetTwitchClipsHandler(c *fiber.Ctx) error {
 	twitchClient := twitchContext.Client
 
 	// TODO: Add query parameters for time range and pagination
-	clips, err := twitchClient.GetClips(c.Context(), twitchToken, twitchUserID, 20)
+	clips, err := twitchClient.GetClips(c.UserContext(), twitchToken, twitchUserID, 20)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to fetch Twitch clips: %v", err),
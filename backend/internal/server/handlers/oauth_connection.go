@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/billing"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+	"github.com/baldybuilds/creatorsync/internal/server/helpers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// oauthConnection is the connect, callback and disconnect flow shared by the
+// platforms connected next to Twitch. Providers supply what happens with the
+// token and how the connection is removed.
+type oauthConnection struct {
+	// provider is one of the helpers.OAuthProvider* names and the route group
+	provider    string
+	displayName string
+	client      *oauthclient.Client
+	// redirectURI and allowsRedirectURI check callbacks against the
+	// provider's registered redirect URIs
+	redirectURI       func(requested, base string) (string, bool)
+	allowsRedirectURI func(uri string) bool
+	repo              analytics.Repository
+	sessions          helpers.SessionStore
+	// connected stores the token of a completed flow. On failure it returns
+	// the reason passed on to the frontend.
+	connected func(c *fiber.Ctx, session *helpers.OAuthSession, token *oauthclient.Token) (reason string)
+	// disconnect removes the connection of a user, false when there is none
+	disconnect func(c *fiber.Ctx, userID string) (bool, error)
+	// limits keeps connecting platforms besides Twitch to plans with them
+	limits *billing.Limits
+}
+
+// UsePlanLimits gates connecting on the user's plan. Disconnecting stays
+// allowed. Call it before RegisterRoutes.
+func (h *oauthConnection) UsePlanLimits(limits *billing.Limits) {
+	h.limits = limits
+}
+
+// RegisterRoutes registers the connection routes on the protected API group.
+// The callback is public, see CallbackHandler.
+func (h *oauthConnection) RegisterRoutes(router fiber.Router) {
+	group := router.Group("/" + h.provider)
+	group.Get("/connect", h.limits.Require(billing.FeatureMultiPlatform), h.ConnectHandler)
+	group.Delete("/connect", h.DisconnectHandler)
+}
+
+// ConnectHandler starts the OAuth flow and returns the authorization URL. It
+// takes the same redirect_uri and return_to parameters as the Twitch flow.
+func (h *oauthConnection) ConnectHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	redirectURI, ok := h.redirectURI(c.Query("redirect_uri"), c.BaseURL())
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("redirect_uri is not a registered %s callback", h.displayName),
+		})
+	}
+
+	frontendURL, ok := config.Frontend().FrontendOrigin(c.Query("return_to", c.Get(fiber.HeaderOrigin)))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "return_to is not an allowed frontend",
+		})
+	}
+
+	state, err := helpers.GenerateOAuthState()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to start %s authorization", h.displayName),
+		})
+	}
+
+	scopes := h.client.Scopes()
+	if err := h.sessions.Set(state, &helpers.OAuthSession{
+		Provider:    h.provider,
+		UserID:      user.ID,
+		Scopes:      scopes,
+		CreatedAt:   time.Now(),
+		RedirectURI: redirectURI,
+		FrontendURL: frontendURL,
+	}); err != nil {
+		log.Printf("Failed to save OAuth session for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to start %s authorization", h.displayName),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"auth_url":     h.client.AuthorizeURL(redirectURI, state),
+		"scopes":       scopes,
+		"redirect_uri": redirectURI,
+		"return_to":    frontendURL,
+	})
+}
+
+// CallbackHandler completes the OAuth flow. Like the Twitch callback it is
+// public, the user is identified by the single-use state parameter.
+func (h *oauthConnection) CallbackHandler(c *fiber.Ctx) error {
+	code := c.Query("code")
+	state := c.Query("state")
+
+	var session *helpers.OAuthSession
+	if state != "" {
+		session, _ = h.sessions.Get(state)
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		log.Printf("%s authorization denied: %s (%s)", h.displayName, errParam, c.Query("error_description"))
+		return h.redirectToFrontend(c, session, "error", errParam)
+	}
+	if code == "" || state == "" {
+		return h.redirectToFrontend(c, session, "error", "missing_code")
+	}
+	if session == nil || session.Provider != h.provider {
+		log.Printf("%s callback with unknown, expired or already used state", h.displayName)
+		return h.redirectToFrontend(c, nil, "error", "invalid_state")
+	}
+	if !h.allowsRedirectURI(session.RedirectURI) {
+		log.Printf("%s callback for user %s with unregistered redirect URI %q", h.displayName, session.UserID, session.RedirectURI)
+		return h.redirectToFrontend(c, session, "error", "invalid_redirect")
+	}
+
+	ctx := c.UserContext()
+	existing, err := h.repo.GetUserByClerkID(ctx, session.UserID)
+	if err != nil || existing == nil {
+		// The token references the users row created when Twitch was connected
+		log.Printf("Failed to look up user record for %s: %v", session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "user_lookup_failed")
+	}
+
+	token, err := h.client.ExchangeCode(ctx, code, session.RedirectURI)
+	if err != nil {
+		log.Printf("Failed to exchange %s code for user %s: %v", h.displayName, session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "token_exchange_failed")
+	}
+
+	if reason := h.connected(c, session, token); reason != "" {
+		return h.redirectToFrontend(c, session, "error", reason)
+	}
+	return h.redirectToFrontend(c, session, "connected", "")
+}
+
+// DisconnectHandler removes the connection
+func (h *oauthConnection) DisconnectHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	found, err := h.disconnect(c, user.ID)
+	if err != nil {
+		log.Printf("Failed to disconnect %s for user %s: %v", h.displayName, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to disconnect %s", h.displayName),
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("%s is not connected", h.displayName),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "disconnected",
+	})
+}
+
+func (h *oauthConnection) redirectToFrontend(c *fiber.Ctx, session *helpers.OAuthSession, status, reason string) error {
+	return redirectToDashboard(c, session, h.provider, status, reason)
+}
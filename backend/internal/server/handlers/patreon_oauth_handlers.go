@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+	"github.com/baldybuilds/creatorsync/internal/patreon"
+	"github.com/baldybuilds/creatorsync/internal/server/helpers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PatreonOAuthHandlers connects a creator's Patreon campaign, whose membership
+// income is added to revenue analytics
+type PatreonOAuthHandlers struct {
+	*oauthConnection
+	repo          analytics.Repository
+	collector     *analytics.PatreonCollector
+	patreonClient *patreon.Client
+	audit         *audit.Logger
+	invalidator   *cache.Invalidator
+}
+
+func NewPatreonOAuthHandlers(repo analytics.Repository, patreonClient *patreon.Client, sessions helpers.SessionStore, auditLog *audit.Logger, invalidator *cache.Invalidator) *PatreonOAuthHandlers {
+	h := &PatreonOAuthHandlers{
+		repo:          repo,
+		collector:     analytics.NewPatreonCollector(repo, patreonClient),
+		patreonClient: patreonClient,
+		audit:         auditLog,
+		invalidator:   invalidator,
+	}
+	h.oauthConnection = &oauthConnection{
+		provider:          helpers.OAuthProviderPatreon,
+		displayName:       "Patreon",
+		client:            patreonClient.Client,
+		redirectURI:       config.Patreon().RedirectURI,
+		allowsRedirectURI: config.Patreon().AllowsRedirectURI,
+		repo:              repo,
+		sessions:          sessions,
+		connected:         h.connected,
+		disconnect:        h.disconnect,
+	}
+	return h
+}
+
+// connected stores the token with its campaign and collects the first income
+// snapshot
+func (h *PatreonOAuthHandlers) connected(c *fiber.Ctx, session *helpers.OAuthSession, token *oauthclient.Token) string {
+	ctx := c.UserContext()
+	patreonUser, err := h.patreonClient.GetIdentity(ctx, token.AccessToken)
+	if err != nil {
+		log.Printf("Failed to get Patreon user for user %s: %v", session.UserID, err)
+		return "user_lookup_failed"
+	}
+
+	campaign, err := h.patreonClient.GetCampaign(ctx, token.AccessToken)
+	if err != nil {
+		log.Printf("Failed to get Patreon campaign for user %s: %v", session.UserID, err)
+		return "campaign_lookup_failed"
+	}
+	if campaign == nil {
+		log.Printf("Patreon account %s of user %s has no campaign", patreonUser.ID, session.UserID)
+		return "no_campaign"
+	}
+
+	if err := h.collector.StoreToken(ctx, session.UserID, patreonUser.ID, campaign.ID, token); err != nil {
+		log.Printf("Failed to store Patreon token for user %s: %v", session.UserID, err)
+		return "token_storage_failed"
+	}
+	h.audit.RecordRequest(c, session.UserID, audit.ActionPatreonTokenStore, patreonUser.ID, map[string]any{
+		"campaign_id": campaign.ID,
+		"scopes":      token.Scope,
+	})
+
+	// Income shows up right away instead of after the next daily collection
+	if err := h.collector.CollectUser(ctx, session.UserID); err != nil {
+		log.Printf("Failed to collect Patreon income for user %s: %v", session.UserID, err)
+	}
+	h.invalidator.InvalidateUser(session.UserID)
+
+	log.Printf("✅ Stored Patreon token for user %s (campaign %s)", session.UserID, campaign.ID)
+	return ""
+}
+
+// disconnect removes the Patreon connection and its income snapshots
+func (h *PatreonOAuthHandlers) disconnect(c *fiber.Ctx, userID string) (bool, error) {
+	ctx := c.UserContext()
+	token, err := h.repo.GetPatreonToken(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up connection: %w", err)
+	}
+	if token == nil {
+		return false, nil
+	}
+
+	if err := h.repo.DeletePatreonConnection(ctx, userID); err != nil {
+		return false, err
+	}
+	h.audit.RecordRequest(c, userID, audit.ActionPatreonTokenDelete, token.PatreonUserID, map[string]any{
+		"campaign_id": token.CampaignID,
+	})
+	h.invalidator.InvalidateUser(userID)
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+	"github.com/baldybuilds/creatorsync/internal/server/helpers"
+	"github.com/baldybuilds/creatorsync/internal/streamlabs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// StreamlabsOAuthHandlers connects a creator's Streamlabs account, whose tips
+// are added to revenue analytics
+type StreamlabsOAuthHandlers struct {
+	*oauthConnection
+	repo             analytics.Repository
+	collector        *analytics.StreamlabsCollector
+	streamlabsClient *streamlabs.Client
+	audit            *audit.Logger
+	invalidator      *cache.Invalidator
+}
+
+func NewStreamlabsOAuthHandlers(repo analytics.Repository, streamlabsClient *streamlabs.Client, sessions helpers.SessionStore, auditLog *audit.Logger, invalidator *cache.Invalidator) *StreamlabsOAuthHandlers {
+	h := &StreamlabsOAuthHandlers{
+		repo:             repo,
+		collector:        analytics.NewStreamlabsCollector(repo, streamlabsClient),
+		streamlabsClient: streamlabsClient,
+		audit:            auditLog,
+		invalidator:      invalidator,
+	}
+	h.oauthConnection = &oauthConnection{
+		provider:          helpers.OAuthProviderStreamlabs,
+		displayName:       "Streamlabs",
+		client:            streamlabsClient.Client,
+		redirectURI:       config.Streamlabs().RedirectURI,
+		allowsRedirectURI: config.Streamlabs().AllowsRedirectURI,
+		repo:              repo,
+		sessions:          sessions,
+		connected:         h.connected,
+		disconnect:        h.disconnect,
+	}
+	return h
+}
+
+// connected stores the token and imports the user's tips
+func (h *StreamlabsOAuthHandlers) connected(c *fiber.Ctx, session *helpers.OAuthSession, token *oauthclient.Token) string {
+	ctx := c.UserContext()
+	streamlabsUser, err := h.streamlabsClient.GetUser(ctx, token.AccessToken)
+	if err != nil {
+		log.Printf("Failed to get Streamlabs user for user %s: %v", session.UserID, err)
+		return "user_lookup_failed"
+	}
+
+	if err := h.collector.StoreToken(ctx, session.UserID, streamlabsUser.ID, token); err != nil {
+		log.Printf("Failed to store Streamlabs token for user %s: %v", session.UserID, err)
+		return "token_storage_failed"
+	}
+	h.audit.RecordRequest(c, session.UserID, audit.ActionStreamlabsTokenStore, streamlabsUser.ID, nil)
+
+	// Past tips show up right away instead of after the next daily collection
+	if err := h.collector.CollectUser(ctx, session.UserID); err != nil {
+		log.Printf("Failed to collect Streamlabs tips for user %s: %v", session.UserID, err)
+	}
+	h.invalidator.InvalidateUser(session.UserID)
+
+	log.Printf("✅ Stored Streamlabs token for user %s (account %s)", session.UserID, streamlabsUser.ID)
+	return ""
+}
+
+// disconnect removes the Streamlabs connection and its tips
+func (h *StreamlabsOAuthHandlers) disconnect(c *fiber.Ctx, userID string) (bool, error) {
+	ctx := c.UserContext()
+	token, err := h.repo.GetStreamlabsToken(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up connection: %w", err)
+	}
+	if token == nil {
+		return false, nil
+	}
+
+	if err := h.repo.DeleteStreamlabsConnection(ctx, userID); err != nil {
+		return false, err
+	}
+	h.audit.RecordRequest(c, userID, audit.ActionStreamlabsTokenDelete, token.StreamlabsUserID, nil)
+	h.invalidator.InvalidateUser(userID)
+	return true, nil
+}
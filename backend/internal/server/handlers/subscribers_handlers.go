@@ -26,7 +26,7 @@ func GetTwitchSubscribersHandler(c *fiber.Ctx) error {
 	limit := 20       // Default limit
 	afterCursor := "" // Default: no cursor
 
-	subscriptionsResponse, err := twitchClient.GetBroadcasterSubscribers(c.Context(), twitchToken, twitchUserID, limit, afterCursor)
+	subscriptionsResponse, err := twitchClient.GetBroadcasterSubscribers(c.UserContext(), twitchToken, twitchUserID, limit, afterCursor)
 	if err != nil {
 		// Consider if more specific error handling from Twitch API is needed here
 		// For example, distinguishing between a 401 (bad token, though helper should catch most) vs 403 (no permission) vs 500
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwitchEventSubHandlers receives Twitch EventSub webhook deliveries
+type TwitchEventSubHandlers struct {
+	repo analytics.Repository
+}
+
+func NewTwitchEventSubHandlers(repo analytics.Repository) *TwitchEventSubHandlers {
+	return &TwitchEventSubHandlers{
+		repo: repo,
+	}
+}
+
+// WebhookHandler verifies the delivery signature, answers the callback
+// verification challenge and stores notifications. Twitch retries anything
+// that isn't a 2xx, so only signature failures are rejected.
+func (h *TwitchEventSubHandlers) WebhookHandler(c *fiber.Ctx) error {
+	cfg := config.EventSub()
+	if !cfg.Enabled() {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	body := c.Body()
+	if !twitch.VerifyEventSubSignature(cfg.Secret,
+		c.Get(twitch.EventSubMessageIDHeader),
+		c.Get(twitch.EventSubMessageTimestampHeader),
+		c.Get(twitch.EventSubMessageSignatureHeader),
+		body, time.Now()) {
+		log.Printf("⚠️ Rejected EventSub delivery with invalid signature")
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+
+	var message twitch.EventSubMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid EventSub message",
+		})
+	}
+
+	ctx := c.UserContext()
+	switch c.Get(twitch.EventSubMessageTypeHeader) {
+	case twitch.EventSubMessageVerification:
+		if err := h.repo.UpdateEventSubSubscriptionStatus(ctx, message.Subscription.ID, "enabled"); err != nil {
+			log.Printf("Failed to mark EventSub subscription %s enabled: %v", message.Subscription.ID, err)
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlain)
+		return c.SendString(message.Challenge)
+
+	case twitch.EventSubMessageRevocation:
+		log.Printf("EventSub subscription %s revoked: %s", message.Subscription.ID, message.Subscription.Status)
+		if err := h.repo.UpdateEventSubSubscriptionStatus(ctx, message.Subscription.ID, message.Subscription.Status); err != nil {
+			log.Printf("Failed to record EventSub revocation for %s: %v", message.Subscription.ID, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+
+	case twitch.EventSubMessageNotification:
+		if err := h.handleNotification(c, &message); err != nil {
+			// Let Twitch redeliver
+			log.Printf("Failed to handle %s notification: %v", message.Subscription.Type, err)
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// handleNotification stores a notification by subscription type. Undecodable
+// events are logged and dropped since redelivering them won't help.
+func (h *TwitchEventSubHandlers) handleNotification(c *fiber.Ctx, message *twitch.EventSubMessage) error {
+	switch message.Subscription.Type {
+	case twitch.EventSubRedemptionAdd:
+		var event twitch.RedemptionEvent
+		if err := json.Unmarshal(message.Event, &event); err != nil {
+			log.Printf("Failed to decode redemption event: %v", err)
+			return nil
+		}
+		return analytics.SaveRedemptionEvent(c.UserContext(), h.repo, &event)
+
+	case twitch.EventSubChannelBan:
+		var event twitch.BanEvent
+		if err := json.Unmarshal(message.Event, &event); err != nil {
+			log.Printf("Failed to decode ban event: %v", err)
+			return nil
+		}
+		return analytics.SaveBanEvent(c.UserContext(), h.repo, c.Get(twitch.EventSubMessageIDHeader), &event)
+
+	case twitch.EventSubChannelRaid:
+		var event twitch.RaidEvent
+		if err := json.Unmarshal(message.Event, &event); err != nil {
+			log.Printf("Failed to decode raid event: %v", err)
+			return nil
+		}
+		return analytics.SaveRaidEvent(c.UserContext(), h.repo, c.Get(twitch.EventSubMessageIDHeader), &event)
+
+	case twitch.EventSubChannelFollow:
+		var event twitch.FollowEvent
+		if err := json.Unmarshal(message.Event, &event); err != nil {
+			log.Printf("Failed to decode follow event: %v", err)
+			return nil
+		}
+		return analytics.SaveFollowEvent(c.UserContext(), h.repo, c.Get(twitch.EventSubMessageIDHeader), &event)
+	}
+
+	return nil
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/server/helpers"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwitchOAuthHandlers implements the server-side Twitch OAuth flow used to grant
+// (or re-grant) the scopes CreatorSync needs
+type TwitchOAuthHandlers struct {
+	repo         analytics.Repository
+	tokens       *analytics.TwitchTokenHelper
+	twitchClient *twitch.Client
+	sessions     helpers.SessionStore
+	audit        *audit.Logger
+	// invalidator evicts cached data about a user whose token changed
+	invalidator *cache.Invalidator
+}
+
+func NewTwitchOAuthHandlers(repo analytics.Repository, twitchClient *twitch.Client, sessions helpers.SessionStore, auditLog *audit.Logger, invalidator *cache.Invalidator) *TwitchOAuthHandlers {
+	return &TwitchOAuthHandlers{
+		repo:         repo,
+		tokens:       analytics.NewTwitchTokenHelper(repo, twitchClient),
+		twitchClient: twitchClient,
+		sessions:     sessions,
+		audit:        auditLog,
+		invalidator:  invalidator,
+	}
+}
+
+// ConnectHandler starts the OAuth flow and returns the Twitch authorization URL.
+// Pass force_verify=true to make Twitch show the consent screen again, e.g. when
+// /api/twitch/scopes reports missing scopes.
+func (h *TwitchOAuthHandlers) ConnectHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		ForceVerify bool `query:"force_verify"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	frontend := config.Frontend()
+	if len(frontend.TwitchRedirectURIs) == 0 {
+		log.Println("Error: TWITCH_REDIRECT_URI environment variable not set.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "twitch client configuration error",
+		})
+	}
+	redirectURI, ok := frontend.TwitchRedirectURI(c.Query("redirect_uri"), c.BaseURL())
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "redirect_uri is not a registered Twitch callback",
+		})
+	}
+
+	// Preview deployments send the user back to the frontend they came from
+	frontendURL, ok := frontend.FrontendOrigin(c.Query("return_to", c.Get(fiber.HeaderOrigin)))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "return_to is not an allowed frontend",
+		})
+	}
+
+	state, err := helpers.GenerateOAuthState()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start Twitch authorization",
+		})
+	}
+
+	scopes := twitch.RequiredScopes()
+	if err := h.sessions.Set(state, &helpers.OAuthSession{
+		Provider:    helpers.OAuthProviderTwitch,
+		UserID:      user.ID,
+		Scopes:      scopes,
+		CreatedAt:   time.Now(),
+		RedirectURI: redirectURI,
+		FrontendURL: frontendURL,
+	}); err != nil {
+		log.Printf("Failed to save OAuth session for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start Twitch authorization",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"auth_url":     h.twitchClient.AuthorizeURL(redirectURI, state, scopes, query.ForceVerify),
+		"scopes":       scopes,
+		"force_verify": query.ForceVerify,
+		"redirect_uri": redirectURI,
+		"return_to":    frontendURL,
+	})
+}
+
+// CallbackHandler completes the OAuth flow. It is a public route because Twitch
+// redirects the browser here without our Authorization header; the user is
+// identified by the single-use state parameter instead.
+func (h *TwitchOAuthHandlers) CallbackHandler(c *fiber.Ctx) error {
+	code := c.Query("code")
+	state := c.Query("state")
+
+	// Get consumes the session, so a replayed callback is rejected. A denied
+	// flow is over too, and its session says which frontend to return to.
+	var session *helpers.OAuthSession
+	if state != "" {
+		session, _ = h.sessions.Get(state)
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		log.Printf("Twitch authorization denied: %s (%s)", errParam, c.Query("error_description"))
+		return h.redirectToFrontend(c, session, "error", errParam)
+	}
+	if code == "" || state == "" {
+		return h.redirectToFrontend(c, session, "error", "missing_code")
+	}
+	if session == nil || session.Provider != helpers.OAuthProviderTwitch {
+		log.Printf("Twitch callback with unknown, expired or already used state")
+		return h.redirectToFrontend(c, nil, "error", "invalid_state")
+	}
+
+	// The exchange repeats the callback the flow was authorized with, as long
+	// as it is still configured
+	frontend := config.Frontend()
+	redirectURI := session.RedirectURI
+	if redirectURI == "" && len(frontend.TwitchRedirectURIs) > 0 {
+		redirectURI = frontend.TwitchRedirectURIs[0]
+	}
+	if !frontend.AllowsTwitchRedirectURI(redirectURI) {
+		log.Printf("Twitch callback for user %s with unregistered redirect URI %q", session.UserID, redirectURI)
+		return h.redirectToFrontend(c, session, "error", "invalid_redirect")
+	}
+
+	ctx := c.UserContext()
+	token, err := h.twitchClient.ExchangeCode(ctx, code, redirectURI)
+	if err != nil {
+		log.Printf("Failed to exchange Twitch code for user %s: %v", session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "token_exchange_failed")
+	}
+
+	twitchUser, err := h.twitchClient.GetUserInfo(ctx, token.AccessToken)
+	if err != nil {
+		log.Printf("Failed to get Twitch user for user %s: %v", session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "user_lookup_failed")
+	}
+
+	existing, err := h.repo.GetUserByClerkID(ctx, session.UserID)
+	if err != nil {
+		log.Printf("Failed to look up user record for %s: %v", session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "user_sync_failed")
+	}
+	if existing != nil && existing.TwitchUserID != "" && existing.TwitchUserID != twitchUser.ID {
+		// The previous account's analytics are set aside before the new account
+		// is linked, so a failed cleanup leaves the user on the previous account
+		job, archive, err := analytics.CleanupTwitchAccount(ctx, h.repo, session.UserID, existing.TwitchUserID, analytics.CleanupAccountSwitch)
+		if err != nil {
+			log.Printf("Failed to clean up previous Twitch account %s of user %s: %v", existing.TwitchUserID, session.UserID, err)
+			return h.redirectToFrontend(c, session, "error", "cleanup_failed")
+		}
+		log.Printf("🔀 User %s switched Twitch account from %s to %s", session.UserID, existing.TwitchUserID, twitchUser.ID)
+		h.audit.RecordRequest(c, session.UserID, audit.ActionTwitchAccountSwitch, twitchUser.ID, map[string]any{
+			"previous_twitch_user_id": existing.TwitchUserID,
+			"login":                   twitchUser.Login,
+			"cleanup_job_id":          job.ID,
+			"archive_id":              archive.ID,
+			"archived_rows":           archive.RowCount,
+		})
+	}
+
+	if err := h.ensureUser(ctx, existing, session.UserID, twitchUser); err != nil {
+		log.Printf("Failed to ensure user record for %s: %v", session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "user_sync_failed")
+	}
+
+	if err := h.tokens.StoreToken(ctx, session.UserID, twitchUser.ID, token); err != nil {
+		log.Printf("Failed to store Twitch token for user %s: %v", session.UserID, err)
+		return h.redirectToFrontend(c, session, "error", "token_storage_failed")
+	}
+	h.audit.RecordRequest(c, session.UserID, audit.ActionTwitchTokenStore, twitchUser.ID, map[string]any{
+		"login":  twitchUser.Login,
+		"scopes": token.Scope,
+	})
+	h.invalidator.InvalidateUser(session.UserID)
+
+	// A fresh token un-parks collections that failed on auth
+	if err := h.repo.DeleteCollectionRetries(ctx, session.UserID); err != nil {
+		log.Printf("Failed to clear collection retries for user %s: %v", session.UserID, err)
+	}
+
+	if err := analytics.EnsureEventSubscriptions(ctx, h.repo, h.twitchClient, session.UserID, twitchUser.ID); err != nil {
+		log.Printf("Failed to set up EventSub for user %s: %v", session.UserID, err)
+	}
+
+	missing := twitch.MissingScopes(session.Scopes, token.Scope)
+	if len(missing) > 0 {
+		log.Printf("⚠️ User %s connected Twitch without scopes: %v", session.UserID, missing)
+	}
+
+	log.Printf("✅ Stored Twitch token for user %s (%s) with %d scopes", session.UserID, twitchUser.Login, len(token.Scope))
+	return h.redirectToFrontend(c, session, "connected", "")
+}
+
+// DisconnectHandler removes the Twitch connection and sets the analytics of
+// its account aside, restorable by reconnecting that account within
+// DISCONNECT_RESTORE_WINDOW. The cleanup runs before responding, as a
+// "twitch_cleanup" job in one transaction, and the response reports its outcome.
+func (h *TwitchOAuthHandlers) DisconnectHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	ctx := c.UserContext()
+	twitchUserID, err := h.connectedTwitchUserID(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to look up Twitch account of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to disconnect Twitch",
+		})
+	}
+
+	job, archive, err := analytics.CleanupTwitchAccount(ctx, h.repo, user.ID, twitchUserID, analytics.CleanupDisconnect)
+	if err != nil {
+		log.Printf("Failed to disconnect Twitch for user %s: %v", user.ID, err)
+		response := fiber.Map{"error": "Failed to disconnect Twitch"}
+		if job != nil {
+			response["status"] = job.Status
+			response["job"] = job
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	log.Printf("📦 Archived %d analytics rows of user %s until %s", archive.RowCount, user.ID, archive.ExpiresAt.Format(time.RFC3339))
+
+	h.audit.RecordRequest(c, user.ID, audit.ActionTwitchTokenDelete, twitchUserID, map[string]any{
+		"cleanup_job_id": job.ID,
+		"archive_id":     archive.ID,
+		"archived_rows":  archive.RowCount,
+	})
+	h.invalidator.InvalidateUser(user.ID)
+
+	return c.JSON(fiber.Map{
+		"status":  job.Status,
+		"job":     job,
+		"archive": archive,
+	})
+}
+
+// connectedTwitchUserID returns the Twitch account of the user's stored token,
+// or else the one linked on the user, "" when there is neither
+func (h *TwitchOAuthHandlers) connectedTwitchUserID(ctx context.Context, userID string) (string, error) {
+	token, err := h.repo.GetTwitchToken(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if token != nil {
+		return token.TwitchUserID, nil
+	}
+
+	existing, err := h.repo.GetUserByClerkID(ctx, userID)
+	if err != nil || existing == nil {
+		return "", err
+	}
+	return existing.TwitchUserID, nil
+}
+
+// restorableArchive returns the archive the user's connected Twitch account
+// can restore, or nil
+func (h *TwitchOAuthHandlers) restorableArchive(ctx context.Context, userID string) (*analytics.DisconnectArchive, error) {
+	token, err := h.repo.GetTwitchToken(ctx, userID)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	return h.repo.GetRestorableDisconnectArchive(ctx, userID, token.TwitchUserID)
+}
+
+// RestoreStatusHandler reports whether analytics archived on a disconnect can
+// be restored, which needs the same Twitch account to be connected again
+func (h *TwitchOAuthHandlers) RestoreStatusHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	archive, err := h.restorableArchive(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Failed to look up disconnect archive of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to look up archived analytics",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"restorable": archive != nil,
+		"archive":    archive,
+	})
+}
+
+// RestoreHandler puts back the analytics archived when the connected Twitch
+// account was last disconnected. Data collected since is kept.
+func (h *TwitchOAuthHandlers) RestoreHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	ctx := c.UserContext()
+	archive, err := h.restorableArchive(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to look up disconnect archive of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore archived analytics",
+		})
+	}
+	if archive == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No archived analytics to restore for the connected Twitch account",
+		})
+	}
+
+	restored, err := h.repo.RestoreDisconnectArchive(ctx, archive.ID)
+	if err != nil {
+		log.Printf("Failed to restore disconnect archive %d of user %s: %v", archive.ID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore archived analytics",
+		})
+	}
+	h.audit.RecordRequest(c, user.ID, audit.ActionAnalyticsRestore, archive.TwitchUserID, map[string]any{
+		"archive_id":    archive.ID,
+		"restored_rows": restored,
+	})
+	h.invalidator.InvalidateUser(user.ID)
+	log.Printf("♻️ Restored %d of %d archived analytics rows of user %s", restored, archive.RowCount, user.ID)
+
+	return c.JSON(fiber.Map{
+		"archive_id":    archive.ID,
+		"archived_rows": archive.RowCount,
+		"restored_rows": restored,
+	})
+}
+
+// ScopesHandler compares the scopes CreatorSync requires against the scopes the
+// user's token actually has, so the frontend can prompt for re-consent
+func (h *TwitchOAuthHandlers) ScopesHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	required := twitch.RequiredScopes()
+	granted, source, err := h.tokens.GetGrantedScopes(c.UserContext(), user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to determine granted Twitch scopes: %v", err),
+		})
+	}
+
+	missing := twitch.MissingScopes(required, granted)
+
+	return c.JSON(fiber.Map{
+		"required":        required,
+		"granted":         granted,
+		"missing":         missing,
+		"needs_reconsent": len(missing) > 0,
+		"token_source":    source,
+	})
+}
+
+// ensureUser makes sure a users row exists before storing the token, which
+// references it, and links the Twitch account to it
+func (h *TwitchOAuthHandlers) ensureUser(ctx context.Context, existing *analytics.User, userID string, twitchUser *twitch.User) error {
+	user := &analytics.User{
+		ID:              userID,
+		ClerkUserID:     userID,
+		TwitchUserID:    twitchUser.ID,
+		Username:        twitchUser.Login,
+		DisplayName:     twitchUser.DisplayName,
+		Email:           twitchUser.Email,
+		ProfileImageURL: twitchUser.ProfileImageURL,
+	}
+	if existing != nil {
+		user.ID = existing.ID
+		if user.Email == "" {
+			user.Email = existing.Email
+		}
+	}
+
+	return h.repo.CreateOrUpdateUser(ctx, user)
+}
+
+// redirectToFrontend sends the user to the dashboard of the frontend the flow
+// started on, or the default one without a session
+func (h *TwitchOAuthHandlers) redirectToFrontend(c *fiber.Ctx, session *helpers.OAuthSession, status, reason string) error {
+	return redirectToDashboard(c, session, helpers.OAuthProviderTwitch, status, reason)
+}
+
+// redirectToDashboard ends an OAuth flow on the dashboard of the frontend it
+// started on, reporting its status in the provider's query parameter
+func redirectToDashboard(c *fiber.Ctx, session *helpers.OAuthSession, provider, status, reason string) error {
+	frontend := config.Frontend()
+	frontendURL := frontend.URL
+	if session != nil {
+		// The frontend may have been removed from the config since
+		if origin, ok := frontend.FrontendOrigin(session.FrontendURL); ok {
+			frontendURL = origin
+		}
+	}
+
+	params := url.Values{}
+	params.Set(provider, status)
+	if reason != "" {
+		params.Set("reason", reason)
+	}
+
+	return c.Redirect(frontendURL+"/dashboard?"+params.Encode(), fiber.StatusFound)
+}
@@ -20,7 +20,7 @@ func GetTwitchVideosHandler(c *fiber.Ctx) error {
 	// TODO: Consider adding a 'limit' query parameter from the request
 	// For now, using the previous default. This could be parsed from c.Query() before calling GetUserVideos.
 	limit := 20 // Default limit
-	videos, _, err := twitchClient.GetUserVideos(c.Context(), twitchToken, twitchUserID, limit)
+	videos, _, err := twitchClient.GetUserVideos(c.UserContext(), twitchToken, twitchUserID, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to fetch Twitch videos: %v", err),
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+	"github.com/baldybuilds/creatorsync/internal/publishing"
+	"github.com/baldybuilds/creatorsync/internal/server/helpers"
+	"github.com/baldybuilds/creatorsync/internal/youtube"
+	"github.com/gofiber/fiber/v2"
+)
+
+// YouTubeOAuthHandlers connects the YouTube channel clips are published to
+type YouTubeOAuthHandlers struct {
+	*oauthConnection
+	repo          publishing.Repository
+	tokens        *publishing.YouTubeTokens
+	youtubeClient *youtube.Client
+	audit         *audit.Logger
+}
+
+func NewYouTubeOAuthHandlers(analyticsRepo analytics.Repository, repo publishing.Repository, youtubeClient *youtube.Client, sessions helpers.SessionStore, auditLog *audit.Logger) *YouTubeOAuthHandlers {
+	h := &YouTubeOAuthHandlers{
+		repo:          repo,
+		tokens:        publishing.NewYouTubeTokens(repo, youtubeClient),
+		youtubeClient: youtubeClient,
+		audit:         auditLog,
+	}
+	h.oauthConnection = &oauthConnection{
+		provider:          helpers.OAuthProviderYouTube,
+		displayName:       "YouTube",
+		client:            youtubeClient.Client,
+		redirectURI:       config.Publishing().YouTubeRedirectURI,
+		allowsRedirectURI: config.Publishing().AllowsYouTubeRedirectURI,
+		repo:              analyticsRepo,
+		sessions:          sessions,
+		connected:         h.connected,
+		disconnect:        h.disconnect,
+	}
+	return h
+}
+
+// connected stores the token with the account's channel
+func (h *YouTubeOAuthHandlers) connected(c *fiber.Ctx, session *helpers.OAuthSession, token *oauthclient.Token) string {
+	// Users can untick scopes on Google's consent screen
+	if !strings.Contains(token.Scope, youtube.Scopes[0]) {
+		log.Printf("YouTube token of user %s lacks the upload scope: %q", session.UserID, token.Scope)
+		return "missing_scope"
+	}
+
+	ctx := c.UserContext()
+	channel, err := h.youtubeClient.GetChannel(ctx, token.AccessToken)
+	if err != nil {
+		log.Printf("Failed to get YouTube channel for user %s: %v", session.UserID, err)
+		return "channel_lookup_failed"
+	}
+	if channel == nil {
+		log.Printf("YouTube account of user %s has no channel", session.UserID)
+		return "no_channel"
+	}
+
+	if err := h.tokens.StoreToken(ctx, session.UserID, channel, token); err != nil {
+		log.Printf("Failed to store YouTube token for user %s: %v", session.UserID, err)
+		return "token_storage_failed"
+	}
+	h.audit.RecordRequest(c, session.UserID, audit.ActionYouTubeTokenStore, channel.ID, map[string]any{
+		"channel_title": channel.Title,
+		"scopes":        token.Scope,
+	})
+
+	log.Printf("✅ Stored YouTube token for user %s (channel %s)", session.UserID, channel.ID)
+	return ""
+}
+
+// disconnect removes the YouTube connection. Queued jobs fail until YouTube is
+// connected again.
+func (h *YouTubeOAuthHandlers) disconnect(c *fiber.Ctx, userID string) (bool, error) {
+	ctx := c.UserContext()
+	token, err := h.repo.GetYouTubeToken(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up connection: %w", err)
+	}
+	if token == nil {
+		return false, nil
+	}
+
+	if _, err := h.repo.DeleteYouTubeToken(ctx, userID); err != nil {
+		return false, err
+	}
+	h.audit.RecordRequest(c, userID, audit.ActionYouTubeTokenDelete, token.ChannelID, map[string]any{
+		"channel_title": token.ChannelTitle,
+	})
+	return true, nil
+}
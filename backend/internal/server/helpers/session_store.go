@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// oauthSessionTTL bounds how long a user has to complete the OAuth consent screen
+const oauthSessionTTL = 10 * time.Minute
+
+// OAuth providers a session can belong to
+const (
+	OAuthProviderTwitch     = "twitch"
+	OAuthProviderPatreon    = "patreon"
+	OAuthProviderYouTube    = "youtube"
+	OAuthProviderStreamlabs = "streamlabs"
+)
+
+// OAuthSession holds the server-side state of an in-progress OAuth flow
+type OAuthSession struct {
+	// Provider is the service the flow connects, so a state issued for one
+	// can't complete another's callback
+	Provider  string    `json:"provider"`
+	UserID    string    `json:"user_id"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	// RedirectURI is the provider callback the flow was authorized with, which
+	// the code exchange must repeat
+	RedirectURI string `json:"redirect_uri"`
+	// FrontendURL is where the user returns once the flow ends
+	FrontendURL string `json:"frontend_url"`
+}
+
+// SessionStore persists OAuth sessions keyed by the state parameter. Sessions
+// are single use: Get consumes the session it returns.
+type SessionStore interface {
+	Set(state string, session *OAuthSession) error
+	Get(state string) (*OAuthSession, bool)
+	Delete(state string)
+}
+
+// databaseSessionStore keeps sessions in Postgres so a flow started on one
+// replica can complete on another, or after a restart
+type databaseSessionStore struct {
+	db *sql.DB
+}
+
+// NewDatabaseSessionStore returns a SessionStore backed by the oauth_sessions table
+func NewDatabaseSessionStore(db *sql.DB) SessionStore {
+	return &databaseSessionStore{db: db}
+}
+
+func (s *databaseSessionStore) Set(state string, session *OAuthSession) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Drop abandoned flows so the table doesn't grow unbounded
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM oauth_sessions WHERE expires_at < NOW()`); err != nil {
+		log.Printf("Failed to prune expired OAuth sessions: %v", err)
+	}
+
+	query := `
+		INSERT INTO oauth_sessions (state_hash, user_id, scopes, created_at, expires_at, redirect_uri, frontend_url, provider)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := s.db.ExecContext(ctx, query, hashState(state), session.UserID,
+		strings.Join(session.Scopes, " "), session.CreatedAt, session.CreatedAt.Add(oauthSessionTTL),
+		session.RedirectURI, session.FrontendURL, session.Provider)
+	return err
+}
+
+// Get deletes and returns the session in one statement, so a state can't be
+// redeemed twice even by concurrent callbacks
+func (s *databaseSessionStore) Get(state string) (*OAuthSession, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		DELETE FROM oauth_sessions
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING user_id, scopes, created_at, redirect_uri, frontend_url, provider
+	`
+	var session OAuthSession
+	var scopes string
+	err := s.db.QueryRowContext(ctx, query, hashState(state)).Scan(&session.UserID, &scopes, &session.CreatedAt,
+		&session.RedirectURI, &session.FrontendURL, &session.Provider)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to load OAuth session: %v", err)
+		}
+		return nil, false
+	}
+
+	session.Scopes = strings.Fields(scopes)
+	return &session, true
+}
+
+func (s *databaseSessionStore) Delete(state string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM oauth_sessions WHERE state_hash = $1`, hashState(state)); err != nil {
+		log.Printf("Failed to delete OAuth session: %v", err)
+	}
+}
+
+// hashState keeps raw state values out of the database
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOAuthState returns a random, URL-safe state parameter
+func GenerateOAuthState() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
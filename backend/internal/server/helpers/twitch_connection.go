@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"sync"
+
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	clerkSDK "github.com/clerk/clerk-sdk-go/v2"
+)
+
+// maxConnectionEntries bounds the connection cache, expired entries are pruned
+// once it is reached
+const maxConnectionEntries = 10000
+
+// twitchConnection is what GetTwitchRequestContext looks up for a user: their
+// Clerk profile, linked Twitch account and a valid access token
+type twitchConnection struct {
+	twitchUserID string
+	accessToken  string
+	client       *twitch.Client
+	clerkUser    *clerkSDK.User
+}
+
+// connections is created on first use so the TTL is read after the
+// environment has been loaded
+var connections = sync.OnceValue(func() *cache.Memory[*twitchConnection] {
+	return cache.NewMemory[*twitchConnection](config.TwitchConnection().CacheTTL, maxConnectionEntries)
+})
+
+// InvalidateTwitchConnection drops the cached connection of userID, so the next
+// request sees a token stored or removed by the OAuth flow. It is registered
+// with the server's cache.Invalidator.
+func InvalidateTwitchConnection(userID string) {
+	connections().Delete(userID)
+}
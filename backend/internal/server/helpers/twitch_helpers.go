@@ -81,7 +81,7 @@ func ensureUserExistsInDatabase(ctx context.Context, db database.Service, userID
 				twitchClientSecret := os.Getenv("TWITCH_CLIENT_SECRET")
 				if twitchClientID != "" && twitchClientSecret != "" {
 					if twitchClient, clientErr := twitch.NewClient(twitchClientID, twitchClientSecret); clientErr == nil {
-						if userInfo, infoErr := twitchClient.GetUserInfo(token); infoErr == nil {
+						if userInfo, infoErr := twitchClient.GetUserInfo(ctx, token); infoErr == nil {
 							user.Username = userInfo.Login
 							user.DisplayName = userInfo.DisplayName
 							user.ProfileImageURL = userInfo.ProfileImageURL
@@ -114,6 +114,17 @@ func GetTwitchRequestContext(c *fiber.Ctx) (*TwitchRequestContext, error) {
 		return nil, fmt.Errorf("user not authenticated")
 	}
 
+	// Reuse a recent lookup rather than asking Clerk and the token store on every request
+	if conn, ok := connections().GetContext(c.UserContext(), user.ID); ok {
+		return &TwitchRequestContext{
+			UserID:      conn.twitchUserID,
+			AccessToken: conn.accessToken,
+			Client:      conn.client,
+			ClerkUser:   conn.clerkUser,
+			LocalUser:   user,
+		}, nil
+	}
+
 	// Get database service from fiber context
 	db, ok := c.UserContext().Value("db").(database.Service)
 	if !ok {
@@ -127,13 +138,13 @@ func GetTwitchRequestContext(c *fiber.Ctx) (*TwitchRequestContext, error) {
 
 	// If we have database access, ensure user exists before proceeding
 	if db != nil {
-		if err := ensureUserExistsInDatabase(c.Context(), db, user.ID); err != nil {
+		if err := ensureUserExistsInDatabase(c.UserContext(), db, user.ID); err != nil {
 			log.Printf("⚠️ Failed to sync user %s to database: %v", user.ID, err)
 			// Don't fail the request, just log the warning and continue
 		}
 	}
 
-	clerkUser, clerkErr := clerk.GetUserByID(c.Context(), user.ID)
+	clerkUser, clerkErr := clerk.GetUserByID(c.UserContext(), user.ID)
 	if clerkErr != nil {
 		return nil, fmt.Errorf("failed to get user profile: %v", clerkErr)
 	}
@@ -150,11 +161,6 @@ func GetTwitchRequestContext(c *fiber.Ctx) (*TwitchRequestContext, error) {
 		return nil, fmt.Errorf("twitch account not connected")
 	}
 
-	token, clerkErr := clerk.GetOAuthToken(c.Context(), user.ID, "oauth_twitch")
-	if clerkErr != nil {
-		return nil, fmt.Errorf("failed to get Twitch token: %v", clerkErr)
-	}
-
 	twitchClientID := os.Getenv("TWITCH_CLIENT_ID")
 	twitchClientSecret := os.Getenv("TWITCH_CLIENT_SECRET")
 	if twitchClientID == "" || twitchClientSecret == "" {
@@ -167,14 +173,32 @@ func GetTwitchRequestContext(c *fiber.Ctx) (*TwitchRequestContext, error) {
 		return nil, fmt.Errorf("failed to initialize Twitch client: %v", clientErr)
 	}
 
-	localUser, _ := clerk.GetUserFromContext(c)
+	// Prefer tokens from our own OAuth flow, which carry any re-consented scopes
+	var token string
+	var tokenErr error
+	if db != nil {
+		tokenHelper := analytics.NewTwitchTokenHelper(analytics.NewRepository(db.GetDB()), initializedClient)
+		token, tokenErr = tokenHelper.GetValidToken(c.UserContext(), user.ID)
+	} else {
+		token, tokenErr = clerk.GetOAuthToken(c.UserContext(), user.ID, "oauth_twitch")
+	}
+	if tokenErr != nil {
+		return nil, fmt.Errorf("failed to get Twitch token: %v", tokenErr)
+	}
+
+	connections().Set(user.ID, &twitchConnection{
+		twitchUserID: foundTwitchUserID,
+		accessToken:  token,
+		client:       initializedClient,
+		clerkUser:    clerkUser,
+	})
 
 	return &TwitchRequestContext{
 		UserID:      foundTwitchUserID,
 		AccessToken: token,
 		Client:      initializedClient,
 		ClerkUser:   clerkUser,
-		LocalUser:   localUser,
+		LocalUser:   user,
 	}, nil
 }
 
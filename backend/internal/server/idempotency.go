@@ -0,0 +1,104 @@
+package server
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks responses replayed for a repeated key
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	status      int
+	contentType string
+	body        []byte
+}
+
+// idempotencyMiddleware stores the response of each request sent with an
+// Idempotency-Key and replays it when the user sends the key again, so a
+// double-submitted POST runs once. Reusing a key for a different body is
+// rejected, and so is a duplicate arriving while the first is still running.
+// Server errors aren't stored, the request can be retried with the same key.
+func idempotencyMiddleware(cfg config.IdempotencyConfig) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	responses := cache.NewMemory[idempotentResponse](cfg.TTL, cfg.MaxEntries)
+	var (
+		mu      sync.Mutex
+		running = make(map[string]bool)
+	)
+
+	return func(c *fiber.Ctx) error {
+		idempotencyKey := c.Get(idempotencyKeyHeader)
+		user, err := clerk.GetUserFromContext(c)
+		if idempotencyKey == "" || err != nil {
+			return c.Next()
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid Idempotency-Key header",
+			})
+		}
+		key := user.ID + " " + c.Method() + " " + c.Path() + " " + idempotencyKey
+		fingerprint := sha256.Sum256(c.Body())
+
+		mu.Lock()
+		stored, ok := responses.GetContext(c.UserContext(), key)
+		if !ok && running[key] {
+			mu.Unlock()
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A request with this Idempotency-Key is still in progress",
+			})
+		}
+		if !ok {
+			running[key] = true
+		}
+		mu.Unlock()
+
+		if ok {
+			if stored.fingerprint != fingerprint {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "Idempotency-Key was already used for a different request",
+				})
+			}
+			c.Status(stored.status)
+			c.Set(fiber.HeaderContentType, stored.contentType)
+			c.Set(idempotentReplayedHeader, "true")
+			return c.Send(stored.body)
+		}
+
+		defer func() {
+			mu.Lock()
+			delete(running, key)
+			mu.Unlock()
+		}()
+
+		err = c.Next()
+		status := c.Response().StatusCode()
+		// Requests over the collection quota may be repeated once it resets
+		if err == nil && status < fiber.StatusInternalServerError && status != fiber.StatusTooManyRequests {
+			responses.Set(key, idempotentResponse{
+				fingerprint: fingerprint,
+				status:      status,
+				contentType: string(c.Response().Header.ContentType()),
+				body:        append([]byte(nil), c.Response().Body()...),
+			})
+		}
+		return err
+	}
+}
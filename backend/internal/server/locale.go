@@ -0,0 +1,113 @@
+package server
+
+import (
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// savedLocale resolves the authenticated user's saved locale for
+// i18n.UserLocale. Lookup failures fall back to Accept-Language.
+func savedLocale(repo analytics.Repository) func(c *fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		user, err := clerk.GetUserFromContext(c)
+		if err != nil {
+			return ""
+		}
+		locale, err := repo.GetUserLocale(c.UserContext(), user.ID)
+		if err != nil {
+			log.Printf("Failed to get locale of user %s: %v", user.ID, err)
+			return ""
+		}
+		return locale
+	}
+}
+
+type localeRequest struct {
+	Locale string `json:"locale"`
+}
+
+// getUserLocaleHandler returns the saved locale ("" when the browser language
+// is used) and the one this request resolved to
+func (s *FiberServer) getUserLocaleHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	saved, err := analytics.NewRepository(s.db.GetDB()).GetUserLocale(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Failed to get locale of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get locale",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"locale":    saved,
+		"effective": i18n.Locale(c),
+		"supported": i18n.Supported(),
+	})
+}
+
+// updateUserLocaleHandler saves the language used for emails, alerts, insights
+// and API messages. An empty locale goes back to the browser language.
+func (s *FiberServer) updateUserLocaleHandler(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req localeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	locale := i18n.Normalize(req.Locale)
+	if locale == "" && req.Locale != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":     "Unsupported locale",
+			"supported": i18n.Supported(),
+		})
+	}
+
+	// The locale lives on the user record, so make sure there is one
+	if err := s.ensureUserExistsInDatabase(c.UserContext(), user.ID); err != nil {
+		log.Printf("Failed to sync user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update locale",
+		})
+	}
+	if err := analytics.NewRepository(s.db.GetDB()).SetUserLocale(c.UserContext(), user.ID, locale); err != nil {
+		log.Printf("Failed to update locale of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update locale",
+		})
+	}
+
+	// Answer in the new language right away
+	effective := locale
+	if effective == "" {
+		effective = i18n.FromAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
+		if effective == "" {
+			effective = i18n.Default
+		}
+	}
+	i18n.SetLocale(c, effective)
+
+	return c.JSON(fiber.Map{
+		"locale":    locale,
+		"effective": effective,
+		"supported": i18n.Supported(),
+	})
+}
@@ -8,8 +8,12 @@ import (
 
 	"github.com/baldybuilds/creatorsync/internal/analytics"
 	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
 	"github.com/baldybuilds/creatorsync/internal/email"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/maintenance"
 	"github.com/baldybuilds/creatorsync/internal/server/handlers"
+	"github.com/baldybuilds/creatorsync/internal/tracing"
 	"github.com/baldybuilds/creatorsync/internal/twitch"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,25 +21,36 @@ import (
 )
 
 func (s *FiberServer) RegisterFiberRoutes() {
-	env := os.Getenv("APP_ENV")
-	allowedOrigins := "*"
+	// The environment's frontends, FRONTEND_URL and FRONTEND_ORIGINS, e.g.
+	// preview deployments
+	frontend := config.Frontend()
 
-	if env == "production" {
-		allowedOrigins = "https://creatorsync.app,https://www.creatorsync.app"
-	} else if env == "staging" {
-		allowedOrigins = "https://dev.creatorsync.app"
-	} else {
-		allowedOrigins = "http://localhost:3000,http://localhost:5173,http://localhost:5174"
-	}
+	// Trace each request, continuing the caller's trace when it sends one
+	s.App.Use(tracing.Middleware())
 
 	s.App.Use(cors.New(cors.Config{
-		AllowOrigins:     allowedOrigins,
+		AllowOriginsFunc: frontend.AllowsOrigin,
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
-		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowHeaders:     "Accept,Authorization,Content-Type,X-API-Key,Idempotency-Key,traceparent,tracestate",
 		AllowCredentials: true, // Enable credentials support for cross-origin requests
 		MaxAge:           300,
 	}))
 
+	// Compress responses for clients that accept it. It runs before the other
+	// middleware so they see the uncompressed body.
+	s.App.Use(compressionMiddleware(config.Compression()))
+
+	// Translate error messages into the request's language
+	s.App.Use(i18n.Middleware())
+
+	// Reject writes while in read-only maintenance, except to switch it off
+	s.App.Use(s.maintenance.Middleware(maintenance.Path))
+
+	// Count each user's API requests, after the routes authenticated them
+	if s.usageRecorder != nil {
+		s.App.Use(s.usageRecorder.Middleware())
+	}
+
 	// Add middleware to inject database service into context
 	s.App.Use(func(c *fiber.Ctx) error {
 		c.Locals("db", s.db)
@@ -47,18 +62,123 @@ func (s *FiberServer) RegisterFiberRoutes() {
 	s.App.Get("/health", s.healthHandler)
 	s.App.Post("/api/waitlist", s.joinWaitlistHandler)
 
+	// Twitch redirects the browser here, so it can't carry our Authorization header
+	s.App.Get("/api/auth/twitch/callback", s.twitchOAuthHandlers.CallbackHandler)
+	s.App.Get("/api/twitch/callback", s.legacyTwitchCallbackHandler)
+	if s.patreonOAuthHandlers != nil {
+		s.App.Get("/api/auth/patreon/callback", s.patreonOAuthHandlers.CallbackHandler)
+	}
+	if s.streamlabsOAuthHandlers != nil {
+		s.App.Get("/api/auth/streamlabs/callback", s.streamlabsOAuthHandlers.CallbackHandler)
+	}
+	if s.youtubeOAuthHandlers != nil {
+		s.App.Get("/api/auth/youtube/callback", s.youtubeOAuthHandlers.CallbackHandler)
+	}
+
+	// Twitch EventSub deliveries are authenticated by their HMAC signature
+	s.App.Post("/api/webhooks/twitch/eventsub", s.eventSubHandlers.WebhookHandler)
+	// and Stripe's subscription events by theirs
+	if s.billingHandlers != nil {
+		s.App.Post("/api/webhooks/stripe", s.billingHandlers.WebhookHandler)
+	}
+
+	// OBS overlays poll this with a share token instead of a session
+	s.App.Get("/api/public/overlay/:token", s.overlayHandlers.PublicOverlay)
+
+	// Opt-in creator stats for embeddable media kit pages
+	s.App.Get("/api/public/creators/:slug", s.publicProfileHandlers.PublicStats)
+
+	// Resized, cached video thumbnails for <img> tags
+	s.App.Get("/api/media/thumbnails/:videoID", s.mediaHandlers.Thumbnail)
+
+	// Profiles and runtime stats for investigating regressions (DEBUG_TOKEN)
+	if debug := config.Debug(); debug.Enabled() {
+		s.registerDebugRoutes(debug.Token)
+	}
+
 	// Register Analytics routes (includes both public and protected routes)
 	s.registerAnalyticsRoutes()
 
 	// Protected routes group
 	api := s.App.Group("/api")
 	api.Use(clerk.AuthMiddleware())
+	api.Use(s.userLocale)
 
 	// User routes
 	api.Get("/user", s.getCurrentUserHandler)
 	api.Get("/user/profile", s.getUserProfileHandler)
 	api.Post("/user/sync", s.syncUserHandler)
+	api.Get("/user/locale", s.getUserLocaleHandler)
+	api.Put("/user/locale", s.updateUserLocaleHandler)
+
+	// Everything the dashboard loads on start, in one request
+	api.Get("/bootstrap", s.bootstrapHandler)
+
+	// Dashboard layout, default date range and theme
+	s.preferencesHandlers.RegisterRoutes(api)
+
+	// API key management (Clerk session only)
+	s.apiKeyHandlers.RegisterRoutes(api)
+
+	// Overlay share token management
+	s.overlayHandlers.RegisterRoutes(api)
+
+	// Public creator page settings
+	s.publicProfileHandlers.RegisterRoutes(api)
 
+	// In-app alerts and alert rules
+	s.alertHandlers.RegisterRoutes(api)
+
+	// Saved reports, their runs and downloads
+	s.reportHandlers.RegisterRoutes(api)
+
+	// Content calendar of planned streams and uploads
+	s.calendarHandlers.RegisterRoutes(api)
+
+	// Public Twitch channels watched to compare growth against
+	s.watchlistHandlers.RegisterRoutes(api)
+
+	// Viewers of the top games on Twitch over time
+	s.trendHandlers.RegisterRoutes(api)
+
+	// Title, description and tag templates for cross-posting
+	s.templateHandlers.RegisterRoutes(api)
+
+	// API requests, collections and exports by day and endpoint
+	s.usageHandlers.RegisterRoutes(api)
+
+	// Plans, Stripe Checkout and the customer portal
+	if s.billingHandlers != nil {
+		s.billingHandlers.RegisterRoutes(api)
+	}
+
+	// Patreon connection for revenue analytics
+	if s.patreonOAuthHandlers != nil {
+		s.patreonOAuthHandlers.RegisterRoutes(api)
+	}
+
+	// Streamlabs connection for tips in revenue analytics
+	if s.streamlabsOAuthHandlers != nil {
+		s.streamlabsOAuthHandlers.RegisterRoutes(api)
+	}
+
+	// Clip downloads and vertical transcodes
+	if s.renderHandlers != nil {
+		s.renderHandlers.RegisterRoutes(api)
+	}
+
+	// YouTube connection and the queue publishing clips to it
+	if s.publishingHandlers != nil {
+		s.youtubeOAuthHandlers.RegisterRoutes(api)
+		s.publishingHandlers.RegisterRoutes(api)
+	}
+
+	// Admin-only routes (ADMIN_USER_IDS)
+	admin := api.Group("/admin", requireAdmin)
+	s.auditHandlers.RegisterRoutes(admin)
+	s.analyticsHandlers.RegisterAdminRoutes(admin)
+	s.maintenanceHandlers.RegisterRoutes(admin)
+	s.backgroundHandlers.RegisterRoutes(admin)
 
 	// Register Twitch routes
 	s.registerTwitchRoutes(api)
@@ -72,8 +192,17 @@ func (s *FiberServer) HelloWorldHandler(c *fiber.Ctx) error {
 	return c.JSON(resp)
 }
 
+// healthHandler reports 503 while the database is down, so load balancers
+// can tell. Analytics are then served from cache where possible.
 func (s *FiberServer) healthHandler(c *fiber.Ctx) error {
-	return c.JSON(s.db.Health())
+	stats := s.db.Health()
+	if stats["status"] != "up" {
+		if config.Degraded().Enabled {
+			stats["degraded"] = "serving cached analytics"
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(stats)
+	}
+	return c.JSON(stats)
 }
 
 func (s *FiberServer) joinWaitlistHandler(c *fiber.Ctx) error {
@@ -132,13 +261,13 @@ func (s *FiberServer) getUserProfileHandler(c *fiber.Ctx) error {
 	}
 
 	// Ensure user exists in our database before returning profile
-	if err := s.ensureUserExistsInDatabase(c.Context(), user.ID); err != nil {
+	if err := s.ensureUserExistsInDatabase(c.UserContext(), user.ID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to sync user data: %v", err),
 		})
 	}
 
-	clerkUser, err := clerk.GetUserByID(c.Context(), user.ID)
+	clerkUser, err := clerk.GetUserByID(c.UserContext(), user.ID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to get user profile: %v", err),
@@ -208,7 +337,7 @@ func (s *FiberServer) ensureUserExistsInDatabase(ctx context.Context, userID str
 				twitchClientSecret := os.Getenv("TWITCH_CLIENT_SECRET")
 				if twitchClientID != "" && twitchClientSecret != "" {
 					if twitchClient, clientErr := twitch.NewClient(twitchClientID, twitchClientSecret); clientErr == nil {
-						if userInfo, infoErr := twitchClient.GetUserInfo(token); infoErr == nil {
+						if userInfo, infoErr := twitchClient.GetUserInfo(ctx, token); infoErr == nil {
 							user.Username = userInfo.Login
 							user.DisplayName = userInfo.DisplayName
 							user.ProfileImageURL = userInfo.ProfileImageURL
@@ -241,7 +370,7 @@ func (s *FiberServer) syncUserHandler(c *fiber.Ctx) error {
 	}
 
 	// Ensure user exists in our database
-	if err := s.ensureUserExistsInDatabase(c.Context(), user.ID); err != nil {
+	if err := s.ensureUserExistsInDatabase(c.UserContext(), user.ID); err != nil {
 		log.Printf("Failed to sync user %s: %v", user.ID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to sync user data: %v", err),
@@ -254,15 +383,29 @@ func (s *FiberServer) syncUserHandler(c *fiber.Ctx) error {
 	})
 }
 
+// legacyTwitchCallbackHandler sends callbacks for the old redirect URI to the
+// state-validated OAuth flow, keeping the query string intact
+func (s *FiberServer) legacyTwitchCallbackHandler(c *fiber.Ctx) error {
+	target := "/api/auth/twitch/callback"
+	if query := string(c.Request().URI().QueryString()); query != "" {
+		target += "?" + query
+	}
+	return c.Redirect(target, fiber.StatusFound)
+}
+
 func (s *FiberServer) registerTwitchRoutes(api fiber.Router) {
 	twitchGroup := api.Group("/twitch")
 	twitchGroup.Get("/channel", handlers.GetTwitchChannelHandler)
 	twitchGroup.Get("/streams", handlers.GetTwitchStreamsHandler)
 	twitchGroup.Get("/videos", handlers.GetTwitchVideosHandler)
 	twitchGroup.Get("/clips", handlers.GetTwitchClipsHandler)
-	twitchGroup.Get("/callback", handlers.TwitchCallbackHandler)
 	twitchGroup.Get("/subscribers", handlers.GetTwitchSubscribersHandler)
 	twitchGroup.Get("/analytics/video_summary", handlers.GetTwitchVideoAnalyticsSummaryHandler)
+	twitchGroup.Get("/connect", s.twitchOAuthHandlers.ConnectHandler)
+	twitchGroup.Delete("/connect", s.twitchOAuthHandlers.DisconnectHandler)
+	twitchGroup.Get("/reconnect-restore", s.twitchOAuthHandlers.RestoreStatusHandler)
+	twitchGroup.Post("/reconnect-restore", s.twitchOAuthHandlers.RestoreHandler)
+	twitchGroup.Get("/scopes", s.twitchOAuthHandlers.ScopesHandler)
 }
 
 func (s *FiberServer) registerAnalyticsRoutes() {
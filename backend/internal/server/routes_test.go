@@ -1,10 +1,19 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/database"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -32,3 +41,286 @@ func TestHandler(t *testing.T) {
 		t.Errorf("expected response body to be %v; got %v", expected, string(body))
 	}
 }
+
+func TestLegacyTwitchCallbackRedirects(t *testing.T) {
+	app := fiber.New()
+	s := &FiberServer{App: app}
+	app.Get("/api/twitch/callback", s.legacyTwitchCallbackHandler)
+	req, err := http.NewRequest("GET", "/api/twitch/callback?code=abc&state=xyz", nil)
+	if err != nil {
+		t.Fatalf("error creating request. Err: %v", err)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected status Found; got %v", resp.Status)
+	}
+	expected := "/api/auth/twitch/callback?code=abc&state=xyz"
+	if location := resp.Header.Get("Location"); location != expected {
+		t.Errorf("expected redirect to %v; got %v", expected, location)
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(compressionMiddleware(config.CompressionConfig{Enabled: true, Level: config.CompressionDefault}))
+	body := strings.Repeat("creatorsync ", 500)
+	app.Get("/api/analytics/enhanced", func(c *fiber.Ctx) error { return c.SendString(body) })
+	app.Get("/api/media/thumbnails/v1", func(c *fiber.Ctx) error { return c.SendString(body) })
+
+	tests := []struct {
+		path     string
+		encoding string
+	}{
+		{"/api/analytics/enhanced", "gzip"},
+		{"/api/media/thumbnails/v1", ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", tt.path, nil)
+		if err != nil {
+			t.Fatalf("error creating request. Err: %v", err)
+		}
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != tt.encoding {
+			t.Errorf("%s: expected Content-Encoding %q; got %q", tt.path, tt.encoding, encoding)
+		}
+	}
+}
+
+type fakeDatabase struct {
+	database.Service
+	err error
+}
+
+func (f fakeDatabase) Health() map[string]string {
+	if f.err != nil {
+		return map[string]string{"status": "down", "error": f.err.Error()}
+	}
+	return map[string]string{"status": "up"}
+}
+
+func TestHealthHandlerReportsDatabaseDown(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("connection refused"), http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		s := &FiberServer{App: app, db: fakeDatabase{err: tt.err}}
+		app.Get("/health", s.healthHandler)
+		req, err := http.NewRequest("GET", "/health", nil)
+		if err != nil {
+			t.Fatalf("error creating request. Err: %v", err)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("database error %v: expected status %d; got %v", tt.err, tt.status, resp.Status)
+		}
+	}
+}
+
+func TestDegradedMiddlewareServesCachedResponses(t *testing.T) {
+	var dbErr error
+	ping := func() error { return dbErr }
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", clerk.User{ID: "user_1"})
+		return c.Next()
+	})
+	app.Use(degradedMiddleware(config.DegradedConfig{Enabled: true, CacheTTL: time.Minute, MaxEntries: 10}, ping))
+	app.Get("/api/analytics/overview", func(c *fiber.Ctx) error {
+		if dbErr != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get overview"})
+		}
+		return c.JSON(fiber.Map{"followers": 10})
+	})
+	app.Get("/api/analytics/detailed", func(c *fiber.Ctx) error {
+		if dbErr != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "db down")
+		}
+		return c.JSON(fiber.Map{"followers": 20})
+	})
+
+	get := func(path string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("error creating request. Err: %v", err)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("error reading response body. Err: %v", err)
+		}
+		return resp, string(body)
+	}
+
+	if resp, _ := get("/api/analytics/overview"); resp.Header.Get(degradedHeader) != "" {
+		t.Errorf("expected a fresh response while the database is up")
+	}
+
+	dbErr = errors.New("connection refused")
+	resp, body := get("/api/analytics/overview")
+	if resp.StatusCode != http.StatusOK || body != `{"followers":10}` {
+		t.Errorf("expected the cached overview; got %v %s", resp.Status, body)
+	}
+	if resp.Header.Get(degradedHeader) != "true" {
+		t.Errorf("expected the %s header", degradedHeader)
+	}
+
+	// Nothing was cached for this path yet
+	if resp, _ := get("/api/analytics/detailed"); resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected status 500 without a cached response; got %v", resp.Status)
+	}
+}
+
+func TestTwitchConnection(t *testing.T) {
+	t.Setenv("TWITCH_SCOPES", "user:read:email clips:edit")
+
+	if status := twitchConnection(nil, nil); status.Connected || status.NeedsReconsent {
+		t.Errorf("expected no connection without a profile; got %+v", status)
+	}
+
+	profile := &analytics.User{TwitchUserID: "123", Username: "baldy"}
+	status := twitchConnection(profile, nil)
+	if !status.Connected || status.Account != "baldy" || status.TokenSource != "clerk" {
+		t.Errorf("expected the Clerk sign-in connection; got %+v", status)
+	}
+
+	status = twitchConnection(profile, &analytics.TwitchToken{Scopes: "user:read:email"})
+	if status.TokenSource != "creatorsync" || !status.NeedsReconsent {
+		t.Errorf("expected a stored token that needs reconsent; got %+v", status)
+	}
+	if len(status.MissingScopes) != 1 || status.MissingScopes[0] != "clips:edit" {
+		t.Errorf("expected clips:edit to be missing; got %v", status.MissingScopes)
+	}
+}
+
+func TestIdempotencyMiddlewareReplaysResponses(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", clerk.User{ID: "user_1"})
+		return c.Next()
+	})
+	app.Use(idempotencyMiddleware(config.IdempotencyConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10}))
+	runs := 0
+	app.Post("/api/analytics/collect", func(c *fiber.Ctx) error {
+		runs++
+		return c.JSON(fiber.Map{"run": runs})
+	})
+
+	post := func(key, body string) (*http.Response, string) {
+		req, err := http.NewRequest("POST", "/api/analytics/collect", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error creating request. Err: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("error reading response body. Err: %v", err)
+		}
+		return resp, string(respBody)
+	}
+
+	post("key-1", `{}`)
+	resp, body := post("key-1", `{}`)
+	if body != `{"run":1}` || resp.Header.Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("expected the first response replayed; got %s", body)
+	}
+	if runs != 1 {
+		t.Errorf("expected one run for a repeated key; got %d", runs)
+	}
+
+	if resp, _ := post("key-1", `{"max_videos":5}`); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for a reused key; got %v", resp.Status)
+	}
+	if _, body := post("key-2", `{}`); body != `{"run":2}` {
+		t.Errorf("expected a new key to run; got %s", body)
+	}
+	if _, body := post("", `{}`); body != `{"run":3}` {
+		t.Errorf("expected requests without a key to run; got %s", body)
+	}
+}
+
+func TestDebugRoutesRequireToken(t *testing.T) {
+	token := strings.Repeat("d", 32)
+	s := &FiberServer{App: fiber.New()}
+	s.registerDebugRoutes(token)
+
+	tests := []struct {
+		path   string
+		auth   string
+		status int
+	}{
+		{"/debug/runtime", "", http.StatusUnauthorized},
+		{"/debug/runtime", "Bearer wrong", http.StatusUnauthorized},
+		{"/debug/pprof/heap", token, http.StatusUnauthorized},
+		{"/debug/runtime", "Bearer " + token, http.StatusOK},
+		{"/debug/pprof/heap", "Bearer " + token, http.StatusOK},
+		{"/debug/pprof/", "Bearer " + token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", tt.path, nil)
+		if err != nil {
+			t.Fatalf("error creating request. Err: %v", err)
+		}
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := s.App.Test(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s with %q: expected status %d; got %v", tt.path, tt.auth, tt.status, resp.Status)
+		}
+	}
+}
+
+func TestRuntimeStatsHandler(t *testing.T) {
+	app := fiber.New()
+	app.Get("/debug/runtime", runtimeStatsHandler)
+	resp, err := app.Test(httptest.NewRequest("GET", "/debug/runtime", nil))
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+
+	var stats struct {
+		Goroutines int `json:"goroutines"`
+		Memory     struct {
+			HeapAlloc uint64 `json:"heap_alloc_bytes"`
+		} `json:"memory"`
+		GC map[string]any `json:"gc"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("error decoding response body. Err: %v", err)
+	}
+	if stats.Goroutines == 0 || stats.Memory.HeapAlloc == 0 {
+		t.Errorf("expected goroutines and heap; got %+v", stats)
+	}
+	if _, ok := stats.GC["count"]; !ok {
+		t.Errorf("expected GC stats; got %v", stats.GC)
+	}
+}
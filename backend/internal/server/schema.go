@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/database"
+)
+
+// prepareSchema applies pending migrations when AUTO_MIGRATE is set, then
+// refuses to start while any are still pending, rather than serving errors on
+// missing tables and columns
+func prepareSchema(db *sql.DB, cfg config.MigrationConfig) error {
+	runner := database.NewMigrationRunner(db)
+	migrations := database.Migrations(cfg.Dir)
+
+	if cfg.AutoMigrate {
+		log.Println("Applying pending migrations")
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.LockTimeout)
+		defer cancel()
+		if err := runner.RunMigrationsLocked(ctx, migrations); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+	}
+
+	if !cfg.SchemaCheck {
+		return nil
+	}
+	pending, err := runner.PendingMigrations(migrations)
+	if err != nil {
+		return fmt.Errorf("failed to check the database schema: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind: %d migrations pending, from %s (run cmd/migrate or set AUTO_MIGRATE=true)", len(pending), pending[0])
+	}
+	return nil
+}
@@ -1,22 +1,90 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/baldybuilds/creatorsync/internal/alerts"
 	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/apikeys"
+	"github.com/baldybuilds/creatorsync/internal/audit"
+	"github.com/baldybuilds/creatorsync/internal/billing"
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/calendar"
 	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
 	"github.com/baldybuilds/creatorsync/internal/database"
+	"github.com/baldybuilds/creatorsync/internal/enrichment"
+	"github.com/baldybuilds/creatorsync/internal/export"
+	"github.com/baldybuilds/creatorsync/internal/i18n"
+	"github.com/baldybuilds/creatorsync/internal/insights"
+	"github.com/baldybuilds/creatorsync/internal/maintenance"
+	"github.com/baldybuilds/creatorsync/internal/media"
+	"github.com/baldybuilds/creatorsync/internal/overlay"
+	"github.com/baldybuilds/creatorsync/internal/patreon"
+	"github.com/baldybuilds/creatorsync/internal/preferences"
+	"github.com/baldybuilds/creatorsync/internal/publicprofile"
+	"github.com/baldybuilds/creatorsync/internal/publishing"
+	"github.com/baldybuilds/creatorsync/internal/reports"
+	"github.com/baldybuilds/creatorsync/internal/server/handlers"
+	"github.com/baldybuilds/creatorsync/internal/server/helpers"
+	"github.com/baldybuilds/creatorsync/internal/streamlabs"
+	"github.com/baldybuilds/creatorsync/internal/stripe"
+	"github.com/baldybuilds/creatorsync/internal/supervisor"
+	"github.com/baldybuilds/creatorsync/internal/templates"
+	"github.com/baldybuilds/creatorsync/internal/trends"
 	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/usage"
+	"github.com/baldybuilds/creatorsync/internal/watchlist"
+	"github.com/baldybuilds/creatorsync/internal/youtube"
 )
 
 type FiberServer struct {
 	*fiber.App
 
-	db                database.Service
-	analyticsHandlers *analytics.Handlers
+	db                  database.Service
+	background          *supervisor.Supervisor
+	collections         *analytics.BackgroundCollectionManager
+	userLocale          fiber.Handler
+	maintenance         *maintenance.Switch
+	analyticsService    analytics.Service
+	analyticsHandlers   *analytics.Handlers
+	twitchOAuthHandlers *handlers.TwitchOAuthHandlers
+	eventSubHandlers    *handlers.TwitchEventSubHandlers
+	apiKeyHandlers      *apikeys.Handlers
+	overlayHandlers     *overlay.Handlers
+	alertHandlers       *alerts.Handlers
+	auditHandlers       *audit.Handlers
+
+	publicProfileHandlers *publicprofile.Handlers
+	preferencesHandlers   *preferences.Handlers
+	reportHandlers        *reports.Handlers
+	mediaHandlers         *media.Handlers
+	maintenanceHandlers   *maintenance.Handlers
+	calendarHandlers      *calendar.Handlers
+	watchlistHandlers     *watchlist.Handlers
+	trendHandlers         *trends.Handlers
+	templateHandlers      *templates.Handlers
+	usageHandlers         *usage.Handlers
+	backgroundHandlers    *supervisor.Handlers
+	// usageRecorder is nil unless usage tracking is enabled
+	usageRecorder *usage.Recorder
+	// patreonOAuthHandlers is nil unless Patreon is configured
+	patreonOAuthHandlers *handlers.PatreonOAuthHandlers
+	// streamlabsOAuthHandlers is nil unless Streamlabs is configured
+	streamlabsOAuthHandlers *handlers.StreamlabsOAuthHandlers
+	// youtubeOAuthHandlers and publishingHandlers are nil unless YouTube is
+	// configured
+	youtubeOAuthHandlers *handlers.YouTubeOAuthHandlers
+	publishingHandlers   *publishing.Handlers
+	// renderHandlers is nil unless clip renders are enabled
+	renderHandlers *media.RenderHandlers
+	// billingHandlers is nil unless Stripe is configured
+	billingHandlers *billing.Handlers
 }
 
 func New() (*FiberServer, error) {
@@ -24,7 +92,14 @@ func New() (*FiberServer, error) {
 		return nil, fmt.Errorf("failed to initialize Clerk client: %w", err)
 	}
 
-	db := database.New()
+	var dbOptions []database.Option
+	if config.Database().RowLevelSecurity {
+		dbOptions = append(dbOptions, database.WithRowLevelSecurity(clerk.UserIDFromContext))
+	}
+	db := database.New(dbOptions...)
+	if err := prepareSchema(db.GetDB(), config.Migrations()); err != nil {
+		return nil, err
+	}
 
 	// Initialize Twitch client
 	twitchClientID := os.Getenv("TWITCH_CLIENT_ID")
@@ -37,21 +112,284 @@ func New() (*FiberServer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Twitch client: %w", err)
 	}
+	twitchClient.SetCallTimeout(config.Twitch().CallTimeout)
+
+	// Sensitive actions are recorded in the audit log
+	auditRepo := audit.NewRepository(db.GetDB())
+	auditLog := audit.NewLogger(auditRepo)
+	auditHandlers := audit.NewHandlers(auditRepo, auditLog)
 
 	// Initialize analytics components
-	analyticsService := analytics.NewService(db, twitchClient)
 	dataCollector := analytics.NewDataCollector(analytics.NewRepository(db.GetDB()), twitchClient)
+	analyticsService := analytics.NewService(db, dataCollector)
 	backgroundMgr := analytics.NewBackgroundCollectionManager(dataCollector, db)
+
+	// Patreon income is collected with the daily collection when Patreon is
+	// configured, ahead of the hooks below that evict and warm cached revenue
+	var patreonClient *patreon.Client
+	if patreonConfig := config.Patreon(); patreonConfig.Enabled() {
+		patreonClient = patreon.NewClient(patreonConfig.ClientID, patreonConfig.ClientSecret)
+		patreonCollector := analytics.NewPatreonCollector(analytics.NewRepository(db.GetDB()), patreonClient)
+		dataCollector.AddCollectionHook(patreonCollector.CollectUser)
+	}
+	// and so are Streamlabs tips
+	var streamlabsClient *streamlabs.Client
+	if streamlabsConfig := config.Streamlabs(); streamlabsConfig.Enabled() {
+		streamlabsClient = streamlabs.NewClient(streamlabsConfig.ClientID, streamlabsConfig.ClientSecret)
+		streamlabsCollector := analytics.NewStreamlabsCollector(analytics.NewRepository(db.GetDB()), streamlabsClient)
+		dataCollector.AddCollectionHook(streamlabsCollector.CollectUser)
+	}
+
+	// Read-only maintenance rejects writes and pauses collections
+	maintenanceSwitch := maintenance.New(config.Maintenance())
+	backgroundMgr.SetPauseCheck(maintenanceSwitch.CollectionsPaused)
+	maintenanceHandlers := maintenance.NewHandlers(maintenanceSwitch)
+	maintenanceHandlers.UseAuditLog(auditLog)
+	if state := maintenanceSwitch.State(); state.ReadOnly || state.PauseCollections {
+		log.Printf("🚧 Starting in maintenance mode: read-only=%t, collections paused=%t", state.ReadOnly, maintenanceSwitch.CollectionsPaused())
+	}
+
+	// Caches holding data about a user register here, so connecting Twitch or
+	// collecting new data evicts them all
+	invalidator := cache.NewInvalidator()
+	invalidator.Register(helpers.InvalidateTwitchConnection)
+	evictUser := func(ctx context.Context, userID string) error {
+		invalidator.InvalidateUser(userID)
+		return nil
+	}
+	dataCollector.AddCollectionHook(evictUser)
+	dataCollector.AddVideoHook(evictUser)
+
+	// Dashboard payloads are computed again right after collection, so the
+	// next dashboard visit is served from memory
+	invalidator.Register(analyticsService.EvictUser)
+	if config.AnalyticsCache().WarmUp {
+		dataCollector.AddCollectionHook(analyticsService.WarmUser)
+		dataCollector.AddVideoHook(analyticsService.WarmUser)
+	}
+
+	// Plans are enforced and sold through Stripe when it is configured.
+	// Feature flags apply either way.
+	var billingRepo billing.Repository
+	var billingHandlers *billing.Handlers
+	billingConfig := config.Billing()
+	if billingConfig.Enabled() {
+		billingRepo = billing.NewRepository(db.GetDB())
+	}
+	entitlements := billing.NewEntitlements(billingRepo, config.Entitlements())
+	planLimits := billing.NewLimits(entitlements)
+	if billingRepo != nil {
+		billingHandlers = billing.NewHandlers(billingRepo, entitlements, stripe.NewClient(billingConfig.StripeSecretKey), billingConfig)
+	}
+
 	analyticsHandlers := analytics.NewHandlers(analyticsService, backgroundMgr)
+	analyticsHandlers.UsePlanLimits(planLimits)
+	analyticsHandlers.UseAuditLog(auditLog)
+	analyticsHandlers.UseCollectionQuota(analytics.NewCollectionQuota(analytics.NewRepository(db.GetDB()), config.Collection().ManualDailyLimit))
+	analyticsHandlers.UseDiagnostics(analytics.NewDiagnostics(analytics.NewRepository(db.GetDB()), twitchClient))
+	twitchOAuthHandlers := handlers.NewTwitchOAuthHandlers(analytics.NewRepository(db.GetDB()), twitchClient, helpers.NewDatabaseSessionStore(db.GetDB()), auditLog, invalidator)
+	eventSubHandlers := handlers.NewTwitchEventSubHandlers(analytics.NewRepository(db.GetDB()))
+	var patreonOAuthHandlers *handlers.PatreonOAuthHandlers
+	if patreonClient != nil {
+		patreonOAuthHandlers = handlers.NewPatreonOAuthHandlers(analytics.NewRepository(db.GetDB()), patreonClient, helpers.NewDatabaseSessionStore(db.GetDB()), auditLog, invalidator)
+		patreonOAuthHandlers.UsePlanLimits(planLimits)
+	}
+	var streamlabsOAuthHandlers *handlers.StreamlabsOAuthHandlers
+	if streamlabsClient != nil {
+		streamlabsOAuthHandlers = handlers.NewStreamlabsOAuthHandlers(analytics.NewRepository(db.GetDB()), streamlabsClient, helpers.NewDatabaseSessionStore(db.GetDB()), auditLog, invalidator)
+		streamlabsOAuthHandlers.UsePlanLimits(planLimits)
+	}
+
+	// API keys can read analytics in place of a Clerk session
+	apiKeyRepo := apikeys.NewRepository(db.GetDB())
+	analyticsHandlers.UseAuthMiddleware(apikeys.Middleware(apiKeyRepo, clerk.AuthMiddleware()))
+
+	// Authenticated requests use the user's saved language over Accept-Language
+	userLocale := i18n.UserLocale(savedLocale(analytics.NewRepository(db.GetDB())))
+	analyticsHandlers.UseLocaleMiddleware(userLocale)
+
+	// Analytics stay readable from memory while the database is unreachable
+	analyticsHandlers.UseDegradedMiddleware(degradedMiddleware(config.Degraded(), db.CheckConnection))
+	// Double-submitted collection triggers are answered with the first response
+	analyticsHandlers.UseIdempotencyMiddleware(idempotencyMiddleware(config.Idempotency()))
+	apiKeyHandlers := apikeys.NewHandlers(apiKeyRepo)
+	apiKeyHandlers.UseAuditLog(auditLog)
+
+	overlayRepo := overlay.NewRepository(db.GetDB())
+	overlayService := overlay.NewService(overlayRepo, analytics.NewRepository(db.GetDB()), twitchClient)
+	invalidator.Register(overlayService.EvictUser)
+	overlayHandlers := overlay.NewHandlers(overlayService, overlayRepo)
+	overlayHandlers.UseAuditLog(auditLog)
+
+	// Alert rules are evaluated after every daily collection, and what each
+	// collection changed is shown as an alert
+	alertRepo := alerts.NewRepository(db.GetDB())
+	alertService := alerts.NewService(alertRepo, analytics.NewRepository(db.GetDB()))
+	dataCollector.AddCollectionHook(alertService.EvaluateUser)
+	dataCollector.AddChangeHook(alertService.NotifyChanges)
+	alertHandlers := alerts.NewHandlers(alertRepo)
+
+	// Video titles are tagged with language and keywords once they are saved
+	enricher := enrichment.NewEnricher(enrichment.NewRepository(db.GetDB()))
+	dataCollector.AddVideoHook(enricher.EnrichUser)
+
+	// Weekly insights are written by a language model on the weekly digest
+	// schedule when INSIGHTS_PROVIDER is set
+	insightsProvider, err := insights.NewProviderFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize insights provider: %w", err)
+	}
+	if insightsProvider != nil {
+		insightsGenerator := analytics.NewInsightsGenerator(analyticsService, analytics.NewRepository(db.GetDB()), insightsProvider)
+		backgroundMgr.AddWeeklyHook(insightsGenerator.GenerateWeekly)
+		log.Printf("💡 Weekly insights enabled with %s (%s)", insightsProvider.Name(), insightsProvider.Model())
+	}
+
+	publicProfileRepo := publicprofile.NewRepository(db.GetDB())
+	publicProfileService := publicprofile.NewService(publicProfileRepo, analytics.NewRepository(db.GetDB()))
+	invalidator.Register(publicProfileService.EvictUser)
+	publicProfileHandlers := publicprofile.NewHandlers(publicProfileService, publicProfileRepo)
+
+	preferencesHandlers := preferences.NewHandlers(preferences.NewRepository(db.GetDB()))
+
+	// Saved reports run on demand, or after collection once their schedule is due
+	reportSink, err := export.NewSinkFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize report export: %w", err)
+	}
+	reportRepo := reports.NewRepository(db.GetDB())
+	reportService := reports.NewService(reportRepo, analytics.NewRepository(db.GetDB()), reportSink)
+	dataCollector.AddCollectionHook(planLimits.Hook(billing.FeatureExports, reportService.RunDueReports))
+	reportHandlers := reports.NewHandlers(reportService, reportRepo)
+	reportHandlers.UseAuditLog(auditLog)
+	reportHandlers.UsePlanLimits(planLimits)
+
+	// Title, description and tag templates used when publishing clips
+	templateRepo := templates.NewRepository(db.GetDB())
+
+	// Planned streams and uploads are reconciled once streams and videos are saved
+	calendarRepo := calendar.NewRepository(db.GetDB())
+	calendarService := calendar.NewService(calendarRepo, analytics.NewRepository(db.GetDB()))
+	dataCollector.AddCollectionHook(calendarService.Reconcile)
+	dataCollector.AddVideoHook(calendarService.Reconcile)
+	calendarHandlers := calendar.NewHandlers(calendarService, calendarRepo)
+
+	// Thumbnails are proxied so the frontend never hotlinks Twitch URLs
+	thumbnailStore, err := media.NewStoreFromConfig(config.Media())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize thumbnail storage: %w", err)
+	}
+	mediaHandlers := media.NewHandlers(media.NewService(media.NewRepository(db.GetDB()), thumbnailStore, twitchClient))
+
+	// Background services are restarted when they fail or panic
+	background := supervisor.New(config.Background())
+	background.Add("analytics_scheduler", backgroundMgr.Run)
+
+	// Public stats of the channels users watch are collected with the app token
+	watchlistRepo := watchlist.NewRepository(db.GetDB())
+	watchlistService := watchlist.NewService(watchlistRepo, analytics.NewRepository(db.GetDB()), twitchClient,
+		analytics.NewTwitchTokenHelper(analytics.NewRepository(db.GetDB()), twitchClient))
+	background.Add("watchlist_collector", watchlistService.Run)
+
+	// The top games on Twitch are sampled with the app token too
+	trendService := trends.NewService(trends.NewRepository(db.GetDB()), twitchClient)
+	if config.Trends().Enabled {
+		background.Add("trend_sampler", trendService.Run)
+	}
+
+	// Each user's API requests are counted in memory and written in batches
+	usageRepo := usage.NewRepository(db.GetDB())
+	var usageRecorder *usage.Recorder
+	if usageConfig := config.Usage(); usageConfig.Enabled {
+		usageRecorder = usage.NewRecorder(usageRepo, usageConfig)
+		background.Add("usage_recorder", usageRecorder.Run)
+	}
+
+	// Clips are downloaded and transcoded to vertical video by a background
+	// worker when CLIP_RENDERS_ENABLED is set
+	var renderHandlers *media.RenderHandlers
+	if config.ClipRenders().Enabled {
+		renderStore, err := media.NewRenderStoreFromConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize clip render storage: %w", err)
+		}
+		renderRepo := media.NewRenderRepository(db.GetDB())
+		clipDownloads := analytics.NewClipDownloads(analytics.NewRepository(db.GetDB()),
+			analytics.NewTwitchTokenHelper(analytics.NewRepository(db.GetDB()), twitchClient), twitchClient)
+		background.Add("clip_renderer", media.NewRenderer(renderRepo, clipDownloads, renderStore).Run)
+		renderHandlers = media.NewRenderHandlers(renderRepo, renderStore)
+	}
+
+	// Clips queued for YouTube are published by a background worker
+	var youtubeOAuthHandlers *handlers.YouTubeOAuthHandlers
+	var publishingHandlers *publishing.Handlers
+	if publishingConfig := config.Publishing(); publishingConfig.Enabled() {
+		youtubeClient := youtube.NewClient(publishingConfig.YouTubeClientID, publishingConfig.YouTubeClientSecret)
+		publishingRepo := publishing.NewRepository(db.GetDB())
+		publisher := publishing.NewPublisher(publishingRepo, analytics.NewRepository(db.GetDB()), twitchClient,
+			analytics.NewTwitchTokenHelper(analytics.NewRepository(db.GetDB()), twitchClient), youtubeClient)
+		background.Add("publisher", publisher.Run)
+		youtubeOAuthHandlers = handlers.NewYouTubeOAuthHandlers(analytics.NewRepository(db.GetDB()), publishingRepo, youtubeClient, helpers.NewDatabaseSessionStore(db.GetDB()), auditLog)
+		youtubeOAuthHandlers.UsePlanLimits(planLimits)
+		publishingHandlers = publishing.NewHandlers(publishingRepo, analytics.NewRepository(db.GetDB()), templateRepo)
+	}
 
 	server := &FiberServer{
 		App: fiber.New(fiber.Config{
 			ServerHeader: "creatorsync",
 			AppName:      "creatorsync",
 		}),
-		db:                db,
-		analyticsHandlers: analyticsHandlers,
+		db:                  db,
+		background:          background,
+		collections:         backgroundMgr,
+		userLocale:          userLocale,
+		maintenance:         maintenanceSwitch,
+		analyticsService:    analyticsService,
+		analyticsHandlers:   analyticsHandlers,
+		twitchOAuthHandlers: twitchOAuthHandlers,
+		eventSubHandlers:    eventSubHandlers,
+		apiKeyHandlers:      apiKeyHandlers,
+		overlayHandlers:     overlayHandlers,
+		alertHandlers:       alertHandlers,
+		auditHandlers:       auditHandlers,
+
+		publicProfileHandlers:   publicProfileHandlers,
+		preferencesHandlers:     preferencesHandlers,
+		reportHandlers:          reportHandlers,
+		mediaHandlers:           mediaHandlers,
+		maintenanceHandlers:     maintenanceHandlers,
+		calendarHandlers:        calendarHandlers,
+		watchlistHandlers:       watchlist.NewHandlers(watchlistService, watchlistRepo),
+		trendHandlers:           trends.NewHandlers(trendService),
+		templateHandlers:        templates.NewHandlers(templateRepo),
+		usageHandlers:           usage.NewHandlers(usageRepo),
+		backgroundHandlers:      supervisor.NewHandlers(background),
+		usageRecorder:           usageRecorder,
+		patreonOAuthHandlers:    patreonOAuthHandlers,
+		streamlabsOAuthHandlers: streamlabsOAuthHandlers,
+		youtubeOAuthHandlers:    youtubeOAuthHandlers,
+		publishingHandlers:      publishingHandlers,
+		renderHandlers:          renderHandlers,
+		billingHandlers:         billingHandlers,
 	}
 
 	return server, nil
 }
+
+// StartBackgroundServices starts the supervised background services, such as
+// the collection scheduler
+func (s *FiberServer) StartBackgroundServices(ctx context.Context) {
+	s.background.Start(ctx)
+}
+
+// DrainCollections stops starting collections and waits for the running ones
+// until ctx ends. Those still running then are queued to resume after the
+// restart. Call it before StopBackgroundServices.
+func (s *FiberServer) DrainCollections(ctx context.Context) bool {
+	return s.collections.Drain(ctx)
+}
+
+// StopBackgroundServices stops the background services and waits for them
+func (s *FiberServer) StopBackgroundServices() {
+	s.background.Stop()
+}
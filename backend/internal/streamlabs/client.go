@@ -0,0 +1,41 @@
+// Package streamlabs is a client for the Streamlabs API v2.0, used to read the
+// tips a streamer received
+package streamlabs
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+)
+
+const apiBaseURL = "https://streamlabs.com/api/v2.0"
+
+// Scopes are requested when connecting Streamlabs
+var Scopes = []string{"donations.read"}
+
+// OAuthToken is the response of the Streamlabs token endpoint. Its ExpiresIn
+// is 0 for tokens that stay valid until access is revoked.
+type OAuthToken = oauthclient.Token
+
+// APIError is returned when Streamlabs responds with an unexpected status code
+type APIError = oauthclient.APIError
+
+// Client handles the Streamlabs OAuth flow and API calls
+type Client struct {
+	*oauthclient.Client
+}
+
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{Client: oauthclient.NewClient(oauthclient.Provider{
+		Name:         "streamlabs",
+		AuthorizeURL: apiBaseURL + "/authorize",
+		TokenURL:     apiBaseURL + "/token",
+		Scopes:       Scopes,
+	}, clientID, clientSecret)}
+}
+
+// getJSON performs an authorized GET of an API path
+func (c *Client) getJSON(ctx context.Context, accessToken, path string, params url.Values, out any) error {
+	return c.GetJSON(ctx, accessToken, apiBaseURL+path, params, out)
+}
@@ -0,0 +1,75 @@
+package streamlabs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respond(status int, body string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestGetDonations(t *testing.T) {
+	client := NewClient("client", "secret")
+	var query string
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.RawQuery
+		assert.Equal(t, "/api/v2.0/donations", req.URL.Path)
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		return respond(http.StatusOK, `{"data": [
+			{"donation_id": "82", "created_at": 1760000000, "currency": "USD", "amount": "13.3700", "name": "viewer"},
+			{"donation_id": 79, "created_at": 1759990000, "currency": "USD", "amount": 5}
+		]}`)(req)
+	}))
+
+	donations, err := client.GetDonations(context.Background(), "token", 90, 100, "USD")
+	require.NoError(t, err)
+	assert.Contains(t, query, "before=90")
+	assert.Contains(t, query, "currency=USD")
+	assert.Equal(t, []Donation{
+		{ID: 82, AmountCents: 1337, Currency: "USD", CreatedAt: time.Unix(1760000000, 0).UTC()},
+		{ID: 79, AmountCents: 500, Currency: "USD", CreatedAt: time.Unix(1759990000, 0).UTC()},
+	}, donations)
+}
+
+func TestGetUser(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(respond(http.StatusOK, `{
+		"streamlabs": {"id": 1234, "display_name": "Creator"},
+		"twitch": {"id": 5678, "name": "creator"}
+	}`))
+
+	user, err := client.GetUser(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, &User{ID: "1234", DisplayName: "Creator"}, user)
+}
+
+func TestErrorsKeepStatus(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(respond(http.StatusUnauthorized, `{"error":"invalid_token"}`))
+
+	_, err := client.GetDonations(context.Background(), "token", 0, 100, "USD")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
@@ -0,0 +1,104 @@
+package streamlabs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxDonationsPerPage is the most donations Streamlabs returns per request
+const MaxDonationsPerPage = 100
+
+// User is the Streamlabs account that authorized the app
+type User struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+// Donation is a tip sent through Streamlabs
+type Donation struct {
+	ID int64 `json:"id"`
+	// AmountCents is in Currency, USD when GetDonations converted it
+	AmountCents int       `json:"amount_cents"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// flexString decodes a JSON string or number as its text. Streamlabs sends IDs
+// and amounts as either.
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	*f = flexString(bytes.Trim(data, `"`))
+	return nil
+}
+
+type userResponse struct {
+	Streamlabs struct {
+		ID          flexString `json:"id"`
+		DisplayName string     `json:"display_name"`
+	} `json:"streamlabs"`
+}
+
+type donationsResponse struct {
+	Data []struct {
+		DonationID flexString `json:"donation_id"`
+		CreatedAt  int64      `json:"created_at"`
+		Currency   string     `json:"currency"`
+		Amount     flexString `json:"amount"`
+	} `json:"data"`
+}
+
+// GetUser returns the account the access token belongs to
+func (c *Client) GetUser(ctx context.Context, accessToken string) (*User, error) {
+	var resp userResponse
+	if err := c.getJSON(ctx, accessToken, "/user", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Streamlabs.ID == "" {
+		return nil, fmt.Errorf("streamlabs user response without an ID")
+	}
+	return &User{
+		ID:          string(resp.Streamlabs.ID),
+		DisplayName: resp.Streamlabs.DisplayName,
+	}, nil
+}
+
+// GetDonations returns up to limit donations, newest first, converted into
+// currency. before pages back from a donation ID, 0 starts at the newest.
+func (c *Client) GetDonations(ctx context.Context, accessToken string, before int64, limit int, currency string) ([]Donation, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("currency", currency)
+	if before > 0 {
+		params.Set("before", strconv.FormatInt(before, 10))
+	}
+
+	var resp donationsResponse
+	if err := c.getJSON(ctx, accessToken, "/donations", params, &resp); err != nil {
+		return nil, err
+	}
+
+	donations := make([]Donation, 0, len(resp.Data))
+	for _, data := range resp.Data {
+		id, err := strconv.ParseInt(string(data.DonationID), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid donation ID %q: %w", data.DonationID, err)
+		}
+		amount, err := strconv.ParseFloat(string(data.Amount), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q of donation %d: %w", data.Amount, id, err)
+		}
+		donations = append(donations, Donation{
+			ID:          id,
+			AmountCents: int(math.Round(amount * 100)),
+			Currency:    data.Currency,
+			CreatedAt:   time.Unix(data.CreatedAt, 0).UTC(),
+		})
+	}
+	return donations, nil
+}
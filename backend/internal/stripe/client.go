@@ -0,0 +1,250 @@
+// Package stripe is a client for the parts of the Stripe API used for
+// subscriptions: Checkout, the customer portal and webhook events
+package stripe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
+)
+
+const apiBaseURL = "https://api.stripe.com/v1"
+
+// DefaultCallTimeout bounds each Stripe request, including reading its
+// response, unless the caller's context ends sooner
+const DefaultCallTimeout = 10 * time.Second
+
+// SignatureHeader carries the signature of webhook deliveries
+const SignatureHeader = "Stripe-Signature"
+
+// webhookTolerance is how old a signed delivery may be, Stripe's default
+const webhookTolerance = 5 * time.Minute
+
+// Event types handled by billing
+const (
+	EventCheckoutCompleted   = "checkout.session.completed"
+	EventSubscriptionCreated = "customer.subscription.created"
+	EventSubscriptionUpdated = "customer.subscription.updated"
+	EventSubscriptionDeleted = "customer.subscription.deleted"
+)
+
+type Client struct {
+	secretKey   string
+	httpClient  *http.Client
+	callTimeout time.Duration
+}
+
+func NewClient(secretKey string) *Client {
+	return &Client{
+		secretKey:   secretKey,
+		httpClient:  httpclient.New("stripe"),
+		callTimeout: DefaultCallTimeout,
+	}
+}
+
+// SetTransport replaces the transport used for Stripe requests, e.g. in
+// tests. Requests are still traced and retried.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = httpclient.NewTransport("stripe", rt)
+}
+
+// APIError is returned when Stripe responds with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("stripe API error: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("stripe API error: status %d, body: %s", e.StatusCode, e.Body)
+}
+
+// post sends a form-encoded request to an API path and decodes the response
+func (c *Client) post(ctx context.Context, path string, form url.Values, out any) error {
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiBaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// CheckoutParams describe a subscription checkout
+type CheckoutParams struct {
+	PriceID string
+	// ClientReferenceID is our user ID, echoed on the completed session
+	ClientReferenceID string
+	// CustomerID reuses the user's Stripe customer, CustomerEmail prefills a
+	// new one
+	CustomerID    string
+	CustomerEmail string
+	SuccessURL    string
+	CancelURL     string
+	// Metadata is copied onto the subscription
+	Metadata map[string]string
+}
+
+// CheckoutSession is a Stripe Checkout session
+type CheckoutSession struct {
+	ID                string            `json:"id"`
+	URL               string            `json:"url"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	Customer          string            `json:"customer"`
+	Subscription      string            `json:"subscription"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+// CreateCheckoutSession starts a subscription checkout and returns the page
+// to send the user to
+func (c *Client) CreateCheckoutSession(ctx context.Context, params CheckoutParams) (*CheckoutSession, error) {
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("line_items[0][price]", params.PriceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("client_reference_id", params.ClientReferenceID)
+	form.Set("success_url", params.SuccessURL)
+	form.Set("cancel_url", params.CancelURL)
+	if params.CustomerID != "" {
+		form.Set("customer", params.CustomerID)
+	} else if params.CustomerEmail != "" {
+		form.Set("customer_email", params.CustomerEmail)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+		form.Set("subscription_data[metadata]["+key+"]", value)
+	}
+
+	var session CheckoutSession
+	if err := c.post(ctx, "/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// PortalSession is a Stripe customer portal session
+type PortalSession struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// CreatePortalSession returns the customer portal, where customers change
+// or cancel their subscription
+func (c *Client) CreatePortalSession(ctx context.Context, customerID, returnURL string) (*PortalSession, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	form.Set("return_url", returnURL)
+
+	var session PortalSession
+	if err := c.post(ctx, "/billing_portal/sessions", form, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Event is a webhook delivery
+type Event struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// CreatedAt is when Stripe created the event
+func (e *Event) CreatedAt() time.Time {
+	return time.Unix(e.Created, 0)
+}
+
+// Subscription is the object of customer.subscription events
+type Subscription struct {
+	ID                string            `json:"id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	Metadata          map[string]string `json:"metadata"`
+	Items             struct {
+		Data []struct {
+			Price struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// PriceID returns the price of the subscription's first item
+func (s *Subscription) PriceID() string {
+	if len(s.Items.Data) == 0 {
+		return ""
+	}
+	return s.Items.Data[0].Price.ID
+}
+
+// VerifyWebhookSignature checks the Stripe-Signature header of a webhook
+// delivery against the endpoint's signing secret and rejects stale ones
+func VerifyWebhookSignature(secret, header string, body []byte, now time.Time) bool {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || now.Sub(time.Unix(sentAt, 0)).Abs() > webhookTolerance {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(expected), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
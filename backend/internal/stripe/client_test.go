@@ -0,0 +1,95 @@
+package stripe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCreateCheckoutSession(t *testing.T) {
+	var form url.Values
+	client := NewClient("sk_test")
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/v1/checkout/sessions", req.URL.Path)
+		assert.Equal(t, "Bearer sk_test", req.Header.Get("Authorization"))
+		body, _ := io.ReadAll(req.Body)
+		form, _ = url.ParseQuery(string(body))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"id": "cs_1", "url": "https://checkout.stripe.com/c/cs_1"}`)),
+			Request:    req,
+		}, nil
+	}))
+
+	session, err := client.CreateCheckoutSession(context.Background(), CheckoutParams{
+		PriceID:           "price_pro",
+		ClientReferenceID: "user_1",
+		CustomerEmail:     "creator@example.com",
+		SuccessURL:        "https://creatorsync.app/billing?checkout=success",
+		CancelURL:         "https://creatorsync.app/billing",
+		Metadata:          map[string]string{"user_id": "user_1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://checkout.stripe.com/c/cs_1", session.URL)
+	assert.Equal(t, "subscription", form.Get("mode"))
+	assert.Equal(t, "price_pro", form.Get("line_items[0][price]"))
+	assert.Equal(t, "user_1", form.Get("client_reference_id"))
+	assert.Equal(t, "creator@example.com", form.Get("customer_email"))
+	assert.Equal(t, "user_1", form.Get("subscription_data[metadata][user_id]"))
+}
+
+func TestCreateCheckoutSessionError(t *testing.T) {
+	client := NewClient("sk_test")
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(`{"error": {"message": "No such price"}}`)),
+			Request:    req,
+		}, nil
+	}))
+
+	_, err := client.CreateCheckoutSession(context.Background(), CheckoutParams{PriceID: "price_missing"})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "t=" + strconv.FormatInt(timestamp, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	body := []byte(`{"id": "evt_1"}`)
+
+	assert.True(t, VerifyWebhookSignature("whsec", sign("whsec", now.Unix(), body), body, now))
+	// Secrets are rolled by signing with both for a while
+	assert.True(t, VerifyWebhookSignature("whsec", sign("old", now.Unix(), body)+",v1="+
+		strings.TrimPrefix(sign("whsec", now.Unix(), body), "t="+strconv.FormatInt(now.Unix(), 10)+",v1="), body, now))
+
+	assert.False(t, VerifyWebhookSignature("other", sign("whsec", now.Unix(), body), body, now))
+	assert.False(t, VerifyWebhookSignature("whsec", sign("whsec", now.Unix(), body), []byte(`{"id": "evt_2"}`), now))
+	assert.False(t, VerifyWebhookSignature("whsec", sign("whsec", now.Add(-time.Hour).Unix(), body), body, now))
+	assert.False(t, VerifyWebhookSignature("whsec", "", body, now))
+}
@@ -0,0 +1,23 @@
+package supervisor
+
+import "github.com/gofiber/fiber/v2"
+
+type Handlers struct {
+	supervisor *Supervisor
+}
+
+func NewHandlers(supervisor *Supervisor) *Handlers {
+	return &Handlers{supervisor: supervisor}
+}
+
+// RegisterRoutes registers the background status on an admin-only router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/background-status", h.GetStatus)
+}
+
+// GetStatus lists each background service with its state and last error
+func (h *Handlers) GetStatus(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"services": h.supervisor.Status(),
+	})
+}
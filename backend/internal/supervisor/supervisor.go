@@ -0,0 +1,177 @@
+// Package supervisor keeps background services such as the collection
+// scheduler running, restarting them with backoff when they fail or panic.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Service states
+const (
+	StateRunning    = "running"
+	StateRestarting = "restarting"
+	StateStopped    = "stopped"
+)
+
+// Service runs until ctx is cancelled. Returning before that, with or without
+// an error, counts as a failure.
+type Service func(ctx context.Context) error
+
+// Status is the state of one supervised service
+type Status struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Restarts    int        `json:"restarts"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// NextRestartAt is set while the service waits to be restarted
+	NextRestartAt *time.Time `json:"next_restart_at,omitempty"`
+}
+
+type service struct {
+	run    Service
+	status Status
+}
+
+// Supervisor runs services in their own goroutines and restarts them when
+// they fail. A service that ran for longer than the maximum backoff restarts
+// after the minimum backoff again.
+type Supervisor struct {
+	cfg config.BackgroundConfig
+
+	mu       sync.Mutex
+	services []*service
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func New(cfg config.BackgroundConfig) *Supervisor {
+	return &Supervisor{cfg: cfg}
+}
+
+// Add registers a service. Call it before Start.
+func (s *Supervisor) Add(name string, run Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.services = append(s.services, &service{
+		run:    run,
+		status: Status{Name: name, State: StateStopped},
+	})
+}
+
+// Start runs every service until Stop is called or ctx is cancelled
+func (s *Supervisor) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, svc := range s.services {
+		s.wg.Add(1)
+		go s.supervise(ctx, svc)
+	}
+}
+
+// Stop cancels the services and waits for them to return
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	s.wg.Wait()
+}
+
+// Status returns the state of every service in the order they were added
+func (s *Supervisor) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.services))
+	for _, svc := range s.services {
+		statuses = append(statuses, svc.status)
+	}
+	return statuses
+}
+
+func (s *Supervisor) supervise(ctx context.Context, svc *service) {
+	defer s.wg.Done()
+
+	name := svc.status.Name
+	backoff := s.cfg.MinBackoff
+	for {
+		started := time.Now()
+		s.update(svc, func(status *Status) {
+			status.State = StateRunning
+			status.StartedAt = &started
+			status.NextRestartAt = nil
+		})
+
+		err := runSafely(ctx, svc.run)
+		if ctx.Err() != nil {
+			s.update(svc, func(status *Status) { status.State = StateStopped })
+			return
+		}
+		if err == nil {
+			err = errors.New("exited unexpectedly")
+		}
+
+		// A service that was up for a while failed on its own, not in a loop
+		if time.Since(started) > s.cfg.MaxBackoff {
+			backoff = s.cfg.MinBackoff
+		}
+		failedAt := time.Now()
+		restartAt := failedAt.Add(backoff)
+		s.update(svc, func(status *Status) {
+			status.State = StateRestarting
+			status.LastError = err.Error()
+			status.LastErrorAt = &failedAt
+			status.NextRestartAt = &restartAt
+		})
+		log.Printf("⚠️ Background service %s failed, restarting in %s: %v", name, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			s.update(svc, func(status *Status) {
+				status.State = StateStopped
+				status.NextRestartAt = nil
+			})
+			return
+		case <-time.After(backoff):
+		}
+
+		s.update(svc, func(status *Status) { status.Restarts++ })
+		backoff = min(backoff*2, s.cfg.MaxBackoff)
+	}
+}
+
+func (s *Supervisor) update(svc *service, change func(status *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(&svc.status)
+}
+
+// runSafely turns a panic in run into an error
+func runSafely(ctx context.Context, run Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Background service panicked: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
@@ -0,0 +1,109 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastBackoff = config.BackgroundConfig{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+func TestSupervisorRestartsPanickingService(t *testing.T) {
+	s := New(fastBackoff)
+	var runs atomic.Int32
+	s.Add("flaky", func(ctx context.Context) error {
+		if runs.Add(1) <= 2 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	})
+
+	s.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return s.Status()[0].State == StateRunning && runs.Load() == 3
+	}, time.Second, time.Millisecond)
+
+	status := s.Status()[0]
+	assert.Equal(t, "flaky", status.Name)
+	assert.Equal(t, 2, status.Restarts)
+	assert.Equal(t, "panic: boom", status.LastError)
+	assert.NotNil(t, status.LastErrorAt)
+	assert.Nil(t, status.NextRestartAt)
+
+	s.Stop()
+	assert.Equal(t, StateStopped, s.Status()[0].State)
+}
+
+func TestSupervisorBacksOffRepeatedFailures(t *testing.T) {
+	s := New(config.BackgroundConfig{MinBackoff: time.Hour, MaxBackoff: 2 * time.Hour})
+	s.Add("broken", func(ctx context.Context) error {
+		return errors.New("no database")
+	})
+
+	s.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return s.Status()[0].State == StateRestarting
+	}, time.Second, time.Millisecond)
+
+	status := s.Status()[0]
+	assert.Equal(t, "no database", status.LastError)
+	assert.Equal(t, 0, status.Restarts)
+	require.NotNil(t, status.NextRestartAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *status.NextRestartAt, time.Minute)
+
+	// Stopping doesn't wait out the backoff
+	s.Stop()
+	status = s.Status()[0]
+	assert.Equal(t, StateStopped, status.State)
+	assert.Nil(t, status.NextRestartAt)
+}
+
+func TestSupervisorTreatsReturnAsFailure(t *testing.T) {
+	s := New(fastBackoff)
+	var runs atomic.Int32
+	s.Add("quitter", func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			return nil
+		}
+		<-ctx.Done()
+		return nil
+	})
+
+	s.Start(context.Background())
+	defer s.Stop()
+	require.Eventually(t, func() bool { return runs.Load() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "exited unexpectedly", s.Status()[0].LastError)
+}
+
+func TestGetStatusListsServices(t *testing.T) {
+	s := New(fastBackoff)
+	s.Add("analytics_scheduler", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	app := fiber.New()
+	NewHandlers(s).RegisterRoutes(app)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/background-status", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Services []Status `json:"services"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Services, 1)
+	assert.Equal(t, "analytics_scheduler", body.Services[0].Name)
+	assert.Equal(t, StateStopped, body.Services[0].State)
+}
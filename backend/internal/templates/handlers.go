@@ -0,0 +1,301 @@
+package templates
+
+import (
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxTemplatesPerUser bounds how many templates a user can save
+const maxTemplatesPerUser = 50
+
+type Handlers struct {
+	repo Repository
+}
+
+func NewHandlers(repo Repository) *Handlers {
+	return &Handlers{
+		repo: repo,
+	}
+}
+
+// RegisterRoutes registers template routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	templates := router.Group("/templates")
+	templates.Get("/", h.ListTemplates)
+	templates.Post("/", h.CreateTemplate)
+	templates.Get("/:id", h.GetTemplate)
+	templates.Put("/:id", h.UpdateTemplate)
+	templates.Delete("/:id", h.DeleteTemplate)
+	templates.Post("/:id/preview", h.PreviewTemplate)
+}
+
+// templateRequest is the body for creating or updating a template
+type templateRequest struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+func (req templateRequest) template(userID string) (*Template, error) {
+	template := &Template{
+		UserID:      userID,
+		Name:        req.Name,
+		Title:       req.Title,
+		Description: req.Description,
+		Tags:        req.Tags,
+	}
+	if err := template.Normalize(); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ListTemplates returns the user's templates and the variables they can use
+func (h *Handlers) ListTemplates(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	templates, err := h.repo.ListTemplates(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing templates for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list templates",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"templates": templates,
+		"variables": knownVariables,
+	})
+}
+
+// CreateTemplate saves a new template
+func (h *Handlers) CreateTemplate(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req templateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	template, err := req.template(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	existing, err := h.repo.ListTemplates(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing templates for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create template",
+		})
+	}
+	if len(existing) >= maxTemplatesPerUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Template limit reached, delete an existing template first",
+		})
+	}
+
+	if err := h.repo.CreateTemplate(c.UserContext(), template); err != nil {
+		log.Printf("Error creating template for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create template",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"template": template,
+	})
+}
+
+// GetTemplate returns one of the user's templates
+func (h *Handlers) GetTemplate(c *fiber.Ctx) error {
+	template, err := h.loadTemplate(c)
+	if template == nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"template": template,
+	})
+}
+
+// UpdateTemplate replaces one of the user's templates
+func (h *Handlers) UpdateTemplate(c *fiber.Ctx) error {
+	current, err := h.loadTemplate(c)
+	if current == nil {
+		return err
+	}
+
+	var req templateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	template, err := req.template(current.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	template.ID = current.ID
+
+	found, err := h.repo.UpdateTemplate(c.UserContext(), template)
+	if err != nil {
+		log.Printf("Error updating template %d for user %s: %v", template.ID, template.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update template",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Template not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"template": template,
+	})
+}
+
+// DeleteTemplate deletes one of the user's templates. Jobs already queued
+// with it keep their rendered texts.
+func (h *Handlers) DeleteTemplate(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid template ID",
+		})
+	}
+
+	found, err := h.repo.DeleteTemplate(c.UserContext(), user.ID, id)
+	if err != nil {
+		log.Printf("Error deleting template %d for user %s: %v", id, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete template",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Template not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Template deleted",
+		"id":      id,
+	})
+}
+
+// previewRequest picks the clip a template is previewed with
+type previewRequest struct {
+	ClipID string `json:"clip_id"`
+}
+
+// PreviewTemplate renders a template for one of the user's clips, or for
+// example values when no clip is given
+func (h *Handlers) PreviewTemplate(c *fiber.Ctx) error {
+	template, err := h.loadTemplate(c)
+	if template == nil {
+		return err
+	}
+
+	var req previewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	vars := exampleVars()
+	if req.ClipID != "" {
+		clipVars, err := h.repo.ClipVars(c.UserContext(), template.UserID, req.ClipID)
+		if err != nil {
+			log.Printf("Error loading clip %s for user %s: %v", req.ClipID, template.UserID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to preview template",
+			})
+		}
+		if clipVars == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Clip not found",
+			})
+		}
+		vars = *clipVars
+	}
+
+	return c.JSON(fiber.Map{
+		"rendered":  template.Render(vars),
+		"variables": vars,
+	})
+}
+
+func (h *Handlers) loadTemplate(c *fiber.Ctx) (*Template, error) {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid template ID",
+		})
+	}
+
+	template, err := h.repo.GetTemplate(c.UserContext(), user.ID, id)
+	if err != nil {
+		log.Printf("Error loading template %d for user %s: %v", id, user.ID, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load template",
+		})
+	}
+	if template == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Template not found",
+		})
+	}
+	return template, nil
+}
+
+// exampleVars are shown in previews before the user picks a clip
+func exampleVars() Vars {
+	date := time.Now().UTC()
+	return Vars{
+		Game:       "Minecraft",
+		StreamDate: &date,
+		ClipTitle:  "Clutch save at the last second",
+		Channel:    "yourchannel",
+		ClipID:     "ExampleClipSlug",
+	}
+}
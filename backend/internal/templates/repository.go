@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListTemplates(ctx context.Context, userID string) ([]Template, error)
+	GetTemplate(ctx context.Context, userID string, id int) (*Template, error)
+	CreateTemplate(ctx context.Context, template *Template) error
+	UpdateTemplate(ctx context.Context, template *Template) (bool, error)
+	DeleteTemplate(ctx context.Context, userID string, id int) (bool, error)
+
+	// ClipVars returns the variables of one of the user's collected clips, or
+	// nil if they have no such clip
+	ClipVars(ctx context.Context, userID, clipID string) (*Vars, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const templateColumns = `id, user_id, name, title_template, description_template, tags, created_at, updated_at`
+
+func (r *repository) ListTemplates(ctx context.Context, userID string) ([]Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM content_templates WHERE user_id = $1 ORDER BY name, id`
+
+	templates := []Template{}
+	err := r.db.SelectContext(ctx, &templates, query, userID)
+	return templates, err
+}
+
+func (r *repository) GetTemplate(ctx context.Context, userID string, id int) (*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM content_templates WHERE id = $1 AND user_id = $2`
+
+	var template Template
+	err := r.db.GetContext(ctx, &template, query, id, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &template, err
+}
+
+func (r *repository) CreateTemplate(ctx context.Context, template *Template) error {
+	query := `
+		INSERT INTO content_templates (user_id, name, title_template, description_template, tags)
+		VALUES ($1, $2, $3, $4, $5::jsonb)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowxContext(ctx, query, template.UserID, template.Name, template.Title,
+		template.Description, template.Tags).
+		Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+}
+
+// UpdateTemplate saves a template, returning false if the user has no such template
+func (r *repository) UpdateTemplate(ctx context.Context, template *Template) (bool, error) {
+	query := `
+		UPDATE content_templates
+		SET name = $3, title_template = $4, description_template = $5, tags = $6::jsonb, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING created_at, updated_at
+	`
+	err := r.db.QueryRowxContext(ctx, query, template.ID, template.UserID, template.Name, template.Title,
+		template.Description, template.Tags).
+		Scan(&template.CreatedAt, &template.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *repository) DeleteTemplate(ctx context.Context, userID string, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM content_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ClipVars takes the game and stream date from the stream the clip was made
+// during. Clips of streams that weren't collected only have their own date.
+func (r *repository) ClipVars(ctx context.Context, userID, clipID string) (*Vars, error) {
+	query := `
+		SELECT c.video_id AS clip_id, COALESCE(c.title, '') AS clip_title,
+			   COALESCE(u.username, '') AS channel, COALESCE(s.game_name, '') AS game,
+			   COALESCE(s.started_at, c.published_at) AS stream_date
+		FROM video_analytics c
+		LEFT JOIN users u ON u.id = c.user_id
+		LEFT JOIN LATERAL (
+			SELECT game_name, started_at FROM stream_sessions
+			WHERE user_id = c.user_id AND started_at <= c.published_at
+			  AND (ended_at IS NULL OR ended_at >= c.published_at)
+			ORDER BY started_at DESC
+			LIMIT 1
+		) s ON true
+		WHERE c.user_id = $1 AND c.video_id = $2 AND c.video_type = 'clip'
+	`
+
+	var vars Vars
+	err := r.db.GetContext(ctx, &vars, query, userID, clipID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &vars, err
+}
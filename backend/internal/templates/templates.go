@@ -0,0 +1,199 @@
+// Package templates lets users save reusable title, description and tag
+// templates for cross-posting. The texts use variables like {{game}} and
+// {{clip_title}}, filled in for a clip when it's queued for publishing or for
+// the channel when a media kit is generated.
+package templates
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Variables templates can use
+const (
+	VarGame       = "game"
+	VarStreamDate = "stream_date"
+	VarClipTitle  = "clip_title"
+	VarChannel    = "channel"
+	VarClipURL    = "clip_url"
+)
+
+var knownVariables = []string{VarGame, VarStreamDate, VarClipTitle, VarChannel, VarClipURL}
+
+const (
+	maxNameLength        = 100
+	maxTitleLength       = 200  // characters, before rendering
+	maxDescriptionLength = 5000 // bytes
+	maxTags              = 30
+	maxTagLength         = 100 // characters
+)
+
+// variablePattern matches {{name}}, allowing spaces inside the braces
+var variablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_]+)\s*\}\}`)
+
+// TagList is stored as a JSON array
+type TagList []string
+
+func (t TagList) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(t))
+	return string(data), err
+}
+
+func (t *TagList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into TagList", src)
+	}
+}
+
+// Template is a saved title, description and tags
+type Template struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      string    `json:"-" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Title       string    `json:"title" db:"title_template"`
+	Description string    `json:"description" db:"description_template"`
+	Tags        TagList   `json:"tags" db:"tags"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Normalize trims and validates the template, de-duplicating tags. Texts may
+// only use known variables, so typos show up when saving rather than in a
+// published title.
+func (t *Template) Normalize() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || utf8.RuneCountInString(t.Name) > maxNameLength {
+		return fmt.Errorf("name must be 1-%d characters", maxNameLength)
+	}
+
+	t.Title = strings.TrimSpace(t.Title)
+	if t.Title == "" || utf8.RuneCountInString(t.Title) > maxTitleLength {
+		return fmt.Errorf("title must be 1-%d characters", maxTitleLength)
+	}
+	t.Description = strings.TrimSpace(t.Description)
+	if len(t.Description) > maxDescriptionLength {
+		return fmt.Errorf("description must be at most %d bytes", maxDescriptionLength)
+	}
+
+	seen := make(map[string]bool)
+	tags := TagList{}
+	for _, tag := range t.Tags {
+		tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return fmt.Errorf("tags must be at most %d characters", maxTagLength)
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxTags {
+		return fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	t.Tags = tags
+
+	for _, text := range append([]string{t.Title, t.Description}, t.Tags...) {
+		for _, match := range variablePattern.FindAllStringSubmatch(text, -1) {
+			if !isKnownVariable(match[1]) {
+				return fmt.Errorf("unknown variable {{%s}} (valid: %s)", match[1], strings.Join(knownVariables, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func isKnownVariable(name string) bool {
+	for _, known := range knownVariables {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Vars are the values filled into a template. Unknown values are left empty.
+type Vars struct {
+	Game       string     `json:"game" db:"game"`
+	StreamDate *time.Time `json:"stream_date" db:"stream_date"`
+	ClipTitle  string     `json:"clip_title" db:"clip_title"`
+	Channel    string     `json:"channel" db:"channel"`
+	ClipID     string     `json:"-" db:"clip_id"`
+}
+
+// value returns the text a variable is replaced with
+func (v Vars) value(name string) string {
+	switch name {
+	case VarGame:
+		return v.Game
+	case VarStreamDate:
+		if v.StreamDate == nil {
+			return ""
+		}
+		return v.StreamDate.UTC().Format("2006-01-02")
+	case VarClipTitle:
+		return v.ClipTitle
+	case VarChannel:
+		return v.Channel
+	case VarClipURL:
+		if v.ClipID == "" {
+			return ""
+		}
+		return "https://clips.twitch.tv/" + v.ClipID
+	}
+	return ""
+}
+
+// Render fills the variables into text. Unknown variables are left as they
+// are, so a template saved before a variable was removed still reads sensibly.
+func Render(text string, vars Vars) string {
+	return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if !isKnownVariable(name) {
+			return match
+		}
+		return vars.value(name)
+	})
+}
+
+// Rendered is a template filled in for one clip or channel
+type Rendered struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// Render fills the variables into the template's texts. Tags that render
+// empty, e.g. {{game}} for a clip without a known game, are dropped.
+func (t *Template) Render(vars Vars) Rendered {
+	rendered := Rendered{
+		Title:       strings.TrimSpace(Render(t.Title, vars)),
+		Description: strings.TrimSpace(Render(t.Description, vars)),
+		Tags:        []string{},
+	}
+	seen := make(map[string]bool)
+	for _, tag := range t.Tags {
+		tag = strings.TrimSpace(Render(tag, vars))
+		if tag != "" && !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			rendered.Tags = append(rendered.Tags, tag)
+		}
+	}
+	return rendered
+}
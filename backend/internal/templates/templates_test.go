@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateNormalize(t *testing.T) {
+	template := &Template{
+		Name:  "  Shorts ",
+		Title: " {{clip_title}} | {{ game }} ",
+		Tags:  TagList{"#Twitch", " twitch ", "", "{{game}}"},
+	}
+	require.NoError(t, template.Normalize())
+	assert.Equal(t, "Shorts", template.Name)
+	assert.Equal(t, "{{clip_title}} | {{ game }}", template.Title)
+	assert.Equal(t, TagList{"Twitch", "{{game}}"}, template.Tags)
+
+	for name, invalid := range map[string]Template{
+		"no name":          {Title: "{{clip_title}}"},
+		"no title":         {Name: "Shorts", Title: " "},
+		"long description": {Name: "Shorts", Title: "x", Description: strings.Repeat("x", maxDescriptionLength+1)},
+		"unknown variable": {Name: "Shorts", Title: "{{clip_name}}"},
+		"unknown tag":      {Name: "Shorts", Title: "x", Tags: TagList{"{{views}}"}},
+		"long tag":         {Name: "Shorts", Title: "x", Tags: TagList{strings.Repeat("x", maxTagLength+1)}},
+	} {
+		err := invalid.Normalize()
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRender(t *testing.T) {
+	streamed := time.Date(2025, 3, 14, 22, 30, 0, 0, time.UTC)
+	vars := Vars{Game: "Elden Ring", StreamDate: &streamed, ClipTitle: "No hit Malenia", Channel: "baldy", ClipID: "Clip-abc"}
+
+	assert.Equal(t, "No hit Malenia | Elden Ring (2025-03-14)", Render("{{clip_title}} | {{ game }} ({{stream_date}})", vars))
+	assert.Equal(t, "twitch.tv/baldy https://clips.twitch.tv/Clip-abc", Render("twitch.tv/{{channel}} {{clip_url}}", vars))
+	// Unknown variables are left alone
+	assert.Equal(t, "{{views}} views", Render("{{views}} views", vars))
+
+	template := &Template{
+		Title:       "{{clip_title}}",
+		Description: "Streamed {{stream_date}}\n",
+		Tags:        TagList{"{{game}}", "elden ring", "{{channel}}"},
+	}
+	rendered := template.Render(vars)
+	assert.Equal(t, "No hit Malenia", rendered.Title)
+	assert.Equal(t, "Streamed 2025-03-14", rendered.Description)
+	assert.Equal(t, []string{"Elden Ring", "baldy"}, rendered.Tags)
+
+	// Tags without a value are dropped
+	rendered = template.Render(Vars{ClipTitle: "Clutch"})
+	assert.Equal(t, []string{"elden ring"}, rendered.Tags)
+	assert.Equal(t, "Streamed", rendered.Description)
+}
+
+func TestTagListScan(t *testing.T) {
+	var tags TagList
+	require.NoError(t, tags.Scan([]byte(`["a","b"]`)))
+	assert.Equal(t, TagList{"a", "b"}, tags)
+
+	value, err := TagList(nil).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "[]", value)
+}
@@ -0,0 +1,1310 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/enrichment"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// seedUser creates a connected user so analytics rows can reference it
+func seedUser(t testing.TB, userID string) analytics.Repository {
+	t.Helper()
+
+	repo := analytics.NewRepository(db.GetDB())
+	require.NoError(t, repo.CreateOrUpdateUser(context.Background(), &analytics.User{
+		ID:           userID,
+		ClerkUserID:  userID,
+		TwitchUserID: fakeTwitchUserID,
+		Username:     "integration",
+		DisplayName:  "Integration",
+	}))
+	return repo
+}
+
+func TestAnalyticsAuth(t *testing.T) {
+	forger, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"malformed token", "not-a-jwt", http.StatusUnauthorized},
+		{"forged signature", sessionToken(t, forger, "user_auth", time.Now().Add(time.Hour)), http.StatusUnauthorized},
+		{"expired token", sessionToken(t, signingKey, "user_auth", time.Now().Add(-time.Hour)), http.StatusUnauthorized},
+		{"valid token", sessionToken(t, signingKey, "user_auth", time.Now().Add(time.Hour)), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, call(t, http.MethodGet, "/api/analytics/overview", tt.token, nil))
+		})
+	}
+
+	// Health stays public
+	assert.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/health", "", nil))
+}
+
+func TestEnhancedAnalyticsAggregatesVideos(t *testing.T) {
+	userID := "user_enhanced"
+	seedUser(t, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, published_at)
+		VALUES ($1, 'v_enh_1', 'First', 'archive', 3600, 300, NOW() - INTERVAL '2 days'),
+		       ($1, 'v_enh_2', 'Second', 'archive', 1800, 100, NOW() - INTERVAL '1 day')
+	`, userID)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`
+		INSERT INTO channel_analytics (user_id, date, followers_count, subscriber_count)
+		VALUES ($1, CURRENT_DATE, 1200, 40)
+	`, userID)
+	require.NoError(t, err)
+
+	var body analytics.EnhancedAnalytics
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/enhanced?days=30", token, &body))
+
+	assert.Equal(t, 2, body.Overview.VideoCount)
+	assert.Equal(t, 400, body.Overview.TotalViews)
+	assert.InDelta(t, 200, body.Overview.AverageViewsPerVideo, 0.001)
+	assert.InDelta(t, 1.5, body.Overview.TotalWatchTimeHours, 0.001)
+	assert.Equal(t, 1200, body.Overview.CurrentFollowers)
+	assert.Equal(t, 40, body.Overview.CurrentSubscribers)
+	require.Len(t, body.TopVideos, 2)
+	assert.Equal(t, 300, body.TopVideos[0].ViewCount)
+	require.Len(t, body.RecentVideos, 2)
+	assert.Equal(t, "v_enh_2", body.RecentVideos[0].VideoID)
+	require.NotEmpty(t, body.Performance.ViewsOverTime)
+	assert.Len(t, body.Performance.ContentDistribution, len(body.Performance.ViewsOverTime))
+}
+
+// BenchmarkEnhancedAnalytics measures the dashboard query for a creator with a
+// large library. Run with -bench EnhancedAnalytics -run '^$'.
+func BenchmarkEnhancedAnalytics(b *testing.B) {
+	userID := "user_bench_enhanced"
+	repo := seedUser(b, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, thumbnail_url, published_at)
+		SELECT $1, 'v_bench_' || n, 'Video ' || n, (ARRAY['archive', 'clip', 'upload'])[n % 3 + 1],
+		       n % 7200, (n * 37) % 5000, 'https://example.com/' || n || '.jpg', NOW() - n * INTERVAL '1 hour'
+		FROM generate_series(1, 2000) AS n
+	`, userID)
+	require.NoError(b, err)
+
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.GetEnhancedAnalytics(ctx, userID, 30); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEnhancedAnalyticsWithoutData(t *testing.T) {
+	userID := "user_empty"
+	seedUser(t, userID)
+
+	var body analytics.EnhancedAnalytics
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/enhanced", token, &body))
+
+	assert.Zero(t, body.Overview.VideoCount)
+	assert.NotNil(t, body.TopVideos)
+}
+
+func TestEnhancedAnalyticsFieldsAndVideoEndpoints(t *testing.T) {
+	userID := "user_fields"
+	seedUser(t, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, thumbnail_url, published_at)
+		VALUES ($1, 'v_fld_1', 'Popular', 'archive', 3600, 900, 'https://example.com/1.jpg', NOW() - INTERVAL '5 days'),
+		       ($1, 'v_fld_2', 'Newest', 'clip', 30, 250, 'https://example.com/2.jpg', NOW() - INTERVAL '1 hour')
+	`, userID)
+	require.NoError(t, err)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	var body map[string]any
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/enhanced?fields=overview.totalViews,topVideos.video_id", token, &body))
+	assert.Len(t, body, 2)
+	assert.Equal(t, map[string]any{"totalViews": float64(1150)}, body["overview"])
+	assert.Equal(t, []any{
+		map[string]any{"video_id": "v_fld_1"},
+		map[string]any{"video_id": "v_fld_2"},
+	}, body["topVideos"])
+
+	require.Equal(t, http.StatusBadRequest, call(t, http.MethodGet, "/api/analytics/enhanced?fields=nope", token, nil))
+
+	var videos struct {
+		Videos []analytics.VideoAnalytics `json:"videos"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/top-videos?type=clip", token, &videos))
+	require.Len(t, videos.Videos, 1)
+	assert.Equal(t, "v_fld_2", videos.Videos[0].VideoID)
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/recent-videos?limit=1", token, &videos))
+	require.Len(t, videos.Videos, 1)
+	assert.Equal(t, "v_fld_2", videos.Videos[0].VideoID)
+
+	// 900 views over 5 days rank below 250 views in the first day
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/top-videos", token, &videos))
+	require.Len(t, videos.Videos, 2)
+	assert.Equal(t, "v_fld_1", videos.Videos[0].VideoID)
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/top-videos?rank=views_per_day", token, &videos))
+	require.Len(t, videos.Videos, 2)
+	assert.Equal(t, "v_fld_2", videos.Videos[0].VideoID)
+
+	require.Equal(t, http.StatusUnprocessableEntity, call(t, http.MethodGet, "/api/analytics/top-videos?rank=likes", token, nil))
+}
+
+func TestRefreshCollectsFromTwitch(t *testing.T) {
+	userID := "user_refresh"
+	repo := seedUser(t, userID)
+
+	// A token from our own OAuth flow, so collection doesn't ask Clerk
+	tokens := analytics.NewTwitchTokenHelper(repo, nil)
+	require.NoError(t, tokens.StoreToken(context.Background(), userID, fakeTwitchUserID, &twitch.OAuthToken{
+		AccessToken:  "fake-access",
+		RefreshToken: "fake-refresh",
+		ExpiresIn:    3600,
+		Scope:        []string{"channel:read:subscriptions"},
+	}))
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodPost, "/api/analytics/refresh", token, nil))
+
+	var overview analytics.DashboardOverview
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/overview", token, &overview))
+	assert.Equal(t, fakeFollowerCount, overview.CurrentFollowers)
+	assert.Equal(t, 98765, overview.TotalViews)
+}
+
+func TestDisconnectTwitchDeletesStoredToken(t *testing.T) {
+	userID := "user_disconnect"
+	repo := seedUser(t, userID)
+
+	tokens := analytics.NewTwitchTokenHelper(repo, nil)
+	require.NoError(t, tokens.StoreToken(context.Background(), userID, fakeTwitchUserID, &twitch.OAuthToken{
+		AccessToken:  "fake-access",
+		RefreshToken: "fake-refresh",
+		ExpiresIn:    3600,
+	}))
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	var disconnected struct {
+		Status string                 `json:"status"`
+		Job    analytics.AnalyticsJob `json:"job"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodDelete, "/api/twitch/connect", token, &disconnected))
+	assert.Equal(t, "completed", disconnected.Status)
+	assert.Equal(t, "twitch_cleanup", disconnected.Job.JobType)
+	assert.Equal(t, analytics.TwitchCleanupSteps, disconnected.Job.ProgressCompleted)
+
+	user, err := repo.GetUserByClerkID(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Empty(t, user.TwitchUserID)
+
+	stored, err := repo.GetTwitchToken(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestPreviewFrontendCompletesTwitchConnectFlow(t *testing.T) {
+	previewCallback := "https://api-pr-12.creatorsync.dev/api/auth/twitch/callback"
+	t.Setenv("TWITCH_REDIRECT_URI", "https://api.creatorsync.app/api/auth/twitch/callback,"+previewCallback)
+	t.Setenv("FRONTEND_ORIGINS", "https://*.creatorsync.pages.dev")
+	userID := "user_preview_connect"
+	seedUser(t, userID)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	query := url.Values{
+		"redirect_uri": {previewCallback},
+		"return_to":    {"https://pr-12.creatorsync.pages.dev/settings"},
+	}
+	var connect struct {
+		AuthURL     string `json:"auth_url"`
+		RedirectURI string `json:"redirect_uri"`
+		ReturnTo    string `json:"return_to"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/twitch/connect?"+query.Encode(), token, &connect))
+	assert.Equal(t, previewCallback, connect.RedirectURI)
+	assert.Equal(t, "https://pr-12.creatorsync.pages.dev", connect.ReturnTo)
+
+	authURL, err := url.Parse(connect.AuthURL)
+	require.NoError(t, err)
+	assert.Equal(t, previewCallback, authURL.Query().Get("redirect_uri"))
+
+	// Denying consent returns the user to the preview they started on
+	callback := url.Values{"error": {"access_denied"}, "state": {authURL.Query().Get("state")}}
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/auth/twitch/callback?"+callback.Encode(), nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://pr-12.creatorsync.pages.dev/dashboard?reason=access_denied&twitch=error", resp.Header.Get("Location"))
+
+	for _, rejected := range []url.Values{
+		{"return_to": {"https://creatorsync.pages.dev.evil.example"}},
+		{"redirect_uri": {"https://evil.example/api/auth/twitch/callback"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, call(t, http.MethodGet, "/api/twitch/connect?"+rejected.Encode(), token, nil))
+	}
+}
+
+func TestCollectionReportIsSavedOnJob(t *testing.T) {
+	userID := "user_collection_report"
+	repo := seedUser(t, userID)
+
+	tokens := analytics.NewTwitchTokenHelper(repo, nil)
+	require.NoError(t, tokens.StoreToken(context.Background(), userID, fakeTwitchUserID, &twitch.OAuthToken{
+		AccessToken:  "fake-access",
+		RefreshToken: "fake-refresh",
+		ExpiresIn:    3600,
+		Scope:        []string{"channel:read:subscriptions"},
+	}))
+
+	client, err := twitch.NewClient("integration-client", "integration-secret")
+	require.NoError(t, err)
+	report, err := analytics.NewDataCollector(repo, client).CollectAllUserData(context.Background(), userID, analytics.DefaultCollectionOptions())
+	require.NoError(t, err)
+
+	steps := make(map[string]analytics.CollectionStep)
+	for _, step := range report.Steps {
+		steps[step.Name] = step
+	}
+	assert.Equal(t, analytics.CollectionCompleted, steps["channel"].Status)
+	assert.Equal(t, analytics.CollectionCompleted, steps["videos"].Status)
+
+	var resp struct {
+		Jobs []analytics.AnalyticsJob `json:"jobs"`
+	}
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/jobs?limit=50", token, &resp))
+
+	var saved *analytics.AnalyticsJob
+	for i, job := range resp.Jobs {
+		if job.JobType == "user_collection" {
+			saved = &resp.Jobs[i]
+		}
+	}
+	require.NotNil(t, saved)
+	assert.Equal(t, report.Status, saved.Status)
+	require.NotNil(t, saved.Report)
+
+	var savedReport analytics.CollectionReport
+	require.NoError(t, json.Unmarshal(*saved.Report, &savedReport))
+	assert.Equal(t, report.Succeeded, savedReport.Succeeded)
+	assert.Len(t, savedReport.Steps, len(report.Steps))
+}
+
+func TestListVideosPaginates(t *testing.T) {
+	userID := "user_library"
+	seedUser(t, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, published_at)
+		SELECT $1, 'v_lib_' || n, 'Video ' || n, 'archive', 600, n * 10, NOW() - n * INTERVAL '1 day'
+		FROM generate_series(1, 5) AS n
+	`, userID)
+	require.NoError(t, err)
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	var first analytics.VideoPage
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/videos?limit=2", token, &first))
+	assert.Equal(t, 5, first.Total)
+	require.Len(t, first.Videos, 2)
+	assert.Equal(t, "v_lib_1", first.Videos[0].VideoID)
+	require.NotNil(t, first.NextOffset)
+	assert.Equal(t, 2, *first.NextOffset)
+
+	var last analytics.VideoPage
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/videos?limit=2&offset=4", token, &last))
+	require.Len(t, last.Videos, 1)
+	assert.Equal(t, "v_lib_5", last.Videos[0].VideoID)
+	assert.Nil(t, last.NextOffset)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, http.MethodGet, "/api/analytics/videos?offset=-1", token, nil))
+}
+
+func TestListVideosSortsAndFilters(t *testing.T) {
+	userID := "user_library_filters"
+	seedUser(t, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, published_at)
+		VALUES ($1, 'v_flt_1', 'Any% speedrun attempts', 'archive', 7200, 50, '2025-05-01T20:00:00Z'),
+		       ($1, 'v_flt_2', 'Speedrun highlights', 'highlight', 300, 900, '2025-05-10T20:00:00Z'),
+		       ($1, 'v_flt_3', 'Cozy farming', 'archive', 5400, 400, '2025-06-01T20:00:00Z'),
+		       ($1, 'v_flt_4', 'Best clip', 'clip', 30, 2000, '2025-06-02T20:00:00Z')
+	`, userID)
+	require.NoError(t, err)
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	ids := func(path string) []string {
+		var page analytics.VideoPage
+		require.Equal(t, http.StatusOK, call(t, http.MethodGet, path, token, &page))
+		var out []string
+		for _, video := range page.Videos {
+			out = append(out, video.VideoID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"v_flt_4", "v_flt_2", "v_flt_3", "v_flt_1"}, ids("/api/analytics/videos?sort=views"))
+	assert.Equal(t, []string{"v_flt_4", "v_flt_2", "v_flt_3", "v_flt_1"}, ids("/api/analytics/videos?sort=duration&order=asc"))
+	assert.Equal(t, []string{"v_flt_3", "v_flt_1"}, ids("/api/analytics/videos?type=archive"))
+	assert.Equal(t, []string{"v_flt_2", "v_flt_1"}, ids("/api/analytics/videos?q=SPEEDRUN"))
+	assert.Equal(t, []string{"v_flt_1"}, ids("/api/analytics/videos?q=any%25"))
+	assert.Equal(t, []string{"v_flt_3", "v_flt_2"}, ids("/api/analytics/videos?from=2025-05-05&to=2025-06-01"))
+
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodGet, "/api/analytics/videos?sort=title", token, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodGet, "/api/analytics/videos?from=yesterday", token, nil))
+}
+
+func TestKeywordInsightsFromEnrichedTitles(t *testing.T) {
+	userID := "user_keywords"
+	seedUser(t, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, published_at)
+		VALUES ($1, 'v_kw_1', 'Celeste speedrun attempts', 'archive', 3600, 900, NOW() - INTERVAL '3 days'),
+		       ($1, 'v_kw_2', 'Celeste speedrun practice', 'archive', 3600, 700, NOW() - INTERVAL '2 days'),
+		       ($1, 'v_kw_3', 'Cozy farming chat', 'archive', 3600, 100, NOW() - INTERVAL '1 day'),
+		       ($1, 'v_kw_4', 'Cozy farming again', 'archive', 3600, 100, NOW()),
+		       ($1, 'v_kw_5', 'Celeste clip', 'clip', 30, 50000, NOW())
+	`, userID)
+	require.NoError(t, err)
+
+	require.NoError(t, enrichment.NewEnricher(enrichment.NewRepository(db.GetDB())).EnrichUser(context.Background(), userID))
+
+	var body analytics.KeywordInsights
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/keywords", token, &body))
+
+	// Clips are left out of the baseline
+	assert.Equal(t, 4, body.VideosAnalyzed)
+	assert.InDelta(t, 450, body.BaselineAvgViews, 0.001)
+	require.Len(t, body.Keywords, 4)
+	assert.Equal(t, "celeste", body.Keywords[0].Keyword)
+	assert.Equal(t, 2, body.Keywords[0].Videos)
+	assert.InDelta(t, 800.0/450.0, body.Keywords[0].Lift, 0.001)
+	assert.Equal(t, "cozy", body.Keywords[2].Keyword)
+}
+
+func TestOverviewIncludesConsistency(t *testing.T) {
+	userID := "user_consistency"
+	seedUser(t, userID)
+	ctx := context.Background()
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, published_at)
+		VALUES ($1, 'v_cs_1', 'Monday stream', 'vod', 7200, 100, NOW() - INTERVAL '15 days'),
+		       ($1, 'v_cs_2', 'Thursday stream', 'archive', 7200, 100, NOW() - INTERVAL '8 days'),
+		       ($1, 'v_cs_3', 'Edited recap', 'upload', 600, 100, NOW() - INTERVAL '2 days'),
+		       ($1, 'v_cs_4', 'Highlight', 'highlight', 60, 100, NOW() - INTERVAL '1 day')
+	`, userID)
+	require.NoError(t, err)
+
+	repo := analytics.NewRepository(db.GetDB())
+	streams, uploads, err := repo.GetBroadcastTimes(ctx, userID, time.Now().AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Len(t, streams, 2)
+	assert.Len(t, uploads, 1)
+
+	// Both unlinked VODs count as two hour streams
+	streamCount, hours, err := repo.GetStreamActivity(ctx, userID, time.Now().AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, 2, streamCount)
+	assert.InDelta(t, 4, hours, 0.001)
+
+	require.NoError(t, repo.SaveChannelConsistency(ctx, &analytics.ChannelConsistency{
+		UserID:                userID,
+		CurrentStreakWeeks:    2,
+		LongestStreakWeeks:    2,
+		AverageStreamsPerWeek: 0.67,
+		LongestGapDays:        8,
+		LastStreamAt:          &streams[1],
+		StreamsLast30Days:     streamCount,
+		HoursStreamedLast30:   hours,
+	}))
+
+	var body analytics.DashboardOverview
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/overview", token, &body))
+	require.NotNil(t, body.Consistency)
+	assert.Equal(t, 2, body.Consistency.CurrentStreakWeeks)
+	assert.Equal(t, 8, body.Consistency.LongestGapDays)
+	assert.Equal(t, 2, body.StreamsLast30Days)
+	assert.InDelta(t, 4, body.HoursStreamedLast30, 0.001)
+}
+
+func TestAudienceChangeFollowsRequestedRange(t *testing.T) {
+	userID := "user_audience_change"
+	seedUser(t, userID)
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO channel_analytics (user_id, date, followers_count, subscriber_count)
+		VALUES ($1, CURRENT_DATE, 1200, 40),
+		       ($1, CURRENT_DATE - 7, 1000, 50),
+		       ($1, CURRENT_DATE - 30, 800, 20),
+		       ($1, CURRENT_DATE - 40, 500, 10)
+	`, userID)
+	require.NoError(t, err)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	var overview analytics.DashboardOverview
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/overview", token, &overview))
+	assert.Equal(t, 7, overview.ChangeDays)
+	assert.Equal(t, 200, overview.FollowerChange)
+	assert.InDelta(t, 20, overview.FollowerChangePercent, 0.001)
+	assert.Equal(t, -10, overview.SubscriberChange)
+	assert.InDelta(t, -20, overview.SubscriberChangePercent, 0.001)
+	require.NotNil(t, overview.ChangeSince)
+	assert.Equal(t, time.Now().UTC().AddDate(0, 0, -7).Format("2006-01-02"), overview.ChangeSince.UTC().Format("2006-01-02"))
+
+	// 20 days back falls between snapshots, the older one is used
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/overview?days=20", token, &overview))
+	assert.Equal(t, 400, overview.FollowerChange)
+
+	// Ranges longer than the history compare with the oldest snapshot
+	var enhanced analytics.EnhancedAnalytics
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/enhanced?days=90", token, &enhanced))
+	assert.Equal(t, 700, enhanced.Overview.FollowerChange)
+	assert.InDelta(t, 140, enhanced.Overview.FollowerChangePercent, 0.001)
+	assert.Zero(t, enhanced.Overview.SubscriberChange, "no collected snapshot is that old")
+}
+
+func TestAudienceChangeIgnoresBackfilledSnapshots(t *testing.T) {
+	userID := "user_audience_backfill"
+	repo := seedUser(t, userID)
+	ctx := context.Background()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	require.NoError(t, repo.SaveChannelAnalytics(ctx, &analytics.ChannelAnalytics{
+		UserID: userID, Date: today, FollowersCount: 1200, SubscriberCount: 40, TotalViews: 5000,
+	}))
+	inserted, err := repo.BackfillChannelAnalytics(ctx, []analytics.ChannelAnalytics{
+		{UserID: userID, Date: today.AddDate(0, 0, -10), FollowersCount: 1000, Source: analytics.ChannelSourceBackfill},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, inserted)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	// Followers compare with the backfilled day, subscribers and views have
+	// nothing to compare with
+	var overview analytics.DashboardOverview
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/overview", token, &overview))
+	assert.Equal(t, 200, overview.FollowerChange)
+	assert.Equal(t, 40, overview.CurrentSubscribers)
+	assert.Zero(t, overview.SubscriberChange)
+	assert.Zero(t, overview.SubscriberChangePercent)
+	assert.Equal(t, 5000, overview.TotalViews)
+	assert.Zero(t, overview.ViewChange)
+
+	var enhanced analytics.EnhancedAnalytics
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/enhanced?days=7", token, &enhanced))
+	assert.Equal(t, 200, enhanced.Overview.FollowerChange)
+	assert.Zero(t, enhanced.Overview.SubscriberChange)
+}
+
+func TestStreamScheduleComparesPlannedAndActualStreams(t *testing.T) {
+	userID := "user_schedule"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	now := time.Now().Truncate(time.Second)
+	ends := func(t time.Time) *time.Time { end := t.Add(3 * time.Hour); return &end }
+	kept := now.AddDate(0, 0, -3)
+	missed := now.AddDate(0, 0, -2)
+	upcoming := now.AddDate(0, 0, 2)
+	dropped := now.AddDate(0, 0, 3)
+
+	require.NoError(t, repo.SaveStreamSchedule(ctx, userID, now.AddDate(0, 0, -7), []analytics.StreamScheduleSegment{
+		{SegmentID: "seg_kept", Title: "Ranked grind", StartTime: kept, EndTime: ends(kept)},
+		{SegmentID: "seg_missed", Title: "Community night", StartTime: missed, EndTime: ends(missed)},
+		{SegmentID: "seg_upcoming", Title: "Speedruns", StartTime: upcoming, EndTime: ends(upcoming)},
+		{SegmentID: "seg_dropped", Title: "Removed later", StartTime: dropped, EndTime: ends(dropped)},
+	}))
+
+	// A later collection no longer has seg_dropped, past segments stay
+	require.NoError(t, repo.SaveStreamSchedule(ctx, userID, now, []analytics.StreamScheduleSegment{
+		{SegmentID: "seg_upcoming", Title: "Speedruns", StartTime: upcoming, EndTime: ends(upcoming)},
+	}))
+
+	_, err := db.GetDB().Exec(`UPDATE stream_schedule_segments SET created_at = NOW() - INTERVAL '10 days' WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`
+		INSERT INTO stream_sessions (user_id, stream_id, title, started_at)
+		VALUES ($1, 'st_sched_1', 'Ranked grind', $2),
+		       ($1, 'st_sched_2', 'Surprise stream', $3)
+	`, userID, kept.Add(10*time.Minute), now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+
+	var body analytics.StreamScheduleComparison
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/schedule?days=7", token, &body))
+
+	assert.Equal(t, 2, body.Planned)
+	assert.Equal(t, 1, body.Kept)
+	assert.Equal(t, 1, body.Missed)
+	assert.Equal(t, 1, body.Extra)
+	require.NotNil(t, body.AdherencePercent)
+	assert.InDelta(t, 50, *body.AdherencePercent, 0.001)
+	require.Len(t, body.Upcoming, 1)
+	assert.Equal(t, "seg_upcoming", body.Upcoming[0].SegmentID)
+}
+
+func TestCreatorGoalsTrackProgressAndEnd(t *testing.T) {
+	userID := "user_goals"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	started := time.Now().AddDate(0, 0, -5).Truncate(time.Second)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	followers := analytics.CreatorGoal{GoalID: "goal_followers", Type: "follower", Description: "Road to 1k", CurrentAmount: 900, TargetAmount: 1000, StartedAt: started}
+	subs := analytics.CreatorGoal{GoalID: "goal_subs", Type: "subscription_count", CurrentAmount: 40, TargetAmount: 50, StartedAt: started}
+
+	require.NoError(t, repo.SaveCreatorGoals(ctx, userID, today.AddDate(0, 0, -1), []analytics.CreatorGoal{followers, subs}))
+
+	// The sub goal is gone from Twitch by the next collection
+	followers.CurrentAmount = 950
+	require.NoError(t, repo.SaveCreatorGoals(ctx, userID, today, []analytics.CreatorGoal{followers}))
+
+	var body analytics.CreatorGoals
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/goals", token, &body))
+
+	require.Len(t, body.Active, 1)
+	assert.Equal(t, "goal_followers", body.Active[0].GoalID)
+	assert.Equal(t, 950, body.Active[0].CurrentAmount)
+	assert.InDelta(t, 95, body.Active[0].ProgressPercent, 0.001)
+	assert.Len(t, body.Active[0].History, 2)
+
+	require.Len(t, body.Ended, 1)
+	assert.Equal(t, "goal_subs", body.Ended[0].GoalID)
+	assert.NotNil(t, body.Ended[0].EndedAt)
+}
+
+func TestMutedVideoReportListsHeavilyMutedVODs(t *testing.T) {
+	userID := "user_muted"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	published := time.Now().AddDate(0, 0, -3)
+	for _, video := range []*analytics.VideoAnalytics{
+		{UserID: userID, VideoID: "v_muted_1", Title: "Music night", VideoType: "vod", Duration: 3600, PublishedAt: &published,
+			MutedSegments: []analytics.MutedSegment{{Offset: 0, Duration: 900}, {Offset: 1800, Duration: 900}}},
+		{UserID: userID, VideoID: "v_muted_2", Title: "Just chatting", VideoType: "vod", Duration: 3600, PublishedAt: &published},
+		{UserID: userID, VideoID: "v_muted_3", Title: "Clip", VideoType: "clip", Duration: 30, PublishedAt: &published},
+	} {
+		require.NoError(t, repo.SaveVideoAnalytics(ctx, video))
+	}
+
+	var report analytics.MutedVideoReport
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/muted-vods?days=30", token, &report))
+
+	assert.Equal(t, 2, report.VideosChecked)
+	assert.Equal(t, 1, report.VideosWithMuting)
+	require.Len(t, report.Videos, 1)
+	assert.Equal(t, "v_muted_1", report.Videos[0].VideoID)
+	assert.Equal(t, 1800, report.Videos[0].MutedSeconds)
+	assert.InDelta(t, 50, report.Videos[0].MutedPercent, 0.001)
+	assert.Len(t, report.Videos[0].MutedSegments, 2)
+
+	// An appeal unmutes the VOD on the next collection
+	require.NoError(t, repo.SaveVideoAnalytics(ctx, &analytics.VideoAnalytics{
+		UserID: userID, VideoID: "v_muted_1", Title: "Music night", VideoType: "vod", Duration: 3600, PublishedAt: &published,
+	}))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/muted-vods?days=30", token, &report))
+	assert.Empty(t, report.Videos)
+}
+
+func TestHighlightSuggestionsFromClipClusters(t *testing.T) {
+	userID := "user_highlights"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	published := time.Now().AddDate(0, 0, -2)
+	offset := func(seconds int) *int { return &seconds }
+	for _, video := range []*analytics.VideoAnalytics{
+		{UserID: userID, VideoID: "v_hl_vod", Title: "Boss fight", VideoType: "vod", Duration: 7200, PublishedAt: &published},
+		{UserID: userID, VideoID: "c_hl_1", Title: "NO WAY", VideoType: "clip", Duration: 30, ViewCount: 40, PublishedAt: &published, SourceVideoID: "v_hl_vod", VodOffset: offset(1800)},
+		{UserID: userID, VideoID: "c_hl_2", Title: "clutch", VideoType: "clip", Duration: 30, ViewCount: 60, PublishedAt: &published, SourceVideoID: "v_hl_vod", VodOffset: offset(1810)},
+		{UserID: userID, VideoID: "c_hl_3", Title: "lol", VideoType: "clip", Duration: 30, ViewCount: 5, PublishedAt: &published, SourceVideoID: "v_hl_vod", VodOffset: offset(5000)},
+	} {
+		require.NoError(t, repo.SaveVideoAnalytics(ctx, video))
+	}
+
+	moments, err := repo.GetClipMoments(ctx, userID, time.Now().AddDate(0, 0, -30))
+	require.NoError(t, err)
+	require.Len(t, moments, 3)
+	assert.Equal(t, 7200, moments[0].VideoDuration)
+
+	require.NoError(t, repo.ReplaceHighlightSuggestions(ctx, userID, []analytics.HighlightSuggestion{{
+		VideoID: "v_hl_vod", StartSeconds: 1770, EndSeconds: 1840, ClipCount: 2, TotalClipViews: 100,
+		Clips: []analytics.HighlightClip{{ClipID: "c_hl_1"}, {ClipID: "c_hl_2"}},
+	}}))
+
+	var body struct {
+		Suggestions []analytics.HighlightSuggestion `json:"suggestions"`
+	}
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/highlight-suggestions", token, &body))
+
+	require.Len(t, body.Suggestions, 1)
+	assert.Equal(t, "Boss fight", body.Suggestions[0].VideoTitle)
+	assert.Equal(t, "https://www.twitch.tv/videos/v_hl_vod?t=0h29m30s", body.Suggestions[0].URL)
+	assert.Len(t, body.Suggestions[0].Clips, 2)
+}
+
+func TestRepurposeRanksShortClips(t *testing.T) {
+	userID := "user_repurpose"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	published := time.Now().AddDate(0, 0, -2)
+	old := time.Now().AddDate(0, 0, -90)
+	thumb := "https://clips-media-assets2.twitch.tv/preview-480x272.jpg"
+	for _, video := range []*analytics.VideoAnalytics{
+		{UserID: userID, VideoID: "c_rp_short", Title: "ace", VideoType: "clip", Duration: 28, ViewCount: 200, ThumbnailURL: thumb, PublishedAt: &published},
+		{UserID: userID, VideoID: "c_rp_long", Title: "whole round", VideoType: "clip", Duration: 60, ViewCount: 800, ThumbnailURL: thumb, PublishedAt: &published},
+		{UserID: userID, VideoID: "c_rp_old", Title: "ancient", VideoType: "clip", Duration: 20, ViewCount: 5000, ThumbnailURL: thumb, PublishedAt: &old},
+		{UserID: userID, VideoID: "v_rp_vod", Title: "stream", VideoType: "vod", Duration: 40, ViewCount: 900, ThumbnailURL: thumb, PublishedAt: &published},
+	} {
+		require.NoError(t, repo.SaveVideoAnalytics(ctx, video))
+	}
+
+	var report analytics.RepurposeReport
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/repurpose?days=30", token, &report))
+
+	assert.Equal(t, 2, report.ClipsChecked)
+	assert.Equal(t, 1, report.ReadyCount)
+	require.Len(t, report.Clips, 2)
+	assert.Equal(t, "c_rp_short", report.Clips[0].ClipID)
+	assert.True(t, report.Clips[0].Ready)
+	assert.Equal(t, "/api/media/thumbnails/c_rp_short?aspect=vertical", report.Clips[0].VerticalThumbnailURL)
+	assert.Equal(t, []string{"too_long"}, report.Clips[1].Issues)
+}
+
+func TestCollaborationReportTagsAndDetection(t *testing.T) {
+	userID := "user_collabs"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	started := time.Now().AddDate(0, 0, -3)
+	_, err := db.GetDB().Exec(`
+		INSERT INTO stream_sessions (user_id, stream_id, title, game_name, game_id, started_at, duration_minutes, average_viewers)
+		VALUES ($1, 'st_collab_solo', 'Solo ranked', 'VALORANT', '516575', $2, 120, 100),
+		       ($1, 'st_collab_duo', 'Duos w/ @Valkyrae', 'VALORANT', '516575', $2, 120, 180)
+	`, userID, started)
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveVideoAnalytics(ctx, &analytics.VideoAnalytics{
+		UserID: userID, VideoID: "v_collab_upload", Title: "Best of the week", VideoType: "upload", ViewCount: 900, PublishedAt: &started,
+	}))
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	var report analytics.CollaborationReport
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/collaborations", token, &report))
+	assert.Equal(t, 1, report.Collab.Streams)
+	require.Len(t, report.Collaborators, 1)
+	assert.Equal(t, "valkyrae", report.Collaborators[0].Login)
+	require.NotNil(t, report.ViewerLiftPercent)
+	assert.InDelta(t, 80.0, *report.ViewerLiftPercent, 0.001)
+
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, "/api/analytics/collaborations/video/v_collab_upload", token,
+		map[string]any{"collaborators": []string{"@Sykkuno"}}, nil))
+	assert.Equal(t, http.StatusNotFound, send(t, http.MethodPut, "/api/analytics/collaborations/video/v_unknown", token,
+		map[string]any{"collaborators": []string{"sykkuno"}}, nil))
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPut, "/api/analytics/collaborations/clip/v_collab_upload", token,
+		map[string]any{"collaborators": []string{"sykkuno"}}, nil))
+
+	// Tagging the duo stream solo overrides the title
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, "/api/analytics/collaborations/stream/st_collab_duo", token,
+		map[string]any{"collaborators": []string{}}, nil))
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/collaborations", token, &report))
+	assert.Equal(t, 0, report.Collab.Streams)
+	assert.Equal(t, 1, report.Collab.Videos)
+	require.Len(t, report.Collaborators, 1)
+	assert.Equal(t, "sykkuno", report.Collaborators[0].Login)
+	require.Len(t, report.Content, 1)
+	assert.Equal(t, "tagged", report.Content[0].Source)
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodDelete, "/api/analytics/collaborations/stream/st_collab_duo", token, nil))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/collaborations", token, &report))
+	assert.Equal(t, 1, report.Collab.Streams)
+}
+
+func TestTitleExperimentComparesVariants(t *testing.T) {
+	userID := "user_title_experiments"
+	seedUser(t, userID)
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+
+	// Both VODs are 10 days old, the B one has twice the views and a clip
+	published := time.Now().AddDate(0, 0, -10)
+	for _, video := range []*analytics.VideoAnalytics{
+		{UserID: userID, VideoID: "v_exp_a", Title: "Ranked grind", VideoType: "archive", ViewCount: 1000, PublishedAt: &published},
+		{UserID: userID, VideoID: "v_exp_b", Title: "Can I hit Radiant?", VideoType: "archive", ViewCount: 2000, PublishedAt: &published},
+		{UserID: userID, VideoID: "c_exp_b", Title: "ace", VideoType: "clip", ViewCount: 50, PublishedAt: &published, SourceVideoID: "v_exp_b"},
+	} {
+		require.NoError(t, repo.SaveVideoAnalytics(ctx, video))
+	}
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPost, "/api/analytics/experiments", token, map[string]any{"name": " "}, nil))
+
+	var created struct {
+		Experiment analytics.TitleExperiment `json:"experiment"`
+	}
+	require.Equal(t, http.StatusCreated, send(t, http.MethodPost, "/api/analytics/experiments", token,
+		map[string]any{"name": "Questions", "variant_a": "Statement", "variant_b": "Question"}, &created))
+	require.NotZero(t, created.Experiment.ID)
+
+	path := fmt.Sprintf("/api/analytics/experiments/%d", created.Experiment.ID)
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, path+"/videos/v_exp_a", token, map[string]any{"variant": "a"}, nil))
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, path+"/videos/v_exp_b", token, map[string]any{"variant": "B"}, nil))
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPut, path+"/videos/v_exp_b", token, map[string]any{"variant": "C"}, nil))
+	assert.Equal(t, http.StatusNotFound, send(t, http.MethodPut, path+"/videos/c_exp_b", token, map[string]any{"variant": "A"}, nil),
+		"clips can't be assigned")
+	assert.Equal(t, http.StatusNotFound, send(t, http.MethodPut, path+"/videos/v_unknown", token, map[string]any{"variant": "A"}, nil))
+
+	var result analytics.ExperimentResult
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, path, token, &result))
+	assert.Equal(t, "Questions", result.Name)
+	assert.Equal(t, 1, result.A.Videos)
+	assert.Equal(t, 1, result.B.Clips)
+	assert.InDelta(t, 100.0, result.A.AvgViewsPerDay, 1)
+	assert.InDelta(t, 0.5, result.B.ClipRate, 0.001)
+	require.NotNil(t, result.ViewsPerDayLiftPercent)
+	assert.InDelta(t, 100.0, *result.ViewsPerDayLiftPercent, 0.1)
+	assert.Len(t, result.Videos, 2)
+
+	var list struct {
+		Experiments []analytics.ExperimentResult `json:"experiments"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/experiments", token, &list))
+	require.Len(t, list.Experiments, 1)
+	assert.Equal(t, 1, list.Experiments[0].B.Videos)
+	assert.Empty(t, list.Experiments[0].Videos)
+
+	// Another user can't see or change the experiment
+	other := "user_title_experiments_other"
+	seedUser(t, other)
+	otherToken := sessionToken(t, signingKey, other, time.Now().Add(time.Hour))
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, path, otherToken, nil))
+	assert.Equal(t, http.StatusNotFound, send(t, http.MethodPut, path+"/videos/v_exp_a", otherToken, map[string]any{"variant": "B"}, nil))
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodDelete, path+"/videos/v_exp_a", token, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodDelete, path+"/videos/v_exp_a", token, nil))
+	require.Equal(t, http.StatusOK, call(t, http.MethodDelete, path, token, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, path, token, nil))
+}
+
+func TestChannelAnalyticsRangeIgnoresSessionTimeZone(t *testing.T) {
+	userID := "user_range_time_zone"
+	seedUser(t, userID)
+
+	// Zones on both sides of UTC, with and without daylight saving
+	for _, zone := range []string{"UTC", "America/New_York", "Pacific/Auckland"} {
+		t.Run(zone, func(t *testing.T) {
+			conn, err := sql.Open("pgx", os.Getenv("DATABASE_URL")+"&timezone="+url.QueryEscape(zone))
+			require.NoError(t, err)
+			defer conn.Close()
+			conn.SetMaxOpenConns(1)
+
+			ctx := context.Background()
+			_, err = conn.ExecContext(ctx, `DELETE FROM channel_analytics WHERE user_id = $1`, userID)
+			require.NoError(t, err)
+			_, err = conn.ExecContext(ctx, `
+				INSERT INTO channel_analytics (user_id, date, followers_count)
+				SELECT $1, CURRENT_DATE - n, 100 - n FROM generate_series(0, 40) n
+			`, userID)
+			require.NoError(t, err)
+
+			repo := analytics.NewRepository(conn)
+
+			// The range includes the day exactly days back
+			rows, err := repo.GetChannelAnalytics(ctx, userID, 7)
+			require.NoError(t, err)
+			require.Len(t, rows, 8)
+			assert.Equal(t, 93, rows[len(rows)-1].FollowersCount)
+
+			rows, err = repo.GetChannelAnalytics(ctx, userID, 30)
+			require.NoError(t, err)
+			assert.Len(t, rows, 31)
+
+			chart, err := repo.GetAnalyticsChartData(ctx, userID, 30)
+			require.NoError(t, err)
+			require.Len(t, chart.FollowerGrowth, 31)
+			assert.InDelta(t, 70, chart.FollowerGrowth[0].Value, 0.001)
+			assert.InDelta(t, 100, chart.FollowerGrowth[30].Value, 0.001)
+		})
+	}
+}
+
+func TestUserDiscoveryUsesStoredTokens(t *testing.T) {
+	userID := "user_token_only"
+	ctx := context.Background()
+	repo := analytics.NewRepository(db.GetDB())
+	require.NoError(t, repo.CreateOrUpdateUser(ctx, &analytics.User{
+		ID:          userID,
+		ClerkUserID: userID,
+		Username:    "token_only",
+	}))
+	require.NoError(t, repo.SaveTwitchToken(ctx, &analytics.TwitchToken{
+		UserID:          userID,
+		TwitchUserID:    "tw_token_only",
+		AccessToken:     "encrypted",
+		EncryptionKeyID: "default",
+	}))
+
+	userIDs, err := repo.ListCollectableUserIDs(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, userIDs, userID)
+
+	updated, err := repo.ReconcileUsersFromTokens(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, updated, int64(1))
+
+	user, err := repo.GetUserByClerkID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "tw_token_only", user.TwitchUserID)
+
+	// Reconciled rows are left alone the next time
+	_, err = repo.ReconcileUsersFromTokens(ctx)
+	require.NoError(t, err)
+	updated, err = repo.ReconcileUsersFromTokens(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+}
+
+func TestDisconnectArchivesAndReconnectRestoresAnalytics(t *testing.T) {
+	userID := "user_disconnect_restore"
+	repo := seedUser(t, userID)
+	ctx := context.Background()
+	tokens := analytics.NewTwitchTokenHelper(repo, nil)
+	storeToken := func(twitchUserID string) {
+		t.Helper()
+		require.NoError(t, tokens.StoreToken(ctx, userID, twitchUserID, &twitch.OAuthToken{
+			AccessToken:  "fake-access",
+			RefreshToken: "fake-refresh",
+			ExpiresIn:    3600,
+		}))
+	}
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		require.NoError(t, db.GetDB().QueryRow(query, args...).Scan(&n))
+		return n
+	}
+	storeToken(fakeTwitchUserID)
+
+	var sessionID int
+	require.NoError(t, db.GetDB().QueryRow(`
+		INSERT INTO stream_sessions (user_id, stream_id, title, started_at)
+		VALUES ($1, 's_restore', 'Restored stream', NOW() - INTERVAL '2 days')
+		RETURNING id
+	`, userID).Scan(&sessionID))
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_analytics (user_id, video_id, title, video_type, view_count, stream_session_id, published_at)
+		VALUES ($1, 'v_restore', 'Restored VOD', 'archive', 50, $2, NOW() - INTERVAL '2 days')
+	`, userID, sessionID)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`INSERT INTO video_daily_stats (video_id, date, view_count) VALUES ('v_restore', CURRENT_DATE, 50)`)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`
+		INSERT INTO channel_analytics (user_id, date, followers_count)
+		SELECT $1, CURRENT_DATE - n, 100 - n FROM generate_series(0, 2) n
+	`, userID)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`
+		INSERT INTO stream_schedule_segments (user_id, segment_id, title, start_time)
+		VALUES ($1, 'seg_restore', 'Planned stream', NOW() + INTERVAL '1 day')
+	`, userID)
+	require.NoError(t, err)
+	var goalID int
+	require.NoError(t, db.GetDB().QueryRow(`
+		INSERT INTO creator_goals (user_id, goal_id, goal_type, current_amount, target_amount, started_at, last_seen_at)
+		VALUES ($1, 'goal_restore', 'follower', 90, 100, NOW() - INTERVAL '3 days', NOW())
+		RETURNING id
+	`, userID).Scan(&goalID))
+	_, err = db.GetDB().Exec(`INSERT INTO creator_goal_snapshots (goal_id, date, current_amount, target_amount) VALUES ($1, CURRENT_DATE - 1, 85, 100)`, goalID)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`
+		INSERT INTO collaboration_tags (user_id, content_type, content_id, collaborators)
+		VALUES ($1, 'stream', 's_restore', '["partner"]')
+	`, userID)
+	require.NoError(t, err)
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	var disconnected struct {
+		Status  string                       `json:"status"`
+		Job     analytics.AnalyticsJob       `json:"job"`
+		Archive *analytics.DisconnectArchive `json:"archive"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodDelete, "/api/twitch/connect", token, &disconnected))
+	assert.Equal(t, "completed", disconnected.Status)
+	require.NotNil(t, disconnected.Archive)
+	assert.Equal(t, 10, disconnected.Archive.RowCount)
+	assert.Equal(t, 1, count(`SELECT count(*) FROM analytics_jobs WHERE id = $1 AND status = 'completed'`, disconnected.Job.ID))
+
+	assert.Zero(t, count(`SELECT count(*) FROM channel_analytics WHERE user_id = $1`, userID))
+	assert.Zero(t, count(`SELECT count(*) FROM video_analytics WHERE user_id = $1`, userID))
+	assert.Zero(t, count(`SELECT count(*) FROM video_daily_stats WHERE video_id = 'v_restore'`))
+	assert.Zero(t, count(`SELECT count(*) FROM stream_schedule_segments WHERE user_id = $1`, userID))
+	assert.Zero(t, count(`SELECT count(*) FROM creator_goals WHERE user_id = $1`, userID))
+	assert.Zero(t, count(`SELECT count(*) FROM creator_goal_snapshots WHERE goal_id = $1`, goalID))
+	assert.Zero(t, count(`SELECT count(*) FROM collaboration_tags WHERE user_id = $1`, userID))
+
+	// Nothing to restore until the same Twitch account is connected again
+	var status struct {
+		Restorable bool                         `json:"restorable"`
+		Archive    *analytics.DisconnectArchive `json:"archive"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/twitch/reconnect-restore", token, &status))
+	assert.False(t, status.Restorable)
+	storeToken("tw_other_account")
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/twitch/reconnect-restore", token, &status))
+	assert.False(t, status.Restorable)
+	require.Equal(t, http.StatusNotFound, call(t, http.MethodPost, "/api/twitch/reconnect-restore", token, nil))
+
+	storeToken(fakeTwitchUserID)
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/twitch/reconnect-restore", token, &status))
+	require.True(t, status.Restorable)
+	assert.Equal(t, 10, status.Archive.RowCount)
+
+	// Today's snapshot was collected again after reconnecting and wins over the archived one
+	_, err = db.GetDB().Exec(`INSERT INTO channel_analytics (user_id, date, followers_count) VALUES ($1, CURRENT_DATE, 150)`, userID)
+	require.NoError(t, err)
+	// ...and so was the goal, under a new id its archived snapshot follows
+	var recollectedGoalID int
+	require.NoError(t, db.GetDB().QueryRow(`
+		INSERT INTO creator_goals (user_id, goal_id, goal_type, current_amount, target_amount, started_at, last_seen_at)
+		VALUES ($1, 'goal_restore', 'follower', 95, 100, NOW() - INTERVAL '3 days', NOW())
+		RETURNING id
+	`, userID).Scan(&recollectedGoalID))
+
+	var restored struct {
+		RestoredRows int `json:"restored_rows"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodPost, "/api/twitch/reconnect-restore", token, &restored))
+	assert.Equal(t, 8, restored.RestoredRows)
+
+	assert.Equal(t, 3, count(`SELECT count(*) FROM channel_analytics WHERE user_id = $1`, userID))
+	assert.Equal(t, 150, count(`SELECT followers_count FROM channel_analytics WHERE user_id = $1 AND date = CURRENT_DATE`, userID))
+	assert.Equal(t, sessionID, count(`SELECT stream_session_id FROM video_analytics WHERE user_id = $1`, userID))
+	assert.Equal(t, 1, count(`SELECT count(*) FROM video_daily_stats WHERE video_id = 'v_restore'`))
+	assert.Equal(t, 1, count(`SELECT count(*) FROM stream_schedule_segments WHERE user_id = $1`, userID))
+	assert.Equal(t, 1, count(`SELECT count(*) FROM creator_goals WHERE user_id = $1`, userID))
+	assert.Equal(t, 1, count(`SELECT count(*) FROM creator_goal_snapshots WHERE goal_id = $1`, recollectedGoalID))
+	assert.Equal(t, 1, count(`SELECT count(*) FROM collaboration_tags WHERE user_id = $1`, userID))
+
+	require.Equal(t, http.StatusNotFound, call(t, http.MethodPost, "/api/twitch/reconnect-restore", token, nil))
+}
+
+func TestOnlyAuthFailuresStopDailyCollection(t *testing.T) {
+	ctx := context.Background()
+	repo := seedUser(t, "user_retries_exhausted")
+	seedUser(t, "user_auth_parked")
+
+	// Rows parked after running out of transient attempts before only auth
+	// failures parked users
+	parkedAt := time.Now()
+	require.NoError(t, repo.SaveCollectionRetry(ctx, &analytics.CollectionRetry{
+		UserID: "user_retries_exhausted", JobType: "daily_channel", Attempts: 5,
+		FailureKind: analytics.FailureTransient, ParkedAt: &parkedAt,
+	}))
+	require.NoError(t, repo.SaveCollectionRetry(ctx, &analytics.CollectionRetry{
+		UserID: "user_auth_parked", JobType: "daily_channel", Attempts: 1,
+		FailureKind: analytics.FailureAuth, ParkedAt: &parkedAt,
+	}))
+
+	userIDs, err := repo.ListCollectableUserIDs(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, userIDs, "user_retries_exhausted")
+	assert.NotContains(t, userIDs, "user_auth_parked")
+}
+
+func TestReconcileArchivesOrphanedTwitchData(t *testing.T) {
+	userID := "user_orphaned"
+	repo := seedUser(t, userID)
+	ctx := context.Background()
+
+	// Left behind by a disconnect whose cleanup didn't finish
+	_, err := db.GetDB().Exec(`UPDATE users SET twitch_user_id = '' WHERE id = $1`, userID)
+	require.NoError(t, err)
+	_, err = db.GetDB().Exec(`INSERT INTO channel_analytics (user_id, date, followers_count) VALUES ($1, CURRENT_DATE, 10)`, userID)
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveCollectionRetry(ctx, &analytics.CollectionRetry{UserID: userID, JobType: "daily_channel", Attempts: 1, FailureKind: analytics.FailureAuth}))
+
+	result, err := analytics.ReconcileTwitchData(ctx, repo, true)
+	require.NoError(t, err)
+	assert.Contains(t, result.Orphaned, userID)
+
+	result, err = analytics.ReconcileTwitchData(ctx, repo, false)
+	require.NoError(t, err)
+	assert.Zero(t, result.Failed)
+
+	var remaining int
+	require.NoError(t, db.GetDB().QueryRow(`
+		SELECT (SELECT count(*) FROM channel_analytics WHERE user_id = $1) + (SELECT count(*) FROM collection_retries WHERE user_id = $1)
+	`, userID).Scan(&remaining))
+	assert.Zero(t, remaining)
+
+	orphaned, err := repo.ListOrphanedTwitchDataUsers(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, orphaned, userID)
+}
+
+func TestQueueInterruptedCollections(t *testing.T) {
+	ctx := context.Background()
+	repo := seedUser(t, "user_interrupted")
+	seedUser(t, "user_interrupted_parked")
+	parkedAt := time.Now()
+	require.NoError(t, repo.SaveCollectionRetry(ctx, &analytics.CollectionRetry{
+		UserID: "user_interrupted_parked", JobType: "user_collection", Attempts: 1,
+		FailureKind: analytics.FailureAuth, ParkedAt: &parkedAt,
+	}))
+
+	options := []byte(`{"max_videos":25,"include_clips":true,"video_types":["archive"]}`)
+	users := []string{"user_interrupted", "user_interrupted_parked", "user_interrupted_deleted"}
+	require.NoError(t, repo.QueueInterruptedCollections(ctx, users, "user_collection", options))
+
+	retry, err := repo.GetCollectionRetry(ctx, "user_interrupted", "user_collection")
+	require.NoError(t, err)
+	require.NotNil(t, retry)
+	assert.Equal(t, analytics.FailureInterrupted, retry.FailureKind)
+	assert.Zero(t, retry.Attempts)
+	require.NotNil(t, retry.Options)
+	assert.JSONEq(t, string(options), string(*retry.Options))
+
+	due, err := repo.GetDueCollectionRetries(ctx, time.Now().Add(time.Second), 50)
+	require.NoError(t, err)
+	var dueUsers []string
+	for _, r := range due {
+		dueUsers = append(dueUsers, r.UserID)
+	}
+	assert.Contains(t, dueUsers, "user_interrupted")
+	assert.NotContains(t, dueUsers, "user_interrupted_parked", "parked users stay parked")
+
+	// Queued again without options, e.g. by the retry it became, they're kept
+	require.NoError(t, repo.QueueInterruptedCollections(ctx, []string{"user_interrupted"}, "user_collection", nil))
+	retry, err = repo.GetCollectionRetry(ctx, "user_interrupted", "user_collection")
+	require.NoError(t, err)
+	require.NotNil(t, retry.Options)
+	assert.JSONEq(t, string(options), string(*retry.Options))
+}
+
+func TestCountUserRowsAndListCollectionRetries(t *testing.T) {
+	ctx := context.Background()
+	repo := seedUser(t, "user_diagnostics")
+	seedOtherUser(t, "user_diagnostics_other", "diagnostics_other")
+	_, err := db.GetDB().Exec(`INSERT INTO channel_analytics (user_id, date, followers_count) VALUES ($1, CURRENT_DATE, 10)`, "user_diagnostics")
+	require.NoError(t, err)
+
+	counts, err := repo.CountUserRows(ctx, "user_diagnostics")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counts["channel_analytics"])
+	assert.Contains(t, counts, "video_daily_stats")
+	assert.Zero(t, counts["video_analytics"], "other users' rows aren't counted")
+
+	require.NoError(t, repo.SaveCollectionRetry(ctx, &analytics.CollectionRetry{
+		UserID: "user_diagnostics", JobType: "daily_channel", Attempts: 2,
+		FailureKind: analytics.FailureTransient, LastError: "twitch returned 503",
+	}))
+	retries, err := repo.ListCollectionRetries(ctx, "user_diagnostics")
+	require.NoError(t, err)
+	require.Len(t, retries, 1)
+	assert.Equal(t, "twitch returned 503", retries[0].LastError)
+
+	retries, err = repo.ListCollectionRetries(ctx, "user_diagnostics_other")
+	require.NoError(t, err)
+	assert.Empty(t, retries)
+}
+
+func TestManualCollectionQuota(t *testing.T) {
+	ctx := context.Background()
+	repo := seedUser(t, "user_quota")
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	used, ok, err := repo.TakeManualCollection(ctx, "user_quota", today.AddDate(0, 0, -1), 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, used)
+
+	// Yesterday's collections don't count today
+	for want := 1; want <= 2; want++ {
+		used, ok, err = repo.TakeManualCollection(ctx, "user_quota", today, 2)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, want, used)
+	}
+	used, ok, err = repo.TakeManualCollection(ctx, "user_quota", today, 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2, used)
+
+	require.NoError(t, repo.ReleaseManualCollection(ctx, "user_quota", today))
+	_, ok, err = repo.TakeManualCollection(ctx, "user_quota", today, 2)
+	require.NoError(t, err)
+	assert.True(t, ok, "a released collection can be used again")
+}
+
+func TestRefreshOverQuotaIsRejected(t *testing.T) {
+	userID := "user_quota_refresh"
+	seedUser(t, userID)
+	_, err := db.GetDB().Exec(`
+		INSERT INTO manual_collection_quotas (user_id, day, used)
+		VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, $2)
+	`, userID, config.Collection().ManualDailyLimit)
+	require.NoError(t, err)
+
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	var rejected struct {
+		Error string                          `json:"error"`
+		Quota analytics.CollectionQuotaStatus `json:"quota"`
+	}
+	require.Equal(t, http.StatusTooManyRequests, call(t, http.MethodPost, "/api/analytics/refresh", token, &rejected))
+	assert.Zero(t, rejected.Quota.Remaining)
+	assert.Equal(t, config.Collection().ManualDailyLimit, rejected.Quota.Limit)
+	assert.True(t, rejected.Quota.ResetsAt.After(time.Now()))
+}
+
+func TestCollectionChangesAreSavedOnJob(t *testing.T) {
+	userID := "user_collection_changes"
+	repo := seedUser(t, userID)
+
+	tokens := analytics.NewTwitchTokenHelper(repo, nil)
+	require.NoError(t, tokens.StoreToken(context.Background(), userID, fakeTwitchUserID, &twitch.OAuthToken{
+		AccessToken:  "fake-access",
+		RefreshToken: "fake-refresh",
+		ExpiresIn:    3600,
+		Scope:        []string{"channel:read:subscriptions"},
+	}))
+
+	client, err := twitch.NewClient("integration-client", "integration-secret")
+	require.NoError(t, err)
+	collector := analytics.NewDataCollector(repo, client)
+	for range 2 {
+		_, err := collector.CollectAllUserData(context.Background(), userID, analytics.DefaultCollectionOptions())
+		require.NoError(t, err)
+	}
+
+	counts, err := repo.GetVideoViewCounts(context.Background(), userID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, counts)
+
+	var resp struct {
+		Changes []analytics.ChangeSummary `json:"changes"`
+		Days    int                       `json:"days"`
+	}
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/changes", token, &resp))
+	assert.Equal(t, 7, resp.Days)
+	require.Len(t, resp.Changes, 2)
+	assert.False(t, resp.Changes[0].FirstCollection)
+	assert.Zero(t, resp.Changes[0].NewVideoCount, "the second collection found the same videos")
+	assert.True(t, resp.Changes[1].FirstCollection)
+	assert.Equal(t, "user_collection", resp.Changes[1].JobType)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, http.MethodGet, "/api/analytics/changes?days=0", token, nil))
+}
+
+func TestVideoDecayCurvesFromDailyStats(t *testing.T) {
+	userID := "user_decay"
+	repo := seedUser(t, userID)
+	ctx := context.Background()
+
+	published := time.Now().UTC().AddDate(0, 0, -40)
+	require.NoError(t, repo.SaveVideoAnalytics(ctx, &analytics.VideoAnalytics{
+		UserID: userID, VideoID: "v_decay", Title: "Old VOD", VideoType: "archive", ViewCount: 200, PublishedAt: &published,
+	}))
+	// A video too young for the curve
+	recent := time.Now().UTC().AddDate(0, 0, -2)
+	require.NoError(t, repo.SaveVideoAnalytics(ctx, &analytics.VideoAnalytics{
+		UserID: userID, VideoID: "v_decay_new", Title: "New VOD", VideoType: "archive", ViewCount: 50, PublishedAt: &recent,
+	}))
+
+	var snapshots int
+	require.NoError(t, db.GetDB().QueryRow(`SELECT count(*) FROM video_daily_stats WHERE video_id = 'v_decay' AND date = (NOW() AT TIME ZONE 'UTC')::date`).Scan(&snapshots))
+	assert.Equal(t, 1, snapshots, "saving a video snapshots its views of the day")
+
+	for day, views := range map[int]int{0: 100, 2: 160} {
+		_, err := db.GetDB().Exec(`INSERT INTO video_daily_stats (video_id, date, view_count) VALUES ('v_decay', $1, $2)`,
+			published.AddDate(0, 0, day).Format("2006-01-02"), views)
+		require.NoError(t, err)
+	}
+
+	var report analytics.VideoDecayReport
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/decay?days=30", token, &report))
+	assert.Equal(t, 30, report.Days)
+	require.Len(t, report.Curves, 1)
+	assert.Equal(t, 1, report.Curves[0].Videos)
+	assert.Equal(t, []analytics.DecayPoint{{Day: 0, Share: 0.5, Videos: 1}, {Day: 2, Share: 0.8, Videos: 1}}, report.Curves[0].Points)
+	require.NotNil(t, report.Curves[0].HalfLifeDays)
+	assert.Equal(t, 0, *report.Curves[0].HalfLifeDays)
+	assert.Nil(t, report.Curves[0].NinetyPercentDays)
+}
+
+func TestAudienceReportByLanguage(t *testing.T) {
+	userID := "user_audience"
+	repo := seedUser(t, userID)
+	ctx := context.Background()
+
+	require.NoError(t, repo.SaveChannelAnalytics(ctx, &analytics.ChannelAnalytics{
+		UserID: userID, Date: time.Now().UTC().AddDate(0, 0, -1), BroadcasterLanguage: "de",
+	}))
+	// A day without the setting keeps the last known language
+	require.NoError(t, repo.SaveChannelAnalytics(ctx, &analytics.ChannelAnalytics{UserID: userID, Date: time.Now().UTC()}))
+
+	published := time.Now().AddDate(0, 0, -3)
+	for _, video := range []*analytics.VideoAnalytics{
+		{UserID: userID, VideoID: "v_lang_de", VideoType: "archive", ViewCount: 300, Language: "de", PublishedAt: &published},
+		{UserID: userID, VideoID: "v_lang_de_clip", VideoType: "clip", ViewCount: 60, Language: "de", PublishedAt: &published},
+		{UserID: userID, VideoID: "v_lang_en", VideoType: "archive", ViewCount: 40, Language: "en", PublishedAt: &published},
+	} {
+		require.NoError(t, repo.SaveVideoAnalytics(ctx, video))
+	}
+
+	var report analytics.AudienceReport
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/analytics/audience?days=30", token, &report))
+	assert.Equal(t, "de", report.BroadcasterLanguage)
+	assert.Equal(t, []analytics.LanguageShare{
+		{Language: "de", Videos: 1, Clips: 1, Views: 360, ViewsPercent: 90},
+		{Language: "en", Videos: 1, Views: 40, ViewsPercent: 10},
+	}, report.Languages)
+}
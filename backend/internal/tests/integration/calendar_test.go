@@ -0,0 +1,79 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/calendar"
+)
+
+func TestCalendarCRUDAndReconcile(t *testing.T) {
+	userID := "user_calendar"
+	seedUser(t, userID)
+	ctx := context.Background()
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	planned := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Hour)
+	var created struct {
+		Entry calendar.Entry `json:"entry"`
+	}
+	require.Equal(t, http.StatusCreated, send(t, http.MethodPost, "/api/calendar", token,
+		map[string]any{"kind": "stream", "title": "Ranked grind", "starts_at": planned}, &created))
+	assert.Equal(t, calendar.StatusPlanned, created.Entry.Status)
+
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPost, "/api/calendar", token,
+		map[string]any{"kind": "podcast", "title": "x", "starts_at": planned}, nil))
+
+	var missed struct {
+		Entry calendar.Entry `json:"entry"`
+	}
+	require.Equal(t, http.StatusCreated, send(t, http.MethodPost, "/api/calendar", token,
+		map[string]any{"kind": "upload", "title": "Patch review", "starts_at": planned.Add(-72 * time.Hour)}, &missed))
+
+	// The stream went live 20 minutes late
+	started := planned.Add(20 * time.Minute)
+	_, err := db.GetDB().ExecContext(ctx, `
+		INSERT INTO stream_sessions (user_id, stream_id, title, game_name, game_id, started_at, duration_minutes)
+		VALUES ($1, 's_calendar', 'Ranked grind', 'VALORANT', '516575', $2, 180)
+	`, userID, started)
+	require.NoError(t, err)
+
+	service := calendar.NewService(calendar.NewRepository(db.GetDB()), analytics.NewRepository(db.GetDB()))
+	require.NoError(t, service.Reconcile(ctx, userID))
+
+	var entry struct {
+		Entry calendar.Entry `json:"entry"`
+	}
+	path := fmt.Sprintf("/api/calendar/%d", created.Entry.ID)
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, path, token, &entry))
+	assert.Equal(t, calendar.StatusDone, entry.Entry.Status)
+	assert.Equal(t, "s_calendar", entry.Entry.MatchedID)
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, fmt.Sprintf("/api/calendar/%d", missed.Entry.ID), token, &entry))
+	assert.Equal(t, calendar.StatusMissed, entry.Entry.Status)
+
+	var month calendar.Month
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/calendar?month="+planned.Format("2006-01"), token, &month))
+	assert.Equal(t, planned.Format("2006-01"), month.Month)
+	assert.Equal(t, "UTC", month.Timezone)
+	assert.NotEmpty(t, month.Days)
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodGet, "/api/calendar?tz=Mars/Olympus", token, nil))
+
+	// Editing the entry puts it back to planned
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, path, token,
+		map[string]any{"kind": "stream", "title": "Ranked grind", "starts_at": planned.Add(24 * time.Hour)}, &entry))
+	assert.Equal(t, calendar.StatusPlanned, entry.Entry.Status)
+	assert.Empty(t, entry.Entry.MatchedID)
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodDelete, path, token, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, path, token, nil))
+}
@@ -0,0 +1,54 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/dbcheck"
+	"github.com/baldybuilds/creatorsync/internal/tokencrypt"
+)
+
+func TestDBCheckFindsOrphansAndBrokenTokens(t *testing.T) {
+	ctx := context.Background()
+	userID := "user_dbcheck"
+	seedUser(t, userID)
+
+	// A restore without foreign key checks can leave rows of deleted users
+	tx, err := db.GetDB().BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.Exec(`SET LOCAL session_replication_role = replica`)
+	require.NoError(t, err)
+	_, err = tx.Exec(`INSERT INTO patreon_revenue (user_id, date, campaign_id) VALUES ('user_dbcheck_deleted', CURRENT_DATE, 'c1')`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	_, err = db.GetDB().Exec(`
+		INSERT INTO user_patreon_tokens (user_id, patreon_user_id, access_token, encryption_key_id)
+		VALUES ($1, 'p1', 'not-a-ciphertext', 'default')
+	`, userID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.GetDB().Exec(`DELETE FROM patreon_revenue WHERE user_id = 'user_dbcheck_deleted'`)
+		db.GetDB().Exec(`DELETE FROM user_patreon_tokens WHERE user_id = $1`, userID)
+	})
+
+	keyring, err := tokencrypt.FromEnv()
+	require.NoError(t, err)
+	minUsers := int64(1)
+	report, err := dbcheck.NewChecker(db.GetDB(), dbcheck.Expectations{"users": {Min: &minUsers}}, keyring).Run(ctx)
+	require.NoError(t, err)
+
+	assert.False(t, report.OK)
+	assert.Contains(t, report.Problems, "patreon_revenue has 1 rows of users that don't exist")
+	assert.Contains(t, report.Problems, "user_patreon_tokens has 1 of 1 tokens that don't decrypt")
+	assert.NotContains(t, report.Problems, "table users is missing")
+	for _, tokens := range report.Tokens {
+		if tokens.Table == "user_patreon_tokens" {
+			assert.Equal(t, []string{userID}, tokens.UserIDs)
+		}
+	}
+}
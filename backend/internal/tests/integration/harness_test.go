@@ -0,0 +1,272 @@
+//go:build integration
+
+// Package integration boots the full API against a throwaway Postgres and a
+// fake Twitch, and drives it over HTTP with signed session tokens.
+//
+// Run with Docker available:
+//
+//	go test -tags integration ./internal/tests/integration -v
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/baldybuilds/creatorsync/internal/database"
+	"github.com/baldybuilds/creatorsync/internal/server"
+)
+
+const (
+	testKeyID = "ins_integration"
+	// fakeTwitchUserID is the broadcaster every fake Twitch token belongs to
+	fakeTwitchUserID = "tw_1001"
+	// fakeFollowerCount is what the fake Twitch reports for the broadcaster
+	fakeFollowerCount = 4242
+)
+
+var (
+	app        *server.FiberServer
+	db         database.Service
+	signingKey *rsa.PrivateKey
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("creatorsync"),
+		postgres.WithUsername("creatorsync"),
+		postgres.WithPassword("password"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60*time.Second)),
+	)
+	if err != nil {
+		log.Printf("could not start postgres container: %v", err)
+		return 1
+	}
+	defer func() {
+		if err := container.Terminate(ctx); err != nil {
+			log.Printf("could not teardown postgres container: %v", err)
+		}
+	}()
+
+	connStr, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		log.Printf("could not get postgres connection string: %v", err)
+		return 1
+	}
+
+	signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Printf("could not generate signing key: %v", err)
+		return 1
+	}
+
+	fake := httptest.NewServer(fakeUpstreams())
+	defer fake.Close()
+	routeTwitchTo(fake.URL)
+
+	os.Setenv("DATABASE_URL", connStr)
+	os.Setenv("CLERK_SECRET_KEY", "sk_test_integration")
+	os.Setenv("CLERK_JWKS_URL", fake.URL+"/jwks")
+	os.Setenv("TWITCH_CLIENT_ID", "integration-client")
+	os.Setenv("TWITCH_CLIENT_SECRET", "integration-secret")
+	os.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "integration-token-key")
+
+	// The server applies the migrations on boot
+	os.Setenv("AUTO_MIGRATE", "true")
+
+	db = database.New()
+	app, err = server.New()
+	if err != nil {
+		log.Printf("could not create server: %v", err)
+		return 1
+	}
+	app.RegisterFiberRoutes()
+
+	return m.Run()
+}
+
+// fakeUpstreams serves the Clerk JWKS and the Helix/OAuth endpoints collection uses.
+// Helix endpoints without a handler answer with an empty page.
+func fakeUpstreams() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kid": testKeyID,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(signingKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signingKey.E)).Bytes()),
+		}}})
+	})
+
+	mux.HandleFunc("/oauth2/validate", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"client_id":  "integration-client",
+			"login":      "integration",
+			"user_id":    fakeTwitchUserID,
+			"scopes":     []string{"channel:read:subscriptions", "moderator:read:followers"},
+			"expires_in": 3600,
+		})
+	})
+
+	// App access tokens (client credentials), e.g. for the watchlist
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"access_token": "fake-app-access", "expires_in": 3600, "token_type": "bearer"})
+	})
+
+	mux.HandleFunc("/helix/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"data": []map[string]any{{
+			"id":           fakeTwitchUserID,
+			"login":        "integration",
+			"display_name": "Integration",
+			"view_count":   98765,
+			"created_at":   "2020-01-01T00:00:00Z",
+		}}})
+	})
+
+	mux.HandleFunc("/helix/channels", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"data": []map[string]any{{
+			"broadcaster_id":    fakeTwitchUserID,
+			"broadcaster_login": "integration",
+			"broadcaster_name":  "Integration",
+			"game_name":         "Celeste",
+			"title":             "Integration stream",
+		}}})
+	})
+
+	mux.HandleFunc("/helix/channels/followers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"data": []any{}, "total": fakeFollowerCount})
+	})
+
+	mux.HandleFunc("/helix/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"data": []any{}, "total": 0})
+	})
+
+	return mux
+}
+
+// routeTwitchTo sends requests for Twitch hosts to the fake server. The shared
+// outbound client uses the default transport, so swapping it before the server
+// is created covers every Helix call.
+func routeTwitchTo(rawURL string) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		log.Fatalf("invalid fake server URL: %v", err)
+	}
+	http.DefaultTransport = &rewriteTransport{target: target, base: http.DefaultTransport}
+}
+
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.URL.Host {
+	case "api.twitch.tv", "id.twitch.tv":
+		req = req.Clone(req.Context())
+		req.URL.Scheme = t.target.Scheme
+		req.URL.Host = t.target.Host
+		req.Host = t.target.Host
+	}
+	return t.base.RoundTrip(req)
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// sessionToken signs a Clerk-style session token for userID
+func sessionToken(t *testing.T, key *rsa.PrivateKey, userID string, expiresAt time.Time) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": testKeyID, "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"sub": userID,
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	})
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// call sends a request to the app and decodes a JSON response into out, when given
+func call(t *testing.T, method, path, token string, out any) int {
+	t.Helper()
+	return send(t, method, path, token, nil, out)
+}
+
+// send is call with a JSON request body
+func send(t *testing.T, method, path, token string, in, out any) int {
+	t.Helper()
+
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			t.Fatalf("failed to encode %s %s body: %v", method, path, err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read %s %s response: %v", method, path, err)
+	}
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			t.Fatalf("failed to decode %s %s response %q: %v", method, path, body, err)
+		}
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,215 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/database"
+)
+
+const (
+	// leakMarker is in the titles and game names of the other user's rows
+	leakMarker = "LEAK_MARKER_OTHER_USER"
+	// leakFollowers is the other user's follower count
+	leakFollowers = "987654321"
+)
+
+// policyTables are the tables under row-level security, see migrations 034, 035, 037, 038, 039, 040, 041, 042, 043, 044, 046, 049 and 051
+var policyTables = []string{
+	"channel_analytics", "stream_sessions", "video_analytics", "video_daily_stats",
+	"game_analytics", "social_analytics", "analytics_jobs", "channel_analytics_rollups",
+	"revenue_analytics", "channel_point_redemptions", "moderation_actions",
+	"moderation_daily_metrics", "raids", "follow_events", "weekly_insights",
+	"channel_consistency", "highlight_suggestions", "disconnect_archives", "disconnect_archive_rows",
+	"patreon_revenue", "publish_templates", "publish_jobs", "clip_renders", "content_templates",
+	"api_usage", "subscriptions", "trials", "streamlabs_donations", "manual_collection_quotas",
+	"watchlist_entries", "title_experiments", "title_experiment_videos",
+}
+
+// seedOtherUser gives userID recognisable rows in the main analytics tables,
+// with id as their stream, video and game id
+func seedOtherUser(t *testing.T, userID, id string) {
+	t.Helper()
+	seedUser(t, userID)
+
+	for _, stmt := range []string{
+		`INSERT INTO channel_analytics (user_id, date, followers_count, total_views)
+		 VALUES ($1, CURRENT_DATE, ` + leakFollowers + `, ` + leakFollowers + `) ON CONFLICT DO NOTHING`,
+		`INSERT INTO video_analytics (user_id, video_id, title, video_type, duration_seconds, view_count, published_at)
+		 VALUES ($1, '` + id + `', '` + leakMarker + `', 'archive', 3600, ` + leakFollowers + `, NOW() - INTERVAL '1 day')
+		 ON CONFLICT DO NOTHING`,
+		`INSERT INTO stream_sessions (user_id, stream_id, title, game_name, started_at, ended_at, duration_minutes, peak_viewers)
+		 VALUES ($1, '` + id + `', '` + leakMarker + `', '` + leakMarker + `', NOW() - INTERVAL '3 hours', NOW() - INTERVAL '1 hour', 120, ` + leakFollowers + `)
+		 ON CONFLICT DO NOTHING`,
+		`INSERT INTO game_analytics (user_id, game_id, game_name, total_streams, peak_viewers, last_streamed_at)
+		 VALUES ($1, '` + id + `', '` + leakMarker + `', 1, ` + leakFollowers + `, NOW()) ON CONFLICT DO NOTHING`,
+	} {
+		_, err := db.GetDB().Exec(stmt, userID)
+		require.NoError(t, err)
+	}
+	_, err := db.GetDB().Exec(`
+		INSERT INTO video_daily_stats (video_id, date, view_count)
+		VALUES ($1, CURRENT_DATE, `+leakFollowers+`) ON CONFLICT DO NOTHING
+	`, id)
+	require.NoError(t, err)
+}
+
+var routeParam = regexp.MustCompile(`:[A-Za-z_]+\??`)
+
+// TestEndpointsDoNotLeakOtherUsersRows calls every authenticated GET endpoint
+// as a user without data, with route parameters naming the other user's rows,
+// and checks nothing of the other user comes back
+func TestEndpointsDoNotLeakOtherUsersRows(t *testing.T) {
+	otherID := "leak_endpoint"
+	seedOtherUser(t, "user_isolation_other", otherID)
+	userID := "user_isolation_self"
+	seedUser(t, userID)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	seen := map[string]bool{}
+	for _, route := range app.App.GetRoutes(true) {
+		if route.Method != http.MethodGet || !strings.HasPrefix(route.Path, "/api/") ||
+			strings.HasPrefix(route.Path, "/api/admin") || seen[route.Path] {
+			continue
+		}
+		seen[route.Path] = true
+
+		path := routeParam.ReplaceAllString(route.Path, otherID)
+		t.Run(route.Path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.NotContains(t, string(body), leakMarker)
+			assert.NotContains(t, string(body), leakFollowers)
+		})
+	}
+	require.NotEmpty(t, seen)
+}
+
+type rlsUserKey struct{}
+
+func asUser(userID string) context.Context {
+	return context.WithValue(context.Background(), rlsUserKey{}, userID)
+}
+
+// openAsAppRole connects with row-level security as a role the policies apply
+// to. The container's user is a superuser, which bypasses them.
+func openAsAppRole(t *testing.T) database.Service {
+	t.Helper()
+
+	conn := db.GetDB()
+	var exists bool
+	require.NoError(t, conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'creatorsync_app')`).Scan(&exists))
+	if !exists {
+		_, err := conn.Exec(`CREATE ROLE creatorsync_app LOGIN PASSWORD 'app'`)
+		require.NoError(t, err)
+	}
+	for _, stmt := range []string{
+		`GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO creatorsync_app`,
+		`GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO creatorsync_app`,
+	} {
+		_, err := conn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	dsn, err := url.Parse(os.Getenv("DATABASE_URL"))
+	require.NoError(t, err)
+	dsn.User = url.UserPassword("creatorsync_app", "app")
+	t.Setenv("DATABASE_URL", dsn.String())
+
+	appDB := database.New(database.WithRowLevelSecurity(func(ctx context.Context) string {
+		userID, _ := ctx.Value(rlsUserKey{}).(string)
+		return userID
+	}))
+	t.Cleanup(func() { appDB.Close() })
+	return appDB
+}
+
+func TestRowLevelSecurityHidesOtherUsersRows(t *testing.T) {
+	other, otherID := "user_rls_other", "leak_rls"
+	seedOtherUser(t, other, otherID)
+	self := "user_rls_self"
+	seedUser(t, self)
+	_, err := db.GetDB().Exec(`INSERT INTO channel_analytics (user_id, date, followers_count) VALUES ($1, CURRENT_DATE, 10)`, self)
+	require.NoError(t, err)
+
+	appDB := openAsAppRole(t).GetDB()
+	// One connection, so it is reused across users
+	appDB.SetMaxOpenConns(1)
+
+	count := func(ctx context.Context, query string, args ...any) int {
+		t.Helper()
+		var n int
+		require.NoError(t, appDB.QueryRowContext(ctx, query, args...).Scan(&n))
+		return n
+	}
+
+	t.Run("queries without a user filter", func(t *testing.T) {
+		for _, table := range []string{"channel_analytics", "video_analytics", "stream_sessions", "game_analytics"} {
+			assert.Zero(t, count(asUser(self), `SELECT count(*) FROM `+table+` WHERE user_id = $1`, other), table)
+			assert.Positive(t, count(asUser(other), `SELECT count(*) FROM `+table+` WHERE user_id = $1`, other), table)
+		}
+		assert.Zero(t, count(asUser(self), `SELECT count(*) FROM video_daily_stats WHERE video_id = $1`, otherID))
+		assert.Positive(t, count(asUser(other), `SELECT count(*) FROM video_daily_stats WHERE video_id = $1`, otherID))
+		assert.Equal(t, 1, count(asUser(self), `SELECT count(DISTINCT user_id) FROM channel_analytics`))
+	})
+
+	t.Run("transactions keep their user", func(t *testing.T) {
+		tx, err := appDB.BeginTx(asUser(self), nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		var n int
+		require.NoError(t, tx.QueryRow(`SELECT count(*) FROM channel_analytics WHERE user_id = $1`, other).Scan(&n))
+		assert.Zero(t, n)
+	})
+
+	t.Run("writes for another user", func(t *testing.T) {
+		_, err := appDB.ExecContext(asUser(self),
+			`INSERT INTO channel_analytics (user_id, date, followers_count) VALUES ($1, CURRENT_DATE - 1, 1)`, other)
+		assert.Error(t, err)
+
+		res, err := appDB.ExecContext(asUser(self), `UPDATE video_analytics SET title = 'changed' WHERE video_id = $1`, otherID)
+		require.NoError(t, err)
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Zero(t, affected)
+	})
+
+	t.Run("background work sees every user", func(t *testing.T) {
+		assert.Positive(t, count(context.Background(), `SELECT count(*) FROM channel_analytics WHERE user_id = $1`, other))
+		assert.Positive(t, count(context.Background(), `SELECT count(*) FROM channel_analytics WHERE user_id = $1`, self))
+	})
+}
+
+func TestAnalyticsTablesHaveIsolationPolicies(t *testing.T) {
+	for _, table := range policyTables {
+		var forced bool
+		err := db.GetDB().QueryRow(`
+			SELECT c.relrowsecurity AND c.relforcerowsecurity
+			FROM pg_class c
+			JOIN pg_policies p ON p.tablename = c.relname AND p.policyname = 'user_isolation'
+			WHERE c.relname = $1 AND c.relnamespace = 'public'::regnamespace
+		`, table).Scan(&forced)
+		require.NoError(t, err, table)
+		assert.True(t, forced, table)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserLocaleTranslatesErrors(t *testing.T) {
+	userID := "user_locale"
+	seedUser(t, userID)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	var locale struct {
+		Locale    string   `json:"locale"`
+		Effective string   `json:"effective"`
+		Supported []string `json:"supported"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/user/locale", token, &locale))
+	assert.Equal(t, "", locale.Locale)
+	assert.Equal(t, "en", locale.Effective)
+	assert.Contains(t, locale.Supported, "es")
+
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, "/api/user/locale", token,
+		map[string]any{"locale": "es-MX"}, &locale))
+	assert.Equal(t, "es", locale.Locale)
+
+	var errorBody struct {
+		Error string `json:"error"`
+	}
+	require.Equal(t, http.StatusNotFound, call(t, http.MethodGet, "/api/reports/999999", token, &errorBody))
+	assert.Equal(t, "Informe no encontrado", errorBody.Error)
+
+	require.Equal(t, http.StatusBadRequest, send(t, http.MethodPut, "/api/user/locale", token,
+		map[string]any{"locale": "tlh"}, &errorBody))
+	assert.Equal(t, "Idioma no admitido", errorBody.Error)
+
+	// Clearing the locale goes back to the browser language, English here
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, "/api/user/locale", token,
+		map[string]any{"locale": ""}, &locale))
+	assert.Equal(t, "", locale.Locale)
+	require.Equal(t, http.StatusNotFound, call(t, http.MethodGet, "/api/reports/999999", token, &errorBody))
+	assert.Equal(t, "Report not found", errorBody.Error)
+}
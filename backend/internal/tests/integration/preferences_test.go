@@ -0,0 +1,55 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/preferences"
+)
+
+func TestPreferencesOptimisticConcurrency(t *testing.T) {
+	userID := "user_preferences"
+	seedUser(t, userID)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	var record preferences.Record
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/user/preferences", token, &record))
+	assert.Nil(t, record.UpdatedAt)
+	assert.Equal(t, "30d", record.Preferences.DefaultDateRange)
+
+	layout := map[string]any{
+		"layout": []map[string]any{{"id": "followers", "x": 0, "y": 0, "w": 6, "h": 4}},
+		"theme":  "dark",
+	}
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, "/api/user/preferences", token,
+		map[string]any{"preferences": layout, "updated_at": nil}, &record))
+	require.NotNil(t, record.UpdatedAt)
+	first := *record.UpdatedAt
+
+	// A second first-save loses to the one above
+	assert.Equal(t, http.StatusConflict, send(t, http.MethodPut, "/api/user/preferences", token,
+		map[string]any{"preferences": layout, "updated_at": nil}, nil))
+
+	layout["theme"] = "light"
+	require.Equal(t, http.StatusOK, send(t, http.MethodPut, "/api/user/preferences", token,
+		map[string]any{"preferences": layout, "updated_at": first}, &record))
+	assert.Equal(t, "light", record.Preferences.Theme)
+
+	// Saving on top of the stale version is rejected with the current document
+	var conflict struct {
+		Current preferences.Record `json:"current"`
+	}
+	require.Equal(t, http.StatusConflict, send(t, http.MethodPut, "/api/user/preferences", token,
+		map[string]any{"preferences": layout, "updated_at": first}, &conflict))
+	assert.Equal(t, "light", conflict.Current.Preferences.Theme)
+
+	layout["theme"] = "neon"
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPut, "/api/user/preferences", token,
+		map[string]any{"preferences": layout, "updated_at": record.UpdatedAt}, nil))
+}
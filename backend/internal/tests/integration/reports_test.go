@@ -0,0 +1,72 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/reports"
+)
+
+func TestReportRunAndDownload(t *testing.T) {
+	userID := "user_reports"
+	seedUser(t, userID)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	_, err := db.GetDB().Exec(`
+		INSERT INTO channel_analytics (user_id, date, followers_count, total_views)
+		VALUES ($1, CURRENT_DATE - 1, 100, 1000), ($1, CURRENT_DATE, 110, 1200)
+	`, userID)
+	require.NoError(t, err)
+
+	var created struct {
+		Report reports.Report `json:"report"`
+	}
+	require.Equal(t, http.StatusCreated, send(t, http.MethodPost, "/api/reports", token, map[string]any{
+		"name":     "Weekly growth",
+		"metrics":  []string{"followers", "views"},
+		"schedule": "weekly",
+	}, &created))
+	require.NotNil(t, created.Report.NextRunAt)
+	assert.Equal(t, time.Monday, created.Report.NextRunAt.UTC().Weekday())
+
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPost, "/api/reports", token, map[string]any{
+		"name": "Bad", "metrics": []string{"revenue"},
+	}, nil))
+
+	var ran struct {
+		Run         reports.Run `json:"run"`
+		DownloadURL string      `json:"download_url"`
+	}
+	require.Equal(t, http.StatusCreated, send(t, http.MethodPost, fmt.Sprintf("/api/reports/%d/run", created.Report.ID), token, nil, &ran))
+	assert.Equal(t, "completed", ran.Run.Status)
+	assert.Equal(t, reports.TriggerManual, ran.Run.TriggeredBy)
+	require.NotEmpty(t, ran.DownloadURL)
+
+	req := httptest.NewRequest(http.MethodGet, ran.DownloadURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	assert.Equal(t, "date,followers,views", lines[0])
+	assert.Len(t, lines, 3)
+
+	// Another user can't see the report
+	other := sessionToken(t, signingKey, "user_reports_other", time.Now().Add(time.Hour))
+	seedUser(t, "user_reports_other")
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, fmt.Sprintf("/api/reports/%d", created.Report.ID), other, nil))
+}
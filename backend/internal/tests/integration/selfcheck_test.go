@@ -0,0 +1,32 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/selfcheck"
+)
+
+func TestSelfCheckDatabaseAndSchema(t *testing.T) {
+	checks, closeChecks := selfcheck.Default()
+	defer closeChecks()
+
+	var database []selfcheck.Check
+	for _, check := range checks {
+		if check.Name == "database" || check.Name == "schema" {
+			database = append(database, check)
+		}
+	}
+	report := selfcheck.Run(context.Background(), database, 10*time.Second)
+
+	assert.True(t, report.OK, report.Checks)
+	require.Len(t, report.Checks, 2)
+	assert.Contains(t, report.Checks[0].Detail, "PostgreSQL")
+	assert.Contains(t, report.Checks[1].Detail, "up to date at ")
+}
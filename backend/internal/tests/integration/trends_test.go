@@ -0,0 +1,59 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/trends"
+)
+
+func TestGameTrendsFromSamples(t *testing.T) {
+	userID := "user_trends"
+	seedUser(t, userID)
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	// Celeste doubles its viewers this week, Tetris stays flat
+	now := time.Now().UTC().Truncate(time.Hour)
+	for _, sample := range []struct {
+		at      time.Time
+		celeste int
+		tetris  int
+	}{
+		{now.AddDate(0, 0, -10), 100, 500},
+		{now.AddDate(0, 0, -2), 150, 500},
+		{now, 250, 500},
+	} {
+		var sampleID int
+		require.NoError(t, db.GetDB().QueryRow(
+			`INSERT INTO game_trend_samples (sampled_at) VALUES ($1) RETURNING id`, sample.at).Scan(&sampleID))
+		_, err := db.GetDB().Exec(`
+			INSERT INTO game_trend_viewers (sample_id, game_id, game_name, rank, viewers, streams)
+			VALUES ($1, 'trend_tetris', 'Tetris', 1, $2, 10), ($1, 'trend_celeste', 'Celeste', 2, $3, 5)
+		`, sampleID, sample.tetris, sample.celeste)
+		require.NoError(t, err)
+	}
+
+	var report trends.Report
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/trends/games?days=7", token, &report))
+	require.NotNil(t, report.SampledAt)
+	require.Len(t, report.Games, 2)
+	assert.Equal(t, "trend_celeste", report.Games[0].GameID, "rising games come first")
+	require.NotNil(t, report.Games[0].ChangePercent)
+	assert.Equal(t, 100.0, *report.Games[0].ChangePercent)
+	assert.Equal(t, 0.0, *report.Games[1].ChangePercent)
+
+	var detail trends.GameDetail
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/trends/games/trend_celeste?days=30", token, &detail))
+	assert.Equal(t, "Celeste", detail.Name)
+	assert.Len(t, detail.Points, 3)
+	require.NotNil(t, detail.PeakHour)
+
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, "/api/trends/games/unknown", token, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodGet, "/api/trends/games/trend_celeste?tz=Nowhere/Else", token, nil))
+}
@@ -0,0 +1,78 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+	"github.com/baldybuilds/creatorsync/internal/watchlist"
+)
+
+func TestWatchlistAddCompareAndRemove(t *testing.T) {
+	userID := "user_watchlist"
+	repo := seedUser(t, userID)
+	ctx := context.Background()
+	token := sessionToken(t, signingKey, userID, time.Now().Add(time.Hour))
+
+	// Follower counts are read with the watching user's Twitch token
+	require.NoError(t, analytics.NewTwitchTokenHelper(repo, nil).StoreToken(ctx, userID, fakeTwitchUserID, &twitch.OAuthToken{
+		AccessToken:  "fake-access",
+		RefreshToken: "fake-refresh",
+		ExpiresIn:    3600,
+		Scope:        []string{"moderator:read:followers"},
+	}))
+	require.NoError(t, repo.SaveChannelAnalytics(ctx, &analytics.ChannelAnalytics{
+		UserID: userID, Date: time.Now().UTC().AddDate(0, 0, -1), FollowersCount: 40,
+	}))
+
+	var added struct {
+		Channel watchlist.Channel `json:"channel"`
+	}
+	require.Equal(t, http.StatusCreated, send(t, http.MethodPost, "/api/watchlist", token, map[string]any{"login": "Integration"}, &added))
+	assert.Equal(t, fakeTwitchUserID, added.Channel.TwitchID)
+	require.NotNil(t, added.Channel.Latest, "a new channel is collected right away")
+	assert.Equal(t, "Celeste", added.Channel.Latest.Category)
+	require.NotNil(t, added.Channel.Latest.FollowersCount)
+	assert.Equal(t, fakeFollowerCount, *added.Channel.Latest.FollowersCount)
+
+	assert.Equal(t, http.StatusBadRequest, send(t, http.MethodPost, "/api/watchlist", token, map[string]any{"login": "not a login"}, nil))
+
+	var list struct {
+		Channels []watchlist.Channel `json:"channels"`
+	}
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/watchlist", token, &list))
+	require.Len(t, list.Channels, 1)
+	assert.Equal(t, "Celeste", list.Channels[0].TopCategory)
+
+	var comparison watchlist.Comparison
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/api/watchlist/compare?days=7", token, &comparison))
+	require.Len(t, comparison.Series, 2)
+	assert.True(t, comparison.Series[0].Own)
+	assert.Equal(t, []int{40}, followers(comparison.Series[0]))
+	assert.Equal(t, []int{fakeFollowerCount}, followers(comparison.Series[1]))
+
+	other := "user_watchlist_other"
+	seedUser(t, other)
+	otherToken := sessionToken(t, signingKey, other, time.Now().Add(time.Hour))
+	path := fmt.Sprintf("/api/watchlist/%d", added.Channel.ID)
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodDelete, path, otherToken, nil), "entries belong to their user")
+	assert.Equal(t, http.StatusOK, call(t, http.MethodDelete, path, token, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodDelete, path, token, nil))
+}
+
+func followers(series watchlist.Series) []int {
+	counts := make([]int, len(series.Points))
+	for i, point := range series.Points {
+		counts[i] = point.Followers
+	}
+	return counts
+}
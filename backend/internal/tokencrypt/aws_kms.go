@@ -0,0 +1,160 @@
+package tokencrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/awsv4"
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// ecsCredentialsHost serves task role credentials inside ECS
+const ecsCredentialsHost = "http://169.254.170.2"
+
+// awsKMS calls the AWS KMS JSON API
+// See: https://docs.aws.amazon.com/kms/latest/APIReference/API_Encrypt.html
+type awsKMS struct {
+	keyID      string
+	region     string
+	endpoint   string
+	static     awsv4.Credentials
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	roleCreds    awsv4.Credentials
+	roleCredsExp time.Time
+}
+
+func newAWSKMS(cfg config.TokenEncryptionConfig) *awsKMS {
+	return &awsKMS{
+		keyID:    cfg.KMSKey,
+		region:   cfg.KMSRegion,
+		endpoint: "https://kms." + cfg.KMSRegion + ".amazonaws.com/",
+		static: awsv4.Credentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		},
+		httpClient: &http.Client{Timeout: kmsRequestTimeout},
+	}
+}
+
+func (a *awsKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var resp struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	body := map[string]any{"KeyId": a.keyID, "Plaintext": plaintext}
+	if err := a.call(ctx, "TrentService.Encrypt", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.CiphertextBlob, nil
+}
+
+func (a *awsKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	body := map[string]any{"KeyId": a.keyID, "CiphertextBlob": ciphertext}
+	if err := a.call(ctx, "TrentService.Decrypt", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// call posts a KMS action. []byte fields are base64 encoded by encoding/json,
+// which is what KMS expects for blobs.
+func (a *awsKMS) call(ctx context.Context, target string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create KMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	awsv4.Sign(req, awsv4.PayloadHash(payload), creds, a.region, "kms", time.Now())
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("KMS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("KMS %s returned %d: %s", target, resp.StatusCode, detail)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode KMS response: %w", err)
+	}
+	return nil
+}
+
+// credentials returns the configured keys, or the ECS task role credentials
+// when none are configured
+func (a *awsKMS) credentials(ctx context.Context) (awsv4.Credentials, error) {
+	if a.static.AccessKeyID != "" {
+		return a.static, nil
+	}
+
+	relativeURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	if relativeURI == "" {
+		return awsv4.Credentials{}, fmt.Errorf("no AWS credentials configured (set AWS_ACCESS_KEY_ID or run with an ECS task role)")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if time.Until(a.roleCredsExp) > time.Minute {
+		return a.roleCreds, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ecsCredentialsHost+relativeURI, nil)
+	if err != nil {
+		return awsv4.Credentials{}, err
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return awsv4.Credentials{}, fmt.Errorf("failed to fetch task role credentials: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return awsv4.Credentials{}, fmt.Errorf("task role credentials endpoint returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return awsv4.Credentials{}, fmt.Errorf("failed to decode task role credentials: %w", err)
+	}
+
+	a.roleCreds = awsv4.Credentials{
+		AccessKeyID:     body.AccessKeyID,
+		SecretAccessKey: body.SecretAccessKey,
+		SessionToken:    body.Token,
+	}
+	a.roleCredsExp = body.Expiration
+	return a.roleCreds, nil
+}
@@ -0,0 +1,133 @@
+package tokencrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+const (
+	gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+	// gcpMetadataTokenURL hands out access tokens for the attached service
+	// account on GCE, GKE and Cloud Run
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// gcpKMS calls the Cloud KMS REST API. keyName is the full CryptoKey resource,
+// projects/*/locations/*/keyRings/*/cryptoKeys/*.
+// See: https://cloud.google.com/kms/docs/reference/rest/v1/projects.locations.keyRings.cryptoKeys/encrypt
+type gcpKMS struct {
+	keyName    string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExp    time.Time
+}
+
+func newGCPKMS(cfg config.TokenEncryptionConfig) *gcpKMS {
+	return &gcpKMS{
+		keyName:    cfg.KMSKey,
+		httpClient: &http.Client{Timeout: kmsRequestTimeout},
+	}
+}
+
+func (g *gcpKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := g.call(ctx, "encrypt", map[string]any{"plaintext": plaintext}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+func (g *gcpKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	if err := g.call(ctx, "decrypt", map[string]any{"ciphertext": ciphertext}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+func (g *gcpKMS) call(ctx context.Context, method string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	token, err := g.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, gcpKMSEndpoint+g.keyName+":"+method, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create KMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("KMS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("KMS %s returned %d: %s", method, resp.StatusCode, detail)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode KMS response: %w", err)
+	}
+	return nil
+}
+
+// token returns a metadata server access token, cached until shortly before it expires
+func (g *gcpKMS) token(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.accessToken != "" && time.Until(g.tokenExp) > time.Minute {
+		return g.accessToken, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch GCP access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GCP metadata server returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode GCP access token: %w", err)
+	}
+
+	g.accessToken = body.AccessToken
+	g.tokenExp = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	return g.accessToken, nil
+}
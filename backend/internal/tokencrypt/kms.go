@@ -0,0 +1,145 @@
+package tokencrypt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/cache"
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// KMS wraps and unwraps data keys with a key that never leaves the provider
+type KMS interface {
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+const (
+	// dataKeySize is the size of the per-token AES-256 data key
+	dataKeySize = 32
+	// dataKeyCacheTTL is how long an unwrapped data key is kept in memory, so
+	// hot tokens don't cost a KMS call on every read
+	dataKeyCacheTTL     = time.Hour
+	dataKeyCacheEntries = 10000
+	kmsRequestTimeout   = 10 * time.Second
+)
+
+var (
+	kmsClientsMu sync.Mutex
+	// kmsClients are shared across keyrings so the data key cache and provider
+	// credentials survive FromEnv being called per request
+	kmsClients = make(map[string]KMS)
+)
+
+// sharedKMS returns the cached client for the configured provider and key
+func sharedKMS(cfg config.TokenEncryptionConfig) (KMS, error) {
+	if cfg.KMSKey == "" {
+		return nil, fmt.Errorf("TOKEN_KMS_KEY is required for token encryption provider %q", cfg.Provider)
+	}
+
+	cacheKey := strings.Join([]string{cfg.Provider, cfg.KMSKey, cfg.KMSRegion, cfg.AWSAccessKeyID}, "|")
+
+	kmsClientsMu.Lock()
+	defer kmsClientsMu.Unlock()
+
+	if client, ok := kmsClients[cacheKey]; ok {
+		return client, nil
+	}
+
+	var client KMS
+	switch cfg.Provider {
+	case config.TokenProviderAWSKMS:
+		if cfg.KMSRegion == "" {
+			return nil, errors.New("TOKEN_KMS_REGION or AWS_REGION is required for aws-kms")
+		}
+		client = newAWSKMS(cfg)
+	case config.TokenProviderGCPKMS:
+		client = newGCPKMS(cfg)
+	default:
+		return nil, fmt.Errorf("unknown token encryption provider %q", cfg.Provider)
+	}
+
+	client = newCachingKMS(client)
+	kmsClients[cacheKey] = client
+	return client, nil
+}
+
+// cachingKMS remembers unwrapped data keys by their wrapped form
+type cachingKMS struct {
+	KMS
+	dataKeys *cache.Memory[[]byte]
+}
+
+func newCachingKMS(kms KMS) *cachingKMS {
+	return &cachingKMS{
+		KMS:      kms,
+		dataKeys: cache.NewMemory[[]byte](dataKeyCacheTTL, dataKeyCacheEntries),
+	}
+}
+
+func (c *cachingKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if key, ok := c.dataKeys.GetContext(ctx, string(ciphertext)); ok {
+		return key, nil
+	}
+
+	key, err := c.KMS.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	c.dataKeys.Set(string(ciphertext), key)
+	return key, nil
+}
+
+// sealEnvelope encrypts plaintext with a fresh data key and returns
+// base64(wrapped data key) "." base64(nonce || ciphertext)
+func sealEnvelope(ctx context.Context, kms KMS, plaintext string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	wrapped, err := kms.Encrypt(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(wrapped) + "." + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openEnvelope reverses sealEnvelope
+func openEnvelope(ctx context.Context, kms KMS, encoded string) (string, error) {
+	wrappedPart, sealedPart, ok := strings.Cut(encoded, ".")
+	if !ok {
+		return "", errors.New("encrypted token is not an envelope")
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedPart)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode data key: %w", err)
+	}
+
+	dataKey, err := kms.Decrypt(ctx, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return open(dataKey, sealedPart)
+}
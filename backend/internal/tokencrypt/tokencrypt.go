@@ -0,0 +1,160 @@
+// Package tokencrypt encrypts stored OAuth tokens with versioned AES-GCM keys.
+// The ID of the key is stored next to each ciphertext, so new keys can be
+// introduced and old ones retired without invalidating stored tokens.
+//
+// In production a KMS provider (AWS KMS or GCP Cloud KMS) can be used instead
+// of local keys: every token gets its own data key, wrapped by the KMS key
+// and stored alongside it, so no long-lived key is held in the environment.
+package tokencrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+// Keyring holds every key tokens may be encrypted with
+type Keyring struct {
+	currentID string
+	keys      map[string][]byte
+	// kms wraps data keys for values stored under kmsID, when a KMS provider is configured
+	kms   KMS
+	kmsID string
+}
+
+// NewKeyring derives 256-bit AES keys from the configured secrets and, for a
+// KMS provider, connects to the provider's key
+func NewKeyring(cfg config.TokenEncryptionConfig) (*Keyring, error) {
+	if !cfg.UsesKMS() {
+		return newKeyring(cfg, nil)
+	}
+
+	kms, err := sharedKMS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyring(cfg, kms)
+}
+
+func newKeyring(cfg config.TokenEncryptionConfig, kms KMS) (*Keyring, error) {
+	if kms == nil {
+		if len(cfg.Keys) == 0 {
+			return nil, errors.New("no token encryption key configured (set TWITCH_TOKEN_ENCRYPTION_KEY or TWITCH_TOKEN_ENCRYPTION_KEYS)")
+		}
+		if _, ok := cfg.Keys[cfg.CurrentKeyID]; !ok {
+			return nil, fmt.Errorf("current token encryption key %q is not configured", cfg.CurrentKeyID)
+		}
+	}
+
+	keyring := &Keyring{
+		currentID: cfg.CurrentKeyID,
+		keys:      make(map[string][]byte, len(cfg.Keys)),
+	}
+	if kms != nil {
+		// Local keys stay readable so tokens can be moved onto the KMS
+		keyring.kms = kms
+		keyring.kmsID = cfg.Provider
+		keyring.currentID = cfg.Provider
+	}
+	for id, secret := range cfg.Keys {
+		key := sha256.Sum256([]byte(secret))
+		keyring.keys[id] = key[:]
+	}
+	return keyring, nil
+}
+
+// FromEnv builds the keyring from the environment
+func FromEnv() (*Keyring, error) {
+	return NewKeyring(config.TokenEncryption())
+}
+
+// CurrentKeyID is the ID of the key Encrypt uses
+func (k *Keyring) CurrentKeyID() string {
+	return k.currentID
+}
+
+// HasKey reports whether tokens encrypted with the key can be decrypted
+func (k *Keyring) HasKey(keyID string) bool {
+	if k.kms != nil && keyID == k.kmsID {
+		return true
+	}
+	_, ok := k.keys[keyID]
+	return ok
+}
+
+// Encrypt encrypts with the current key and returns the ciphertext along with
+// the key ID to store next to it. Local keys produce base64(nonce || ciphertext),
+// KMS providers an envelope that also carries the wrapped data key.
+func (k *Keyring) Encrypt(ctx context.Context, plaintext string) (string, string, error) {
+	if k.kms != nil {
+		encoded, err := sealEnvelope(ctx, k.kms, plaintext)
+		return encoded, k.kmsID, err
+	}
+
+	gcm, err := newGCM(k.keys[k.currentID])
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), k.currentID, nil
+}
+
+// Decrypt reverses Encrypt using the key the value was encrypted with
+func (k *Keyring) Decrypt(ctx context.Context, encoded, keyID string) (string, error) {
+	if k.kms != nil && keyID == k.kmsID {
+		return openEnvelope(ctx, k.kms, encoded)
+	}
+
+	key, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("token encryption key %q is not configured", keyID)
+	}
+	return open(key, encoded)
+}
+
+// open decrypts base64(nonce || ciphertext) with key
+func open(key []byte, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted token is too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
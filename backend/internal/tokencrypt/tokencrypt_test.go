@@ -0,0 +1,135 @@
+package tokencrypt
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+)
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	old, err := NewKeyring(config.TokenEncryptionConfig{
+		Keys:         map[string]string{"k1": "first-secret"},
+		CurrentKeyID: "k1",
+	})
+	require.NoError(t, err)
+
+	encrypted, keyID, err := old.Encrypt(ctx, "access-token")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+	assert.NotEqual(t, "access-token", encrypted)
+
+	rotated, err := NewKeyring(config.TokenEncryptionConfig{
+		Keys:         map[string]string{"k1": "first-secret", "k2": "second-secret"},
+		CurrentKeyID: "k2",
+	})
+	require.NoError(t, err)
+
+	// Tokens under the old key stay readable
+	decrypted, err := rotated.Decrypt(ctx, encrypted, keyID)
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", decrypted)
+
+	reencrypted, keyID, err := rotated.Encrypt(ctx, decrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keyID)
+
+	// ...but not under the wrong ID, or once the old key is retired
+	_, err = rotated.Decrypt(ctx, reencrypted, "k1")
+	assert.Error(t, err)
+	_, err = old.Decrypt(ctx, reencrypted, "k2")
+	assert.Error(t, err)
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	_, err := NewKeyring(config.TokenEncryptionConfig{CurrentKeyID: "k1"})
+	assert.Error(t, err)
+
+	_, err = NewKeyring(config.TokenEncryptionConfig{
+		Keys:         map[string]string{"k1": "secret"},
+		CurrentKeyID: "k2",
+	})
+	assert.Error(t, err)
+}
+
+func TestTokenEncryptionConfig(t *testing.T) {
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY", "legacy-secret")
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEYS", "2025a:new-secret, broken")
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY_ID", "2025a")
+
+	cfg := config.TokenEncryption()
+	assert.Equal(t, map[string]string{config.LegacyTokenKeyID: "legacy-secret", "2025a": "new-secret"}, cfg.Keys)
+	assert.Equal(t, "2025a", cfg.CurrentKeyID)
+}
+
+// fakeKMS "wraps" keys by prefixing them and counts unwrap calls
+type fakeKMS struct {
+	decrypts int
+}
+
+func (f *fakeKMS) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	return append([]byte("wrapped:"), plaintext...), nil
+}
+
+func (f *fakeKMS) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	f.decrypts++
+	return bytes.TrimPrefix(ciphertext, []byte("wrapped:")), nil
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewKeyring(config.TokenEncryptionConfig{
+		Keys:         map[string]string{"k1": "first-secret"},
+		CurrentKeyID: "k1",
+	})
+	require.NoError(t, err)
+	legacy, _, err := local.Encrypt(ctx, "old-token")
+	require.NoError(t, err)
+
+	kms := &fakeKMS{}
+	keyring, err := newKeyring(config.TokenEncryptionConfig{
+		Provider: config.TokenProviderAWSKMS,
+		Keys:     map[string]string{"k1": "first-secret"},
+	}, newCachingKMS(kms))
+	require.NoError(t, err)
+	assert.Equal(t, config.TokenProviderAWSKMS, keyring.CurrentKeyID())
+
+	encrypted, keyID, err := keyring.Encrypt(ctx, "access-token")
+	require.NoError(t, err)
+	assert.Equal(t, config.TokenProviderAWSKMS, keyID)
+	assert.NotContains(t, encrypted, "access-token")
+
+	for range 2 {
+		decrypted, err := keyring.Decrypt(ctx, encrypted, keyID)
+		require.NoError(t, err)
+		assert.Equal(t, "access-token", decrypted)
+	}
+	assert.Equal(t, 1, kms.decrypts, "unwrapped data keys should be cached")
+
+	// Tokens under local keys stay readable until they are re-encrypted
+	decrypted, err := keyring.Decrypt(ctx, legacy, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "old-token", decrypted)
+
+	// A local keyring can't read envelopes
+	_, err = local.Decrypt(ctx, encrypted, keyID)
+	assert.Error(t, err)
+}
+
+func TestKMSProviderConfig(t *testing.T) {
+	t.Setenv("TOKEN_ENCRYPTION_PROVIDER", "gcp-kms")
+	t.Setenv("TWITCH_TOKEN_ENCRYPTION_KEY_ID", "2025a")
+
+	cfg := config.TokenEncryption()
+	assert.True(t, cfg.UsesKMS())
+	assert.Equal(t, config.TokenProviderGCPKMS, cfg.CurrentKeyID)
+
+	// The KMS key is required
+	_, err := NewKeyring(cfg)
+	assert.Error(t, err)
+}
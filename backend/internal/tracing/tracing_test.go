@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddlewareTracesRequestsAndTheirQueries(t *testing.T) {
+	recorder := recordSpans(t)
+
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/api/analytics/:id", func(c *fiber.Ctx) error {
+		c.Locals("user", "user_1")
+		ctx := c.UserContext()
+		assert.Equal(t, "user_1", ctx.Value("user"), "Locals stay visible through the user context")
+
+		tracer := QueryTracer{}
+		ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "select 1"})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/api/analytics/enhanced?token=secret", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /api/analytics/:id", server.Name())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", server.SpanContext().TraceID().String())
+	assert.Equal(t, "/api/analytics/enhanced", attr(server, "url.path").AsString())
+	assert.Equal(t, int64(503), attr(server, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	assert.Equal(t, "postgresql SELECT", query.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, "select 1", attr(query, "db.query.text").AsString())
+	assert.Equal(t, int64(1), attr(query, "db.response.rows").AsInt64())
+}
+
+func TestQueriesOutsideRequestsAreNotTraced(t *testing.T) {
+	recorder := recordSpans(t)
+
+	tracer := QueryTracer{}
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "delete from x"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	assert.Empty(t, recorder.Ended())
+}
@@ -0,0 +1,83 @@
+package trends
+
+import (
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	service *Service
+}
+
+func NewHandlers(service *Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers trend routes on a Clerk-protected router. Trends
+// are public Twitch data, the same for every user.
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	trends := router.Group("/trends")
+	trends.Get("/games", h.GetGames)
+	trends.Get("/games/:id", h.GetGame)
+}
+
+// GetGames returns the top games, rising first, comparing the last ?days=
+// (default 7) with the days before. Samples are kept for 90 days, so both
+// windows fit in at most 45 days.
+func (h *Handlers) GetGames(c *fiber.Ctx) error {
+	var query struct {
+		Days int `query:"days" default:"7" validate:"min=1,max=45"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	report, err := h.service.GetReport(c.UserContext(), query.Days)
+	if err != nil {
+		log.Printf("Error getting game trends: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get game trends",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetGame returns a game's viewers over the last ?days= (default 30) and the
+// hours and weekdays they peak at in ?tz= (UTC by default)
+func (h *Handlers) GetGame(c *fiber.Ctx) error {
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=90"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	loc, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown time zone",
+		})
+	}
+
+	gameID := c.Params("id")
+	detail, err := h.service.GetGame(c.UserContext(), gameID, query.Days, loc)
+	if err != nil {
+		log.Printf("Error getting trend of game %s: %v", gameID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get game trend",
+		})
+	}
+	if detail == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Game not sampled in this period",
+		})
+	}
+
+	return c.JSON(detail)
+}
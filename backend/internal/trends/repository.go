@@ -0,0 +1,130 @@
+package trends
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ClaimSample(ctx context.Context, slot time.Time) (sampleID int, claimed bool, err error)
+	SaveViewers(ctx context.Context, sampleID int, games []GameViewers) error
+	DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error)
+
+	GetLatestGames(ctx context.Context, since, previousSince time.Time) ([]latestGame, error)
+	GetGameViewers(ctx context.Context, gameID string, since time.Time) ([]GameViewers, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+// ClaimSample records the sample of a slot. claimed is false when another
+// replica already took the slot.
+func (r *repository) ClaimSample(ctx context.Context, slot time.Time) (int, bool, error) {
+	query := `
+		INSERT INTO game_trend_samples (sampled_at)
+		VALUES ($1)
+		ON CONFLICT (sampled_at) DO NOTHING
+		RETURNING id
+	`
+
+	var id int
+	err := r.db.QueryRowxContext(ctx, query, slot).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// SaveViewers stores the games of a sample
+func (r *repository) SaveViewers(ctx context.Context, sampleID int, games []GameViewers) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO game_trend_viewers (sample_id, game_id, game_name, box_art_url, rank, viewers, streams)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (sample_id, game_id)
+		DO UPDATE SET
+			game_name = EXCLUDED.game_name,
+			box_art_url = EXCLUDED.box_art_url,
+			rank = EXCLUDED.rank,
+			viewers = EXCLUDED.viewers,
+			streams = EXCLUDED.streams
+	`
+	for _, game := range games {
+		if _, err := tx.ExecContext(ctx, query, sampleID, game.GameID, game.GameName, game.BoxArtURL,
+			game.Rank, game.Viewers, game.Streams); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteSamplesBefore deletes the samples taken before the given time
+func (r *repository) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM game_trend_samples WHERE sampled_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetLatestGames returns the games of the latest sample with their average
+// viewers since since and between previousSince and since, by rank
+func (r *repository) GetLatestGames(ctx context.Context, since, previousSince time.Time) ([]latestGame, error) {
+	query := `
+		WITH latest AS (
+			SELECT s.id, s.sampled_at
+			FROM game_trend_samples s
+			WHERE EXISTS (SELECT 1 FROM game_trend_viewers v WHERE v.sample_id = s.id)
+			ORDER BY s.sampled_at DESC
+			LIMIT 1
+		)
+		SELECT v.game_id, v.game_name, v.box_art_url, v.rank, v.viewers, v.streams, latest.sampled_at,
+			(SELECT AVG(w.viewers)::float8 FROM game_trend_viewers w
+				JOIN game_trend_samples s ON s.id = w.sample_id
+				WHERE w.game_id = v.game_id AND s.sampled_at >= $1) AS average_viewers,
+			(SELECT AVG(w.viewers)::float8 FROM game_trend_viewers w
+				JOIN game_trend_samples s ON s.id = w.sample_id
+				WHERE w.game_id = v.game_id AND s.sampled_at >= $2 AND s.sampled_at < $1) AS previous_average_viewers
+		FROM game_trend_viewers v
+		JOIN latest ON latest.id = v.sample_id
+		ORDER BY v.rank
+	`
+
+	games := []latestGame{}
+	err := r.db.SelectContext(ctx, &games, query, since, previousSince)
+	return games, err
+}
+
+// GetGameViewers returns a game's samples since the given time, oldest first
+func (r *repository) GetGameViewers(ctx context.Context, gameID string, since time.Time) ([]GameViewers, error) {
+	query := `
+		SELECT v.game_id, v.game_name, v.box_art_url, v.rank, v.viewers, v.streams, s.sampled_at
+		FROM game_trend_viewers v
+		JOIN game_trend_samples s ON s.id = v.sample_id
+		WHERE v.game_id = $1 AND s.sampled_at >= $2
+		ORDER BY s.sampled_at
+	`
+
+	samples := []GameViewers{}
+	err := r.db.SelectContext(ctx, &samples, query, gameID, since)
+	return samples, err
+}
@@ -0,0 +1,176 @@
+package trends
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// pollInterval is how often the worker checks whether a slot is due. Slots are
+// claimed in the database, so every replica can poll.
+const pollInterval = 5 * time.Minute
+
+// TwitchAPI is the part of the Twitch client the top games are sampled with
+type TwitchAPI interface {
+	GetAppAccessToken(ctx context.Context) (*twitch.OAuthToken, error)
+	GetTopGames(ctx context.Context, accessToken string, limit int) ([]twitch.Game, error)
+	GetGameStreamsPage(ctx context.Context, accessToken, gameID string, limit int, afterCursor string) ([]twitch.StreamInfo, string, error)
+}
+
+// Service samples the top games on Twitch and reports their trends
+type Service struct {
+	repo   Repository
+	twitch TwitchAPI
+	cfg    config.TrendsConfig
+	now    func() time.Time
+}
+
+func NewService(repo Repository, twitchClient TwitchAPI) *Service {
+	return &Service{
+		repo:   repo,
+		twitch: twitchClient,
+		cfg:    config.Trends(),
+		now:    time.Now,
+	}
+}
+
+// Run samples the top games once per SampleInterval until ctx is done
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(min(pollInterval, s.cfg.SampleInterval))
+	defer ticker.Stop()
+
+	for {
+		if err := s.sample(ctx); err != nil {
+			log.Printf("❌ Failed to sample top games: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample takes the sample of the current slot unless a replica already did,
+// then deletes samples past retention
+func (s *Service) sample(ctx context.Context) error {
+	now := s.now().UTC()
+	sampleID, claimed, err := s.repo.ClaimSample(ctx, now.Truncate(s.cfg.SampleInterval))
+	if err != nil {
+		return fmt.Errorf("failed to claim sample: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	token, err := s.twitch.GetAppAccessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get app access token: %w", err)
+	}
+	games, err := s.twitch.GetTopGames(ctx, token.AccessToken, s.cfg.TopGames)
+	if err != nil {
+		return fmt.Errorf("failed to get top games: %w", err)
+	}
+
+	samples := make([]GameViewers, 0, len(games))
+	for i, game := range games {
+		viewers, streams, err := s.countViewers(ctx, token.AccessToken, game.ID)
+		if err != nil {
+			// The other games still make a useful sample
+			log.Printf("⚠️ Failed to count viewers of %s: %v", game.Name, err)
+			continue
+		}
+		samples = append(samples, GameViewers{
+			GameID:    game.ID,
+			GameName:  game.Name,
+			BoxArtURL: game.BoxArtURL,
+			Rank:      i + 1,
+			Viewers:   viewers,
+			Streams:   streams,
+		})
+	}
+	if err := s.repo.SaveViewers(ctx, sampleID, samples); err != nil {
+		return fmt.Errorf("failed to save sample: %w", err)
+	}
+
+	deleted, err := s.repo.DeleteSamplesBefore(ctx, now.AddDate(0, 0, -s.cfg.RetentionDays))
+	if err != nil {
+		return fmt.Errorf("failed to delete old samples: %w", err)
+	}
+	log.Printf("✅ Sampled %d top games, deleted %d old samples", len(samples), deleted)
+	return nil
+}
+
+// countViewers sums the viewers of a game's top StreamPages pages of streams
+func (s *Service) countViewers(ctx context.Context, accessToken, gameID string) (int, int, error) {
+	viewers, streams := 0, 0
+	cursor := ""
+	for page := 0; page < s.cfg.StreamPages; page++ {
+		live, next, err := s.twitch.GetGameStreamsPage(ctx, accessToken, gameID, 100, cursor)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, stream := range live {
+			viewers += stream.ViewerCount
+		}
+		streams += len(live)
+		if next == "" || len(live) == 0 {
+			break
+		}
+		cursor = next
+	}
+	return viewers, streams, nil
+}
+
+// GetReport returns the games of the latest sample, comparing their average
+// viewers over the last days with the days before
+func (s *Service) GetReport(ctx context.Context, days int) (*Report, error) {
+	now := s.now()
+	games, err := s.repo.GetLatestGames(ctx, now.AddDate(0, 0, -days), now.AddDate(0, 0, -2*days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest games: %w", err)
+	}
+
+	report := &Report{Days: days, Games: rising(games)}
+	if len(games) > 0 {
+		report.SampledAt = &games[0].SampledAt
+	}
+	return report, nil
+}
+
+// GetGame returns a game's viewers over the last days and when they peak in
+// loc. It returns nil when the game wasn't sampled in that time.
+func (s *Service) GetGame(ctx context.Context, gameID string, days int, loc *time.Location) (*GameDetail, error) {
+	samples, err := s.repo.GetGameViewers(ctx, gameID, s.now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game viewers: %w", err)
+	}
+	if len(samples) == 0 {
+		return nil, nil
+	}
+
+	latest := samples[len(samples)-1]
+	detail := &GameDetail{
+		GameID:    gameID,
+		Name:      latest.GameName,
+		BoxArtURL: latest.BoxArtURL,
+		Days:      days,
+		Timezone:  loc.String(),
+		Points:    make([]Point, 0, len(samples)),
+	}
+	for _, sample := range samples {
+		detail.Points = append(detail.Points, Point{
+			SampledAt: sample.SampledAt,
+			Rank:      sample.Rank,
+			Viewers:   sample.Viewers,
+			Streams:   sample.Streams,
+		})
+	}
+	detail.Hours, detail.Weekdays, detail.PeakHour, detail.PeakWeekday = peakTimes(samples, loc)
+	return detail, nil
+}
@@ -0,0 +1,171 @@
+package trends
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// GameViewers is a game's viewers in one sample
+type GameViewers struct {
+	GameID    string    `db:"game_id"`
+	GameName  string    `db:"game_name"`
+	BoxArtURL string    `db:"box_art_url"`
+	Rank      int       `db:"rank"`
+	Viewers   int       `db:"viewers"`
+	Streams   int       `db:"streams"`
+	SampledAt time.Time `db:"sampled_at"`
+}
+
+// latestGame is a game of the latest sample with its average viewers over the
+// compared windows, averaged over the samples it was among the top games in
+type latestGame struct {
+	GameViewers
+	AverageViewers         *float64 `db:"average_viewers"`
+	PreviousAverageViewers *float64 `db:"previous_average_viewers"`
+}
+
+// GameTrend is a game of the latest sample and how its viewers changed
+type GameTrend struct {
+	GameID    string `json:"game_id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+	Rank      int    `json:"rank"`
+	Viewers   int    `json:"viewers"`
+	// AverageViewers covers the last days, PreviousAverageViewers the days
+	// before them. nil when the game wasn't among the top games then.
+	AverageViewers         *int `json:"average_viewers"`
+	PreviousAverageViewers *int `json:"previous_average_viewers"`
+	// ChangePercent compares both averages, nil without a previous one
+	ChangePercent *float64 `json:"change_percent"`
+}
+
+// Report is returned by /api/trends/games
+type Report struct {
+	Days int `json:"days"`
+	// SampledAt is when the latest sample was taken, nil before the first
+	SampledAt *time.Time `json:"sampled_at"`
+	// Games are ordered by ChangePercent, rising first
+	Games []GameTrend `json:"games"`
+}
+
+// Point is a game's viewers in one sample
+type Point struct {
+	SampledAt time.Time `json:"sampled_at"`
+	Rank      int       `json:"rank"`
+	Viewers   int       `json:"viewers"`
+	Streams   int       `json:"streams"`
+}
+
+// HourViewers is a game's average viewers at an hour of the day
+type HourViewers struct {
+	Hour           int `json:"hour"`
+	AverageViewers int `json:"average_viewers"`
+}
+
+// WeekdayViewers is a game's average viewers on a day of the week
+type WeekdayViewers struct {
+	Weekday        string `json:"weekday"`
+	AverageViewers int    `json:"average_viewers"`
+}
+
+// GameDetail is returned by /api/trends/games/:id
+type GameDetail struct {
+	GameID    string  `json:"game_id"`
+	Name      string  `json:"name"`
+	BoxArtURL string  `json:"box_art_url"`
+	Days      int     `json:"days"`
+	Timezone  string  `json:"timezone"`
+	Points    []Point `json:"points"`
+	// Hours and Weekdays only list those with samples, in Timezone
+	Hours       []HourViewers    `json:"hours"`
+	Weekdays    []WeekdayViewers `json:"weekdays"`
+	PeakHour    *int             `json:"peak_hour"`
+	PeakWeekday string           `json:"peak_weekday"`
+}
+
+// rising turns the latest sample into trends, the fastest rising first and
+// games without a previous average last, by rank
+func rising(games []latestGame) []GameTrend {
+	trends := make([]GameTrend, 0, len(games))
+	for _, game := range games {
+		trend := GameTrend{
+			GameID:                 game.GameID,
+			Name:                   game.GameName,
+			BoxArtURL:              game.BoxArtURL,
+			Rank:                   game.Rank,
+			Viewers:                game.Viewers,
+			AverageViewers:         roundViewers(game.AverageViewers),
+			PreviousAverageViewers: roundViewers(game.PreviousAverageViewers),
+		}
+		if game.AverageViewers != nil && game.PreviousAverageViewers != nil && *game.PreviousAverageViewers > 0 {
+			change := math.Round((*game.AverageViewers-*game.PreviousAverageViewers) / *game.PreviousAverageViewers * 10000) / 100
+			trend.ChangePercent = &change
+		}
+		trends = append(trends, trend)
+	}
+
+	sort.SliceStable(trends, func(i, j int) bool {
+		a, b := trends[i].ChangePercent, trends[j].ChangePercent
+		switch {
+		case a != nil && b != nil && *a != *b:
+			return *a > *b
+		case (a == nil) != (b == nil):
+			return a != nil
+		}
+		return trends[i].Rank < trends[j].Rank
+	})
+	return trends
+}
+
+func roundViewers(average *float64) *int {
+	if average == nil {
+		return nil
+	}
+	viewers := int(math.Round(*average))
+	return &viewers
+}
+
+// peakTimes averages a game's viewers per hour of the day and day of the
+// week in loc. samples are ordered oldest first.
+func peakTimes(samples []GameViewers, loc *time.Location) ([]HourViewers, []WeekdayViewers, *int, string) {
+	var hourTotals, hourSamples [24]int
+	var weekdayTotals, weekdaySamples [7]int
+	for _, sample := range samples {
+		at := sample.SampledAt.In(loc)
+		hourTotals[at.Hour()] += sample.Viewers
+		hourSamples[at.Hour()]++
+		weekdayTotals[at.Weekday()] += sample.Viewers
+		weekdaySamples[at.Weekday()]++
+	}
+
+	hours := []HourViewers{}
+	var peakHour *int
+	peakAverage := -1
+	for hour := range hourTotals {
+		if hourSamples[hour] == 0 {
+			continue
+		}
+		average := int(math.Round(float64(hourTotals[hour]) / float64(hourSamples[hour])))
+		hours = append(hours, HourViewers{Hour: hour, AverageViewers: average})
+		if average > peakAverage {
+			peak := hour
+			peakHour, peakAverage = &peak, average
+		}
+	}
+
+	weekdays := []WeekdayViewers{}
+	peakWeekday := ""
+	peakAverage = -1
+	for day := range weekdayTotals {
+		if weekdaySamples[day] == 0 {
+			continue
+		}
+		average := int(math.Round(float64(weekdayTotals[day]) / float64(weekdaySamples[day])))
+		weekdays = append(weekdays, WeekdayViewers{Weekday: time.Weekday(day).String(), AverageViewers: average})
+		if average > peakAverage {
+			peakWeekday, peakAverage = time.Weekday(day).String(), average
+		}
+	}
+	return hours, weekdays, peakHour, peakWeekday
+}
@@ -0,0 +1,148 @@
+package trends
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestRising(t *testing.T) {
+	games := []latestGame{
+		{GameViewers: GameViewers{GameID: "1", Rank: 1}, AverageViewers: float(1000), PreviousAverageViewers: float(1000)},
+		{GameViewers: GameViewers{GameID: "2", Rank: 2}, AverageViewers: float(500.4), PreviousAverageViewers: float(250)},
+		// New to the top games
+		{GameViewers: GameViewers{GameID: "3", Rank: 3}, AverageViewers: float(400)},
+		{GameViewers: GameViewers{GameID: "4", Rank: 4}, AverageViewers: float(90), PreviousAverageViewers: float(120)},
+	}
+
+	trends := rising(games)
+	require.Len(t, trends, 4)
+	ids := make([]string, len(trends))
+	for i, trend := range trends {
+		ids[i] = trend.GameID
+	}
+	assert.Equal(t, []string{"2", "1", "4", "3"}, ids)
+
+	require.NotNil(t, trends[0].ChangePercent)
+	assert.Equal(t, 100.16, *trends[0].ChangePercent)
+	assert.Equal(t, 500, *trends[0].AverageViewers)
+	assert.Equal(t, -25.0, *trends[2].ChangePercent)
+	assert.Nil(t, trends[3].ChangePercent)
+	assert.Nil(t, trends[3].PreviousAverageViewers)
+
+	assert.Empty(t, rising(nil))
+}
+
+func TestPeakTimes(t *testing.T) {
+	// Monday 2025-06-02
+	monday := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	samples := []GameViewers{
+		{SampledAt: monday.Add(18 * time.Hour), Viewers: 100},
+		{SampledAt: monday.Add(20 * time.Hour), Viewers: 300},
+		{SampledAt: monday.Add(44 * time.Hour), Viewers: 500},
+		{SampledAt: monday.Add(42 * time.Hour), Viewers: 200},
+	}
+
+	hours, weekdays, peakHour, peakWeekday := peakTimes(samples, time.UTC)
+	assert.Equal(t, []HourViewers{{Hour: 18, AverageViewers: 150}, {Hour: 20, AverageViewers: 400}}, hours)
+	assert.Equal(t, []WeekdayViewers{{Weekday: "Monday", AverageViewers: 200}, {Weekday: "Tuesday", AverageViewers: 350}}, weekdays)
+	require.NotNil(t, peakHour)
+	assert.Equal(t, 20, *peakHour)
+	assert.Equal(t, "Tuesday", peakWeekday)
+
+	// 20:00 UTC is 22:00 in Berlin in summer
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	_, _, peakHour, _ = peakTimes(samples, berlin)
+	assert.Equal(t, 22, *peakHour)
+
+	hours, weekdays, peakHour, peakWeekday = peakTimes(nil, time.UTC)
+	assert.Empty(t, hours)
+	assert.Empty(t, weekdays)
+	assert.Nil(t, peakHour)
+	assert.Empty(t, peakWeekday)
+}
+
+type fakeRepo struct {
+	Repository
+	claimed map[time.Time]bool
+	saved   []GameViewers
+}
+
+func (r *fakeRepo) ClaimSample(ctx context.Context, slot time.Time) (int, bool, error) {
+	if r.claimed[slot] {
+		return 0, false, nil
+	}
+	r.claimed[slot] = true
+	return len(r.claimed), true, nil
+}
+
+func (r *fakeRepo) SaveViewers(ctx context.Context, sampleID int, games []GameViewers) error {
+	r.saved = append(r.saved, games...)
+	return nil
+}
+
+func (r *fakeRepo) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeTwitch struct {
+	pages map[string][][]twitch.StreamInfo
+	calls int
+}
+
+func (f *fakeTwitch) GetAppAccessToken(ctx context.Context) (*twitch.OAuthToken, error) {
+	return &twitch.OAuthToken{AccessToken: "app"}, nil
+}
+
+func (f *fakeTwitch) GetTopGames(ctx context.Context, accessToken string, limit int) ([]twitch.Game, error) {
+	return []twitch.Game{{ID: "a", Name: "Celeste"}, {ID: "b", Name: "Tetris"}}, nil
+}
+
+func (f *fakeTwitch) GetGameStreamsPage(ctx context.Context, accessToken, gameID string, limit int, afterCursor string) ([]twitch.StreamInfo, string, error) {
+	f.calls++
+	page := 0
+	if afterCursor != "" {
+		page = int(afterCursor[0] - '0')
+	}
+	pages := f.pages[gameID]
+	next := ""
+	if page+1 < len(pages) {
+		next = string(rune('0' + page + 1))
+	}
+	return pages[page], next, nil
+}
+
+func TestSampleClaimsEachSlotOnce(t *testing.T) {
+	repo := &fakeRepo{claimed: make(map[time.Time]bool)}
+	client := &fakeTwitch{pages: map[string][][]twitch.StreamInfo{
+		"a": {{{ViewerCount: 50}, {ViewerCount: 30}}, {{ViewerCount: 5}}, {{ViewerCount: 1}}},
+		"b": {{}},
+	}}
+	now := time.Date(2025, 6, 2, 18, 40, 0, 0, time.UTC)
+	svc := &Service{repo: repo, twitch: client, now: func() time.Time { return now },
+		cfg: config.TrendsConfig{SampleInterval: time.Hour, TopGames: 2, StreamPages: 2, RetentionDays: 90}}
+
+	require.NoError(t, svc.sample(context.Background()))
+	assert.True(t, repo.claimed[time.Date(2025, 6, 2, 18, 0, 0, 0, time.UTC)])
+	// Only StreamPages pages of Celeste are counted
+	assert.Equal(t, []GameViewers{
+		{GameID: "a", GameName: "Celeste", Rank: 1, Viewers: 85, Streams: 3},
+		{GameID: "b", GameName: "Tetris", Rank: 2},
+	}, repo.saved)
+
+	// Later in the same slot nothing is sampled
+	now = now.Add(15 * time.Minute)
+	calls := client.calls
+	require.NoError(t, svc.sample(context.Background()))
+	assert.Equal(t, calls, client.calls)
+	assert.Len(t, repo.saved, 2)
+}
@@ -0,0 +1,92 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AdSchedule describes a broadcaster's ad schedule and snooze state.
+// Time fields are empty when not applicable (e.g. no ad run yet).
+type AdSchedule struct {
+	SnoozeCount     int    `json:"snooze_count"`
+	SnoozeRefreshAt string `json:"snooze_refresh_at"`
+	NextAdAt        string `json:"next_ad_at"`
+	Duration        int    `json:"duration"`
+	LastAdAt        string `json:"last_ad_at"`
+	PrerollFreeTime int    `json:"preroll_free_time"`
+}
+
+// LastAdTime parses LastAdAt, returning nil when no ad has run
+func (s *AdSchedule) LastAdTime() *time.Time {
+	return parseAdTime(s.LastAdAt)
+}
+
+// NextAdTime parses NextAdAt, returning nil when no ad is scheduled
+func (s *AdSchedule) NextAdTime() *time.Time {
+	return parseAdTime(s.NextAdAt)
+}
+
+func parseAdTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+type adScheduleResponse struct {
+	Data []AdSchedule `json:"data"`
+}
+
+// GetAdSchedule fetches the broadcaster's ad schedule. Twitch does not expose ad
+// revenue; this is the closest signal of how many ads a channel runs.
+// Required scope: channel:read:ads
+// See: https://dev.twitch.tv/docs/api/reference/#get-ad-schedule
+func (c *Client) GetAdSchedule(ctx context.Context, userAccessToken, broadcasterID string) (*AdSchedule, error) {
+	if broadcasterID == "" {
+		return nil, fmt.Errorf("broadcasterID cannot be empty")
+	}
+
+	params := url.Values{}
+	params.Set("broadcaster_id", broadcasterID)
+
+	apiURL := fmt.Sprintf("%s/channels/ads?%s", twitchAPIBaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.clientID != "" {
+		req.Header.Set("Client-ID", c.clientID)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var response adScheduleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode ad schedule response: %w", err)
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("no ad schedule returned")
+	}
+
+	return &response.Data[0], nil
+}
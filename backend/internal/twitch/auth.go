@@ -15,7 +15,7 @@ func (c *Client) ValidateToken(ctx context.Context, token string) (bool, error)
 
 	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", token))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return false, fmt.Errorf("failed to execute validation request: %w", err)
 	}
@@ -36,3 +36,31 @@ func (c *Client) ValidateToken(ctx context.Context, token string) (bool, error)
 
 	return true, nil
 }
+
+// GetTokenInfo validates a token and returns its details, including the granted scopes.
+// It returns nil without an error when Twitch reports the token as invalid.
+func (c *Client) GetTokenInfo(ctx context.Context, token string) (*TokenValidationResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://id.twitch.tv/oauth2/validate", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create validation request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", token))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute validation request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+
+	var validationResp TokenValidationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&validationResp); err != nil {
+		return nil, fmt.Errorf("failed to decode validation response: %w", err)
+	}
+
+	return &validationResp, nil
+}
@@ -23,7 +23,7 @@ func (c *Client) GetChannelInfo(ctx context.Context, userAccessToken string, bro
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -1,11 +1,15 @@
 package twitch
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/httpclient"
 )
 
 const (
@@ -13,29 +17,75 @@ const (
 	twitchAuthURL    = "https://id.twitch.tv/oauth2/token"
 )
 
+// DefaultCallTimeout bounds each Twitch request, including reading its
+// response, unless the caller's context ends sooner
+const DefaultCallTimeout = 10 * time.Second
+
 type Client struct {
 	clientID     string
 	clientSecret string
 	httpClient   *http.Client
+	callTimeout  time.Duration
 }
 
 func NewClient(clientID, clientSecret string) (*Client, error) {
 	return &Client{
 		clientID:     clientID,
 		clientSecret: clientSecret,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpClient:   httpclient.New("twitch"),
+		callTimeout:  DefaultCallTimeout,
 	}, nil
 }
 
-func (c *Client) makeRequest(method, endpoint string, headers map[string]string, params url.Values) (*http.Response, error) {
+// SetCallTimeout changes the time budget of each request. Zero leaves requests
+// bounded only by their context.
+func (c *Client) SetCallTimeout(timeout time.Duration) {
+	c.callTimeout = timeout
+}
+
+// SetTransport replaces the transport used for Twitch requests, e.g. with a
+// Recorder in tests. Requests are still traced and retried, and a nil
+// transport restores the shared connection pool.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = httpclient.NewTransport("twitch", rt)
+}
+
+// do sends req within the call budget, which ends when the response body is
+// closed. A shorter deadline on the request's context wins.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.callTimeout <= 0 {
+		return c.httpClient.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.callTimeout)
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, headers map[string]string, params url.Values) (*http.Response, error) {
 	reqURL := twitchAPIBaseURL + endpoint
 	if len(params) > 0 {
 		reqURL += "?" + params.Encode()
 	}
 
-	req, err := http.NewRequest(method, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -45,11 +95,11 @@ func (c *Client) makeRequest(method, endpoint string, headers map[string]string,
 		req.Header.Set(key, value)
 	}
 
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
-func (c *Client) GetChannelInfoWithToken(accessToken string) (*ChannelInfo, error) {
-	userID, err := c.getUserID(accessToken)
+func (c *Client) GetChannelInfoWithToken(ctx context.Context, accessToken string) (*ChannelInfo, error) {
+	userID, err := c.getUserID(ctx, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +111,7 @@ func (c *Client) GetChannelInfoWithToken(accessToken string) (*ChannelInfo, erro
 	params := url.Values{}
 	params.Set("broadcaster_id", userID)
 
-	resp, err := c.makeRequest("GET", "/channels", headers, params)
+	resp, err := c.makeRequest(ctx, "GET", "/channels", headers, params)
 	if err != nil {
 		return nil, err
 	}
@@ -83,8 +133,8 @@ func (c *Client) GetChannelInfoWithToken(accessToken string) (*ChannelInfo, erro
 	return &channelResp.Data[0], nil
 }
 
-func (c *Client) GetFollowerCount(accessToken string) (int, error) {
-	userID, err := c.getUserID(accessToken)
+func (c *Client) GetFollowerCount(ctx context.Context, accessToken string) (int, error) {
+	userID, err := c.getUserID(ctx, accessToken)
 	if err != nil {
 		return 0, err
 	}
@@ -97,7 +147,7 @@ func (c *Client) GetFollowerCount(accessToken string) (int, error) {
 	params.Set("broadcaster_id", userID)
 	params.Set("first", "1")
 
-	resp, err := c.makeRequest("GET", "/channels/followers", headers, params)
+	resp, err := c.makeRequest(ctx, "GET", "/channels/followers", headers, params)
 	if err != nil {
 		return 0, err
 	}
@@ -115,8 +165,8 @@ func (c *Client) GetFollowerCount(accessToken string) (int, error) {
 	return followersResp.Total, nil
 }
 
-func (c *Client) GetSubscriberCount(accessToken string) (int, error) {
-	userID, err := c.getUserID(accessToken)
+func (c *Client) GetSubscriberCount(ctx context.Context, accessToken string) (int, error) {
+	userID, err := c.getUserID(ctx, accessToken)
 	if err != nil {
 		return 0, err
 	}
@@ -128,7 +178,7 @@ func (c *Client) GetSubscriberCount(accessToken string) (int, error) {
 	params := url.Values{}
 	params.Set("broadcaster_id", userID)
 
-	resp, err := c.makeRequest("GET", "/subscriptions", headers, params)
+	resp, err := c.makeRequest(ctx, "GET", "/subscriptions", headers, params)
 	if err != nil {
 		return 0, err
 	}
@@ -146,8 +196,8 @@ func (c *Client) GetSubscriberCount(accessToken string) (int, error) {
 	return len(subsResp.Data), nil
 }
 
-func (c *Client) GetVideos(accessToken, videoType string, limit int) ([]VideoInfo, error) {
-	userID, err := c.getUserID(accessToken)
+func (c *Client) GetVideos(ctx context.Context, accessToken, videoType string, limit int) ([]VideoInfo, error) {
+	userID, err := c.getUserID(ctx, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -161,7 +211,7 @@ func (c *Client) GetVideos(accessToken, videoType string, limit int) ([]VideoInf
 	params.Set("type", videoType)
 	params.Set("first", fmt.Sprintf("%d", limit))
 
-	resp, err := c.makeRequest("GET", "/videos", headers, params)
+	resp, err := c.makeRequest(ctx, "GET", "/videos", headers, params)
 	if err != nil {
 		return nil, err
 	}
@@ -179,8 +229,8 @@ func (c *Client) GetVideos(accessToken, videoType string, limit int) ([]VideoInf
 	return videosResp.Data, nil
 }
 
-func (c *Client) GetStreamInfo(accessToken string) (*StreamInfo, error) {
-	userID, err := c.getUserID(accessToken)
+func (c *Client) GetStreamInfo(ctx context.Context, accessToken string) (*StreamInfo, error) {
+	userID, err := c.getUserID(ctx, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +242,7 @@ func (c *Client) GetStreamInfo(accessToken string) (*StreamInfo, error) {
 	params := url.Values{}
 	params.Set("user_id", userID)
 
-	resp, err := c.makeRequest("GET", "/streams", headers, params)
+	resp, err := c.makeRequest(ctx, "GET", "/streams", headers, params)
 	if err != nil {
 		return nil, err
 	}
@@ -214,19 +264,19 @@ func (c *Client) GetStreamInfo(accessToken string) (*StreamInfo, error) {
 	return &streamResp.Data[0], nil
 }
 
-func (c *Client) GetUserInfo(accessToken string) (*User, error) {
+func (c *Client) GetUserInfo(ctx context.Context, accessToken string) (*User, error) {
 	headers := map[string]string{
 		"Authorization": "Bearer " + accessToken,
 	}
 
-	resp, err := c.makeRequest("GET", "/users", headers, nil)
+	resp, err := c.makeRequest(ctx, "GET", "/users", headers, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("twitch API error: %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var userResp UsersResponse
@@ -241,10 +291,39 @@ func (c *Client) GetUserInfo(accessToken string) (*User, error) {
 	return &userResp.Data[0], nil
 }
 
-func (c *Client) getUserID(accessToken string) (string, error) {
-	user, err := c.GetUserInfo(accessToken)
+func (c *Client) getUserID(ctx context.Context, accessToken string) (string, error) {
+	user, err := c.GetUserInfo(ctx, accessToken)
 	if err != nil {
 		return "", err
 	}
 	return user.ID, nil
 }
+
+// getJSON performs an authorized GET and decodes the JSON response into out
+func (c *Client) getJSON(ctx context.Context, userAccessToken, apiURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.clientID != "" {
+		req.Header.Set("Client-ID", c.clientID)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package twitch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// hangingTransport never answers, it waits for the request to be cancelled
+var hangingTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+})
+
+func TestCallTimeoutBoundsRequests(t *testing.T) {
+	client, err := NewClient("client", "secret")
+	require.NoError(t, err)
+	client.SetTransport(hangingTransport)
+	client.SetCallTimeout(20 * time.Millisecond)
+
+	started := time.Now()
+	_, err = client.GetUserInfo(context.Background(), "token")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), time.Second)
+}
+
+func TestCallerContextCancelsRequests(t *testing.T) {
+	client, err := NewClient("client", "secret")
+	require.NoError(t, err)
+	client.SetTransport(hangingTransport)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetFollowerCount(ctx, "token")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCallTimeoutCoversReadingTheResponse(t *testing.T) {
+	client, err := NewClient("client", "secret")
+	require.NoError(t, err)
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"data":[{"id":"1001","login":"creator"}]}`)),
+			Request:    req,
+		}, nil
+	}))
+
+	// The budget is still running while the body is decoded
+	user, err := client.GetUserInfo(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "1001", user.ID)
+}
@@ -27,7 +27,7 @@ type ClipInfo struct {
 	CreatedAt       time.Time `json:"created_at"`
 	ThumbnailURL    string    `json:"thumbnail_url"`
 	Duration        float64   `json:"duration"`
-	VodOffset       int       `json:"vod_offset"`
+	VodOffset       *int      `json:"vod_offset"` // seconds into VideoID, nil until the VOD is available
 	IsFeatured      bool      `json:"is_featured"`
 }
 
@@ -41,6 +41,16 @@ type ClipsResponse struct {
 
 // GetClips fetches clips for a specific broadcaster
 func (c *Client) GetClips(ctx context.Context, userAccessToken string, broadcasterID string, limit int) ([]ClipInfo, error) {
+	clipsResponse, err := c.GetClipsPage(ctx, userAccessToken, broadcasterID, limit, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return clipsResponse.Data, nil
+}
+
+// GetClipsPage fetches one page of a broadcaster's clips from the last year
+func (c *Client) GetClipsPage(ctx context.Context, userAccessToken string, broadcasterID string, limit int, afterCursor string) (*ClipsResponse, error) {
 	baseURL := "https://api.twitch.tv/helix/clips"
 	params := url.Values{}
 	params.Add("broadcaster_id", broadcasterID)
@@ -51,6 +61,10 @@ func (c *Client) GetClips(ctx context.Context, userAccessToken string, broadcast
 	params.Add("started_at", startTime.Format(time.RFC3339))
 	params.Add("ended_at", endTime.Format(time.RFC3339))
 
+	if afterCursor != "" {
+		params.Add("after", afterCursor)
+	}
+
 	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
@@ -61,7 +75,7 @@ func (c *Client) GetClips(ctx context.Context, userAccessToken string, broadcast
 	req.Header.Set("Client-ID", c.clientID)
 	req.Header.Set("Authorization", "Bearer "+userAccessToken)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -78,5 +92,50 @@ func (c *Client) GetClips(ctx context.Context, userAccessToken string, broadcast
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return clipsResponse.Data, nil
+	return &clipsResponse, nil
+}
+
+// ClipDownload holds the MP4 URLs of a clip. PortraitURL is only set when a
+// vertical version of the clip was made.
+type ClipDownload struct {
+	ClipID       string `json:"clip_id"`
+	LandscapeURL string `json:"landscape_download_url"`
+	PortraitURL  string `json:"portrait_download_url"`
+}
+
+// GetClipDownload returns the download URLs of one of the broadcaster's clips.
+// The editor is the user whose token is used, the broadcaster for their own
+// channel. The URLs expire shortly after.
+// Required scope: channel:manage:clips or editor:manage:clips
+// See: https://dev.twitch.tv/docs/api/reference/#get-clips-download
+func (c *Client) GetClipDownload(ctx context.Context, userAccessToken, editorID, broadcasterID, clipID string) (*ClipDownload, error) {
+	params := url.Values{}
+	params.Set("editor_id", editorID)
+	params.Set("broadcaster_id", broadcasterID)
+	params.Set("clip_id", clipID)
+
+	apiURL := fmt.Sprintf("%s/clips/downloads?%s", twitchAPIBaseURL, params.Encode())
+	var response struct {
+		Data []struct {
+			ClipID       string  `json:"clip_id"`
+			LandscapeURL *string `json:"landscape_download_url"`
+			PortraitURL  *string `json:"portrait_download_url"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, userAccessToken, apiURL, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("clip %s not found", clipID)
+	}
+
+	data := response.Data[0]
+	download := &ClipDownload{ClipID: data.ClipID}
+	if data.LandscapeURL != nil {
+		download.LandscapeURL = *data.LandscapeURL
+	}
+	if data.PortraitURL != nil {
+		download.PortraitURL = *data.PortraitURL
+	}
+	return download, nil
 }
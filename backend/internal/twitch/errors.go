@@ -0,0 +1,30 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when Twitch responds with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("twitch API error: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("twitch API error: status %d, body: %s", e.StatusCode, e.Body)
+}
+
+// IsUnauthorized reports whether err is a Twitch 401 or 403 response, meaning the
+// token was revoked, expired or lacks the required scopes
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,32 @@
+package twitch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyEventSubSignature(t *testing.T) {
+	secret := "s3cre7-s3cre7-s3cre7"
+	messageID := "e76c6bd4-55c9-4987-8304-da1588d8988b"
+	sentAt := time.Date(2025, 6, 1, 12, 0, 0, 123456789, time.UTC)
+	timestamp := sentAt.Format(time.RFC3339Nano)
+	body := []byte(`{"event":{"id":"1"}}`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(messageID + timestamp))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	now := sentAt.Add(time.Minute)
+	assert.True(t, VerifyEventSubSignature(secret, messageID, timestamp, signature, body, now))
+
+	assert.False(t, VerifyEventSubSignature("wrong-secret-wrong", messageID, timestamp, signature, body, now))
+	assert.False(t, VerifyEventSubSignature(secret, messageID, timestamp, signature, []byte(`{}`), now))
+	assert.False(t, VerifyEventSubSignature(secret, messageID, timestamp, signature, body, sentAt.Add(time.Hour)))
+	assert.False(t, VerifyEventSubSignature(secret, messageID, "not-a-time", signature, body, now))
+}
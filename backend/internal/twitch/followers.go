@@ -0,0 +1,63 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GetChannelFollowers fetches one page of a broadcaster's followers, newest first.
+// Required scope: moderator:read:followers
+// See: https://dev.twitch.tv/docs/api/reference/#get-channel-followers
+func (c *Client) GetChannelFollowers(ctx context.Context, userAccessToken, broadcasterID string, limit int, afterCursor string) (*FollowersResponse, error) {
+	if broadcasterID == "" {
+		return nil, fmt.Errorf("broadcasterID cannot be empty")
+	}
+
+	params := url.Values{}
+	params.Set("broadcaster_id", broadcasterID)
+
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100 // Max limit per Twitch API
+	}
+	params.Set("first", strconv.Itoa(limit))
+
+	if afterCursor != "" {
+		params.Set("after", afterCursor)
+	}
+
+	apiURL := fmt.Sprintf("%s/channels/followers?%s", twitchAPIBaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.clientID != "" {
+		req.Header.Set("Client-ID", c.clientID)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("twitch API error getting followers: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var response FollowersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode followers response: %w", err)
+	}
+
+	return &response, nil
+}
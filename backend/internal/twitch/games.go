@@ -0,0 +1,65 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Game is a Twitch category
+type Game struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+}
+
+// GamesResponse is the response from the Twitch Get Top Games endpoint
+type GamesResponse struct {
+	Data       []Game `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+// GetTopGames returns the categories with the most viewers right now, most
+// watched first. Works with an app access token.
+// See: https://dev.twitch.tv/docs/api/reference/#get-top-games
+func (c *Client) GetTopGames(ctx context.Context, accessToken string, limit int) ([]Game, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	params := url.Values{}
+	params.Set("first", strconv.Itoa(limit))
+
+	var resp GamesResponse
+	if err := c.getJSON(ctx, accessToken, fmt.Sprintf("%s/games/top?%s", twitchAPIBaseURL, params.Encode()), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// GetGameStreamsPage returns a page of the live streams in a category, most
+// viewers first, and the cursor of the next page. Works with an app access
+// token.
+// See: https://dev.twitch.tv/docs/api/reference/#get-streams
+func (c *Client) GetGameStreamsPage(ctx context.Context, accessToken, gameID string, limit int, afterCursor string) ([]StreamInfo, string, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	params := url.Values{}
+	params.Set("game_id", gameID)
+	params.Set("type", "live")
+	params.Set("first", strconv.Itoa(limit))
+	if afterCursor != "" {
+		params.Set("after", afterCursor)
+	}
+
+	var resp StreamResponse
+	if err := c.getJSON(ctx, accessToken, fmt.Sprintf("%s/streams?%s", twitchAPIBaseURL, params.Encode()), &resp); err != nil {
+		return nil, "", err
+	}
+	return resp.Data, resp.Pagination.Cursor, nil
+}
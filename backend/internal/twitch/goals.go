@@ -0,0 +1,42 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// CreatorGoal is an active follower, subscription or bits goal set up in the
+// Creator Dashboard
+type CreatorGoal struct {
+	ID            string    `json:"id"`
+	BroadcasterID string    `json:"broadcaster_id"`
+	Type          string    `json:"type"` // follower, subscription(_count), new_subscription(_count), new_bit, new_cheerer
+	Description   string    `json:"description"`
+	CurrentAmount int       `json:"current_amount"`
+	TargetAmount  int       `json:"target_amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GetCreatorGoals fetches the broadcaster's active goals. Twitch only returns
+// goals that are running, ended goals disappear from the response.
+// Required scope: channel:read:goals
+// See: https://dev.twitch.tv/docs/api/reference/#get-creator-goals
+func (c *Client) GetCreatorGoals(ctx context.Context, userAccessToken, broadcasterID string) ([]CreatorGoal, error) {
+	if broadcasterID == "" {
+		return nil, fmt.Errorf("broadcasterID cannot be empty")
+	}
+
+	params := url.Values{}
+	params.Set("broadcaster_id", broadcasterID)
+
+	apiURL := fmt.Sprintf("%s/goals?%s", twitchAPIBaseURL, params.Encode())
+	var response struct {
+		Data []CreatorGoal `json:"data"`
+	}
+	if err := c.getJSON(ctx, userAccessToken, apiURL, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -17,6 +17,9 @@ type ChannelInfo struct {
 	StartedAt       string   `json:"started_at"`
 	TagIDs          []string `json:"tag_ids"`
 	IsMature        bool     `json:"is_mature"`
+
+	// BroadcasterLanguage is the channel's language as returned by Get Channel Information
+	BroadcasterLanguage string `json:"broadcaster_language"`
 }
 
 // ChannelResponse represents the response from the Twitch Get Channel endpoint
@@ -0,0 +1,136 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const twitchAuthorizeURL = "https://id.twitch.tv/oauth2/authorize"
+
+// defaultScopes are requested when TWITCH_SCOPES is not set
+var defaultScopes = []string{
+	"user:read:email",
+	"channel:read:subscriptions",
+	"moderator:read:followers",
+	"channel:read:redemptions",
+	"moderation:read",
+	"bits:read",
+	"channel:read:ads",
+	"channel:moderate",
+	"moderator:read:automod_settings",
+	"channel:read:goals",
+	"channel:manage:clips",
+}
+
+// OAuthToken represents the response from the Twitch token endpoint
+type OAuthToken struct {
+	AccessToken  string   `json:"access_token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    int      `json:"expires_in"`
+	Scope        []string `json:"scope"`
+	TokenType    string   `json:"token_type"`
+}
+
+// ExpiresAt returns the absolute expiry time of the token relative to now
+func (t *OAuthToken) ExpiresAt() time.Time {
+	return time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// RequiredScopes returns the scopes the application needs, read from the
+// space separated TWITCH_SCOPES environment variable
+func RequiredScopes() []string {
+	raw := strings.TrimSpace(os.Getenv("TWITCH_SCOPES"))
+	if raw == "" {
+		return append([]string(nil), defaultScopes...)
+	}
+	return strings.Fields(raw)
+}
+
+// MissingScopes returns the required scopes that are not present in granted
+func MissingScopes(required, granted []string) []string {
+	grantedSet := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		grantedSet[scope] = true
+	}
+
+	missing := []string{}
+	for _, scope := range required {
+		if !grantedSet[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// AuthorizeURL builds the Twitch authorization URL for the authorization code flow.
+// forceVerify makes Twitch show the consent screen again even if the user already
+// authorized the app, which is required to grant newly added scopes.
+func (c *Client) AuthorizeURL(redirectURI, state string, scopes []string, forceVerify bool) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.clientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(scopes, " "))
+	params.Set("state", state)
+	if forceVerify {
+		params.Set("force_verify", "true")
+	}
+
+	return twitchAuthorizeURL + "?" + params.Encode()
+}
+
+// ExchangeCode exchanges an authorization code for an access token
+func (c *Client) ExchangeCode(ctx context.Context, code, redirectURI string) (*OAuthToken, error) {
+	form := url.Values{}
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("code", code)
+	form.Set("grant_type", "authorization_code")
+	form.Set("redirect_uri", redirectURI)
+
+	return c.requestToken(ctx, form)
+}
+
+// RefreshToken exchanges a refresh token for a new access token
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	form := url.Values{}
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	return c.requestToken(ctx, form)
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*OAuthToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, twitchAuthURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var token OAuthToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,167 @@
+package twitch
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Re-record with:
+//
+//	TWITCH_CLIENT_ID=... TWITCH_TEST_ACCESS_TOKEN=... TWITCH_TEST_BROADCASTER_ID=... \
+//	  go test ./internal/twitch -run Recorded -twitch.record
+//
+// The token needs moderator:read:followers and channel:read:subscriptions for
+// the broadcaster. Their ID (and TWITCH_TEST_BROADCASTER_LOGIN, if set) is
+// replaced with the fixture channel before anything is written.
+var recordFixtures = flag.Bool("twitch.record", false, "re-record Twitch fixtures against the real API")
+
+const (
+	fixtureBroadcasterID    = "141981764"
+	fixtureBroadcasterLogin = "twitchdev"
+)
+
+// fixtureClient returns a client wired to the named cassette, the token to
+// call it with and the broadcaster to query. The bool reports whether
+// responses come from the fixture, so tests only pin exact values then.
+func fixtureClient(t *testing.T, name string) (*Client, string, string, bool) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "fixtures", name+".json")
+	clientID := os.Getenv("TWITCH_CLIENT_ID")
+	token := os.Getenv("TWITCH_TEST_ACCESS_TOKEN")
+	broadcasterID := os.Getenv("TWITCH_TEST_BROADCASTER_ID")
+
+	client, err := NewClient(clientID, "")
+	require.NoError(t, err)
+
+	if *recordFixtures {
+		if clientID == "" || token == "" || broadcasterID == "" {
+			t.Skip("-twitch.record needs TWITCH_CLIENT_ID, TWITCH_TEST_ACCESS_TOKEN and TWITCH_TEST_BROADCASTER_ID")
+		}
+
+		recorder, err := NewRecorder(path, ModeRecord)
+		require.NoError(t, err)
+		recorder.Redact = map[string]string{broadcasterID: fixtureBroadcasterID}
+		if login := os.Getenv("TWITCH_TEST_BROADCASTER_LOGIN"); login != "" {
+			recorder.Redact[login] = fixtureBroadcasterLogin
+		}
+		t.Cleanup(func() {
+			if !t.Failed() {
+				require.NoError(t, recorder.Save())
+			}
+		})
+
+		client.SetTransport(recorder)
+		return client, token, broadcasterID, false
+	}
+
+	recorder, err := NewRecorder(path, ModeReplay)
+	require.NoError(t, err)
+	client.SetTransport(recorder)
+
+	return client, "fixture-token", fixtureBroadcasterID, true
+}
+
+func TestRecordedVideos(t *testing.T) {
+	client, token, broadcasterID, replaying := fixtureClient(t, "videos")
+	ctx := context.Background()
+
+	firstPage, cursor, err := client.GetUserVideosPage(ctx, token, broadcasterID, "archive", 2, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, firstPage)
+	require.NotEmpty(t, cursor, "fixture channel needs more than one page of archives")
+
+	secondPage, _, err := client.GetUserVideosPage(ctx, token, broadcasterID, "archive", 2, cursor)
+	require.NoError(t, err)
+
+	for _, video := range append(firstPage, secondPage...) {
+		assert.Equal(t, "archive", video.Type)
+		assert.NotEmpty(t, video.Duration)
+	}
+
+	if replaying {
+		require.Len(t, firstPage, 2)
+		require.Len(t, secondPage, 1)
+		assert.Equal(t, "2201452511", firstPage[0].ID)
+		assert.Equal(t, 1863, firstPage[0].ViewCount)
+		assert.Equal(t, time.Date(2025, 6, 3, 17, 2, 11, 0, time.UTC), firstPage[0].CreatedAt.UTC())
+		require.Len(t, firstPage[1].MutedSegments, 1)
+		assert.Equal(t, 1200, firstPage[1].MutedSegments[0].Offset)
+		assert.Equal(t, "Extensions deep dive", secondPage[0].Title)
+	}
+}
+
+func TestRecordedFollowers(t *testing.T) {
+	client, token, broadcasterID, replaying := fixtureClient(t, "followers")
+	ctx := context.Background()
+
+	firstPage, err := client.GetChannelFollowers(ctx, token, broadcasterID, 2, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, firstPage.Pagination.Cursor, "fixture channel needs more than two followers")
+
+	secondPage, err := client.GetChannelFollowers(ctx, token, broadcasterID, 2, firstPage.Pagination.Cursor)
+	require.NoError(t, err)
+	assert.Equal(t, firstPage.Total, secondPage.Total)
+
+	if replaying {
+		assert.Equal(t, 3, firstPage.Total)
+		require.Len(t, firstPage.Data, 2)
+		assert.Equal(t, "userloginname", firstPage.Data[0].UserLogin)
+		assert.Equal(t, time.Date(2025, 6, 2, 20, 12, 2, 0, time.UTC), firstPage.Data[0].FollowedAt.UTC())
+		require.Len(t, secondPage.Data, 1)
+		assert.Equal(t, "33333", secondPage.Data[0].UserID)
+		assert.Empty(t, secondPage.Pagination.Cursor)
+	}
+}
+
+func TestRecordedSubscriptions(t *testing.T) {
+	client, token, broadcasterID, replaying := fixtureClient(t, "subscriptions")
+
+	subs, err := client.GetBroadcasterSubscribers(context.Background(), token, broadcasterID, 100, "")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, subs.Total, len(subs.Data))
+
+	if replaying {
+		assert.Equal(t, 3, subs.Total)
+		assert.Equal(t, 7, subs.Points)
+
+		tiers := map[string]int{}
+		gifted := 0
+		for _, sub := range subs.Data {
+			assert.Equal(t, fixtureBroadcasterID, sub.BroadcasterID)
+			tiers[sub.Tier]++
+			if sub.IsGift {
+				gifted++
+			}
+		}
+		assert.Equal(t, map[string]int{"1000": 2, "3000": 1}, tiers)
+		assert.Equal(t, 1, gifted)
+	}
+}
+
+func TestRecorderRejectsUnrecordedRequests(t *testing.T) {
+	recorder, err := NewRecorder(filepath.Join("testdata", "fixtures", "followers.json"), ModeReplay)
+	require.NoError(t, err)
+
+	client, err := NewClient("", "")
+	require.NoError(t, err)
+	client.SetTransport(recorder)
+
+	ctx := context.Background()
+	_, err = client.GetChannelFollowers(ctx, "fixture-token", "999", 2, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no recorded interaction")
+
+	// Each interaction is served once
+	_, err = client.GetChannelFollowers(ctx, "fixture-token", fixtureBroadcasterID, 2, "")
+	require.NoError(t, err)
+	_, err = client.GetChannelFollowers(ctx, "fixture-token", fixtureBroadcasterID, 2, "")
+	require.Error(t, err)
+}
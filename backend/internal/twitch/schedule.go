@@ -0,0 +1,83 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ScheduleSegment is one planned broadcast from a channel's stream schedule.
+// Recurring segments are returned once per occurrence, each with its own ID.
+type ScheduleSegment struct {
+	ID            string     `json:"id"`
+	StartTime     time.Time  `json:"start_time"`
+	EndTime       *time.Time `json:"end_time"`
+	Title         string     `json:"title"`
+	CanceledUntil *time.Time `json:"canceled_until"`
+	Category      *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"category"`
+	IsRecurring bool `json:"is_recurring"`
+}
+
+// StreamScheduleResponse represents the response from the Get Channel Stream
+// Schedule endpoint
+type StreamScheduleResponse struct {
+	Data struct {
+		Segments         []ScheduleSegment `json:"segments"`
+		BroadcasterID    string            `json:"broadcaster_id"`
+		BroadcasterLogin string            `json:"broadcaster_login"`
+		Vacation         *struct {
+			StartTime time.Time `json:"start_time"`
+			EndTime   time.Time `json:"end_time"`
+		} `json:"vacation"`
+	} `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+// GetChannelStreamSchedulePage fetches one page of the broadcaster's schedule
+// starting at startTime (now when zero). Channels without a schedule return an
+// empty response rather than an error.
+// Required scope: none
+// See: https://dev.twitch.tv/docs/api/reference/#get-channel-stream-schedule
+func (c *Client) GetChannelStreamSchedulePage(ctx context.Context, userAccessToken, broadcasterID string, startTime time.Time, limit int, afterCursor string) (*StreamScheduleResponse, error) {
+	if broadcasterID == "" {
+		return nil, fmt.Errorf("broadcasterID cannot be empty")
+	}
+
+	params := url.Values{}
+	params.Set("broadcaster_id", broadcasterID)
+
+	if !startTime.IsZero() {
+		params.Set("start_time", startTime.UTC().Format(time.RFC3339))
+	}
+
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 25 {
+		limit = 25 // Max limit per Twitch API
+	}
+	params.Set("first", strconv.Itoa(limit))
+
+	if afterCursor != "" {
+		params.Set("after", afterCursor)
+	}
+
+	apiURL := fmt.Sprintf("%s/schedule?%s", twitchAPIBaseURL, params.Encode())
+	var response StreamScheduleResponse
+	if err := c.getJSON(ctx, userAccessToken, apiURL, &response); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return &StreamScheduleResponse{}, nil
+		}
+		return nil, err
+	}
+	return &response, nil
+}
@@ -47,7 +47,7 @@ func (c *Client) GetBroadcasterSubscribers(ctx context.Context, userAccessToken,
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
 
 	// Execute request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
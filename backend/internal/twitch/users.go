@@ -0,0 +1,30 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// GetUsersByLogin looks up public users by login, e.g. with an app access
+// token. Unknown logins are left out of the result.
+// See: https://dev.twitch.tv/docs/api/reference/#get-users
+func (c *Client) GetUsersByLogin(ctx context.Context, accessToken string, logins []string) ([]User, error) {
+	if len(logins) == 0 {
+		return nil, nil
+	}
+	if len(logins) > 100 {
+		return nil, fmt.Errorf("at most 100 logins can be looked up at once, got %d", len(logins))
+	}
+
+	params := url.Values{}
+	for _, login := range logins {
+		params.Add("login", login)
+	}
+
+	var resp UsersResponse
+	if err := c.getJSON(ctx, accessToken, fmt.Sprintf("%s/users?%s", twitchAPIBaseURL, params.Encode()), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
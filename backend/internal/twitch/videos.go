@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 // GetUserVideos retrieves videos for a specific user
@@ -14,9 +16,33 @@ func (c *Client) GetUserVideos(ctx context.Context, userAccessToken string, user
 		limit = 20 // Default limit
 	}
 
-	url := fmt.Sprintf("%s/videos?user_id=%s&first=%d", twitchAPIBaseURL, userID, limit)
+	return c.GetUserVideosPage(ctx, userAccessToken, userID, "", limit, "")
+}
+
+// GetUserVideosPage retrieves one page of a user's videos, newest first.
+// videoType may be "all", "archive", "highlight" or "upload" (empty means all).
+// See: https://dev.twitch.tv/docs/api/reference/#get-videos
+func (c *Client) GetUserVideosPage(ctx context.Context, userAccessToken, userID, videoType string, limit int, afterCursor string) ([]VideoInfo, string, error) {
+	params := url.Values{}
+	params.Set("user_id", userID)
+
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100 // Max limit per Twitch API
+	}
+	params.Set("first", strconv.Itoa(limit))
+
+	if videoType != "" {
+		params.Set("type", videoType)
+	}
+	if afterCursor != "" {
+		params.Set("after", afterCursor)
+	}
+
+	apiURL := fmt.Sprintf("%s/videos?%s", twitchAPIBaseURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -30,7 +56,7 @@ func (c *Client) GetUserVideos(ctx context.Context, userAccessToken string, user
 	// Set the authorization header with the user's OAuth token
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -82,7 +108,7 @@ func (c *Client) GetVideosByID(ctx context.Context, userAccessToken string, vide
 	// Set the authorization header with the user's OAuth token
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
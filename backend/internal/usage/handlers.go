@@ -0,0 +1,89 @@
+package usage
+
+import (
+	"log"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	repo Repository
+}
+
+func NewHandlers(repo Repository) *Handlers {
+	return &Handlers{
+		repo: repo,
+	}
+}
+
+// RegisterRoutes registers usage routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	router.Get("/usage", h.GetUsage)
+}
+
+// Totals sums the daily usage of the window
+type Totals struct {
+	Requests    int64 `json:"requests"`
+	Failed      int64 `json:"failed"`
+	Collections int64 `json:"collections"`
+	Exports     int64 `json:"exports"`
+	ExportBytes int64 `json:"export_bytes"`
+}
+
+func sumDays(days []DailyUsage) Totals {
+	var totals Totals
+	for _, day := range days {
+		totals.Requests += day.Requests
+		totals.Failed += day.Failed
+		totals.Collections += day.Collections
+		totals.Exports += day.Exports
+		totals.ExportBytes += day.ExportBytes
+	}
+	return totals
+}
+
+// GetUsage returns the user's API usage of the last ?days= (default 30) by
+// day and endpoint. The latest requests show up once they are flushed,
+// within USAGE_FLUSH_INTERVAL.
+func (h *Handlers) GetUsage(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-query.Days)
+	daily, err := h.repo.GetDailyUsage(c.UserContext(), user.ID, since)
+	if err != nil {
+		log.Printf("Error getting daily API usage for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get API usage",
+		})
+	}
+	endpoints, err := h.repo.GetEndpointUsage(c.UserContext(), user.ID, since)
+	if err != nil {
+		log.Printf("Error getting endpoint API usage for user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get API usage",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"days":      query.Days,
+		"since":     since.Format(time.DateOnly),
+		"totals":    sumDays(daily),
+		"daily":     daily,
+		"endpoints": endpoints,
+	})
+}
@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxRowsPerInsert keeps each batched insert well under Postgres' parameter limit
+const maxRowsPerInsert = 500
+
+type Repository interface {
+	// AddCounts adds the counts to the stored ones in one transaction
+	AddCounts(ctx context.Context, counts map[Key]*Counts) error
+
+	GetDailyUsage(ctx context.Context, userID string, since time.Time) ([]DailyUsage, error)
+	GetEndpointUsage(ctx context.Context, userID string, since time.Time) ([]EndpointUsage, error)
+}
+
+// DailyUsage totals a user's requests on one day. Collections and exports
+// only count the ones that succeeded.
+type DailyUsage struct {
+	Day         time.Time `json:"day" db:"day"`
+	Requests    int64     `json:"requests" db:"requests"`
+	Failed      int64     `json:"failed" db:"failed"`
+	Collections int64     `json:"collections" db:"collections"`
+	Exports     int64     `json:"exports" db:"exports"`
+	ExportBytes int64     `json:"export_bytes" db:"export_bytes"`
+}
+
+// EndpointUsage totals a user's requests to one endpoint
+type EndpointUsage struct {
+	Endpoint      string `json:"endpoint" db:"endpoint"`
+	Kind          string `json:"kind" db:"kind"`
+	Requests      int64  `json:"requests" db:"requests"`
+	Failed        int64  `json:"failed" db:"failed"`
+	ResponseBytes int64  `json:"response_bytes" db:"response_bytes"`
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+func (r *repository) AddCounts(ctx context.Context, counts map[Key]*Counts) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const columns = 7
+	values := make([]string, 0, maxRowsPerInsert)
+	args := make([]any, 0, maxRowsPerInsert*columns)
+	insert := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+		query := `
+			INSERT INTO api_usage (user_id, day, endpoint, kind, requests, failed, response_bytes)
+			VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT (user_id, day, endpoint) DO UPDATE SET
+				requests = api_usage.requests + EXCLUDED.requests,
+				failed = api_usage.failed + EXCLUDED.failed,
+				response_bytes = api_usage.response_bytes + EXCLUDED.response_bytes
+		`
+		_, err := tx.ExecContext(ctx, query, args...)
+		values, args = values[:0], args[:0]
+		return err
+	}
+
+	for key, c := range counts {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+		args = append(args, key.UserID, key.Day, key.Endpoint, kindOf(key.Endpoint), c.Requests, c.Failed, c.ResponseBytes)
+		if len(values) == maxRowsPerInsert {
+			if err := insert(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := insert(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *repository) GetDailyUsage(ctx context.Context, userID string, since time.Time) ([]DailyUsage, error) {
+	query := `
+		SELECT day,
+			   SUM(requests) AS requests,
+			   SUM(failed) AS failed,
+			   COALESCE(SUM(requests - failed) FILTER (WHERE kind = 'collection'), 0) AS collections,
+			   COALESCE(SUM(requests - failed) FILTER (WHERE kind = 'export'), 0) AS exports,
+			   COALESCE(SUM(response_bytes) FILTER (WHERE kind = 'export'), 0) AS export_bytes
+		FROM api_usage
+		WHERE user_id = $1 AND day >= $2
+		GROUP BY day
+		ORDER BY day
+	`
+
+	days := []DailyUsage{}
+	err := r.db.SelectContext(ctx, &days, query, userID, since)
+	return days, err
+}
+
+func (r *repository) GetEndpointUsage(ctx context.Context, userID string, since time.Time) ([]EndpointUsage, error) {
+	query := `
+		SELECT endpoint, MIN(kind) AS kind, SUM(requests) AS requests, SUM(failed) AS failed,
+			   SUM(response_bytes) AS response_bytes
+		FROM api_usage
+		WHERE user_id = $1 AND day >= $2
+		GROUP BY endpoint
+		ORDER BY requests DESC, endpoint
+	`
+
+	endpoints := []EndpointUsage{}
+	err := r.db.SelectContext(ctx, &endpoints, query, userID, since)
+	return endpoints, err
+}
@@ -0,0 +1,183 @@
+// Package usage counts each user's API requests per endpoint and day, so
+// power users can see what they consume and billing can later charge for it.
+// Counts are kept in memory and written to the database in batches.
+package usage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Kinds of endpoint, summed separately on the usage page
+const (
+	KindRequest    = "request"
+	KindCollection = "collection"
+	KindExport     = "export"
+)
+
+// collectionRoutes trigger a data collection for the user
+var collectionRoutes = map[string]bool{
+	"POST /api/analytics/collect": true,
+	"POST /api/analytics/refresh": true,
+}
+
+// kindOf classifies an endpoint. Downloads of reports and media kits are exports.
+func kindOf(endpoint string) string {
+	switch {
+	case collectionRoutes[endpoint]:
+		return KindCollection
+	case strings.HasPrefix(endpoint, fiber.MethodGet+" ") && strings.HasSuffix(endpoint, "/download"):
+		return KindExport
+	}
+	return KindRequest
+}
+
+// Counts are the requests to one endpoint by one user on one day
+type Counts struct {
+	Requests      int64
+	Failed        int64
+	ResponseBytes int64
+}
+
+// Key identifies the counts of a user's endpoint on a day (UTC)
+type Key struct {
+	UserID   string
+	Day      time.Time
+	Endpoint string
+}
+
+// Recorder collects counts in memory until Run flushes them
+type Recorder struct {
+	repo Repository
+	cfg  config.UsageConfig
+
+	mu      sync.Mutex
+	pending map[Key]*Counts
+	full    chan struct{}
+}
+
+func NewRecorder(repo Repository, cfg config.UsageConfig) *Recorder {
+	return &Recorder{
+		repo:    repo,
+		cfg:     cfg,
+		pending: make(map[Key]*Counts),
+		full:    make(chan struct{}, 1),
+	}
+}
+
+// Record counts one request by userID to endpoint
+func (r *Recorder) Record(userID, endpoint string, status, responseBytes int) {
+	key := Key{UserID: userID, Day: time.Now().UTC().Truncate(24 * time.Hour), Endpoint: endpoint}
+
+	r.mu.Lock()
+	counts, ok := r.pending[key]
+	if !ok {
+		counts = &Counts{}
+		r.pending[key] = counts
+	}
+	counts.Requests++
+	if status >= fiber.StatusBadRequest {
+		counts.Failed++
+	}
+	if responseBytes > 0 {
+		counts.ResponseBytes += int64(responseBytes)
+	}
+	full := r.cfg.MaxPending > 0 && len(r.pending) >= r.cfg.MaxPending
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Middleware counts the authenticated requests to /api routes. It runs
+// before the routes' own authentication and reads the user they set.
+func (r *Recorder) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		route := c.Route().Path
+		user, userErr := clerk.GetUserFromContext(c)
+		if userErr != nil || !strings.HasPrefix(route, "/api/") {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		// Streamed downloads are counted by their length, their body isn't read
+		size := c.Response().Header.ContentLength()
+		if !c.Response().IsBodyStream() {
+			size = len(c.Response().Body())
+		}
+		r.Record(user.ID, c.Method()+" "+route, status, size)
+		return err
+	}
+}
+
+// Run flushes the counts every FlushInterval, or sooner once MaxPending are
+// waiting, until ctx is cancelled. The last counts are flushed on the way out.
+func (r *Recorder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			r.Flush(flushCtx)
+			return nil
+		case <-ticker.C:
+		case <-r.full:
+		}
+		r.Flush(ctx)
+	}
+}
+
+// Flush writes the pending counts. Counts that fail to be written are kept
+// for the next flush, unless that would hold more than MaxPending.
+func (r *Recorder) Flush(ctx context.Context) {
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = make(map[Key]*Counts)
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+	if err := r.repo.AddCounts(ctx, batch); err != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.cfg.MaxPending > 0 && len(r.pending)+len(batch) > r.cfg.MaxPending {
+			log.Printf("⚠️ Failed to write API usage of %d endpoints, dropping it: %v", len(batch), err)
+			return
+		}
+		log.Printf("⚠️ Failed to write API usage of %d endpoints, retrying with the next flush: %v", len(batch), err)
+		for key, counts := range batch {
+			if current, ok := r.pending[key]; ok {
+				current.Requests += counts.Requests
+				current.Failed += counts.Failed
+				current.ResponseBytes += counts.ResponseBytes
+			} else {
+				r.pending[key] = counts
+			}
+		}
+	}
+}
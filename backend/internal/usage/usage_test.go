@@ -0,0 +1,103 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	Repository
+	err     error
+	flushed []map[Key]*Counts
+}
+
+func (f *fakeRepository) AddCounts(_ context.Context, counts map[Key]*Counts) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.flushed = append(f.flushed, counts)
+	return nil
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, KindCollection, kindOf("POST /api/analytics/collect"))
+	assert.Equal(t, KindExport, kindOf("GET /api/reports/:id/runs/:runID/download"))
+	assert.Equal(t, KindExport, kindOf("GET /api/analytics/media-kit/:id/download"))
+	assert.Equal(t, KindRequest, kindOf("GET /api/analytics/overview"))
+}
+
+func TestMiddlewareCountsRoutes(t *testing.T) {
+	repo := &fakeRepository{}
+	recorder := NewRecorder(repo, config.UsageConfig{FlushInterval: time.Minute, MaxPending: 100})
+
+	app := fiber.New()
+	app.Use(recorder.Middleware())
+	authenticated := func(c *fiber.Ctx) error {
+		c.Locals("user", clerk.User{ID: "user_1"})
+		return c.Next()
+	}
+	app.Get("/api/videos/:id", authenticated, func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Video not found"})
+		}
+		return c.SendString("video")
+	})
+	app.Get("/api/public/stats", func(c *fiber.Ctx) error {
+		return c.SendString("stats")
+	})
+
+	for _, path := range []string{"/api/videos/1", "/api/videos/2", "/api/videos/missing", "/api/public/stats"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		_, err = app.Test(req)
+		require.NoError(t, err)
+	}
+
+	recorder.Flush(context.Background())
+	require.Len(t, repo.flushed, 1)
+	require.Len(t, repo.flushed[0], 1, "requests without a user aren't counted")
+	for key, counts := range repo.flushed[0] {
+		assert.Equal(t, "user_1", key.UserID)
+		assert.Equal(t, "GET /api/videos/:id", key.Endpoint)
+		assert.Equal(t, time.Now().UTC().Truncate(24*time.Hour), key.Day)
+		assert.Equal(t, int64(3), counts.Requests)
+		assert.Equal(t, int64(1), counts.Failed)
+		assert.Equal(t, int64(len("video")*2+len(`{"error":"Video not found"}`)), counts.ResponseBytes)
+	}
+}
+
+func TestFlushKeepsFailedCounts(t *testing.T) {
+	repo := &fakeRepository{err: errors.New("connection refused")}
+	recorder := NewRecorder(repo, config.UsageConfig{FlushInterval: time.Minute, MaxPending: 2})
+
+	recorder.Record("user_1", "GET /api/usage", fiber.StatusOK, 10)
+	recorder.Flush(context.Background())
+	recorder.Record("user_1", "GET /api/usage", fiber.StatusOK, 10)
+
+	repo.err = nil
+	recorder.Flush(context.Background())
+	require.Len(t, repo.flushed, 1)
+	for _, counts := range repo.flushed[0] {
+		assert.Equal(t, &Counts{Requests: 2, ResponseBytes: 20}, counts)
+	}
+
+	// Counts beyond MaxPending are dropped rather than kept forever
+	repo.err = errors.New("connection refused")
+	recorder.Record("user_1", "GET /api/usage", fiber.StatusOK, 10)
+	recorder.Record("user_2", "GET /api/usage", fiber.StatusOK, 10)
+	recorder.Flush(context.Background())
+	recorder.Record("user_3", "GET /api/usage", fiber.StatusOK, 10)
+	recorder.Flush(context.Background())
+	recorder.mu.Lock()
+	assert.Empty(t, recorder.pending)
+	recorder.mu.Unlock()
+}
@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrInvalidBody is returned by Body when the request body can't be parsed
+var ErrInvalidBody = errors.New("invalid request body")
+
+// Query fills dst, a pointer to a struct, from the query string and validates
+// it. Missing parameters take the field's `default` tag, or keep dst's value
+// without one. Values that don't parse are reported as Errors along with the
+// broken rules.
+func Query(c *fiber.Ctx, dst any) error {
+	value := reflect.ValueOf(dst).Elem()
+	var errs Errors
+	failed := make(map[string]bool)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		raw := c.Query(name)
+		if raw == "" {
+			raw = field.Tag.Get("default")
+		}
+		if raw == "" {
+			continue
+		}
+		if msg := setValue(value.Field(i), raw); msg != "" {
+			errs = append(errs, FieldError{Field: name, Message: msg})
+			failed[name] = true
+		}
+	}
+
+	var ruleErrs Errors
+	if errors.As(Struct(dst), &ruleErrs) {
+		for _, fieldErr := range ruleErrs {
+			if !failed[fieldErr.Field] {
+				errs = append(errs, fieldErr)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// setValue parses raw into v, returning why it couldn't
+func setValue(v reflect.Value, raw string) string {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if msg := setValue(elem.Elem(), raw); msg != "" {
+			return msg
+		}
+		v.Set(elem)
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "must be true or false"
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			panic(fmt.Sprintf("validate: unsupported query type %s", v.Type()))
+		}
+		var values []string
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		v.Set(reflect.ValueOf(values))
+	default:
+		panic(fmt.Sprintf("validate: unsupported query type %s", v.Type()))
+	}
+	return ""
+}
+
+// Body parses the request body into dst and validates it. An empty body
+// leaves dst as it is, for endpoints whose body is optional.
+func Body(c *fiber.Ctx, dst any) error {
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(dst); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBody, err)
+		}
+	}
+	return Struct(dst)
+}
+
+// Respond answers a request rejected by Query or Body: 422 with the
+// field-level details, or 400 for a body that didn't parse
+func Respond(c *fiber.Ctx, err error) error {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid request parameters",
+			"fields": errs,
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": "Invalid request body",
+	})
+}
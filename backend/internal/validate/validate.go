@@ -0,0 +1,173 @@
+// Package validate binds query strings and request bodies to structs and
+// checks them against `validate` struct tags, so handlers reject bad
+// parameters with field-level details instead of silently replacing them.
+//
+// Query fields name their parameter with a `query` tag and may set a
+// `default`. Rules are comma separated:
+//
+//	required     the value must be set
+//	min=N        numbers must be at least N, strings and slices that long
+//	max=N        numbers must be at most N, strings and slices at most that long
+//	oneof=a b c  the value must be one of the listed words
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError says why one parameter was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors lists every rejected parameter of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fieldErr := range e {
+		parts[i] = fieldErr.Field + " " + fieldErr.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// rules are the parsed `validate` tag of a field
+type rules struct {
+	required bool
+	min, max *float64
+	oneOf    []string
+}
+
+func parseRules(tag string) (rules, error) {
+	var r rules
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			r.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return r, fmt.Errorf("invalid %s rule %q", name, arg)
+			}
+			if name == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "oneof":
+			r.oneOf = strings.Fields(arg)
+		default:
+			return r, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return r, nil
+}
+
+// Struct checks the `validate` tags of dst, a pointer to a struct, and
+// returns Errors naming each field that broke a rule. Fields are named by
+// their query or json tag. A malformed tag is a programming error and panics.
+func Struct(dst any) error {
+	value := reflect.Indirect(reflect.ValueOf(dst))
+	var errs Errors
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+		r, err := parseRules(tag)
+		if err != nil {
+			panic(fmt.Sprintf("validate: field %s: %v", field.Name, err))
+		}
+		if msg := r.check(value.Field(i)); msg != "" {
+			errs = append(errs, FieldError{Field: fieldName(field), Message: msg})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// check returns why v breaks the rules, or "" if it doesn't. Unset optional
+// pointers pass.
+func (r rules) check(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if r.required {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+	if r.required && v.IsZero() {
+		return "is required"
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.checkRange(float64(v.Int()), "")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return r.checkRange(float64(v.Uint()), "")
+	case reflect.Float32, reflect.Float64:
+		return r.checkRange(v.Float(), "")
+	case reflect.String:
+		if len(r.oneOf) > 0 && v.String() != "" && !slices.Contains(r.oneOf, v.String()) {
+			return "must be one of " + strings.Join(r.oneOf, ", ")
+		}
+		return r.checkRange(float64(len([]rune(v.String()))), " characters")
+	case reflect.Slice:
+		if len(r.oneOf) > 0 && v.Type().Elem().Kind() == reflect.String {
+			for j := 0; j < v.Len(); j++ {
+				if !slices.Contains(r.oneOf, v.Index(j).String()) {
+					return "may only contain " + strings.Join(r.oneOf, ", ")
+				}
+			}
+		}
+		return r.checkRange(float64(v.Len()), " items")
+	}
+	return ""
+}
+
+func (r rules) checkRange(n float64, unit string) string {
+	switch {
+	case r.min != nil && r.max != nil && (n < *r.min || n > *r.max):
+		if unit != "" {
+			return fmt.Sprintf("must have between %s and %s%s", formatNumber(*r.min), formatNumber(*r.max), unit)
+		}
+		return fmt.Sprintf("must be between %s and %s", formatNumber(*r.min), formatNumber(*r.max))
+	case r.min != nil && n < *r.min:
+		if unit != "" {
+			return fmt.Sprintf("must have at least %s%s", formatNumber(*r.min), unit)
+		}
+		return "must be at least " + formatNumber(*r.min)
+	case r.max != nil && n > *r.max:
+		if unit != "" {
+			return fmt.Sprintf("must have at most %s%s", formatNumber(*r.max), unit)
+		}
+		return "must be at most " + formatNumber(*r.max)
+	}
+	return ""
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// fieldName is the name clients know a field by
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"query", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,137 @@
+package validate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listQuery struct {
+	Days   int      `query:"days" default:"30" validate:"min=1,max=365"`
+	Order  string   `query:"order" default:"desc" validate:"oneof=asc desc"`
+	Score  float64  `query:"min_score" validate:"min=0,max=100"`
+	Types  []string `query:"types" validate:"oneof=archive clip"`
+	Verify bool     `query:"verify"`
+	Search string   `query:"q"`
+}
+
+// query binds a listQuery from the query string through a Fiber app
+func query(t *testing.T, rawQuery string) (int, listQuery, Errors) {
+	t.Helper()
+
+	var bound listQuery
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if err := Query(c, &bound); err != nil {
+			return Respond(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(newRequest(t, http.MethodGet, "/?"+rawQuery, ""))
+	require.NoError(t, err)
+	var body struct {
+		Fields Errors `json:"fields"`
+	}
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if len(data) > 0 && resp.StatusCode != fiber.StatusOK {
+		require.NoError(t, json.Unmarshal(data, &body))
+	}
+	return resp.StatusCode, bound, body.Fields
+}
+
+func newRequest(t *testing.T, method, target, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+func TestQueryDefaults(t *testing.T) {
+	status, bound, _ := query(t, "")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, listQuery{Days: 30, Order: "desc"}, bound)
+
+	status, bound, _ = query(t, "days=7&order=asc&min_score=2.5&types=clip,archive&verify=true&q=speedrun")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, listQuery{
+		Days: 7, Order: "asc", Score: 2.5, Types: []string{"clip", "archive"}, Verify: true, Search: "speedrun",
+	}, bound)
+}
+
+func TestQueryRejectsInvalidValues(t *testing.T) {
+	status, _, fields := query(t, "days=400&order=up&min_score=x&types=clip,vod&verify=maybe")
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	assert.Equal(t, Errors{
+		{Field: "min_score", Message: "must be a number"},
+		{Field: "verify", Message: "must be true or false"},
+		{Field: "days", Message: "must be between 1 and 365"},
+		{Field: "order", Message: "must be one of asc, desc"},
+		{Field: "types", Message: "may only contain archive, clip"},
+	}, fields)
+
+	// A value that doesn't parse isn't also reported for its rules
+	_, _, fields = query(t, "days=abc")
+	assert.Equal(t, Errors{{Field: "days", Message: "must be an integer"}}, fields)
+}
+
+func TestStruct(t *testing.T) {
+	type request struct {
+		Name      string   `json:"name" validate:"required,max=5"`
+		MaxVideos *int     `json:"max_videos" validate:"min=1"`
+		Tags      []string `json:"tags" validate:"max=2"`
+	}
+
+	assert.NoError(t, Struct(&request{Name: "clips"}))
+
+	zero := 0
+	err := Struct(&request{Name: "highlights", MaxVideos: &zero, Tags: []string{"a", "b", "c"}})
+	assert.Equal(t, Errors{
+		{Field: "name", Message: "must have at most 5 characters"},
+		{Field: "max_videos", Message: "must be at least 1"},
+		{Field: "tags", Message: "must have at most 2 items"},
+	}, err)
+
+	err = Struct(&request{})
+	assert.Equal(t, Errors{{Field: "name", Message: "is required"}}, err)
+
+	assert.Panics(t, func() {
+		_ = Struct(&struct {
+			Days int `validate:"between=1"`
+		}{})
+	})
+}
+
+func TestBody(t *testing.T) {
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		var req struct {
+			MaxVideos *int `json:"max_videos" validate:"min=1"`
+		}
+		if err := Body(c, &req); err != nil {
+			return Respond(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for body, want := range map[string]int{
+		``:                  fiber.StatusOK,
+		`{"max_videos":10}`: fiber.StatusOK,
+		`{"max_videos":0}`:  fiber.StatusUnprocessableEntity,
+		`{"max_videos":`:    fiber.StatusBadRequest,
+	} {
+		resp, err := app.Test(newRequest(t, http.MethodPost, "/", body))
+		require.NoError(t, err)
+		assert.Equal(t, want, resp.StatusCode, body)
+	}
+}
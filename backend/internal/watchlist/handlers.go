@@ -0,0 +1,160 @@
+package watchlist
+
+import (
+	"errors"
+	"log"
+
+	"github.com/baldybuilds/creatorsync/internal/clerk"
+	"github.com/baldybuilds/creatorsync/internal/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handlers struct {
+	service *Service
+	repo    Repository
+}
+
+func NewHandlers(service *Service, repo Repository) *Handlers {
+	return &Handlers{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// RegisterRoutes registers watchlist routes on a Clerk-protected router
+func (h *Handlers) RegisterRoutes(router fiber.Router) {
+	watchlist := router.Group("/watchlist")
+	watchlist.Get("/", h.ListChannels)
+	watchlist.Post("/", h.AddChannel)
+	watchlist.Get("/compare", h.Compare)
+	watchlist.Delete("/:id", h.RemoveChannel)
+}
+
+// ListChannels returns the watched channels with their latest public stats
+func (h *Handlers) ListChannels(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	channels, err := h.service.List(c.UserContext(), user.ID)
+	if err != nil {
+		log.Printf("Error listing watchlist of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list watched channels",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"channels": channels,
+	})
+}
+
+// AddChannel puts the public Twitch channel {"login": "..."} on the watchlist
+func (h *Handlers) AddChannel(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req struct {
+		Login string `json:"login"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	channel, err := h.service.Add(c.UserContext(), user.ID, req.Login)
+	switch {
+	case errors.Is(err, ErrInvalidLogin):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "login must be a Twitch login",
+		})
+	case errors.Is(err, ErrChannelNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Twitch channel not found",
+		})
+	case errors.Is(err, ErrLimitReached):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Watchlist limit reached, remove a channel first",
+		})
+	case err != nil:
+		log.Printf("Error adding %q to the watchlist of user %s: %v", req.Login, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add channel",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"channel": channel,
+	})
+}
+
+// RemoveChannel takes a channel off the watchlist
+func (h *Handlers) RemoveChannel(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	entryID, err := c.ParamsInt("id")
+	if err != nil || entryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid watchlist entry ID",
+		})
+	}
+
+	found, err := h.repo.DeleteEntry(c.UserContext(), user.ID, entryID)
+	if err != nil {
+		log.Printf("Error removing watchlist entry %d of user %s: %v", entryID, user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove channel",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Watched channel not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Channel removed from the watchlist",
+		"id":      entryID,
+	})
+}
+
+// Compare returns follower charts of the user's channel and the watched
+// channels over the last ?days= (default 30)
+func (h *Handlers) Compare(c *fiber.Ctx) error {
+	user, err := clerk.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var query struct {
+		Days int `query:"days" default:"30" validate:"min=1,max=365"`
+	}
+	if err := validate.Query(c, &query); err != nil {
+		return validate.Respond(c, err)
+	}
+
+	comparison, err := h.service.Compare(c.UserContext(), user.ID, query.Days)
+	if err != nil {
+		log.Printf("Error comparing watchlist of user %s: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compare channels",
+		})
+	}
+
+	return c.JSON(comparison)
+}
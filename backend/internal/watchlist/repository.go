@@ -0,0 +1,161 @@
+package watchlist
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListChannels(ctx context.Context, userID string) ([]Channel, error)
+	CountChannels(ctx context.Context, userID string) (int, error)
+	SaveChannel(ctx context.Context, channel *Channel) error
+	AddEntry(ctx context.Context, userID, twitchID string) (*Channel, error)
+	DeleteEntry(ctx context.Context, userID string, entryID int) (bool, error)
+
+	ClaimDueChannels(ctx context.Context, collectedBefore time.Time, limit int) ([]dueChannel, error)
+	SaveStats(ctx context.Context, stats *Stats) error
+	GetStats(ctx context.Context, userID string, since time.Time) ([]Stats, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{
+		db: sqlx.NewDb(db, "postgres"),
+	}
+}
+
+const channelColumns = `e.id, c.twitch_id, c.login, c.display_name, c.profile_image_url, e.created_at AS added_at, c.collected_at`
+
+// ListChannels returns the channels the user watches, in the order they were added
+func (r *repository) ListChannels(ctx context.Context, userID string) ([]Channel, error) {
+	query := `SELECT ` + channelColumns + ` FROM watchlist_entries e
+		JOIN watched_channels c ON c.twitch_id = e.twitch_id
+		WHERE e.user_id = $1
+		ORDER BY e.created_at, e.id`
+
+	channels := []Channel{}
+	err := r.db.SelectContext(ctx, &channels, query, userID)
+	return channels, err
+}
+
+func (r *repository) CountChannels(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM watchlist_entries WHERE user_id = $1`, userID)
+	return count, err
+}
+
+// SaveChannel stores a channel looked up on Twitch, keeping when it was collected
+func (r *repository) SaveChannel(ctx context.Context, channel *Channel) error {
+	query := `
+		INSERT INTO watched_channels (twitch_id, login, display_name, profile_image_url)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (twitch_id)
+		DO UPDATE SET
+			login = EXCLUDED.login,
+			display_name = EXCLUDED.display_name,
+			profile_image_url = EXCLUDED.profile_image_url
+		RETURNING collected_at
+	`
+	return r.db.QueryRowxContext(ctx, query, channel.TwitchID, channel.Login, channel.DisplayName, channel.ProfileImageURL).
+		Scan(&channel.CollectedAt)
+}
+
+// AddEntry puts a saved channel on the user's watchlist. Adding a channel
+// twice returns the existing entry.
+func (r *repository) AddEntry(ctx context.Context, userID, twitchID string) (*Channel, error) {
+	insert := `
+		INSERT INTO watchlist_entries (user_id, twitch_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, twitch_id) DO NOTHING
+	`
+	if _, err := r.db.ExecContext(ctx, insert, userID, twitchID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + channelColumns + ` FROM watchlist_entries e
+		JOIN watched_channels c ON c.twitch_id = e.twitch_id
+		WHERE e.user_id = $1 AND e.twitch_id = $2`
+
+	var channel Channel
+	if err := r.db.GetContext(ctx, &channel, query, userID, twitchID); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// DeleteEntry takes a channel off the user's watchlist, returning false if
+// there is no such entry. The channel's stats stay for other watchers.
+func (r *repository) DeleteEntry(ctx context.Context, userID string, entryID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM watchlist_entries WHERE id = $1 AND user_id = $2`, entryID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimDueChannels marks up to limit watched channels not collected since
+// collectedBefore as collected and returns them, so replicas don't collect
+// the same channel. Channels nobody watches anymore aren't collected.
+func (r *repository) ClaimDueChannels(ctx context.Context, collectedBefore time.Time, limit int) ([]dueChannel, error) {
+	query := `
+		WITH due AS (
+			SELECT c.twitch_id
+			FROM watched_channels c
+			WHERE (c.collected_at IS NULL OR c.collected_at < $1)
+			  AND EXISTS (SELECT 1 FROM watchlist_entries e WHERE e.twitch_id = c.twitch_id)
+			ORDER BY c.collected_at NULLS FIRST
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE watched_channels c
+		SET collected_at = NOW()
+		FROM due
+		WHERE c.twitch_id = due.twitch_id
+		RETURNING c.twitch_id,
+			(SELECT MIN(e.user_id) FROM watchlist_entries e WHERE e.twitch_id = c.twitch_id) AS watcher_id
+	`
+
+	channels := []dueChannel{}
+	err := r.db.SelectContext(ctx, &channels, query, collectedBefore, limit)
+	return channels, err
+}
+
+// SaveStats stores a channel's stats, replacing those of the same day
+func (r *repository) SaveStats(ctx context.Context, stats *Stats) error {
+	query := `
+		INSERT INTO watched_channel_stats (twitch_id, date, followers_count, streams_last_30_days, hours_last_30_days, category)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (twitch_id, date)
+		DO UPDATE SET
+			followers_count = COALESCE(EXCLUDED.followers_count, watched_channel_stats.followers_count),
+			streams_last_30_days = EXCLUDED.streams_last_30_days,
+			hours_last_30_days = EXCLUDED.hours_last_30_days,
+			category = EXCLUDED.category
+	`
+	_, err := r.db.ExecContext(ctx, query, stats.TwitchID, stats.Date, stats.FollowersCount,
+		stats.StreamsLast30Days, stats.HoursLast30Days, stats.Category)
+	return err
+}
+
+// GetStats returns the stats since the given day of the channels the user
+// watches, newest first
+func (r *repository) GetStats(ctx context.Context, userID string, since time.Time) ([]Stats, error) {
+	query := `
+		SELECT s.twitch_id, s.date, s.followers_count, s.streams_last_30_days, s.hours_last_30_days, s.category
+		FROM watched_channel_stats s
+		JOIN watchlist_entries e ON e.twitch_id = s.twitch_id
+		WHERE e.user_id = $1 AND s.date >= $2
+		ORDER BY s.date DESC
+	`
+
+	stats := []Stats{}
+	err := r.db.SelectContext(ctx, &stats, query, userID, since)
+	return stats, err
+}
@@ -0,0 +1,280 @@
+package watchlist
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/analytics"
+	"github.com/baldybuilds/creatorsync/internal/config"
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+const (
+	// claimBatch is how many due channels the worker claims at once
+	claimBatch = 20
+	// vodsScanned is how many recent VODs stream frequency is counted from
+	vodsScanned = 100
+)
+
+// TwitchAPI is the part of the Twitch client watched channels are collected
+// with. Everything but the follower count works with the app access token.
+type TwitchAPI interface {
+	GetAppAccessToken(ctx context.Context) (*twitch.OAuthToken, error)
+	GetUsersByLogin(ctx context.Context, accessToken string, logins []string) ([]twitch.User, error)
+	GetChannelInfo(ctx context.Context, accessToken, broadcasterID string) (*twitch.ChannelInfo, error)
+	GetUserVideosPage(ctx context.Context, accessToken, userID, videoType string, limit int, afterCursor string) ([]twitch.VideoInfo, string, error)
+	GetChannelFollowers(ctx context.Context, userAccessToken, broadcasterID string, limit int, afterCursor string) (*twitch.FollowersResponse, error)
+}
+
+// UserTokens returns a user's Twitch token, see analytics.TwitchTokenHelper
+type UserTokens interface {
+	GetValidToken(ctx context.Context, userID string) (string, error)
+}
+
+// Service manages watchlists and collects the public stats of watched channels
+type Service struct {
+	repo          Repository
+	analyticsRepo analytics.Repository
+	twitch        TwitchAPI
+	tokens        UserTokens
+	cfg           config.WatchlistConfig
+	now           func() time.Time
+}
+
+func NewService(repo Repository, analyticsRepo analytics.Repository, twitchClient TwitchAPI, tokens UserTokens) *Service {
+	return &Service{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+		twitch:        twitchClient,
+		tokens:        tokens,
+		cfg:           config.Watchlist(),
+		now:           time.Now,
+	}
+}
+
+// Add looks up a channel by login and puts it on the user's watchlist. A
+// channel nobody watched before is collected right away.
+func (s *Service) Add(ctx context.Context, userID, login string) (*Channel, error) {
+	login = strings.ToLower(strings.TrimSpace(login))
+	if !loginPattern.MatchString(login) {
+		return nil, ErrInvalidLogin
+	}
+
+	count, err := s.repo.CountChannels(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count watched channels: %w", err)
+	}
+	if count >= s.cfg.MaxChannels {
+		return nil, ErrLimitReached
+	}
+
+	token, err := s.twitch.GetAppAccessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app access token: %w", err)
+	}
+	users, err := s.twitch.GetUsersByLogin(ctx, token.AccessToken, []string{login})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", login, err)
+	}
+	if len(users) == 0 {
+		return nil, ErrChannelNotFound
+	}
+
+	channel := &Channel{
+		TwitchID:        users[0].ID,
+		Login:           users[0].Login,
+		DisplayName:     users[0].DisplayName,
+		ProfileImageURL: users[0].ProfileImageURL,
+	}
+	if err := s.repo.SaveChannel(ctx, channel); err != nil {
+		return nil, fmt.Errorf("failed to save channel: %w", err)
+	}
+	neverCollected := channel.CollectedAt == nil
+
+	added, err := s.repo.AddEntry(ctx, userID, channel.TwitchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add channel: %w", err)
+	}
+
+	if neverCollected {
+		stats, err := s.collect(ctx, token.AccessToken, dueChannel{TwitchID: channel.TwitchID, WatcherID: userID})
+		if err != nil {
+			// The worker collects it on its next run
+			log.Printf("⚠️ Failed to collect watched channel %s: %v", login, err)
+		} else {
+			added.Latest = stats
+			added.TopCategory = stats.Category
+		}
+	}
+	return added, nil
+}
+
+// List returns the user's watched channels with their latest stats
+func (s *Service) List(ctx context.Context, userID string) ([]Channel, error) {
+	channels, err := s.repo.ListChannels(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watched channels: %w", err)
+	}
+	if len(channels) == 0 {
+		return channels, nil
+	}
+
+	stats, err := s.repo.GetStats(ctx, userID, s.today().AddDate(0, 0, -statsWindowDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watched channel stats: %w", err)
+	}
+	byChannel := make(map[string][]Stats)
+	for _, day := range stats {
+		byChannel[day.TwitchID] = append(byChannel[day.TwitchID], day)
+	}
+
+	for i := range channels {
+		days := byChannel[channels[i].TwitchID]
+		if len(days) > 0 {
+			channels[i].Latest = &days[0]
+		}
+		channels[i].TopCategory = topCategory(days)
+	}
+	return channels, nil
+}
+
+// Compare returns the followers of the user's channel and of the channels they
+// watch over the last days
+func (s *Service) Compare(ctx context.Context, userID string, days int) (*Comparison, error) {
+	channels, err := s.repo.ListChannels(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watched channels: %w", err)
+	}
+	stats, err := s.repo.GetStats(ctx, userID, s.today().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watched channel stats: %w", err)
+	}
+	own, err := s.analyticsRepo.GetChannelAnalytics(ctx, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel analytics: %w", err)
+	}
+	user, err := s.analyticsRepo.GetUserByClerkID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	comparison := &Comparison{Days: days, Series: make([]Series, 0, len(channels)+1)}
+
+	// Both are ordered newest first, the series oldest first
+	ownSeries := Series{Own: true, Points: []Point{}}
+	if user != nil {
+		ownSeries.Login, ownSeries.DisplayName = user.Username, user.DisplayName
+	}
+	for i := len(own) - 1; i >= 0; i-- {
+		ownSeries.Points = append(ownSeries.Points, Point{Date: own[i].Date, Followers: own[i].FollowersCount})
+	}
+	ownSeries.growth()
+	comparison.Series = append(comparison.Series, ownSeries)
+
+	byChannel := make(map[string][]Point)
+	for i := len(stats) - 1; i >= 0; i-- {
+		if stats[i].FollowersCount == nil {
+			continue
+		}
+		byChannel[stats[i].TwitchID] = append(byChannel[stats[i].TwitchID], Point{Date: stats[i].Date, Followers: *stats[i].FollowersCount})
+	}
+	for _, channel := range channels {
+		series := Series{Login: channel.Login, DisplayName: channel.DisplayName, Points: byChannel[channel.TwitchID]}
+		if series.Points == nil {
+			series.Points = []Point{}
+		}
+		series.growth()
+		comparison.Series = append(comparison.Series, series)
+	}
+	return comparison, nil
+}
+
+// Run collects due channels every PollInterval until ctx is done
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.collectDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectDue collects the channels not collected for CollectInterval
+func (s *Service) collectDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		channels, err := s.repo.ClaimDueChannels(ctx, s.now().Add(-s.cfg.CollectInterval), claimBatch)
+		if err != nil {
+			log.Printf("❌ Failed to claim watched channels: %v", err)
+			return
+		}
+		if len(channels) == 0 {
+			return
+		}
+
+		token, err := s.twitch.GetAppAccessToken(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to get app access token for the watchlist: %v", err)
+			return
+		}
+		for _, channel := range channels {
+			if _, err := s.collect(ctx, token.AccessToken, channel); err != nil {
+				log.Printf("⚠️ Failed to collect watched channel %s: %v", channel.TwitchID, err)
+			}
+		}
+	}
+}
+
+// collect saves today's public stats of a channel. The follower count is
+// left out when the watcher's token can't read it.
+func (s *Service) collect(ctx context.Context, appToken string, channel dueChannel) (*Stats, error) {
+	info, err := s.twitch.GetChannelInfo(ctx, appToken, channel.TwitchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel info: %w", err)
+	}
+	vods, _, err := s.twitch.GetUserVideosPage(ctx, appToken, channel.TwitchID, "archive", vodsScanned, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get VODs: %w", err)
+	}
+
+	today := s.today()
+	stats := &Stats{TwitchID: channel.TwitchID, Date: today, Category: info.GameName}
+	stats.StreamsLast30Days, stats.HoursLast30Days = streamActivity(vods, today.AddDate(0, 0, -statsWindowDays))
+	stats.FollowersCount = s.followers(ctx, channel)
+
+	if err := s.repo.SaveStats(ctx, stats); err != nil {
+		return nil, fmt.Errorf("failed to save stats: %w", err)
+	}
+	return stats, nil
+}
+
+// followers reads the channel's follower count with the watcher's token
+func (s *Service) followers(ctx context.Context, channel dueChannel) *int {
+	if s.tokens == nil || channel.WatcherID == "" {
+		return nil
+	}
+
+	token, err := s.tokens.GetValidToken(ctx, channel.WatcherID)
+	if err != nil {
+		log.Printf("⚠️ No Twitch token of user %s to count followers of %s: %v", channel.WatcherID, channel.TwitchID, err)
+		return nil
+	}
+	resp, err := s.twitch.GetChannelFollowers(ctx, token, channel.TwitchID, 1, "")
+	if err != nil {
+		log.Printf("⚠️ Failed to count followers of %s: %v", channel.TwitchID, err)
+		return nil
+	}
+	return &resp.Total
+}
+
+func (s *Service) today() time.Time {
+	return s.now().UTC().Truncate(24 * time.Hour)
+}
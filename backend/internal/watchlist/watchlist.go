@@ -0,0 +1,137 @@
+package watchlist
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+// statsWindowDays is the window stream frequency and the top category cover
+const statsWindowDays = 30
+
+var (
+	// ErrInvalidLogin means the login can't be a Twitch login
+	ErrInvalidLogin = errors.New("invalid Twitch login")
+	// ErrChannelNotFound means Twitch has no channel with the login
+	ErrChannelNotFound = errors.New("twitch channel not found")
+	// ErrLimitReached means the user watches as many channels as allowed
+	ErrLimitReached = errors.New("watchlist limit reached")
+)
+
+// loginPattern matches Twitch logins
+var loginPattern = regexp.MustCompile(`^[a-zA-Z0-9_]{3,25}$`)
+
+// Channel is a public Twitch channel on a user's watchlist
+type Channel struct {
+	ID              int        `json:"id" db:"id"`
+	TwitchID        string     `json:"twitch_id" db:"twitch_id"`
+	Login           string     `json:"login" db:"login"`
+	DisplayName     string     `json:"display_name" db:"display_name"`
+	ProfileImageURL string     `json:"profile_image_url" db:"profile_image_url"`
+	AddedAt         time.Time  `json:"added_at" db:"added_at"`
+	CollectedAt     *time.Time `json:"collected_at" db:"collected_at"`
+	// Latest are the last collected stats, nil before the first collection
+	Latest *Stats `json:"latest" db:"-"`
+	// TopCategory is the category the channel was seen in most often over the
+	// last statsWindowDays
+	TopCategory string `json:"top_category" db:"-"`
+}
+
+// Stats are a watched channel's public stats on a day
+type Stats struct {
+	TwitchID       string    `json:"-" db:"twitch_id"`
+	Date           time.Time `json:"date" db:"date"`
+	FollowersCount *int      `json:"followers_count" db:"followers_count"`
+	// StreamsLast30Days and HoursLast30Days are counted from the channel's
+	// VODs, so channels that don't keep VODs show none
+	StreamsLast30Days int     `json:"streams_last_30_days" db:"streams_last_30_days"`
+	HoursLast30Days   float64 `json:"hours_last_30_days" db:"hours_last_30_days"`
+	Category          string  `json:"category" db:"category"`
+}
+
+// dueChannel is a watched channel claimed for collection
+type dueChannel struct {
+	TwitchID string `db:"twitch_id"`
+	// WatcherID is a user watching the channel. Twitch only returns follower
+	// counts to user tokens, so theirs is used for it.
+	WatcherID string `db:"watcher_id"`
+}
+
+// Point is a channel's follower count on a day
+type Point struct {
+	Date      time.Time `json:"date"`
+	Followers int       `json:"followers"`
+}
+
+// Series is one channel's followers over the compared days
+type Series struct {
+	Login       string `json:"login"`
+	DisplayName string `json:"display_name"`
+	// Own marks the user's own channel
+	Own    bool    `json:"own"`
+	Points []Point `json:"points"`
+	// FollowersGained and GrowthPercent compare the first and last point, nil
+	// with fewer than two points
+	FollowersGained *int     `json:"followers_gained"`
+	GrowthPercent   *float64 `json:"growth_percent"`
+}
+
+// Comparison is returned by /api/watchlist/compare
+type Comparison struct {
+	Days   int      `json:"days"`
+	Series []Series `json:"series"`
+}
+
+// streamActivity counts the streams and hours of the VODs created since the
+// given time
+func streamActivity(vods []twitch.VideoInfo, since time.Time) (int, float64) {
+	streams := 0
+	var seconds float64
+	for _, vod := range vods {
+		if vod.CreatedAt.Before(since) {
+			continue
+		}
+		streams++
+		if duration, err := time.ParseDuration(vod.Duration); err == nil {
+			seconds += duration.Seconds()
+		}
+	}
+	return streams, math.Round(seconds/360) / 10
+}
+
+// topCategory returns the category seen on the most days, the latest of
+// them on a tie. stats are ordered newest first.
+func topCategory(stats []Stats) string {
+	days := make(map[string]int)
+	for _, day := range stats {
+		if day.Category != "" {
+			days[day.Category]++
+		}
+	}
+
+	top := ""
+	for _, day := range stats {
+		if days[day.Category] > days[top] {
+			top = day.Category
+		}
+	}
+	return top
+}
+
+// growth fills in the followers gained and the growth percent of a series
+func (s *Series) growth() {
+	if len(s.Points) < 2 {
+		return
+	}
+
+	first, last := s.Points[0].Followers, s.Points[len(s.Points)-1].Followers
+	gained := last - first
+	s.FollowersGained = &gained
+	if first > 0 {
+		percent := math.Round(float64(gained)/float64(first)*10000) / 100
+		s.GrowthPercent = &percent
+	}
+}
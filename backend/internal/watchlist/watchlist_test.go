@@ -0,0 +1,65 @@
+package watchlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/baldybuilds/creatorsync/internal/twitch"
+)
+
+func TestStreamActivity(t *testing.T) {
+	since := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	vods := []twitch.VideoInfo{
+		{CreatedAt: since.AddDate(0, 0, 20), Duration: "3h30m0s"},
+		{CreatedAt: since.AddDate(0, 0, 5), Duration: "1h5m"},
+		{CreatedAt: since.AddDate(0, 0, 1), Duration: "garbage"},
+		// Before the window
+		{CreatedAt: since.AddDate(0, 0, -1), Duration: "8h"},
+	}
+
+	streams, hours := streamActivity(vods, since)
+	assert.Equal(t, 3, streams)
+	assert.Equal(t, 4.6, hours)
+}
+
+func TestTopCategory(t *testing.T) {
+	// Newest first; Celeste and Tetris tie, Celeste was seen last
+	stats := []Stats{
+		{Category: "Celeste"}, {Category: ""}, {Category: "Tetris"},
+		{Category: "Tetris"}, {Category: "Celeste"}, {Category: "Just Chatting"},
+	}
+	assert.Equal(t, "Celeste", topCategory(stats))
+	assert.Equal(t, "Tetris", topCategory(stats[1:]))
+	assert.Empty(t, topCategory([]Stats{{Category: ""}}))
+	assert.Empty(t, topCategory(nil))
+}
+
+func TestSeriesGrowth(t *testing.T) {
+	day := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	series := Series{Points: []Point{{Date: day, Followers: 800}, {Date: day.AddDate(0, 0, 7), Followers: 1000}}}
+	series.growth()
+	require.NotNil(t, series.FollowersGained)
+	assert.Equal(t, 200, *series.FollowersGained)
+	require.NotNil(t, series.GrowthPercent)
+	assert.Equal(t, 25.0, *series.GrowthPercent)
+
+	// No percent from zero followers, nothing from a single point
+	series = Series{Points: []Point{{Followers: 0}, {Followers: 10}}}
+	series.growth()
+	assert.Equal(t, 10, *series.FollowersGained)
+	assert.Nil(t, series.GrowthPercent)
+
+	series = Series{Points: []Point{{Followers: 10}}}
+	series.growth()
+	assert.Nil(t, series.FollowersGained)
+}
+
+func TestLoginPattern(t *testing.T) {
+	assert.True(t, loginPattern.MatchString("some_streamer42"))
+	assert.False(t, loginPattern.MatchString("ab"))
+	assert.False(t, loginPattern.MatchString("not a login"))
+	assert.False(t, loginPattern.MatchString("https://twitch.tv/x"))
+}
@@ -0,0 +1,202 @@
+// Package youtube is a client for the parts of the YouTube Data API v3 used to
+// publish clips: Google OAuth, the connected channel and resumable uploads
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/baldybuilds/creatorsync/internal/oauthclient"
+)
+
+const (
+	apiBaseURL     = "https://www.googleapis.com/youtube/v3"
+	uploadURL      = "https://www.googleapis.com/upload/youtube/v3/videos"
+	gamingCategory = "20"
+)
+
+// Scopes are requested when connecting YouTube
+var Scopes = []string{
+	"https://www.googleapis.com/auth/youtube.upload",
+	"https://www.googleapis.com/auth/youtube.readonly",
+}
+
+// Privacy statuses of an uploaded video
+const (
+	PrivacyPrivate  = "private"
+	PrivacyUnlisted = "unlisted"
+	PrivacyPublic   = "public"
+)
+
+// OAuthToken is the response of the Google token endpoint. Refreshing returns
+// no new refresh token, the old one stays valid.
+type OAuthToken = oauthclient.Token
+
+// APIError is returned when Google responds with an unexpected status code
+type APIError = oauthclient.APIError
+
+// Client handles the Google OAuth flow and YouTube API calls. The call timeout
+// doesn't apply to sending the video itself.
+type Client struct {
+	*oauthclient.Client
+}
+
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{Client: oauthclient.NewClient(oauthclient.Provider{
+		Name:         "youtube",
+		AuthorizeURL: "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		Scopes:       Scopes,
+		// Offline access with a consent prompt makes Google return a refresh
+		// token on every connect
+		AuthorizeParams: url.Values{
+			"access_type": {"offline"},
+			"prompt":      {"consent"},
+		},
+	}, clientID, clientSecret)}
+}
+
+// Channel is the YouTube channel of the connected account
+type Channel struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	CustomURL string `json:"custom_url"`
+}
+
+type channelsResponse struct {
+	Items []struct {
+		ID      string `json:"id"`
+		Snippet struct {
+			Title     string `json:"title"`
+			CustomURL string `json:"customUrl"`
+		} `json:"snippet"`
+	} `json:"items"`
+}
+
+// GetChannel returns the channel of the access token's account, or nil when
+// the account has no channel yet
+func (c *Client) GetChannel(ctx context.Context, accessToken string) (*Channel, error) {
+	params := url.Values{}
+	params.Set("part", "snippet")
+	params.Set("mine", "true")
+
+	var resp channelsResponse
+	if err := c.GetJSON(ctx, accessToken, apiBaseURL+"/channels", params, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Items) == 0 {
+		return nil, nil
+	}
+	item := resp.Items[0]
+	return &Channel{ID: item.ID, Title: item.Snippet.Title, CustomURL: item.Snippet.CustomURL}, nil
+}
+
+// VideoMetadata describes an upload
+type VideoMetadata struct {
+	Title       string
+	Description string
+	Tags        []string
+	// Privacy is one of the Privacy* statuses
+	Privacy string
+}
+
+// Video is an uploaded video
+type Video struct {
+	ID string `json:"id"`
+}
+
+// URL is the video's watch page
+func (v *Video) URL() string {
+	return "https://www.youtube.com/watch?v=" + url.QueryEscape(v.ID)
+}
+
+type videoResource struct {
+	Snippet struct {
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags,omitempty"`
+		CategoryID  string   `json:"categoryId"`
+	} `json:"snippet"`
+	Status struct {
+		PrivacyStatus           string `json:"privacyStatus"`
+		SelfDeclaredMadeForKids bool   `json:"selfDeclaredMadeForKids"`
+	} `json:"status"`
+}
+
+// UploadVideo uploads an MP4 of size bytes with a resumable upload session.
+// Uploads are bounded by ctx only, not the call timeout. YouTube lists
+// vertical or square videos of up to three minutes as Shorts.
+func (c *Client) UploadVideo(ctx context.Context, accessToken string, metadata VideoMetadata, media io.Reader, size int64) (*Video, error) {
+	var resource videoResource
+	resource.Snippet.Title = metadata.Title
+	resource.Snippet.Description = metadata.Description
+	resource.Snippet.Tags = metadata.Tags
+	resource.Snippet.CategoryID = gamingCategory
+	resource.Status.PrivacyStatus = metadata.Privacy
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode video metadata: %w", err)
+	}
+
+	sessionURL, err := c.startUpload(ctx, accessToken, body, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start upload: %w", err)
+	}
+
+	// The media body can't be replayed, so the transport doesn't retry it
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURL, media)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "video/mp4")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.Send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var video Video
+	if err := json.NewDecoder(resp.Body).Decode(&video); err != nil {
+		return nil, fmt.Errorf("failed to decode uploaded video: %w", err)
+	}
+	return &video, nil
+}
+
+// startUpload creates a resumable upload session and returns its URL
+func (c *Client) startUpload(ctx context.Context, accessToken string, metadata []byte, size int64) (string, error) {
+	ctx, cancel := c.CallContext(ctx)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("uploadType", "resumable")
+	params.Set("part", "snippet,status")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL+"?"+params.Encode(), bytes.NewReader(metadata))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", "video/mp4")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+	resp, err := c.Send(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("upload session has no location")
+	}
+	return location, nil
+}
@@ -0,0 +1,92 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func response(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestUploadVideoUsesResumableSession(t *testing.T) {
+	client := NewClient("client", "secret")
+	var uploaded string
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.Method {
+		case http.MethodPost:
+			assert.Equal(t, "resumable", req.URL.Query().Get("uploadType"))
+			assert.Equal(t, "11", req.Header.Get("X-Upload-Content-Length"))
+
+			var resource videoResource
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&resource))
+			assert.Equal(t, "Big play #Shorts", resource.Snippet.Title)
+			assert.Equal(t, PrivacyUnlisted, resource.Status.PrivacyStatus)
+			return response(req, http.StatusOK, http.Header{"Location": {"https://upload.example/session/1"}}, ""), nil
+		case http.MethodPut:
+			assert.Equal(t, "https://upload.example/session/1", req.URL.String())
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+			body, _ := io.ReadAll(req.Body)
+			uploaded = string(body)
+			return response(req, http.StatusOK, nil, `{"id": "vid123"}`), nil
+		}
+		t.Fatalf("unexpected %s %s", req.Method, req.URL)
+		return nil, nil
+	}))
+
+	video, err := client.UploadVideo(context.Background(), "token", VideoMetadata{
+		Title:   "Big play #Shorts",
+		Privacy: PrivacyUnlisted,
+	}, strings.NewReader("mp4 content"), 11)
+	require.NoError(t, err)
+	assert.Equal(t, "vid123", video.ID)
+	assert.Equal(t, "https://www.youtube.com/watch?v=vid123", video.URL())
+	assert.Equal(t, "mp4 content", uploaded)
+}
+
+func TestUploadVideoKeepsErrorStatus(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(req, http.StatusForbidden, nil, `{"error":{"errors":[{"reason":"quotaExceeded"}]}}`), nil
+	}))
+
+	_, err := client.UploadVideo(context.Background(), "token", VideoMetadata{}, strings.NewReader(""), 0)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Body, "quotaExceeded")
+}
+
+func TestGetChannelWithoutChannel(t *testing.T) {
+	client := NewClient("client", "secret")
+	client.SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "true", req.URL.Query().Get("mine"))
+		return response(req, http.StatusOK, nil, `{"items": []}`), nil
+	}))
+
+	channel, err := client.GetChannel(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Nil(t, channel)
+}
@@ -0,0 +1,15 @@
+-- Migration: 002_create_user_twitch_tokens.sql
+-- Description: Store Twitch OAuth tokens and granted scopes per user
+
+CREATE TABLE IF NOT EXISTS user_twitch_tokens (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    twitch_user_id VARCHAR(255) NOT NULL,
+    access_token TEXT NOT NULL, -- encrypted
+    refresh_token TEXT, -- encrypted
+    scopes TEXT NOT NULL DEFAULT '', -- space separated, as returned by Twitch
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_twitch_tokens_twitch_user ON user_twitch_tokens(twitch_user_id);
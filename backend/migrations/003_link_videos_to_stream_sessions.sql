@@ -0,0 +1,6 @@
+-- Migration: 003_link_videos_to_stream_sessions.sql
+-- Description: Link archive VODs to the stream session they were recorded from
+
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS stream_session_id INTEGER REFERENCES stream_sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_video_analytics_stream_session ON video_analytics(stream_session_id);
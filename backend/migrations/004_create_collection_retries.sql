@@ -0,0 +1,17 @@
+-- Migration: 004_create_collection_retries.sql
+-- Description: Retry queue for failed per-user collections. Auth failures park the user until they reconnect Twitch
+
+CREATE TABLE IF NOT EXISTS collection_retries (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    job_type VARCHAR(50) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    failure_kind VARCHAR(20) NOT NULL, -- 'auth' or 'transient'
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    parked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, job_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_retries_due ON collection_retries(next_attempt_at) WHERE parked_at IS NULL;
@@ -0,0 +1,8 @@
+-- Migration: 005_add_analytics_job_progress.sql
+-- Description: Track progress of multi-user collection runs. Run-level jobs have no user_id
+
+ALTER TABLE analytics_jobs ADD COLUMN IF NOT EXISTS progress_total INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE analytics_jobs ADD COLUMN IF NOT EXISTS progress_completed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE analytics_jobs ADD COLUMN IF NOT EXISTS progress_failed INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_analytics_jobs_type_created ON analytics_jobs(job_type, created_at DESC) WHERE user_id IS NULL;
@@ -0,0 +1,68 @@
+-- Migration: 006_create_retention_tables.sql
+-- Description: Weekly/monthly rollups and cold archive tables for raw daily analytics past the retention window
+
+CREATE TABLE IF NOT EXISTS channel_analytics_rollups (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+    period VARCHAR(10) NOT NULL, -- 'week' or 'month'
+    period_start DATE NOT NULL,
+    first_date DATE NOT NULL,
+    last_date DATE NOT NULL,
+    days INTEGER NOT NULL DEFAULT 0,
+    followers_start INTEGER DEFAULT 0,
+    followers_end INTEGER DEFAULT 0,
+    followers_max INTEGER DEFAULT 0,
+    total_views_end INTEGER DEFAULT 0,
+    subscribers_end INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, period, period_start)
+);
+
+CREATE TABLE IF NOT EXISTS video_daily_stats_rollups (
+    id SERIAL PRIMARY KEY,
+    video_id VARCHAR(255) REFERENCES video_analytics(video_id) ON DELETE CASCADE,
+    period VARCHAR(10) NOT NULL, -- 'week' or 'month'
+    period_start DATE NOT NULL,
+    first_date DATE NOT NULL,
+    last_date DATE NOT NULL,
+    days INTEGER NOT NULL DEFAULT 0,
+    views_start INTEGER DEFAULT 0,
+    views_end INTEGER DEFAULT 0,
+    likes_end INTEGER DEFAULT 0,
+    comments_end INTEGER DEFAULT 0,
+    watch_time_minutes INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(video_id, period, period_start)
+);
+
+-- Cold storage for raw rows removed from the hot tables (no foreign keys so archives outlive their parents)
+CREATE TABLE IF NOT EXISTS channel_analytics_archive (
+    id INTEGER PRIMARY KEY,
+    user_id VARCHAR(255),
+    date DATE NOT NULL,
+    followers_count INTEGER,
+    following_count INTEGER,
+    total_views INTEGER,
+    subscriber_count INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS video_daily_stats_archive (
+    id INTEGER PRIMARY KEY,
+    video_id VARCHAR(255),
+    date DATE NOT NULL,
+    view_count INTEGER,
+    like_count INTEGER,
+    comment_count INTEGER,
+    watch_time_minutes INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_analytics_rollups_user ON channel_analytics_rollups(user_id, period, period_start DESC);
+CREATE INDEX IF NOT EXISTS idx_video_daily_stats_rollups_video ON video_daily_stats_rollups(video_id, period, period_start DESC);
+CREATE INDEX IF NOT EXISTS idx_channel_analytics_archive_user ON channel_analytics_archive(user_id, date);
+CREATE INDEX IF NOT EXISTS idx_video_daily_stats_archive_video ON video_daily_stats_archive(video_id, date);
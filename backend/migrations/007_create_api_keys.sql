@@ -0,0 +1,17 @@
+-- Migration: 007_create_api_keys.sql
+-- Description: Personal API keys for read-only access to analytics from overlays and spreadsheets
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL, -- first characters of the key, for display
+    key_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 of the full key
+    scopes TEXT NOT NULL DEFAULT 'analytics:read', -- space separated
+    rate_limit_per_minute INTEGER NOT NULL DEFAULT 60,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id, created_at DESC);
@@ -0,0 +1,14 @@
+-- Migration: 008_create_overlay_tokens.sql
+-- Description: Public share tokens for OBS browser-source overlays
+
+CREATE TABLE IF NOT EXISTS overlay_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE, -- kept in clear text so the overlay URL can be shown again
+    fields TEXT NOT NULL DEFAULT '', -- comma separated, empty means all
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_overlay_tokens_user ON overlay_tokens(user_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Migration: 009_create_public_profiles.sql
+-- Description: Opt-in public creator stats pages addressed by slug
+
+CREATE TABLE IF NOT EXISTS public_profiles (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    slug VARCHAR(32) NOT NULL UNIQUE,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,15 @@
+-- Migration: 010_create_media_kits.sql
+-- Description: Generated media kit PDFs, rendered asynchronously and downloaded by the creator
+
+CREATE TABLE IF NOT EXISTS media_kits (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, completed, failed
+    pdf BYTEA,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_kits_user ON media_kits(user_id, created_at DESC);
@@ -0,0 +1,29 @@
+-- Migration: 011_create_revenue_analytics.sql
+-- Description: Daily revenue estimates from subscriptions, bits and the ad schedule
+
+CREATE TABLE IF NOT EXISTS revenue_analytics (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    -- Subscription snapshot (estimated monthly revenue at current sub counts)
+    total_subscribers INTEGER NOT NULL DEFAULT 0,
+    tier1_subscribers INTEGER NOT NULL DEFAULT 0,
+    tier2_subscribers INTEGER NOT NULL DEFAULT 0,
+    tier3_subscribers INTEGER NOT NULL DEFAULT 0,
+    gifted_subscribers INTEGER NOT NULL DEFAULT 0,
+    sub_points INTEGER NOT NULL DEFAULT 0,
+    estimated_sub_revenue_cents INTEGER NOT NULL DEFAULT 0,
+    -- Bits cheered during the day (top cheerers on the leaderboard)
+    bits INTEGER NOT NULL DEFAULT 0,
+    estimated_bits_revenue_cents INTEGER NOT NULL DEFAULT 0,
+    -- Ad schedule snapshot, Twitch does not report ad revenue
+    ad_duration_seconds INTEGER NOT NULL DEFAULT 0,
+    ad_snooze_count INTEGER NOT NULL DEFAULT 0,
+    last_ad_at TIMESTAMP WITH TIME ZONE,
+    next_ad_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_revenue_analytics_user_date ON revenue_analytics(user_id, date DESC);
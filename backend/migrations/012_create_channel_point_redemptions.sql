@@ -0,0 +1,27 @@
+-- Migration: 012_create_channel_point_redemptions.sql
+-- Description: Channel points redemptions received through EventSub, and the
+-- EventSub subscriptions created per user
+
+CREATE TABLE IF NOT EXISTS channel_point_redemptions (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redemption_id VARCHAR(255) NOT NULL UNIQUE,
+    reward_id VARCHAR(255) NOT NULL,
+    reward_title VARCHAR(255) NOT NULL,
+    reward_cost INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'unfulfilled',
+    redeemed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_point_redemptions_user_date ON channel_point_redemptions(user_id, redeemed_at DESC);
+
+CREATE TABLE IF NOT EXISTS eventsub_subscriptions (
+    id VARCHAR(255) PRIMARY KEY, -- Twitch subscription ID
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(255) NOT NULL,
+    status VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, type)
+);
@@ -0,0 +1,25 @@
+-- Migration: 013_create_moderation_metrics.sql
+-- Description: Bans and timeouts received through EventSub plus a daily snapshot
+-- of active bans and the AutoMod level. Targets are not stored.
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id VARCHAR(255) NOT NULL UNIQUE, -- EventSub message ID, deduplicates redeliveries
+    action VARCHAR(20) NOT NULL, -- ban, timeout
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_user_date ON moderation_actions(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS moderation_daily_metrics (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    active_bans INTEGER NOT NULL DEFAULT 0,
+    automod_level INTEGER, -- NULL when AutoMod categories are set individually or unknown
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, date)
+);
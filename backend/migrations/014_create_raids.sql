@@ -0,0 +1,28 @@
+-- Migration: 014_create_raids.sql
+-- Description: Raids and follow timestamps received through EventSub, used for
+-- raid partner and follower conversion analytics
+
+CREATE TABLE IF NOT EXISTS raids (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id VARCHAR(255) NOT NULL,
+    direction VARCHAR(10) NOT NULL, -- incoming, outgoing
+    partner_id VARCHAR(255) NOT NULL,
+    partner_login VARCHAR(255) NOT NULL,
+    partner_name VARCHAR(255) NOT NULL,
+    viewers INTEGER NOT NULL DEFAULT 0,
+    raided_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(user_id, message_id) -- both sides of a raid between two users get a row
+);
+
+CREATE INDEX IF NOT EXISTS idx_raids_user_date ON raids(user_id, raided_at DESC);
+
+-- Only the time of each follow is kept, not who followed
+CREATE TABLE IF NOT EXISTS follow_events (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id VARCHAR(255) NOT NULL UNIQUE,
+    followed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_events_user_date ON follow_events(user_id, followed_at);
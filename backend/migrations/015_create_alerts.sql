@@ -0,0 +1,36 @@
+-- Migration: 015_create_alerts.sql
+-- Description: User-defined alert rules and the in-app alerts they generate
+-- after each collection
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL, -- follower_drop, views_spike, stream_missed, anomaly
+    metric VARCHAR(50) NOT NULL DEFAULT '', -- anomaly rules only
+    threshold DOUBLE PRECISION NOT NULL,
+    window_days INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    notify_email BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_user ON alert_rules(user_id);
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rule_id INTEGER REFERENCES alert_rules(id) ON DELETE SET NULL,
+    type VARCHAR(50) NOT NULL,
+    metric VARCHAR(50) NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    expected DOUBLE PRECISION NOT NULL DEFAULT 0,
+    dedupe_key VARCHAR(255) NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, dedupe_key) -- a rule fires at most once for the same data
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_user_date ON alerts(user_id, created_at DESC);
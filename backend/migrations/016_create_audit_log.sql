@@ -0,0 +1,19 @@
+-- Migration: 016_create_audit_log.sql
+-- Description: Append-only log of sensitive actions (token changes, exports,
+-- admin triggers) for security review
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id VARCHAR(255) NOT NULL, -- Clerk user ID, or "system"
+    action VARCHAR(100) NOT NULL,
+    target_id VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- No foreign key on actor_id so entries outlive deleted users
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, created_at DESC);
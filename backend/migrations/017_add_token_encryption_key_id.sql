@@ -0,0 +1,8 @@
+-- Migration: 017_add_token_encryption_key_id.sql
+-- Description: Record which encryption key each stored Twitch token uses so the
+-- key can be rotated. Existing tokens were encrypted with TWITCH_TOKEN_ENCRYPTION_KEY,
+-- whose key ID is "default".
+
+ALTER TABLE user_twitch_tokens ADD COLUMN IF NOT EXISTS encryption_key_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_user_twitch_tokens_key ON user_twitch_tokens(encryption_key_id);
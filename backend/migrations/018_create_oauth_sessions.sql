@@ -0,0 +1,13 @@
+-- Migration: 018_create_oauth_sessions.sql
+-- Description: Server-side state of in-progress OAuth flows, shared by all API
+-- replicas. Rows are consumed by the callback and expire after a few minutes.
+
+CREATE TABLE IF NOT EXISTS oauth_sessions (
+    state_hash VARCHAR(64) PRIMARY KEY, -- hex SHA-256 of the state parameter
+    user_id VARCHAR(255) NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '', -- space separated
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_sessions_expires ON oauth_sessions(expires_at);
@@ -0,0 +1,10 @@
+-- Migration: 019_add_video_library_indexes.sql
+-- Description: Indexes behind GET /api/analytics/videos sorting (views, duration),
+-- the video_type filter and case-insensitive title search (trigram index for ILIKE)
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_video_analytics_user_views ON video_analytics(user_id, view_count DESC);
+CREATE INDEX IF NOT EXISTS idx_video_analytics_user_duration ON video_analytics(user_id, duration_seconds DESC);
+CREATE INDEX IF NOT EXISTS idx_video_analytics_user_type_published ON video_analytics(user_id, video_type, published_at DESC);
+CREATE INDEX IF NOT EXISTS idx_video_analytics_title_trgm ON video_analytics USING GIN (title gin_trgm_ops);
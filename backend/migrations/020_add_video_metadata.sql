@@ -0,0 +1,7 @@
+-- Migration: 020_add_video_metadata.sql
+-- Description: Enrichment output for video titles (language, keywords, optional
+-- sentiment) written after each video collection
+
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_video_analytics_keywords ON video_analytics USING GIN ((metadata->'keywords'));
@@ -0,0 +1,16 @@
+-- Migration: 021_create_weekly_insights.sql
+-- Description: Natural-language insights generated once per week from a creator's
+-- growth and content metrics by the configured language model provider
+
+CREATE TABLE IF NOT EXISTS weekly_insights (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    week_start DATE NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    insights JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, week_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_weekly_insights_user ON weekly_insights(user_id, week_start DESC);
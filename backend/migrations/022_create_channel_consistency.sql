@@ -0,0 +1,14 @@
+-- Migration: 022_create_channel_consistency.sql
+-- Description: Streaming and upload consistency (weekly streak, streams per week,
+-- longest gap), recomputed after each video collection for the overview widget
+
+CREATE TABLE IF NOT EXISTS channel_consistency (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    current_streak_weeks INTEGER NOT NULL DEFAULT 0,
+    longest_streak_weeks INTEGER NOT NULL DEFAULT 0,
+    average_streams_per_week DOUBLE PRECISION NOT NULL DEFAULT 0,
+    average_uploads_per_week DOUBLE PRECISION NOT NULL DEFAULT 0,
+    longest_gap_days INTEGER NOT NULL DEFAULT 0,
+    last_stream_at TIMESTAMP WITH TIME ZONE,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
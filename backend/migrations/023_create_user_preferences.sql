@@ -0,0 +1,11 @@
+-- Migration: 023_create_user_preferences.sql
+-- Description: Per-user dashboard customization (widget layout, default date range,
+-- theme) saved by the frontend. updated_at doubles as the version for optimistic
+-- concurrency.
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    preferences JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,38 @@
+-- Migration: 024_create_reports.sql
+-- Description: Saved report definitions, run on demand or on a schedule after
+-- collection, and the generated output of each run
+
+CREATE TABLE IF NOT EXISTS reports (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    metrics JSONB NOT NULL DEFAULT '[]',
+    range_days INTEGER NOT NULL,
+    format VARCHAR(10) NOT NULL, -- json, csv
+    schedule VARCHAR(20) NOT NULL DEFAULT '', -- empty (on demand), daily, weekly, monthly
+    email BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_user ON reports(user_id);
+CREATE INDEX IF NOT EXISTS idx_reports_due ON reports(user_id, next_run_at) WHERE next_run_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS report_runs (
+    id SERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL, -- completed, failed
+    triggered_by VARCHAR(20) NOT NULL, -- manual, schedule
+    format VARCHAR(10) NOT NULL,
+    output BYTEA,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    storage_key TEXT NOT NULL DEFAULT '',
+    emailed BOOLEAN NOT NULL DEFAULT FALSE,
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_runs_report ON report_runs(report_id, created_at DESC);
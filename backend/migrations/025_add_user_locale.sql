@@ -0,0 +1,6 @@
+-- Migration: 025_add_user_locale.sql
+-- Description: Language the user wants emails, alerts and insights in. Empty means
+-- not chosen, in which case requests follow Accept-Language and background work
+-- uses English.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+-- Migration: 026_create_stream_schedule.sql
+-- Description: Planned streams from the creator's Twitch schedule, refreshed during
+-- daily collection. Past segments are kept to compare against the streams that happened.
+
+CREATE TABLE IF NOT EXISTS stream_schedule_segments (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    segment_id VARCHAR(255) NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    category_name VARCHAR(255) NOT NULL DEFAULT '',
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE,
+    is_recurring BOOLEAN NOT NULL DEFAULT FALSE,
+    canceled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, segment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_schedule_segments_user_start ON stream_schedule_segments(user_id, start_time);
@@ -0,0 +1,31 @@
+-- Migration: 027_create_creator_goals.sql
+-- Description: Twitch creator goals (followers, subs, bits) seen during daily
+-- collection, with a daily progress snapshot. Goals that stop being returned
+-- by Twitch are marked ended.
+
+CREATE TABLE IF NOT EXISTS creator_goals (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    goal_id VARCHAR(255) NOT NULL, -- Twitch goal ID
+    goal_type VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    current_amount INTEGER NOT NULL DEFAULT 0,
+    target_amount INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(user_id, goal_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_creator_goals_user_ended ON creator_goals(user_id, ended_at);
+
+CREATE TABLE IF NOT EXISTS creator_goal_snapshots (
+    id SERIAL PRIMARY KEY,
+    goal_id INTEGER NOT NULL REFERENCES creator_goals(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    current_amount INTEGER NOT NULL DEFAULT 0,
+    target_amount INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(goal_id, date)
+);
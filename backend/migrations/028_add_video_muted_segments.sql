@@ -0,0 +1,6 @@
+-- Migration: 028_add_video_muted_segments.sql
+-- Description: Muted segments Twitch reports for VODs and highlights (offset and
+-- duration in seconds), plus their total length for the muted VOD report
+
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS muted_segments JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS muted_seconds INTEGER NOT NULL DEFAULT 0;
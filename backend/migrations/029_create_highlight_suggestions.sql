@@ -0,0 +1,22 @@
+-- Migration: 029_create_highlight_suggestions.sql
+-- Description: Where clips sit on their source VOD, and the high-activity moments
+-- found by clustering them, recomputed after each video collection
+
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS source_video_id VARCHAR(255);
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS vod_offset_seconds INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_video_analytics_source_video ON video_analytics(source_video_id) WHERE source_video_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS highlight_suggestions (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id VARCHAR(255) NOT NULL, -- the VOD the moment is in
+    start_seconds INTEGER NOT NULL,
+    end_seconds INTEGER NOT NULL,
+    clip_count INTEGER NOT NULL,
+    total_clip_views INTEGER NOT NULL DEFAULT 0,
+    clips JSONB NOT NULL DEFAULT '[]'::jsonb,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_highlight_suggestions_user ON highlight_suggestions(user_id, clip_count DESC, total_clip_views DESC);
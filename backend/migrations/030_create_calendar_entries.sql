@@ -0,0 +1,21 @@
+-- Migration: 030_create_calendar_entries.sql
+-- Description: Content calendar of planned streams and uploads. After each
+-- collection planned entries are reconciled with the streams and videos that
+-- happened, and marked done or missed.
+
+CREATE TABLE IF NOT EXISTS calendar_entries (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- stream or upload
+    title VARCHAR(200) NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) NOT NULL DEFAULT 'planned', -- planned, done or missed
+    matched_id VARCHAR(255) NOT NULL DEFAULT '', -- stream ID or video ID once done
+    matched_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_entries_user_starts ON calendar_entries(user_id, starts_at);
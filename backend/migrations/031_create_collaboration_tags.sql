@@ -0,0 +1,14 @@
+-- Migration: 031_create_collaboration_tags.sql
+-- Description: Collaborators a user tagged on a stream or video. A tag row
+-- replaces the collaborators detected from the title ("ft.", "w/"), and an
+-- empty list marks the content as solo.
+
+CREATE TABLE IF NOT EXISTS collaboration_tags (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content_type VARCHAR(20) NOT NULL, -- stream or video
+    content_id VARCHAR(255) NOT NULL, -- stream_sessions.stream_id or video_analytics.video_id
+    collaborators JSONB NOT NULL DEFAULT '[]', -- lowercase Twitch logins
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, content_type, content_id)
+);
@@ -0,0 +1,7 @@
+-- Migration: 032_add_stream_activity.sql
+-- Description: Streams and hours streamed in the last 30 days for the overview
+-- tiles, from stream sessions plus VODs not linked to one, stored with the
+-- consistency figures after each video collection
+
+ALTER TABLE channel_consistency ADD COLUMN IF NOT EXISTS streams_last_30_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE channel_consistency ADD COLUMN IF NOT EXISTS hours_streamed_last_30 DOUBLE PRECISION NOT NULL DEFAULT 0;
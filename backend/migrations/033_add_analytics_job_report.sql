@@ -0,0 +1,6 @@
+-- Migration: 033_add_analytics_job_report.sql
+-- Description: Per-step report of user collections (what succeeded, what failed
+-- and how many items were saved). Jobs where only some steps failed get the
+-- status partial.
+
+ALTER TABLE analytics_jobs ADD COLUMN IF NOT EXISTS report JSONB;
@@ -0,0 +1,127 @@
+-- Migration: 034_row_level_security.sql
+-- Description: Row-level security on the per-user analytics tables. Each
+-- policy admits the rows of the user named by the app.user_id session
+-- setting, which the API sets per request when DB_ROW_LEVEL_SECURITY=true.
+-- Connections that never set it, such as background jobs, see every row.
+-- FORCE makes the policies apply to the table owner as well, superusers and
+-- BYPASSRLS roles are still exempt.
+
+ALTER TABLE channel_analytics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE channel_analytics FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON channel_analytics;
+CREATE POLICY user_isolation ON channel_analytics
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE stream_sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stream_sessions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON stream_sessions;
+CREATE POLICY user_isolation ON stream_sessions
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE video_analytics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE video_analytics FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON video_analytics;
+CREATE POLICY user_isolation ON video_analytics
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE game_analytics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE game_analytics FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON game_analytics;
+CREATE POLICY user_isolation ON game_analytics
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE social_analytics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE social_analytics FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON social_analytics;
+CREATE POLICY user_isolation ON social_analytics
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE analytics_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE analytics_jobs FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON analytics_jobs;
+CREATE POLICY user_isolation ON analytics_jobs
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE channel_analytics_rollups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE channel_analytics_rollups FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON channel_analytics_rollups;
+CREATE POLICY user_isolation ON channel_analytics_rollups
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE revenue_analytics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE revenue_analytics FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON revenue_analytics;
+CREATE POLICY user_isolation ON revenue_analytics
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE channel_point_redemptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE channel_point_redemptions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON channel_point_redemptions;
+CREATE POLICY user_isolation ON channel_point_redemptions
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE moderation_actions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE moderation_actions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON moderation_actions;
+CREATE POLICY user_isolation ON moderation_actions
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE moderation_daily_metrics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE moderation_daily_metrics FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON moderation_daily_metrics;
+CREATE POLICY user_isolation ON moderation_daily_metrics
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE raids ENABLE ROW LEVEL SECURITY;
+ALTER TABLE raids FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON raids;
+CREATE POLICY user_isolation ON raids
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE follow_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE follow_events FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON follow_events;
+CREATE POLICY user_isolation ON follow_events
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE weekly_insights ENABLE ROW LEVEL SECURITY;
+ALTER TABLE weekly_insights FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON weekly_insights;
+CREATE POLICY user_isolation ON weekly_insights
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE channel_consistency ENABLE ROW LEVEL SECURITY;
+ALTER TABLE channel_consistency FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON channel_consistency;
+CREATE POLICY user_isolation ON channel_consistency
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE highlight_suggestions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE highlight_suggestions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON highlight_suggestions;
+CREATE POLICY user_isolation ON highlight_suggestions
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+-- Daily video stats have no user_id and belong to the user of their video
+ALTER TABLE video_daily_stats ENABLE ROW LEVEL SECURITY;
+ALTER TABLE video_daily_stats FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON video_daily_stats;
+CREATE POLICY user_isolation ON video_daily_stats
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR video_id IN (SELECT video_id FROM video_analytics))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR video_id IN (SELECT video_id FROM video_analytics));
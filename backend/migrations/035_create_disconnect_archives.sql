@@ -0,0 +1,41 @@
+-- Migration: 035_create_disconnect_archives.sql
+-- Description: Twitch analytics set aside when a user disconnects Twitch. The
+-- rows are kept as JSON per source table until expires_at, so reconnecting the
+-- same Twitch account within the restore window can put them back.
+
+CREATE TABLE IF NOT EXISTS disconnect_archives (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    twitch_user_id VARCHAR(255) NOT NULL,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    restored_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_disconnect_archives_restorable
+    ON disconnect_archives (user_id, twitch_user_id, archived_at DESC) WHERE restored_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_disconnect_archives_expires_at ON disconnect_archives (expires_at);
+
+CREATE TABLE IF NOT EXISTS disconnect_archive_rows (
+    archive_id BIGINT NOT NULL REFERENCES disconnect_archives(id) ON DELETE CASCADE,
+    table_name VARCHAR(64) NOT NULL,
+    row_data JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_disconnect_archive_rows_archive ON disconnect_archive_rows (archive_id, table_name);
+
+-- Archives belong to their user like the analytics they hold, see 034
+ALTER TABLE disconnect_archives ENABLE ROW LEVEL SECURITY;
+ALTER TABLE disconnect_archives FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON disconnect_archives;
+CREATE POLICY user_isolation ON disconnect_archives
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE disconnect_archive_rows ENABLE ROW LEVEL SECURITY;
+ALTER TABLE disconnect_archive_rows FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON disconnect_archive_rows;
+CREATE POLICY user_isolation ON disconnect_archive_rows
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR archive_id IN (SELECT id FROM disconnect_archives))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR archive_id IN (SELECT id FROM disconnect_archives));
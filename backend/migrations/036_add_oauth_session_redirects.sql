@@ -0,0 +1,7 @@
+-- Migration: 036_add_oauth_session_redirects.sql
+-- Description: The Twitch callback and the frontend an OAuth flow started
+-- with, so preview deployments complete the flow where they started it. Empty
+-- means the configured default.
+
+ALTER TABLE oauth_sessions ADD COLUMN IF NOT EXISTS redirect_uri TEXT NOT NULL DEFAULT '';
+ALTER TABLE oauth_sessions ADD COLUMN IF NOT EXISTS frontend_url TEXT NOT NULL DEFAULT '';
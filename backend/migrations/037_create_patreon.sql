@@ -0,0 +1,46 @@
+-- Migration: 037_create_patreon.sql
+-- Description: Patreon connections and daily membership income snapshots, so
+-- revenue analytics include Patreon pledges next to Twitch subscriptions.
+-- OAuth sessions record which provider started them.
+
+CREATE TABLE IF NOT EXISTS user_patreon_tokens (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    patreon_user_id VARCHAR(255) NOT NULL,
+    campaign_id VARCHAR(255) NOT NULL DEFAULT '', -- empty until the user has a campaign
+    access_token TEXT NOT NULL, -- encrypted
+    refresh_token TEXT NOT NULL DEFAULT '', -- encrypted
+    encryption_key_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    scopes TEXT NOT NULL DEFAULT '', -- space separated, as returned by Patreon
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS patreon_revenue (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    campaign_id VARCHAR(255) NOT NULL,
+    patron_count INTEGER NOT NULL DEFAULT 0, -- as shown on the campaign page
+    active_members INTEGER NOT NULL DEFAULT 0, -- members currently paying
+    -- Active pledges per month in US cents, annual pledges count a twelfth
+    monthly_pledge_cents INTEGER NOT NULL DEFAULT 0,
+    -- What the creator keeps after Patreon's fees
+    estimated_income_cents INTEGER NOT NULL DEFAULT 0,
+    tiers JSONB NOT NULL DEFAULT '[]', -- active members and pledges per tier
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_patreon_revenue_user_date ON patreon_revenue(user_id, date DESC);
+
+-- Patreon income belongs to its user like the Twitch revenue, see 034
+ALTER TABLE patreon_revenue ENABLE ROW LEVEL SECURITY;
+ALTER TABLE patreon_revenue FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON patreon_revenue;
+CREATE POLICY user_isolation ON patreon_revenue
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE oauth_sessions ADD COLUMN IF NOT EXISTS provider VARCHAR(32) NOT NULL DEFAULT 'twitch';
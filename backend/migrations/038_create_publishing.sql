@@ -0,0 +1,62 @@
+-- Migration: 038_create_publishing.sql
+-- Description: YouTube connections, title and description templates, and the
+-- queue of Twitch clips being uploaded to YouTube.
+
+CREATE TABLE IF NOT EXISTS user_youtube_tokens (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    channel_id VARCHAR(255) NOT NULL,
+    channel_title VARCHAR(255) NOT NULL DEFAULT '',
+    access_token TEXT NOT NULL, -- encrypted
+    refresh_token TEXT NOT NULL DEFAULT '', -- encrypted
+    encryption_key_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    scopes TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS publish_templates (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    title_template TEXT NOT NULL,
+    description_template TEXT NOT NULL,
+    privacy VARCHAR(20) NOT NULL DEFAULT 'private', -- private, unlisted or public
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS publish_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    clip_id VARCHAR(255) NOT NULL,
+    target VARCHAR(32) NOT NULL DEFAULT 'youtube',
+    -- queued, downloading, uploading, completed or failed
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    title TEXT NOT NULL, -- rendered from the template when queued
+    description TEXT NOT NULL DEFAULT '',
+    privacy VARCHAR(20) NOT NULL DEFAULT 'private',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT NOT NULL DEFAULT '',
+    external_id VARCHAR(255) NOT NULL DEFAULT '', -- the YouTube video ID
+    external_url TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_publish_jobs_user_created ON publish_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_publish_jobs_queued ON publish_jobs(created_at) WHERE status = 'queued';
+
+-- Templates and jobs belong to their user like the analytics, see 034
+ALTER TABLE publish_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE publish_templates FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON publish_templates;
+CREATE POLICY user_isolation ON publish_templates
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE publish_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE publish_jobs FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON publish_jobs;
+CREATE POLICY user_isolation ON publish_jobs
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,33 @@
+-- Migration: 039_create_clip_renders.sql
+-- Description: Clips downloaded from Twitch, and optionally transcoded to
+-- vertical video, into object storage.
+
+CREATE TABLE IF NOT EXISTS clip_renders (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    clip_id VARCHAR(255) NOT NULL,
+    -- original, vertical_crop, vertical_blur or vertical_fit
+    preset VARCHAR(32) NOT NULL DEFAULT 'original',
+    -- queued, downloading, transcoding, storing, completed or failed
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT NOT NULL DEFAULT '',
+    object_key TEXT NOT NULL DEFAULT '', -- under the renders/ prefix of the export bucket
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_clip_renders_user_created ON clip_renders(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_clip_renders_user_clip ON clip_renders(user_id, clip_id, preset);
+CREATE INDEX IF NOT EXISTS idx_clip_renders_queued ON clip_renders(created_at) WHERE status = 'queued';
+
+-- Renders belong to their user like the analytics, see 034
+ALTER TABLE clip_renders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE clip_renders FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON clip_renders;
+CREATE POLICY user_isolation ON clip_renders
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,26 @@
+-- Migration: 040_create_content_templates.sql
+-- Description: Reusable title, description and tag templates for cross-posting,
+-- and the tags rendered onto publishing jobs.
+
+CREATE TABLE IF NOT EXISTS content_templates (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    title_template TEXT NOT NULL, -- may use {{game}}, {{stream_date}}, {{clip_title}} and more
+    description_template TEXT NOT NULL DEFAULT '',
+    tags JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_templates_user ON content_templates(user_id, name);
+
+ALTER TABLE publish_jobs ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+
+-- Templates belong to their user like the analytics, see 034
+ALTER TABLE content_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE content_templates FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON content_templates;
+CREATE POLICY user_isolation ON content_templates
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,24 @@
+-- Migration: 041_create_api_usage.sql
+-- Description: Daily per-user API request counts by endpoint, for the usage
+-- page and later billing.
+
+CREATE TABLE IF NOT EXISTS api_usage (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    endpoint VARCHAR(255) NOT NULL, -- method and route, e.g. GET /api/analytics/overview
+    kind VARCHAR(20) NOT NULL DEFAULT 'request', -- request, collection or export
+    requests BIGINT NOT NULL DEFAULT 0,
+    failed BIGINT NOT NULL DEFAULT 0, -- requests answered with an error status
+    response_bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, endpoint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_usage_user_day ON api_usage(user_id, day DESC);
+
+-- Usage belongs to its user like the analytics, see 034
+ALTER TABLE api_usage ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_usage FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON api_usage;
+CREATE POLICY user_isolation ON api_usage
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
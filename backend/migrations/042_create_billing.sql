@@ -0,0 +1,39 @@
+-- Migration: 042_create_billing.sql
+-- Description: Plans with their limits, and each user's Stripe subscription.
+
+CREATE TABLE IF NOT EXISTS plans (
+    id VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    max_platforms INTEGER, -- connected platforms including Twitch, NULL for all of them
+    history_days INTEGER, -- how far back analytics reach, NULL for all time
+    exports BOOLEAN NOT NULL DEFAULT false -- reports, media kits and their downloads
+);
+
+INSERT INTO plans (id, name, max_platforms, history_days, exports) VALUES
+    ('free', 'Free', 1, 30, false),
+    ('pro', 'Pro', NULL, NULL, true)
+ON CONFLICT (id) DO NOTHING;
+
+-- Users without a row are on the free plan
+CREATE TABLE IF NOT EXISTS subscriptions (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    plan_id VARCHAR(20) NOT NULL REFERENCES plans(id),
+    status VARCHAR(30) NOT NULL, -- Stripe's subscription status, e.g. active or canceled
+    stripe_customer_id VARCHAR(255) NOT NULL,
+    stripe_subscription_id VARCHAR(255),
+    current_period_end TIMESTAMP WITH TIME ZONE,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT false,
+    last_event_at TIMESTAMP WITH TIME ZONE NOT NULL, -- older webhook events are ignored
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_stripe_subscription ON subscriptions(stripe_subscription_id);
+
+-- Subscriptions belong to their user like the analytics, see 034
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON subscriptions;
+CREATE POLICY user_isolation ON subscriptions
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,17 @@
+-- Migration: 043_create_trials.sql
+-- Description: Plan trials, one per user, that apply until they end.
+
+CREATE TABLE IF NOT EXISTS trials (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    plan_id VARCHAR(20) NOT NULL REFERENCES plans(id),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Trials belong to their user like the analytics, see 034
+ALTER TABLE trials ENABLE ROW LEVEL SECURITY;
+ALTER TABLE trials FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON trials;
+CREATE POLICY user_isolation ON trials
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
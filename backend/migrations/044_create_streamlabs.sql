@@ -0,0 +1,37 @@
+-- Migration: 044_create_streamlabs.sql
+-- Description: Streamlabs connections and the tips received through them, so
+-- revenue analytics include tips next to subscriptions, bits and Patreon.
+
+CREATE TABLE IF NOT EXISTS user_streamlabs_tokens (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    streamlabs_user_id VARCHAR(255) NOT NULL,
+    access_token TEXT NOT NULL, -- encrypted
+    refresh_token TEXT NOT NULL DEFAULT '', -- encrypted
+    encryption_key_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    scopes TEXT NOT NULL DEFAULT '', -- space separated
+    expires_at TIMESTAMP WITH TIME ZONE, -- NULL when the token doesn't expire
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS streamlabs_donations (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    donation_id BIGINT NOT NULL, -- Streamlabs' ID, increasing over time
+    -- What the donor sent in US cents, converted by Streamlabs, before
+    -- payment processing fees
+    amount_cents INTEGER NOT NULL DEFAULT 0,
+    donated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, donation_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_streamlabs_donations_user_donated ON streamlabs_donations(user_id, donated_at DESC);
+
+-- Tips belong to their user like the rest of the revenue, see 034
+ALTER TABLE streamlabs_donations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE streamlabs_donations FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON streamlabs_donations;
+CREATE POLICY user_isolation ON streamlabs_donations
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,6 @@
+-- Migration: 045_add_collection_retry_options.sql
+-- Description: Collections interrupted by a shutdown are queued as retries of
+-- kind interrupted and run again after the restart. Options are the
+-- CollectionOptions of interrupted user collections.
+
+ALTER TABLE collection_retries ADD COLUMN IF NOT EXISTS options JSONB;
@@ -0,0 +1,19 @@
+-- Migration: 046_create_manual_collection_quotas.sql
+-- Description: How many manual collections (POST /collect and /refresh) each
+-- user ran today, for the daily quota. One row per user, reset on the first
+-- collection of a new day.
+
+CREATE TABLE IF NOT EXISTS manual_collection_quotas (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL, -- UTC
+    used INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Quotas belong to their user like the analytics, see 034
+ALTER TABLE manual_collection_quotas ENABLE ROW LEVEL SECURITY;
+ALTER TABLE manual_collection_quotas FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON manual_collection_quotas;
+CREATE POLICY user_isolation ON manual_collection_quotas
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,8 @@
+-- Migration: 047_add_analytics_job_changes.sql
+-- Description: What each collection changed compared with the data stored
+-- before it ran (new videos, follower change, videos gaining views), for
+-- /api/analytics/changes, change notifications and the weekly digest.
+
+ALTER TABLE analytics_jobs ADD COLUMN IF NOT EXISTS changes JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_analytics_jobs_user_changes ON analytics_jobs(user_id, created_at DESC) WHERE changes IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Migration: 048_add_content_languages.sql
+-- Description: Broadcast language of the channel and of each video and clip,
+-- for the language breakdown of /api/analytics/audience. Twitch doesn't expose
+-- where viewers are, so there's no region data to store.
+
+ALTER TABLE channel_analytics ADD COLUMN IF NOT EXISTS broadcaster_language VARCHAR(10);
+ALTER TABLE video_analytics ADD COLUMN IF NOT EXISTS language VARCHAR(10);
+
+CREATE INDEX IF NOT EXISTS idx_video_analytics_user_language ON video_analytics(user_id, language);
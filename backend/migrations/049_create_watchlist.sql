@@ -0,0 +1,42 @@
+-- Migration: 049_create_watchlist.sql
+-- Description: Public Twitch channels users watch to compare against their own
+-- growth. Channels and their daily stats are public data shared by everyone
+-- watching them; which user watches which channel is private.
+
+CREATE TABLE IF NOT EXISTS watched_channels (
+    twitch_id VARCHAR(64) PRIMARY KEY,
+    login VARCHAR(64) NOT NULL,
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    profile_image_url TEXT NOT NULL DEFAULT '',
+    -- when the stats were last collected, or claimed for collection
+    collected_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS watched_channel_stats (
+    twitch_id VARCHAR(64) NOT NULL REFERENCES watched_channels(twitch_id) ON DELETE CASCADE,
+    date DATE NOT NULL, -- UTC
+    followers_count INTEGER, -- NULL when no watching user's token could read it
+    streams_last_30_days INTEGER NOT NULL DEFAULT 0,
+    hours_last_30_days DECIMAL(7,1) NOT NULL DEFAULT 0,
+    category VARCHAR(255) NOT NULL DEFAULT '', -- the channel's category when collected
+    PRIMARY KEY (twitch_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS watchlist_entries (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    twitch_id VARCHAR(64) NOT NULL REFERENCES watched_channels(twitch_id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, twitch_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlist_entries_twitch ON watchlist_entries(twitch_id);
+
+-- Entries belong to their user like the analytics, see 034
+ALTER TABLE watchlist_entries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE watchlist_entries FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON watchlist_entries;
+CREATE POLICY user_isolation ON watchlist_entries
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
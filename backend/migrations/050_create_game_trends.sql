@@ -0,0 +1,23 @@
+-- Migration: 050_create_game_trends.sql
+-- Description: Samples of the top games on Twitch by viewers. Public data shared
+-- by every user, so there is no user_id and no row-level security.
+
+CREATE TABLE IF NOT EXISTS game_trend_samples (
+    id SERIAL PRIMARY KEY,
+    -- the start of the sampling slot; unique so only one replica samples it
+    sampled_at TIMESTAMP WITH TIME ZONE NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS game_trend_viewers (
+    sample_id INTEGER NOT NULL REFERENCES game_trend_samples(id) ON DELETE CASCADE,
+    game_id VARCHAR(64) NOT NULL,
+    game_name VARCHAR(255) NOT NULL,
+    box_art_url TEXT NOT NULL DEFAULT '',
+    rank INTEGER NOT NULL, -- 1 is the most watched game of the sample
+    viewers INTEGER NOT NULL, -- summed over the game's top streams, see TRENDS_STREAM_PAGES
+    streams INTEGER NOT NULL,
+    PRIMARY KEY (sample_id, game_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_trend_viewers_game ON game_trend_viewers(game_id, sample_id);
@@ -0,0 +1,42 @@
+-- Migration: 051_create_title_experiments.sql
+-- Description: Title A/B experiments. A user defines what the titles of each
+-- variant try, then assigns their VODs to variant A or B to compare how they
+-- perform.
+
+CREATE TABLE IF NOT EXISTS title_experiments (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    variant_a VARCHAR(255) NOT NULL DEFAULT '', -- e.g. "Question titles"
+    variant_b VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_title_experiments_user ON title_experiments(user_id);
+
+CREATE TABLE IF NOT EXISTS title_experiment_videos (
+    experiment_id INTEGER NOT NULL REFERENCES title_experiments(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id VARCHAR(255) NOT NULL, -- video_analytics.video_id
+    variant CHAR(1) NOT NULL CHECK (variant IN ('A', 'B')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (experiment_id, video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_title_experiment_videos_user ON title_experiment_videos(user_id);
+
+-- Experiments belong to their user like the analytics, see 034
+ALTER TABLE title_experiments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE title_experiments FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON title_experiments;
+CREATE POLICY user_isolation ON title_experiments
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
+
+ALTER TABLE title_experiment_videos ENABLE ROW LEVEL SECURITY;
+ALTER TABLE title_experiment_videos FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_isolation ON title_experiment_videos;
+CREATE POLICY user_isolation ON title_experiment_videos
+    USING (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true))
+    WITH CHECK (COALESCE(current_setting('app.user_id', true), '') = '' OR user_id = current_setting('app.user_id', true));
@@ -0,0 +1,7 @@
+-- Migration: 052_unpark_transient_collection_retries.sql
+-- Description: Only auth failures park a user's collection. Retries parked
+-- after running out of transient attempts are dropped so the daily collection
+-- picks those users up again.
+
+DELETE FROM collection_retries
+WHERE parked_at IS NOT NULL AND failure_kind <> 'auth';
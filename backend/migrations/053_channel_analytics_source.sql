@@ -0,0 +1,12 @@
+-- Migration: 053_channel_analytics_source.sql
+-- Description: Where each channel_analytics row came from. Follower backfills
+-- and CSV imports only know the follower count, their subscriber and view
+-- counts of 0 mean unknown and are left out of changes and charts.
+
+ALTER TABLE channel_analytics ADD COLUMN IF NOT EXISTS source VARCHAR(16) NOT NULL DEFAULT 'collected';
+
+-- Rows written before the column existed. Collected snapshots are written on
+-- their day, reconstructed ones for days long past.
+UPDATE channel_analytics SET source = 'backfill'
+WHERE source = 'collected' AND created_at > date + INTERVAL '2 days'
+AND subscriber_count = 0 AND total_views = 0;
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations, so binaries apply them
+// wherever they run from. Files are applied in name order (NNN_name.sql).
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"io/fs"
+	"regexp"
+	"testing"
+)
+
+var migrationName = regexp.MustCompile(`^(\d{3})_[a-z0-9_]+\.sql$`)
+
+func TestEmbeddedMigrationsAreNumberedInOrder(t *testing.T) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	seen := make(map[string]string)
+	for _, file := range files {
+		match := migrationName.FindStringSubmatch(file)
+		if match == nil {
+			t.Errorf("%s doesn't follow NNN_name.sql", file)
+			continue
+		}
+		if other, ok := seen[match[1]]; ok {
+			t.Errorf("%s and %s share number %s", other, file, match[1])
+		}
+		seen[match[1]] = file
+	}
+}
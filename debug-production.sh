@@ -23,6 +23,7 @@ echo "To test authenticated endpoints, run:"
 echo ""
 echo "export TOKEN=\"your_clerk_jwt_token_here\""
 echo "curl -H \"Authorization: Bearer \$TOKEN\" ${API_BASE}/api/analytics/debug/data-status"
+echo "curl -H \"Authorization: Bearer \$TOKEN\" ${API_BASE}/api/analytics/debug/diagnostics"
 echo "curl -H \"Authorization: Bearer \$TOKEN\" ${API_BASE}/api/analytics/enhanced?days=30"
 
 echo ""